@@ -21,12 +21,21 @@ import (
 	"pressluft/internal/controlplane/activity"
 	"pressluft/internal/controlplane/auth"
 	"pressluft/internal/controlplane/dispatch"
+	"pressluft/internal/controlplane/metrics"
+	"pressluft/internal/controlplane/notify"
 	"pressluft/internal/controlplane/server"
+	"pressluft/internal/controlplane/uptime"
+	"pressluft/internal/controlplane/vulnscan"
+	"pressluft/internal/controlplane/webhooks"
 	"pressluft/internal/infra/pki"
 	"pressluft/internal/infra/provider"
+	"pressluft/internal/infra/provider/fake"
 	"pressluft/internal/infra/registration"
+	"pressluft/internal/infra/runner"
 	"pressluft/internal/infra/runner/ansible"
+	"pressluft/internal/infra/runner/simulated"
 	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/orchestration/scheduler"
 	"pressluft/internal/orchestration/worker"
 	"pressluft/internal/platform"
 	"pressluft/internal/platform/database"
@@ -34,10 +43,17 @@ import (
 	"pressluft/internal/shared/security"
 	"pressluft/internal/shared/ws"
 
+	_ "pressluft/internal/infra/provider/custom"
+	_ "pressluft/internal/infra/provider/digitalocean"
 	_ "pressluft/internal/infra/provider/hetzner"
 )
 
-const defaultAddr = ":8080"
+const (
+	defaultAddr = ":8080"
+	// defaultFakeCloudDelay is how long simulated provider actions and
+	// playbook runs take unless PRESSLUFT_FAKE_CLOUD_DELAY says otherwise.
+	defaultFakeCloudDelay = 2 * time.Second
+)
 
 type playbookPaths struct {
 	basePath  string
@@ -64,6 +80,10 @@ func main() {
 	)
 	executionMode := runtimeConfig.ExecutionMode
 	logExecutionMode(logger, executionMode)
+	fakeCloud := fakeCloudEnabled()
+	if fakeCloud && !isDevBuild() {
+		log.Fatalf("PRESSLUFT_FAKE_CLOUD requires a dev build of the control plane")
+	}
 
 	allowGenerate := strings.TrimSpace(os.Getenv("PRESSLUFT_AGE_KEY_PATH")) == ""
 	generated, err := security.EnsureAgeKey(runtimeConfig.AgeKeyPath, allowGenerate)
@@ -85,6 +105,8 @@ func main() {
 	providerStore := provider.NewStore(db.DB)
 	siteStore := server.NewSiteStore(db.DB)
 	domainStore := server.NewDomainStore(db.DB)
+	backupStore := server.NewBackupStore(db.DB)
+	componentStore := server.NewComponentStore(db.DB)
 	activityStore := activity.NewStore(db.DB)
 	agentTokenStore := agentauth.NewStore(db.DB)
 	pkiStore := pki.NewStore(db.DB)
@@ -124,11 +146,15 @@ func main() {
 	}
 
 	ansibleBinary, err := resolveAnsibleBinary(runtimeConfig.AnsibleBinary, runtimeConfig.AnsibleDir)
-	if err != nil {
-		log.Fatalf("resolve ansible binary: %v", err)
+	if err == nil {
+		err = logAnsibleVersion(ansibleBinary, runtimeConfig.AnsibleDir, logger)
 	}
-	if err := logAnsibleVersion(ansibleBinary, runtimeConfig.AnsibleDir, logger); err != nil {
-		log.Fatalf("ansible preflight failed: %v", err)
+	if err != nil {
+		// The fake cloud simulates its playbooks, so it runs without ansible.
+		if !fakeCloud {
+			log.Fatalf("ansible preflight failed: %v", err)
+		}
+		logger.Warn("ansible unavailable; only simulated servers can be set up", "error", err)
 	}
 	playbooks := defaultPlaybookPaths()
 
@@ -140,13 +166,44 @@ func main() {
 		)
 	}
 
+	oidcConfig, err := auth.LoadOIDCConfig(controlPlaneURL)
+	if err != nil {
+		log.Fatalf("load oidc config: %v", err)
+	}
+	if oidcConfig != nil {
+		oidcProvider, err := auth.NewOIDCProvider(*oidcConfig, nil)
+		if err != nil {
+			log.Fatalf("configure oidc: %v", err)
+		}
+		authService.ConfigureOIDC(oidcProvider, auth.PasswordLoginDisabled())
+		logger.Info("single sign-on enabled", "issuer", oidcProvider.Issuer(), "password_login", authService.LoginMethods().Password)
+	} else if auth.PasswordLoginDisabled() {
+		log.Fatalf("PRESSLUFT_PASSWORD_LOGIN_DISABLED requires PRESSLUFT_OIDC_ISSUER")
+	}
+
 	ansibleRunner := ansible.NewAdapter(ansibleBinary, runtimeConfig.AnsibleDir, []string{
 		playbooks.configure,
 		playbooks.basePath + "/",
 	})
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var simulatedRunner runner.Runner
+	if fakeCloud {
+		delay, err := fakeCloudDelay()
+		if err != nil {
+			log.Fatalf("load fake cloud config: %v", err)
+		}
+		fakeProvider := fake.Register()
+		fakeProvider.ActionDelay = delay
+		simulatedRunner = simulated.New(ctx, playbooks.configure, delay, logger)
+		logger.Warn("fake cloud enabled", "provider", fakeProvider.Info().Type, "delay", delay, "note", "servers, playbooks and agents are simulated in memory")
+	}
+
 	hub := ws.NewHub()
 	agentRunner := dispatch.NewAgentRunner(hub, jobStore, logger)
+	vulnScanner := vulnscan.NewScanner(vulnscan.NewFileSource(runtimeConfig.VulnFeedPath), siteStore, componentStore, server.NewVulnerabilityStore(db.DB), activityStore, logger)
 	executor := worker.NewExecutor(
 		jobStore,
 		worker.NewServerStoreAdapter(serverStore),
@@ -163,14 +220,50 @@ func main() {
 			DevTokenStore:         agentTokenStore,
 			RegistrationStore:     registrationStore,
 			AgentRunner:           agentRunner,
+			BackupStore:           backupStore,
+			CostStore:             server.NewCostStore(db.DB),
+			SiteHealthProber:      agentRunner,
+			InventoryCollector:    agentRunner,
+			ComponentStore:        vulnScanner,
+			SimulatedRunner:       simulatedRunner,
 		},
 		logger,
 	)
-	w := worker.New(jobStore, executor, logger, worker.DefaultConfig())
+	workerConfig := worker.DefaultConfig()
+	workerConfig.MaxJobs = runtimeConfig.Worker.MaxJobs
+	workerConfig.MaxJobsPerProvider = runtimeConfig.Worker.MaxJobsPerProvider
+	workerConfig.MaxJobsPerServer = runtimeConfig.Worker.MaxJobsPerServer
+	w := worker.New(jobStore, executor, logger, workerConfig)
+
+	metricsToken, err := metrics.LoadToken()
+	if err != nil {
+		log.Fatalf("load metrics token: %v", err)
+	}
+	metricsRegistry := metrics.New(metrics.Sources{DB: db.DB, Hub: hub, WorkerSlots: w})
+	w.SetJobObserver(metricsRegistry)
+	hub.SetCommandObserver(metricsRegistry)
+	var metricsHandler http.Handler
+	if metricsToken != "" {
+		metricsHandler = metricsRegistry.Handler(metricsToken)
+		logger.Info("metrics endpoint enabled", "path", "/metrics")
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 	go w.Run(ctx)
+	jobScheduler := scheduler.New(server.NewScheduleStore(db.DB), jobStore, serverStore, activityStore, logger, scheduler.DefaultConfig())
+	go jobScheduler.Run(ctx)
+	webhookDispatcher := webhooks.NewDispatcher(webhooks.NewStore(db.DB), activityStore, nil, logger, webhooks.DefaultConfig())
+	go webhookDispatcher.Run(ctx)
+	smtpConfig, err := notify.LoadSMTPConfig()
+	if err != nil {
+		log.Fatalf("load smtp config: %v", err)
+	}
+	if smtpConfig != nil {
+		notifierConfig := notify.DefaultConfig()
+		notifierConfig.PublicURL = controlPlaneURL
+		notifier := notify.NewNotifier(notify.NewStore(db.DB), activityStore, notify.NewSMTPMailer(*smtpConfig), logger, notifierConfig)
+		go notifier.Run(ctx)
+		logger.Info("email notifications enabled", "smtp_host", smtpConfig.Host, "smtp_port", smtpConfig.Port)
+	}
 
 	resultWaiter := ws.NewResultWaiter()
 	hub.SetResultWaiter(resultWaiter)
@@ -180,14 +273,18 @@ func main() {
 	nodeHandler := server.NewNodeHandler(db.DB, pkiStore, registrationStore, ca, logger)
 
 	monitor := ws.NewMonitor(hub, serverStore, logger)
+	monitor.SetOfflineNotifier(dispatch.NewOfflineRecorder(serverStore, activityStore, logger))
 	go monitor.Start(ctx)
 	siteHealthMonitor := server.NewSiteHealthMonitor(siteStore, domainStore, activityStore, hub, logger)
 	go siteHealthMonitor.Start(ctx)
+	uptimeProber := uptime.NewProber(siteStore, domainStore, server.NewUptimeStore(db.DB), activityStore, logger, uptime.DefaultConfig())
+	go uptimeProber.Run(ctx)
+	go vulnScanner.Start(ctx)
 
 	operatorAuthenticator := operatorAuthenticatorForMode(executionMode, authService)
 	httpServer := &http.Server{
 		Addr:              resolveAddr(),
-		Handler:           server.WithRequestLogging(server.NewHandlerWithOptions(db.DB, hub, wsHTTPHandler, nodeHandler, server.HandlerOptions{Authenticator: operatorAuthenticator, AuthService: authService, IsDev: executionMode == platform.ExecutionModeDev, ControlPlaneURL: controlPlaneURL}), logger),
+		Handler:           server.WithRequestLogging(server.NewHandlerWithOptions(db.DB, hub, wsHTTPHandler, nodeHandler, server.HandlerOptions{Authenticator: operatorAuthenticator, AuthService: authService, IsDev: executionMode == platform.ExecutionModeDev, ControlPlaneURL: controlPlaneURL, VulnerabilityScanner: vulnScanner, JobCanceller: w, WorkerSlots: w, EmailConfigured: smtpConfig != nil, MetricsHandler: metricsHandler}), logger, metricsRegistry),
 		ReadHeaderTimeout: 5 * time.Second,
 		ReadTimeout:       15 * time.Second,
 		WriteTimeout:      2 * time.Minute,
@@ -236,7 +333,7 @@ func operatorAuthenticatorForMode(mode platform.ExecutionMode, authService *auth
 	if mode == platform.ExecutionModeDev {
 		return auth.NewDevAuthenticator()
 	}
-	return auth.NewSessionAuthenticator(authService)
+	return auth.NewTokenAuthenticator(authService, auth.NewSessionAuthenticator(authService))
 }
 
 func logExecutionMode(logger *slog.Logger, mode platform.ExecutionMode) {
@@ -249,12 +346,31 @@ func logExecutionMode(logger *slog.Logger, mode platform.ExecutionMode) {
 	case platform.ExecutionModeDev:
 		logger.Info("development transport enabled", "agent_trust", "dev websocket token", "server_tls", "not required")
 	case platform.ExecutionModeSingleNodeLocal:
-		logger.Warn("single-node local control plane mode is for local infrastructure work only", "agent_bootstrap", "disabled", "provider_support", "hetzner only")
+		logger.Warn("single-node local control plane mode is for local infrastructure work only", "agent_bootstrap", "disabled", "provider_support", "hetzner, digitalocean and custom")
 	case platform.ExecutionModeProductionBootstrap:
 		logger.Info("production bootstrap path enabled", "server_tls", "required in-process", "agent_transport", "wss plus mTLS")
 	}
 }
 
+// fakeCloudEnabled reports whether PRESSLUFT_FAKE_CLOUD asks for the
+// simulated provider, playbook runner and agents.
+func fakeCloudEnabled() bool {
+	raw := strings.TrimSpace(os.Getenv("PRESSLUFT_FAKE_CLOUD"))
+	return raw == "1" || strings.EqualFold(raw, "true")
+}
+
+func fakeCloudDelay() (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv("PRESSLUFT_FAKE_CLOUD_DELAY"))
+	if raw == "" {
+		return defaultFakeCloudDelay, nil
+	}
+	delay, err := time.ParseDuration(raw)
+	if err != nil || delay < 0 {
+		return 0, fmt.Errorf("PRESSLUFT_FAKE_CLOUD_DELAY must be a non-negative duration, got %q", raw)
+	}
+	return delay, nil
+}
+
 func resolveAddr() string {
 	port := strings.TrimSpace(os.Getenv("PORT"))
 	if port == "" {
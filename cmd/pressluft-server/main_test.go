@@ -78,3 +78,20 @@ func TestResolveAnsibleBinary(t *testing.T) {
 		}
 	})
 }
+
+func TestFakeCloudDelay(t *testing.T) {
+	t.Setenv("PRESSLUFT_FAKE_CLOUD_DELAY", "")
+	if got, err := fakeCloudDelay(); err != nil || got != defaultFakeCloudDelay {
+		t.Fatalf("fakeCloudDelay() = %v, %v, want the default", got, err)
+	}
+
+	t.Setenv("PRESSLUFT_FAKE_CLOUD_DELAY", "0s")
+	if got, err := fakeCloudDelay(); err != nil || got != 0 {
+		t.Fatalf("fakeCloudDelay() = %v, %v, want 0", got, err)
+	}
+
+	t.Setenv("PRESSLUFT_FAKE_CLOUD_DELAY", "-1s")
+	if _, err := fakeCloudDelay(); err == nil {
+		t.Fatal("expected a negative delay to fail")
+	}
+}
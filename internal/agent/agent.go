@@ -116,46 +116,64 @@ func (a *Agent) handleMessage(ctx context.Context, env ws.Envelope) {
 			a.logger.Error("command decode failed", "server_id", a.config.ServerID, "error", err)
 			return
 		}
-		serverID := a.config.ServerID
-		if cmd.ServerID == "" {
-			cmd.ServerID = serverID
-		}
-		corr := observability.Correlation{ServerID: serverID, CommandID: cmd.ID}
-		a.logger.Info("command execution started", corr.LogArgs("command_type", cmd.Type)...)
-
-		result := a.executor.Execute(ctx, cmd)
-		if result.JobID == "" {
-			result.JobID = cmd.JobID
-		}
-		if result.ServerID == "" {
-			result.ServerID = cmd.ServerID
-		}
-		payload, err := json.Marshal(result)
-		if err != nil {
-			a.logger.Error("command result encode failed", corr.LogArgs("error", err)...)
-			return
-		}
-
-		resultEnv := ws.Envelope{
-			Type:    ws.TypeCommandResult,
-			Payload: payload,
-		}
-
-		message, err := json.Marshal(resultEnv)
-		if err != nil {
-			a.logger.Error("command result envelope encode failed", corr.LogArgs("error", err)...)
+		// Commands run concurrently so the read loop keeps receiving cancel
+		// requests while a long command is in flight.
+		go a.runCommand(ctx, cmd)
+	case ws.TypeCommandCancel:
+		var cancel ws.CommandCancel
+		if err := json.Unmarshal(env.Payload, &cancel); err != nil {
+			a.logger.Error("command cancel decode failed", "server_id", a.config.ServerID, "error", err)
 			return
 		}
-		if err := a.conn.Write(ctx, websocket.MessageText, message); err != nil && !errors.Is(err, context.Canceled) {
-			a.logger.Debug("command result send failed", corr.LogArgs("error", err)...)
-			return
+		corr := observability.Correlation{ServerID: a.config.ServerID, CommandID: cancel.CommandID}
+		if a.executor.Cancel(cancel.CommandID) {
+			a.logger.Info("command cancellation requested", corr.LogArgs()...)
+		} else {
+			a.logger.Debug("command cancellation ignored", corr.LogArgs("reason", "command not running")...)
 		}
-		a.logger.Info("command execution finished", observability.Correlation{ServerID: serverID, CommandID: result.CommandID}.LogArgs("success", result.Success, "error_code", result.ErrorCode)...)
 	case ws.TypeHeartbeatAck:
 		return
 	}
 }
 
+func (a *Agent) runCommand(ctx context.Context, cmd ws.Command) {
+	serverID := a.config.ServerID
+	if cmd.ServerID == "" {
+		cmd.ServerID = serverID
+	}
+	corr := observability.Correlation{ServerID: serverID, CommandID: cmd.ID}
+	a.logger.Info("command execution started", corr.LogArgs("command_type", cmd.Type)...)
+
+	result := a.executor.Execute(ctx, cmd)
+	if result.JobID == "" {
+		result.JobID = cmd.JobID
+	}
+	if result.ServerID == "" {
+		result.ServerID = cmd.ServerID
+	}
+	payload, err := json.Marshal(result)
+	if err != nil {
+		a.logger.Error("command result encode failed", corr.LogArgs("error", err)...)
+		return
+	}
+
+	resultEnv := ws.Envelope{
+		Type:    ws.TypeCommandResult,
+		Payload: payload,
+	}
+
+	message, err := json.Marshal(resultEnv)
+	if err != nil {
+		a.logger.Error("command result envelope encode failed", corr.LogArgs("error", err)...)
+		return
+	}
+	if err := a.conn.Write(ctx, websocket.MessageText, message); err != nil && !errors.Is(err, context.Canceled) {
+		a.logger.Debug("command result send failed", corr.LogArgs("error", err)...)
+		return
+	}
+	a.logger.Info("command execution finished", observability.Correlation{ServerID: serverID, CommandID: result.CommandID}.LogArgs("success", result.Success, "error_code", result.ErrorCode)...)
+}
+
 func retryDelay(attempt int) time.Duration {
 	if attempt < 1 {
 		attempt = 1
@@ -13,6 +13,7 @@ const (
 	TypeRestartService = "restart_service"
 	TypeListServices   = "list_services"
 	TypeSiteHealth     = "site_health_snapshot"
+	TypeWPInventory    = "wp_inventory"
 
 	ErrorCodeUnknownCommand      = "unknown_command"
 	ErrorCodeInvalidPayload      = "invalid_payload"
@@ -20,6 +21,7 @@ const (
 	ErrorCodeServiceNotAllowed   = "service_not_allowed"
 	ErrorCodeExecutionFailed     = "execution_failed"
 	ErrorCodeCommandTimedOut     = "command_timed_out"
+	ErrorCodeCommandCancelled    = "command_cancelled"
 	ErrorCodeSerializationFailed = "serialization_failed"
 )
 
@@ -60,6 +62,9 @@ type SiteHealthSnapshotParams struct {
 	SiteID   string `json:"site_id"`
 	Hostname string `json:"hostname"`
 	SitePath string `json:"site_path"`
+	// Paths are extra URL paths to fetch and report in Pages, e.g. to compare
+	// a site before and after a change.
+	Paths []string `json:"paths,omitempty"`
 }
 
 type SiteHealthCheck struct {
@@ -77,10 +82,52 @@ type SiteHealthSnapshot struct {
 	Services     []Service         `json:"services,omitempty"`
 	Checks       []SiteHealthCheck `json:"checks,omitempty"`
 	RecentErrors []string          `json:"recent_errors,omitempty"`
+	Pages        []SitePageProbe   `json:"pages,omitempty"`
+}
+
+// SitePageProbe is the response to one requested path. StatusCode is 0 when
+// the request itself failed.
+type SitePageProbe struct {
+	Path       string `json:"path"`
+	StatusCode int    `json:"status_code"`
+	Bytes      int    `json:"bytes"`
+	Title      string `json:"title,omitempty"`
+}
+
+// WordPress component types reported by wp_inventory.
+const (
+	WPComponentCore   = "core"
+	WPComponentPlugin = "plugin"
+	WPComponentTheme  = "theme"
+)
+
+type WPInventoryParams struct {
+	SiteID   string `json:"site_id"`
+	SitePath string `json:"site_path"`
+}
+
+// WPComponent is one installed piece of a WordPress site. UpdateVersion is
+// empty when the component is current.
+type WPComponent struct {
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	Title         string `json:"title,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Version       string `json:"version"`
+	UpdateVersion string `json:"update_version,omitempty"`
+}
+
+type WPInventory struct {
+	SiteID      string        `json:"site_id"`
+	GeneratedAt string        `json:"generated_at"`
+	Components  []WPComponent `json:"components"`
 }
 
 var serviceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]{0,127}$`)
 
+// MaxSiteHealthPaths bounds how many extra pages one snapshot may fetch.
+const MaxSiteHealthPaths = 5
+
 var allowedServiceNames = map[string]struct{}{
 	"nginx":           {},
 	"php8.3-fpm":      {},
@@ -91,7 +138,8 @@ var allowedServiceNames = map[string]struct{}{
 var specs = map[string]Spec{
 	TypeRestartService: {Type: TypeRestartService, Timeout: 2 * time.Minute, Validate: validateRestartServicePayload},
 	TypeListServices:   {Type: TypeListServices, Timeout: 10 * time.Second, Validate: validateEmptyPayload},
-	TypeSiteHealth:     {Type: TypeSiteHealth, Timeout: 20 * time.Second, Validate: validateSiteHealthPayload},
+	TypeSiteHealth:     {Type: TypeSiteHealth, Timeout: 45 * time.Second, Validate: validateSiteHealthPayload},
+	TypeWPInventory:    {Type: TypeWPInventory, Timeout: 90 * time.Second, Validate: validateWPInventoryPayload},
 }
 
 func Lookup(commandType string) (Spec, bool) {
@@ -201,9 +249,54 @@ func validateSiteHealthPayload(payload json.RawMessage) (json.RawMessage, error)
 	if params.SitePath == "" {
 		return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: "site_path is required"}
 	}
+	if len(params.Paths) > MaxSiteHealthPaths {
+		return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: fmt.Sprintf("at most %d paths can be probed", MaxSiteHealthPaths)}
+	}
+	for i, path := range params.Paths {
+		path = strings.TrimSpace(path)
+		if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n") {
+			return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: fmt.Sprintf("path %q must be an absolute URL path", path)}
+		}
+		params.Paths[i] = path
+	}
 	normalized, err := json.Marshal(params)
 	if err != nil {
 		return nil, &ValidationError{Code: ErrorCodeSerializationFailed, Message: "failed to normalize site_health_snapshot payload"}
 	}
 	return normalized, nil
 }
+
+func DecodeWPInventoryPayload(payload json.RawMessage) (WPInventoryParams, error) {
+	normalized, err := validateWPInventoryPayload(payload)
+	if err != nil {
+		return WPInventoryParams{}, err
+	}
+	var params WPInventoryParams
+	if err := json.Unmarshal(normalized, &params); err != nil {
+		return WPInventoryParams{}, &ValidationError{Code: ErrorCodeInvalidPayload, Message: "invalid wp_inventory payload"}
+	}
+	return params, nil
+}
+
+func validateWPInventoryPayload(payload json.RawMessage) (json.RawMessage, error) {
+	if strings.TrimSpace(string(payload)) == "" {
+		return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: "wp_inventory payload is required"}
+	}
+	var params WPInventoryParams
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: "invalid wp_inventory payload"}
+	}
+	params.SiteID = strings.TrimSpace(params.SiteID)
+	params.SitePath = strings.TrimSpace(params.SitePath)
+	if params.SiteID == "" {
+		return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: "site_id is required"}
+	}
+	if params.SitePath == "" {
+		return nil, &ValidationError{Code: ErrorCodeInvalidPayload, Message: "site_path is required"}
+	}
+	normalized, err := json.Marshal(params)
+	if err != nil {
+		return nil, &ValidationError{Code: ErrorCodeSerializationFailed, Message: "failed to normalize wp_inventory payload"}
+	}
+	return normalized, nil
+}
@@ -82,3 +82,30 @@ func TestValidateSiteHealthRejectsMissingHostname(t *testing.T) {
 		t.Fatalf("code = %q, want %q", validationErr.Code, ErrorCodeInvalidPayload)
 	}
 }
+
+func TestValidateSiteHealthRejectsRelativePath(t *testing.T) {
+	payload, err := json.Marshal(SiteHealthSnapshotParams{SiteID: "site-1", Hostname: "example.testable.io", SitePath: "/srv/www/site", Paths: []string{"/", "shop"}})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	_, err = Validate(TypeSiteHealth, payload)
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("err = %v, want ValidationError", err)
+	}
+	if validationErr.Code != ErrorCodeInvalidPayload {
+		t.Fatalf("code = %q, want %q", validationErr.Code, ErrorCodeInvalidPayload)
+	}
+}
+
+func TestValidateWPInventoryRequiresSitePath(t *testing.T) {
+	_, err := Validate(TypeWPInventory, json.RawMessage(`{"site_id":"site-1","site_path":" "}`))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("err = %v, want ValidationError", err)
+	}
+	if validationErr.Code != ErrorCodeInvalidPayload {
+		t.Fatalf("code = %q, want %q", validationErr.Code, ErrorCodeInvalidPayload)
+	}
+}
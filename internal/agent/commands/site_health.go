@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -44,6 +47,11 @@ func SiteHealthSnapshot(ctx context.Context, cmd ws.Command) ws.CommandResult {
 	markCheck("home-page", probeLocalSite(ctx, params.Hostname, "/"))
 	markCheck("login-page", probeLocalSite(ctx, params.Hostname, "/wp-login.php"))
 
+	pages := make([]agentcommand.SitePageProbe, 0, len(params.Paths))
+	for _, path := range params.Paths {
+		pages = append(pages, probeLocalPage(ctx, params.Hostname, path))
+	}
+
 	for _, service := range services {
 		if service.ActiveState != "active" {
 			healthy = false
@@ -75,6 +83,7 @@ func SiteHealthSnapshot(ctx context.Context, cmd ws.Command) ws.CommandResult {
 		Services:     services,
 		Checks:       checks,
 		RecentErrors: recentErrors,
+		Pages:        pages,
 	}
 	payload, marshalErr := json.Marshal(result)
 	if marshalErr != nil {
@@ -147,6 +156,35 @@ func probeLocalSite(ctx context.Context, hostname, requestPath string) error {
 	return nil
 }
 
+var pageTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// probeLocalPage fetches one path without failing on HTTP errors so the
+// caller can compare status codes, sizes, and titles across snapshots.
+func probeLocalPage(ctx context.Context, hostname, requestPath string) agentcommand.SitePageProbe {
+	probe := agentcommand.SitePageProbe{Path: requestPath}
+	url := fmt.Sprintf("https://%s%s", hostname, requestPath)
+	out, err := commandContext(ctx, "curl", "--silent", "--insecure", "--noproxy", "*", "--max-time", "5", "--resolve", hostname+":443:127.0.0.1", "--write-out", "\n%{http_code}", url).Output()
+	if err != nil {
+		return probe
+	}
+	// --write-out appends the status code as the last line.
+	idx := strings.LastIndex(string(out), "\n")
+	if idx < 0 {
+		return probe
+	}
+	body := string(out[:idx])
+	statusCode, err := strconv.Atoi(strings.TrimSpace(string(out[idx+1:])))
+	if err != nil {
+		return probe
+	}
+	probe.StatusCode = statusCode
+	probe.Bytes = len(body)
+	if match := pageTitlePattern.FindStringSubmatch(body); match != nil {
+		probe.Title = strings.Join(strings.Fields(html.UnescapeString(match[1])), " ")
+	}
+	return probe
+}
+
 func collectJournalErrors(ctx context.Context, unit string, lines int) []string {
 	if lines <= 0 {
 		return nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"pressluft/internal/agent/agentcommand"
@@ -565,3 +566,47 @@ func TestProbeLocalSite_CurlFailure(t *testing.T) {
 		t.Fatal("expected error when curl fails")
 	}
 }
+
+func TestSiteHealthSnapshot_ProbesRequestedPages(t *testing.T) {
+	original := commandContext
+	defer func() { commandContext = original }()
+
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		if name != "curl" {
+			return exec.Command("true")
+		}
+		for i, arg := range args {
+			if arg == "--write-out" && i+2 < len(args) {
+				if strings.HasSuffix(args[i+2], "/missing") {
+					return exec.Command("printf", "%s\n404", "<html><title>Not found</title></html>")
+				}
+				return exec.Command("printf", "%s\n200", "<html><head><title>Shop &amp; More</title></head></html>")
+			}
+		}
+		return exec.Command("echo", "<html></html>")
+	}
+
+	payload, _ := json.Marshal(agentcommand.SiteHealthSnapshotParams{
+		SiteID:   "site-1",
+		Hostname: "example.com",
+		SitePath: "/srv/www/site",
+		Paths:    []string{"/shop", "/missing"},
+	})
+	result := SiteHealthSnapshot(context.Background(), ws.Command{ID: "cmd-sh-pages", Payload: payload})
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s (code: %s)", result.Error, result.ErrorCode)
+	}
+	var snapshot agentcommand.SiteHealthSnapshot
+	if err := json.Unmarshal(result.Payload, &snapshot); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if len(snapshot.Pages) != 2 {
+		t.Fatalf("pages = %+v, want two probes", snapshot.Pages)
+	}
+	if page := snapshot.Pages[0]; page.Path != "/shop" || page.StatusCode != 200 || page.Title != "Shop & More" || page.Bytes == 0 {
+		t.Errorf("shop probe = %+v", page)
+	}
+	if page := snapshot.Pages[1]; page.StatusCode != 404 {
+		t.Errorf("missing probe = %+v, want 404", page)
+	}
+}
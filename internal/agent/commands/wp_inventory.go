@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/shared/ws"
+)
+
+// WPInventory lists the WordPress core, plugin, and theme versions of one site
+// together with the updates WordPress.org offers for them.
+func WPInventory(ctx context.Context, cmd ws.Command) ws.CommandResult {
+	params, err := agentcommand.DecodeWPInventoryPayload(cmd.Payload)
+	if err != nil {
+		var validationErr *agentcommand.ValidationError
+		if errors.As(err, &validationErr) {
+			return ws.FailureResult(cmd.ID, validationErr.Code, validationErr.Message, nil, "")
+		}
+		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeInvalidPayload, "invalid wp_inventory payload", nil, "")
+	}
+
+	publicPath := filepath.ToSlash(filepath.Join(params.SitePath, "public"))
+	core, err := wpCoreComponent(ctx, publicPath)
+	if err != nil {
+		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeExecutionFailed, "failed to read WordPress core version", nil, err.Error())
+	}
+	components := []agentcommand.WPComponent{core}
+	for _, componentType := range []string{agentcommand.WPComponentPlugin, agentcommand.WPComponentTheme} {
+		listed, err := wpListComponents(ctx, publicPath, componentType)
+		if err != nil {
+			return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeExecutionFailed, fmt.Sprintf("failed to list %ss", componentType), nil, err.Error())
+		}
+		components = append(components, listed...)
+	}
+
+	return ws.SuccessResult(cmd.ID, agentcommand.WPInventory{
+		SiteID:      params.SiteID,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Components:  components,
+	}, "")
+}
+
+func wpCoreComponent(ctx context.Context, publicPath string) (agentcommand.WPComponent, error) {
+	version, err := wpCLI(ctx, publicPath, "core", "version")
+	if err != nil {
+		return agentcommand.WPComponent{}, err
+	}
+	core := agentcommand.WPComponent{
+		Type:    agentcommand.WPComponentCore,
+		Name:    "wordpress",
+		Title:   "WordPress",
+		Version: strings.TrimSpace(string(version)),
+	}
+	// check-update prints a success line instead of JSON when core is current.
+	out, err := wpCLI(ctx, publicPath, "core", "check-update", "--format=json")
+	if err != nil {
+		return agentcommand.WPComponent{}, err
+	}
+	trimmed := strings.TrimSpace(string(out))
+	if strings.HasPrefix(trimmed, "[") {
+		var updates []struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &updates); err != nil {
+			return agentcommand.WPComponent{}, fmt.Errorf("decode core updates: %w", err)
+		}
+		if len(updates) > 0 {
+			core.UpdateVersion = strings.TrimSpace(updates[0].Version)
+		}
+	}
+	return core, nil
+}
+
+func wpListComponents(ctx context.Context, publicPath, componentType string) ([]agentcommand.WPComponent, error) {
+	out, err := wpCLI(ctx, publicPath, componentType, "list", "--format=json", "--fields=name,title,status,version,update_version")
+	if err != nil {
+		return nil, err
+	}
+	var listed []agentcommand.WPComponent
+	if err := json.Unmarshal(out, &listed); err != nil {
+		return nil, fmt.Errorf("decode %s list: %w", componentType, err)
+	}
+	for i := range listed {
+		listed[i].Type = componentType
+		listed[i].UpdateVersion = strings.TrimSpace(listed[i].UpdateVersion)
+	}
+	return listed, nil
+}
+
+// wpCLI skips plugins and themes so a broken plugin cannot hide the inventory
+// that is needed to fix it.
+func wpCLI(ctx context.Context, publicPath string, args ...string) ([]byte, error) {
+	base := []string{"--path=" + publicPath, "--allow-root", "--skip-plugins", "--skip-themes"}
+	out, err := commandContext(ctx, "wp", append(base, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("wp %s failed: %w", strings.Join(args[:2], " "), err)
+	}
+	return out, nil
+}
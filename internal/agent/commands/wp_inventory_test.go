@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/shared/ws"
+)
+
+func TestWPInventory_ListsComponentsAndUpdates(t *testing.T) {
+	original := commandContext
+	defer func() { commandContext = original }()
+
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		joined := strings.Join(args, " ")
+		switch {
+		case strings.Contains(joined, "core version"):
+			return exec.Command("echo", "6.4.3")
+		case strings.Contains(joined, "core check-update"):
+			return exec.Command("echo", `[{"version":"6.5.2","update_type":"major"}]`)
+		case strings.Contains(joined, "plugin list"):
+			return exec.Command("echo", `[{"name":"woocommerce","title":"WooCommerce","status":"active","version":"8.6.0","update_version":"8.7.0"},{"name":"akismet","title":"Akismet","status":"inactive","version":"5.3","update_version":""}]`)
+		case strings.Contains(joined, "theme list"):
+			return exec.Command("echo", `[{"name":"twentytwentyfour","title":"Twenty Twenty-Four","status":"active","version":"1.1","update_version":""}]`)
+		default:
+			return exec.Command("false")
+		}
+	}
+
+	payload, _ := json.Marshal(agentcommand.WPInventoryParams{SiteID: "site-1", SitePath: "/srv/www/site"})
+	result := WPInventory(context.Background(), ws.Command{ID: "cmd-inv-1", Payload: payload})
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s (code: %s)", result.Error, result.ErrorCode)
+	}
+
+	var inventory agentcommand.WPInventory
+	if err := json.Unmarshal(result.Payload, &inventory); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if inventory.SiteID != "site-1" || len(inventory.Components) != 4 {
+		t.Fatalf("inventory = %+v, want core, two plugins and a theme", inventory)
+	}
+	core := inventory.Components[0]
+	if core.Type != agentcommand.WPComponentCore || core.Version != "6.4.3" || core.UpdateVersion != "6.5.2" {
+		t.Errorf("core = %+v, want 6.4.3 with 6.5.2 available", core)
+	}
+	woo := inventory.Components[1]
+	if woo.Type != agentcommand.WPComponentPlugin || woo.Name != "woocommerce" || woo.UpdateVersion != "8.7.0" {
+		t.Errorf("plugin = %+v, want woocommerce with update", woo)
+	}
+	if theme := inventory.Components[3]; theme.Type != agentcommand.WPComponentTheme || theme.UpdateVersion != "" {
+		t.Errorf("theme = %+v, want current theme", theme)
+	}
+}
+
+func TestWPInventory_CoreCurrentPrintsNoJSON(t *testing.T) {
+	original := commandContext
+	defer func() { commandContext = original }()
+
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		joined := strings.Join(args, " ")
+		switch {
+		case strings.Contains(joined, "core version"):
+			return exec.Command("echo", "6.5.2")
+		case strings.Contains(joined, "core check-update"):
+			return exec.Command("echo", "Success: WordPress is at the latest version.")
+		default:
+			return exec.Command("echo", "[]")
+		}
+	}
+
+	payload, _ := json.Marshal(agentcommand.WPInventoryParams{SiteID: "site-1", SitePath: "/srv/www/site"})
+	result := WPInventory(context.Background(), ws.Command{ID: "cmd-inv-2", Payload: payload})
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s (code: %s)", result.Error, result.ErrorCode)
+	}
+	var inventory agentcommand.WPInventory
+	if err := json.Unmarshal(result.Payload, &inventory); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if len(inventory.Components) != 1 || inventory.Components[0].UpdateVersion != "" {
+		t.Fatalf("inventory = %+v, want only a current core", inventory)
+	}
+}
+
+func TestWPInventory_FailsWhenWPCLIFails(t *testing.T) {
+	original := commandContext
+	defer func() { commandContext = original }()
+
+	commandContext = func(ctx context.Context, name string, args ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	payload, _ := json.Marshal(agentcommand.WPInventoryParams{SiteID: "site-1", SitePath: "/srv/www/site"})
+	result := WPInventory(context.Background(), ws.Command{ID: "cmd-inv-3", Payload: payload})
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if result.ErrorCode != agentcommand.ErrorCodeExecutionFailed {
+		t.Errorf("ErrorCode = %q, want %q", result.ErrorCode, agentcommand.ErrorCodeExecutionFailed)
+	}
+}
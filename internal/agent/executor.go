@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"pressluft/internal/agent/agentcommand"
 	"pressluft/internal/agent/commands"
@@ -15,6 +16,10 @@ type Executor struct {
 	restartService commandFunc
 	listServices   commandFunc
 	siteHealth     commandFunc
+	wpInventory    commandFunc
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
 }
 
 func NewExecutor() *Executor {
@@ -22,6 +27,7 @@ func NewExecutor() *Executor {
 		restartService: commands.RestartService,
 		listServices:   commands.ListServices,
 		siteHealth:     commands.SiteHealthSnapshot,
+		wpInventory:    commands.WPInventory,
 	}
 }
 
@@ -42,6 +48,48 @@ func (e *Executor) Execute(ctx context.Context, cmd ws.Command) ws.CommandResult
 		defer cancel()
 	}
 
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	e.track(cmd.ID, func() { cancel(errCommandCancelled) })
+	defer e.untrack(cmd.ID)
+
+	result := e.dispatch(ctx, cmd)
+	if !result.Success && errors.Is(context.Cause(ctx), errCommandCancelled) {
+		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeCommandCancelled, "command cancelled", nil, result.Output)
+	}
+	return result
+}
+
+// Cancel aborts a running command. It reports false when no command with
+// that ID is running.
+func (e *Executor) Cancel(commandID string) bool {
+	e.mu.Lock()
+	cancel, ok := e.running[commandID]
+	e.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+var errCommandCancelled = errors.New("command cancelled by control plane")
+
+func (e *Executor) track(commandID string, cancel context.CancelFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running == nil {
+		e.running = make(map[string]context.CancelFunc)
+	}
+	e.running[commandID] = cancel
+}
+
+func (e *Executor) untrack(commandID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.running, commandID)
+}
+
+func (e *Executor) dispatch(ctx context.Context, cmd ws.Command) ws.CommandResult {
 	switch cmd.Type {
 	case agentcommand.TypeRestartService:
 		return e.restartService(ctx, cmd)
@@ -49,6 +97,8 @@ func (e *Executor) Execute(ctx context.Context, cmd ws.Command) ws.CommandResult
 		return e.listServices(ctx, cmd)
 	case agentcommand.TypeSiteHealth:
 		return e.siteHealth(ctx, cmd)
+	case agentcommand.TypeWPInventory:
+		return e.wpInventory(ctx, cmd)
 	default:
 		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeUnknownCommand, "unknown command", nil, "")
 	}
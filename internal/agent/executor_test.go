@@ -93,3 +93,64 @@ func TestExecutorDispatchesSiteHealthCommand(t *testing.T) {
 		t.Fatalf("expected success, got %+v", result)
 	}
 }
+
+func TestExecutorDispatchesWPInventoryCommand(t *testing.T) {
+	called := false
+	executor := &Executor{
+		wpInventory: func(ctx context.Context, cmd ws.Command) ws.CommandResult {
+			called = true
+			return ws.SuccessResult(cmd.ID, agentcommand.WPInventory{SiteID: "site-1"}, "")
+		},
+	}
+	payload, err := json.Marshal(agentcommand.WPInventoryParams{SiteID: "site-1", SitePath: "/srv/www/site"})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	result := executor.Execute(context.Background(), ws.Command{ID: "cmd-wp-inventory", Type: agentcommand.TypeWPInventory, Payload: payload})
+	if !called {
+		t.Fatal("expected wp inventory handler to be called")
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+}
+
+func TestExecutorCancelAbortsRunningCommand(t *testing.T) {
+	started := make(chan struct{})
+	executor := &Executor{
+		restartService: func(ctx context.Context, cmd ws.Command) ws.CommandResult {
+			close(started)
+			<-ctx.Done()
+			return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeExecutionFailed, ctx.Err().Error(), nil, "partial output")
+		},
+	}
+	payload, err := json.Marshal(agentcommand.RestartServiceParams{ServiceName: "nginx"})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	if executor.Cancel("cmd-cancel") {
+		t.Fatal("expected cancel of an unknown command to report false")
+	}
+	results := make(chan ws.CommandResult, 1)
+	go func() {
+		results <- executor.Execute(context.Background(), ws.Command{ID: "cmd-cancel", Type: agentcommand.TypeRestartService, Payload: payload})
+	}()
+	<-started
+	if !executor.Cancel("cmd-cancel") {
+		t.Fatal("expected cancel of the running command to report true")
+	}
+
+	select {
+	case result := <-results:
+		if result.Success || result.ErrorCode != agentcommand.ErrorCodeCommandCancelled || result.Output != "partial output" {
+			t.Fatalf("result = %+v, want a cancelled failure keeping the output", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the cancelled command to return")
+	}
+	if executor.Cancel("cmd-cancel") {
+		t.Fatal("expected finished command to be forgotten")
+	}
+}
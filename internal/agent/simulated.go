@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/shared/ws"
+)
+
+// simulatedServices are the services a simulated server reports running.
+var simulatedServices = []agentcommand.Service{
+	{Name: "nginx", Description: "A high performance web server and a reverse proxy server", ActiveState: "active", LoadState: "loaded"},
+	{Name: "php8.3-fpm", Description: "The PHP 8.3 FastCGI Process Manager", ActiveState: "active", LoadState: "loaded"},
+	{Name: "mariadb", Description: "MariaDB database server", ActiveState: "active", LoadState: "loaded"},
+}
+
+// NewSimulated returns an agent that connects and heartbeats like a real one
+// but answers every command with a healthy canned result instead of touching
+// the host. It stands in for the agent on servers of simulated providers,
+// which have no machine to run on.
+func NewSimulated(config *Config, logger *slog.Logger) *Agent {
+	return &Agent{
+		config:   config,
+		executor: newSimulatedExecutor(),
+		logger:   logger,
+	}
+}
+
+func newSimulatedExecutor() *Executor {
+	return &Executor{
+		restartService: simulatedRestartService,
+		listServices:   simulatedListServices,
+		siteHealth:     simulatedSiteHealth,
+		wpInventory:    simulatedWPInventory,
+	}
+}
+
+func simulatedRestartService(ctx context.Context, cmd ws.Command) ws.CommandResult {
+	params, err := agentcommand.DecodeRestartServicePayload(cmd.Payload)
+	if err != nil {
+		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeInvalidPayload, err.Error(), nil, "")
+	}
+	return ws.SuccessResult(cmd.ID, agentcommand.RestartServiceResult{ServiceName: params.ServiceName, Action: "restarted"}, "")
+}
+
+func simulatedListServices(ctx context.Context, cmd ws.Command) ws.CommandResult {
+	return ws.SuccessResult(cmd.ID, agentcommand.ListServicesResult{Services: simulatedServices}, "")
+}
+
+func simulatedSiteHealth(ctx context.Context, cmd ws.Command) ws.CommandResult {
+	params, err := agentcommand.DecodeSiteHealthPayload(cmd.Payload)
+	if err != nil {
+		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeInvalidPayload, err.Error(), nil, "")
+	}
+	checks := make([]agentcommand.SiteHealthCheck, 0, 5)
+	for _, name := range []string{"wordpress-installed", "wordpress-home-url", "wordpress-siteurl", "home-page", "login-page"} {
+		checks = append(checks, agentcommand.SiteHealthCheck{Name: name, OK: true})
+	}
+	pages := make([]agentcommand.SitePageProbe, 0, len(params.Paths))
+	for _, path := range params.Paths {
+		pages = append(pages, agentcommand.SitePageProbe{Path: path, StatusCode: 200, Bytes: 1024, Title: params.Hostname})
+	}
+	return ws.SuccessResult(cmd.ID, agentcommand.SiteHealthSnapshot{
+		SiteID:      params.SiteID,
+		Hostname:    params.Hostname,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Healthy:     true,
+		Summary:     "Simulated WordPress runtime checks passed.",
+		Services:    simulatedServices,
+		Checks:      checks,
+		Pages:       pages,
+	}, "")
+}
+
+func simulatedWPInventory(ctx context.Context, cmd ws.Command) ws.CommandResult {
+	params, err := agentcommand.DecodeWPInventoryPayload(cmd.Payload)
+	if err != nil {
+		return ws.FailureResult(cmd.ID, agentcommand.ErrorCodeInvalidPayload, err.Error(), nil, "")
+	}
+	return ws.SuccessResult(cmd.ID, agentcommand.WPInventory{
+		SiteID:      params.SiteID,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Components: []agentcommand.WPComponent{
+			{Type: agentcommand.WPComponentCore, Name: "wordpress", Title: "WordPress", Version: "6.8"},
+			{Type: agentcommand.WPComponentTheme, Name: "twentytwentyfive", Title: "Twenty Twenty-Five", Status: "active", Version: "1.2"},
+		},
+	}, "")
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/shared/ws"
+)
+
+func TestSimulatedExecutorReportsHealthySite(t *testing.T) {
+	executor := newSimulatedExecutor()
+	payload, err := json.Marshal(agentcommand.SiteHealthSnapshotParams{SiteID: "site-1", Hostname: "example.testable.io", SitePath: "/srv/www/site", Paths: []string{"/about/"}})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	result := executor.Execute(context.Background(), ws.Command{ID: "cmd-1", Type: agentcommand.TypeSiteHealth, Payload: payload})
+	if !result.Success {
+		t.Fatalf("expected success, got %+v", result)
+	}
+	var snapshot agentcommand.SiteHealthSnapshot
+	if err := json.Unmarshal(result.Payload, &snapshot); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if !snapshot.Healthy || snapshot.SiteID != "site-1" || len(snapshot.Pages) != 1 || snapshot.Pages[0].StatusCode != 200 {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+}
+
+func TestSimulatedExecutorStillValidatesCommands(t *testing.T) {
+	executor := newSimulatedExecutor()
+	payload, err := json.Marshal(agentcommand.RestartServiceParams{ServiceName: "sshd"})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	result := executor.Execute(context.Background(), ws.Command{ID: "cmd-2", Type: agentcommand.TypeRestartService, Payload: payload})
+	if result.Success || result.ErrorCode != agentcommand.ErrorCodeServiceNotAllowed {
+		t.Fatalf("result = %+v, want the disallowed service rejected", result)
+	}
+}
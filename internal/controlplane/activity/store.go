@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 )
 
 // Store persists activity entries.
@@ -26,7 +27,7 @@ const activityColumns = `id, event_type, category, level,
 		parent_resource_type, parent_resource_id,
 		actor_type, actor_id,
 		title, message, payload,
-		requires_attention, read_at, created_at`
+		requires_attention, read_at, created_at, workspace_id`
 
 // scanActivityRow scans a single row into an Activity struct, handling all
 // nullable column conversions internally.
@@ -61,6 +62,7 @@ func scanActivityRow(scanner interface{ Scan(dest ...any) error }) (Activity, er
 		&requiresAttention,
 		&readAt,
 		&a.CreatedAt,
+		&a.WorkspaceID,
 	)
 	if err != nil {
 		return Activity{}, err
@@ -108,11 +110,14 @@ func scanActivityRows(rows *sql.Rows) ([]Activity, error) {
 	return out, nil
 }
 
-// buildActivityFilterClause turns a ListFilter into SQL AND-clauses and args.
-// The returned clause string begins with " AND ..." (or is empty).
-func buildActivityFilterClause(filter ListFilter) (string, []any) {
+// buildActivityFilterClause turns a ListFilter into SQL AND-clauses and args,
+// always keeping to the workspace of ctx. The returned clause string begins
+// with " AND ...".
+func buildActivityFilterClause(ctx context.Context, filter ListFilter) (string, []any) {
 	var b strings.Builder
-	var args []any
+	scope := workspace.Filter(ctx)
+	b.WriteString(workspaceCondition)
+	args := []any{scope, scope}
 
 	if cursor := parseCursor(filter.Cursor); cursor != "" {
 		b.WriteString(" AND id < ?")
@@ -157,6 +162,51 @@ func buildActivityFilterClause(filter ListFilter) (string, []any) {
 	return b.String(), args
 }
 
+// workspaceCondition keeps activity queries inside the request's workspace;
+// it takes workspace.Filter twice.
+const workspaceCondition = ` AND (? = '' OR workspace_id = ?)`
+
+// workspaceTables maps the resource types that belong to a workspace to the
+// table recording it.
+var workspaceTables = map[ResourceType]string{
+	ResourceJob:          "jobs",
+	ResourceServer:       "servers",
+	ResourceProvider:     "providers",
+	ResourceSite:         "sites",
+	ResourceDomain:       "domains",
+	ResourceAPIKey:       "api_tokens",
+	ResourceBackupTarget: "backup_targets",
+	ResourceSchedule:     "schedules",
+	ResourceWebhook:      "webhook_subscriptions",
+}
+
+// workspaceFor picks the workspace an entry is recorded in. Requests carry
+// theirs; entries emitted by workers and agents follow the resource they are
+// about, and anything else lands in the default workspace.
+func (s *Store) workspaceFor(ctx context.Context, in EmitInput) string {
+	if id, ok := workspace.IDFromContext(ctx); ok {
+		return id
+	}
+	refs := []struct {
+		resourceType ResourceType
+		resourceID   string
+	}{
+		{in.ResourceType, in.ResourceID},
+		{in.ParentResourceType, in.ParentResourceID},
+	}
+	for _, ref := range refs {
+		table, ok := workspaceTables[ref.resourceType]
+		if !ok || strings.TrimSpace(ref.resourceID) == "" {
+			continue
+		}
+		var id string
+		if err := s.db.QueryRowContext(ctx, `SELECT workspace_id FROM `+table+` WHERE id = ?`, ref.resourceID).Scan(&id); err == nil {
+			return id
+		}
+	}
+	return workspace.DefaultID
+}
+
 // clampLimit normalises a page-size value into the [1, 200] range with a
 // default of 50.
 func clampLimit(limit int) int {
@@ -201,15 +251,16 @@ func (s *Store) Emit(ctx context.Context, in EmitInput) (Activity, error) {
 	}
 	_, err = s.db.ExecContext(ctx,
 		`INSERT INTO activity (
-			id,
+			id, workspace_id,
 			event_type, category, level,
 			resource_type, resource_id,
 			parent_resource_type, parent_resource_id,
 			actor_type, actor_id,
 			title, message, payload,
 			requires_attention, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		publicID,
+		s.workspaceFor(ctx, in),
 		in.EventType,
 		in.Category,
 		in.Level,
@@ -242,8 +293,8 @@ func (s *Store) GetByID(ctx context.Context, id string) (Activity, error) {
 	a, err := scanActivityRow(s.db.QueryRowContext(ctx,
 		`SELECT `+activityColumns+`
 		FROM activity
-		WHERE id = ?`,
-		publicID,
+		WHERE id = ?`+workspaceCondition,
+		publicID, workspace.Filter(ctx), workspace.Filter(ctx),
 	))
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -260,7 +311,7 @@ func (s *Store) GetByID(ctx context.Context, id string) (Activity, error) {
 func (s *Store) List(ctx context.Context, filter ListFilter) ([]Activity, string, error) {
 	limit := clampLimit(filter.Limit)
 
-	whereClause, args := buildActivityFilterClause(filter)
+	whereClause, args := buildActivityFilterClause(ctx, filter)
 
 	query := `SELECT ` + activityColumns + `
 	FROM activity
@@ -305,7 +356,7 @@ func (s *Store) ListForServer(ctx context.Context, serverID string, filter ListF
 	scoped.ResourceID = ""
 	scoped.ParentResourceType = ""
 	scoped.ParentResourceID = ""
-	whereClause, filterArgs := buildActivityFilterClause(scoped)
+	whereClause, filterArgs := buildActivityFilterClause(ctx, scoped)
 
 	baseArgs := []any{ResourceServer, serverID, ResourceServer, serverID}
 	args := append(baseArgs, filterArgs...)
@@ -350,7 +401,7 @@ func (s *Store) ListForSite(ctx context.Context, siteID string, filter ListFilte
 	scoped.ResourceID = ""
 	scoped.ParentResourceType = ""
 	scoped.ParentResourceID = ""
-	whereClause, filterArgs := buildActivityFilterClause(scoped)
+	whereClause, filterArgs := buildActivityFilterClause(ctx, scoped)
 
 	baseArgs := []any{ResourceSite, siteID, ResourceSite, siteID}
 	args := append(baseArgs, filterArgs...)
@@ -389,10 +440,13 @@ func (s *Store) MarkRead(ctx context.Context, id string) error {
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
+	scope := workspace.Filter(ctx)
 	res, err := s.db.ExecContext(ctx,
-		`UPDATE activity SET read_at = ? WHERE id = ? AND read_at IS NULL`,
+		`UPDATE activity SET read_at = ? WHERE id = ? AND read_at IS NULL`+workspaceCondition,
 		now,
 		publicID,
+		scope,
+		scope,
 	)
 	if err != nil {
 		return fmt.Errorf("mark read: %w", err)
@@ -402,7 +456,7 @@ func (s *Store) MarkRead(ctx context.Context, id string) error {
 	if rows == 0 {
 		// Check if the activity exists
 		var exists int
-		err := s.db.QueryRowContext(ctx, `SELECT 1 FROM activity WHERE id = ?`, publicID).Scan(&exists)
+		err := s.db.QueryRowContext(ctx, `SELECT 1 FROM activity WHERE id = ?`+workspaceCondition, publicID, scope, scope).Scan(&exists)
 		if err == sql.ErrNoRows {
 			return fmt.Errorf("activity %s not found", publicID)
 		}
@@ -429,7 +483,7 @@ func (s *Store) MarkAllRead(ctx context.Context, filter ListFilter) error {
 		scoped.RequiresAttention = nil
 	}
 
-	whereClause, filterArgs := buildActivityFilterClause(scoped)
+	whereClause, filterArgs := buildActivityFilterClause(ctx, scoped)
 
 	args := []any{now}
 	args = append(args, filterArgs...)
@@ -455,7 +509,7 @@ func (s *Store) CountUnread(ctx context.Context, filter ListFilter) (int64, erro
 		scoped.RequiresAttention = nil
 	}
 
-	whereClause, filterArgs := buildActivityFilterClause(scoped)
+	whereClause, filterArgs := buildActivityFilterClause(ctx, scoped)
 
 	query := `SELECT COUNT(*) FROM activity WHERE read_at IS NULL` + whereClause
 
@@ -468,11 +522,12 @@ func (s *Store) CountUnread(ctx context.Context, filter ListFilter) (int64, erro
 	return count, nil
 }
 
-// GetLatestID returns the ID of the most recent activity entry.
-// Returns empty string if no entries exist (not an error).
+// GetLatestID returns the ID of the most recent activity entry in the
+// workspace. Returns empty string if no entries exist (not an error).
 func (s *Store) GetLatestID(ctx context.Context) (string, error) {
 	var id sql.NullString
-	err := s.db.QueryRowContext(ctx, `SELECT id FROM activity ORDER BY id DESC LIMIT 1`).Scan(&id)
+	scope := workspace.Filter(ctx)
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM activity WHERE 1=1`+workspaceCondition+` ORDER BY id DESC LIMIT 1`, scope, scope).Scan(&id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", nil
@@ -501,10 +556,12 @@ func (s *Store) ListSince(ctx context.Context, sinceID string, limit int) ([]Act
 	rows, err := s.db.QueryContext(ctx,
 		`SELECT `+activityColumns+`
 		FROM activity
-		WHERE id > ?
+		WHERE id > ?`+workspaceCondition+`
 		ORDER BY id ASC
 		LIMIT ?`,
 		sinceID,
+		workspace.Filter(ctx),
+		workspace.Filter(ctx),
 		limit,
 	)
 	if err != nil {
@@ -468,6 +468,7 @@ func mustOpenActivityDB(t *testing.T) *sql.DB {
 	if _, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS activity (
 			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			event_type           TEXT NOT NULL,
 			category             TEXT NOT NULL,
 			level                TEXT NOT NULL,
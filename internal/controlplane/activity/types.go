@@ -23,6 +23,8 @@ const (
 	CategoryDomain   Category = "domain"
 	CategoryAccount  Category = "account"
 	CategorySecurity Category = "security"
+	CategoryBackup   Category = "backup"
+	CategorySchedule Category = "schedule"
 )
 
 // ActorType identifies who triggered the activity.
@@ -45,6 +47,7 @@ const (
 	EventJobCompleted EventType = "job.completed"
 	EventJobFailed    EventType = "job.failed"
 	EventJobCancelled EventType = "job.cancelled"
+	EventJobRetrying  EventType = "job.retrying"
 )
 
 // Server events
@@ -53,6 +56,7 @@ const (
 	EventServerProvisioned   EventType = "server.provisioned"
 	EventServerDeleted       EventType = "server.deleted"
 	EventServerStatusChanged EventType = "server.status_changed"
+	EventServerAgentOffline  EventType = "server.agent_offline"
 )
 
 // Provider events
@@ -64,11 +68,18 @@ const (
 
 // Site events
 const (
-	EventSiteCreated       EventType = "site.created"
-	EventSiteUpdated       EventType = "site.updated"
-	EventSiteDeployed      EventType = "site.deployed"
-	EventSiteHealthChanged EventType = "site.health_changed"
-	EventSiteDeleted       EventType = "site.deleted"
+	EventSiteCreated           EventType = "site.created"
+	EventSiteUpdated           EventType = "site.updated"
+	EventSiteDeployed          EventType = "site.deployed"
+	EventSiteHealthChanged     EventType = "site.health_changed"
+	EventSiteDeleted           EventType = "site.deleted"
+	EventSiteStagingReady      EventType = "site.staging_ready"
+	EventSitePushed            EventType = "site.pushed"
+	EventSitePushRolledBack    EventType = "site.push_rolled_back"
+	EventSiteComponentsUpdated EventType = "site.components_updated"
+	EventSiteUpdateRolledBack  EventType = "site.update_rolled_back"
+	EventSiteDowntimeStarted   EventType = "site.downtime_started"
+	EventSiteDowntimeResolved  EventType = "site.downtime_resolved"
 )
 
 // Domain events
@@ -79,6 +90,28 @@ const (
 	EventDomainAssigned EventType = "domain.assigned"
 )
 
+// Backup events
+const (
+	EventBackupTargetAdded   EventType = "backup.target_added"
+	EventBackupTargetRemoved EventType = "backup.target_removed"
+	EventBackupCompleted     EventType = "backup.completed"
+	EventBackupFailed        EventType = "backup.failed"
+	EventBackupRestored      EventType = "backup.restored"
+	EventBackupRolledBack    EventType = "backup.restore_rolled_back"
+)
+
+// Schedule events
+const (
+	EventScheduleCreated    EventType = "schedule.created"
+	EventScheduleUpdated    EventType = "schedule.updated"
+	EventScheduleDeleted    EventType = "schedule.deleted"
+	EventSchedulePaused     EventType = "schedule.paused"
+	EventScheduleResumed    EventType = "schedule.resumed"
+	EventScheduleTriggered  EventType = "schedule.triggered"
+	EventScheduleRunSkipped EventType = "schedule.run_skipped"
+	EventScheduleRunFailed  EventType = "schedule.run_failed"
+)
+
 // Account events
 const (
 	EventAccountSettingsChanged EventType = "account.settings_changed"
@@ -86,13 +119,33 @@ const (
 
 // Security events
 const (
-	EventSecurityAPIKeyCreated  EventType = "security.api_key_created"
-	EventSecurityAPIKeyRevoked  EventType = "security.api_key_revoked"
-	EventSecurityLoginSucceeded EventType = "security.login_succeeded"
-	EventSecurityLoginFailed    EventType = "security.login_failed"
-	EventSecurityLogout         EventType = "security.logout"
-	EventSecurityBootstrapAdmin EventType = "security.bootstrap_admin_created"
-	EventSecuritySessionRevoked EventType = "security.session_revoked"
+	EventSecurityAPIKeyCreated         EventType = "security.api_key_created"
+	EventSecurityAPIKeyRevoked         EventType = "security.api_key_revoked"
+	EventSecurityLoginSucceeded        EventType = "security.login_succeeded"
+	EventSecurityLoginFailed           EventType = "security.login_failed"
+	EventSecurityLogout                EventType = "security.logout"
+	EventSecurityBootstrapAdmin        EventType = "security.bootstrap_admin_created"
+	EventSecuritySessionRevoked        EventType = "security.session_revoked"
+	EventSecuritySiteGrantsUpdated     EventType = "security.site_grants_updated"
+	EventSecurityTOTPEnabled           EventType = "security.totp_enabled"
+	EventSecurityTOTPDisabled          EventType = "security.totp_disabled"
+	EventSecurityTOTPFailed            EventType = "security.totp_failed"
+	EventSecurityRecoveryCodeUsed      EventType = "security.recovery_code_used"
+	EventSecurityAuthPolicyUpdated     EventType = "security.auth_policy_updated"
+	EventSecurityUserInvited           EventType = "security.user_invited"
+	EventSecurityInvitationAccepted    EventType = "security.invitation_accepted"
+	EventSecurityUserRoleChanged       EventType = "security.user_role_changed"
+	EventSecurityUserSuspended         EventType = "security.user_suspended"
+	EventSecurityUserReactivated       EventType = "security.user_reactivated"
+	EventSecurityUserDeleted           EventType = "security.user_deleted"
+	EventSecurityPasswordChanged       EventType = "security.password_changed"
+	EventSecurityPasswordResetIssued   EventType = "security.password_reset_issued"
+	EventSecurityWorkspaceCreated      EventType = "security.workspace_created"
+	EventSecurityMemberAdded           EventType = "security.workspace_member_added"
+	EventSecurityWebhookCreated        EventType = "security.webhook_created"
+	EventSecurityWebhookDeleted        EventType = "security.webhook_deleted"
+	EventSecurityVulnerabilityDetected EventType = "security.vulnerability_detected"
+	EventSecurityVulnerabilityResolved EventType = "security.vulnerability_resolved"
 )
 
 // validEventTypes is the set of all allowed event types.
@@ -103,36 +156,81 @@ var validEventTypes = map[EventType]bool{
 	EventJobCompleted: true,
 	EventJobFailed:    true,
 	EventJobCancelled: true,
+	EventJobRetrying:  true,
 	// Server events
 	EventServerCreated:       true,
 	EventServerProvisioned:   true,
 	EventServerDeleted:       true,
 	EventServerStatusChanged: true,
+	EventServerAgentOffline:  true,
 	// Provider events
 	EventProviderAdded:   true,
 	EventProviderUpdated: true,
 	EventProviderRemoved: true,
 	// Site events
-	EventSiteCreated:       true,
-	EventSiteUpdated:       true,
-	EventSiteDeployed:      true,
-	EventSiteHealthChanged: true,
-	EventSiteDeleted:       true,
+	EventSiteCreated:           true,
+	EventSiteUpdated:           true,
+	EventSiteDeployed:          true,
+	EventSiteHealthChanged:     true,
+	EventSiteDeleted:           true,
+	EventSiteStagingReady:      true,
+	EventSitePushed:            true,
+	EventSitePushRolledBack:    true,
+	EventSiteComponentsUpdated: true,
+	EventSiteUpdateRolledBack:  true,
+	EventSiteDowntimeStarted:   true,
+	EventSiteDowntimeResolved:  true,
 	// Domain events
 	EventDomainCreated:  true,
 	EventDomainUpdated:  true,
 	EventDomainDeleted:  true,
 	EventDomainAssigned: true,
+	// Backup events
+	EventBackupTargetAdded:   true,
+	EventBackupTargetRemoved: true,
+	EventBackupCompleted:     true,
+	EventBackupFailed:        true,
+	EventBackupRestored:      true,
+	EventBackupRolledBack:    true,
+	// Schedule events
+	EventScheduleCreated:    true,
+	EventScheduleUpdated:    true,
+	EventScheduleDeleted:    true,
+	EventSchedulePaused:     true,
+	EventScheduleResumed:    true,
+	EventScheduleTriggered:  true,
+	EventScheduleRunSkipped: true,
+	EventScheduleRunFailed:  true,
 	// Account events
 	EventAccountSettingsChanged: true,
 	// Security events
-	EventSecurityAPIKeyCreated:  true,
-	EventSecurityAPIKeyRevoked:  true,
-	EventSecurityLoginSucceeded: true,
-	EventSecurityLoginFailed:    true,
-	EventSecurityLogout:         true,
-	EventSecurityBootstrapAdmin: true,
-	EventSecuritySessionRevoked: true,
+	EventSecurityAPIKeyCreated:         true,
+	EventSecurityAPIKeyRevoked:         true,
+	EventSecurityLoginSucceeded:        true,
+	EventSecurityLoginFailed:           true,
+	EventSecurityLogout:                true,
+	EventSecurityBootstrapAdmin:        true,
+	EventSecuritySessionRevoked:        true,
+	EventSecuritySiteGrantsUpdated:     true,
+	EventSecurityTOTPEnabled:           true,
+	EventSecurityTOTPDisabled:          true,
+	EventSecurityTOTPFailed:            true,
+	EventSecurityRecoveryCodeUsed:      true,
+	EventSecurityAuthPolicyUpdated:     true,
+	EventSecurityUserInvited:           true,
+	EventSecurityInvitationAccepted:    true,
+	EventSecurityUserRoleChanged:       true,
+	EventSecurityUserSuspended:         true,
+	EventSecurityUserReactivated:       true,
+	EventSecurityUserDeleted:           true,
+	EventSecurityPasswordChanged:       true,
+	EventSecurityPasswordResetIssued:   true,
+	EventSecurityWorkspaceCreated:      true,
+	EventSecurityMemberAdded:           true,
+	EventSecurityWebhookCreated:        true,
+	EventSecurityWebhookDeleted:        true,
+	EventSecurityVulnerabilityDetected: true,
+	EventSecurityVulnerabilityResolved: true,
 }
 
 // ValidateEventType checks if the given event type is valid.
@@ -147,13 +245,17 @@ func ValidateEventType(et EventType) error {
 type ResourceType string
 
 const (
-	ResourceJob      ResourceType = "job"
-	ResourceServer   ResourceType = "server"
-	ResourceProvider ResourceType = "provider"
-	ResourceSite     ResourceType = "site"
-	ResourceDomain   ResourceType = "domain"
-	ResourceAccount  ResourceType = "account"
-	ResourceAPIKey   ResourceType = "api_key"
+	ResourceJob          ResourceType = "job"
+	ResourceServer       ResourceType = "server"
+	ResourceProvider     ResourceType = "provider"
+	ResourceSite         ResourceType = "site"
+	ResourceDomain       ResourceType = "domain"
+	ResourceAccount      ResourceType = "account"
+	ResourceAPIKey       ResourceType = "api_key"
+	ResourceBackup       ResourceType = "backup"
+	ResourceBackupTarget ResourceType = "backup_target"
+	ResourceSchedule     ResourceType = "schedule"
+	ResourceWebhook      ResourceType = "webhook"
 )
 
 // Activity is a single entry in the activity stream.
@@ -187,6 +289,10 @@ type Activity struct {
 	ReadAt            string `json:"read_at,omitempty"`
 
 	CreatedAt string `json:"created_at"`
+
+	// WorkspaceID is the workspace the entry belongs to. API responses leave
+	// it out; the request already decided the workspace.
+	WorkspaceID string `json:"-"`
 }
 
 // EmitInput is the input for creating a new activity entry.
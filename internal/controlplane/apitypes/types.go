@@ -34,49 +34,121 @@ func ParseAppID(raw string) (string, error) {
 }
 
 var PublishedTypes = map[string]any{
-	"LoginRequest":            LoginRequest{},
-	"StatusResponse":          StatusResponse{},
-	"HealthResponse":          HealthResponse{},
-	"CreateProviderRequest":   CreateProviderRequest{},
-	"ValidateProviderRequest": ValidateProviderRequest{},
-	"CreateProviderResponse":  CreateProviderResponse{},
-	"ProviderType":            provider.Info{},
-	"StoredProvider":          provider.StoredProvider{},
-	"ValidationResult":        provider.ValidationResult{},
-	"CreateServerRequest":     CreateServerRequest{},
-	"CreateSiteRequest":       CreateSiteRequest{},
-	"CreateDomainRequest":     CreateDomainRequest{},
-	"ServerCatalogResponse":   ServerCatalogResponse{},
-	"CreateServerResponse":    CreateServerResponse{},
-	"StoredSite":              StoredSite{},
-	"SiteHealthCheck":         agentcommand.SiteHealthCheck{},
-	"SiteHealthSnapshot":      agentcommand.SiteHealthSnapshot{},
-	"SiteHealthResponse":      SiteHealthResponse{},
-	"StoredDomain":            StoredDomain{},
-	"DeleteSiteResponse":      DeleteSiteResponse{},
-	"DeleteDomainResponse":    DeleteDomainResponse{},
-	"DeleteServerResponse":    DeleteServerResponse{},
-	"UpdateSiteRequest":       UpdateSiteRequest{},
-	"UpdateDomainRequest":     UpdateDomainRequest{},
-	"RebuildOptionsResponse":  RebuildOptionsResponse{},
-	"ResizeOptionsResponse":   ResizeOptionsResponse{},
-	"FirewallsResponse":       FirewallsResponse{},
-	"VolumesResponse":         VolumesResponse{},
-	"ServerProfile":           profiles.Profile{},
-	"ServerCatalog":           provider.ServerCatalog{},
-	"ServerLocation":          provider.ServerLocation{},
-	"ServerTypePrice":         provider.ServerTypePrice{},
-	"ServerTypeOption":        provider.ServerTypeOption{},
-	"StoredServer":            StoredServer{},
-	"AgentInfo":               ws.AgentInfo{},
-	"AgentStatusMapResponse":  AgentStatusMapResponse{},
-	"Service":                 agentcommand.Service{},
-	"ServicesResponse":        ServicesResponse{},
-	"AuthActor":               auth.Actor{},
-	"CreateJobRequest":        CreateJobRequest{},
-	"Job":                     Job{},
-	"JobEvent":                orchestrator.JobEvent{},
-	"Activity":                Activity{},
-	"ActivityListResponse":    ActivityListResponse{},
-	"UnreadCountResponse":     UnreadCountResponse{},
+	"LoginRequest":                         LoginRequest{},
+	"StatusResponse":                       StatusResponse{},
+	"HealthResponse":                       HealthResponse{},
+	"WorkerHealth":                         WorkerHealth{},
+	"WorkerSlotUsage":                      WorkerSlotUsage{},
+	"CreateProviderRequest":                CreateProviderRequest{},
+	"ValidateProviderRequest":              ValidateProviderRequest{},
+	"CreateProviderResponse":               CreateProviderResponse{},
+	"ProviderType":                         provider.Info{},
+	"StoredProvider":                       provider.StoredProvider{},
+	"ValidationResult":                     provider.ValidationResult{},
+	"CreateServerRequest":                  CreateServerRequest{},
+	"CreateSiteRequest":                    CreateSiteRequest{},
+	"CreateDomainRequest":                  CreateDomainRequest{},
+	"ServerCatalogResponse":                ServerCatalogResponse{},
+	"CreateServerResponse":                 CreateServerResponse{},
+	"ConnectServerRequest":                 ConnectServerRequest{},
+	"ServerSSHKeyResponse":                 ServerSSHKeyResponse{},
+	"StoredSite":                           StoredSite{},
+	"SiteHealthCheck":                      agentcommand.SiteHealthCheck{},
+	"SiteHealthSnapshot":                   agentcommand.SiteHealthSnapshot{},
+	"SiteHealthResponse":                   SiteHealthResponse{},
+	"StoredDomain":                         StoredDomain{},
+	"DeleteSiteResponse":                   DeleteSiteResponse{},
+	"DeleteDomainResponse":                 DeleteDomainResponse{},
+	"DeleteServerResponse":                 DeleteServerResponse{},
+	"UpdateSiteRequest":                    UpdateSiteRequest{},
+	"UpdateDomainRequest":                  UpdateDomainRequest{},
+	"RebuildOptionsResponse":               RebuildOptionsResponse{},
+	"ResizeOptionsResponse":                ResizeOptionsResponse{},
+	"FirewallsResponse":                    FirewallsResponse{},
+	"VolumesResponse":                      VolumesResponse{},
+	"ServerProfile":                        profiles.Profile{},
+	"ServerCatalog":                        provider.ServerCatalog{},
+	"ServerLocation":                       provider.ServerLocation{},
+	"ServerTypePrice":                      provider.ServerTypePrice{},
+	"ServerTypeOption":                     provider.ServerTypeOption{},
+	"StoredServer":                         StoredServer{},
+	"AgentInfo":                            ws.AgentInfo{},
+	"AgentStatusMapResponse":               AgentStatusMapResponse{},
+	"Service":                              agentcommand.Service{},
+	"ServicesResponse":                     ServicesResponse{},
+	"AuthActor":                            auth.Actor{},
+	"SiteGrants":                           SiteGrants{},
+	"UpdateSiteGrantsRequest":              UpdateSiteGrantsRequest{},
+	"User":                                 User{},
+	"InviteUserRequest":                    InviteUserRequest{},
+	"UserAccountLink":                      UserAccountLink{},
+	"UpdateUserRequest":                    UpdateUserRequest{},
+	"SetPasswordRequest":                   SetPasswordRequest{},
+	"ChangePasswordRequest":                ChangePasswordRequest{},
+	"UserSession":                          UserSession{},
+	"RevokeSessionsResponse":               RevokeSessionsResponse{},
+	"LoginChallengeResponse":               LoginChallengeResponse{},
+	"LoginTOTPRequest":                     LoginTOTPRequest{},
+	"TOTPStatus":                           TOTPStatus{},
+	"TOTPEnrollmentResponse":               TOTPEnrollmentResponse{},
+	"TOTPCodeRequest":                      TOTPCodeRequest{},
+	"TOTPRecoveryCodesResponse":            TOTPRecoveryCodesResponse{},
+	"AuthPolicy":                           AuthPolicy{},
+	"UpdateAuthPolicyRequest":              UpdateAuthPolicyRequest{},
+	"LoginMethods":                         LoginMethods{},
+	"APIToken":                             APIToken{},
+	"CreateAPITokenRequest":                CreateAPITokenRequest{},
+	"CreateAPITokenResponse":               CreateAPITokenResponse{},
+	"Workspace":                            Workspace{},
+	"CreateWorkspaceRequest":               CreateWorkspaceRequest{},
+	"AddWorkspaceMemberRequest":            AddWorkspaceMemberRequest{},
+	"WebhookSubscription":                  WebhookSubscription{},
+	"WebhookSubscriptionRequest":           WebhookSubscriptionRequest{},
+	"CreateWebhookSubscriptionResponse":    CreateWebhookSubscriptionResponse{},
+	"NotificationPreferences":              NotificationPreferences{},
+	"UpdateNotificationPreferencesRequest": UpdateNotificationPreferencesRequest{},
+	"WebhookDelivery":                      WebhookDelivery{},
+	"CreateJobRequest":                     CreateJobRequest{},
+	"Job":                                  Job{},
+	"JobEvent":                             orchestrator.JobEvent{},
+	"Activity":                             Activity{},
+	"ActivityListResponse":                 ActivityListResponse{},
+	"UnreadCountResponse":                  UnreadCountResponse{},
+	"CreateBackupTargetRequest":            CreateBackupTargetRequest{},
+	"BackupTarget":                         BackupTarget{},
+	"DeleteBackupTargetResponse":           DeleteBackupTargetResponse{},
+	"CreateSiteBackupRequest":              CreateSiteBackupRequest{},
+	"CreateSiteBackupResponse":             CreateSiteBackupResponse{},
+	"CreateSiteRestoreRequest":             CreateSiteRestoreRequest{},
+	"CreateSiteRestoreResponse":            CreateSiteRestoreResponse{},
+	"SiteBackup":                           SiteBackup{},
+	"CreateScheduleRequest":                CreateScheduleRequest{},
+	"UpdateScheduleRequest":                UpdateScheduleRequest{},
+	"Schedule":                             Schedule{},
+	"DeleteScheduleResponse":               DeleteScheduleResponse{},
+	"CreateStagingRequest":                 CreateStagingRequest{},
+	"CreateStagingResponse":                CreateStagingResponse{},
+	"CreateSitePushRequest":                CreateSitePushRequest{},
+	"CreateSitePushResponse":               CreateSitePushResponse{},
+	"PushSiteDiff":                         orchestrator.PushSiteDiff{},
+	"ProviderActionEvent":                  orchestrator.ProviderActionEvent{},
+	"SiteComponent":                        SiteComponent{},
+	"SiteComponentsResponse":               SiteComponentsResponse{},
+	"CreateSiteUpdateRequest":              CreateSiteUpdateRequest{},
+	"CreateSiteUpdateResponse":             CreateSiteUpdateResponse{},
+	"SiteVulnerability":                    SiteVulnerability{},
+	"SiteVulnerabilitiesResponse":          SiteVulnerabilitiesResponse{},
+	"VulnerabilitySeverityCounts":          VulnerabilitySeverityCounts{},
+	"VulnerabilityReport":                  VulnerabilityReport{},
+	"UptimeCheck":                          UptimeCheck{},
+	"UptimeWindow":                         UptimeWindow{},
+	"UptimeHostname":                       UptimeHostname{},
+	"UptimeIncident":                       UptimeIncident{},
+	"SiteUptimeResponse":                   SiteUptimeResponse{},
+	"UpdateUptimeSettingsRequest":          UpdateUptimeSettingsRequest{},
+	"CostTotal":                            CostTotal{},
+	"ServerCost":                           ServerCost{},
+	"SiteCost":                             SiteCost{},
+	"WorkspaceCost":                        WorkspaceCost{},
+	"CostReport":                           CostReport{},
 }
@@ -28,4 +28,100 @@ type HealthResponse struct {
 	Status             string                   `json:"status"`
 	CallbackURLMode    platform.CallbackURLMode `json:"callback_url_mode,omitempty"`
 	CallbackURLWarning string                   `json:"callback_url_warning,omitempty"`
+	Worker             *WorkerHealth            `json:"worker,omitempty"`
+}
+
+// WorkerHealth is the part of WorkerSlotUsage that the unauthenticated
+// health endpoint reports: counts only, no server or provider IDs.
+type WorkerHealth struct {
+	Running            int `json:"running"`
+	MaxJobs            int `json:"max_jobs"`
+	MaxJobsPerProvider int `json:"max_jobs_per_provider"`
+	MaxJobsPerServer   int `json:"max_jobs_per_server"`
+	BusyProviders      int `json:"busy_providers"`
+	BusyServers        int `json:"busy_servers"`
+}
+
+// WorkerSlotUsage is a snapshot of the job worker's concurrency slots, served
+// by GET /api/worker/slots. Providers and servers list only the ones with a
+// running job that the caller's workspace owns.
+type WorkerSlotUsage struct {
+	Running            int            `json:"running"`
+	MaxJobs            int            `json:"max_jobs"`
+	MaxJobsPerProvider int            `json:"max_jobs_per_provider"`
+	MaxJobsPerServer   int            `json:"max_jobs_per_server"`
+	Providers          map[string]int `json:"providers"`
+	Servers            map[string]int `json:"servers"`
+}
+
+// LoginChallengeResponse is returned by POST /api/auth/login instead of the
+// actor when the user has TOTP enabled. The challenge and a code are then
+// posted to /api/auth/login/totp.
+type LoginChallengeResponse struct {
+	TOTPRequired bool   `json:"totp_required"`
+	Challenge    string `json:"challenge"`
+	ExpiresAt    string `json:"expires_at"`
+}
+
+type LoginTOTPRequest struct {
+	Challenge string `json:"challenge"`
+	// Code is a current TOTP code or one of the user's recovery codes.
+	Code string `json:"code"`
+}
+
+func (r *LoginTOTPRequest) Validate() error {
+	r.Challenge = strings.TrimSpace(r.Challenge)
+	r.Code = strings.TrimSpace(r.Code)
+	if r.Challenge == "" || r.Code == "" {
+		return fmt.Errorf("challenge and code are required")
+	}
+	return nil
+}
+
+type TOTPStatus struct {
+	Enabled                bool   `json:"enabled"`
+	EnabledAt              string `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int    `json:"recovery_codes_remaining"`
+	RequiredByPolicy       bool   `json:"required_by_policy"`
+}
+
+// TOTPEnrollmentResponse carries the new secret; provisioning_uri is meant
+// to be rendered as a QR code.
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (r *TOTPCodeRequest) Validate() error {
+	r.Code = strings.TrimSpace(r.Code)
+	if r.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	return nil
+}
+
+// TOTPRecoveryCodesResponse lists recovery codes. They are only shown once.
+type TOTPRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type AuthPolicy struct {
+	RequireTOTP bool   `json:"require_totp"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
+
+type UpdateAuthPolicyRequest struct {
+	RequireTOTP bool `json:"require_totp"`
+}
+
+// LoginMethods tells the login page which sign-in options to offer. With
+// OIDC enabled, the browser starts single sign-on at /api/auth/oidc/login.
+type LoginMethods struct {
+	Password bool   `json:"password"`
+	OIDC     bool   `json:"oidc"`
+	OIDCName string `json:"oidc_name,omitempty"`
 }
@@ -0,0 +1,132 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+type CreateBackupTargetRequest struct {
+	Name            string `json:"name"`
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region,omitempty"`
+	Bucket          string `json:"bucket"`
+	PathPrefix      string `json:"path_prefix,omitempty"`
+	PathStyle       bool   `json:"path_style"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	RetentionDays   int    `json:"retention_days,omitempty"`
+}
+
+func (r *CreateBackupTargetRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Endpoint = strings.TrimSpace(r.Endpoint)
+	r.Region = strings.TrimSpace(r.Region)
+	r.Bucket = strings.TrimSpace(r.Bucket)
+	r.PathPrefix = strings.TrimSpace(r.PathPrefix)
+	r.AccessKeyID = strings.TrimSpace(r.AccessKeyID)
+	r.SecretAccessKey = strings.TrimSpace(r.SecretAccessKey)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	if r.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if r.AccessKeyID == "" {
+		return fmt.Errorf("access_key_id is required")
+	}
+	if r.SecretAccessKey == "" {
+		return fmt.Errorf("secret_access_key is required")
+	}
+	if r.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	return nil
+}
+
+type BackupTarget struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Endpoint      string `json:"endpoint"`
+	Region        string `json:"region"`
+	Bucket        string `json:"bucket"`
+	PathPrefix    string `json:"path_prefix,omitempty"`
+	PathStyle     bool   `json:"path_style"`
+	AccessKeyID   string `json:"access_key_id"`
+	RetentionDays int    `json:"retention_days"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type DeleteBackupTargetResponse struct {
+	TargetID    string `json:"target_id"`
+	Deleted     bool   `json:"deleted"`
+	Description string `json:"description"`
+}
+
+type CreateSiteBackupRequest struct {
+	TargetID      string `json:"target_id"`
+	RetentionDays int    `json:"retention_days,omitempty"`
+}
+
+func (r *CreateSiteBackupRequest) Validate() error {
+	r.TargetID = strings.TrimSpace(r.TargetID)
+	if r.TargetID == "" {
+		return fmt.Errorf("target_id is required")
+	}
+	if r.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	return nil
+}
+
+type CreateSiteBackupResponse struct {
+	SiteID    string                 `json:"site_id"`
+	JobID     string                 `json:"job_id"`
+	JobStatus orchestrator.JobStatus `json:"job_status"`
+}
+
+// CreateSiteRestoreRequest restores a completed backup onto the site named in
+// the URL. The backup may come from a different site or server.
+type CreateSiteRestoreRequest struct {
+	BackupID string `json:"backup_id"`
+}
+
+func (r *CreateSiteRestoreRequest) Validate() error {
+	r.BackupID = strings.TrimSpace(r.BackupID)
+	if r.BackupID == "" {
+		return fmt.Errorf("backup_id is required")
+	}
+	return nil
+}
+
+type CreateSiteRestoreResponse struct {
+	SiteID    string                 `json:"site_id"`
+	BackupID  string                 `json:"backup_id"`
+	JobID     string                 `json:"job_id"`
+	JobStatus orchestrator.JobStatus `json:"job_status"`
+}
+
+type SiteBackup struct {
+	ID             string `json:"id"`
+	SiteID         string `json:"site_id"`
+	ServerID       string `json:"server_id"`
+	TargetID       string `json:"target_id"`
+	TargetName     string `json:"target_name"`
+	JobID          string `json:"job_id,omitempty"`
+	Status         string `json:"status"`
+	ObjectKey      string `json:"object_key"`
+	SizeBytes      int64  `json:"size_bytes"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+	RetentionDays  int    `json:"retention_days"`
+	ExpiresAt      string `json:"expires_at,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	StartedAt      string `json:"started_at,omitempty"`
+	CompletedAt    string `json:"completed_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
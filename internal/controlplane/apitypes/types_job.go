@@ -23,38 +23,40 @@ func (r *CreateJobRequest) Validate() error {
 }
 
 type Job struct {
-	ID          string                 `json:"id"`
-	ServerID    string                 `json:"server_id,omitempty"`
-	Kind        string                 `json:"kind"`
-	Status      orchestrator.JobStatus `json:"status"`
-	CurrentStep string                 `json:"current_step"`
-	RetryCount  int                    `json:"retry_count"`
-	LastError   string                 `json:"last_error,omitempty"`
-	Payload     string                 `json:"payload,omitempty"`
-	StartedAt   string                 `json:"started_at,omitempty"`
-	FinishedAt  string                 `json:"finished_at,omitempty"`
-	TimeoutAt   string                 `json:"timeout_at,omitempty"`
-	CreatedAt   string                 `json:"created_at"`
-	UpdatedAt   string                 `json:"updated_at"`
-	CommandID   *string                `json:"command_id,omitempty"`
+	ID            string                 `json:"id"`
+	ServerID      string                 `json:"server_id,omitempty"`
+	Kind          string                 `json:"kind"`
+	Status        orchestrator.JobStatus `json:"status"`
+	CurrentStep   string                 `json:"current_step"`
+	RetryCount    int                    `json:"retry_count"`
+	LastError     string                 `json:"last_error,omitempty"`
+	Payload       string                 `json:"payload,omitempty"`
+	StartedAt     string                 `json:"started_at,omitempty"`
+	FinishedAt    string                 `json:"finished_at,omitempty"`
+	TimeoutAt     string                 `json:"timeout_at,omitempty"`
+	CreatedAt     string                 `json:"created_at"`
+	UpdatedAt     string                 `json:"updated_at"`
+	CommandID     *string                `json:"command_id,omitempty"`
+	NextAttemptAt string                 `json:"next_attempt_at,omitempty"`
 }
 
 func APIJob(in orchestrator.Job) Job {
 	return Job{
-		ID:          in.ID,
-		ServerID:    FormatAppID(in.ServerID),
-		Kind:        in.Kind,
-		Status:      in.Status,
-		CurrentStep: in.CurrentStep,
-		RetryCount:  in.RetryCount,
-		LastError:   in.LastError,
-		Payload:     in.Payload,
-		StartedAt:   in.StartedAt,
-		FinishedAt:  in.FinishedAt,
-		TimeoutAt:   in.TimeoutAt,
-		CreatedAt:   in.CreatedAt,
-		UpdatedAt:   in.UpdatedAt,
-		CommandID:   in.CommandID,
+		ID:            in.ID,
+		ServerID:      FormatAppID(in.ServerID),
+		Kind:          in.Kind,
+		Status:        in.Status,
+		CurrentStep:   in.CurrentStep,
+		RetryCount:    in.RetryCount,
+		LastError:     in.LastError,
+		Payload:       in.Payload,
+		StartedAt:     in.StartedAt,
+		FinishedAt:    in.FinishedAt,
+		TimeoutAt:     in.TimeoutAt,
+		CreatedAt:     in.CreatedAt,
+		UpdatedAt:     in.UpdatedAt,
+		CommandID:     in.CommandID,
+		NextAttemptAt: in.NextAttemptAt,
 	}
 }
 
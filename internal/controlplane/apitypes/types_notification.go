@@ -0,0 +1,38 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+)
+
+// NotificationPreferences are the signed-in user's email notification
+// settings. Delivery is immediate, hourly or daily; digests only list
+// activity nobody has marked read.
+type NotificationPreferences struct {
+	EmailEnabled bool                 `json:"email_enabled"`
+	EventTypes   []activity.EventType `json:"event_types"`
+	Delivery     string               `json:"delivery"`
+	// AvailableEventTypes are the events that can be subscribed to.
+	AvailableEventTypes []activity.EventType `json:"available_event_types"`
+	// EmailConfigured is false while the control plane has no SMTP relay;
+	// preferences are kept but nothing is sent.
+	EmailConfigured bool `json:"email_configured"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	EmailEnabled bool                 `json:"email_enabled"`
+	EventTypes   []activity.EventType `json:"event_types"`
+	Delivery     string               `json:"delivery,omitempty"`
+}
+
+func (r *UpdateNotificationPreferencesRequest) Validate() error {
+	r.Delivery = strings.TrimSpace(r.Delivery)
+	switch r.Delivery {
+	case "", "immediate", "hourly", "daily":
+	default:
+		return fmt.Errorf("delivery must be immediate, hourly or daily")
+	}
+	return nil
+}
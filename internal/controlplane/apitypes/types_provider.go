@@ -16,8 +16,11 @@ type CreateProviderRequest struct {
 func (r *CreateProviderRequest) Validate() error {
 	r.Type = strings.TrimSpace(r.Type)
 	r.Name = strings.TrimSpace(r.Name)
-	if r.Type == "" || r.Name == "" || strings.TrimSpace(r.APIToken) == "" {
-		return fmt.Errorf("type, name, and api_token are required")
+	if r.Type == "" || r.Name == "" {
+		return fmt.Errorf("type and name are required")
+	}
+	if strings.TrimSpace(r.APIToken) == "" && provider.RequiresAPIToken(r.Type) {
+		return fmt.Errorf("api_token is required for %s providers", r.Type)
 	}
 	return nil
 }
@@ -29,8 +32,11 @@ type ValidateProviderRequest struct {
 
 func (r *ValidateProviderRequest) Validate() error {
 	r.Type = strings.TrimSpace(r.Type)
-	if r.Type == "" || strings.TrimSpace(r.APIToken) == "" {
-		return fmt.Errorf("type and api_token are required")
+	if r.Type == "" {
+		return fmt.Errorf("type is required")
+	}
+	if strings.TrimSpace(r.APIToken) == "" && provider.RequiresAPIToken(r.Type) {
+		return fmt.Errorf("api_token is required for %s providers", r.Type)
 	}
 	return nil
 }
@@ -0,0 +1,59 @@
+package apitypes
+
+// Cost amounts are decimal strings with two places, e.g. "12.34", in the
+// currency next to them. They are estimates from the provider list prices
+// recorded when servers were provisioned or resized.
+
+// CostTotal is the cost of every reported server in one currency.
+type CostTotal struct {
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+// ServerCost is one server's estimated cost in the month. ServerType is the
+// type it had last; Hours counts the started hours it was billed for.
+type ServerCost struct {
+	ServerID      string `json:"server_id"`
+	ServerName    string `json:"server_name"`
+	ProviderType  string `json:"provider_type"`
+	ServerType    string `json:"server_type"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	Currency      string `json:"currency"`
+	Hours         int64  `json:"hours"`
+	Amount        string `json:"amount"`
+	Sites         int    `json:"sites"`
+}
+
+// SiteCost is a site's even share of its server's cost.
+type SiteCost struct {
+	SiteID        string `json:"site_id"`
+	SiteName      string `json:"site_name"`
+	ServerID      string `json:"server_id"`
+	ServerName    string `json:"server_name"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	Currency      string `json:"currency"`
+	Amount        string `json:"amount"`
+}
+
+// WorkspaceCost is the cost of a workspace's servers in one currency.
+type WorkspaceCost struct {
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+	Currency      string `json:"currency"`
+	Servers       int    `json:"servers"`
+	Sites         int    `json:"sites"`
+	Amount        string `json:"amount"`
+}
+
+// CostReport estimates hosting costs for one calendar month (UTC), formatted
+// YYYY-MM. The running month is estimated up to GeneratedAt.
+type CostReport struct {
+	Month       string          `json:"month"`
+	GeneratedAt string          `json:"generated_at"`
+	Totals      []CostTotal     `json:"totals"`
+	Workspaces  []WorkspaceCost `json:"workspaces"`
+	Servers     []ServerCost    `json:"servers"`
+	Sites       []SiteCost      `json:"sites"`
+}
@@ -0,0 +1,103 @@
+package apitypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CreateScheduleRequest defines a recurring job. Payload is validated against
+// JobKind exactly like POST /api/jobs.
+type CreateScheduleRequest struct {
+	Name            string          `json:"name"`
+	JobKind         string          `json:"job_kind"`
+	ServerID        string          `json:"server_id,omitempty"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+	CronExpression  string          `json:"cron_expression"`
+	Timezone        string          `json:"timezone,omitempty"`
+	JitterSeconds   int             `json:"jitter_seconds,omitempty"`
+	MissedRunPolicy string          `json:"missed_run_policy,omitempty"`
+	Paused          bool            `json:"paused"`
+}
+
+func (r *CreateScheduleRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.JobKind = strings.TrimSpace(r.JobKind)
+	r.ServerID = strings.TrimSpace(r.ServerID)
+	r.CronExpression = strings.TrimSpace(r.CronExpression)
+	r.Timezone = strings.TrimSpace(r.Timezone)
+	r.MissedRunPolicy = strings.TrimSpace(r.MissedRunPolicy)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.JobKind == "" {
+		return fmt.Errorf("job_kind is required")
+	}
+	if r.CronExpression == "" {
+		return fmt.Errorf("cron_expression is required")
+	}
+	if r.JitterSeconds < 0 {
+		return fmt.Errorf("jitter_seconds must not be negative")
+	}
+	return nil
+}
+
+// UpdateScheduleRequest changes an existing schedule. Omitted fields keep
+// their current value; job_kind and server_id cannot be changed.
+type UpdateScheduleRequest struct {
+	Name            *string         `json:"name,omitempty"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+	CronExpression  *string         `json:"cron_expression,omitempty"`
+	Timezone        *string         `json:"timezone,omitempty"`
+	JitterSeconds   *int            `json:"jitter_seconds,omitempty"`
+	MissedRunPolicy *string         `json:"missed_run_policy,omitempty"`
+}
+
+func (r *UpdateScheduleRequest) Validate() error {
+	trim := func(value **string) {
+		if *value == nil {
+			return
+		}
+		trimmed := strings.TrimSpace(**value)
+		*value = &trimmed
+	}
+	trim(&r.Name)
+	trim(&r.CronExpression)
+	trim(&r.Timezone)
+	trim(&r.MissedRunPolicy)
+	if r.Name != nil && *r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.CronExpression != nil && *r.CronExpression == "" {
+		return fmt.Errorf("cron_expression is required")
+	}
+	if r.JitterSeconds != nil && *r.JitterSeconds < 0 {
+		return fmt.Errorf("jitter_seconds must not be negative")
+	}
+	return nil
+}
+
+type Schedule struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	JobKind         string `json:"job_kind"`
+	ServerID        string `json:"server_id,omitempty"`
+	Payload         string `json:"payload,omitempty"`
+	CronExpression  string `json:"cron_expression"`
+	Timezone        string `json:"timezone"`
+	JitterSeconds   int    `json:"jitter_seconds"`
+	MissedRunPolicy string `json:"missed_run_policy"`
+	Paused          bool   `json:"paused"`
+	NextRunAt       string `json:"next_run_at,omitempty"`
+	LastRunAt       string `json:"last_run_at,omitempty"`
+	LastJobID       string `json:"last_job_id,omitempty"`
+	LastError       string `json:"last_error,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+type DeleteScheduleResponse struct {
+	ScheduleID  string `json:"schedule_id"`
+	Deleted     bool   `json:"deleted"`
+	Description string `json:"description"`
+}
@@ -9,43 +9,81 @@ import (
 	"pressluft/internal/shared/ws"
 )
 
+// CreateServerRequest creates a server. For providers that adopt existing
+// servers, IPv4, SSHPort and SSHUser say how to reach the machine and
+// ServerType is not used; Password, when set, is used once to install
+// Pressluft's key and is never stored.
 type CreateServerRequest struct {
 	ProviderID string `json:"provider_id"`
 	Name       string `json:"name"`
 	Location   string `json:"location"`
 	ServerType string `json:"server_type"`
 	ProfileKey string `json:"profile_key"`
+	IPv4       string `json:"ipv4,omitempty"`
+	SSHPort    int    `json:"ssh_port,omitempty"`
+	SSHUser    string `json:"ssh_user,omitempty"`
+	// SSHHostKeyFingerprint is the SHA256 fingerprint of an adopted
+	// server's ED25519 host key. It is required with Password, which is only
+	// sent to a host presenting that key.
+	SSHHostKeyFingerprint string `json:"ssh_host_key_fingerprint,omitempty"`
+	Password              string `json:"password,omitempty"`
 }
 
+// CreateServerResponse is returned when a server is created. JobID is empty
+// for an adopted server that waits for its key to be installed; SSHPublicKey
+// is the key to install.
 type CreateServerResponse struct {
-	ServerID string                `json:"server_id"`
-	JobID    string                `json:"job_id"`
-	Status   platform.ServerStatus `json:"status"`
+	ServerID     string                `json:"server_id"`
+	JobID        string                `json:"job_id"`
+	Status       platform.ServerStatus `json:"status"`
+	SSHPublicKey string                `json:"ssh_public_key,omitempty"`
+}
+
+// ConnectServerRequest starts connecting an adopted server. Password, when
+// set, is used once to install Pressluft's key and is never stored. It is
+// only sent to a host presenting the recorded host key, or the one named by
+// SSHHostKeyFingerprint when none has been recorded yet.
+type ConnectServerRequest struct {
+	SSHHostKeyFingerprint string `json:"ssh_host_key_fingerprint,omitempty"`
+	Password              string `json:"password,omitempty"`
+}
+
+// ServerSSHKeyResponse is the public key an adopted server must trust, with
+// a command that installs it for the configured user.
+type ServerSSHKeyResponse struct {
+	ServerID       string `json:"server_id"`
+	PublicKey      string `json:"public_key"`
+	SSHUser        string `json:"ssh_user"`
+	SSHPort        int    `json:"ssh_port"`
+	InstallCommand string `json:"install_command"`
 }
 
 type StoredServer struct {
-	ID               string                `json:"id"`
-	ProviderID       string                `json:"provider_id"`
-	ProviderType     string                `json:"provider_type"`
-	ProviderServerID string                `json:"provider_server_id,omitempty"`
-	IPv4             string                `json:"ipv4,omitempty"`
-	IPv6             string                `json:"ipv6,omitempty"`
-	Name             string                `json:"name"`
-	Location         string                `json:"location"`
-	ServerType       string                `json:"server_type"`
-	Image            string                `json:"image"`
-	ProfileKey       string                `json:"profile_key"`
-	Status           platform.ServerStatus `json:"status"`
-	SetupState       platform.SetupState   `json:"setup_state"`
-	SetupLastError   string                `json:"setup_last_error,omitempty"`
-	ActionID         string                `json:"action_id,omitempty"`
-	ActionStatus     string                `json:"action_status,omitempty"`
-	HasKey           bool                  `json:"has_key"`
-	NodeStatus       platform.NodeStatus   `json:"node_status,omitempty"`
-	NodeLastSeen     string                `json:"node_last_seen,omitempty"`
-	NodeVersion      string                `json:"node_version,omitempty"`
-	CreatedAt        string                `json:"created_at"`
-	UpdatedAt        string                `json:"updated_at"`
+	ID                    string                `json:"id"`
+	ProviderID            string                `json:"provider_id"`
+	ProviderType          string                `json:"provider_type"`
+	ProviderServerID      string                `json:"provider_server_id,omitempty"`
+	IPv4                  string                `json:"ipv4,omitempty"`
+	IPv6                  string                `json:"ipv6,omitempty"`
+	Name                  string                `json:"name"`
+	Location              string                `json:"location"`
+	ServerType            string                `json:"server_type"`
+	Image                 string                `json:"image"`
+	ProfileKey            string                `json:"profile_key"`
+	Status                platform.ServerStatus `json:"status"`
+	SetupState            platform.SetupState   `json:"setup_state"`
+	SetupLastError        string                `json:"setup_last_error,omitempty"`
+	ActionID              string                `json:"action_id,omitempty"`
+	ActionStatus          string                `json:"action_status,omitempty"`
+	HasKey                bool                  `json:"has_key"`
+	NodeStatus            platform.NodeStatus   `json:"node_status,omitempty"`
+	NodeLastSeen          string                `json:"node_last_seen,omitempty"`
+	NodeVersion           string                `json:"node_version,omitempty"`
+	SSHPort               int                   `json:"ssh_port"`
+	SSHUser               string                `json:"ssh_user"`
+	SSHHostKeyFingerprint string                `json:"ssh_host_key_fingerprint,omitempty"`
+	CreatedAt             string                `json:"created_at"`
+	UpdatedAt             string                `json:"updated_at"`
 }
 
 type DeleteServerResponse struct {
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/orchestration/orchestrator"
 )
 
 type CreateSiteRequest struct {
@@ -154,10 +155,114 @@ type StoredSite struct {
 	WordPressPath       string `json:"wordpress_path,omitempty"`
 	PHPVersion          string `json:"php_version,omitempty"`
 	WordPressVersion    string `json:"wordpress_version,omitempty"`
+	Environment         string `json:"environment"`
+	ParentSiteID        string `json:"parent_site_id,omitempty"`
+	BasicAuthUsername   string `json:"basic_auth_username,omitempty"`
 	CreatedAt           string `json:"created_at"`
 	UpdatedAt           string `json:"updated_at"`
 }
 
+// CreateStagingRequest clones the production site named in the URL into a new
+// staging site on the same server. Label becomes the first label of the
+// staging site's fallback resolver hostname.
+type CreateStagingRequest struct {
+	Name  string `json:"name,omitempty"`
+	Label string `json:"label,omitempty"`
+}
+
+func (r *CreateStagingRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Label = strings.TrimSpace(r.Label)
+	if strings.Contains(r.Label, ".") {
+		return fmt.Errorf("label must be a single subdomain label")
+	}
+	return nil
+}
+
+// CreateStagingResponse is returned once when a staging site is created. The
+// basic auth password is only revealed here; the control plane stores it
+// encrypted.
+type CreateStagingResponse struct {
+	Site              StoredSite             `json:"site"`
+	ParentSiteID      string                 `json:"parent_site_id"`
+	JobID             string                 `json:"job_id"`
+	JobStatus         orchestrator.JobStatus `json:"job_status"`
+	BasicAuthUsername string                 `json:"basic_auth_username"`
+	BasicAuthPassword string                 `json:"basic_auth_password"`
+}
+
+// CreateSitePushRequest pushes the staging site named in the URL back onto its
+// production parent. Scope defaults to "all". Table filters accept `*` globs
+// and only apply when the database is pushed.
+type CreateSitePushRequest struct {
+	Scope         string   `json:"scope,omitempty"`
+	IncludeTables []string `json:"include_tables,omitempty"`
+	ExcludeTables []string `json:"exclude_tables,omitempty"`
+	DryRun        bool     `json:"dry_run,omitempty"`
+}
+
+func (r *CreateSitePushRequest) Validate() error {
+	r.Scope = strings.ToLower(strings.TrimSpace(r.Scope))
+	switch r.Scope {
+	case "", orchestrator.PushScopeAll, orchestrator.PushScopeFiles, orchestrator.PushScopeDatabase:
+		return nil
+	default:
+		return fmt.Errorf("scope must be one of all, files, database")
+	}
+}
+
+type CreateSitePushResponse struct {
+	SiteID       string                 `json:"site_id"`
+	TargetSiteID string                 `json:"target_site_id"`
+	JobID        string                 `json:"job_id"`
+	JobStatus    orchestrator.JobStatus `json:"job_status"`
+	DryRun       bool                   `json:"dry_run"`
+}
+
+// SiteComponent is one WordPress core, plugin, or theme install from a site's
+// last inventory. UpdateVersion is empty when the component is current.
+type SiteComponent struct {
+	SiteID        string `json:"site_id"`
+	SiteName      string `json:"site_name,omitempty"`
+	ServerID      string `json:"server_id,omitempty"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	Title         string `json:"title,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Version       string `json:"version"`
+	UpdateVersion string `json:"update_version,omitempty"`
+	CheckedAt     string `json:"checked_at"`
+}
+
+type SiteComponentsResponse struct {
+	SiteID     string          `json:"site_id"`
+	CheckedAt  string          `json:"checked_at,omitempty"`
+	Components []SiteComponent `json:"components"`
+}
+
+// CreateSiteUpdateRequest selects the components to update. Plugins and
+// themes take slugs, or a single "*" for every install with an update.
+// CheckPaths are compared before and after the update and default to "/".
+type CreateSiteUpdateRequest struct {
+	Core       bool     `json:"core,omitempty"`
+	Plugins    []string `json:"plugins,omitempty"`
+	Themes     []string `json:"themes,omitempty"`
+	CheckPaths []string `json:"check_paths,omitempty"`
+}
+
+func (r *CreateSiteUpdateRequest) Validate() error {
+	if !r.Core && len(r.Plugins) == 0 && len(r.Themes) == 0 {
+		return fmt.Errorf("select core, plugins, or themes to update")
+	}
+	return nil
+}
+
+type CreateSiteUpdateResponse struct {
+	SiteID    string                 `json:"site_id"`
+	JobID     string                 `json:"job_id"`
+	JobStatus orchestrator.JobStatus `json:"job_status"`
+}
+
 type SiteHealthResponse struct {
 	SiteID         string                           `json:"site_id"`
 	AgentConnected bool                             `json:"agent_connected"`
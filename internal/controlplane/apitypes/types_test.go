@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"pressluft/internal/controlplane/activity"
+	_ "pressluft/internal/infra/provider/custom"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/shared/idutil"
 )
@@ -112,6 +113,17 @@ func TestCreateProviderRequest_Validate_WhitespaceAPIToken(t *testing.T) {
 	}
 }
 
+func TestCreateProviderRequest_Validate_CustomNeedsNoAPIToken(t *testing.T) {
+	r := &CreateProviderRequest{Type: "custom", Name: "office servers"}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v := &ValidateProviderRequest{Type: "custom"}
+	if err := v.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestCreateProviderRequest_Validate_WhitespaceType(t *testing.T) {
 	r := &CreateProviderRequest{Type: "   ", Name: "my-provider", APIToken: "tok-123"}
 	if err := r.Validate(); err == nil {
@@ -1273,3 +1285,135 @@ func TestSitePrimaryHostnameConfig_Validate_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+// --- CreateBackupTargetRequest ---
+
+func TestCreateBackupTargetRequest_Validate_TrimsFields(t *testing.T) {
+	r := &CreateBackupTargetRequest{Name: " Offsite ", Endpoint: " https://s3.example.test ", Bucket: " backups ", AccessKeyID: " key ", SecretAccessKey: " secret "}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Name != "Offsite" || r.Endpoint != "https://s3.example.test" || r.Bucket != "backups" || r.AccessKeyID != "key" || r.SecretAccessKey != "secret" {
+		t.Fatalf("request = %+v, want trimmed fields", r)
+	}
+}
+
+func TestCreateBackupTargetRequest_Validate_RequiresCredentials(t *testing.T) {
+	r := &CreateBackupTargetRequest{Name: "Offsite", Endpoint: "https://s3.example.test", Bucket: "backups", AccessKeyID: "key", SecretAccessKey: "   "}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for whitespace-only secret_access_key")
+	}
+}
+
+func TestCreateBackupTargetRequest_Validate_NegativeRetention(t *testing.T) {
+	r := &CreateBackupTargetRequest{Name: "Offsite", Endpoint: "https://s3.example.test", Bucket: "backups", AccessKeyID: "key", SecretAccessKey: "secret", RetentionDays: -1}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for negative retention_days")
+	}
+}
+
+// --- CreateSiteBackupRequest ---
+
+func TestCreateSiteBackupRequest_Validate_EmptyTarget(t *testing.T) {
+	r := &CreateSiteBackupRequest{TargetID: "  "}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for empty target_id")
+	}
+}
+
+// --- CreateSiteRestoreRequest ---
+
+func TestCreateSiteRestoreRequest_Validate_EmptyBackup(t *testing.T) {
+	r := &CreateSiteRestoreRequest{BackupID: " "}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for empty backup_id")
+	}
+}
+
+// --- CreateStagingRequest ---
+
+func TestCreateStagingRequest_Validate_TrimsFields(t *testing.T) {
+	r := &CreateStagingRequest{Name: " Shop staging ", Label: " shop-staging "}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Name != "Shop staging" || r.Label != "shop-staging" {
+		t.Fatalf("request = %+v, want trimmed fields", r)
+	}
+}
+
+func TestCreateStagingRequest_Validate_RejectsDottedLabel(t *testing.T) {
+	r := &CreateStagingRequest{Label: "staging.example"}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for dotted label")
+	}
+}
+
+// --- CreateSitePushRequest ---
+
+func TestCreateSitePushRequest_Validate_NormalizesScope(t *testing.T) {
+	r := &CreateSitePushRequest{Scope: " Database "}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Scope != "database" {
+		t.Fatalf("scope = %q, want database", r.Scope)
+	}
+}
+
+func TestCreateSitePushRequest_Validate_RejectsUnknownScope(t *testing.T) {
+	r := &CreateSitePushRequest{Scope: "uploads"}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for unknown scope")
+	}
+}
+
+// --- CreateSiteUpdateRequest ---
+
+func TestCreateSiteUpdateRequest_Validate_RequiresSelection(t *testing.T) {
+	r := &CreateSiteUpdateRequest{CheckPaths: []string{"/shop"}}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error without core, plugins, or themes")
+	}
+	r.Plugins = []string{"*"}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// --- CreateScheduleRequest ---
+
+func TestCreateScheduleRequest_Validate_TrimsFields(t *testing.T) {
+	r := &CreateScheduleRequest{Name: " Nightly backup ", JobKind: " backup_site ", CronExpression: " 0 3 * * * ", Timezone: " Europe/Berlin "}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Name != "Nightly backup" || r.JobKind != "backup_site" || r.CronExpression != "0 3 * * *" || r.Timezone != "Europe/Berlin" {
+		t.Fatalf("request = %+v, want trimmed fields", r)
+	}
+}
+
+func TestCreateScheduleRequest_Validate_RequiresCronExpression(t *testing.T) {
+	r := &CreateScheduleRequest{Name: "Nightly backup", JobKind: "backup_site", CronExpression: "  "}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for empty cron_expression")
+	}
+}
+
+// --- UpdateScheduleRequest ---
+
+func TestUpdateScheduleRequest_Validate_RejectsEmptyName(t *testing.T) {
+	name := "  "
+	r := &UpdateScheduleRequest{Name: &name}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for whitespace-only name")
+	}
+}
+
+func TestUpdateScheduleRequest_Validate_NegativeJitter(t *testing.T) {
+	jitter := -5
+	r := &UpdateScheduleRequest{JitterSeconds: &jitter}
+	if err := r.Validate(); err == nil {
+		t.Fatal("expected error for negative jitter_seconds")
+	}
+}
@@ -0,0 +1,54 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+
+	"pressluft/internal/controlplane/auth"
+)
+
+// APIToken describes an API token without its secret. Personal tokens act as
+// their user; service tokens carry their own role.
+type APIToken struct {
+	ID         string            `json:"id"`
+	UserID     string            `json:"user_id"`
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Role       auth.Role         `json:"role"`
+	Scopes     []auth.Capability `json:"scopes"`
+	Prefix     string            `json:"prefix"`
+	CreatedAt  string            `json:"created_at"`
+	ExpiresAt  string            `json:"expires_at"`
+	LastUsedAt string            `json:"last_used_at,omitempty"`
+	LastUsedIP string            `json:"last_used_ip,omitempty"`
+	RevokedAt  string            `json:"revoked_at,omitempty"`
+}
+
+type CreateAPITokenRequest struct {
+	Name string `json:"name"`
+	// Kind is "personal" (the default) or "service".
+	Kind string `json:"kind,omitempty"`
+	// Role is required for service tokens and ignored for personal ones.
+	Role auth.Role `json:"role,omitempty"`
+	// Scopes narrow the role; leave empty to keep every capability of it.
+	Scopes        []auth.Capability `json:"scopes,omitempty"`
+	ExpiresInDays int               `json:"expires_in_days,omitempty"`
+}
+
+func (r *CreateAPITokenRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Kind = strings.TrimSpace(r.Kind)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.ExpiresInDays < 0 {
+		return fmt.Errorf("expires_in_days must not be negative")
+	}
+	return nil
+}
+
+// CreateAPITokenResponse carries the token secret. It is only returned once.
+type CreateAPITokenResponse struct {
+	Token  APIToken `json:"token"`
+	Secret string   `json:"secret"`
+}
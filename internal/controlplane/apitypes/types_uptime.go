@@ -0,0 +1,68 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxUptimeKeywordLength mirrors the store's limit on probe keywords.
+const maxUptimeKeywordLength = 200
+
+// UptimeCheck is one external HTTPS probe of a hostname. KeywordMatch is
+// omitted when the site has no keyword configured.
+type UptimeCheck struct {
+	Hostname     string `json:"hostname"`
+	CheckedAt    string `json:"checked_at"`
+	Up           bool   `json:"up"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	LatencyMS    int64  `json:"latency_ms"`
+	TLSExpiresAt string `json:"tls_expires_at,omitempty"`
+	KeywordMatch *bool  `json:"keyword_match,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// UptimeWindow is the share of successful probes over a trailing window.
+type UptimeWindow struct {
+	Window        string  `json:"window"`
+	Checks        int     `json:"checks"`
+	UptimePercent float64 `json:"uptime_percent"`
+	AvgLatencyMS  int64   `json:"avg_latency_ms"`
+}
+
+// UptimeHostname is the probe state of one of a site's hostnames.
+type UptimeHostname struct {
+	Hostname  string         `json:"hostname"`
+	LastCheck *UptimeCheck   `json:"last_check,omitempty"`
+	Windows   []UptimeWindow `json:"windows"`
+}
+
+// UptimeIncident spans from the first failed probe of an outage to the probe
+// that saw the hostname recover. ResolvedAt is empty while it is open.
+type UptimeIncident struct {
+	ID         string `json:"id"`
+	Hostname   string `json:"hostname"`
+	Cause      string `json:"cause"`
+	StartedAt  string `json:"started_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+}
+
+type SiteUptimeResponse struct {
+	SiteID        string           `json:"site_id"`
+	Enabled       bool             `json:"enabled"`
+	Keyword       string           `json:"keyword,omitempty"`
+	Hostnames     []UptimeHostname `json:"hostnames"`
+	OpenIncidents []UptimeIncident `json:"open_incidents"`
+}
+
+type UpdateUptimeSettingsRequest struct {
+	Enabled *bool  `json:"enabled,omitempty"`
+	Keyword string `json:"keyword"`
+}
+
+func (r *UpdateUptimeSettingsRequest) Validate() error {
+	r.Keyword = strings.TrimSpace(r.Keyword)
+	if len(r.Keyword) > maxUptimeKeywordLength {
+		return fmt.Errorf("keyword must be at most %d characters", maxUptimeKeywordLength)
+	}
+	return nil
+}
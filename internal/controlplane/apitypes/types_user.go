@@ -0,0 +1,132 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+
+	"pressluft/internal/controlplane/auth"
+)
+
+// SiteGrants lists the sites a user is limited to. Clients only ever see
+// granted sites; developers and viewers are limited once they have any.
+type SiteGrants struct {
+	UserID  string   `json:"user_id"`
+	SiteIDs []string `json:"site_ids"`
+}
+
+type UpdateSiteGrantsRequest struct {
+	SiteIDs []string `json:"site_ids"`
+}
+
+func (r *UpdateSiteGrantsRequest) Validate() error {
+	for i, siteID := range r.SiteIDs {
+		parsed, err := ParseAppID(siteID)
+		if err != nil {
+			return fmt.Errorf("site_ids[%d] must be a valid app id", i)
+		}
+		r.SiteIDs[i] = parsed
+	}
+	return nil
+}
+
+// User is a control-plane account. Status is "active", "invited" (has not
+// accepted the invitation yet) or "suspended".
+type User struct {
+	ID          string    `json:"id"`
+	Email       string    `json:"email"`
+	Role        auth.Role `json:"role"`
+	Status      string    `json:"status"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+	LastLoginAt string    `json:"last_login_at,omitempty"`
+}
+
+type InviteUserRequest struct {
+	Email         string    `json:"email"`
+	Role          auth.Role `json:"role"`
+	ExpiresInDays int       `json:"expires_in_days,omitempty"`
+}
+
+func (r *InviteUserRequest) Validate() error {
+	r.Email = strings.TrimSpace(r.Email)
+	if r.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if !r.Role.Valid() {
+		return fmt.Errorf("role must be one of owner, admin, developer, viewer or client")
+	}
+	if r.ExpiresInDays < 0 {
+		return fmt.Errorf("expires_in_days must not be negative")
+	}
+	return nil
+}
+
+// UserAccountLink carries a one-time invitation or password reset link for
+// the admin to hand over. The token is only returned once.
+type UserAccountLink struct {
+	User      User   `json:"user"`
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// UpdateUserRequest changes a role, suspends ("suspended") or reactivates
+// ("active") a user. Omitted fields stay as they are.
+type UpdateUserRequest struct {
+	Role   auth.Role `json:"role,omitempty"`
+	Status string    `json:"status,omitempty"`
+}
+
+func (r *UpdateUserRequest) Validate() error {
+	r.Status = strings.TrimSpace(r.Status)
+	if r.Role == "" && r.Status == "" {
+		return fmt.Errorf("role or status is required")
+	}
+	if r.Role != "" && !r.Role.Valid() {
+		return fmt.Errorf("role must be one of owner, admin, developer, viewer or client")
+	}
+	return nil
+}
+
+// SetPasswordRequest redeems an invitation or password reset token.
+type SetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (r *SetPasswordRequest) Validate() error {
+	r.Token = strings.TrimSpace(r.Token)
+	if r.Token == "" || r.Password == "" {
+		return fmt.Errorf("token and password are required")
+	}
+	return nil
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (r *ChangePasswordRequest) Validate() error {
+	if r.CurrentPassword == "" || r.NewPassword == "" {
+		return fmt.Errorf("current_password and new_password are required")
+	}
+	return nil
+}
+
+// UserSession is one of the caller's own sign-ins. Current marks the session
+// the request was made with.
+type UserSession struct {
+	ID         string `json:"id"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	ExpiresAt  string `json:"expires_at"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	AuthMethod string `json:"auth_method"`
+	Current    bool   `json:"current"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
@@ -0,0 +1,43 @@
+package apitypes
+
+// SiteVulnerability is a feed entry that applies to a component installed on
+// a site. FixedIn is empty when the feed does not name a fixed release.
+type SiteVulnerability struct {
+	SiteID           string   `json:"site_id"`
+	SiteName         string   `json:"site_name,omitempty"`
+	ServerID         string   `json:"server_id,omitempty"`
+	VulnerabilityID  string   `json:"vulnerability_id"`
+	ComponentType    string   `json:"component_type"`
+	ComponentName    string   `json:"component_name"`
+	InstalledVersion string   `json:"installed_version"`
+	Title            string   `json:"title"`
+	Description      string   `json:"description,omitempty"`
+	Severity         string   `json:"severity"`
+	Score            float64  `json:"score,omitempty"`
+	FixedIn          string   `json:"fixed_in,omitempty"`
+	References       []string `json:"references,omitempty"`
+	FirstSeenAt      string   `json:"first_seen_at"`
+	ScannedAt        string   `json:"scanned_at"`
+}
+
+type SiteVulnerabilitiesResponse struct {
+	SiteID          string              `json:"site_id"`
+	ScannedAt       string              `json:"scanned_at,omitempty"`
+	Vulnerabilities []SiteVulnerability `json:"vulnerabilities"`
+}
+
+type VulnerabilitySeverityCounts struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+	Unknown  int `json:"unknown"`
+}
+
+// VulnerabilityReport is the fleet-wide view of open vulnerabilities, most
+// severe first.
+type VulnerabilityReport struct {
+	AffectedSites   int                         `json:"affected_sites"`
+	Counts          VulnerabilitySeverityCounts `json:"counts"`
+	Vulnerabilities []SiteVulnerability         `json:"vulnerabilities"`
+}
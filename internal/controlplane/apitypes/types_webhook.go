@@ -0,0 +1,79 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+)
+
+// WebhookSubscription sends matching activity events to an external URL.
+// Empty filters match every event of the workspace.
+type WebhookSubscription struct {
+	ID           string                `json:"id"`
+	Name         string                `json:"name"`
+	URL          string                `json:"url"`
+	EventTypes   []activity.EventType  `json:"event_types"`
+	Categories   []activity.Category   `json:"categories"`
+	ResourceType activity.ResourceType `json:"resource_type,omitempty"`
+	ResourceID   string                `json:"resource_id,omitempty"`
+	Enabled      bool                  `json:"enabled"`
+	CreatedAt    string                `json:"created_at"`
+	UpdatedAt    string                `json:"updated_at"`
+}
+
+// WebhookSubscriptionRequest creates or replaces a subscription. Enabled
+// defaults to true.
+type WebhookSubscriptionRequest struct {
+	Name         string                `json:"name"`
+	URL          string                `json:"url"`
+	EventTypes   []activity.EventType  `json:"event_types,omitempty"`
+	Categories   []activity.Category   `json:"categories,omitempty"`
+	ResourceType activity.ResourceType `json:"resource_type,omitempty"`
+	ResourceID   string                `json:"resource_id,omitempty"`
+	Enabled      *bool                 `json:"enabled,omitempty"`
+}
+
+func (r *WebhookSubscriptionRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.URL = strings.TrimSpace(r.URL)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if r.ResourceID != "" {
+		id, err := ParseAppID(r.ResourceID)
+		if err != nil {
+			return fmt.Errorf("resource_id must be a valid id")
+		}
+		r.ResourceID = id
+	}
+	return nil
+}
+
+// CreateWebhookSubscriptionResponse carries the signing secret. It is only
+// returned once.
+type CreateWebhookSubscriptionResponse struct {
+	Subscription WebhookSubscription `json:"subscription"`
+	Secret       string              `json:"secret"`
+}
+
+// WebhookDelivery is one entry of a subscription's delivery log. Pending
+// deliveries are retried with exponential backoff until they succeed or
+// run out of attempts.
+type WebhookDelivery struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	ActivityID     string `json:"activity_id,omitempty"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string `json:"last_attempt_at,omitempty"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,51 @@
+package apitypes
+
+import (
+	"fmt"
+	"strings"
+
+	"pressluft/internal/controlplane/auth"
+)
+
+// Workspace is a tenant the signed-in user belongs to. Role is their role
+// there. Requests pick a workspace with the X-Pressluft-Workspace header.
+type Workspace struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Role      auth.Role `json:"role"`
+	CreatedAt string    `json:"created_at"`
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *CreateWorkspaceRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(r.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+	return nil
+}
+
+// AddWorkspaceMemberRequest adds an existing user to a workspace. People
+// without an account are invited through /api/users instead.
+type AddWorkspaceMemberRequest struct {
+	Email string    `json:"email"`
+	Role  auth.Role `json:"role"`
+}
+
+func (r *AddWorkspaceMemberRequest) Validate() error {
+	r.Email = strings.TrimSpace(r.Email)
+	if r.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if !r.Role.Valid() {
+		return fmt.Errorf("role must be one of owner, admin, developer, viewer or client")
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "net/http"
+import (
+	"net/http"
+
+	"pressluft/internal/shared/workspace"
+)
 
 type Authenticator interface {
 	Authenticate(*http.Request) (Actor, error)
@@ -18,6 +22,28 @@ func (a *SessionAuthenticator) Authenticate(r *http.Request) (Actor, error) {
 	return a.service.AuthenticateRequest(r)
 }
 
+// TokenAuthenticator accepts "Authorization: Bearer" API tokens and hands
+// every other request to the fallback. A bearer token that does not resolve
+// is rejected rather than retried as a session.
+type TokenAuthenticator struct {
+	service  *Service
+	fallback Authenticator
+}
+
+func NewTokenAuthenticator(service *Service, fallback Authenticator) *TokenAuthenticator {
+	return &TokenAuthenticator{service: service, fallback: fallback}
+}
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (Actor, error) {
+	if bearerTokenFromRequest(r) != "" {
+		return a.service.AuthenticateToken(r)
+	}
+	if a.fallback == nil {
+		return AnonymousActor(), ErrUnauthenticated
+	}
+	return a.fallback.Authenticate(r)
+}
+
 type DevAuthenticator struct {
 	actor Actor
 }
@@ -32,6 +58,7 @@ func NewDevAuthenticator() *DevAuthenticator {
 			Capabilities:  RoleCapabilities(RoleAdmin),
 			Authenticated: true,
 			AuthSource:    "dev",
+			WorkspaceID:   workspace.DefaultID,
 		},
 	}
 }
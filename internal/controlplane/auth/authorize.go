@@ -4,26 +4,64 @@ type Capability string
 
 const (
 	CapabilityManageProviders Capability = "manage_providers"
+	CapabilityReadServers     Capability = "read_servers"
 	CapabilityManageServers   Capability = "manage_servers"
+	CapabilityDeleteServers   Capability = "delete_servers"
+	CapabilityReadSites       Capability = "read_sites"
 	CapabilityManageSites     Capability = "manage_sites"
+	CapabilityManageBackups   Capability = "manage_backups"
+	CapabilityReadJobs        Capability = "read_jobs"
 	CapabilityQueueJobs       Capability = "queue_jobs"
 	CapabilityReadActivity    Capability = "read_activity"
+	CapabilityManageUsers     Capability = "manage_users"
+	CapabilityManageWebhooks  Capability = "manage_webhooks"
 )
 
 func AllCapabilities() []Capability {
 	return []Capability{
 		CapabilityManageProviders,
+		CapabilityReadServers,
 		CapabilityManageServers,
+		CapabilityDeleteServers,
+		CapabilityReadSites,
 		CapabilityManageSites,
+		CapabilityManageBackups,
+		CapabilityReadJobs,
 		CapabilityQueueJobs,
 		CapabilityReadActivity,
+		CapabilityManageUsers,
+		CapabilityManageWebhooks,
 	}
 }
 
+// RoleCapabilities lists what a role may do. Owners and admins share the full
+// set; what sets the owner apart is that only another owner can change them.
 func RoleCapabilities(role Role) []Capability {
 	switch role {
-	case RoleAdmin:
+	case RoleOwner, RoleAdmin:
 		return AllCapabilities()
+	case RoleDeveloper:
+		return []Capability{
+			CapabilityReadServers,
+			CapabilityReadSites,
+			CapabilityManageSites,
+			CapabilityManageBackups,
+			CapabilityReadJobs,
+			CapabilityQueueJobs,
+			CapabilityReadActivity,
+		}
+	case RoleViewer:
+		return []Capability{
+			CapabilityReadServers,
+			CapabilityReadSites,
+			CapabilityReadJobs,
+			CapabilityReadActivity,
+		}
+	case RoleClient:
+		return []Capability{
+			CapabilityReadSites,
+			CapabilityReadJobs,
+		}
 	default:
 		return nil
 	}
@@ -33,7 +71,30 @@ func HasCapability(actor Actor, capability Capability) bool {
 	if !actor.IsAuthenticated() {
 		return false
 	}
-	for _, granted := range RoleCapabilities(actor.Role) {
+	if len(actor.Scopes) > 0 && !containsCapability(actor.Scopes, capability) {
+		return false
+	}
+	return containsCapability(RoleCapabilities(actor.Role), capability)
+}
+
+// EffectiveCapabilities is what an actor with the given role and token
+// scopes may do. Empty scopes leave the role untouched.
+func EffectiveCapabilities(role Role, scopes []Capability) []Capability {
+	granted := RoleCapabilities(role)
+	if len(scopes) == 0 {
+		return granted
+	}
+	out := make([]Capability, 0, len(scopes))
+	for _, capability := range granted {
+		if containsCapability(scopes, capability) {
+			out = append(out, capability)
+		}
+	}
+	return out
+}
+
+func containsCapability(capabilities []Capability, capability Capability) bool {
+	for _, granted := range capabilities {
 		if granted == capability {
 			return true
 		}
@@ -46,3 +107,11 @@ func RequireCapability(capability Capability) func(Actor) bool {
 		return HasCapability(actor, capability)
 	}
 }
+
+// RequireUnscopedCapability guards fleet-wide resources such as servers and
+// providers, which actors limited to specific sites never see.
+func RequireUnscopedCapability(capability Capability) func(Actor) bool {
+	return func(actor Actor) bool {
+		return !actor.Scoped && HasCapability(actor, capability)
+	}
+}
@@ -35,3 +35,77 @@ func TestAllCapabilitiesIncludesManageSites(t *testing.T) {
 	}
 	t.Fatal("expected manage_sites capability to be published")
 }
+
+func TestRoleCapabilities(t *testing.T) {
+	tests := []struct {
+		role  Role
+		allow []Capability
+		deny  []Capability
+	}{
+		{RoleOwner, AllCapabilities(), nil},
+		{
+			RoleDeveloper,
+			[]Capability{CapabilityReadServers, CapabilityManageSites, CapabilityManageBackups, CapabilityQueueJobs},
+			[]Capability{CapabilityManageServers, CapabilityDeleteServers, CapabilityManageProviders, CapabilityManageUsers},
+		},
+		{
+			RoleViewer,
+			[]Capability{CapabilityReadServers, CapabilityReadSites, CapabilityReadJobs, CapabilityReadActivity},
+			[]Capability{CapabilityManageSites, CapabilityManageBackups, CapabilityQueueJobs, CapabilityManageServers},
+		},
+		{
+			RoleClient,
+			[]Capability{CapabilityReadSites, CapabilityReadJobs},
+			[]Capability{CapabilityReadServers, CapabilityReadActivity, CapabilityManageSites},
+		},
+		{Role("unknown"), nil, AllCapabilities()},
+	}
+	for _, tt := range tests {
+		actor := Actor{ID: "1", Role: tt.role, Authenticated: true}
+		for _, capability := range tt.allow {
+			if !HasCapability(actor, capability) {
+				t.Errorf("%s: expected capability %q", tt.role, capability)
+			}
+		}
+		for _, capability := range tt.deny {
+			if HasCapability(actor, capability) {
+				t.Errorf("%s: unexpected capability %q", tt.role, capability)
+			}
+		}
+	}
+}
+
+func TestScopeToSites(t *testing.T) {
+	tests := []struct {
+		role       Role
+		siteIDs    []string
+		wantScoped bool
+	}{
+		{RoleOwner, []string{"a"}, false},
+		{RoleAdmin, []string{"a"}, false},
+		{RoleDeveloper, nil, false},
+		{RoleDeveloper, []string{"a"}, true},
+		{RoleViewer, []string{"a"}, true},
+		{RoleClient, nil, true},
+	}
+	for _, tt := range tests {
+		actor := Actor{ID: "1", Role: tt.role, Authenticated: true}.ScopeToSites(tt.siteIDs)
+		if actor.Scoped != tt.wantScoped {
+			t.Errorf("%s with %v: Scoped = %v, want %v", tt.role, tt.siteIDs, actor.Scoped, tt.wantScoped)
+		}
+		if got := actor.CanAccessSite("b"); got == tt.wantScoped {
+			t.Errorf("%s with %v: CanAccessSite(b) = %v", tt.role, tt.siteIDs, got)
+		}
+		if tt.wantScoped && len(tt.siteIDs) > 0 && !actor.CanAccessSite("a") {
+			t.Errorf("%s with %v: expected access to granted site", tt.role, tt.siteIDs)
+		}
+	}
+
+	scoped := Actor{ID: "1", Role: RoleDeveloper, Authenticated: true}.ScopeToSites([]string{"a"})
+	if RequireUnscopedCapability(CapabilityReadServers)(scoped) {
+		t.Fatal("expected scoped developer to be denied fleet-wide reads")
+	}
+	if !RequireCapability(CapabilityReadServers)(scoped) {
+		t.Fatal("expected scoped developer to keep its role capabilities")
+	}
+}
@@ -56,3 +56,72 @@ func BootstrapCredentials(mode platform.ExecutionMode) (string, string, error) {
 	}
 	return email, password, nil
 }
+
+// LoadOIDCConfig reads the single sign-on settings. It returns nil when
+// PRESSLUFT_OIDC_ISSUER is unset. The redirect URL defaults to the callback
+// under controlPlaneURL.
+func LoadOIDCConfig(controlPlaneURL string) (*OIDCConfig, error) {
+	issuer := strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_ISSUER"))
+	if issuer == "" {
+		return nil, nil
+	}
+	secret := strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_CLIENT_SECRET"))
+	if secretFile := strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_CLIENT_SECRET_FILE")); secret == "" && secretFile != "" {
+		data, err := os.ReadFile(secretFile)
+		if err != nil {
+			return nil, fmt.Errorf("read oidc client secret file: %w", err)
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+	redirectURL := strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_REDIRECT_URL"))
+	if redirectURL == "" && strings.TrimSpace(controlPlaneURL) != "" {
+		redirectURL = strings.TrimRight(strings.TrimSpace(controlPlaneURL), "/") + "/api/auth/oidc/callback"
+	}
+	roleMapping, err := parseOIDCRoleMapping(os.Getenv("PRESSLUFT_OIDC_ROLE_MAP"))
+	if err != nil {
+		return nil, err
+	}
+	config := &OIDCConfig{
+		Issuer:       issuer,
+		ClientID:     strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_CLIENT_ID")),
+		ClientSecret: secret,
+		RedirectURL:  redirectURL,
+		Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv("PRESSLUFT_OIDC_SCOPES"), ",", " ")),
+		DisplayName:  strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_NAME")),
+		GroupsClaim:  strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_GROUPS_CLAIM")),
+		RoleMapping:  roleMapping,
+		DefaultRole:  Role(strings.TrimSpace(os.Getenv("PRESSLUFT_OIDC_DEFAULT_ROLE"))),
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// PasswordLoginDisabled reports whether PRESSLUFT_PASSWORD_LOGIN_DISABLED is
+// set. It only takes effect together with single sign-on.
+func PasswordLoginDisabled() bool {
+	raw := strings.TrimSpace(os.Getenv("PRESSLUFT_PASSWORD_LOGIN_DISABLED"))
+	return raw == "1" || strings.EqualFold(raw, "true")
+}
+
+// parseOIDCRoleMapping reads "group=role" pairs separated by commas.
+func parseOIDCRoleMapping(raw string) (map[string]Role, error) {
+	mapping := map[string]Role{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("PRESSLUFT_OIDC_ROLE_MAP: %q is not group=role", pair)
+		}
+		if !Role(role).Valid() {
+			return nil, fmt.Errorf("PRESSLUFT_OIDC_ROLE_MAP: invalid role %q for group %q", role, group)
+		}
+		mapping[group] = Role(role)
+	}
+	return mapping, nil
+}
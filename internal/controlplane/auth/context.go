@@ -1,19 +1,42 @@
 package auth
 
-import "context"
+import (
+	"context"
+
+	"pressluft/internal/shared/workspace"
+)
 
 type ActorType string
 
 const (
 	ActorTypeOperator ActorType = "operator"
+	// ActorTypeService is a service API token acting on its own behalf.
+	ActorTypeService ActorType = "service"
 )
 
 type Role string
 
 const (
-	RoleAdmin Role = "admin"
+	RoleOwner     Role = "owner"
+	RoleAdmin     Role = "admin"
+	RoleDeveloper Role = "developer"
+	RoleViewer    Role = "viewer"
+	RoleClient    Role = "client"
 )
 
+func AllRoles() []Role {
+	return []Role{RoleOwner, RoleAdmin, RoleDeveloper, RoleViewer, RoleClient}
+}
+
+func (r Role) Valid() bool {
+	for _, role := range AllRoles() {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type Actor struct {
 	ID            string       `json:"id"`
 	Type          ActorType    `json:"type"`
@@ -22,6 +45,20 @@ type Actor struct {
 	Capabilities  []Capability `json:"capabilities,omitempty"`
 	Authenticated bool         `json:"authenticated"`
 	AuthSource    string       `json:"auth_source,omitempty"`
+	// WorkspaceID is the workspace the request acts in; Role is the
+	// actor's role there.
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	// Scoped actors only see the sites listed in SiteIDs.
+	Scoped  bool     `json:"scoped,omitempty"`
+	SiteIDs []string `json:"site_ids,omitempty"`
+	// TokenID is set when the request authenticated with an API token; the
+	// token's scopes further narrow what the role allows.
+	TokenID string       `json:"token_id,omitempty"`
+	Scopes  []Capability `json:"scopes,omitempty"`
+	// TOTPEnrollmentRequired is set on session actors without TOTP while
+	// policy requires it; they may only reach the enrollment endpoints.
+	TOTPEnabled            bool `json:"totp_enabled,omitempty"`
+	TOTPEnrollmentRequired bool `json:"totp_enrollment_required,omitempty"`
 }
 
 func AnonymousActor() Actor {
@@ -32,6 +69,50 @@ func (a Actor) IsAuthenticated() bool {
 	return a.Authenticated && a.ID != ""
 }
 
+// Workspace returns the workspace the actor acts in. Actors built outside a
+// request belong to the default workspace.
+func (a Actor) Workspace() string {
+	if a.WorkspaceID != "" {
+		return a.WorkspaceID
+	}
+	return workspace.DefaultID
+}
+
+// CanAccessSite reports whether the actor may see the given site at all; the
+// capability checks still decide what it may do there.
+func (a Actor) CanAccessSite(siteID string) bool {
+	if !a.Scoped {
+		return true
+	}
+	for _, id := range a.SiteIDs {
+		if id == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeToSites limits the actor to its site grants. Clients are always
+// limited, so a client without grants sees no sites; developers and viewers
+// are limited once they have been granted at least one site. Owners and
+// admins are never limited.
+func (a Actor) ScopeToSites(siteIDs []string) Actor {
+	switch a.Role {
+	case RoleClient:
+		a.Scoped = true
+	case RoleDeveloper, RoleViewer:
+		a.Scoped = len(siteIDs) > 0
+	default:
+		a.Scoped = false
+	}
+	if a.Scoped {
+		a.SiteIDs = append([]string(nil), siteIDs...)
+	} else {
+		a.SiteIDs = nil
+	}
+	return a
+}
+
 type contextKey string
 
 const actorContextKey contextKey = "pressluft/auth/actor"
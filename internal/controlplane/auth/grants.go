@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+// ListSiteGrants returns the sites a user has been granted, in ID order.
+// Grants on sites of other workspaces than the one in ctx are left out.
+func (s *Store) ListSiteGrants(ctx context.Context, userID string) ([]string, error) {
+	userID, err := s.lookupUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	scope := workspace.Filter(ctx)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT site_id FROM user_site_grants
+		WHERE user_id = ?
+		  AND (? = '' OR site_id NOT IN (SELECT id FROM sites WHERE workspace_id != ?))
+		ORDER BY site_id ASC
+	`, userID, scope, scope)
+	if err != nil {
+		return nil, fmt.Errorf("list site grants: %w", err)
+	}
+	defer rows.Close()
+
+	siteIDs := make([]string, 0)
+	for rows.Next() {
+		var siteID string
+		if err := rows.Scan(&siteID); err != nil {
+			return nil, fmt.Errorf("scan site grant: %w", err)
+		}
+		siteIDs = append(siteIDs, siteID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate site grants: %w", err)
+	}
+	return siteIDs, nil
+}
+
+// ReplaceSiteGrants sets the exact list of sites a user may access. An empty
+// list removes every grant. Grants on sites of other workspaces than the one
+// in ctx stay untouched.
+func (s *Store) ReplaceSiteGrants(ctx context.Context, userID string, siteIDs []string) ([]string, error) {
+	userID, err := s.lookupUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]string, 0, len(siteIDs))
+	seen := make(map[string]bool, len(siteIDs))
+	for _, siteID := range siteIDs {
+		id, err := idutil.Normalize(siteID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid site id %q: %w", siteID, err)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		normalized = append(normalized, id)
+	}
+	sort.Strings(normalized)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin site grants tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	scope := workspace.Filter(ctx)
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM user_site_grants
+		WHERE user_id = ?
+		  AND (? = '' OR site_id NOT IN (SELECT id FROM sites WHERE workspace_id != ?))
+	`, userID, scope, scope); err != nil {
+		return nil, fmt.Errorf("clear site grants: %w", err)
+	}
+	for _, siteID := range normalized {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_site_grants (user_id, site_id, created_at)
+			VALUES (?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		`, userID, siteID); err != nil {
+			return nil, fmt.Errorf("insert site grant: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit site grants tx: %w", err)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,574 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultOIDCGroupsClaim = "groups"
+	DefaultOIDCDisplayName = "single sign-on"
+
+	oidcHTTPTimeout      = 10 * time.Second
+	oidcMetadataLifetime = time.Hour
+	oidcClockSkew        = time.Minute
+	oidcMaxResponseBytes = 1 << 20
+)
+
+// OIDCConfig describes the identity provider used for single sign-on.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	DisplayName  string
+	// GroupsClaim names the ID token claim holding the user's groups.
+	GroupsClaim string
+	// RoleMapping maps provider groups to roles. A user in several mapped
+	// groups gets the most privileged role.
+	RoleMapping map[string]Role
+	// DefaultRole is given to users in no mapped group; when empty those
+	// users are refused.
+	DefaultRole Role
+}
+
+func (c OIDCConfig) Validate() error {
+	if strings.TrimSpace(c.Issuer) == "" {
+		return fmt.Errorf("oidc issuer is required")
+	}
+	if strings.TrimSpace(c.ClientID) == "" {
+		return fmt.Errorf("oidc client id is required")
+	}
+	redirect, err := url.Parse(c.RedirectURL)
+	if err != nil || redirect.Scheme == "" || redirect.Host == "" {
+		return fmt.Errorf("oidc redirect url must be an absolute url")
+	}
+	for group, role := range c.RoleMapping {
+		if !role.Valid() {
+			return fmt.Errorf("oidc role mapping for group %q: invalid role %q", group, role)
+		}
+	}
+	if c.DefaultRole != "" && !c.DefaultRole.Valid() {
+		return fmt.Errorf("oidc default role %q is invalid", c.DefaultRole)
+	}
+	if len(c.RoleMapping) == 0 && c.DefaultRole == "" {
+		return fmt.Errorf("oidc needs a role mapping or a default role")
+	}
+	return nil
+}
+
+// OIDCProvider talks to an OpenID Connect identity provider. Discovery and
+// the signing keys are fetched lazily and cached, so a provider that is down
+// at startup does not keep the control plane from booting.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu         sync.Mutex
+	metadata   *oidcMetadata
+	keys       map[string]crypto.PublicKey
+	fetchedAt  time.Time
+	keysLoaded time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCIdentity is what a verified ID token tells us about the user.
+type OIDCIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	Name    string
+	Groups  []string
+}
+
+func NewOIDCProvider(config OIDCConfig, client *http.Client) (*OIDCProvider, error) {
+	config.Issuer = strings.TrimRight(strings.TrimSpace(config.Issuer), "/")
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+	if strings.TrimSpace(config.GroupsClaim) == "" {
+		config.GroupsClaim = DefaultOIDCGroupsClaim
+	}
+	if strings.TrimSpace(config.DisplayName) == "" {
+		config.DisplayName = DefaultOIDCDisplayName
+	}
+	if client == nil {
+		client = &http.Client{Timeout: oidcHTTPTimeout}
+	}
+	return &OIDCProvider{config: config, client: client}, nil
+}
+
+func (p *OIDCProvider) DisplayName() string {
+	return p.config.DisplayName
+}
+
+func (p *OIDCProvider) Issuer() string {
+	return p.config.Issuer
+}
+
+// AuthorizationURL is where the browser is sent to sign in, using the
+// authorization code flow with a S256 PKCE challenge.
+func (p *OIDCProvider) AuthorizationURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse oidc authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", pkceChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange trades an authorization code for a verified identity.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (OIDCIdentity, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("build oidc token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var tokens struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &tokens)
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return OIDCIdentity{}, fmt.Errorf("oidc token exchange: status %d: %s %s", status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return OIDCIdentity{}, fmt.Errorf("oidc token exchange: response has no id_token")
+	}
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// RoleForGroups maps the user's groups to a role. It reports false when no
+// group is mapped and there is no default role.
+func (p *OIDCProvider) RoleForGroups(groups []string) (Role, bool) {
+	best := -1
+	roles := AllRoles()
+	for _, group := range groups {
+		role, ok := p.config.RoleMapping[group]
+		if !ok {
+			continue
+		}
+		if rank := slices.Index(roles, role); rank >= 0 && (best < 0 || rank < best) {
+			best = rank
+		}
+	}
+	if best >= 0 {
+		return roles[best], true
+	}
+	if p.config.DefaultRole != "" {
+		return p.config.DefaultRole, true
+	}
+	return "", false
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil && time.Since(p.fetchedAt) < oidcMetadataLifetime {
+		return *p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return oidcMetadata{}, fmt.Errorf("build oidc discovery request: %w", err)
+	}
+	var metadata oidcMetadata
+	status, err := p.doJSON(req, &metadata)
+	if err != nil {
+		return oidcMetadata{}, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if status != http.StatusOK {
+		return oidcMetadata{}, fmt.Errorf("oidc discovery: status %d", status)
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != p.config.Issuer {
+		return oidcMetadata{}, fmt.Errorf("oidc discovery: issuer %q does not match configured %q", metadata.Issuer, p.config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return oidcMetadata{}, fmt.Errorf("oidc discovery: metadata is missing endpoints")
+	}
+	p.metadata = &metadata
+	p.fetchedAt = time.Now()
+	p.keys = nil
+	return metadata, nil
+}
+
+// signingKey returns the provider key with the given id, refetching the key
+// set once when the id is unknown so key rotation is picked up.
+func (p *OIDCProvider) signingKey(ctx context.Context, metadata oidcMetadata, keyID string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := lookupOIDCKey(p.keys, keyID); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysLoaded) < time.Minute {
+		return nil, fmt.Errorf("oidc signing key %q not found", keyID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build oidc jwks request: %w", err)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc jwks: status %d", status)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	p.keys = keys
+	p.keysLoaded = time.Now()
+	if key, ok := lookupOIDCKey(keys, keyID); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc signing key %q not found", keyID)
+}
+
+// lookupOIDCKey finds a key by id. Tokens without a key id are accepted when
+// the provider publishes exactly one key.
+func lookupOIDCKey(keys map[string]crypto.PublicKey, keyID string) (crypto.PublicKey, bool) {
+	if key, ok := keys[keyID]; ok {
+		return key, true
+	}
+	if keyID == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func (p *OIDCProvider) doJSON(req *http.Request, dst any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxResponseBytes))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("read response: %w", err)
+	}
+	if err := json.Unmarshal(body, dst); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      audience        `json:"aud"`
+	AuthorizedBy  string          `json:"azp"`
+	ExpiresAt     int64           `json:"exp"`
+	IssuedAt      int64           `json:"iat"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	Name          string          `json:"name"`
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, raw, nonce string) (OIDCIdentity, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return OIDCIdentity{}, fmt.Errorf("id token is not a signed jwt")
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return OIDCIdentity{}, fmt.Errorf("decode id token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("decode id token signature: %w", err)
+	}
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	key, err := p.signingKey(ctx, metadata, header.KeyID)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+	if err := verifyJWTSignature(header.Algorithm, key, parts[0]+"."+parts[1], signature); err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	var claims idTokenClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return OIDCIdentity{}, fmt.Errorf("decode id token claims: %w", err)
+	}
+	var extra map[string]json.RawMessage
+	if err := decodeJWTSegment(parts[1], &extra); err != nil {
+		return OIDCIdentity{}, fmt.Errorf("decode id token claims: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case strings.TrimRight(claims.Issuer, "/") != p.config.Issuer:
+		return OIDCIdentity{}, fmt.Errorf("id token issuer %q is not %q", claims.Issuer, p.config.Issuer)
+	case !slices.Contains(claims.Audience, p.config.ClientID):
+		return OIDCIdentity{}, fmt.Errorf("id token audience does not include the client id")
+	case len(claims.Audience) > 1 && claims.AuthorizedBy != "" && claims.AuthorizedBy != p.config.ClientID:
+		return OIDCIdentity{}, fmt.Errorf("id token was issued to another client")
+	case claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(oidcClockSkew)):
+		return OIDCIdentity{}, fmt.Errorf("id token has expired")
+	case claims.IssuedAt != 0 && time.Unix(claims.IssuedAt, 0).After(now.Add(oidcClockSkew)):
+		return OIDCIdentity{}, fmt.Errorf("id token is issued in the future")
+	case claims.Nonce == "" || claims.Nonce != nonce:
+		return OIDCIdentity{}, fmt.Errorf("id token nonce does not match")
+	case claims.Subject == "":
+		return OIDCIdentity{}, fmt.Errorf("id token has no subject")
+	case strings.TrimSpace(claims.Email) == "":
+		return OIDCIdentity{}, fmt.Errorf("id token has no email claim")
+	case isFalseClaim(claims.EmailVerified):
+		return OIDCIdentity{}, fmt.Errorf("id token email is not verified")
+	}
+
+	return OIDCIdentity{
+		Issuer:  p.config.Issuer,
+		Subject: claims.Subject,
+		Email:   strings.ToLower(strings.TrimSpace(claims.Email)),
+		Name:    claims.Name,
+		Groups:  stringListClaim(extra[p.config.GroupsClaim]),
+	}, nil
+}
+
+// audience accepts the "aud" claim as a single string or a list.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// stringListClaim reads a claim that providers send either as a list or as a
+// single space or comma separated string.
+func stringListClaim(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return strings.FieldsFunc(single, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
+
+// isFalseClaim reports whether a boolean claim is present and false. Some
+// providers send "false" as a string.
+func isFalseClaim(raw json.RawMessage) bool {
+	if len(raw) == 0 {
+		return false
+	}
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return !value
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return strings.EqualFold(text, "false")
+	}
+	return false
+}
+
+func decodeJWTSegment(segment string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func verifyJWTSignature(algorithm string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hasher hash.Hash
+	var hashID crypto.Hash
+	switch algorithm {
+	case "RS256", "ES256":
+		hasher, hashID = sha256.New(), crypto.SHA256
+	case "RS384", "ES384":
+		hasher, hashID = sha512.New384(), crypto.SHA384
+	case "RS512":
+		hasher, hashID = sha512.New(), crypto.SHA512
+	default:
+		return fmt.Errorf("id token algorithm %q is not supported", algorithm)
+	}
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "RS") {
+			return fmt.Errorf("id token algorithm %q does not match an rsa key", algorithm)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hashID, digest, signature); err != nil {
+			return fmt.Errorf("id token signature is invalid")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") {
+			return fmt.Errorf("id token algorithm %q does not match an ec key", algorithm)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("id token signature is invalid")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("id token signature is invalid")
+		}
+	default:
+		return fmt.Errorf("unsupported oidc signing key")
+	}
+	return nil
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("ec point is not on the curve")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
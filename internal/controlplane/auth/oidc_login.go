@@ -0,0 +1,354 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+const (
+	// OIDCStateCookieName binds an OIDC login to the browser that started it.
+	OIDCStateCookieName = "pressluft_oidc_state"
+	OIDCLoginLifetime   = 10 * time.Minute
+
+	sessionAuthMethodPassword = "password"
+	sessionAuthMethodOIDC     = "oidc"
+)
+
+var (
+	ErrOIDCNotConfigured     = errors.New("single sign-on is not configured")
+	ErrPasswordLoginDisabled = errors.New("password login is disabled")
+	// ErrOIDCLoginFailed covers every way the provider round trip can fail;
+	// the wrapped error has the details for the log.
+	ErrOIDCLoginFailed = errors.New("single sign-on failed")
+	// ErrOIDCNoRole means the provider vouched for the user but none of
+	// their groups maps to a role.
+	ErrOIDCNoRole = errors.New("single sign-on user has no mapped role")
+)
+
+// OIDCLoginError reports who tried to sign in when a login was refused after
+// the provider identified the user.
+type OIDCLoginError struct {
+	Email string
+	Err   error
+}
+
+func (e *OIDCLoginError) Error() string {
+	return fmt.Sprintf("single sign-on for %s: %v", e.Email, e.Err)
+}
+
+func (e *OIDCLoginError) Unwrap() error {
+	return e.Err
+}
+
+// LoginMethods lists how operators can sign in.
+type LoginMethods struct {
+	Password bool
+	OIDC     bool
+	OIDCName string
+}
+
+// ConfigureOIDC enables single sign-on. With disablePasswordLogin set, Login
+// refuses every password and the provider is the only way in.
+func (s *Service) ConfigureOIDC(provider *OIDCProvider, disablePasswordLogin bool) {
+	s.oidc = provider
+	s.passwordLoginDisabled = disablePasswordLogin && provider != nil
+}
+
+func (s *Service) LoginMethods() LoginMethods {
+	methods := LoginMethods{Password: !s.passwordLoginDisabled}
+	if s.oidc != nil {
+		methods.OIDC = true
+		methods.OIDCName = s.oidc.DisplayName()
+	}
+	return methods
+}
+
+// BeginOIDCLogin records a login state and returns the provider URL to send
+// the browser to. redirectPath is where the browser lands after the callback.
+func (s *Service) BeginOIDCLogin(ctx context.Context, w http.ResponseWriter, redirectPath string) (string, error) {
+	if s.oidc == nil {
+		return "", ErrOIDCNotConfigured
+	}
+	state, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	authURL, err := s.oidc.AuthorizationURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrOIDCLoginFailed, err)
+	}
+	expiresAt := time.Now().UTC().Add(OIDCLoginLifetime)
+	if err := s.store.createOIDCLoginState(ctx, HashOpaqueToken(s.sessionSecret, state), nonce, verifier, SafeRedirectPath(redirectPath), expiresAt); err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     OIDCStateCookieName,
+		Value:    state,
+		Path:     "/api/auth/oidc",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   s.secureCookies,
+		MaxAge:   int(OIDCLoginLifetime.Seconds()),
+	})
+	return authURL, nil
+}
+
+// CompleteOIDCLogin handles the provider callback: it checks the state
+// against the browser cookie, exchanges the code, provisions or updates the
+// user and starts a session. It returns where to send the browser next.
+func (s *Service) CompleteOIDCLogin(ctx context.Context, w http.ResponseWriter, r *http.Request) (Actor, string, error) {
+	if s.oidc == nil {
+		return AnonymousActor(), "", ErrOIDCNotConfigured
+	}
+	query := r.URL.Query()
+	state := strings.TrimSpace(query.Get("state"))
+	cookie, err := r.Cookie(OIDCStateCookieName)
+	http.SetCookie(w, &http.Cookie{Name: OIDCStateCookieName, Value: "", Path: "/api/auth/oidc", HttpOnly: true, SameSite: http.SameSiteLaxMode, Secure: s.secureCookies, MaxAge: -1})
+	if state == "" || err != nil || cookie.Value != state {
+		return AnonymousActor(), "", fmt.Errorf("%w: state does not match this browser", ErrOIDCLoginFailed)
+	}
+	login, err := s.store.consumeOIDCLoginState(ctx, HashOpaqueToken(s.sessionSecret, state))
+	if err != nil {
+		return AnonymousActor(), "", err
+	}
+	if providerErr := query.Get("error"); providerErr != "" {
+		return AnonymousActor(), "", fmt.Errorf("%w: provider returned %s: %s", ErrOIDCLoginFailed, providerErr, query.Get("error_description"))
+	}
+	code := strings.TrimSpace(query.Get("code"))
+	if code == "" {
+		return AnonymousActor(), "", fmt.Errorf("%w: callback has no code", ErrOIDCLoginFailed)
+	}
+
+	identity, err := s.oidc.Exchange(ctx, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return AnonymousActor(), "", fmt.Errorf("%w: %w", ErrOIDCLoginFailed, err)
+	}
+	role, ok := s.oidc.RoleForGroups(identity.Groups)
+	if !ok {
+		return AnonymousActor(), "", &OIDCLoginError{Email: identity.Email, Err: ErrOIDCNoRole}
+	}
+	user, err := s.provisionOIDCUser(ctx, identity, role)
+	if err != nil {
+		return AnonymousActor(), "", err
+	}
+	actor, err := s.startSession(ctx, w, r, user, sessionAuthMethodOIDC)
+	if err != nil {
+		return AnonymousActor(), "", err
+	}
+	return actor, login.RedirectPath, nil
+}
+
+// provisionOIDCUser finds the user linked to the identity, links an existing
+// user with the same email, or creates one just in time. The role in the
+// default workspace follows the provider groups on every login.
+func (s *Service) provisionOIDCUser(ctx context.Context, identity OIDCIdentity, role Role) (*User, error) {
+	userID, err := s.store.getIdentityUserID(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	var user *User
+	created := false
+	if userID != "" {
+		user, err = s.store.GetUserByID(ctx, userID)
+	} else {
+		user, err = s.store.GetUserByEmail(ctx, identity.Email)
+		if errors.Is(err, ErrInvalidCredentials) {
+			// The password is random and never shown: these users sign in
+			// through the provider only.
+			var password string
+			password, err = GenerateOpaqueToken()
+			if err != nil {
+				return nil, err
+			}
+			user, err = s.store.CreateUser(ctx, identity.Email, password, role)
+			created = err == nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch user.Status {
+	case UserStatusActive:
+	case UserStatusInvited:
+		// The provider vouched for the email, which is all an invitation
+		// would have checked.
+		if err := s.store.setUserStatus(ctx, user.ID, UserStatusActive); err != nil {
+			return nil, err
+		}
+		user.Status = UserStatusActive
+	default:
+		return nil, &OIDCLoginError{Email: identity.Email, Err: ErrInvalidCredentials}
+	}
+	if err := s.store.upsertIdentity(ctx, identity, user.ID); err != nil {
+		return nil, err
+	}
+	if !created {
+		// CreateUser already made the default-workspace membership.
+		if err := s.syncOIDCRole(ctx, user, role); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// syncOIDCRole applies the role the provider groups map to. Group mappings
+// only decide the role in the default workspace, and only for its members:
+// users who belong to client workspaces alone are not pulled into it. As in
+// the members API, the last active owner keeps the role; a demotion waits
+// until there is another owner.
+func (s *Service) syncOIDCRole(ctx context.Context, user *User, role Role) error {
+	current, err := s.store.membershipRole(ctx, workspace.DefaultID, user.ID)
+	if errors.Is(err, ErrWorkspaceAccess) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current == role {
+		return nil
+	}
+	member := *user
+	member.Role = current
+	err = s.ensureAnotherOwner(ctx, workspace.DefaultID, &member)
+	if errors.Is(err, ErrLastOwner) {
+		user.Role = current
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.store.setMemberRole(ctx, workspace.DefaultID, user.ID, role); err != nil {
+		return err
+	}
+	if user.Role != role {
+		if err := s.store.updateUserRole(ctx, user.ID, role); err != nil {
+			return err
+		}
+		user.Role = role
+	}
+	return nil
+}
+
+// SafeRedirectPath keeps post-login redirects on this origin.
+func SafeRedirectPath(path string) string {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") || strings.ContainsAny(path, "\r\n") {
+		return "/"
+	}
+	return path
+}
+
+type oidcLoginState struct {
+	Nonce        string
+	CodeVerifier string
+	RedirectPath string
+}
+
+func (s *Store) createOIDCLoginState(ctx context.Context, stateHash, nonce, verifier, redirectPath string, expiresAt time.Time) error {
+	id, err := idutil.New()
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE datetime(expires_at) <= datetime('now')`); err != nil {
+		return fmt.Errorf("prune oidc login states: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO oidc_login_states (id, state_hash, nonce, code_verifier, redirect_path, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?)
+	`, id, stateHash, nonce, verifier, redirectPath, expiresAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("insert oidc login state: %w", err)
+	}
+	return nil
+}
+
+// consumeOIDCLoginState spends a login state so a callback URL works once.
+func (s *Store) consumeOIDCLoginState(ctx context.Context, stateHash string) (oidcLoginState, error) {
+	var id string
+	var state oidcLoginState
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, nonce, code_verifier, redirect_path
+		FROM oidc_login_states
+		WHERE state_hash = ?
+		  AND consumed_at IS NULL
+		  AND datetime(expires_at) > datetime('now')
+	`, stateHash).Scan(&id, &state.Nonce, &state.CodeVerifier, &state.RedirectPath)
+	if errors.Is(err, sql.ErrNoRows) {
+		return oidcLoginState{}, fmt.Errorf("%w: login state expired or already used", ErrOIDCLoginFailed)
+	}
+	if err != nil {
+		return oidcLoginState{}, fmt.Errorf("get oidc login state: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE oidc_login_states
+		SET consumed_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ? AND consumed_at IS NULL
+	`, id)
+	if err != nil {
+		return oidcLoginState{}, fmt.Errorf("consume oidc login state: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return oidcLoginState{}, fmt.Errorf("consume oidc login state: %w", err)
+	}
+	if affected == 0 {
+		return oidcLoginState{}, fmt.Errorf("%w: login state expired or already used", ErrOIDCLoginFailed)
+	}
+	return state, nil
+}
+
+func (s *Store) getIdentityUserID(ctx context.Context, issuer, subject string) (string, error) {
+	var userID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id FROM user_identities WHERE issuer = ? AND subject = ?
+	`, issuer, subject).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get user identity: %w", err)
+	}
+	return userID, nil
+}
+
+func (s *Store) upsertIdentity(ctx context.Context, identity OIDCIdentity, userID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, user_id, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		ON CONFLICT(issuer, subject) DO UPDATE SET
+		    email = excluded.email,
+		    last_login_at = excluded.last_login_at
+	`, identity.Issuer, identity.Subject, userID, identity.Email)
+	if err != nil {
+		return fmt.Errorf("upsert user identity: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) updateUserRole(ctx context.Context, userID string, role Role) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET role = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ?
+	`, string(role), userID)
+	if err != nil {
+		return fmt.Errorf("update user role: %w", err)
+	}
+	return nil
+}
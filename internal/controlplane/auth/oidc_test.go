@@ -0,0 +1,363 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/auth/oidctest"
+	"pressluft/internal/shared/workspace"
+)
+
+func TestOIDCLoginProvisionsUserAndFollowsProviderGroups(t *testing.T) {
+	service, store, _, _ := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	idp := oidctest.NewServer(oidctest.User{Subject: "user-1", Email: "Dev@Agency.test", EmailVerified: true, Groups: []string{"staff", "ops"}})
+	defer idp.Close()
+	configureTestOIDC(t, service, idp, false)
+
+	if err := store.UpdateAuthPolicy(ctx, AuthPolicy{RequireTOTP: true}); err != nil {
+		t.Fatalf("UpdateAuthPolicy() error = %v", err)
+	}
+
+	actor, redirect, cookie := completeTestOIDCLogin(t, service, idp, "/sites?tab=all")
+	if redirect != "/sites?tab=all" {
+		t.Fatalf("redirect = %q, want /sites?tab=all", redirect)
+	}
+	if actor.Email != "dev@agency.test" || actor.Role != RoleAdmin || actor.AuthSource != "oidc" {
+		t.Fatalf("actor = %+v, want admin dev@agency.test from oidc", actor)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+	req.AddCookie(cookie)
+	sessionActor, err := service.AuthenticateRequest(req)
+	if err != nil {
+		t.Fatalf("AuthenticateRequest() error = %v", err)
+	}
+	if sessionActor.ID != actor.ID || sessionActor.AuthSource != "oidc" {
+		t.Fatalf("session actor = %+v, want oidc session of %s", sessionActor, actor.ID)
+	}
+	if sessionActor.TOTPEnrollmentRequired {
+		t.Fatal("single sign-on session must not require panel TOTP enrollment")
+	}
+
+	// The same subject signs in again after being moved to another group;
+	// the account is reused and its role follows the provider.
+	idp.SetUser(oidctest.User{Subject: "user-1", Email: "dev@agency.test", EmailVerified: true, Groups: []string{"developers"}})
+	again, _, _ := completeTestOIDCLogin(t, service, idp, "")
+	if again.ID != actor.ID || again.Role != RoleDeveloper {
+		t.Fatalf("second login actor = %+v, want %s as developer", again, actor.ID)
+	}
+	count, err := store.UserCount(ctx)
+	if err != nil {
+		t.Fatalf("UserCount() error = %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("user count = %d, want bootstrap user plus one provisioned user", count)
+	}
+}
+
+func TestOIDCLoginLinksExistingUserByEmail(t *testing.T) {
+	service, _, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	idp := oidctest.NewServer(oidctest.User{Subject: "owner-subject", Email: user.Email, EmailVerified: true, Groups: []string{"owners"}})
+	defer idp.Close()
+	configureTestOIDC(t, service, idp, false)
+
+	actor, redirect, _ := completeTestOIDCLogin(t, service, idp, "https://evil.test/")
+	if actor.ID != user.ID || actor.Role != RoleOwner {
+		t.Fatalf("actor = %+v, want existing user %s as owner", actor, user.ID)
+	}
+	if redirect != "/" {
+		t.Fatalf("redirect = %q, want off-site redirect replaced by /", redirect)
+	}
+}
+
+func TestOIDCLoginLeavesClientOnlyUsersOutOfDefaultWorkspace(t *testing.T) {
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	owner, err := store.CreateUser(ctx, "owner@agency.test", "correct horse battery staple", RoleOwner)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	client, err := service.CreateWorkspace(ctx, operatorActor(owner), "Client Co")
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+	ownerInClient := operatorActor(owner)
+	ownerInClient.WorkspaceID = client.ID
+	contact, err := store.CreateUser(ctx, "contact@client.test", "correct horse battery staple", RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := service.AddWorkspaceMember(ctx, ownerInClient, contact.Email, RoleViewer); err != nil {
+		t.Fatalf("AddWorkspaceMember() error = %v", err)
+	}
+	if _, err := service.DeleteUser(ctx, operatorActor(admin), contact.ID); err != nil {
+		t.Fatalf("DeleteUser() from the default workspace error = %v", err)
+	}
+
+	idp := oidctest.NewServer(oidctest.User{Subject: "contact", Email: contact.Email, EmailVerified: true, Groups: []string{"developers"}})
+	defer idp.Close()
+	configureTestOIDC(t, service, idp, false)
+
+	actor, _, _ := completeTestOIDCLogin(t, service, idp, "")
+	if actor.ID != contact.ID || actor.Workspace() != client.ID || actor.Role != RoleViewer {
+		t.Fatalf("actor = %+v, want the viewer in the client workspace", actor)
+	}
+	if _, err := store.GetWorkspaceUser(ctx, workspace.DefaultID, contact.ID); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("GetWorkspaceUser() in the default workspace error = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestOIDCLoginKeepsLastOwner(t *testing.T) {
+	service, store, _, _ := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	owner, err := store.CreateUser(ctx, "owner@agency.test", "correct horse battery staple", RoleOwner)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	idp := oidctest.NewServer(oidctest.User{Subject: "owner", Email: owner.Email, EmailVerified: true, Groups: []string{"developers"}})
+	defer idp.Close()
+	configureTestOIDC(t, service, idp, false)
+
+	// Demoting the only owner would leave the default workspace without one.
+	actor, _, _ := completeTestOIDCLogin(t, service, idp, "")
+	if actor.ID != owner.ID || actor.Role != RoleOwner {
+		t.Fatalf("actor = %+v, want the last owner kept", actor)
+	}
+
+	if _, err := store.CreateUser(ctx, "second-owner@agency.test", "correct horse battery staple", RoleOwner); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	actor, _, _ = completeTestOIDCLogin(t, service, idp, "")
+	if actor.ID != owner.ID || actor.Role != RoleDeveloper {
+		t.Fatalf("actor = %+v, want the demotion applied once another owner exists", actor)
+	}
+}
+
+func TestOIDCLoginRefusesUnmappedGroupsAndBadTokens(t *testing.T) {
+	service, store, _, _ := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	idp := oidctest.NewServer(oidctest.User{Subject: "guest", Email: "guest@agency.test", EmailVerified: true, Groups: []string{"marketing"}})
+	defer idp.Close()
+	configureTestOIDC(t, service, idp, false)
+
+	_, _, _, err := runTestOIDCLogin(t, service, idp, "")
+	if !errors.Is(err, ErrOIDCNoRole) {
+		t.Fatalf("unmapped groups error = %v, want %v", err, ErrOIDCNoRole)
+	}
+	if _, err := store.GetUserByEmail(ctx, "guest@agency.test"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refused user was provisioned: err = %v", err)
+	}
+
+	idp.SetUser(oidctest.User{Subject: "dev", Email: "dev@agency.test", EmailVerified: true, Groups: []string{"developers"}})
+	for name, tamper := range map[string]func(map[string]any){
+		"wrong audience":     func(claims map[string]any) { claims["aud"] = "another-client" },
+		"wrong nonce":        func(claims map[string]any) { claims["nonce"] = "replayed" },
+		"expired":            func(claims map[string]any) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"unverified email":   func(claims map[string]any) { claims["email_verified"] = false },
+		"wrong issuer":       func(claims map[string]any) { claims["iss"] = "https://other-idp.test" },
+		"missing email":      func(claims map[string]any) { delete(claims, "email") },
+		"missing subject":    func(claims map[string]any) { claims["sub"] = "" },
+		"issued in future":   func(claims map[string]any) { claims["iat"] = time.Now().Add(time.Hour).Unix() },
+		"list audience miss": func(claims map[string]any) { claims["aud"] = []string{"a", "b"} },
+	} {
+		idp.TamperClaims(tamper)
+		if _, _, _, err := runTestOIDCLogin(t, service, idp, ""); !errors.Is(err, ErrOIDCLoginFailed) {
+			t.Fatalf("%s: error = %v, want %v", name, err, ErrOIDCLoginFailed)
+		}
+	}
+}
+
+func TestOIDCCallbackRequiresMatchingBrowserStateOnce(t *testing.T) {
+	service, _, _, _ := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	idp := oidctest.NewServer(oidctest.User{Subject: "dev", Email: "dev@agency.test", EmailVerified: true, Groups: []string{"developers"}})
+	defer idp.Close()
+	configureTestOIDC(t, service, idp, false)
+
+	begin := httptest.NewRecorder()
+	authURL, err := service.BeginOIDCLogin(ctx, begin, "/")
+	if err != nil {
+		t.Fatalf("BeginOIDCLogin() error = %v", err)
+	}
+	stateCookie := begin.Result().Cookies()[0]
+	callback, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+
+	// Without the cookie the callback could have been planted by someone
+	// else, so it is refused.
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	if _, _, err := service.CompleteOIDCLogin(ctx, httptest.NewRecorder(), req); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Fatalf("callback without state cookie error = %v, want %v", err, ErrOIDCLoginFailed)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	req.AddCookie(stateCookie)
+	if _, _, err := service.CompleteOIDCLogin(ctx, httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("CompleteOIDCLogin() error = %v", err)
+	}
+	req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	req.AddCookie(stateCookie)
+	if _, _, err := service.CompleteOIDCLogin(ctx, httptest.NewRecorder(), req); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Fatalf("replayed callback error = %v, want %v", err, ErrOIDCLoginFailed)
+	}
+}
+
+func TestPasswordLoginCanBeDisabledWithOIDC(t *testing.T) {
+	service, _, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	idp := oidctest.NewServer(oidctest.User{})
+	defer idp.Close()
+
+	service.ConfigureOIDC(nil, true)
+	if methods := service.LoginMethods(); !methods.Password || methods.OIDC {
+		t.Fatalf("methods without oidc = %+v, want password only", methods)
+	}
+
+	configureTestOIDC(t, service, idp, true)
+	if methods := service.LoginMethods(); methods.Password || !methods.OIDC || methods.OIDCName != "Agency SSO" {
+		t.Fatalf("methods = %+v, want oidc only", methods)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	if _, err := service.Login(context.Background(), httptest.NewRecorder(), req, user.Email, "correct horse battery staple"); !errors.Is(err, ErrPasswordLoginDisabled) {
+		t.Fatalf("Login() error = %v, want %v", err, ErrPasswordLoginDisabled)
+	}
+}
+
+func TestOIDCRoleForGroupsPicksMostPrivilegedRole(t *testing.T) {
+	provider, err := NewOIDCProvider(OIDCConfig{
+		Issuer:      "https://idp.example.test",
+		ClientID:    "pressluft",
+		RedirectURL: "https://panel.example.test/api/auth/oidc/callback",
+		RoleMapping: map[string]Role{"clients": RoleClient, "developers": RoleDeveloper, "ops": RoleAdmin},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewOIDCProvider() error = %v", err)
+	}
+	for _, tc := range []struct {
+		groups []string
+		want   Role
+		ok     bool
+	}{
+		{groups: []string{"clients", "ops", "developers"}, want: RoleAdmin, ok: true},
+		{groups: []string{"clients"}, want: RoleClient, ok: true},
+		{groups: []string{"marketing"}, ok: false},
+		{groups: nil, ok: false},
+	} {
+		got, ok := provider.RoleForGroups(tc.groups)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("RoleForGroups(%v) = %q, %v; want %q, %v", tc.groups, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestLoadOIDCConfigFromEnvironment(t *testing.T) {
+	t.Setenv("PRESSLUFT_OIDC_ISSUER", "")
+	if config, err := LoadOIDCConfig("https://panel.example.test"); err != nil || config != nil {
+		t.Fatalf("LoadOIDCConfig() without issuer = %+v, %v; want nil, nil", config, err)
+	}
+
+	t.Setenv("PRESSLUFT_OIDC_ISSUER", "https://idp.example.test")
+	t.Setenv("PRESSLUFT_OIDC_CLIENT_ID", "pressluft")
+	t.Setenv("PRESSLUFT_OIDC_ROLE_MAP", "ops=admin, devs = developer")
+	t.Setenv("PRESSLUFT_OIDC_SCOPES", "openid,email groups")
+	config, err := LoadOIDCConfig("https://panel.example.test/")
+	if err != nil {
+		t.Fatalf("LoadOIDCConfig() error = %v", err)
+	}
+	if config.RedirectURL != "https://panel.example.test/api/auth/oidc/callback" {
+		t.Fatalf("redirect url = %q", config.RedirectURL)
+	}
+	if config.RoleMapping["ops"] != RoleAdmin || config.RoleMapping["devs"] != RoleDeveloper {
+		t.Fatalf("role mapping = %v", config.RoleMapping)
+	}
+	if len(config.Scopes) != 3 || config.Scopes[2] != "groups" {
+		t.Fatalf("scopes = %v", config.Scopes)
+	}
+
+	t.Setenv("PRESSLUFT_OIDC_ROLE_MAP", "ops=superuser")
+	if _, err := LoadOIDCConfig("https://panel.example.test"); err == nil {
+		t.Fatal("expected an invalid role in the role map to be rejected")
+	}
+}
+
+func TestSafeRedirectPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                   "/",
+		"/sites/1":           "/sites/1",
+		"/jobs?status=done":  "/jobs?status=done",
+		"//evil.test":        "/",
+		"/\\evil.test":       "/",
+		"https://evil.test/": "/",
+		"sites":              "/",
+	} {
+		if got := SafeRedirectPath(in); got != want {
+			t.Fatalf("SafeRedirectPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func configureTestOIDC(t *testing.T, service *Service, idp *oidctest.Server, disablePasswordLogin bool) {
+	t.Helper()
+	provider, err := NewOIDCProvider(OIDCConfig{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "https://panel.example.test/api/auth/oidc/callback",
+		DisplayName:  "Agency SSO",
+		RoleMapping: map[string]Role{
+			"owners":     RoleOwner,
+			"ops":        RoleAdmin,
+			"developers": RoleDeveloper,
+		},
+	}, idp.Client())
+	if err != nil {
+		t.Fatalf("NewOIDCProvider() error = %v", err)
+	}
+	service.ConfigureOIDC(provider, disablePasswordLogin)
+}
+
+// runTestOIDCLogin walks the browser through the provider and back to the
+// callback.
+func runTestOIDCLogin(t *testing.T, service *Service, idp *oidctest.Server, redirectPath string) (Actor, string, *httptest.ResponseRecorder, error) {
+	t.Helper()
+	ctx := context.Background()
+	begin := httptest.NewRecorder()
+	authURL, err := service.BeginOIDCLogin(ctx, begin, redirectPath)
+	if err != nil {
+		t.Fatalf("BeginOIDCLogin() error = %v", err)
+	}
+	callback, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	for _, cookie := range begin.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	res := httptest.NewRecorder()
+	actor, redirect, err := service.CompleteOIDCLogin(ctx, res, req)
+	return actor, redirect, res, err
+}
+
+// completeTestOIDCLogin is runTestOIDCLogin for logins that must succeed; it
+// returns the session cookie.
+func completeTestOIDCLogin(t *testing.T, service *Service, idp *oidctest.Server, redirectPath string) (Actor, string, *http.Cookie) {
+	t.Helper()
+	actor, redirect, res, err := runTestOIDCLogin(t, service, idp, redirectPath)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin() error = %v", err)
+	}
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name == SessionCookieName {
+			return actor, redirect, cookie
+		}
+	}
+	t.Fatal("CompleteOIDCLogin() did not set a session cookie")
+	return actor, redirect, nil
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"pressluft/internal/shared/workspace"
 )
 
 const SessionCookieName = "pressluft_session"
@@ -16,6 +18,9 @@ type Service struct {
 	idleTimeout     time.Duration
 	absoluteTimeout time.Duration
 	secureCookies   bool
+
+	oidc                  *OIDCProvider
+	passwordLoginDisabled bool
 }
 
 func NewService(store *Store, sessionSecret []byte, idleTimeout, absoluteTimeout time.Duration, secureCookies bool) *Service {
@@ -45,10 +50,15 @@ func (s *Service) EnsureBootstrapAdmin(ctx context.Context, email, password stri
 	if strings.TrimSpace(email) == "" || strings.TrimSpace(password) == "" {
 		return nil, fmt.Errorf("bootstrap admin credentials are required")
 	}
-	return s.store.CreateUser(ctx, email, password, RoleAdmin)
+	return s.store.CreateUser(ctx, email, password, RoleOwner)
 }
 
+// Login checks the password and starts a session. Users with TOTP enabled
+// get a *SecondFactorRequiredError instead and finish with CompleteLogin.
 func (s *Service) Login(ctx context.Context, w http.ResponseWriter, r *http.Request, email, password string) (Actor, error) {
+	if s.passwordLoginDisabled {
+		return AnonymousActor(), ErrPasswordLoginDisabled
+	}
 	user, err := s.store.GetUserByEmail(ctx, email)
 	if err != nil {
 		return AnonymousActor(), ErrInvalidCredentials
@@ -56,6 +66,16 @@ func (s *Service) Login(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	if err := VerifyPassword(user.PasswordHash, password); err != nil {
 		return AnonymousActor(), err
 	}
+	if user.Status != UserStatusActive {
+		return AnonymousActor(), ErrInvalidCredentials
+	}
+	if err := s.beginSecondFactor(ctx, user); err != nil {
+		return AnonymousActor(), err
+	}
+	return s.startSession(ctx, w, r, user, sessionAuthMethodPassword)
+}
+
+func (s *Service) startSession(ctx context.Context, w http.ResponseWriter, r *http.Request, user *User, method string) (Actor, error) {
 	token, err := GenerateOpaqueToken()
 	if err != nil {
 		return AnonymousActor(), err
@@ -67,14 +87,14 @@ func (s *Service) Login(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	if absoluteExpiresAt.Before(expiresAt) {
 		expiresAt = absoluteExpiresAt
 	}
-	if err := s.store.CreateSession(ctx, user.ID, tokenHash, expiresAt, absoluteExpiresAt, r.UserAgent(), extractRequestIP(r)); err != nil {
+	if err := s.store.createSession(ctx, user.ID, tokenHash, expiresAt, absoluteExpiresAt, r.UserAgent(), extractRequestIP(r), method); err != nil {
 		return AnonymousActor(), err
 	}
 	if err := s.store.UpdateLastLogin(ctx, user.ID); err != nil {
 		return AnonymousActor(), err
 	}
 	s.setSessionCookie(w, token)
-	return userToActor(*user, "session"), nil
+	return s.sessionActor(ctx, *user, method, r.Header.Get(WorkspaceHeader))
 }
 
 func (s *Service) Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -94,14 +114,14 @@ func (s *Service) AuthenticateRequest(r *http.Request) (Actor, error) {
 		return AnonymousActor(), ErrUnauthenticated
 	}
 	hash := HashOpaqueToken(s.sessionSecret, token)
-	user, err := s.store.GetSessionUserByHash(r.Context(), hash)
+	user, method, err := s.store.GetSessionUserByHash(r.Context(), hash)
 	if err != nil {
 		return AnonymousActor(), err
 	}
 	if err := s.store.TouchSession(r.Context(), hash, time.Now().UTC().Add(s.idleTimeout)); err != nil {
 		return AnonymousActor(), err
 	}
-	return userToActor(*user, "session"), nil
+	return s.sessionActor(r.Context(), *user, method, r.Header.Get(WorkspaceHeader))
 }
 
 func sessionTokenFromRequest(r *http.Request) string {
@@ -115,6 +135,49 @@ func sessionTokenFromRequest(r *http.Request) string {
 	return strings.TrimSpace(cookie.Value)
 }
 
+// actorForUser builds the actor for a user acting in one of their
+// workspaces, with their role there and limited to their site grants. An
+// empty workspaceID picks the user's first workspace.
+func (s *Service) actorForUser(ctx context.Context, user User, workspaceID, source string) (Actor, error) {
+	workspaceID, role, err := s.store.resolveMembership(ctx, user.ID, strings.TrimSpace(workspaceID))
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	user.Role = role
+	siteIDs, err := s.store.ListSiteGrants(workspace.WithID(ctx, workspaceID), user.ID)
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	actor := userToActor(user, source).ScopeToSites(siteIDs)
+	actor.WorkspaceID = workspaceID
+	return actor, nil
+}
+
+// sessionActor is actorForUser for people signed in with a session: it also
+// reports their TOTP enrollment and whether policy still requires one.
+// Single sign-on sessions leave the second factor to the identity provider.
+func (s *Service) sessionActor(ctx context.Context, user User, method, workspaceID string) (Actor, error) {
+	source := "session"
+	if method == sessionAuthMethodOIDC {
+		source = sessionAuthMethodOIDC
+	}
+	actor, err := s.actorForUser(ctx, user, workspaceID, source)
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	enabled, err := s.store.totpEnabled(ctx, user.ID)
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	policy, err := s.store.GetAuthPolicy(ctx)
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	actor.TOTPEnabled = enabled
+	actor.TOTPEnrollmentRequired = policy.RequireTOTP && !enabled && method != sessionAuthMethodOIDC
+	return actor, nil
+}
+
 func userToActor(user User, source string) Actor {
 	return Actor{
 		ID:            user.ID,
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"pressluft/internal/shared/idutil"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -62,6 +65,170 @@ func TestAuthenticateRequestRejectsAbsoluteExpiredSession(t *testing.T) {
 	}
 }
 
+func TestAuthenticateRequestScopesClientToSiteGrants(t *testing.T) {
+	service, store, _, _ := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+
+	client, err := store.CreateUser(ctx, "client@example.test", "correct horse battery staple", RoleClient)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	token := "client-session"
+	hash := HashOpaqueToken(service.sessionSecret, token)
+	now := time.Now().UTC()
+	if err := store.CreateSession(ctx, client.ID, hash, now.Add(time.Hour), now.Add(2*time.Hour), "test-agent", "192.0.2.10"); err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+	authenticate := func() Actor {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: token})
+		actor, err := service.AuthenticateRequest(req)
+		if err != nil {
+			t.Fatalf("AuthenticateRequest() error = %v", err)
+		}
+		return actor
+	}
+
+	actor := authenticate()
+	if !actor.Scoped || len(actor.SiteIDs) != 0 {
+		t.Fatalf("client without grants = scoped %v sites %v, want scoped with no sites", actor.Scoped, actor.SiteIDs)
+	}
+
+	siteID := mustNewID(t)
+	if _, err := store.ReplaceSiteGrants(ctx, client.ID, []string{siteID, siteID}); err != nil {
+		t.Fatalf("ReplaceSiteGrants() error = %v", err)
+	}
+	actor = authenticate()
+	if !actor.CanAccessSite(siteID) || len(actor.SiteIDs) != 1 {
+		t.Fatalf("client sites = %v, want only %s", actor.SiteIDs, siteID)
+	}
+	if actor.CanAccessSite(mustNewID(t)) {
+		t.Fatal("expected client to be denied an ungranted site")
+	}
+}
+
+func TestAuthenticateTokenAppliesScopesAndRevocation(t *testing.T) {
+	service, _, db, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	admin := userToActor(*user, "session")
+
+	token, secret, err := service.CreateAPIToken(ctx, admin, CreateAPITokenInput{Name: "ci", Scopes: []Capability{CapabilityReadSites, CapabilityQueueJobs}})
+	if err != nil {
+		t.Fatalf("CreateAPIToken() error = %v", err)
+	}
+	if !strings.HasPrefix(secret, token.Prefix) || token.Kind != TokenKindPersonal {
+		t.Fatalf("token = %+v secret %q, want personal token whose prefix starts the secret", token, secret)
+	}
+	var storedHash string
+	if err := db.QueryRow(`SELECT token_hash FROM api_tokens WHERE id = ?`, token.ID).Scan(&storedHash); err != nil {
+		t.Fatalf("query token hash: %v", err)
+	}
+	if storedHash == secret {
+		t.Fatal("expected the token secret to be stored hashed")
+	}
+
+	authenticate := func(bearer string) (Actor, error) {
+		req := httptest.NewRequest(http.MethodGet, "/api/sites", nil)
+		req.RemoteAddr = "192.0.2.20:4321"
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		return NewTokenAuthenticator(service, NewSessionAuthenticator(service)).Authenticate(req)
+	}
+	actor, err := authenticate(secret)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if actor.ID != user.ID || actor.TokenID != token.ID || actor.AuthSource != "api_token" {
+		t.Fatalf("actor = %+v, want user %s via token %s", actor, user.ID, token.ID)
+	}
+	if !HasCapability(actor, CapabilityQueueJobs) || HasCapability(actor, CapabilityManageUsers) {
+		t.Fatalf("capabilities = %v, want only the token scopes", actor.Capabilities)
+	}
+	if _, _, err := service.CreateAPIToken(ctx, actor, CreateAPITokenInput{Name: "nested"}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("CreateAPIToken() from token actor error = %v, want %v", err, ErrForbidden)
+	}
+
+	listed, err := service.ListAPITokens(ctx, admin)
+	if err != nil {
+		t.Fatalf("ListAPITokens() error = %v", err)
+	}
+	if len(listed) != 1 || listed[0].LastUsedAt == "" || listed[0].LastUsedIP != "192.0.2.20" {
+		t.Fatalf("listed tokens = %+v, want last use recorded", listed)
+	}
+
+	if _, err := service.RevokeAPIToken(ctx, admin, token.ID); err != nil {
+		t.Fatalf("RevokeAPIToken() error = %v", err)
+	}
+	if _, err := authenticate(secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("Authenticate() with revoked token error = %v, want %v", err, ErrUnauthenticated)
+	}
+	if _, err := authenticate("plt_unknown"); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("Authenticate() with unknown token error = %v, want %v", err, ErrUnauthenticated)
+	}
+}
+
+func TestCreateServiceTokenRequiresRoleAndUserManagement(t *testing.T) {
+	service, store, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	admin := userToActor(*user, "session")
+
+	if _, _, err := service.CreateAPIToken(ctx, admin, CreateAPITokenInput{Kind: TokenKindService, Name: "deploys", Role: RoleOwner}); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("owner service token error = %v, want %v", err, ErrInvalidAPIToken)
+	}
+	if _, _, err := service.CreateAPIToken(ctx, admin, CreateAPITokenInput{Name: "too long", Lifetime: 2 * MaxAPITokenLifetime}); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("overlong token error = %v, want %v", err, ErrInvalidAPIToken)
+	}
+
+	token, secret, err := service.CreateAPIToken(ctx, admin, CreateAPITokenInput{Kind: TokenKindService, Name: "deploys", Role: RoleDeveloper})
+	if err != nil {
+		t.Fatalf("CreateAPIToken() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/sites", nil)
+	req.Header.Set("Authorization", "Bearer "+secret)
+	actor, err := service.AuthenticateToken(req)
+	if err != nil {
+		t.Fatalf("AuthenticateToken() error = %v", err)
+	}
+	if actor.Type != ActorTypeService || actor.ID != token.ID || actor.Role != RoleDeveloper {
+		t.Fatalf("actor = %+v, want developer service actor %s", actor, token.ID)
+	}
+
+	// The token outlives neither the creator's membership nor their right to
+	// issue service tokens.
+	if _, err := store.db.ExecContext(ctx, `UPDATE workspace_members SET role = ? WHERE user_id = ?`, string(RoleDeveloper), user.ID); err != nil {
+		t.Fatalf("demote creator: %v", err)
+	}
+	if _, err := service.AuthenticateToken(req); !errors.Is(err, ErrWorkspaceAccess) {
+		t.Fatalf("AuthenticateToken() after demotion error = %v, want %v", err, ErrWorkspaceAccess)
+	}
+	if _, err := store.db.ExecContext(ctx, `DELETE FROM workspace_members WHERE user_id = ?`, user.ID); err != nil {
+		t.Fatalf("remove creator: %v", err)
+	}
+	if _, err := service.AuthenticateToken(req); !errors.Is(err, ErrWorkspaceAccess) {
+		t.Fatalf("AuthenticateToken() after removal error = %v, want %v", err, ErrWorkspaceAccess)
+	}
+
+	viewer, err := store.CreateUser(ctx, "viewer@example.test", "correct horse battery staple", RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, _, err := service.CreateAPIToken(ctx, userToActor(*viewer, "session"), CreateAPITokenInput{Kind: TokenKindService, Name: "sneaky", Role: RoleViewer}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("viewer service token error = %v, want %v", err, ErrForbidden)
+	}
+	if _, _, err := service.CreateAPIToken(ctx, userToActor(*viewer, "session"), CreateAPITokenInput{Name: "mine", Scopes: []Capability{CapabilityManageSites}}); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Fatalf("viewer scope escalation error = %v, want %v", err, ErrInvalidAPIToken)
+	}
+}
+
+func mustNewID(t *testing.T) string {
+	t.Helper()
+	id, err := idutil.New()
+	if err != nil {
+		t.Fatalf("idutil.New() error = %v", err)
+	}
+	return id
+}
+
 func newSessionServiceTestHarness(t *testing.T, idleTimeout, absoluteTimeout time.Duration) (*Service, *Store, *sql.DB, *User) {
 	t.Helper()
 
@@ -73,7 +240,20 @@ func newSessionServiceTestHarness(t *testing.T, idleTimeout, absoluteTimeout tim
 
 	for _, statement := range []string{
 		`CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT NOT NULL UNIQUE, password_hash TEXT NOT NULL, role TEXT NOT NULL, status TEXT NOT NULL DEFAULT 'active', created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), last_login_at TEXT)`,
-		`CREATE TABLE sessions (id TEXT PRIMARY KEY, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, session_hash TEXT NOT NULL UNIQUE, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), expires_at TEXT NOT NULL, absolute_expires_at TEXT, revoked_at TEXT, last_used_at TEXT, user_agent TEXT, ip TEXT)`,
+		`CREATE TABLE sessions (id TEXT PRIMARY KEY, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, session_hash TEXT NOT NULL UNIQUE, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), expires_at TEXT NOT NULL, absolute_expires_at TEXT, revoked_at TEXT, last_used_at TEXT, user_agent TEXT, ip TEXT, auth_method TEXT NOT NULL DEFAULT 'password')`,
+		`CREATE TABLE user_site_grants (user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, site_id TEXT NOT NULL, created_at TEXT NOT NULL, PRIMARY KEY (user_id, site_id))`,
+		`CREATE TABLE user_totp (user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE, secret_ciphertext TEXT NOT NULL, enabled_at TEXT, last_used_step INTEGER NOT NULL DEFAULT 0, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')))`,
+		`CREATE TABLE user_recovery_codes (id TEXT PRIMARY KEY, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, code_hash TEXT NOT NULL UNIQUE, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), used_at TEXT)`,
+		`CREATE TABLE login_challenges (id TEXT PRIMARY KEY, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, challenge_hash TEXT NOT NULL UNIQUE, attempts INTEGER NOT NULL DEFAULT 0, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), expires_at TEXT NOT NULL, consumed_at TEXT)`,
+		`CREATE TABLE auth_policy (id INTEGER PRIMARY KEY CHECK (id = 1), require_totp INTEGER NOT NULL DEFAULT 0, updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')))`,
+		`CREATE TABLE api_tokens (id TEXT PRIMARY KEY, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, kind TEXT NOT NULL, name TEXT NOT NULL, role TEXT NOT NULL, scopes TEXT NOT NULL DEFAULT '[]', token_hash TEXT NOT NULL UNIQUE, token_prefix TEXT NOT NULL, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), expires_at TEXT NOT NULL, last_used_at TEXT, last_used_ip TEXT, revoked_at TEXT, workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001')`,
+		`CREATE TABLE user_identities (issuer TEXT NOT NULL, subject TEXT NOT NULL, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, email TEXT NOT NULL, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), last_login_at TEXT, PRIMARY KEY (issuer, subject))`,
+		`CREATE TABLE oidc_login_states (id TEXT PRIMARY KEY, state_hash TEXT NOT NULL UNIQUE, nonce TEXT NOT NULL, code_verifier TEXT NOT NULL, redirect_path TEXT NOT NULL DEFAULT '/', created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), expires_at TEXT NOT NULL, consumed_at TEXT)`,
+		`CREATE TABLE user_account_tokens (id TEXT PRIMARY KEY, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, purpose TEXT NOT NULL, token_hash TEXT NOT NULL UNIQUE, created_by TEXT, created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')), expires_at TEXT NOT NULL, used_at TEXT)`,
+		`CREATE TABLE workspaces (id TEXT PRIMARY KEY, name TEXT NOT NULL, slug TEXT NOT NULL UNIQUE, created_at TEXT NOT NULL, updated_at TEXT NOT NULL)`,
+		`INSERT INTO workspaces (id, name, slug, created_at, updated_at) VALUES ('00000000-0000-7000-8000-000000000001', 'Default', 'default', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`,
+		`CREATE TABLE workspace_members (workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE, user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE, role TEXT NOT NULL, created_at TEXT NOT NULL, PRIMARY KEY (workspace_id, user_id))`,
+		`CREATE TABLE sites (id TEXT PRIMARY KEY, workspace_id TEXT NOT NULL)`,
 	} {
 		if _, err := db.Exec(statement); err != nil {
 			t.Fatalf("exec %q: %v", statement, err)
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -51,6 +52,11 @@ type Session struct {
 	LastUsedAt        string
 	UserAgent         string
 	IP                string
+	AuthMethod        string
+	// Current marks the session the listing request came with.
+	Current bool
+
+	hash string
 }
 
 type Store struct {
@@ -69,7 +75,13 @@ func (s *Store) UserCount(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
+// CreateUser creates an active user who is a member of the workspace in ctx,
+// or of the default workspace, with the given role.
 func (s *Store) CreateUser(ctx context.Context, email, password string, role Role) (*User, error) {
+	return s.createUser(ctx, email, password, role, UserStatusActive)
+}
+
+func (s *Store) createUser(ctx context.Context, email, password string, role Role, status string) (*User, error) {
 	email = strings.TrimSpace(strings.ToLower(email))
 	if email == "" || strings.TrimSpace(password) == "" {
 		return nil, fmt.Errorf("email and password are required")
@@ -85,14 +97,26 @@ func (s *Store) CreateUser(ctx context.Context, email, password string, role Rol
 	if err != nil {
 		return nil, err
 	}
-	res, err := s.db.ExecContext(ctx, `
-		INSERT INTO users (id, email, password_hash, role, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, 'active', ?, ?)
-	`, publicID, email, string(hash), string(role), now, now)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		return nil, fmt.Errorf("begin create user tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO users (id, email, password_hash, role, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, publicID, email, string(hash), string(role), status, now, now); err != nil {
 		return nil, fmt.Errorf("insert user: %w", err)
 	}
-	_ = res
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+		VALUES (?, ?, ?, ?)
+	`, workspace.ForWrite(ctx), publicID, string(role), now); err != nil {
+		return nil, fmt.Errorf("insert workspace membership: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit create user tx: %w", err)
+	}
 	return s.GetUserByID(ctx, publicID)
 }
 
@@ -152,6 +176,10 @@ func (s *Store) UpdateLastLogin(ctx context.Context, userID string) error {
 }
 
 func (s *Store) CreateSession(ctx context.Context, userID string, tokenHash string, expiresAt, absoluteExpiresAt time.Time, userAgent, ip string) error {
+	return s.createSession(ctx, userID, tokenHash, expiresAt, absoluteExpiresAt, userAgent, ip, sessionAuthMethodPassword)
+}
+
+func (s *Store) createSession(ctx context.Context, userID string, tokenHash string, expiresAt, absoluteExpiresAt time.Time, userAgent, ip, method string) error {
 	userID, err := s.lookupUserID(ctx, userID)
 	if err != nil {
 		return err
@@ -161,18 +189,20 @@ func (s *Store) CreateSession(ctx context.Context, userID string, tokenHash stri
 		return err
 	}
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO sessions (id, user_id, session_hash, created_at, expires_at, absolute_expires_at, last_used_at, user_agent, ip)
-		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?, ?)
-	`, sessionID, userID, tokenHash, expiresAt.UTC().Format(time.RFC3339), absoluteExpiresAt.UTC().Format(time.RFC3339), nullableString(userAgent), nullableString(ip))
+		INSERT INTO sessions (id, user_id, session_hash, created_at, expires_at, absolute_expires_at, last_used_at, user_agent, ip, auth_method)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?, ?, ?)
+	`, sessionID, userID, tokenHash, expiresAt.UTC().Format(time.RFC3339), absoluteExpiresAt.UTC().Format(time.RFC3339), nullableString(userAgent), nullableString(ip), method)
 	if err != nil {
 		return fmt.Errorf("insert session: %w", err)
 	}
 	return nil
 }
 
-func (s *Store) GetSessionUserByHash(ctx context.Context, tokenHash string) (*User, error) {
+// GetSessionUserByHash returns the user of a live session and how that
+// session was started.
+func (s *Store) GetSessionUserByHash(ctx context.Context, tokenHash string) (*User, string, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT u.id, u.email, u.password_hash, u.role, u.status, u.created_at, u.updated_at, COALESCE(u.last_login_at, '')
+		SELECT u.id, u.email, u.password_hash, u.role, u.status, u.created_at, u.updated_at, COALESCE(u.last_login_at, ''), s.auth_method
 		FROM sessions s
 		JOIN users u ON u.id = s.user_id
 		WHERE s.session_hash = ?
@@ -183,15 +213,15 @@ func (s *Store) GetSessionUserByHash(ctx context.Context, tokenHash string) (*Us
 		LIMIT 1
 	`, tokenHash)
 	var user User
-	var role string
-	if err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &role, &user.Status, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt); err != nil {
+	var role, method string
+	if err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &role, &user.Status, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt, &method); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrUnauthenticated
+			return nil, "", ErrUnauthenticated
 		}
-		return nil, fmt.Errorf("lookup session user: %w", err)
+		return nil, "", fmt.Errorf("lookup session user: %w", err)
 	}
 	user.Role = Role(role)
-	return &user, nil
+	return &user, method, nil
 }
 
 func (s *Store) TouchSession(ctx context.Context, tokenHash string, expiresAt time.Time) error {
@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+)
+
+type TokenKind string
+
+const (
+	// TokenKindPersonal tokens act as the user who created them.
+	TokenKindPersonal TokenKind = "personal"
+	// TokenKindService tokens carry their own role and outlive their
+	// creator's sessions; they are meant for CI pipelines.
+	TokenKindService TokenKind = "service"
+)
+
+const (
+	APITokenPrefix          = "plt_"
+	DefaultAPITokenLifetime = 90 * 24 * time.Hour
+	MaxAPITokenLifetime     = 365 * 24 * time.Hour
+	apiTokenAuthSource      = "api_token"
+)
+
+var (
+	ErrAPITokenNotFound = errors.New("api token not found")
+	ErrInvalidAPIToken  = errors.New("invalid api token")
+	ErrForbidden        = errors.New("forbidden")
+)
+
+type APIToken struct {
+	ID     string
+	UserID string
+	// WorkspaceID is the workspace the token acts in.
+	WorkspaceID string
+	Kind        TokenKind
+	Name        string
+	Role        Role
+	Scopes      []Capability
+	Prefix      string
+	CreatedAt   string
+	ExpiresAt   string
+	LastUsedAt  string
+	LastUsedIP  string
+	RevokedAt   string
+}
+
+type CreateAPITokenInput struct {
+	Kind TokenKind
+	Name string
+	// Role is only used for service tokens; personal tokens follow their
+	// user's role.
+	Role     Role
+	Scopes   []Capability
+	Lifetime time.Duration
+}
+
+// CreateAPIToken issues a token for the creator and returns it together with
+// the secret, which is never stored and cannot be shown again.
+func (s *Service) CreateAPIToken(ctx context.Context, creator Actor, in CreateAPITokenInput) (APIToken, string, error) {
+	if !creator.IsAuthenticated() || creator.TokenID != "" || creator.Type != ActorTypeOperator {
+		return APIToken{}, "", ErrForbidden
+	}
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return APIToken{}, "", fmt.Errorf("%w: name is required", ErrInvalidAPIToken)
+	}
+	lifetime := in.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultAPITokenLifetime
+	}
+	if lifetime > MaxAPITokenLifetime {
+		return APIToken{}, "", fmt.Errorf("%w: tokens expire after at most %d days", ErrInvalidAPIToken, int(MaxAPITokenLifetime.Hours()/24))
+	}
+
+	role := creator.Role
+	switch in.Kind {
+	case TokenKindPersonal, "":
+		in.Kind = TokenKindPersonal
+	case TokenKindService:
+		if !HasCapability(creator, CapabilityManageUsers) {
+			return APIToken{}, "", ErrForbidden
+		}
+		role = in.Role
+		if !role.Valid() || role == RoleOwner {
+			return APIToken{}, "", fmt.Errorf("%w: service tokens need a role other than owner", ErrInvalidAPIToken)
+		}
+	default:
+		return APIToken{}, "", fmt.Errorf("%w: unsupported token kind %q", ErrInvalidAPIToken, in.Kind)
+	}
+	for _, scope := range in.Scopes {
+		if !containsCapability(RoleCapabilities(role), scope) {
+			return APIToken{}, "", fmt.Errorf("%w: role %s cannot grant scope %q", ErrInvalidAPIToken, role, scope)
+		}
+	}
+
+	secret, err := GenerateOpaqueToken()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	secret = APITokenPrefix + secret
+	token, err := s.store.CreateAPIToken(ctx, APIToken{
+		UserID:      creator.ID,
+		WorkspaceID: creator.Workspace(),
+		Kind:        in.Kind,
+		Name:        name,
+		Role:        role,
+		Scopes:      in.Scopes,
+		Prefix:      secret[:len(APITokenPrefix)+6],
+		ExpiresAt:   time.Now().UTC().Add(lifetime).Format(time.RFC3339),
+	}, HashOpaqueToken(s.sessionSecret, secret))
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	return *token, secret, nil
+}
+
+// ListAPITokens returns the actor's own tokens in their current workspace,
+// or every token of the workspace for actors allowed to manage users.
+func (s *Service) ListAPITokens(ctx context.Context, actor Actor) ([]APIToken, error) {
+	if HasCapability(actor, CapabilityManageUsers) {
+		return s.store.ListAPITokens(ctx, actor.Workspace(), "")
+	}
+	return s.store.ListAPITokens(ctx, actor.Workspace(), actor.ID)
+}
+
+// RevokeAPIToken revokes one of the actor's tokens; user managers may
+// revoke any token of their workspace.
+func (s *Service) RevokeAPIToken(ctx context.Context, actor Actor, id string) (APIToken, error) {
+	token, err := s.store.GetAPIToken(ctx, id)
+	if err != nil {
+		return APIToken{}, err
+	}
+	if token.UserID != actor.ID && (!HasCapability(actor, CapabilityManageUsers) || token.WorkspaceID != actor.Workspace()) {
+		return APIToken{}, ErrAPITokenNotFound
+	}
+	if err := s.store.RevokeAPIToken(ctx, token.ID); err != nil {
+		return APIToken{}, err
+	}
+	return s.store.getAPIToken(ctx, token.ID)
+}
+
+// AuthenticateToken resolves the bearer token on the request and records
+// when and from where it was last used.
+func (s *Service) AuthenticateToken(r *http.Request) (Actor, error) {
+	secret := bearerTokenFromRequest(r)
+	if secret == "" {
+		return AnonymousActor(), ErrUnauthenticated
+	}
+	token, user, err := s.store.GetAPITokenByHash(r.Context(), HashOpaqueToken(s.sessionSecret, secret))
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	if err := s.store.TouchAPIToken(r.Context(), token.ID, extractRequestIP(r)); err != nil {
+		return AnonymousActor(), err
+	}
+
+	if token.Kind == TokenKindService {
+		// A service token stops working once its creator leaves the
+		// workspace or may no longer issue service tokens there.
+		_, creatorRole, err := s.store.resolveMembership(r.Context(), user.ID, token.WorkspaceID)
+		if err != nil {
+			return AnonymousActor(), err
+		}
+		if !containsCapability(RoleCapabilities(creatorRole), CapabilityManageUsers) {
+			return AnonymousActor(), ErrWorkspaceAccess
+		}
+		actor := Actor{
+			ID:            token.ID,
+			Type:          ActorTypeService,
+			Role:          token.Role,
+			Capabilities:  EffectiveCapabilities(token.Role, token.Scopes),
+			Authenticated: true,
+			AuthSource:    apiTokenAuthSource,
+			TokenID:       token.ID,
+			Scopes:        token.Scopes,
+			WorkspaceID:   token.WorkspaceID,
+		}
+		return actor.ScopeToSites(nil), nil
+	}
+	// A personal token stops working once its user leaves the workspace.
+	actor, err := s.actorForUser(r.Context(), *user, token.WorkspaceID, apiTokenAuthSource)
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	actor.TokenID = token.ID
+	actor.Scopes = token.Scopes
+	actor.Capabilities = EffectiveCapabilities(actor.Role, token.Scopes)
+	return actor, nil
+}
+
+func bearerTokenFromRequest(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	scheme, value, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+func (s *Store) CreateAPIToken(ctx context.Context, in APIToken, tokenHash string) (*APIToken, error) {
+	userID, err := s.lookupUserID(ctx, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := json.Marshal(nonNilCapabilities(in.Scopes))
+	if err != nil {
+		return nil, fmt.Errorf("encode token scopes: %w", err)
+	}
+	id, err := idutil.New()
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO api_tokens (id, workspace_id, user_id, kind, name, role, scopes, token_hash, token_prefix, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?)
+	`, id, in.WorkspaceID, userID, string(in.Kind), in.Name, string(in.Role), string(scopes), tokenHash, in.Prefix, in.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert api token: %w", err)
+	}
+	token, err := s.getAPIToken(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListAPITokens lists the tokens of a workspace newest first. An empty
+// userID lists the tokens of all its users.
+func (s *Store) ListAPITokens(ctx context.Context, workspaceID, userID string) ([]APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE workspace_id = ?`
+	args := []any{workspaceID}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list api tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]APIToken, 0)
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *Store) GetAPIToken(ctx context.Context, id string) (APIToken, error) {
+	publicID, err := idutil.Normalize(id)
+	if err != nil {
+		return APIToken{}, ErrAPITokenNotFound
+	}
+	return s.getAPIToken(ctx, publicID)
+}
+
+func (s *Store) getAPIToken(ctx context.Context, id string) (APIToken, error) {
+	token, err := scanAPIToken(s.db.QueryRowContext(ctx, `SELECT `+apiTokenColumns+` FROM api_tokens WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIToken{}, ErrAPITokenNotFound
+	}
+	return token, err
+}
+
+// GetAPITokenByHash returns a usable token and its user. Revoked and expired
+// tokens, and tokens of suspended users, are rejected.
+func (s *Store) GetAPITokenByHash(ctx context.Context, tokenHash string) (APIToken, *User, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT t.id, t.user_id, t.workspace_id, t.kind, t.name, t.role, t.scopes, t.token_prefix, t.created_at, t.expires_at,
+		       COALESCE(t.last_used_at, ''), COALESCE(t.last_used_ip, ''), COALESCE(t.revoked_at, ''),
+		       u.id, u.email, u.password_hash, u.role, u.status, u.created_at, u.updated_at, COALESCE(u.last_login_at, '')
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ?
+		  AND t.revoked_at IS NULL
+		  AND datetime(t.expires_at) > datetime('now')
+		  AND u.status = 'active'
+		LIMIT 1
+	`, tokenHash)
+	var (
+		token    APIToken
+		user     User
+		kind     string
+		role     string
+		scopes   string
+		userRole string
+	)
+	if err := row.Scan(
+		&token.ID, &token.UserID, &token.WorkspaceID, &kind, &token.Name, &role, &scopes, &token.Prefix, &token.CreatedAt, &token.ExpiresAt,
+		&token.LastUsedAt, &token.LastUsedIP, &token.RevokedAt,
+		&user.ID, &user.Email, &user.PasswordHash, &userRole, &user.Status, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIToken{}, nil, ErrUnauthenticated
+		}
+		return APIToken{}, nil, fmt.Errorf("lookup api token: %w", err)
+	}
+	token.Kind = TokenKind(kind)
+	token.Role = Role(role)
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+		return APIToken{}, nil, fmt.Errorf("decode token scopes: %w", err)
+	}
+	user.Role = Role(userRole)
+	return token, &user, nil
+}
+
+func (s *Store) TouchAPIToken(ctx context.Context, id, ip string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE api_tokens
+		SET last_used_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+		    last_used_ip = ?
+		WHERE id = ?
+	`, nullableString(ip), id)
+	if err != nil {
+		return fmt.Errorf("touch api token: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RevokeAPIToken(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE api_tokens
+		SET revoked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ?
+		  AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("revoke api token: %w", err)
+	}
+	return nil
+}
+
+const apiTokenColumns = `id, user_id, workspace_id, kind, name, role, scopes, token_prefix, created_at, expires_at,
+	COALESCE(last_used_at, ''), COALESCE(last_used_ip, ''), COALESCE(revoked_at, '')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIToken(row rowScanner) (APIToken, error) {
+	var (
+		token  APIToken
+		kind   string
+		role   string
+		scopes string
+	)
+	if err := row.Scan(&token.ID, &token.UserID, &token.WorkspaceID, &kind, &token.Name, &role, &scopes, &token.Prefix, &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt, &token.LastUsedIP, &token.RevokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return APIToken{}, err
+		}
+		return APIToken{}, fmt.Errorf("scan api token: %w", err)
+	}
+	token.Kind = TokenKind(kind)
+	token.Role = Role(role)
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+		return APIToken{}, fmt.Errorf("decode token scopes: %w", err)
+	}
+	return token, nil
+}
+
+func nonNilCapabilities(values []Capability) []Capability {
+	if values == nil {
+		return []Capability{}
+	}
+	return values
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1 seed "12345678901234567890", truncated to
+	// six digits.
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	for _, tc := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	} {
+		got, err := TOTPCode(secret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) error = %v", tc.unix, err)
+		}
+		if got != tc.want {
+			t.Fatalf("TOTPCode(%d) = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestVerifyTOTPRejectsReplayAndDistantSteps(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	code, _ := TOTPCode(secret, now)
+
+	step, ok := verifyTOTP(secret, code, now, 0)
+	if !ok || step != totpStep(now) {
+		t.Fatalf("verifyTOTP() = %d, %v; want current step", step, ok)
+	}
+	if _, ok := verifyTOTP(secret, code, now, step); ok {
+		t.Fatal("expected a used step to be refused")
+	}
+	if _, ok := verifyTOTP(secret, code, now.Add(5*time.Minute), 0); ok {
+		t.Fatal("expected a code from five minutes ago to be refused")
+	}
+}
+
+func TestTOTPLoginRequiresSecondFactor(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	service, _, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	actor := userToActor(*user, "session")
+
+	enrollment, err := service.BeginTOTPEnrollment(ctx, actor)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment() error = %v", err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/") || !strings.Contains(enrollment.ProvisioningURI, "secret="+enrollment.Secret) {
+		t.Fatalf("provisioning uri = %q", enrollment.ProvisioningURI)
+	}
+	if _, err := service.ConfirmTOTPEnrollment(ctx, actor, "not-a-code"); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("ConfirmTOTPEnrollment() with a wrong code error = %v", err)
+	}
+	code, _ := TOTPCode(enrollment.Secret, time.Now())
+	recoveryCodes, err := service.ConfirmTOTPEnrollment(ctx, actor, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment() error = %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("recovery codes = %d, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+
+	login := func() string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+		res := httptest.NewRecorder()
+		_, err := service.Login(ctx, res, req, user.Email, "correct horse battery staple")
+		var required *SecondFactorRequiredError
+		if !errors.As(err, &required) {
+			t.Fatalf("Login() error = %v, want second factor required", err)
+		}
+		if len(res.Result().Cookies()) != 0 {
+			t.Fatal("expected no session cookie before the second factor")
+		}
+		return required.Challenge
+	}
+	complete := func(challenge, code string) (Actor, bool, error) {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login/totp", nil)
+		return service.CompleteLogin(ctx, httptest.NewRecorder(), req, challenge, code)
+	}
+
+	challenge := login()
+	if _, _, err := complete(challenge, "not-a-code"); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("CompleteLogin() with a wrong code error = %v, want %v", err, ErrInvalidSecondFactor)
+	}
+	// The code used for enrollment has been spent, so only a recovery code
+	// gets in within the same step.
+	if _, _, err := complete(challenge, code); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("CompleteLogin() with a replayed code error = %v, want %v", err, ErrInvalidSecondFactor)
+	}
+	signedIn, usedRecoveryCode, err := complete(challenge, strings.ToUpper(recoveryCodes[0]))
+	if err != nil {
+		t.Fatalf("CompleteLogin() with a recovery code error = %v", err)
+	}
+	if !usedRecoveryCode || signedIn.ID != user.ID || !signedIn.TOTPEnabled {
+		t.Fatalf("actor = %+v usedRecoveryCode %v, want user with TOTP via recovery code", signedIn, usedRecoveryCode)
+	}
+	if _, _, err := complete(challenge, recoveryCodes[1]); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("CompleteLogin() with a consumed challenge error = %v, want %v", err, ErrUnauthenticated)
+	}
+	if _, _, err := complete(login(), recoveryCodes[0]); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("CompleteLogin() with a spent recovery code error = %v, want %v", err, ErrInvalidSecondFactor)
+	}
+
+	challenge = login()
+	for range maxLoginChallengeAttempts {
+		_, _, _ = complete(challenge, "000000")
+	}
+	if _, _, err := complete(challenge, recoveryCodes[2]); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("CompleteLogin() after too many attempts error = %v, want %v", err, ErrUnauthenticated)
+	}
+}
+
+func TestTOTPPolicyFlagsUnenrolledSessions(t *testing.T) {
+	service, store, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+
+	// The policy covers every workspace, so only default-workspace owners
+	// may change it.
+	owner, err := store.CreateUser(ctx, "owner@example.test", "correct horse battery staple", RoleOwner)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	clientOwner := userToActor(*owner, "session")
+	clientOwner.WorkspaceID = "00000000-0000-7000-8000-0000000000c1"
+	for _, forbidden := range []Actor{userToActor(*user, "session"), clientOwner} {
+		if _, err := service.UpdateAuthPolicy(ctx, forbidden, AuthPolicy{RequireTOTP: true}); !errors.Is(err, ErrForbidden) {
+			t.Fatalf("UpdateAuthPolicy() as %s in %s error = %v, want %v", forbidden.Role, forbidden.Workspace(), err, ErrForbidden)
+		}
+	}
+	if _, err := service.UpdateAuthPolicy(ctx, userToActor(*owner, "session"), AuthPolicy{RequireTOTP: true}); err != nil {
+		t.Fatalf("UpdateAuthPolicy() error = %v", err)
+	}
+	actor, err := service.sessionActor(ctx, *user, sessionAuthMethodPassword, "")
+	if err != nil {
+		t.Fatalf("sessionActor() error = %v", err)
+	}
+	if !actor.TOTPEnrollmentRequired {
+		t.Fatal("expected an unenrolled session to require enrollment")
+	}
+	if err := service.DisableTOTP(ctx, actor, "000000"); !errors.Is(err, ErrTOTPRequiredByPolicy) {
+		t.Fatalf("DisableTOTP() error = %v, want %v", err, ErrTOTPRequiredByPolicy)
+	}
+	if err := store.ResetTOTP(ctx, user.ID); err != nil {
+		t.Fatalf("ResetTOTP() error = %v", err)
+	}
+}
+
+func TestResetTOTPProtectsOwnersAndSharedUsers(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	enroll := func(email string, role Role) *User {
+		t.Helper()
+		user, err := store.CreateUser(ctx, email, "correct horse battery staple", role)
+		if err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		actor := userToActor(*user, "session")
+		enrollment, err := service.BeginTOTPEnrollment(ctx, actor)
+		if err != nil {
+			t.Fatalf("BeginTOTPEnrollment() error = %v", err)
+		}
+		code, _ := TOTPCode(enrollment.Secret, time.Now())
+		if _, err := service.ConfirmTOTPEnrollment(ctx, actor, code); err != nil {
+			t.Fatalf("ConfirmTOTPEnrollment() error = %v", err)
+		}
+		return user
+	}
+	enrolled := func(user *User) bool {
+		t.Helper()
+		status, err := service.TOTPStatus(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("TOTPStatus() error = %v", err)
+		}
+		return status.Enabled
+	}
+	owner := enroll("owner@agency.test", RoleOwner)
+	developer := enroll("dev@agency.test", RoleDeveloper)
+	adminActor := operatorActor(admin)
+
+	// Without the owner's second factor an admin could reset their
+	// password next and take the account over.
+	if _, err := service.ResetTOTP(ctx, adminActor, owner.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("admin resetting an owner error = %v, want ErrForbidden", err)
+	}
+	if !enrolled(owner) {
+		t.Fatal("expected the owner to keep their second factor")
+	}
+
+	client, err := service.CreateWorkspace(ctx, operatorActor(owner), "Client Co")
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+	ownerInClient := operatorActor(owner)
+	ownerInClient.WorkspaceID = client.ID
+	if _, err := service.AddWorkspaceMember(ctx, ownerInClient, developer.Email, RoleDeveloper); err != nil {
+		t.Fatalf("AddWorkspaceMember() error = %v", err)
+	}
+	for _, actor := range []Actor{adminActor, ownerInClient} {
+		if _, err := service.ResetTOTP(ctx, actor, developer.ID); !errors.Is(err, ErrInvalidUser) {
+			t.Fatalf("resetting a shared user from %s error = %v, want ErrInvalidUser", actor.Workspace(), err)
+		}
+	}
+	if !enrolled(developer) {
+		t.Fatal("expected the shared user to keep their second factor")
+	}
+
+	if _, err := service.DeleteUser(ctx, ownerInClient, developer.ID); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := service.ResetTOTP(ctx, adminActor, developer.ID); err != nil {
+		t.Fatalf("ResetTOTP() error = %v", err)
+	}
+	if enrolled(developer) {
+		t.Fatal("expected the developer's second factor to be removed")
+	}
+}
@@ -0,0 +1,585 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/workspace"
+)
+
+const (
+	LoginChallengeLifetime    = 5 * time.Minute
+	maxLoginChallengeAttempts = 5
+	recoveryCodeCount         = 10
+)
+
+var (
+	ErrInvalidSecondFactor  = errors.New("invalid two-factor code")
+	ErrTOTPNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTOTPAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTOTPRequiredByPolicy = errors.New("two-factor authentication is required by policy")
+)
+
+// SecondFactorRequiredError is returned by Login when the password was right
+// but the user still has to present a TOTP or recovery code. The challenge
+// is traded for a session with CompleteLogin.
+type SecondFactorRequiredError struct {
+	UserID    string
+	Challenge string
+	ExpiresAt time.Time
+}
+
+func (e *SecondFactorRequiredError) Error() string {
+	return "second factor required"
+}
+
+// SecondFactorError reports a rejected code for a known login challenge so
+// that the caller can attribute the failure.
+type SecondFactorError struct {
+	UserID string
+	Email  string
+}
+
+func (e *SecondFactorError) Error() string {
+	return ErrInvalidSecondFactor.Error()
+}
+
+func (e *SecondFactorError) Is(target error) bool {
+	return target == ErrInvalidSecondFactor
+}
+
+type TOTPEnrollment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+type TOTPStatus struct {
+	Enabled                bool
+	EnabledAt              string
+	RecoveryCodesRemaining int
+	RequiredByPolicy       bool
+}
+
+type AuthPolicy struct {
+	RequireTOTP bool
+	UpdatedAt   string
+}
+
+// BeginTOTPEnrollment creates a new unconfirmed secret for the actor. It only
+// takes effect once ConfirmTOTPEnrollment sees a code generated from it.
+func (s *Service) BeginTOTPEnrollment(ctx context.Context, actor Actor) (TOTPEnrollment, error) {
+	if !isSessionActor(actor) {
+		return TOTPEnrollment{}, ErrForbidden
+	}
+	state, err := s.store.getTOTP(ctx, actor.ID)
+	if err != nil && !errors.Is(err, ErrTOTPNotEnabled) {
+		return TOTPEnrollment{}, err
+	}
+	if err == nil && state.EnabledAt != "" {
+		return TOTPEnrollment{}, ErrTOTPAlreadyEnabled
+	}
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	ciphertext, err := security.EncryptTOTPSecret(secret)
+	if err != nil {
+		return TOTPEnrollment{}, fmt.Errorf("encrypt totp secret: %w", err)
+	}
+	if err := s.store.saveTOTPSecret(ctx, actor.ID, ciphertext); err != nil {
+		return TOTPEnrollment{}, err
+	}
+	return TOTPEnrollment{Secret: secret, ProvisioningURI: TOTPProvisioningURI(secret, actor.Email)}, nil
+}
+
+// ConfirmTOTPEnrollment enables TOTP once the actor proves their app works
+// and returns fresh recovery codes, which are only shown this once.
+func (s *Service) ConfirmTOTPEnrollment(ctx context.Context, actor Actor, code string) ([]string, error) {
+	if !isSessionActor(actor) {
+		return nil, ErrForbidden
+	}
+	state, err := s.store.getTOTP(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state.EnabledAt != "" {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	secret, err := security.DecryptTOTPSecret(state.SecretCiphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt totp secret: %w", err)
+	}
+	step, ok := verifyTOTP(secret, code, time.Now(), state.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidSecondFactor
+	}
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.enableTOTP(ctx, actor.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns TOTP off for the actor after checking a current code or
+// an unused recovery code. It is refused while policy requires TOTP.
+func (s *Service) DisableTOTP(ctx context.Context, actor Actor, code string) error {
+	if !isSessionActor(actor) {
+		return ErrForbidden
+	}
+	policy, err := s.store.GetAuthPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if policy.RequireTOTP {
+		return ErrTOTPRequiredByPolicy
+	}
+	if _, err := s.verifySecondFactor(ctx, actor.ID, code); err != nil {
+		return err
+	}
+	return s.store.deleteTOTP(ctx, actor.ID)
+}
+
+// ResetTOTP removes another user's second factor so they can enroll again.
+// Like a password reset, it would let an admin take over an owner's
+// account, and it is limited to users who belong to no other workspace.
+func (s *Service) ResetTOTP(ctx context.Context, actor Actor, userID string) (*User, error) {
+	user, err := s.managedUser(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureSingleWorkspace(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.store.ResetTOTP(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Service) TOTPStatus(ctx context.Context, userID string) (TOTPStatus, error) {
+	policy, err := s.store.GetAuthPolicy(ctx)
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+	status := TOTPStatus{RequiredByPolicy: policy.RequireTOTP}
+	state, err := s.store.getTOTP(ctx, userID)
+	if errors.Is(err, ErrTOTPNotEnabled) {
+		return status, nil
+	}
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+	if state.EnabledAt == "" {
+		return status, nil
+	}
+	status.Enabled = true
+	status.EnabledAt = state.EnabledAt
+	status.RecoveryCodesRemaining, err = s.store.countRecoveryCodes(ctx, userID)
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+	return status, nil
+}
+
+func (s *Service) AuthPolicy(ctx context.Context) (AuthPolicy, error) {
+	return s.store.GetAuthPolicy(ctx)
+}
+
+// UpdateAuthPolicy changes the login policy. It applies to every workspace,
+// so only owners of the default workspace may change it.
+func (s *Service) UpdateAuthPolicy(ctx context.Context, actor Actor, policy AuthPolicy) (AuthPolicy, error) {
+	if !actor.IsAuthenticated() || actor.Role != RoleOwner || actor.Workspace() != workspace.DefaultID {
+		return AuthPolicy{}, ErrForbidden
+	}
+	if err := s.store.UpdateAuthPolicy(ctx, policy); err != nil {
+		return AuthPolicy{}, err
+	}
+	return s.store.GetAuthPolicy(ctx)
+}
+
+// CompleteLogin trades a login challenge and a TOTP or recovery code for a
+// session. usedRecoveryCode reports which of the two was accepted. A
+// challenge is burned after too many wrong codes.
+func (s *Service) CompleteLogin(ctx context.Context, w http.ResponseWriter, r *http.Request, challenge, code string) (actor Actor, usedRecoveryCode bool, err error) {
+	record, err := s.store.getLoginChallenge(ctx, HashOpaqueToken(s.sessionSecret, strings.TrimSpace(challenge)))
+	if err != nil {
+		return AnonymousActor(), false, err
+	}
+	user, err := s.store.GetUserByID(ctx, record.UserID)
+	if err != nil {
+		return AnonymousActor(), false, ErrUnauthenticated
+	}
+	usedRecoveryCode, err = s.verifySecondFactor(ctx, user.ID, code)
+	if errors.Is(err, ErrInvalidSecondFactor) {
+		if recordErr := s.store.recordLoginChallengeFailure(ctx, record.ID); recordErr != nil {
+			return AnonymousActor(), false, recordErr
+		}
+		return AnonymousActor(), false, &SecondFactorError{UserID: user.ID, Email: user.Email}
+	}
+	if err != nil {
+		return AnonymousActor(), false, err
+	}
+	consumed, err := s.store.consumeLoginChallenge(ctx, record.ID)
+	if err != nil {
+		return AnonymousActor(), false, err
+	}
+	if !consumed {
+		return AnonymousActor(), false, ErrUnauthenticated
+	}
+	actor, err = s.startSession(ctx, w, r, user, sessionAuthMethodPassword)
+	return actor, usedRecoveryCode, err
+}
+
+// beginSecondFactor issues a login challenge when the user has TOTP enabled.
+func (s *Service) beginSecondFactor(ctx context.Context, user *User) error {
+	state, err := s.store.getTOTP(ctx, user.ID)
+	if errors.Is(err, ErrTOTPNotEnabled) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if state.EnabledAt == "" {
+		return nil
+	}
+	challenge, err := GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().UTC().Add(LoginChallengeLifetime)
+	if err := s.store.createLoginChallenge(ctx, user.ID, HashOpaqueToken(s.sessionSecret, challenge), expiresAt); err != nil {
+		return err
+	}
+	return &SecondFactorRequiredError{UserID: user.ID, Challenge: challenge, ExpiresAt: expiresAt}
+}
+
+// verifySecondFactor accepts a TOTP code or, failing that, an unused
+// recovery code, which is then spent.
+func (s *Service) verifySecondFactor(ctx context.Context, userID, code string) (usedRecoveryCode bool, err error) {
+	state, err := s.store.getTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if state.EnabledAt == "" {
+		return false, ErrTOTPNotEnabled
+	}
+	secret, err := security.DecryptTOTPSecret(state.SecretCiphertext)
+	if err != nil {
+		return false, fmt.Errorf("decrypt totp secret: %w", err)
+	}
+	if step, ok := verifyTOTP(secret, code, time.Now(), state.LastUsedStep); ok {
+		advanced, err := s.store.advanceTOTPStep(ctx, userID, step)
+		if err != nil {
+			return false, err
+		}
+		if !advanced {
+			return false, ErrInvalidSecondFactor
+		}
+		return false, nil
+	}
+	used, err := s.store.useRecoveryCode(ctx, userID, HashOpaqueToken(s.sessionSecret, normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, err
+	}
+	if !used {
+		return false, ErrInvalidSecondFactor
+	}
+	return true, nil
+}
+
+func (s *Service) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for range recoveryCodeCount {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, HashOpaqueToken(s.sessionSecret, raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, spaces and dashes so that codes can be
+// typed the way they were printed or not.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// isSessionActor reports whether the actor is a person signed in with a
+// session, the only kind of actor that manages its own second factor.
+func isSessionActor(actor Actor) bool {
+	return actor.IsAuthenticated() && actor.Type == ActorTypeOperator && actor.TokenID == ""
+}
+
+type userTOTP struct {
+	SecretCiphertext string
+	EnabledAt        string
+	LastUsedStep     int64
+}
+
+type loginChallenge struct {
+	ID     string
+	UserID string
+}
+
+func (s *Store) getTOTP(ctx context.Context, userID string) (userTOTP, error) {
+	var state userTOTP
+	err := s.db.QueryRowContext(ctx, `
+		SELECT secret_ciphertext, COALESCE(enabled_at, ''), last_used_step
+		FROM user_totp WHERE user_id = ?
+	`, userID).Scan(&state.SecretCiphertext, &state.EnabledAt, &state.LastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return userTOTP{}, ErrTOTPNotEnabled
+	}
+	if err != nil {
+		return userTOTP{}, fmt.Errorf("get totp: %w", err)
+	}
+	return state, nil
+}
+
+// totpEnabled reports whether the user has a confirmed TOTP enrollment.
+func (s *Store) totpEnabled(ctx context.Context, userID string) (bool, error) {
+	state, err := s.getTOTP(ctx, userID)
+	if errors.Is(err, ErrTOTPNotEnabled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return state.EnabledAt != "", nil
+}
+
+func (s *Store) saveTOTPSecret(ctx context.Context, userID, ciphertext string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret_ciphertext, enabled_at, last_used_step, created_at, updated_at)
+		VALUES (?, ?, NULL, 0, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		ON CONFLICT(user_id) DO UPDATE SET
+		    secret_ciphertext = excluded.secret_ciphertext,
+		    enabled_at = NULL,
+		    last_used_step = 0,
+		    updated_at = excluded.updated_at
+	`, userID, ciphertext)
+	if err != nil {
+		return fmt.Errorf("save totp secret: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) enableTOTP(ctx context.Context, userID string, step int64, recoveryCodeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin totp enrollment: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_totp
+		SET enabled_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now'),
+		    last_used_step = ?,
+		    updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE user_id = ?
+	`, step, userID); err != nil {
+		return fmt.Errorf("enable totp: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("clear recovery codes: %w", err)
+	}
+	for _, hash := range recoveryCodeHashes {
+		id, err := idutil.New()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at)
+			VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		`, id, userID, hash); err != nil {
+			return fmt.Errorf("insert recovery code: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit totp enrollment: %w", err)
+	}
+	return nil
+}
+
+// advanceTOTPStep records the step of an accepted code. It reports false when
+// a concurrent request already used this or a later step.
+func (s *Store) advanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE user_totp
+		SET last_used_step = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE user_id = ? AND last_used_step < ?
+	`, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("advance totp step: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("advance totp step: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (s *Store) deleteTOTP(ctx context.Context, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin totp removal: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("delete totp: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit totp removal: %w", err)
+	}
+	return nil
+}
+
+// ResetTOTP removes a user's TOTP enrollment and recovery codes, for
+// operators who lost both their device and their codes.
+func (s *Store) ResetTOTP(ctx context.Context, userID string) error {
+	userID, err := s.lookupUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.deleteTOTP(ctx, userID)
+}
+
+func (s *Store) useRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("use recovery code: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (s *Store) countRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL
+	`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Store) createLoginChallenge(ctx context.Context, userID, challengeHash string, expiresAt time.Time) error {
+	id, err := idutil.New()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO login_challenges (id, user_id, challenge_hash, created_at, expires_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?)
+	`, id, userID, challengeHash, expiresAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("insert login challenge: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) getLoginChallenge(ctx context.Context, challengeHash string) (loginChallenge, error) {
+	var challenge loginChallenge
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id
+		FROM login_challenges
+		WHERE challenge_hash = ?
+		  AND consumed_at IS NULL
+		  AND attempts < ?
+		  AND datetime(expires_at) > datetime('now')
+	`, challengeHash, maxLoginChallengeAttempts).Scan(&challenge.ID, &challenge.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return loginChallenge{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return loginChallenge{}, fmt.Errorf("get login challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+func (s *Store) recordLoginChallengeFailure(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE login_challenges SET attempts = attempts + 1 WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("record login challenge failure: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) consumeLoginChallenge(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE login_challenges
+		SET consumed_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ? AND consumed_at IS NULL
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("consume login challenge: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("consume login challenge: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// GetAuthPolicy returns the login policy. A missing row means the defaults.
+func (s *Store) GetAuthPolicy(ctx context.Context) (AuthPolicy, error) {
+	var (
+		policy      AuthPolicy
+		requireTOTP int
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT require_totp, updated_at FROM auth_policy WHERE id = 1`).Scan(&requireTOTP, &policy.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuthPolicy{}, nil
+	}
+	if err != nil {
+		return AuthPolicy{}, fmt.Errorf("get auth policy: %w", err)
+	}
+	policy.RequireTOTP = requireTOTP != 0
+	return policy, nil
+}
+
+func (s *Store) UpdateAuthPolicy(ctx context.Context, policy AuthPolicy) error {
+	requireTOTP := 0
+	if policy.RequireTOTP {
+		requireTOTP = 1
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO auth_policy (id, require_totp, updated_at)
+		VALUES (1, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		ON CONFLICT(id) DO UPDATE SET
+		    require_totp = excluded.require_totp,
+		    updated_at = excluded.updated_at
+	`, requireTOTP)
+	if err != nil {
+		return fmt.Errorf("update auth policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,647 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	UserStatusActive    = "active"
+	UserStatusInvited   = "invited"
+	UserStatusSuspended = "suspended"
+
+	MinPasswordLength = 12
+	// maxPasswordBytes is the most bcrypt looks at.
+	maxPasswordBytes = 72
+
+	DefaultInvitationLifetime = 7 * 24 * time.Hour
+	MaxInvitationLifetime     = 30 * 24 * time.Hour
+	PasswordResetLifetime     = 24 * time.Hour
+
+	accountTokenInvitation    = "invitation"
+	accountTokenPasswordReset = "password_reset"
+)
+
+var (
+	// ErrInvalidUser wraps every rejected user management request; the
+	// message says what was wrong.
+	ErrInvalidUser = errors.New("invalid user")
+	ErrUserExists  = errors.New("a user with this email already exists")
+	ErrLastOwner   = errors.New("the last active owner cannot be removed, suspended or demoted")
+	// ErrInvalidPassword means a new password is too short or too long.
+	ErrInvalidPassword = errors.New("invalid password")
+	// ErrInvalidAccountToken means an invitation or password reset link is
+	// unknown, expired or already used.
+	ErrInvalidAccountToken = errors.New("this link is invalid or has expired")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+type InviteUserInput struct {
+	Email    string
+	Role     Role
+	Lifetime time.Duration
+}
+
+// UpdateUserInput changes a user's role, status or both; empty fields are
+// left alone.
+type UpdateUserInput struct {
+	Role   Role
+	Status string
+}
+
+// AccountToken is a freshly issued invitation or password reset token. The
+// token itself is only available here; the store keeps its HMAC.
+type AccountToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < MinPasswordLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrInvalidPassword, MinPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: use at most %d bytes", ErrInvalidPassword, maxPasswordBytes)
+	}
+	return nil
+}
+
+// ListUsers returns the members of the actor's workspace with their role
+// there.
+func (s *Service) ListUsers(ctx context.Context, actor Actor) ([]User, error) {
+	return s.store.ListUsers(ctx, actor.Workspace())
+}
+
+// InviteUser creates a user in the invited state as a member of the
+// inviter's workspace and returns the one-time token they set their password
+// with. Only owners invite owners.
+func (s *Service) InviteUser(ctx context.Context, inviter Actor, in InviteUserInput) (*User, AccountToken, error) {
+	email := strings.TrimSpace(strings.ToLower(in.Email))
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, AccountToken{}, fmt.Errorf("%w: a valid email is required", ErrInvalidUser)
+	}
+	if !in.Role.Valid() {
+		return nil, AccountToken{}, fmt.Errorf("%w: invalid role %q", ErrInvalidUser, in.Role)
+	}
+	if in.Role == RoleOwner && inviter.Role != RoleOwner {
+		return nil, AccountToken{}, ErrForbidden
+	}
+	lifetime := in.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultInvitationLifetime
+	}
+	if lifetime > MaxInvitationLifetime {
+		return nil, AccountToken{}, fmt.Errorf("%w: invitations expire after at most %d days", ErrInvalidUser, int(MaxInvitationLifetime.Hours()/24))
+	}
+	if _, err := s.store.GetUserByEmail(ctx, email); err == nil {
+		return nil, AccountToken{}, ErrUserExists
+	} else if !errors.Is(err, ErrInvalidCredentials) {
+		return nil, AccountToken{}, err
+	}
+
+	// The password is random and never shown; accepting the invitation
+	// replaces it.
+	password, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	user, err := s.store.createUser(workspace.WithID(ctx, inviter.Workspace()), email, password, in.Role, UserStatusInvited)
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	token, err := s.issueAccountToken(ctx, user.ID, accountTokenInvitation, inviter.ID, lifetime)
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	return user, token, nil
+}
+
+// ReissueInvitation replaces the invitation of a user who has not accepted
+// yet, for when the first link expired or got lost.
+func (s *Service) ReissueInvitation(ctx context.Context, actor Actor, userID string) (*User, AccountToken, error) {
+	user, err := s.managedUser(ctx, actor, userID)
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	if user.Status != UserStatusInvited {
+		return nil, AccountToken{}, fmt.Errorf("%w: %s has already accepted their invitation", ErrInvalidUser, user.Email)
+	}
+	token, err := s.issueAccountToken(ctx, user.ID, accountTokenInvitation, actor.ID, DefaultInvitationLifetime)
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	return user, token, nil
+}
+
+// UpdateUser changes another member's role in the workspace, or suspends
+// and reactivates them. Suspending signs the user out everywhere, so it is
+// only allowed for users who belong to no other workspace.
+func (s *Service) UpdateUser(ctx context.Context, actor Actor, userID string, in UpdateUserInput) (*User, error) {
+	user, err := s.managedUser(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Role != "" && in.Role != user.Role {
+		if !in.Role.Valid() {
+			return nil, fmt.Errorf("%w: invalid role %q", ErrInvalidUser, in.Role)
+		}
+		if in.Role == RoleOwner && actor.Role != RoleOwner {
+			return nil, ErrForbidden
+		}
+		if err := s.ensureAnotherOwner(ctx, actor.Workspace(), user); err != nil {
+			return nil, err
+		}
+		if err := s.store.setMemberRole(ctx, actor.Workspace(), user.ID, in.Role); err != nil {
+			return nil, err
+		}
+	}
+	if in.Status != "" && in.Status != user.Status {
+		if err := s.ensureSingleWorkspace(ctx, user); err != nil {
+			return nil, err
+		}
+		switch in.Status {
+		case UserStatusSuspended:
+			if err := s.ensureAnotherOwner(ctx, actor.Workspace(), user); err != nil {
+				return nil, err
+			}
+		case UserStatusActive:
+			if user.Status == UserStatusInvited {
+				return nil, fmt.Errorf("%w: invited users become active by accepting their invitation", ErrInvalidUser)
+			}
+		default:
+			return nil, fmt.Errorf("%w: status must be %q or %q", ErrInvalidUser, UserStatusActive, UserStatusSuspended)
+		}
+		if err := s.store.setUserStatus(ctx, user.ID, in.Status); err != nil {
+			return nil, err
+		}
+		if in.Status == UserStatusSuspended {
+			if _, err := s.store.revokeUserSessions(ctx, user.ID, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.store.GetWorkspaceUser(ctx, actor.Workspace(), user.ID)
+}
+
+// DeleteUser removes a user from the actor's workspace. Users who belong to
+// no other workspace are deleted together with their sessions, tokens and
+// grants.
+func (s *Service) DeleteUser(ctx context.Context, actor Actor, userID string) (*User, error) {
+	user, err := s.managedUser(ctx, actor, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureAnotherOwner(ctx, actor.Workspace(), user); err != nil {
+		return nil, err
+	}
+	if err := s.store.removeMember(ctx, actor.Workspace(), user.ID); err != nil {
+		return nil, err
+	}
+	remaining, err := s.store.countMemberships(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		if err := s.store.deleteUser(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// IssuePasswordReset returns a one-time token an active user sets a new
+// password with. Like suspending, it is limited to users who belong to no
+// other workspace.
+func (s *Service) IssuePasswordReset(ctx context.Context, actor Actor, userID string) (*User, AccountToken, error) {
+	user, err := s.store.GetWorkspaceUser(ctx, actor.Workspace(), userID)
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	if user.Role == RoleOwner && actor.Role != RoleOwner {
+		return nil, AccountToken{}, ErrForbidden
+	}
+	if err := s.ensureSingleWorkspace(ctx, user); err != nil {
+		return nil, AccountToken{}, err
+	}
+	if user.Status != UserStatusActive {
+		return nil, AccountToken{}, fmt.Errorf("%w: only active users can reset their password", ErrInvalidUser)
+	}
+	token, err := s.issueAccountToken(ctx, user.ID, accountTokenPasswordReset, actor.ID, PasswordResetLifetime)
+	if err != nil {
+		return nil, AccountToken{}, err
+	}
+	return user, token, nil
+}
+
+// AcceptInvitation sets the invited user's password, activates them and
+// signs them in.
+func (s *Service) AcceptInvitation(ctx context.Context, w http.ResponseWriter, r *http.Request, token, password string) (Actor, error) {
+	if err := ValidatePassword(password); err != nil {
+		return AnonymousActor(), err
+	}
+	userID, err := s.store.consumeAccountToken(ctx, accountTokenInvitation, HashOpaqueToken(s.sessionSecret, token))
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	user, err := s.store.GetUserByID(ctx, userID)
+	if err != nil {
+		return AnonymousActor(), err
+	}
+	if user.Status != UserStatusInvited {
+		return AnonymousActor(), ErrInvalidAccountToken
+	}
+	if err := s.store.setUserPassword(ctx, user.ID, password); err != nil {
+		return AnonymousActor(), err
+	}
+	if err := s.store.setUserStatus(ctx, user.ID, UserStatusActive); err != nil {
+		return AnonymousActor(), err
+	}
+	user.Status = UserStatusActive
+	return s.startSession(ctx, w, r, user, sessionAuthMethodPassword)
+}
+
+// ResetPassword redeems a password reset token. Every session of the user is
+// revoked, so they sign in again with the new password.
+func (s *Service) ResetPassword(ctx context.Context, token, password string) (*User, error) {
+	if err := ValidatePassword(password); err != nil {
+		return nil, err
+	}
+	userID, err := s.store.consumeAccountToken(ctx, accountTokenPasswordReset, HashOpaqueToken(s.sessionSecret, token))
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.store.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != UserStatusActive {
+		return nil, ErrInvalidAccountToken
+	}
+	if err := s.store.setUserPassword(ctx, user.ID, password); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.revokeUserSessions(ctx, user.ID, ""); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword is the self-service password change. It needs the current
+// password and signs out every other session of the user.
+func (s *Service) ChangePassword(ctx context.Context, r *http.Request, actor Actor, currentPassword, newPassword string) error {
+	if !isSessionActor(actor) {
+		return ErrForbidden
+	}
+	user, err := s.store.GetUserByID(ctx, actor.ID)
+	if err != nil {
+		return err
+	}
+	if err := VerifyPassword(user.PasswordHash, currentPassword); err != nil {
+		return err
+	}
+	if err := ValidatePassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.store.setUserPassword(ctx, user.ID, newPassword); err != nil {
+		return err
+	}
+	_, err = s.store.revokeUserSessions(ctx, user.ID, s.currentSessionHash(r))
+	return err
+}
+
+// ListSessions returns the live sessions of the actor's own user, marking
+// the one the request came with.
+func (s *Service) ListSessions(ctx context.Context, r *http.Request, actor Actor) ([]Session, error) {
+	if !actor.IsAuthenticated() || actor.Type != ActorTypeOperator {
+		return nil, ErrForbidden
+	}
+	sessions, err := s.store.listUserSessions(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	current := s.currentSessionHash(r)
+	for i := range sessions {
+		sessions[i].Current = current != "" && sessions[i].hash == current
+	}
+	return sessions, nil
+}
+
+// RevokeOwnSession ends one of the actor's own sessions.
+func (s *Service) RevokeOwnSession(ctx context.Context, actor Actor, sessionID string) error {
+	if !actor.IsAuthenticated() || actor.Type != ActorTypeOperator {
+		return ErrForbidden
+	}
+	return s.store.revokeUserSession(ctx, actor.ID, sessionID)
+}
+
+// RevokeOtherSessions ends every session of the actor's user except the one
+// the request came with, and reports how many were ended.
+func (s *Service) RevokeOtherSessions(ctx context.Context, r *http.Request, actor Actor) (int64, error) {
+	if !actor.IsAuthenticated() || actor.Type != ActorTypeOperator {
+		return 0, ErrForbidden
+	}
+	return s.store.revokeUserSessions(ctx, actor.ID, s.currentSessionHash(r))
+}
+
+func (s *Service) currentSessionHash(r *http.Request) string {
+	token := sessionTokenFromRequest(r)
+	if token == "" {
+		return ""
+	}
+	return HashOpaqueToken(s.sessionSecret, token)
+}
+
+// managedUser loads a member of the actor's workspace the actor is about to
+// change, with their role there. Nobody changes their own role or status
+// here, and only owners touch owners.
+func (s *Service) managedUser(ctx context.Context, actor Actor, userID string) (*User, error) {
+	user, err := s.store.GetWorkspaceUser(ctx, actor.Workspace(), userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, fmt.Errorf("%w: you cannot change your own account here", ErrInvalidUser)
+	}
+	if user.Role == RoleOwner && actor.Role != RoleOwner {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// ensureAnotherOwner refuses to take away the last active owner of a
+// workspace.
+func (s *Service) ensureAnotherOwner(ctx context.Context, workspaceID string, user *User) error {
+	if user.Role != RoleOwner || user.Status != UserStatusActive {
+		return nil
+	}
+	others, err := s.store.countOtherActiveOwners(ctx, workspaceID, user.ID)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// ensureSingleWorkspace guards account-wide changes: a workspace may only
+// suspend or reset users no other workspace depends on.
+func (s *Service) ensureSingleWorkspace(ctx context.Context, user *User) error {
+	count, err := s.store.countMemberships(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		return fmt.Errorf("%w: %s also belongs to other workspaces", ErrInvalidUser, user.Email)
+	}
+	return nil
+}
+
+func (s *Service) issueAccountToken(ctx context.Context, userID, purpose, createdBy string, lifetime time.Duration) (AccountToken, error) {
+	token, err := GenerateOpaqueToken()
+	if err != nil {
+		return AccountToken{}, err
+	}
+	expiresAt := time.Now().UTC().Add(lifetime)
+	if err := s.store.createAccountToken(ctx, userID, purpose, HashOpaqueToken(s.sessionSecret, token), createdBy, expiresAt); err != nil {
+		return AccountToken{}, err
+	}
+	return AccountToken{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// ListUsers returns the members of a workspace with their role there.
+func (s *Store) ListUsers(ctx context.Context, workspaceID string) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.email, u.password_hash, m.role, u.status, u.created_at, u.updated_at, COALESCE(u.last_login_at, '')
+		FROM users u
+		JOIN workspace_members m ON m.user_id = u.id
+		WHERE m.workspace_id = ?
+		ORDER BY u.email ASC
+	`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var user User
+		var role string
+		if err := rows.Scan(&user.ID, &user.Email, &user.PasswordHash, &role, &user.Status, &user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt); err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		user.Role = Role(role)
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, nil
+}
+
+func (s *Store) setUserPassword(ctx context.Context, userID, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ?
+	`, string(hash), userID)
+	if err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) setUserStatus(ctx context.Context, userID, status string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users
+		SET status = ?, updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ?
+	`, status, userID)
+	if err != nil {
+		return fmt.Errorf("update user status: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) deleteUser(ctx context.Context, userID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, userID); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	return nil
+}
+
+// GetWorkspaceUser returns a member of a workspace with their role there.
+// Users of other workspaces are reported as not found.
+func (s *Store) GetWorkspaceUser(ctx context.Context, workspaceID, userID string) (*User, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.membershipRole(ctx, workspaceID, user.ID)
+	if errors.Is(err, ErrWorkspaceAccess) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+func (s *Store) countOtherActiveOwners(ctx context.Context, workspaceID, userID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = ? AND m.role = ? AND u.status = ? AND u.id != ?
+	`, workspaceID, string(RoleOwner), UserStatusActive, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count owners: %w", err)
+	}
+	return count, nil
+}
+
+// createAccountToken stores a new token and drops any unused one of the same
+// purpose, so only the latest link works.
+func (s *Store) createAccountToken(ctx context.Context, userID, purpose, tokenHash, createdBy string, expiresAt time.Time) error {
+	id, err := idutil.New()
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin account token: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM user_account_tokens WHERE user_id = ? AND purpose = ? AND used_at IS NULL
+	`, userID, purpose); err != nil {
+		return fmt.Errorf("clear account tokens: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_account_tokens (id, user_id, purpose, token_hash, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), ?)
+	`, id, userID, purpose, tokenHash, nullableString(createdBy), expiresAt.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("insert account token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit account token: %w", err)
+	}
+	return nil
+}
+
+// consumeAccountToken spends a token and returns its user.
+func (s *Store) consumeAccountToken(ctx context.Context, purpose, tokenHash string) (string, error) {
+	var id, userID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id FROM user_account_tokens
+		WHERE token_hash = ?
+		  AND purpose = ?
+		  AND used_at IS NULL
+		  AND datetime(expires_at) > datetime('now')
+	`, tokenHash, purpose).Scan(&id, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidAccountToken
+	}
+	if err != nil {
+		return "", fmt.Errorf("get account token: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE user_account_tokens
+		SET used_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ? AND used_at IS NULL
+	`, id)
+	if err != nil {
+		return "", fmt.Errorf("consume account token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return "", fmt.Errorf("consume account token: %w", err)
+	}
+	if affected == 0 {
+		return "", ErrInvalidAccountToken
+	}
+	return userID, nil
+}
+
+func (s *Store) listUserSessions(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, session_hash, created_at, expires_at, COALESCE(absolute_expires_at, expires_at),
+		       COALESCE(last_used_at, ''), COALESCE(user_agent, ''), COALESCE(ip, ''), auth_method
+		FROM sessions
+		WHERE user_id = ?
+		  AND revoked_at IS NULL
+		  AND datetime(expires_at) > datetime('now')
+		  AND datetime(COALESCE(absolute_expires_at, expires_at)) > datetime('now')
+		ORDER BY COALESCE(last_used_at, created_at) DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.hash, &session.CreatedAt, &session.ExpiresAt, &session.AbsoluteExpiresAt, &session.LastUsedAt, &session.UserAgent, &session.IP, &session.AuthMethod); err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *Store) revokeUserSession(ctx context.Context, userID, sessionID string) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// revokeUserSessions ends every session of the user except the one with
+// keepHash, if given.
+func (s *Store) revokeUserSessions(ctx context.Context, userID, keepHash string) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE user_id = ? AND revoked_at IS NULL AND session_hash != ?
+	`, userID, keepHash)
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	return affected, nil
+}
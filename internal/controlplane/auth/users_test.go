@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInvitationSetsPasswordOnceAndSignsIn(t *testing.T) {
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	inviter := operatorActor(admin)
+
+	user, invitation, err := service.InviteUser(ctx, inviter, InviteUserInput{Email: " Dev@Agency.test ", Role: RoleDeveloper})
+	if err != nil {
+		t.Fatalf("InviteUser() error = %v", err)
+	}
+	if user.Email != "dev@agency.test" || user.Status != UserStatusInvited {
+		t.Fatalf("invited user = %+v, want invited dev@agency.test", user)
+	}
+	if _, err := service.Login(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/auth/login", nil), user.Email, invitation.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login() before accepting error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := service.InviteUser(ctx, inviter, InviteUserInput{Email: "dev@agency.test", Role: RoleViewer}); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("InviteUser() duplicate error = %v, want ErrUserExists", err)
+	}
+	if _, _, err := service.InviteUser(ctx, inviter, InviteUserInput{Email: "boss@agency.test", Role: RoleOwner}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("admin inviting an owner error = %v, want ErrForbidden", err)
+	}
+
+	accept := func(token, password string) (Actor, *httptest.ResponseRecorder, error) {
+		res := httptest.NewRecorder()
+		actor, err := service.AcceptInvitation(ctx, res, httptest.NewRequest(http.MethodPost, "/api/auth/invitations/accept", nil), token, password)
+		return actor, res, err
+	}
+	if _, _, err := accept(invitation.Token, "short"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("AcceptInvitation() weak password error = %v, want ErrInvalidPassword", err)
+	}
+	actor, res, err := accept(invitation.Token, "a much longer passphrase")
+	if err != nil {
+		t.Fatalf("AcceptInvitation() error = %v", err)
+	}
+	if actor.ID != user.ID || actor.Role != RoleDeveloper || len(res.Result().Cookies()) == 0 {
+		t.Fatalf("actor = %+v, want signed-in developer %s", actor, user.ID)
+	}
+	if _, _, err := accept(invitation.Token, "another long passphrase"); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("AcceptInvitation() replay error = %v, want ErrInvalidAccountToken", err)
+	}
+	accepted, err := store.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if accepted.Status != UserStatusActive || VerifyPassword(accepted.PasswordHash, "a much longer passphrase") != nil {
+		t.Fatalf("accepted user = %+v, want active with the chosen password", accepted)
+	}
+}
+
+func TestReissuedInvitationReplacesEarlierLink(t *testing.T) {
+	service, _, db, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	inviter := operatorActor(admin)
+
+	user, first, err := service.InviteUser(ctx, inviter, InviteUserInput{Email: "viewer@agency.test", Role: RoleViewer, Lifetime: time.Hour})
+	if err != nil {
+		t.Fatalf("InviteUser() error = %v", err)
+	}
+	_, second, err := service.ReissueInvitation(ctx, inviter, user.ID)
+	if err != nil {
+		t.Fatalf("ReissueInvitation() error = %v", err)
+	}
+	accept := func(token string) error {
+		_, err := service.AcceptInvitation(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), token, "a much longer passphrase")
+		return err
+	}
+	if err := accept(first.Token); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("first invitation error = %v, want ErrInvalidAccountToken", err)
+	}
+
+	if _, err := db.Exec(`UPDATE user_account_tokens SET expires_at = ? WHERE user_id = ?`, time.Now().UTC().Add(-time.Minute).Format(time.RFC3339), user.ID); err != nil {
+		t.Fatalf("expire invitation: %v", err)
+	}
+	if err := accept(second.Token); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("expired invitation error = %v, want ErrInvalidAccountToken", err)
+	}
+}
+
+func TestUpdateUserProtectsOwnersAndSelf(t *testing.T) {
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	owner, err := store.CreateUser(ctx, "owner@agency.test", "correct horse battery staple", RoleOwner)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	adminActor := operatorActor(admin)
+	ownerActor := operatorActor(owner)
+
+	if _, err := service.UpdateUser(ctx, adminActor, owner.ID, UpdateUserInput{Status: UserStatusSuspended}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("admin suspending owner error = %v, want ErrForbidden", err)
+	}
+	if _, err := service.UpdateUser(ctx, adminActor, admin.ID, UpdateUserInput{Role: RoleViewer}); !errors.Is(err, ErrInvalidUser) {
+		t.Fatalf("self demotion error = %v, want ErrInvalidUser", err)
+	}
+	if _, err := service.UpdateUser(ctx, ownerActor, admin.ID, UpdateUserInput{Role: RoleOwner}); err != nil {
+		t.Fatalf("owner promoting admin error = %v", err)
+	}
+	// Two owners now; either may step down, but not both.
+	if _, err := service.UpdateUser(ctx, ownerActor, admin.ID, UpdateUserInput{Role: RoleDeveloper}); err != nil {
+		t.Fatalf("demoting second owner error = %v", err)
+	}
+	promoted := operatorActor(mustGetUser(t, store, admin.ID))
+	if _, err := service.DeleteUser(ctx, promoted, owner.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("developer deleting owner error = %v, want ErrForbidden", err)
+	}
+
+	if _, err := store.CreateUser(ctx, "second-owner@agency.test", "correct horse battery staple", RoleOwner); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	second, err := store.GetUserByEmail(ctx, "second-owner@agency.test")
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+	if _, err := service.UpdateUser(ctx, ownerActor, second.ID, UpdateUserInput{Status: UserStatusSuspended}); err != nil {
+		t.Fatalf("suspend second owner error = %v", err)
+	}
+	secondActor := operatorActor(second)
+	if _, err := service.DeleteUser(ctx, secondActor, owner.ID); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("deleting the last active owner error = %v, want ErrLastOwner", err)
+	}
+}
+
+func TestSuspendingUserRevokesSessions(t *testing.T) {
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "dev@agency.test", "correct horse battery staple", RoleDeveloper)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	cookie := mustLogin(t, service, user.Email, "correct horse battery staple")
+
+	if _, err := service.UpdateUser(ctx, operatorActor(admin), user.ID, UpdateUserInput{Status: UserStatusSuspended}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+	req.AddCookie(cookie)
+	if _, err := service.AuthenticateRequest(req); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("AuthenticateRequest() after suspension error = %v, want ErrUnauthenticated", err)
+	}
+	if _, err := service.Login(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil), user.Email, "correct horse battery staple"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login() while suspended error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestPasswordResetRevokesSessions(t *testing.T) {
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, "dev@agency.test", "correct horse battery staple", RoleDeveloper)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	cookie := mustLogin(t, service, user.Email, "correct horse battery staple")
+
+	_, reset, err := service.IssuePasswordReset(ctx, operatorActor(admin), user.ID)
+	if err != nil {
+		t.Fatalf("IssuePasswordReset() error = %v", err)
+	}
+	if _, err := service.ResetPassword(ctx, reset.Token, "a brand new passphrase"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+	req.AddCookie(cookie)
+	if _, err := service.AuthenticateRequest(req); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("AuthenticateRequest() after reset error = %v, want ErrUnauthenticated", err)
+	}
+	mustLogin(t, service, user.Email, "a brand new passphrase")
+	if _, err := service.ResetPassword(ctx, reset.Token, "yet another passphrase"); !errors.Is(err, ErrInvalidAccountToken) {
+		t.Fatalf("ResetPassword() replay error = %v, want ErrInvalidAccountToken", err)
+	}
+}
+
+func TestChangePasswordKeepsCurrentSessionOnly(t *testing.T) {
+	service, store, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	current := mustLogin(t, service, user.Email, "correct horse battery staple")
+	other := mustLogin(t, service, user.Email, "correct horse battery staple")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/me/password", nil)
+	req.AddCookie(current)
+	actor, err := service.AuthenticateRequest(req)
+	if err != nil {
+		t.Fatalf("AuthenticateRequest() error = %v", err)
+	}
+	sessions, err := service.ListSessions(ctx, req, actor)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions))
+	}
+	currentCount := 0
+	for _, session := range sessions {
+		if session.Current {
+			currentCount++
+		}
+	}
+	if currentCount != 1 {
+		t.Fatalf("sessions marked current = %d, want 1", currentCount)
+	}
+
+	if err := service.ChangePassword(ctx, req, actor, "wrong password", "a brand new passphrase"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("ChangePassword() wrong current error = %v, want ErrInvalidCredentials", err)
+	}
+	if err := service.ChangePassword(ctx, req, actor, "correct horse battery staple", "a brand new passphrase"); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	for name, cookie := range map[string]*http.Cookie{"current": current, "other": other} {
+		check := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+		check.AddCookie(cookie)
+		_, err := service.AuthenticateRequest(check)
+		if wantValid := name == "current"; (err == nil) != wantValid {
+			t.Fatalf("%s session after password change error = %v, want valid = %v", name, err, wantValid)
+		}
+	}
+	if updated := mustGetUser(t, store, user.ID); VerifyPassword(updated.PasswordHash, "a brand new passphrase") != nil {
+		t.Fatal("password was not changed")
+	}
+
+	tokenActor := actor
+	tokenActor.TokenID = "token-1"
+	if err := service.ChangePassword(ctx, req, tokenActor, "a brand new passphrase", "something else entirely"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("ChangePassword() with api token error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestRevokeOwnSessionIgnoresOtherUsersSessions(t *testing.T) {
+	service, store, _, user := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	other, err := store.CreateUser(ctx, "dev@agency.test", "correct horse battery staple", RoleDeveloper)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	mustLogin(t, service, other.Email, "correct horse battery staple")
+	otherActor := operatorActor(other)
+	otherSessions, err := service.ListSessions(ctx, httptest.NewRequest(http.MethodGet, "/", nil), otherActor)
+	if err != nil || len(otherSessions) != 1 {
+		t.Fatalf("ListSessions() = %d, %v; want one session", len(otherSessions), err)
+	}
+
+	actor := operatorActor(user)
+	if err := service.RevokeOwnSession(ctx, actor, otherSessions[0].ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("RevokeOwnSession() on another user's session error = %v, want ErrSessionNotFound", err)
+	}
+	if err := service.RevokeOwnSession(ctx, otherActor, otherSessions[0].ID); err != nil {
+		t.Fatalf("RevokeOwnSession() error = %v", err)
+	}
+}
+
+func mustLogin(t *testing.T, service *Service, email, password string) *http.Cookie {
+	t.Helper()
+	res := httptest.NewRecorder()
+	if _, err := service.Login(context.Background(), res, httptest.NewRequest(http.MethodPost, "/api/auth/login", nil), email, password); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name == SessionCookieName {
+			return cookie
+		}
+	}
+	t.Fatal("Login() did not set a session cookie")
+	return nil
+}
+
+func mustGetUser(t *testing.T, store *Store, userID string) *User {
+	t.Helper()
+	user, err := store.GetUserByID(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	return user
+}
+
+func operatorActor(user *User) Actor {
+	return Actor{ID: user.ID, Type: ActorTypeOperator, Email: user.Email, Role: user.Role, Authenticated: true}
+}
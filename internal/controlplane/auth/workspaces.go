@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+// WorkspaceHeader selects the workspace a signed-in request acts in. Without
+// it a session acts in the user's first workspace; API tokens always act in
+// the workspace they were created in.
+const WorkspaceHeader = "X-Pressluft-Workspace"
+
+var (
+	// ErrWorkspaceAccess means the user is not a member of the requested
+	// workspace, or of any workspace at all.
+	ErrWorkspaceAccess  = errors.New("no access to this workspace")
+	ErrInvalidWorkspace = errors.New("invalid workspace")
+)
+
+// Workspace is a tenant owning providers, servers, sites and domains. Role
+// is the role of the user it was listed for.
+type Workspace struct {
+	ID        string
+	Name      string
+	Slug      string
+	Role      Role
+	CreatedAt string
+}
+
+var workspaceSlugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ListWorkspaces returns the workspaces the actor's user belongs to.
+func (s *Service) ListWorkspaces(ctx context.Context, actor Actor) ([]Workspace, error) {
+	if !isMemberActor(actor) {
+		return nil, ErrForbidden
+	}
+	return s.store.listUserWorkspaces(ctx, actor.ID)
+}
+
+// CreateWorkspace creates a workspace with the actor as its owner. Only
+// owners of their current workspace may start new ones.
+func (s *Service) CreateWorkspace(ctx context.Context, actor Actor, name string) (Workspace, error) {
+	if !isMemberActor(actor) || actor.TokenID != "" || actor.Role != RoleOwner {
+		return Workspace{}, ErrForbidden
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return Workspace{}, fmt.Errorf("%w: name must be between 1 and 100 characters", ErrInvalidWorkspace)
+	}
+	return s.store.createWorkspace(ctx, name, actor.ID)
+}
+
+// AddWorkspaceMember adds an existing user to the actor's current workspace.
+// New people are invited instead, which also makes them members.
+func (s *Service) AddWorkspaceMember(ctx context.Context, actor Actor, email string, role Role) (*User, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: invalid role %q", ErrInvalidUser, role)
+	}
+	if role == RoleOwner && actor.Role != RoleOwner {
+		return nil, ErrForbidden
+	}
+	user, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.store.membershipRole(ctx, actor.Workspace(), user.ID); err == nil {
+		return nil, fmt.Errorf("%w: %s is already a member of this workspace", ErrInvalidUser, user.Email)
+	} else if !errors.Is(err, ErrWorkspaceAccess) {
+		return nil, err
+	}
+	if err := s.store.setMemberRole(ctx, actor.Workspace(), user.ID, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+// isMemberActor reports whether the actor is a person acting through one of
+// their workspace memberships.
+func isMemberActor(actor Actor) bool {
+	return actor.IsAuthenticated() && actor.Type == ActorTypeOperator
+}
+
+// resolveMembership picks the workspace a user acts in: the requested one,
+// which they must belong to, or else their first membership with the
+// default workspace preferred.
+func (s *Store) resolveMembership(ctx context.Context, userID, workspaceID string) (string, Role, error) {
+	if workspaceID != "" {
+		normalized, err := idutil.Normalize(workspaceID)
+		if err != nil {
+			return "", "", ErrWorkspaceAccess
+		}
+		role, err := s.membershipRole(ctx, normalized, userID)
+		return normalized, role, err
+	}
+	var id, role string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT workspace_id, role FROM workspace_members
+		WHERE user_id = ?
+		ORDER BY workspace_id = ? DESC, created_at ASC, workspace_id ASC
+		LIMIT 1
+	`, userID, workspace.DefaultID).Scan(&id, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrWorkspaceAccess
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("resolve workspace: %w", err)
+	}
+	return id, Role(role), nil
+}
+
+func (s *Store) membershipRole(ctx context.Context, workspaceID, userID string) (Role, error) {
+	var role string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ?
+	`, workspaceID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrWorkspaceAccess
+	}
+	if err != nil {
+		return "", fmt.Errorf("get workspace membership: %w", err)
+	}
+	return Role(role), nil
+}
+
+// setMemberRole adds the user to the workspace or changes their role there.
+func (s *Store) setMemberRole(ctx context.Context, workspaceID, userID string, role Role) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		ON CONFLICT(workspace_id, user_id) DO UPDATE SET role = excluded.role
+	`, workspaceID, userID, string(role))
+	if err != nil {
+		return fmt.Errorf("set workspace membership: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) removeMember(ctx context.Context, workspaceID, userID string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?
+	`, workspaceID, userID); err != nil {
+		return fmt.Errorf("remove workspace membership: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) countMemberships(ctx context.Context, userID string) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM workspace_members WHERE user_id = ?
+	`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count workspace memberships: %w", err)
+	}
+	return count, nil
+}
+
+func (s *Store) listUserWorkspaces(ctx context.Context, userID string) ([]Workspace, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT w.id, w.name, w.slug, m.role, w.created_at
+		FROM workspace_members m
+		JOIN workspaces w ON w.id = m.workspace_id
+		WHERE m.user_id = ?
+		ORDER BY w.name ASC, w.id ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := make([]Workspace, 0)
+	for rows.Next() {
+		var ws Workspace
+		var role string
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.Slug, &role, &ws.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan workspace: %w", err)
+		}
+		ws.Role = Role(role)
+		workspaces = append(workspaces, ws)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+func (s *Store) createWorkspace(ctx context.Context, name, ownerID string) (Workspace, error) {
+	id, err := idutil.New()
+	if err != nil {
+		return Workspace{}, err
+	}
+	base := strings.Trim(workspaceSlugUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if base == "" {
+		base = "workspace"
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Workspace{}, fmt.Errorf("begin workspace tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Slugs only need to be unique; a short suffix settles collisions.
+	slug := base
+	for attempt := 2; ; attempt++ {
+		var taken int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM workspaces WHERE slug = ?`, slug).Scan(&taken); err != nil {
+			return Workspace{}, fmt.Errorf("check workspace slug: %w", err)
+		}
+		if taken == 0 {
+			break
+		}
+		slug = fmt.Sprintf("%s-%d", base, attempt)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workspaces (id, name, slug, created_at, updated_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+	`, id, name, slug); err != nil {
+		return Workspace{}, fmt.Errorf("insert workspace: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+		VALUES (?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+	`, id, ownerID, string(RoleOwner)); err != nil {
+		return Workspace{}, fmt.Errorf("insert workspace owner: %w", err)
+	}
+	var ws Workspace
+	if err := tx.QueryRowContext(ctx, `SELECT id, name, slug, created_at FROM workspaces WHERE id = ?`, id).Scan(&ws.ID, &ws.Name, &ws.Slug, &ws.CreatedAt); err != nil {
+		return Workspace{}, fmt.Errorf("read workspace: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return Workspace{}, fmt.Errorf("commit workspace tx: %w", err)
+	}
+	ws.Role = RoleOwner
+	return ws, nil
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pressluft/internal/shared/workspace"
+)
+
+func TestWorkspaceMembershipDecidesRoleAndAccess(t *testing.T) {
+	service, store, _, admin := newSessionServiceTestHarness(t, time.Hour, 2*time.Hour)
+	ctx := context.Background()
+	owner, err := store.CreateUser(ctx, "owner@example.test", "correct horse battery staple", RoleOwner)
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	ownerActor := operatorActor(owner)
+
+	if _, err := service.CreateWorkspace(ctx, operatorActor(admin), "Agency"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("admin CreateWorkspace() error = %v, want ErrForbidden", err)
+	}
+	tokenActor := ownerActor
+	tokenActor.TokenID = "token"
+	if _, err := service.CreateWorkspace(ctx, tokenActor, "Agency"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("token CreateWorkspace() error = %v, want ErrForbidden", err)
+	}
+	if _, err := service.CreateWorkspace(ctx, ownerActor, "   "); !errors.Is(err, ErrInvalidWorkspace) {
+		t.Fatalf("blank CreateWorkspace() error = %v, want ErrInvalidWorkspace", err)
+	}
+	first, err := service.CreateWorkspace(ctx, ownerActor, "Client Co.")
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+	second, err := service.CreateWorkspace(ctx, ownerActor, "Client Co")
+	if err != nil {
+		t.Fatalf("CreateWorkspace() second error = %v", err)
+	}
+	if first.Slug != "client-co" || second.Slug != "client-co-2" {
+		t.Fatalf("slugs = %q, %q, want client-co and client-co-2", first.Slug, second.Slug)
+	}
+
+	// The admin joins the client workspace as a viewer only.
+	ownerInClient := ownerActor
+	ownerInClient.WorkspaceID = first.ID
+	if _, err := service.AddWorkspaceMember(ctx, ownerInClient, admin.Email, RoleViewer); err != nil {
+		t.Fatalf("AddWorkspaceMember() error = %v", err)
+	}
+	if _, err := service.AddWorkspaceMember(ctx, ownerInClient, admin.Email, RoleViewer); !errors.Is(err, ErrInvalidUser) {
+		t.Fatalf("AddWorkspaceMember() duplicate error = %v, want ErrInvalidUser", err)
+	}
+
+	authenticate := func(header string) (Actor, error) {
+		loginRes := httptest.NewRecorder()
+		if _, err := service.Login(ctx, loginRes, httptest.NewRequest(http.MethodPost, "/api/auth/login", nil), admin.Email, "correct horse battery staple"); err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/sites", nil)
+		for _, cookie := range loginRes.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		if header != "" {
+			req.Header.Set(WorkspaceHeader, header)
+		}
+		return service.AuthenticateRequest(req)
+	}
+	actor, err := authenticate("")
+	if err != nil {
+		t.Fatalf("AuthenticateRequest() error = %v", err)
+	}
+	if actor.WorkspaceID != workspace.DefaultID || actor.Role != RoleAdmin {
+		t.Fatalf("default actor = %+v, want admin in the default workspace", actor)
+	}
+	actor, err = authenticate(first.ID)
+	if err != nil {
+		t.Fatalf("AuthenticateRequest() in client workspace error = %v", err)
+	}
+	if actor.WorkspaceID != first.ID || actor.Role != RoleViewer {
+		t.Fatalf("client actor = %+v, want viewer in %s", actor, first.ID)
+	}
+	if _, err := authenticate(second.ID); !errors.Is(err, ErrWorkspaceAccess) {
+		t.Fatalf("AuthenticateRequest() in foreign workspace error = %v, want ErrWorkspaceAccess", err)
+	}
+
+	// Belonging to two workspaces protects the account from either one.
+	if _, err := service.UpdateUser(ctx, ownerInClient, admin.ID, UpdateUserInput{Status: UserStatusSuspended}); !errors.Is(err, ErrInvalidUser) {
+		t.Fatalf("suspending a shared user error = %v, want ErrInvalidUser", err)
+	}
+	if _, err := service.DeleteUser(ctx, ownerInClient, admin.ID); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := store.GetUserByID(ctx, admin.ID); err != nil {
+		t.Fatalf("GetUserByID() after leaving one workspace error = %v", err)
+	}
+	if _, err := store.GetWorkspaceUser(ctx, first.ID, admin.ID); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("GetWorkspaceUser() after removal error = %v, want ErrInvalidCredentials", err)
+	}
+	users, err := service.ListUsers(ctx, ownerInClient)
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(users) != 1 || users[0].ID != owner.ID {
+		t.Fatalf("client workspace users = %+v, want only the owner", users)
+	}
+}
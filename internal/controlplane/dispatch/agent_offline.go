@@ -0,0 +1,63 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/server/stores"
+	"pressluft/internal/shared/observability"
+)
+
+// ServerLookup resolves the server behind an agent.
+type ServerLookup interface {
+	GetByID(ctx context.Context, id string) (*stores.StoredServer, error)
+}
+
+// OfflineRecorder records an activity entry whenever the node monitor takes
+// an agent offline, so operators hear about it without watching the
+// dashboard.
+type OfflineRecorder struct {
+	servers  ServerLookup
+	activity *activity.Store
+	logger   *slog.Logger
+}
+
+func NewOfflineRecorder(servers ServerLookup, activityStore *activity.Store, logger *slog.Logger) *OfflineRecorder {
+	return &OfflineRecorder{servers: servers, activity: activityStore, logger: logger}
+}
+
+// NodeOffline implements ws.OfflineNotifier.
+func (r *OfflineRecorder) NodeOffline(ctx context.Context, serverID string) {
+	if r.activity == nil {
+		return
+	}
+	title := "Server agent went offline"
+	message := "The agent stopped sending heartbeats."
+	if r.servers != nil {
+		server, err := r.servers.GetByID(ctx, serverID)
+		if err != nil {
+			r.logger.Warn("offline server lookup failed", observability.Correlation{ServerID: serverID}.LogArgs("error", err)...)
+		} else {
+			title = fmt.Sprintf("Agent on '%s' went offline", server.Name)
+			if lastSeen, err := time.Parse(time.RFC3339, server.NodeLastSeen); err == nil {
+				message = fmt.Sprintf("The agent was last seen at %s.", lastSeen.UTC().Format(time.RFC1123))
+			}
+		}
+	}
+	if _, err := r.activity.Emit(ctx, activity.EmitInput{
+		EventType:         activity.EventServerAgentOffline,
+		Category:          activity.CategoryServer,
+		Level:             activity.LevelError,
+		ResourceType:      activity.ResourceServer,
+		ResourceID:        serverID,
+		ActorType:         activity.ActorSystem,
+		Title:             title,
+		Message:           message,
+		RequiresAttention: true,
+	}); err != nil {
+		r.logger.Error("record agent offline failed", observability.Correlation{ServerID: serverID}.LogArgs("error", err)...)
+	}
+}
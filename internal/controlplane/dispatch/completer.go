@@ -40,7 +40,7 @@ func (c *Completer) HandleResult(result ws.CommandResult) error {
 		result.ServerID = ws.FormatAppID(job.ServerID)
 	}
 	corr := observability.Correlation{ServerID: job.ServerID, CommandID: result.CommandID}
-	if job.Status == orchestrator.JobStatusSucceeded || job.Status == orchestrator.JobStatusFailed {
+	if orchestrator.IsTerminalStatus(job.Status) {
 		c.logger.Debug("stale command result ignored", corr.LogArgs("job_status", job.Status)...)
 		return nil
 	}
@@ -0,0 +1,40 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/shared/ws"
+)
+
+// SiteHealthSnapshot asks the agent on serverID for a runtime snapshot of one
+// site and waits for the result. Workers use it to verify a site after
+// changing it in place.
+func (r *AgentRunner) SiteHealthSnapshot(ctx context.Context, serverID string, params agentcommand.SiteHealthSnapshotParams) (*agentcommand.SiteHealthSnapshot, error) {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, agentcommand.Timeout(agentcommand.TypeSiteHealth))
+	defer cancel()
+	result, err := r.hub.SendCommandAndWait(ctx, serverID, ws.Command{
+		ID:       uuid.NewString(),
+		ServerID: ws.FormatAppID(serverID),
+		Type:     agentcommand.TypeSiteHealth,
+		Payload:  payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, errors.New(result.Error)
+	}
+	var snapshot agentcommand.SiteHealthSnapshot
+	if err := json.Unmarshal(result.Payload, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
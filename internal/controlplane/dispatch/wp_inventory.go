@@ -0,0 +1,39 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/shared/ws"
+)
+
+// WPInventory asks the agent on serverID for the WordPress core, plugin, and
+// theme versions of one site and waits for the result.
+func (r *AgentRunner) WPInventory(ctx context.Context, serverID string, params agentcommand.WPInventoryParams) (*agentcommand.WPInventory, error) {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, agentcommand.Timeout(agentcommand.TypeWPInventory))
+	defer cancel()
+	result, err := r.hub.SendCommandAndWait(ctx, serverID, ws.Command{
+		ID:       uuid.NewString(),
+		ServerID: ws.FormatAppID(serverID),
+		Type:     agentcommand.TypeWPInventory,
+		Payload:  payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, errors.New(result.Error)
+	}
+	var inventory agentcommand.WPInventory
+	if err := json.Unmarshal(result.Payload, &inventory); err != nil {
+		return nil, err
+	}
+	return &inventory, nil
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// LoadToken reads the scrape token from PRESSLUFT_METRICS_TOKEN, or the file
+// named by PRESSLUFT_METRICS_TOKEN_FILE. An empty token means the metrics
+// endpoint stays disabled.
+func LoadToken() (string, error) {
+	token := strings.TrimSpace(os.Getenv("PRESSLUFT_METRICS_TOKEN"))
+	if tokenFile := strings.TrimSpace(os.Getenv("PRESSLUFT_METRICS_TOKEN_FILE")); token == "" && tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("read metrics token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return token, nil
+}
+
+// requireToken lets through only requests bearing token. The metrics endpoint
+// sits outside operator auth, since scrapers have no session.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(presented)), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pressluft-metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
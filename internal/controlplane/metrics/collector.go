@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"pressluft/internal/shared/ws"
+)
+
+// collectTimeout bounds the database reads of one scrape.
+const collectTimeout = 5 * time.Second
+
+// stateCollector reads point-in-time state at scrape time, so gauges never
+// drift from what the database and the hub actually hold.
+type stateCollector struct {
+	db          *sql.DB
+	hub         *ws.Hub
+	workerSlots WorkerSlotReporter
+
+	jobs             *prometheus.Desc
+	queueDepth       *prometheus.Desc
+	workerRunning    *prometheus.Desc
+	workerMaxJobs    *prometheus.Desc
+	sites            *prometheus.Desc
+	connections      *prometheus.Desc
+	agentCPU         *prometheus.Desc
+	agentMemUsed     *prometheus.Desc
+	agentMemTotal    *prometheus.Desc
+	agentLastSeen    *prometheus.Desc
+	collectionErrors *prometheus.Desc
+}
+
+func newStateCollector(sources Sources) *stateCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+	}
+	return &stateCollector{
+		db:               sources.DB,
+		hub:              sources.Hub,
+		workerSlots:      sources.WorkerSlots,
+		jobs:             desc("jobs", "Jobs in the database, by kind and status.", "kind", "status"),
+		queueDepth:       desc("worker_queue_depth", "Jobs queued and waiting for a worker slot."),
+		workerRunning:    desc("worker_running_jobs", "Jobs the worker is executing right now."),
+		workerMaxJobs:    desc("worker_max_jobs", "Jobs the worker runs at most at once."),
+		sites:            desc("sites", "Sites by runtime health state.", "health_state"),
+		connections:      desc("websocket_connections", "Agents connected to the WebSocket hub."),
+		agentCPU:         desc("agent_cpu_percent", "CPU usage reported in the agent's last heartbeat.", "server_id"),
+		agentMemUsed:     desc("agent_memory_used_bytes", "Memory in use reported in the agent's last heartbeat.", "server_id"),
+		agentMemTotal:    desc("agent_memory_total_bytes", "Total memory reported in the agent's last heartbeat.", "server_id"),
+		agentLastSeen:    desc("agent_last_heartbeat_timestamp_seconds", "Unix time of the agent's last heartbeat.", "server_id"),
+		collectionErrors: desc("metrics_collection_errors", "Database reads that failed during this scrape.", "source"),
+	}
+}
+
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.jobs, c.queueDepth, c.workerRunning, c.workerMaxJobs, c.sites, c.connections,
+		c.agentCPU, c.agentMemUsed, c.agentMemTotal, c.agentLastSeen, c.collectionErrors,
+	} {
+		ch <- d
+	}
+}
+
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.db != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+		defer cancel()
+		c.collectJobs(ctx, ch)
+		c.collectSites(ctx, ch)
+	}
+	if c.workerSlots != nil {
+		usage := c.workerSlots.SlotUsage()
+		ch <- prometheus.MustNewConstMetric(c.workerRunning, prometheus.GaugeValue, float64(usage.Running))
+		ch <- prometheus.MustNewConstMetric(c.workerMaxJobs, prometheus.GaugeValue, float64(usage.MaxJobs))
+	}
+	if c.hub != nil {
+		c.collectAgents(ch)
+	}
+}
+
+func (c *stateCollector) collectJobs(ctx context.Context, ch chan<- prometheus.Metric) {
+	rows, err := c.db.QueryContext(ctx, `SELECT kind, status, COUNT(*) FROM jobs GROUP BY kind, status`)
+	if err != nil {
+		c.collectionFailed(ch, "jobs")
+		return
+	}
+	defer rows.Close()
+	queued := 0
+	for rows.Next() {
+		var kind, status string
+		var count int
+		if err := rows.Scan(&kind, &status, &count); err != nil {
+			c.collectionFailed(ch, "jobs")
+			return
+		}
+		if status == "queued" {
+			queued += count
+		}
+		ch <- prometheus.MustNewConstMetric(c.jobs, prometheus.GaugeValue, float64(count), kind, status)
+	}
+	if err := rows.Err(); err != nil {
+		c.collectionFailed(ch, "jobs")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(queued))
+}
+
+func (c *stateCollector) collectSites(ctx context.Context, ch chan<- prometheus.Metric) {
+	rows, err := c.db.QueryContext(ctx, `SELECT runtime_health_state, COUNT(*) FROM sites GROUP BY runtime_health_state`)
+	if err != nil {
+		c.collectionFailed(ch, "sites")
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var state string
+		var count int
+		if err := rows.Scan(&state, &count); err != nil {
+			c.collectionFailed(ch, "sites")
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(c.sites, prometheus.GaugeValue, float64(count), state)
+	}
+	if err := rows.Err(); err != nil {
+		c.collectionFailed(ch, "sites")
+	}
+}
+
+func (c *stateCollector) collectAgents(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(len(c.hub.ConnectedServerIDs())))
+	for serverID, info := range c.hub.GetAllAgentInfo() {
+		if info.LastSeen.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.agentCPU, prometheus.GaugeValue, info.CPUPercent, serverID)
+		ch <- prometheus.MustNewConstMetric(c.agentMemUsed, prometheus.GaugeValue, float64(info.MemUsedMB)*1024*1024, serverID)
+		ch <- prometheus.MustNewConstMetric(c.agentMemTotal, prometheus.GaugeValue, float64(info.MemTotalMB)*1024*1024, serverID)
+		ch <- prometheus.MustNewConstMetric(c.agentLastSeen, prometheus.GaugeValue, float64(info.LastSeen.Unix()), serverID)
+	}
+}
+
+func (c *stateCollector) collectionFailed(ch chan<- prometheus.Metric, source string) {
+	ch <- prometheus.MustNewConstMetric(c.collectionErrors, prometheus.GaugeValue, 1, source)
+}
@@ -0,0 +1,116 @@
+// Package metrics exposes control plane internals in the Prometheus text
+// format for an existing monitoring stack to scrape.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/shared/ws"
+)
+
+const namespace = "pressluft"
+
+// WorkerSlotReporter reports how many of the worker's job slots are taken.
+type WorkerSlotReporter interface {
+	SlotUsage() apitypes.WorkerSlotUsage
+}
+
+// Sources are what the scrape-time gauges are read from. Any of them may be
+// nil, which leaves its metrics out.
+type Sources struct {
+	DB          *sql.DB
+	Hub         *ws.Hub
+	WorkerSlots WorkerSlotReporter
+}
+
+// Registry holds every control plane metric. Its Observe methods satisfy the
+// worker's JobObserver, the hub's CommandObserver and the request logging
+// middleware's RequestObserver.
+type Registry struct {
+	registry        *prometheus.Registry
+	jobDuration     *prometheus.HistogramVec
+	commandDuration *prometheus.HistogramVec
+	requestDuration *prometheus.HistogramVec
+}
+
+// New creates a registry reading its gauges from sources.
+func New(sources Sources) *Registry {
+	r := &Registry{
+		registry: prometheus.NewRegistry(),
+		jobDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Time the worker spent executing a job, by kind and the status it ended in.",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+		}, []string{"kind", "status"}),
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "agent_command_duration_seconds",
+			Help:      "Round trip of commands sent to agents, from send to result.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, []string{"type", "outcome"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests served by the control plane.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+	r.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		r.jobDuration,
+		r.commandDuration,
+		r.requestDuration,
+		newStateCollector(sources),
+	)
+	return r
+}
+
+// ObserveJob records one job execution.
+func (r *Registry) ObserveJob(kind, status string, duration time.Duration) {
+	r.jobDuration.WithLabelValues(kind, status).Observe(duration.Seconds())
+}
+
+// ObserveCommand records one agent command round trip.
+func (r *Registry) ObserveCommand(commandType, outcome string, duration time.Duration) {
+	r.commandDuration.WithLabelValues(commandType, outcome).Observe(duration.Seconds())
+}
+
+// ObserveRequest records one served HTTP request.
+func (r *Registry) ObserveRequest(req *http.Request, status int, duration time.Duration) {
+	r.requestDuration.WithLabelValues(methodLabel(req.Method), routeLabel(req.Pattern), strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics to scrapers presenting token as a bearer token.
+func (r *Registry) Handler(token string) http.Handler {
+	return requireToken(token, promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{}))
+}
+
+// routeLabel labels a request by the registered route pattern that served
+// it, so the label set is bounded by the routes the server registers.
+// Requests no route matched and the dashboard catch-all share one label.
+func routeLabel(pattern string) string {
+	if pattern == "" || pattern == "/" {
+		return "other"
+	}
+	return pattern
+}
+
+// methodLabel keeps the standard methods; clients may send any token.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/shared/ws"
+
+	_ "modernc.org/sqlite"
+)
+
+type fakeSlots struct{}
+
+func (fakeSlots) SlotUsage() apitypes.WorkerSlotUsage {
+	return apitypes.WorkerSlotUsage{Running: 1, MaxJobs: 4}
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	handler := New(Sources{}).Handler("s3cret")
+
+	for _, header := range []string{"", "Bearer wrong", "s3cret"} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: status = %d, want 401", header, res.Code)
+		}
+	}
+}
+
+func TestHandlerExposesMetrics(t *testing.T) {
+	db := mustOpenMetricsDB(t)
+	if _, err := db.Exec(`
+		INSERT INTO jobs (kind, status) VALUES
+			('provision_server', 'succeeded'),
+			('deploy_site', 'queued'),
+			('deploy_site', 'queued');
+		INSERT INTO sites (runtime_health_state) VALUES ('healthy'), ('healthy'), ('unhealthy');
+	`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	registry := New(Sources{DB: db, Hub: ws.NewHub(), WorkerSlots: fakeSlots{}})
+	registry.ObserveJob("deploy_site", "succeeded", 42*time.Second)
+	registry.ObserveCommand("site_health_snapshot", "success", 300*time.Millisecond)
+	served := httptest.NewRequest(http.MethodGet, "/api/sites/0190f1b2-6c1e-7a4b-9f2d-1c2b3a4d5e6f/uptime", nil)
+	served.Pattern = "/api/sites/"
+	registry.ObserveRequest(served, http.StatusOK, 20*time.Millisecond)
+	registry.ObserveRequest(httptest.NewRequest("PROPFIND", "/api/a1b2c3", nil), http.StatusNotFound, time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	res := httptest.NewRecorder()
+	registry.Handler("s3cret").ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", res.Code)
+	}
+	body, _ := io.ReadAll(res.Body)
+
+	for _, want := range []string{
+		`pressluft_jobs{kind="deploy_site",status="queued"} 2`,
+		`pressluft_jobs{kind="provision_server",status="succeeded"} 1`,
+		`pressluft_worker_queue_depth 2`,
+		`pressluft_worker_running_jobs 1`,
+		`pressluft_worker_max_jobs 4`,
+		`pressluft_sites{health_state="healthy"} 2`,
+		`pressluft_websocket_connections 0`,
+		`pressluft_job_duration_seconds_count{kind="deploy_site",status="succeeded"} 1`,
+		`pressluft_agent_command_duration_seconds_count{outcome="success",type="site_health_snapshot"} 1`,
+		`pressluft_http_request_duration_seconds_count{method="GET",route="/api/sites/",status="200"} 1`,
+		`pressluft_http_request_duration_seconds_count{method="other",route="other",status="404"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if strings.Contains(string(body), "pressluft_metrics_collection_errors") {
+		t.Errorf("unexpected collection errors:\n%s", body)
+	}
+}
+
+func TestRouteLabel(t *testing.T) {
+	cases := map[string]string{
+		"/api/sites/": "/api/sites/",
+		"/api/health": "/api/health",
+		"/ws/agent":   "/ws/agent",
+		"/":           "other",
+		"":            "other",
+	}
+	for pattern, want := range cases {
+		if got := routeLabel(pattern); got != want {
+			t.Errorf("routeLabel(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func mustOpenMetricsDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec(`
+		CREATE TABLE jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			status TEXT NOT NULL
+		);
+		CREATE TABLE sites (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			runtime_health_state TEXT NOT NULL DEFAULT 'pending'
+		);
+	`); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,223 @@
+// Package notify emails users about the activity they subscribed to, either
+// as it happens or batched into hourly or daily digests.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+
+	smtpDialTimeout = 15 * time.Second
+)
+
+// Message is a plain-text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig describes the relay notifications are sent through.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// Security is starttls (the default), tls for implicit TLS, or none
+	// for relays on a trusted network.
+	Security string
+}
+
+func (c SMTPConfig) Validate() error {
+	if strings.TrimSpace(c.Host) == "" {
+		return fmt.Errorf("smtp host is required")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("smtp port must be between 1 and 65535")
+	}
+	if strings.TrimSpace(c.From) == "" {
+		return fmt.Errorf("PRESSLUFT_SMTP_FROM is required")
+	}
+	switch c.Security {
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return fmt.Errorf("PRESSLUFT_SMTP_SECURITY must be starttls, tls or none")
+	}
+	return nil
+}
+
+// LoadSMTPConfig reads the SMTP relay from the environment. It returns nil
+// when PRESSLUFT_SMTP_HOST is unset, which leaves email notifications off.
+func LoadSMTPConfig() (*SMTPConfig, error) {
+	host := strings.TrimSpace(os.Getenv("PRESSLUFT_SMTP_HOST"))
+	if host == "" {
+		return nil, nil
+	}
+	port := 587
+	if raw := strings.TrimSpace(os.Getenv("PRESSLUFT_SMTP_PORT")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("PRESSLUFT_SMTP_PORT: %w", err)
+		}
+		port = parsed
+	}
+	password := os.Getenv("PRESSLUFT_SMTP_PASSWORD")
+	if passwordFile := strings.TrimSpace(os.Getenv("PRESSLUFT_SMTP_PASSWORD_FILE")); password == "" && passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("read smtp password file: %w", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
+	security := strings.ToLower(strings.TrimSpace(os.Getenv("PRESSLUFT_SMTP_SECURITY")))
+	if security == "" {
+		security = SMTPSecurityStartTLS
+	}
+	config := &SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: strings.TrimSpace(os.Getenv("PRESSLUFT_SMTP_USERNAME")),
+		Password: password,
+		From:     strings.TrimSpace(os.Getenv("PRESSLUFT_SMTP_FROM")),
+		Security: security,
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SMTPMailer sends each message over its own SMTP connection. Notifications
+// are infrequent enough that pooling connections is not worth it.
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if m.config.Security == SMTPSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.config.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connect to smtp relay: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if m.config.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp relay does not offer STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(m.config.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	raw, err := m.render(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(raw); err != nil {
+		return fmt.Errorf("smtp write message: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("smtp send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// render builds the RFC 5322 message with a quoted-printable UTF-8 body.
+func (m *SMTPMailer) render(msg Message) ([]byte, error) {
+	var idBytes [12]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, fmt.Errorf("generate message id: %w", err)
+	}
+	domain := "pressluft.local"
+	if at := strings.LastIndex(m.config.From, "@"); at >= 0 {
+		domain = strings.Trim(m.config.From[at+1:], "> ")
+	}
+
+	var buf bytes.Buffer
+	headers := [][2]string{
+		{"From", m.config.From},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().UTC().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(idBytes[:]), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+		{"Auto-Submitted", "auto-generated"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("encode message body: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return nil, fmt.Errorf("encode message body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+)
+
+const (
+	// pageSize is how many activity entries are read per query.
+	pageSize = 200
+	// maxEntriesPerTick bounds how far one tick catches up for one user.
+	maxEntriesPerTick = 2000
+)
+
+// Config holds notifier configuration.
+type Config struct {
+	// PollInterval is how often new activity is checked and digests fall
+	// due.
+	PollInterval time.Duration
+	// PublicURL is the dashboard address linked from emails.
+	PublicURL string
+	// MaxListedEntries caps how many entries one email lists; the rest are
+	// counted.
+	MaxListedEntries int
+}
+
+// DefaultConfig returns sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:     time.Minute,
+		MaxListedEntries: 50,
+	}
+}
+
+// Notifier emails subscribed users about new activity.
+type Notifier struct {
+	store         *Store
+	activityStore *activity.Store
+	mailer        Mailer
+	config        Config
+	logger        *slog.Logger
+}
+
+// NewNotifier creates a notifier with the given dependencies.
+func NewNotifier(store *Store, activityStore *activity.Store, mailer Mailer, logger *slog.Logger, config Config) *Notifier {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.MaxListedEntries <= 0 {
+		config.MaxListedEntries = defaults.MaxListedEntries
+	}
+	config.PublicURL = strings.TrimRight(strings.TrimSpace(config.PublicURL), "/")
+	return &Notifier{
+		store:         store,
+		activityStore: activityStore,
+		mailer:        mailer,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// Run starts the notification loop. It blocks until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context) {
+	n.logger.Info("email notifier started", "poll_interval", n.config.PollInterval)
+
+	ticker := time.NewTicker(n.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.logger.Info("email notifier shutting down")
+			return
+		case <-ticker.C:
+			n.Tick(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// Tick mails every recipient whose notifications are due at now.
+func (n *Notifier) Tick(ctx context.Context, now time.Time) {
+	recipients, err := n.store.listRecipients(ctx)
+	if err != nil {
+		n.logger.Error("list notification recipients failed", "error", err)
+		return
+	}
+	for _, r := range recipients {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := n.notify(ctx, r, now); err != nil {
+			n.logger.Error("email notification failed", "user_id", r.UserID, "error", err)
+		}
+	}
+}
+
+func (n *Notifier) notify(ctx context.Context, r recipient, now time.Time) error {
+	digest := r.Delivery != DeliveryImmediate
+	if digest && !digestDue(r.Preferences, now) {
+		return nil
+	}
+	workspaces, err := n.store.readableWorkspaces(ctx, r.UserID)
+	if err != nil {
+		return err
+	}
+	entries, cursor, err := n.collect(ctx, r.Preferences, workspaces, digest)
+	if err != nil {
+		return err
+	}
+	var sentAt time.Time
+	if digest {
+		sentAt = now
+	}
+	if len(entries) > 0 {
+		// The cursor only moves once the mail is out, so a failed send is
+		// retried on the next tick.
+		if err := n.mailer.Send(ctx, n.compose(r, entries, now)); err != nil {
+			return err
+		}
+	}
+	if cursor == r.LastActivityID && sentAt.IsZero() {
+		return nil
+	}
+	return n.store.advance(ctx, r.UserID, cursor, sentAt)
+}
+
+// digestDue reports whether a digest period has passed since the last one.
+func digestDue(prefs Preferences, now time.Time) bool {
+	period := time.Hour
+	if prefs.Delivery == DeliveryDaily {
+		period = 24 * time.Hour
+	}
+	last, err := time.Parse(time.RFC3339, prefs.LastSentAt)
+	if err != nil {
+		return true
+	}
+	return !now.Before(last.Add(period))
+}
+
+// collect reads the activity recorded after the user's cursor and keeps what
+// they subscribed to in workspaces they can read. Digests only batch entries
+// nobody has marked read yet.
+func (n *Notifier) collect(ctx context.Context, prefs Preferences, workspaces map[string]bool, unreadOnly bool) ([]activity.Activity, string, error) {
+	cursor := prefs.LastActivityID
+	matched := make([]activity.Activity, 0)
+	for read := 0; read < maxEntriesPerTick; read += pageSize {
+		page, err := n.activityStore.ListSince(ctx, cursor, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, entry := range page {
+			cursor = entry.ID
+			if !workspaces[entry.WorkspaceID] || !prefs.Wants(entry) || (unreadOnly && entry.ReadAt != "") {
+				continue
+			}
+			matched = append(matched, entry)
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+	return matched, cursor, nil
+}
+
+func (n *Notifier) compose(r recipient, entries []activity.Activity, now time.Time) Message {
+	var subject string
+	switch {
+	case r.Delivery == DeliveryHourly:
+		subject = fmt.Sprintf("Hourly digest: %s", countEvents(len(entries)))
+	case r.Delivery == DeliveryDaily:
+		subject = fmt.Sprintf("Daily digest: %s", countEvents(len(entries)))
+	case len(entries) == 1:
+		subject = entries[0].Title
+	default:
+		subject = fmt.Sprintf("%d new alerts", len(entries))
+	}
+
+	var body strings.Builder
+	if r.Delivery != DeliveryImmediate {
+		fmt.Fprintf(&body, "Unread activity up to %s:\n\n", now.UTC().Format(time.RFC1123))
+	}
+	listed := entries
+	if len(listed) > n.config.MaxListedEntries {
+		listed = listed[:n.config.MaxListedEntries]
+	}
+	for _, entry := range listed {
+		fmt.Fprintf(&body, "* %s\n  %s (%s)\n", entry.Title, entry.CreatedAt, entry.Level)
+		if message := strings.TrimSpace(entry.Message); message != "" {
+			fmt.Fprintf(&body, "  %s\n", strings.ReplaceAll(message, "\n", "\n  "))
+		}
+		body.WriteString("\n")
+	}
+	if rest := len(entries) - len(listed); rest > 0 {
+		fmt.Fprintf(&body, "...and %d more.\n\n", rest)
+	}
+	if n.config.PublicURL != "" {
+		fmt.Fprintf(&body, "Open the activity log: %s/activity\n\n", n.config.PublicURL)
+	}
+	body.WriteString("-- \nYou receive this because you subscribed to Pressluft notifications.\nChange what you receive under /api/users/me/notifications.\n")
+
+	return Message{
+		To:      r.Email,
+		Subject: "[Pressluft] " + subject,
+		Body:    body.String(),
+	}
+}
+
+func countEvents(n int) string {
+	if n == 1 {
+		return "1 event"
+	}
+	return fmt.Sprintf("%d events", n)
+}
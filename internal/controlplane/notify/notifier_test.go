@@ -0,0 +1,295 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/quotedprintable"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/controlplane/notify/smtptest"
+	"pressluft/internal/shared/workspace"
+
+	_ "modernc.org/sqlite"
+)
+
+const otherWorkspaceID = "00000000-0000-7000-8000-0000000000f1"
+
+type notifierHarness struct {
+	db            *sql.DB
+	store         *Store
+	activityStore *activity.Store
+	relay         *smtptest.Server
+	notifier      *Notifier
+}
+
+func newNotifierHarness(t *testing.T) *notifierHarness {
+	t.Helper()
+	db := mustOpenNotifyDB(t)
+	relay := smtptest.NewServer()
+	t.Cleanup(relay.Close)
+	store := NewStore(db)
+	activityStore := activity.NewStore(db)
+	mailer := NewSMTPMailer(SMTPConfig{Host: relay.Host(), Port: relay.Port(), From: "pressluft@example.test", Security: SMTPSecurityNone})
+	notifier := NewNotifier(store, activityStore, mailer, slog.New(slog.NewTextHandler(io.Discard, nil)), Config{PublicURL: "https://panel.example.test/"})
+	return &notifierHarness{db: db, store: store, activityStore: activityStore, relay: relay, notifier: notifier}
+}
+
+func (h *notifierHarness) addUser(t *testing.T, id, email string, role auth.Role) {
+	t.Helper()
+	if _, err := h.db.Exec(`INSERT INTO users (id, email, status) VALUES (?, ?, 'active')`, id, email); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if _, err := h.db.Exec(`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)`, workspace.DefaultID, id, string(role)); err != nil {
+		t.Fatalf("insert membership: %v", err)
+	}
+}
+
+func (h *notifierHarness) emit(t *testing.T, workspaceID string, in activity.EmitInput) activity.Activity {
+	t.Helper()
+	if in.Category == "" {
+		in.Category = activity.CategoryJob
+	}
+	if in.Level == "" {
+		in.Level = activity.LevelError
+	}
+	in.ActorType = activity.ActorSystem
+	entry, err := h.activityStore.Emit(workspace.WithID(context.Background(), workspaceID), in)
+	if err != nil {
+		t.Fatalf("emit activity: %v", err)
+	}
+	return entry
+}
+
+func decodedBody(t *testing.T, msg smtptest.Message) string {
+	t.Helper()
+	_, body, ok := strings.Cut(msg.Data, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message without body: %q", msg.Data)
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	return string(decoded)
+}
+
+func TestNotifierEmailsSubscribedActivityAsItHappens(t *testing.T) {
+	h := newNotifierHarness(t)
+	ctx := context.Background()
+	h.addUser(t, "owner", "owner@example.test", auth.RoleOwner)
+	h.addUser(t, "client", "client@example.test", auth.RoleClient)
+	h.addUser(t, "quiet", "quiet@example.test", auth.RoleAdmin)
+	for _, userID := range []string{"owner", "client"} {
+		if _, err := h.store.Save(ctx, userID, PreferencesInput{EmailEnabled: true, EventTypes: DefaultEventTypes()}); err != nil {
+			t.Fatalf("Save(%s) error = %v", userID, err)
+		}
+	}
+	if _, err := h.store.Save(ctx, "quiet", PreferencesInput{EmailEnabled: true, EventTypes: []activity.EventType{activity.EventJobCompleted}}); !errors.Is(err, ErrInvalidPreferences) {
+		t.Fatalf("Save() with an event that cannot be subscribed to error = %v, want ErrInvalidPreferences", err)
+	}
+
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventJobFailed, Title: "Job 'deploy' failed", Message: "exit status 2"})
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventJobCompleted, Level: activity.LevelSuccess, Title: "Job completed"})
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventSiteHealthChanged, Category: activity.CategorySite, Level: activity.LevelSuccess, Title: "Site 'blog' recovered"})
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventSiteHealthChanged, Category: activity.CategorySite, Level: activity.LevelWarning, Title: "Site 'shop' needs runtime attention", RequiresAttention: true})
+	h.emit(t, otherWorkspaceID, activity.EmitInput{EventType: activity.EventJobFailed, Title: "Someone else's job failed"})
+
+	h.notifier.Tick(ctx, time.Now().UTC())
+
+	messages := h.relay.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("relay got %d messages, want only the owner's", len(messages))
+	}
+	msg := messages[0]
+	if len(msg.To) != 1 || msg.To[0] != "owner@example.test" || msg.From != "pressluft@example.test" {
+		t.Fatalf("envelope = %s -> %v", msg.From, msg.To)
+	}
+	if !strings.Contains(msg.Data, "Subject: [Pressluft] 2 new alerts\r\n") {
+		t.Fatalf("message = %q, want a subject counting two alerts", msg.Data)
+	}
+	body := decodedBody(t, msg)
+	for _, want := range []string{"Job 'deploy' failed", "exit status 2", "Site 'shop' needs runtime attention", "https://panel.example.test/activity"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body lacks %q:\n%s", want, body)
+		}
+	}
+	for _, unwanted := range []string{"Job completed", "recovered", "Someone else"} {
+		if strings.Contains(body, unwanted) {
+			t.Errorf("body mentions %q:\n%s", unwanted, body)
+		}
+	}
+
+	h.notifier.Tick(ctx, time.Now().UTC())
+	if got := len(h.relay.Messages()); got != 1 {
+		t.Fatalf("relay got %d messages after a quiet tick, want 1", got)
+	}
+}
+
+func TestNotifierSkipsWorkspacesWhereUsersAreLimitedToSites(t *testing.T) {
+	h := newNotifierHarness(t)
+	ctx := context.Background()
+	h.addUser(t, "developer", "developer@example.test", auth.RoleDeveloper)
+	h.addUser(t, "scoped", "scoped@example.test", auth.RoleDeveloper)
+	const siteID = "00000000-0000-7000-8000-0000000000a1"
+	if _, err := h.db.Exec(`INSERT INTO sites (id, workspace_id) VALUES (?, ?)`, siteID, workspace.DefaultID); err != nil {
+		t.Fatalf("insert site: %v", err)
+	}
+	if _, err := h.db.Exec(`INSERT INTO user_site_grants (user_id, site_id) VALUES ('scoped', ?)`, siteID); err != nil {
+		t.Fatalf("insert site grant: %v", err)
+	}
+	for _, userID := range []string{"developer", "scoped"} {
+		if _, err := h.store.Save(ctx, userID, PreferencesInput{EmailEnabled: true, EventTypes: DefaultEventTypes()}); err != nil {
+			t.Fatalf("Save(%s) error = %v", userID, err)
+		}
+	}
+
+	// The scoped developer cannot read the workspace's activity feed, so
+	// mail must not show it to them either.
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventJobFailed, Title: "Job 'deploy' failed"})
+	h.notifier.Tick(ctx, time.Now().UTC())
+
+	messages := h.relay.Messages()
+	if len(messages) != 1 || len(messages[0].To) != 1 || messages[0].To[0] != "developer@example.test" {
+		t.Fatalf("messages = %+v, want only the unscoped developer mailed", messages)
+	}
+}
+
+func TestNotifierRetriesWhenTheRelayRejects(t *testing.T) {
+	h := newNotifierHarness(t)
+	ctx := context.Background()
+	h.addUser(t, "owner", "owner@example.test", auth.RoleOwner)
+	if _, err := h.store.Save(ctx, "owner", PreferencesInput{EmailEnabled: true, EventTypes: []activity.EventType{activity.EventServerAgentOffline}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventServerAgentOffline, Category: activity.CategoryServer, Title: "Agent on 'web-1' went offline"})
+
+	h.relay.Reject(true)
+	h.notifier.Tick(ctx, time.Now().UTC())
+	if got := len(h.relay.Messages()); got != 0 {
+		t.Fatalf("relay accepted %d messages while rejecting", got)
+	}
+
+	h.relay.Reject(false)
+	h.notifier.Tick(ctx, time.Now().UTC())
+	messages := h.relay.Messages()
+	if len(messages) != 1 || !strings.Contains(messages[0].Data, "Subject: [Pressluft] Agent on 'web-1' went offline\r\n") {
+		t.Fatalf("messages = %+v, want the retried alert", messages)
+	}
+}
+
+func TestNotifierBatchesUnreadActivityIntoDigests(t *testing.T) {
+	h := newNotifierHarness(t)
+	ctx := context.Background()
+	h.addUser(t, "owner", "owner@example.test", auth.RoleOwner)
+	prefs, err := h.store.Save(ctx, "owner", PreferencesInput{EmailEnabled: true, EventTypes: []activity.EventType{activity.EventJobFailed}, Delivery: DeliveryHourly})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	start, err := time.Parse(time.RFC3339, prefs.LastSentAt)
+	if err != nil {
+		t.Fatalf("last sent at = %q: %v", prefs.LastSentAt, err)
+	}
+
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventJobFailed, Title: "Backup job failed"})
+	read := h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventJobFailed, Title: "Already handled failure"})
+	h.emit(t, workspace.DefaultID, activity.EmitInput{EventType: activity.EventJobFailed, Title: "Deploy job failed"})
+	if err := h.activityStore.MarkRead(ctx, read.ID); err != nil {
+		t.Fatalf("MarkRead() error = %v", err)
+	}
+
+	h.notifier.Tick(ctx, start.Add(30*time.Minute))
+	if got := len(h.relay.Messages()); got != 0 {
+		t.Fatalf("relay got %d messages before the digest was due", got)
+	}
+
+	h.notifier.Tick(ctx, start.Add(time.Hour))
+	messages := h.relay.Messages()
+	if len(messages) != 1 || !strings.Contains(messages[0].Data, "Subject: [Pressluft] Hourly digest: 2 events\r\n") {
+		t.Fatalf("messages = %+v, want one digest of two events", messages)
+	}
+	body := decodedBody(t, messages[0])
+	if !strings.Contains(body, "Backup job failed") || !strings.Contains(body, "Deploy job failed") || strings.Contains(body, "Already handled") {
+		t.Fatalf("digest body =\n%s", body)
+	}
+
+	// The next digest is an hour after this one, and only has new entries.
+	h.notifier.Tick(ctx, start.Add(90*time.Minute))
+	h.notifier.Tick(ctx, start.Add(2*time.Hour))
+	if got := len(h.relay.Messages()); got != 1 {
+		t.Fatalf("relay got %d messages, want no empty digest", got)
+	}
+}
+
+func mustOpenNotifyDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "notify.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec(`
+		CREATE TABLE activity (
+			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			event_type           TEXT NOT NULL,
+			category             TEXT NOT NULL,
+			level                TEXT NOT NULL,
+			resource_type        TEXT,
+			resource_id          TEXT,
+			parent_resource_type TEXT,
+			parent_resource_id   TEXT,
+			actor_type           TEXT NOT NULL,
+			actor_id             TEXT,
+			title                TEXT NOT NULL,
+			message              TEXT,
+			payload              TEXT,
+			requires_attention   INTEGER NOT NULL DEFAULT 0,
+			read_at              TEXT,
+			created_at           TEXT NOT NULL
+		);
+		CREATE TABLE users (
+			id     TEXT PRIMARY KEY,
+			email  TEXT NOT NULL UNIQUE,
+			status TEXT NOT NULL DEFAULT 'active'
+		);
+		CREATE TABLE workspace_members (
+			workspace_id TEXT NOT NULL,
+			user_id      TEXT NOT NULL,
+			role         TEXT NOT NULL,
+			PRIMARY KEY (workspace_id, user_id)
+		);
+		CREATE TABLE sites (
+			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL
+		);
+		CREATE TABLE user_site_grants (
+			user_id TEXT NOT NULL,
+			site_id TEXT NOT NULL,
+			PRIMARY KEY (user_id, site_id)
+		);
+		CREATE TABLE notification_preferences (
+			user_id          TEXT PRIMARY KEY,
+			email_enabled    INTEGER NOT NULL DEFAULT 0,
+			event_types      TEXT NOT NULL DEFAULT '[]',
+			delivery         TEXT NOT NULL DEFAULT 'immediate',
+			last_activity_id TEXT NOT NULL DEFAULT '',
+			last_sent_at     TEXT,
+			created_at       TEXT NOT NULL,
+			updated_at       TEXT NOT NULL
+		);
+	`); err != nil {
+		t.Fatalf("create notify schema: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,185 @@
+// Package smtptest provides an in-process stand-in for an SMTP relay. It
+// speaks enough plain SMTP to accept messages from net/smtp and keeps them
+// for inspection, and is intended for tests only.
+package smtptest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message is one message the stand-in accepted.
+type Message struct {
+	From string
+	To   []string
+	// Data is the raw message with headers, as sent after DATA.
+	Data string
+}
+
+// Server listens on a loopback port until Close.
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	// reject, when set, fails every DATA command with this reply.
+	reject string
+	wg     sync.WaitGroup
+}
+
+// NewServer starts a stand-in on a random loopback port.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: listen: %v", err))
+	}
+	s := &Server{listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Host is the address to configure the mailer with.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port is the port to configure the mailer with.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Messages returns the messages accepted so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Reject makes the stand-in refuse messages with a temporary failure, or
+// accept them again when reject is false.
+func (s *Server) Reject(reject bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject = ""
+	if reject {
+		s.reject = "451 4.3.0 try again later"
+	}
+}
+
+// Close stops listening and waits for open sessions to end.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.session(conn)
+		}()
+	}
+}
+
+func (s *Server) session(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) bool {
+		_, err := fmt.Fprintf(conn, "%s\r\n", line)
+		return err == nil
+	}
+
+	var current Message
+	if !reply("220 smtptest ready") {
+		return
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-smtptest")
+			reply("250 8BITMIME")
+		case "HELO", "NOOP":
+			reply("250 ok")
+		case "RSET":
+			current = Message{}
+			reply("250 ok")
+		case "MAIL":
+			current = Message{From: addressArg(arg)}
+			reply("250 ok")
+		case "RCPT":
+			current.To = append(current.To, addressArg(arg))
+			reply("250 ok")
+		case "DATA":
+			s.mu.Lock()
+			reject := s.reject
+			s.mu.Unlock()
+			if reject != "" {
+				reply(reject)
+				continue
+			}
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := readData(reader)
+			if err != nil {
+				return
+			}
+			current.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			current = Message{}
+			reply("250 ok: queued as " + strconv.Itoa(len(s.Messages())))
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+// addressArg extracts the address from "FROM:<a@b>" or "TO:<a@b>".
+func addressArg(arg string) string {
+	_, address, _ := strings.Cut(arg, ":")
+	address = strings.TrimSpace(address)
+	if i := strings.Index(address, " "); i >= 0 {
+		address = address[:i]
+	}
+	return strings.Trim(address, "<>")
+}
+
+// readData reads a dot-terminated message and undoes dot-stuffing.
+func readData(reader *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "." {
+			return b.String(), nil
+		}
+		if strings.HasPrefix(trimmed, ".") {
+			trimmed = trimmed[1:]
+		}
+		b.WriteString(trimmed)
+		b.WriteString("\r\n")
+	}
+}
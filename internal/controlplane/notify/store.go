@@ -0,0 +1,296 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/auth"
+)
+
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryHourly    = "hourly"
+	DeliveryDaily     = "daily"
+)
+
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+// notifiableEvents are the events worth an email, with what makes a single
+// entry of them worth one.
+var notifiableEvents = map[activity.EventType]func(activity.Activity) bool{
+	activity.EventJobFailed:                     always,
+	activity.EventServerAgentOffline:            always,
+	activity.EventBackupFailed:                  always,
+	activity.EventScheduleRunFailed:             always,
+	activity.EventSecurityVulnerabilityDetected: always,
+	activity.EventSiteDowntimeStarted:           always,
+	// Only the change to an issue; recoveries are left to the dashboard.
+	activity.EventSiteHealthChanged: func(a activity.Activity) bool { return a.RequiresAttention },
+}
+
+func always(activity.Activity) bool { return true }
+
+// NotifiableEventTypes lists the events users can subscribe to.
+func NotifiableEventTypes() []activity.EventType {
+	out := make([]activity.EventType, 0, len(notifiableEvents))
+	for eventType := range notifiableEvents {
+		out = append(out, eventType)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// DefaultEventTypes are subscribed to until the user picks their own.
+func DefaultEventTypes() []activity.EventType {
+	return []activity.EventType{
+		activity.EventJobFailed,
+		activity.EventServerAgentOffline,
+		activity.EventSiteHealthChanged,
+		activity.EventSiteDowntimeStarted,
+	}
+}
+
+// Preferences are a user's email notification settings.
+type Preferences struct {
+	UserID       string
+	EmailEnabled bool
+	EventTypes   []activity.EventType
+	Delivery     string
+	// LastActivityID is the newest activity entry already considered.
+	LastActivityID string
+	// LastSentAt is when the last digest went out.
+	LastSentAt string
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+// Wants reports whether an activity entry is one the user subscribed to.
+func (p Preferences) Wants(entry activity.Activity) bool {
+	notifiable, ok := notifiableEvents[entry.EventType]
+	return ok && slices.Contains(p.EventTypes, entry.EventType) && notifiable(entry)
+}
+
+type PreferencesInput struct {
+	EmailEnabled bool
+	EventTypes   []activity.EventType
+	Delivery     string
+}
+
+// Store persists notification preferences.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Get returns the user's preferences, or the defaults with email off when
+// they never saved any.
+func (s *Store) Get(ctx context.Context, userID string) (Preferences, error) {
+	prefs, err := scanPreferences(s.db.QueryRowContext(ctx, `
+		SELECT user_id, email_enabled, event_types, delivery, last_activity_id, COALESCE(last_sent_at, ''), created_at, updated_at
+		FROM notification_preferences
+		WHERE user_id = ?
+	`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Preferences{UserID: userID, EventTypes: DefaultEventTypes(), Delivery: DeliveryImmediate}, nil
+	}
+	if err != nil {
+		return Preferences{}, fmt.Errorf("get notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+func scanPreferences(scanner interface{ Scan(dest ...any) error }) (Preferences, error) {
+	var (
+		prefs      Preferences
+		enabled    int
+		eventTypes string
+	)
+	if err := scanner.Scan(&prefs.UserID, &enabled, &eventTypes, &prefs.Delivery, &prefs.LastActivityID, &prefs.LastSentAt, &prefs.CreatedAt, &prefs.UpdatedAt); err != nil {
+		return Preferences{}, err
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &prefs.EventTypes); err != nil {
+		return Preferences{}, fmt.Errorf("decode event types: %w", err)
+	}
+	prefs.EmailEnabled = enabled == 1
+	return prefs, nil
+}
+
+// Save stores the user's preferences. Switching email on starts from the
+// newest activity entry, so nothing recorded while it was off is mailed.
+func (s *Store) Save(ctx context.Context, userID string, in PreferencesInput) (Preferences, error) {
+	if in.Delivery == "" {
+		in.Delivery = DeliveryImmediate
+	}
+	switch in.Delivery {
+	case DeliveryImmediate, DeliveryHourly, DeliveryDaily:
+	default:
+		return Preferences{}, fmt.Errorf("%w: delivery must be immediate, hourly or daily", ErrInvalidPreferences)
+	}
+	eventTypes := make([]activity.EventType, 0, len(in.EventTypes))
+	for _, eventType := range in.EventTypes {
+		if _, ok := notifiableEvents[eventType]; !ok {
+			return Preferences{}, fmt.Errorf("%w: %q cannot be subscribed to", ErrInvalidPreferences, eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if in.EmailEnabled && len(eventTypes) == 0 {
+		return Preferences{}, fmt.Errorf("%w: pick at least one event", ErrInvalidPreferences)
+	}
+
+	existing, err := s.Get(ctx, userID)
+	if err != nil {
+		return Preferences{}, err
+	}
+	cursor := existing.LastActivityID
+	lastSentAt := existing.LastSentAt
+	now := time.Now().UTC().Format(time.RFC3339)
+	if in.EmailEnabled && !existing.EmailEnabled {
+		if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), '') FROM activity`).Scan(&cursor); err != nil {
+			return Preferences{}, fmt.Errorf("read latest activity: %w", err)
+		}
+		// The first digest covers one period from now.
+		lastSentAt = now
+	}
+	encoded, _ := json.Marshal(eventTypes)
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_preferences (user_id, email_enabled, event_types, delivery, last_activity_id, last_sent_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			email_enabled = excluded.email_enabled,
+			event_types = excluded.event_types,
+			delivery = excluded.delivery,
+			last_activity_id = excluded.last_activity_id,
+			last_sent_at = excluded.last_sent_at,
+			updated_at = excluded.updated_at
+	`, userID, boolInt(in.EmailEnabled), string(encoded), in.Delivery, cursor, nullableString(lastSentAt), now, now); err != nil {
+		return Preferences{}, fmt.Errorf("save notification preferences: %w", err)
+	}
+	return s.Get(ctx, userID)
+}
+
+// recipient is an active user with email notifications switched on.
+type recipient struct {
+	Preferences
+	Email string
+}
+
+func (s *Store) listRecipients(ctx context.Context) ([]recipient, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.user_id, p.email_enabled, p.event_types, p.delivery, p.last_activity_id, COALESCE(p.last_sent_at, ''), p.created_at, p.updated_at, u.email
+		FROM notification_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.email_enabled = 1 AND u.status = ?
+		ORDER BY p.user_id ASC
+	`, auth.UserStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("list notification recipients: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]recipient, 0)
+	for rows.Next() {
+		var r recipient
+		var enabled int
+		var eventTypes string
+		if err := rows.Scan(&r.UserID, &enabled, &eventTypes, &r.Delivery, &r.LastActivityID, &r.LastSentAt, &r.CreatedAt, &r.UpdatedAt, &r.Email); err != nil {
+			return nil, fmt.Errorf("scan notification recipient: %w", err)
+		}
+		if err := json.Unmarshal([]byte(eventTypes), &r.EventTypes); err != nil {
+			return nil, fmt.Errorf("decode event types: %w", err)
+		}
+		r.EmailEnabled = enabled == 1
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate notification recipients: %w", err)
+	}
+	return out, nil
+}
+
+// readableWorkspaces returns the workspaces whose activity the user may
+// read. Like GET /api/activity, that takes the capability and no site grants
+// in the workspace: a member limited to some sites is not told about the
+// rest.
+func (s *Store) readableWorkspaces(ctx context.Context, userID string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.workspace_id, m.role, COALESCE(g.site_id, '')
+		FROM workspace_members m
+		LEFT JOIN user_site_grants g
+			ON g.user_id = m.user_id
+			AND g.site_id NOT IN (SELECT id FROM sites WHERE workspace_id != m.workspace_id)
+		WHERE m.user_id = ?
+		ORDER BY m.workspace_id ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list workspace memberships: %w", err)
+	}
+	defer rows.Close()
+
+	roles := map[string]auth.Role{}
+	grants := map[string][]string{}
+	for rows.Next() {
+		var workspaceID, role, siteID string
+		if err := rows.Scan(&workspaceID, &role, &siteID); err != nil {
+			return nil, fmt.Errorf("scan workspace membership: %w", err)
+		}
+		roles[workspaceID] = auth.Role(role)
+		if siteID != "" {
+			grants[workspaceID] = append(grants[workspaceID], siteID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate workspace memberships: %w", err)
+	}
+
+	canRead := auth.RequireUnscopedCapability(auth.CapabilityReadActivity)
+	out := map[string]bool{}
+	for workspaceID, role := range roles {
+		member := auth.Actor{ID: userID, Type: auth.ActorTypeOperator, Role: role, Authenticated: true}
+		if canRead(member.ScopeToSites(grants[workspaceID])) {
+			out[workspaceID] = true
+		}
+	}
+	return out, nil
+}
+
+// advance moves the user's cursor past what was just considered, and
+// records a sent digest.
+func (s *Store) advance(ctx context.Context, userID, lastActivityID string, sentAt time.Time) error {
+	var lastSentAt any
+	if !sentAt.IsZero() {
+		lastSentAt = sentAt.UTC().Format(time.RFC3339)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE notification_preferences
+		SET last_activity_id = ?, last_sent_at = COALESCE(?, last_sent_at)
+		WHERE user_id = ?
+	`, lastActivityID, lastSentAt, userID); err != nil {
+		return fmt.Errorf("advance notification cursor: %w", err)
+	}
+	return nil
+}
+
+func nullableString(v string) any {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+func boolInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -7,8 +7,14 @@ import (
 	"pressluft/internal/controlplane/auth"
 )
 
+// activityActorFromRequest attributes activity to the signed-in user.
+// Requests made with an API token are recorded as API actors, keyed by the
+// token's user for personal tokens and by the token itself for service ones.
 func activityActorFromRequest(r *http.Request) (activity.ActorType, string) {
 	actor := auth.ActorFromContext(r.Context())
+	if actor.IsAuthenticated() && actor.TokenID != "" {
+		return activity.ActorAPI, actor.ID
+	}
 	if actor.IsAuthenticated() {
 		return activity.ActorUser, actor.ID
 	}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"pressluft/internal/controlplane/activity"
 	"pressluft/internal/controlplane/apitypes"
@@ -44,7 +45,20 @@ func (h *authHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	actor, err := h.service.Login(r.Context(), w, r, req.Email, req.Password)
+	var secondFactor *auth.SecondFactorRequiredError
+	if errors.As(err, &secondFactor) {
+		respondJSON(w, http.StatusOK, apitypes.LoginChallengeResponse{
+			TOTPRequired: true,
+			Challenge:    secondFactor.Challenge,
+			ExpiresAt:    secondFactor.ExpiresAt.Format(time.RFC3339),
+		})
+		return
+	}
 	if err != nil {
+		if errors.Is(err, auth.ErrPasswordLoginDisabled) {
+			respondError(w, http.StatusForbidden, "password login is disabled; sign in with single sign-on")
+			return
+		}
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			h.emitSecurityActivity(r, activity.EventSecurityLoginFailed, activity.ActorAPI, "", fmt.Sprintf("Failed login for %s", req.Email), true)
 			respondError(w, http.StatusUnauthorized, "invalid credentials")
@@ -85,6 +99,13 @@ func (h *authHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
 }
 
+func (h *authHandler) loggerOrDefault() *slog.Logger {
+	if h.logger == nil {
+		return slog.Default()
+	}
+	return h.logger
+}
+
 func (h *authHandler) emitSecurityActivity(r *http.Request, event activity.EventType, actorType activity.ActorType, actorID, title string, attention bool) {
 	if h.activityStore == nil {
 		return
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+// handleAcceptInvitation redeems an invitation link: the invited user picks
+// a password and is signed in right away.
+func (h *authHandler) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req apitypes.SetPasswordRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	actor, err := h.service.AcceptInvitation(r.Context(), w, r, req.Token, req.Password)
+	if err != nil {
+		h.respondSetPasswordError(w, err, "failed to accept invitation")
+		return
+	}
+	h.emitSecurityActivity(r, activity.EventSecurityInvitationAccepted, activity.ActorUser, actor.ID, fmt.Sprintf("%s accepted their invitation", actor.Email), false)
+	respondJSON(w, http.StatusOK, actor)
+}
+
+// handleResetPassword redeems a password reset link. The user signs in
+// afterwards with the new password.
+func (h *authHandler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req apitypes.SetPasswordRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := h.service.ResetPassword(r.Context(), req.Token, req.Password)
+	if err != nil {
+		h.respondSetPasswordError(w, err, "failed to reset password")
+		return
+	}
+	h.emitSecurityActivity(r, activity.EventSecurityPasswordChanged, activity.ActorUser, user.ID, fmt.Sprintf("%s reset their password", user.Email), false)
+	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
+}
+
+func (h *authHandler) respondSetPasswordError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidPassword), errors.Is(err, auth.ErrInvalidAccountToken):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.loggerOrDefault().Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+// oidcFailurePath is where the browser lands when single sign-on fails; the
+// login page explains the error code.
+const oidcFailurePath = "/login?error="
+
+func (h *authHandler) handleLoginMethods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	methods := h.service.LoginMethods()
+	respondJSON(w, http.StatusOK, apitypes.LoginMethods{
+		Password: methods.Password,
+		OIDC:     methods.OIDC,
+		OIDCName: methods.OIDCName,
+	})
+}
+
+// handleOIDCLogin sends the browser to the identity provider. It is a plain
+// navigation, so failures redirect back to the login page instead of
+// answering with JSON.
+func (h *authHandler) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	authURL, err := h.service.BeginOIDCLogin(r.Context(), w, r.URL.Query().Get("redirect"))
+	if errors.Is(err, auth.ErrOIDCNotConfigured) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.loggerOrDefault().Error("start single sign-on failed", "error", err)
+		http.Redirect(w, r, oidcFailurePath+"sso_unavailable", http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *authHandler) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	actor, redirectPath, err := h.service.CompleteOIDCLogin(r.Context(), w, r)
+	var refused *auth.OIDCLoginError
+	switch {
+	case errors.Is(err, auth.ErrOIDCNotConfigured):
+		http.NotFound(w, r)
+		return
+	case errors.As(err, &refused):
+		h.loggerOrDefault().Warn("single sign-on refused", "email", refused.Email, "error", refused.Err)
+		h.emitSecurityActivity(r, activity.EventSecurityLoginFailed, activity.ActorAPI, "", fmt.Sprintf("Single sign-on refused for %s", refused.Email), true)
+		code := "sso_failed"
+		if errors.Is(err, auth.ErrOIDCNoRole) {
+			code = "sso_no_role"
+		}
+		http.Redirect(w, r, oidcFailurePath+code, http.StatusFound)
+		return
+	case errors.Is(err, auth.ErrOIDCLoginFailed):
+		h.loggerOrDefault().Warn("single sign-on failed", "error", err)
+		http.Redirect(w, r, oidcFailurePath+"sso_failed", http.StatusFound)
+		return
+	case err != nil:
+		h.loggerOrDefault().Error("single sign-on failed", "error", err)
+		http.Redirect(w, r, oidcFailurePath+"sso_failed", http.StatusFound)
+		return
+	}
+
+	h.emitSecurityActivity(r, activity.EventSecurityLoginSucceeded, activity.ActorUser, actor.ID, fmt.Sprintf("User %s logged in with %s", actor.Email, h.service.LoginMethods().OIDCName), false)
+	http.Redirect(w, r, redirectPath, http.StatusFound)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/controlplane/auth/oidctest"
+)
+
+func TestOIDCLoginFlowStartsSessionAndDisablesPasswordLogin(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	store := auth.NewStore(db)
+	if _, err := store.CreateUser(context.Background(), "owner@agency.test", "correct horse battery staple", auth.RoleOwner); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	idp := oidctest.NewServer(oidctest.User{Subject: "dev-1", Email: "dev@agency.test", EmailVerified: true, Groups: []string{"developers"}})
+	defer idp.Close()
+	provider, err := auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  "https://panel.example.test/api/auth/oidc/callback",
+		DisplayName:  "Agency SSO",
+		RoleMapping:  map[string]auth.Role{"developers": auth.RoleDeveloper},
+	}, idp.Client())
+	if err != nil {
+		t.Fatalf("NewOIDCProvider() error = %v", err)
+	}
+	service := auth.NewService(store, []byte("test-session-secret"), time.Hour, 2*time.Hour, false)
+	service.ConfigureOIDC(provider, true)
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator: auth.NewSessionAuthenticator(service),
+		AuthService:   service,
+	})
+
+	serve := func(method, target, body string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodGet, "/api/auth/methods", "", nil)
+	var methods apitypes.LoginMethods
+	if err := json.Unmarshal(res.Body.Bytes(), &methods); err != nil {
+		t.Fatalf("decode methods: %v", err)
+	}
+	if methods.Password || !methods.OIDC || methods.OIDCName != "Agency SSO" {
+		t.Fatalf("methods = %+v, want single sign-on only", methods)
+	}
+	if res := serve(http.MethodPost, "/api/auth/login", `{"email":"owner@agency.test","password":"correct horse battery staple"}`, nil); res.Code != http.StatusForbidden {
+		t.Fatalf("password login status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+
+	res = serve(http.MethodGet, "/api/auth/oidc/login?redirect=/sites", "", nil)
+	if res.Code != http.StatusFound {
+		t.Fatalf("oidc login status = %d; body = %s", res.Code, res.Body.String())
+	}
+	stateCookies := res.Result().Cookies()
+	callback, err := idp.Authorize(res.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+
+	res = serve(http.MethodGet, callback.RequestURI(), "", stateCookies)
+	if res.Code != http.StatusFound || res.Header().Get("Location") != "/sites" {
+		t.Fatalf("callback status = %d location = %q, want redirect to /sites", res.Code, res.Header().Get("Location"))
+	}
+	var session []*http.Cookie
+	for _, cookie := range res.Result().Cookies() {
+		if cookie.Name == auth.SessionCookieName {
+			session = append(session, cookie)
+		}
+	}
+	if len(session) != 1 {
+		t.Fatal("callback did not set a session cookie")
+	}
+
+	res = serve(http.MethodGet, "/api/auth/me", "", session)
+	var actor auth.Actor
+	if err := json.Unmarshal(res.Body.Bytes(), &actor); err != nil {
+		t.Fatalf("decode actor: %v", err)
+	}
+	if actor.Email != "dev@agency.test" || actor.Role != auth.RoleDeveloper || actor.AuthSource != "oidc" {
+		t.Fatalf("actor = %+v, want developer dev@agency.test from oidc", actor)
+	}
+
+	// A replayed callback is bounced back to the login page.
+	res = serve(http.MethodGet, callback.RequestURI(), "", stateCookies)
+	if res.Code != http.StatusFound || res.Header().Get("Location") != "/login?error=sso_failed" {
+		t.Fatalf("replayed callback status = %d location = %q", res.Code, res.Header().Get("Location"))
+	}
+}
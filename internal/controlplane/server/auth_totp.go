@@ -0,0 +1,214 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+// handleLoginTOTP is the second login step for users with TOTP enabled.
+func (h *authHandler) handleLoginTOTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req apitypes.LoginTOTPRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	actor, usedRecoveryCode, err := h.service.CompleteLogin(r.Context(), w, r, req.Challenge, req.Code)
+	var failed *auth.SecondFactorError
+	switch {
+	case errors.As(err, &failed):
+		h.emitSecurityActivity(r, activity.EventSecurityTOTPFailed, activity.ActorAPI, "", fmt.Sprintf("Failed two-factor code for %s", failed.Email), true)
+		respondError(w, http.StatusUnauthorized, "invalid two-factor code")
+		return
+	case errors.Is(err, auth.ErrUnauthenticated):
+		respondError(w, http.StatusUnauthorized, "login challenge expired; sign in again")
+		return
+	case err != nil:
+		h.respondTOTPError(w, err, "login failed")
+		return
+	}
+
+	if usedRecoveryCode {
+		h.emitSecurityActivity(r, activity.EventSecurityRecoveryCodeUsed, activity.ActorUser, actor.ID, fmt.Sprintf("Recovery code used by %s", actor.Email), true)
+	}
+	h.emitSecurityActivity(r, activity.EventSecurityLoginSucceeded, activity.ActorUser, actor.ID, fmt.Sprintf("User %s logged in", actor.Email), false)
+	respondJSON(w, http.StatusOK, actor)
+}
+
+func (h *authHandler) handleTOTPStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	actor, ok := h.requireActor(w, r)
+	if !ok {
+		return
+	}
+	status, err := h.service.TOTPStatus(r.Context(), actor.ID)
+	if err != nil {
+		h.respondTOTPError(w, err, "failed to read two-factor status")
+		return
+	}
+	respondJSON(w, http.StatusOK, apitypes.TOTPStatus{
+		Enabled:                status.Enabled,
+		EnabledAt:              status.EnabledAt,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+		RequiredByPolicy:       status.RequiredByPolicy,
+	})
+}
+
+func (h *authHandler) handleTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	actor, ok := h.requireActor(w, r)
+	if !ok {
+		return
+	}
+	enrollment, err := h.service.BeginTOTPEnrollment(r.Context(), actor)
+	if err != nil {
+		h.respondTOTPError(w, err, "failed to start two-factor enrollment")
+		return
+	}
+	respondJSON(w, http.StatusOK, apitypes.TOTPEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.ProvisioningURI,
+	})
+}
+
+func (h *authHandler) handleTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	actor, ok := h.requireActor(w, r)
+	if !ok {
+		return
+	}
+	var req apitypes.TOTPCodeRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	codes, err := h.service.ConfirmTOTPEnrollment(r.Context(), actor, req.Code)
+	if err != nil {
+		h.respondTOTPError(w, err, "failed to confirm two-factor enrollment")
+		return
+	}
+	h.emitSecurityActivity(r, activity.EventSecurityTOTPEnabled, activity.ActorUser, actor.ID, fmt.Sprintf("Two-factor authentication enabled for %s", actor.Email), false)
+	respondJSON(w, http.StatusOK, apitypes.TOTPRecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *authHandler) handleTOTPDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	actor, ok := h.requireActor(w, r)
+	if !ok {
+		return
+	}
+	var req apitypes.TOTPCodeRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.service.DisableTOTP(r.Context(), actor, req.Code); err != nil {
+		if errors.Is(err, auth.ErrInvalidSecondFactor) {
+			h.emitSecurityActivity(r, activity.EventSecurityTOTPFailed, activity.ActorUser, actor.ID, fmt.Sprintf("Failed two-factor code for %s", actor.Email), true)
+		}
+		h.respondTOTPError(w, err, "failed to disable two-factor authentication")
+		return
+	}
+	h.emitSecurityActivity(r, activity.EventSecurityTOTPDisabled, activity.ActorUser, actor.ID, fmt.Sprintf("Two-factor authentication disabled for %s", actor.Email), true)
+	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
+}
+
+// handleAuthPolicy reads and updates the control-plane login policy. The
+// policy applies to every workspace, so the service limits updates to owners
+// of the default workspace.
+func (h *authHandler) handleAuthPolicy(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := h.service.AuthPolicy(r.Context())
+		if err != nil {
+			h.respondTOTPError(w, err, "failed to read auth policy")
+			return
+		}
+		respondJSON(w, http.StatusOK, apiAuthPolicy(policy))
+	case http.MethodPut:
+		var req apitypes.UpdateAuthPolicyRequest
+		if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+			return
+		}
+		policy, err := h.service.UpdateAuthPolicy(r.Context(), auth.ActorFromContext(r.Context()), auth.AuthPolicy{RequireTOTP: req.RequireTOTP})
+		if errors.Is(err, auth.ErrForbidden) {
+			respondError(w, http.StatusForbidden, "only owners of the default workspace may change the login policy")
+			return
+		}
+		if err != nil {
+			h.respondTOTPError(w, err, "failed to update auth policy")
+			return
+		}
+		actorType, actorID := activityActorFromRequest(r)
+		title := "Two-factor authentication is no longer required"
+		if policy.RequireTOTP {
+			title = "Two-factor authentication is now required for all operators"
+		}
+		h.emitSecurityActivity(r, activity.EventSecurityAuthPolicyUpdated, actorType, actorID, title, false)
+		respondJSON(w, http.StatusOK, apiAuthPolicy(policy))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *authHandler) requireActor(w http.ResponseWriter, r *http.Request) (auth.Actor, bool) {
+	actor := auth.ActorFromContext(r.Context())
+	if !actor.IsAuthenticated() {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return auth.Actor{}, false
+	}
+	return actor, true
+}
+
+func (h *authHandler) respondTOTPError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidSecondFactor):
+		respondError(w, http.StatusUnauthorized, "invalid two-factor code")
+	case errors.Is(err, auth.ErrForbidden):
+		respondError(w, http.StatusForbidden, "two-factor authentication is managed from a signed-in session")
+	case errors.Is(err, auth.ErrTOTPNotEnabled), errors.Is(err, auth.ErrTOTPAlreadyEnabled), errors.Is(err, auth.ErrTOTPRequiredByPolicy):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		logger := h.logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func apiAuthPolicy(policy auth.AuthPolicy) apitypes.AuthPolicy {
+	return apitypes.AuthPolicy{RequireTOTP: policy.RequireTOTP, UpdatedAt: policy.UpdatedAt}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+func TestTOTPEnrollmentAndTwoStepLogin(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	store := auth.NewStore(db)
+	const password = "correct horse battery staple"
+	if _, err := store.CreateUser(context.Background(), "operator@example.test", password, auth.RoleOwner); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	service := auth.NewService(store, []byte("test-session-secret"), time.Hour, 2*time.Hour, false)
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator: auth.NewSessionAuthenticator(service),
+		AuthService:   service,
+	})
+
+	var cookies []*http.Cookie
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if set := res.Result().Cookies(); len(set) > 0 {
+			cookies = set
+		}
+		return res
+	}
+	loginBody := `{"email":"operator@example.test","password":"` + password + `"}`
+
+	if res := serve(http.MethodPost, "/api/auth/login", loginBody); res.Code != http.StatusOK {
+		t.Fatalf("login status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodPut, "/api/auth/policy", `{"require_totp":true}`); res.Code != http.StatusOK {
+		t.Fatalf("update policy status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/sites", ""); res.Code != http.StatusForbidden {
+		t.Fatalf("unenrolled GET /api/sites status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+
+	res := serve(http.MethodPost, "/api/auth/totp/enroll", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("enroll status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var enrollment apitypes.TOTPEnrollmentResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &enrollment); err != nil {
+		t.Fatalf("decode enrollment: %v", err)
+	}
+	code, err := auth.TOTPCode(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatalf("TOTPCode() error = %v", err)
+	}
+	res = serve(http.MethodPost, "/api/auth/totp/confirm", `{"code":"`+code+`"}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("confirm status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var recovery apitypes.TOTPRecoveryCodesResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &recovery); err != nil {
+		t.Fatalf("decode recovery codes: %v", err)
+	}
+	if res := serve(http.MethodGet, "/api/sites", ""); res.Code != http.StatusOK {
+		t.Fatalf("enrolled GET /api/sites status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	cookies = nil
+	res = serve(http.MethodPost, "/api/auth/login", loginBody)
+	var challenge apitypes.LoginChallengeResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &challenge); err != nil {
+		t.Fatalf("decode login challenge: %v", err)
+	}
+	if res.Code != http.StatusOK || !challenge.TOTPRequired || challenge.Challenge == "" || len(cookies) != 0 {
+		t.Fatalf("login = %d %s, want a challenge and no session", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodPost, "/api/auth/login/totp", `{"challenge":"`+challenge.Challenge+`","code":"000000"}`); res.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong code status = %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+	res = serve(http.MethodPost, "/api/auth/login/totp", `{"challenge":"`+challenge.Challenge+`","code":"`+recovery.RecoveryCodes[0]+`"}`)
+	if res.Code != http.StatusOK || len(cookies) == 0 {
+		t.Fatalf("recovery code login status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/sites", ""); res.Code != http.StatusOK {
+		t.Fatalf("two-step session GET /api/sites status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodPost, "/api/auth/totp/disable", `{"code":"`+recovery.RecoveryCodes[1]+`"}`); res.Code != http.StatusConflict {
+		t.Fatalf("disable under policy status = %d, want %d", res.Code, http.StatusConflict)
+	}
+
+	activities, _, err := activity.NewStore(db).List(context.Background(), activity.ListFilter{Category: activity.CategorySecurity, Limit: 20})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	seen := map[activity.EventType]bool{}
+	for _, entry := range activities {
+		seen[entry.EventType] = true
+	}
+	for _, event := range []activity.EventType{
+		activity.EventSecurityAuthPolicyUpdated,
+		activity.EventSecurityTOTPEnabled,
+		activity.EventSecurityTOTPFailed,
+		activity.EventSecurityRecoveryCodeUsed,
+	} {
+		if !seen[event] {
+			t.Errorf("missing %s activity; got %v", event, seen)
+		}
+	}
+}
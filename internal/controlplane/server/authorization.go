@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+// requestRule decides whether an actor may make a request.
+type requestRule func(auth.Actor, *http.Request) bool
+
+// byMethod picks a rule by request method. HEAD follows GET and the "*"
+// entry covers every method without a rule of its own.
+func byMethod(rules map[string]func(auth.Actor) bool) requestRule {
+	return func(actor auth.Actor, r *http.Request) bool {
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		allow, ok := rules[method]
+		if !ok {
+			allow, ok = rules["*"]
+		}
+		return ok && allow(actor)
+	}
+}
+
+// readWrite guards reads with one rule and every other method with another.
+func readWrite(read, write func(auth.Actor) bool) requestRule {
+	return byMethod(map[string]func(auth.Actor) bool{
+		http.MethodGet: read,
+		"*":            write,
+	})
+}
+
+// siteRouteRule guards /api/sites/{id}/...: a scoped actor must hold a grant
+// for the site, and the sub-resource and method pick the capability.
+func siteRouteRule(actor auth.Actor, r *http.Request) bool {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/sites/"), "/"), "/")
+	siteID, _ := apitypes.ParseAppID(parts[0])
+	if !actor.CanAccessSite(siteID) {
+		return false
+	}
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+	resource := ""
+	if len(parts) > 1 {
+		resource = parts[1]
+	}
+	switch {
+	case read:
+		return auth.HasCapability(actor, auth.CapabilityReadSites)
+	case resource == "backups" || resource == "restore":
+		return auth.HasCapability(actor, auth.CapabilityManageBackups)
+	default:
+		return auth.HasCapability(actor, auth.CapabilityManageSites)
+	}
+}
+
+// jobKindCapability is what queueing a job of the given kind requires on
+// top of queue_jobs.
+func jobKindCapability(kind string) auth.Capability {
+	spec, ok := orchestrator.JobKindPolicy(kind)
+	if ok && spec.Destructive {
+		return auth.CapabilityDeleteServers
+	}
+	switch orchestrator.JobKind(kind) {
+	case orchestrator.JobKindBackupSite, orchestrator.JobKindRestoreSite:
+		return auth.CapabilityManageBackups
+	case orchestrator.JobKindDeploySite, orchestrator.JobKindCreateStaging, orchestrator.JobKindPushSite, orchestrator.JobKindUpdateSiteComponents:
+		return auth.CapabilityManageSites
+	default:
+		return auth.CapabilityManageServers
+	}
+}
+
+// canAccessJob hides jobs for sites outside a scoped actor's grants. Jobs
+// that are not tied to a site are fleet-wide and hidden from scoped actors.
+func canAccessJob(actor auth.Actor, job orchestrator.Job) bool {
+	if !actor.Scoped {
+		return true
+	}
+	siteID := orchestrator.SiteIDFromPayload(job.Payload)
+	return siteID != "" && actor.CanAccessSite(siteID)
+}
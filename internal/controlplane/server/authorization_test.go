@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+func TestRouteAuthorizationByRole(t *testing.T) {
+	const (
+		owner     = "owner"
+		admin     = "admin"
+		developer = "developer"
+		viewer    = "viewer"
+		client    = "client"
+	)
+	admins := []string{owner, admin}
+	developers := []string{owner, admin, developer}
+	readers := []string{owner, admin, developer, viewer}
+	everyone := []string{owner, admin, developer, viewer, client}
+
+	type route struct {
+		method  string
+		path    string
+		body    string
+		allowed []string
+	}
+	routes := func(providerID, serverID, siteID string) []route {
+		return []route{
+			{http.MethodGet, "/api/providers", "", admins},
+			{http.MethodPost, "/api/providers", "{}", admins},
+			{http.MethodGet, "/api/providers/types", "", admins},
+			{http.MethodPost, "/api/providers/validate", "{}", admins},
+			{http.MethodDelete, "/api/providers/" + providerID, "", admins},
+
+			{http.MethodGet, "/api/servers", "", readers},
+			{http.MethodPost, "/api/servers", "{}", admins},
+			{http.MethodGet, "/api/servers/profiles", "", readers},
+			{http.MethodGet, "/api/servers/" + serverID, "", readers},
+			{http.MethodGet, "/api/servers/" + serverID + "/jobs", "", readers},
+			{http.MethodGet, "/api/servers/" + serverID + "/activity", "", readers},
+			{http.MethodGet, "/api/servers/" + serverID + "/sites", "", readers},
+
+			{http.MethodGet, "/api/backup-targets", "", developers},
+			{http.MethodPost, "/api/backup-targets", "{}", developers},
+
+			{http.MethodGet, "/api/sites", "", everyone},
+			{http.MethodPost, "/api/sites", "{}", developers},
+			{http.MethodGet, "/api/sites/" + siteID, "", everyone},
+			{http.MethodPatch, "/api/sites/" + siteID, "{}", developers},
+			{http.MethodGet, "/api/sites/" + siteID + "/activity", "", everyone},
+			{http.MethodGet, "/api/sites/" + siteID + "/domains", "", everyone},
+			{http.MethodPost, "/api/sites/" + siteID + "/domains", "{}", developers},
+			{http.MethodGet, "/api/sites/" + siteID + "/backups", "", everyone},
+			{http.MethodPost, "/api/sites/" + siteID + "/backups", "{}", developers},
+			{http.MethodPost, "/api/sites/" + siteID + "/restore", "{}", developers},
+			{http.MethodGet, "/api/sites/" + siteID + "/staging", "", everyone},
+			{http.MethodPost, "/api/sites/" + siteID + "/staging", "{}", developers},
+			{http.MethodPost, "/api/sites/" + siteID + "/push", "{}", developers},
+			{http.MethodGet, "/api/sites/" + siteID + "/components", "", everyone},
+			{http.MethodPost, "/api/sites/" + siteID + "/components/refresh", "", developers},
+			{http.MethodPost, "/api/sites/" + siteID + "/updates", "{}", developers},
+			{http.MethodGet, "/api/sites/" + siteID + "/vulnerabilities", "", everyone},
+			{http.MethodGet, "/api/sites/" + siteID + "/health", "", everyone},
+			{http.MethodGet, "/api/components/outdated", "", readers},
+			{http.MethodGet, "/api/vulnerabilities", "", readers},
+			{http.MethodGet, "/api/reports/costs", "", readers},
+
+			{http.MethodGet, "/api/domains", "", readers},
+			{http.MethodPost, "/api/domains", "{}", developers},
+
+			{http.MethodGet, "/api/jobs", "", everyone},
+			{http.MethodPost, "/api/jobs", `{"kind":"restart_service","server_id":"` + serverID + `","payload":{"service_name":"nginx"}}`, admins},
+			{http.MethodPost, "/api/jobs", `{"kind":"backup_site","payload":{"site_id":"` + siteID + `"}}`, developers},
+			{http.MethodGet, "/api/jobs/" + testPublicID(900), "", everyone},
+			{http.MethodPost, "/api/jobs/" + testPublicID(900) + "/cancel", "", developers},
+			{http.MethodGet, "/api/worker/slots", "", readers},
+
+			{http.MethodGet, "/api/schedules", "", readers},
+			{http.MethodPost, "/api/schedules", "{}", developers},
+
+			{http.MethodGet, "/api/activity", "", readers},
+			{http.MethodGet, "/api/activity/unread-count", "", readers},
+
+			{http.MethodGet, "/api/users", "", admins},
+			{http.MethodPatch, "/api/users/" + testPublicID(901), `{"role":"viewer"}`, admins},
+			{http.MethodPost, "/api/users/" + testPublicID(901) + "/password-reset", "", admins},
+			{http.MethodGet, "/api/users/" + testPublicID(901) + "/site-grants", "", admins},
+			{http.MethodPut, "/api/users/" + testPublicID(901) + "/site-grants", `{"site_ids":[]}`, admins},
+			{http.MethodDelete, "/api/users/" + testPublicID(901) + "/totp", "", admins},
+
+			// Destructive requests run last so they cannot hide earlier routes.
+			{http.MethodPost, "/api/jobs", `{"kind":"delete_server","server_id":"` + serverID + `","payload":{}}`, admins},
+			{http.MethodDelete, "/api/sites/" + siteID, "", developers},
+			{http.MethodDelete, "/api/servers/" + serverID, "", admins},
+		}
+	}
+
+	for _, role := range everyone {
+		t.Run(role, func(t *testing.T) {
+			db := mustOpenServerHandlerDB(t)
+			_, providerID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+			serverID := mustInsertServerRecord(t, db, providerID, "ready")
+			siteID := mustCreateAuthorizationTestSite(t, db, serverID, "Agency Site")
+
+			// Only the client is limited to a grant here; scoped developers
+			// and viewers are covered by TestScopedActorOnlySeesGrantedSites.
+			var grants []string
+			if role == client {
+				grants = []string{siteID}
+			}
+			actor := auth.Actor{ID: "user-" + role, Type: auth.ActorTypeOperator, Role: auth.Role(role), Authenticated: true}
+			actor = actor.ScopeToSites(grants)
+			handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+				Authenticator: staticAuthenticator{actor: actor},
+				WorkerSlots:   staticWorkerSlots{},
+			})
+
+			for _, rt := range routes(providerID, serverID, siteID) {
+				req := httptest.NewRequest(rt.method, rt.path, strings.NewReader(rt.body))
+				req.Header.Set("Content-Type", "application/json")
+				res := httptest.NewRecorder()
+				handler.ServeHTTP(res, req)
+
+				wantAllowed := false
+				for _, allowed := range rt.allowed {
+					if allowed == role {
+						wantAllowed = true
+					}
+				}
+				if forbidden := res.Code == http.StatusForbidden; forbidden == wantAllowed {
+					t.Errorf("%s %s %s status = %d, want allowed = %v; body = %s", role, rt.method, rt.path, res.Code, wantAllowed, res.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestScopedActorOnlySeesGrantedSites(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	_, providerID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerID, "ready")
+	grantedID := mustCreateAuthorizationTestSite(t, db, serverID, "Granted Site")
+	otherID := mustCreateAuthorizationTestSite(t, db, serverID, "Other Site")
+
+	jobStore := orchestrator.NewStore(db)
+	grantedJob := mustCreateSiteJob(t, jobStore, serverID, grantedID)
+	otherJob := mustCreateSiteJob(t, jobStore, serverID, otherID)
+
+	actor := auth.Actor{ID: "freelancer", Type: auth.ActorTypeOperator, Role: auth.RoleDeveloper, Authenticated: true}
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator: staticAuthenticator{actor: actor.ScopeToSites([]string{grantedID})},
+	})
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodGet, "/api/sites", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("list sites status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var sites []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &sites); err != nil {
+		t.Fatalf("decode sites: %v", err)
+	}
+	if len(sites) != 1 || sites[0].ID != grantedID {
+		t.Fatalf("listed sites = %+v, want only %s", sites, grantedID)
+	}
+
+	if res := serve(http.MethodGet, "/api/sites/"+grantedID, ""); res.Code != http.StatusOK {
+		t.Fatalf("granted site status = %d; body = %s", res.Code, res.Body.String())
+	}
+	for _, path := range []string{"/api/sites/" + otherID, "/api/sites/" + otherID + "/backups", "/api/servers", "/api/activity", "/api/domains"} {
+		if res := serve(http.MethodGet, path, ""); res.Code != http.StatusForbidden {
+			t.Fatalf("GET %s status = %d, want %d", path, res.Code, http.StatusForbidden)
+		}
+	}
+	if res := serve(http.MethodPost, "/api/sites/"+otherID+"/backups", "{}"); res.Code != http.StatusForbidden {
+		t.Fatalf("backup of ungranted site status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+	if res := serve(http.MethodPost, "/api/jobs", `{"kind":"backup_site","payload":{"site_id":"`+grantedID+`"}}`); res.Code != http.StatusForbidden {
+		t.Fatalf("scoped POST /api/jobs status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+
+	res = serve(http.MethodGet, "/api/jobs", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("list jobs status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var jobs []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &jobs); err != nil {
+		t.Fatalf("decode jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != grantedJob.ID {
+		t.Fatalf("listed jobs = %+v, want only %s", jobs, grantedJob.ID)
+	}
+	if res := serve(http.MethodGet, "/api/jobs/"+grantedJob.ID, ""); res.Code != http.StatusOK {
+		t.Fatalf("granted job status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/jobs/"+otherJob.ID, ""); res.Code != http.StatusNotFound {
+		t.Fatalf("ungranted job status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+}
+
+func mustCreateAuthorizationTestSite(t *testing.T, db *sql.DB, serverID, name string) string {
+	t.Helper()
+	siteID, err := NewSiteStore(db).Create(context.Background(), CreateSiteInput{ServerID: serverID, Name: name, WordPressAdminEmail: "owner@example.test", Status: SiteStatusDraft})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	return siteID
+}
+
+func mustCreateSiteJob(t *testing.T, store *orchestrator.Store, serverID, siteID string) orchestrator.Job {
+	t.Helper()
+	job, err := store.CreateJob(context.Background(), orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindBackupSite),
+		ServerID: serverID,
+		Payload:  `{"site_id":"` + siteID + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("create site job: %v", err)
+	}
+	return job
+}
@@ -14,6 +14,9 @@ import (
 	"pressluft/internal/controlplane/activity"
 	"pressluft/internal/controlplane/apitypes"
 	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/controlplane/notify"
+	"pressluft/internal/controlplane/server/middleware"
+	"pressluft/internal/controlplane/webhooks"
 	"pressluft/internal/infra/provider"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
@@ -47,12 +50,23 @@ func NewHandlerWithOptions(db *sql.DB, hub *ws.Hub, wsHandler *WSHandler, nodeHa
 		}
 		return withAuthorization(handler, allow)
 	}
+	authorizeRequest := func(handler http.Handler, allow requestRule) http.Handler {
+		if options.Authenticator == nil {
+			return handler
+		}
+		return withRequestAuthorization(handler, allow)
+	}
 
 	// Health
 	mux.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
 		handleHealth(w, r, options)
 	})
 
+	// Prometheus metrics
+	if options.MetricsHandler != nil {
+		mux.Handle("/metrics", options.MetricsHandler)
+	}
+
 	// Agent WebSocket
 	if wsHandler != nil {
 		mux.HandleFunc("/ws/agent", wsHandler.handleAgentWebSocket)
@@ -77,6 +91,23 @@ func NewHandlerWithOptions(db *sql.DB, hub *ws.Hub, wsHandler *WSHandler, nodeHa
 		mux.Handle("/api/auth/me", withOptionalActor(http.HandlerFunc(authHandler.handleMe), options.Authenticator))
 		mux.Handle("/api/auth/logout", withOptionalActor(http.HandlerFunc(authHandler.handleLogout), options.Authenticator))
 		mux.Handle("/api/auth/login", withRateLimit(http.HandlerFunc(authHandler.handleLogin), newRateLimiter(10, time.Minute), "auth-login"))
+		mux.Handle("/api/auth/login/totp", withRateLimit(http.HandlerFunc(authHandler.handleLoginTOTP), newRateLimiter(10, time.Minute), "auth-login-totp"))
+		mux.HandleFunc("/api/auth/methods", authHandler.handleLoginMethods)
+		oidcLimiter := newRateLimiter(20, time.Minute)
+		mux.Handle("/api/auth/oidc/login", withRateLimit(http.HandlerFunc(authHandler.handleOIDCLogin), oidcLimiter, "auth-oidc"))
+		mux.Handle("/api/auth/oidc/callback", withRateLimit(http.HandlerFunc(authHandler.handleOIDCCallback), oidcLimiter, "auth-oidc"))
+		// Invitation and password reset links are redeemed before sign-in.
+		accountLimiter := newRateLimiter(10, time.Minute)
+		mux.Handle("/api/auth/invitations/accept", withRateLimit(http.HandlerFunc(authHandler.handleAcceptInvitation), accountLimiter, "auth-account"))
+		mux.Handle("/api/auth/password/reset", withRateLimit(http.HandlerFunc(authHandler.handleResetPassword), accountLimiter, "auth-account"))
+		// Enrollment stays reachable for actors that policy still blocks
+		// from the operator API until they have enrolled.
+		totpLimiter := newRateLimiter(20, time.Minute)
+		mux.Handle("/api/auth/totp", withOptionalActor(http.HandlerFunc(authHandler.handleTOTPStatus), options.Authenticator))
+		mux.Handle("/api/auth/totp/enroll", withOptionalActor(withRateLimit(http.HandlerFunc(authHandler.handleTOTPEnroll), totpLimiter, "auth-totp"), options.Authenticator))
+		mux.Handle("/api/auth/totp/confirm", withOptionalActor(withRateLimit(http.HandlerFunc(authHandler.handleTOTPConfirm), totpLimiter, "auth-totp"), options.Authenticator))
+		mux.Handle("/api/auth/totp/disable", withOptionalActor(withRateLimit(http.HandlerFunc(authHandler.handleTOTPDisable), totpLimiter, "auth-totp"), options.Authenticator))
+		operatorMux.Handle("/api/auth/policy", authorize(http.HandlerFunc(authHandler.handleAuthPolicy), auth.RequireUnscopedCapability(auth.CapabilityManageUsers)))
 	}
 
 	// Provider endpoints (only when database is available)
@@ -88,15 +119,16 @@ func NewHandlerWithOptions(db *sql.DB, hub *ws.Hub, wsHandler *WSHandler, nodeHa
 			store:         provider.NewStore(db),
 			activityStore: activityStore,
 		}
-		operatorMux.Handle("/api/providers", authorize(http.HandlerFunc(ph.route), auth.RequireCapability(auth.CapabilityManageProviders)))
-		operatorMux.Handle("/api/providers/", authorize(http.HandlerFunc(ph.routeWithID), auth.RequireCapability(auth.CapabilityManageProviders)))
-		operatorMux.Handle("/api/providers/validate", authorize(withRateLimit(http.HandlerFunc(ph.handleValidate), newRateLimiter(15, time.Minute), "provider-validate"), auth.RequireCapability(auth.CapabilityManageProviders)))
-		operatorMux.Handle("/api/providers/types", authorize(http.HandlerFunc(ph.handleTypes), auth.RequireCapability(auth.CapabilityManageProviders)))
+		operatorMux.Handle("/api/providers", authorize(http.HandlerFunc(ph.route), auth.RequireUnscopedCapability(auth.CapabilityManageProviders)))
+		operatorMux.Handle("/api/providers/", authorize(http.HandlerFunc(ph.routeWithID), auth.RequireUnscopedCapability(auth.CapabilityManageProviders)))
+		operatorMux.Handle("/api/providers/validate", authorize(withRateLimit(http.HandlerFunc(ph.handleValidate), newRateLimiter(15, time.Minute), "provider-validate"), auth.RequireUnscopedCapability(auth.CapabilityManageProviders)))
+		operatorMux.Handle("/api/providers/types", authorize(http.HandlerFunc(ph.handleTypes), auth.RequireUnscopedCapability(auth.CapabilityManageProviders)))
 
 		jobStore := orchestrator.NewStore(db)
 		serverStore := NewServerStore(db)
 		siteStore := NewSiteStore(db)
 		domainStore := NewDomainStore(db)
+		backupStore := NewBackupStore(db)
 		_ = domainStore.BackfillLegacyPrimaryDomains(context.Background())
 
 		sh := &serversHandler{
@@ -107,46 +139,124 @@ func NewHandlerWithOptions(db *sql.DB, hub *ws.Hub, wsHandler *WSHandler, nodeHa
 			activityStore: activityStore,
 			hub:           hub,
 		}
-		operatorMux.Handle("/api/servers", authorize(withRateLimit(http.HandlerFunc(sh.route), newRateLimiter(30, time.Minute), "servers"), auth.RequireCapability(auth.CapabilityManageServers)))
-		operatorMux.Handle("/api/servers/", authorize(withRateLimit(http.HandlerFunc(sh.routeWithPath), newRateLimiter(60, time.Minute), "servers-path"), auth.RequireCapability(auth.CapabilityManageServers)))
-
-		sih := &sitesHandler{
-			store:         siteStore,
-			serverStore:   serverStore,
+		serverRule := byMethod(map[string]func(auth.Actor) bool{
+			http.MethodGet:    auth.RequireUnscopedCapability(auth.CapabilityReadServers),
+			http.MethodDelete: auth.RequireUnscopedCapability(auth.CapabilityDeleteServers),
+			"*":               auth.RequireUnscopedCapability(auth.CapabilityManageServers),
+		})
+		operatorMux.Handle("/api/servers", authorizeRequest(withRateLimit(http.HandlerFunc(sh.route), newRateLimiter(30, time.Minute), "servers"), serverRule))
+		operatorMux.Handle("/api/servers/", authorizeRequest(withRateLimit(http.HandlerFunc(sh.routeWithPath), newRateLimiter(60, time.Minute), "servers-path"), serverRule))
+
+		bh := &backupsHandler{
+			store:         backupStore,
+			siteStore:     siteStore,
 			jobStore:      jobStore,
-			domainStore:   domainStore,
 			activityStore: activityStore,
-			hub:           hub,
 		}
-		operatorMux.Handle("/api/sites", authorize(withRateLimit(http.HandlerFunc(sih.route), newRateLimiter(30, time.Minute), "sites"), auth.RequireCapability(auth.CapabilityManageSites)))
-		operatorMux.Handle("/api/sites/", authorize(withRateLimit(http.HandlerFunc(sih.routeWithID), newRateLimiter(60, time.Minute), "sites-path"), auth.RequireCapability(auth.CapabilityManageSites)))
+		operatorMux.Handle("/api/backup-targets", authorize(withRateLimit(http.HandlerFunc(bh.routeTargets), newRateLimiter(15, time.Minute), "backup-targets"), auth.RequireUnscopedCapability(auth.CapabilityManageBackups)))
+		operatorMux.Handle("/api/backup-targets/", authorize(withRateLimit(http.HandlerFunc(bh.routeTargetWithID), newRateLimiter(30, time.Minute), "backup-targets-path"), auth.RequireUnscopedCapability(auth.CapabilityManageBackups)))
+
+		sih := &sitesHandler{
+			store:              siteStore,
+			serverStore:        serverStore,
+			jobStore:           jobStore,
+			domainStore:        domainStore,
+			activityStore:      activityStore,
+			backupsHandler:     bh,
+			componentStore:     NewComponentStore(db),
+			vulnScanner:        options.VulnerabilityScanner,
+			vulnerabilityStore: NewVulnerabilityStore(db),
+			uptimeStore:        NewUptimeStore(db),
+			hub:                hub,
+		}
+		operatorMux.Handle("/api/sites", authorizeRequest(withRateLimit(http.HandlerFunc(sih.route), newRateLimiter(30, time.Minute), "sites"), readWrite(auth.RequireCapability(auth.CapabilityReadSites), auth.RequireUnscopedCapability(auth.CapabilityManageSites))))
+		operatorMux.Handle("/api/sites/", authorizeRequest(withRateLimit(http.HandlerFunc(sih.routeWithID), newRateLimiter(60, time.Minute), "sites-path"), siteRouteRule))
+		operatorMux.Handle("/api/components/outdated", authorize(http.HandlerFunc(sih.handleListOutdated), auth.RequireUnscopedCapability(auth.CapabilityReadSites)))
+		operatorMux.Handle("/api/vulnerabilities", authorize(http.HandlerFunc(sih.handleVulnerabilityReport), auth.RequireUnscopedCapability(auth.CapabilityReadSites)))
+
+		rh := &reportsHandler{costStore: NewCostStore(db)}
+		operatorMux.Handle("/api/reports/costs", authorize(http.HandlerFunc(rh.handleCosts), auth.RequireUnscopedCapability(auth.CapabilityReadServers)))
 
 		dh := &domainsHandler{store: domainStore, activityStore: activityStore}
-		operatorMux.Handle("/api/domains", authorize(withRateLimit(http.HandlerFunc(dh.route), newRateLimiter(30, time.Minute), "domains"), auth.RequireCapability(auth.CapabilityManageSites)))
-		operatorMux.Handle("/api/domains/", authorize(withRateLimit(http.HandlerFunc(dh.routeWithID), newRateLimiter(60, time.Minute), "domains-path"), auth.RequireCapability(auth.CapabilityManageSites)))
+		domainRule := readWrite(auth.RequireUnscopedCapability(auth.CapabilityReadSites), auth.RequireUnscopedCapability(auth.CapabilityManageSites))
+		operatorMux.Handle("/api/domains", authorizeRequest(withRateLimit(http.HandlerFunc(dh.route), newRateLimiter(30, time.Minute), "domains"), domainRule))
+		operatorMux.Handle("/api/domains/", authorizeRequest(withRateLimit(http.HandlerFunc(dh.routeWithID), newRateLimiter(60, time.Minute), "domains-path"), domainRule))
 
+		references := payloadReferences{sites: siteStore, backups: backupStore}
 		jh := &jobsHandler{
 			store:         jobStore,
 			serverStore:   serverStore,
+			references:    references,
 			activityStore: activityStore,
+			hub:           hub,
+			canceller:     options.JobCanceller,
+		}
+		operatorMux.Handle("/api/jobs", authorizeRequest(withRateLimit(http.HandlerFunc(jh.route), newRateLimiter(30, time.Minute), "jobs"), readWrite(auth.RequireCapability(auth.CapabilityReadJobs), auth.RequireUnscopedCapability(auth.CapabilityQueueJobs))))
+		operatorMux.Handle("/api/jobs/", authorizeRequest(http.HandlerFunc(jh.routeWithID), readWrite(auth.RequireCapability(auth.CapabilityReadJobs), auth.RequireCapability(auth.CapabilityQueueJobs))))
+		if options.WorkerSlots != nil {
+			wh := &workerSlotsHandler{slots: options.WorkerSlots, serverStore: serverStore, providerStore: provider.NewStore(db)}
+			operatorMux.Handle("/api/worker/slots", authorize(http.HandlerFunc(wh.handleSlots), auth.RequireUnscopedCapability(auth.CapabilityReadJobs)))
 		}
-		operatorMux.Handle("/api/jobs", authorize(withRateLimit(http.HandlerFunc(jh.route), newRateLimiter(30, time.Minute), "jobs"), auth.RequireCapability(auth.CapabilityQueueJobs)))
-		operatorMux.Handle("/api/jobs/", authorize(http.HandlerFunc(jh.routeWithID), auth.RequireCapability(auth.CapabilityQueueJobs)))
+
+		sch := &schedulesHandler{
+			store:         NewScheduleStore(db),
+			serverStore:   serverStore,
+			references:    references,
+			activityStore: activityStore,
+		}
+		scheduleRule := readWrite(auth.RequireUnscopedCapability(auth.CapabilityReadJobs), auth.RequireUnscopedCapability(auth.CapabilityQueueJobs))
+		operatorMux.Handle("/api/schedules", authorizeRequest(withRateLimit(http.HandlerFunc(sch.route), newRateLimiter(30, time.Minute), "schedules"), scheduleRule))
+		operatorMux.Handle("/api/schedules/", authorizeRequest(withRateLimit(http.HandlerFunc(sch.routeWithID), newRateLimiter(60, time.Minute), "schedules-path"), scheduleRule))
 
 		ah := &activityHandler{store: activityStore}
-		operatorMux.Handle("/api/activity", authorize(http.HandlerFunc(ah.route), auth.RequireCapability(auth.CapabilityReadActivity)))
-		operatorMux.Handle("/api/activity/", authorize(http.HandlerFunc(ah.routeWithID), auth.RequireCapability(auth.CapabilityReadActivity)))
+		operatorMux.Handle("/api/activity", authorize(http.HandlerFunc(ah.route), auth.RequireUnscopedCapability(auth.CapabilityReadActivity)))
+		operatorMux.Handle("/api/activity/", authorize(http.HandlerFunc(ah.routeWithID), auth.RequireUnscopedCapability(auth.CapabilityReadActivity)))
+
+		uh := &usersHandler{
+			store:         auth.NewStore(db),
+			siteStore:     siteStore,
+			activityStore: activityStore,
+			service:       options.AuthService,
+			publicURL:     options.ControlPlaneURL,
+		}
+		operatorMux.Handle("/api/users", authorize(withRateLimit(http.HandlerFunc(uh.route), newRateLimiter(30, time.Minute), "users"), auth.RequireUnscopedCapability(auth.CapabilityManageUsers)))
+		operatorMux.Handle("/api/users/", authorize(http.HandlerFunc(uh.routeWithID), auth.RequireUnscopedCapability(auth.CapabilityManageUsers)))
+
+		whh := &webhooksHandler{store: webhooks.NewStore(db), activityStore: activityStore}
+		operatorMux.Handle("/api/webhooks", authorize(withRateLimit(http.HandlerFunc(whh.route), newRateLimiter(30, time.Minute), "webhooks"), auth.RequireUnscopedCapability(auth.CapabilityManageWebhooks)))
+		operatorMux.Handle("/api/webhooks/", authorize(withRateLimit(http.HandlerFunc(whh.routeWithID), newRateLimiter(60, time.Minute), "webhooks-path"), auth.RequireUnscopedCapability(auth.CapabilityManageWebhooks)))
+
+		// Every signed-in actor manages its own API tokens; the auth service
+		// decides who may issue service tokens or see other users' tokens.
+		// The same goes for the password and sessions under /api/users/me/.
+		if options.AuthService != nil {
+			acc := &accountHandler{
+				service:         options.AuthService,
+				activityStore:   activityStore,
+				notifications:   notify.NewStore(db),
+				emailConfigured: options.EmailConfigured,
+			}
+			operatorMux.Handle("/api/users/me/", authorize(withRateLimit(http.HandlerFunc(acc.route), newRateLimiter(30, time.Minute), "account"), auth.Actor.IsAuthenticated))
+			th := &tokensHandler{service: options.AuthService, activityStore: activityStore}
+			operatorMux.Handle("/api/tokens", authorize(withRateLimit(http.HandlerFunc(th.route), newRateLimiter(30, time.Minute), "tokens"), auth.Actor.IsAuthenticated))
+			operatorMux.Handle("/api/tokens/", authorize(http.HandlerFunc(th.routeWithID), auth.Actor.IsAuthenticated))
+			// Anyone signed in lists their workspaces; the auth service and the
+			// handler decide who creates workspaces and adds members.
+			wsh := &workspacesHandler{service: options.AuthService, activityStore: activityStore}
+			operatorMux.Handle("/api/workspaces", authorize(withRateLimit(http.HandlerFunc(wsh.route), newRateLimiter(30, time.Minute), "workspaces"), auth.Actor.IsAuthenticated))
+			operatorMux.Handle("/api/workspaces/", authorize(withRateLimit(http.HandlerFunc(wsh.routeWithID), newRateLimiter(30, time.Minute), "workspaces-path"), auth.Actor.IsAuthenticated))
+		}
 
 		// Inject activity handler into servers handler for /api/servers/{id}/activity
 		sh.activityHandler = ah
 		sih.activityHandler = ah
 	}
-	mux.Handle("/api/", withOperatorAuth(operatorMux, options.Authenticator))
+	mux.Handle("/api/", withOperatorAuth(middleware.RecordRoute(operatorMux), options.Authenticator))
 
 	// Dashboard SPA (catch-all)
 	dashboard := newDashboardHandler(options.Authenticator)
 	mux.Handle("/", dashboard)
-	return withSecurityHeaders(mux, options.IsDev)
+	return withSecurityHeaders(middleware.RecordRoute(mux), options.IsDev)
 }
 
 func newDashboardHandler(authenticator auth.Authenticator) http.Handler {
@@ -210,7 +320,7 @@ func isEmbeddedFile(fsys fs.FS, path string) bool {
 
 func shouldAllowPublicDashboardPath(path string) bool {
 	switch path {
-	case "/login", "/favicon.ico", "/robots.txt":
+	case "/login", "/invite", "/reset-password", "/favicon.ico", "/robots.txt":
 		return true
 	}
 	return strings.HasPrefix(path, "/_nuxt/")
@@ -231,6 +341,17 @@ func missingDashboardHandler() http.Handler {
 
 func handleHealth(w http.ResponseWriter, _ *http.Request, options HandlerOptions) {
 	payload := apitypes.HealthResponse{Status: "healthy"}
+	if options.WorkerSlots != nil {
+		usage := options.WorkerSlots.SlotUsage()
+		payload.Worker = &apitypes.WorkerHealth{
+			Running:            usage.Running,
+			MaxJobs:            usage.MaxJobs,
+			MaxJobsPerProvider: usage.MaxJobsPerProvider,
+			MaxJobsPerServer:   usage.MaxJobsPerServer,
+			BusyProviders:      len(usage.Providers),
+			BusyServers:        len(usage.Servers),
+		}
+	}
 	if options.IsDev {
 		mode := platform.DetectCallbackURLMode(options.ControlPlaneURL)
 		payload.CallbackURLMode = mode
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/controlplane/notify"
+)
+
+// accountHandler serves /api/users/me/: what every signed-in user manages
+// about their own account.
+type accountHandler struct {
+	service         *auth.Service
+	activityStore   *activity.Store
+	notifications   *notify.Store
+	emailConfigured bool
+}
+
+func (ah *accountHandler) route(w http.ResponseWriter, r *http.Request) {
+	tail := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users/me/"), "/")
+	parts := strings.Split(tail, "/")
+	switch {
+	case tail == "password":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ah.handleChangePassword(w, r)
+	case tail == "sessions":
+		switch r.Method {
+		case http.MethodGet:
+			ah.handleListSessions(w, r)
+		case http.MethodDelete:
+			ah.handleRevokeOtherSessions(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case tail == "notifications":
+		switch r.Method {
+		case http.MethodGet:
+			ah.handleGetNotifications(w, r)
+		case http.MethodPut:
+			ah.handleUpdateNotifications(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(parts) == 2 && parts[0] == "sessions":
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sessionID, err := apitypes.ParseAppID(parts[1])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid session id")
+			return
+		}
+		ah.handleRevokeSession(w, r, sessionID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (ah *accountHandler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.ChangePasswordRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	actor := auth.ActorFromContext(r.Context())
+	if err := ah.service.ChangePassword(r.Context(), r, actor, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			respondError(w, http.StatusBadRequest, "current password is incorrect")
+			return
+		}
+		respondAccountError(w, err, "failed to change password")
+		return
+	}
+	ah.emitActivity(r, activity.EventSecurityPasswordChanged, actor, fmt.Sprintf("%s changed their password", actor.Email), "Their other sessions were signed out.")
+	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
+}
+
+func (ah *accountHandler) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := ah.service.ListSessions(r.Context(), r, auth.ActorFromContext(r.Context()))
+	if err != nil {
+		respondAccountError(w, err, "failed to list sessions")
+		return
+	}
+	out := make([]apitypes.UserSession, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, apitypes.UserSession{
+			ID:         apitypes.FormatAppID(session.ID),
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			AuthMethod: session.AuthMethod,
+			Current:    session.Current,
+		})
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (ah *accountHandler) handleRevokeSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	actor := auth.ActorFromContext(r.Context())
+	if err := ah.service.RevokeOwnSession(r.Context(), actor, sessionID); err != nil {
+		respondAccountError(w, err, "failed to revoke session")
+		return
+	}
+	ah.emitActivity(r, activity.EventSecuritySessionRevoked, actor, fmt.Sprintf("Session revoked for %s", actor.Email), "")
+	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
+}
+
+func (ah *accountHandler) handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	actor := auth.ActorFromContext(r.Context())
+	revoked, err := ah.service.RevokeOtherSessions(r.Context(), r, actor)
+	if err != nil {
+		respondAccountError(w, err, "failed to revoke sessions")
+		return
+	}
+	if revoked > 0 {
+		ah.emitActivity(r, activity.EventSecuritySessionRevoked, actor, fmt.Sprintf("Other sessions revoked for %s", actor.Email), fmt.Sprintf("%d session(s) were signed out.", revoked))
+	}
+	respondJSON(w, http.StatusOK, apitypes.RevokeSessionsResponse{Revoked: revoked})
+}
+
+func (ah *accountHandler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	actor := auth.ActorFromContext(r.Context())
+	if actor.Type != auth.ActorTypeOperator || actor.ID == "" {
+		respondAccountError(w, auth.ErrForbidden, "")
+		return
+	}
+	prefs, err := ah.notifications.Get(r.Context(), actor.ID)
+	if err != nil {
+		respondAccountError(w, err, "failed to get notification preferences")
+		return
+	}
+	respondJSON(w, http.StatusOK, ah.apiNotificationPreferences(prefs))
+}
+
+func (ah *accountHandler) handleUpdateNotifications(w http.ResponseWriter, r *http.Request) {
+	actor := auth.ActorFromContext(r.Context())
+	if actor.Type != auth.ActorTypeOperator || actor.ID == "" {
+		respondAccountError(w, auth.ErrForbidden, "")
+		return
+	}
+	var req apitypes.UpdateNotificationPreferencesRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	prefs, err := ah.notifications.Save(r.Context(), actor.ID, notify.PreferencesInput{
+		EmailEnabled: req.EmailEnabled,
+		EventTypes:   req.EventTypes,
+		Delivery:     req.Delivery,
+	})
+	if err != nil {
+		respondAccountError(w, err, "failed to save notification preferences")
+		return
+	}
+	respondJSON(w, http.StatusOK, ah.apiNotificationPreferences(prefs))
+}
+
+func (ah *accountHandler) apiNotificationPreferences(prefs notify.Preferences) apitypes.NotificationPreferences {
+	return apitypes.NotificationPreferences{
+		EmailEnabled:        prefs.EmailEnabled,
+		EventTypes:          prefs.EventTypes,
+		Delivery:            prefs.Delivery,
+		AvailableEventTypes: notify.NotifiableEventTypes(),
+		EmailConfigured:     ah.emailConfigured,
+	}
+}
+
+func (ah *accountHandler) emitActivity(r *http.Request, eventType activity.EventType, actor auth.Actor, title, message string) {
+	if ah.activityStore == nil {
+		return
+	}
+	_, _ = ah.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategorySecurity,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceAccount,
+		ResourceID:   actor.ID,
+		ActorType:    activity.ActorUser,
+		ActorID:      actor.ID,
+		Title:        title,
+		Message:      message,
+	})
+}
+
+func respondAccountError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidPassword), errors.Is(err, notify.ErrInvalidPreferences):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		respondError(w, http.StatusForbidden, "only signed-in users can manage their own account")
+	case errors.Is(err, auth.ErrSessionNotFound):
+		respondError(w, http.StatusNotFound, "session not found")
+	default:
+		slog.Default().Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
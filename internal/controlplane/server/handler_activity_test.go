@@ -344,11 +344,14 @@ func mustOpenActivityHandlerDB(t *testing.T) *sql.DB {
 	// Create minimal schema needed for handler tests
 	if _, err := db.Exec(`
 		CREATE TABLE servers (
-			id TEXT PRIMARY KEY
+			id          TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			provider_id TEXT
 		);
 
 		CREATE TABLE jobs (
 			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id    TEXT,
 			kind         TEXT    NOT NULL,
 			status       TEXT    NOT NULL,
@@ -360,6 +363,8 @@ func mustOpenActivityHandlerDB(t *testing.T) *sql.DB {
 			finished_at  TEXT,
 			timeout_at   TEXT,
 			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
 			created_at   TEXT    NOT NULL,
 			updated_at   TEXT    NOT NULL,
 			FOREIGN KEY (server_id) REFERENCES servers(id)
@@ -369,6 +374,7 @@ func mustOpenActivityHandlerDB(t *testing.T) *sql.DB {
 			id         TEXT PRIMARY KEY,
 			job_id     TEXT    NOT NULL,
 			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
 			event_type TEXT    NOT NULL,
 			level      TEXT    NOT NULL,
 			step_key   TEXT,
@@ -381,6 +387,7 @@ func mustOpenActivityHandlerDB(t *testing.T) *sql.DB {
 
 		CREATE TABLE IF NOT EXISTS activity (
 			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			event_type           TEXT NOT NULL,
 			category             TEXT NOT NULL,
 			level                TEXT NOT NULL,
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/infra/s3"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+// backupTargetProbeTimeout bounds the bucket reachability check performed
+// before a target is saved.
+const backupTargetProbeTimeout = 10 * time.Second
+
+type backupsHandler struct {
+	store         *BackupStore
+	siteStore     *SiteStore
+	jobStore      *orchestrator.Store
+	activityStore *activity.Store
+}
+
+func (bh *backupsHandler) routeTargets(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/backup-targets" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		bh.handleListTargets(w, r)
+	case http.MethodPost:
+		bh.handleCreateTarget(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (bh *backupsHandler) routeTargetWithID(w http.ResponseWriter, r *http.Request) {
+	tail := strings.TrimPrefix(r.URL.Path, "/api/backup-targets/")
+	parts := strings.Split(strings.Trim(tail, "/"), "/")
+	if len(parts) != 1 || strings.TrimSpace(parts[0]) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	targetID, err := apitypes.ParseAppID(parts[0])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid backup target id")
+		return
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		bh.handleDeleteTarget(w, r, targetID)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (bh *backupsHandler) handleListTargets(w http.ResponseWriter, r *http.Request) {
+	targets, err := bh.store.ListTargets(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list backup targets: "+err.Error())
+		return
+	}
+	payload := make([]apitypes.BackupTarget, 0, len(targets))
+	for _, target := range targets {
+		payload = append(payload, apiBackupTarget(target))
+	}
+	respondJSON(w, http.StatusOK, payload)
+}
+
+func (bh *backupsHandler) handleCreateTarget(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.CreateBackupTargetRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	in := CreateBackupTargetInput{
+		Name:            req.Name,
+		Endpoint:        req.Endpoint,
+		Region:          req.Region,
+		Bucket:          req.Bucket,
+		PathPrefix:      req.PathPrefix,
+		PathStyle:       req.PathStyle,
+		AccessKeyID:     req.AccessKeyID,
+		SecretAccessKey: req.SecretAccessKey,
+		RetentionDays:   req.RetentionDays,
+	}
+	if err := probeBackupTarget(r.Context(), StoredBackupTarget{
+		Endpoint:        in.Endpoint,
+		Region:          in.Region,
+		Bucket:          in.Bucket,
+		PathStyle:       in.PathStyle,
+		AccessKeyID:     in.AccessKeyID,
+		SecretAccessKey: in.SecretAccessKey,
+	}); err != nil {
+		respondError(w, http.StatusBadRequest, "backup target is not reachable: "+err.Error())
+		return
+	}
+	id, err := bh.store.CreateTarget(r.Context(), in)
+	if err != nil {
+		respondBackupError(w, err, "failed to create backup target")
+		return
+	}
+	target, err := bh.store.GetTarget(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	bh.emitTargetActivity(r, activity.EventBackupTargetAdded, target, fmt.Sprintf("Backup target '%s' added", target.Name), fmt.Sprintf("Site backups can now be stored in bucket %s.", target.Bucket))
+	respondJSON(w, http.StatusCreated, apiBackupTarget(*target))
+}
+
+func (bh *backupsHandler) handleDeleteTarget(w http.ResponseWriter, r *http.Request, targetID string) {
+	target, err := bh.store.GetTarget(r.Context(), targetID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := bh.store.DeleteTarget(r.Context(), targetID); err != nil {
+		respondBackupError(w, err, "failed to delete backup target")
+		return
+	}
+	bh.emitTargetActivity(r, activity.EventBackupTargetRemoved, target, fmt.Sprintf("Backup target '%s' removed", target.Name), "The bucket is no longer used for site backups.")
+	respondJSON(w, http.StatusOK, apitypes.DeleteBackupTargetResponse{TargetID: apitypes.FormatAppID(target.ID), Deleted: true, Description: "Backup target deleted"})
+}
+
+func (bh *backupsHandler) handleListBySite(w http.ResponseWriter, r *http.Request, siteID string) {
+	if _, err := bh.siteStore.GetByID(r.Context(), siteID); err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	backups, err := bh.store.ListBySite(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list site backups: "+err.Error())
+		return
+	}
+	payload := make([]apitypes.SiteBackup, 0, len(backups))
+	for _, backup := range backups {
+		payload = append(payload, apiSiteBackup(backup))
+	}
+	respondJSON(w, http.StatusOK, payload)
+}
+
+func (bh *backupsHandler) handleCreateForSite(w http.ResponseWriter, r *http.Request, siteID string) {
+	var req apitypes.CreateSiteBackupRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	targetID, err := apitypes.ParseAppID(req.TargetID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid target_id")
+		return
+	}
+	site, err := bh.siteStore.GetByID(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if site.DeploymentState != SiteDeploymentStateReady {
+		respondError(w, http.StatusConflict, "site must be deployed before it can be backed up")
+		return
+	}
+	if _, err := bh.store.GetTarget(r.Context(), targetID); err != nil {
+		respondBackupError(w, err, "failed to read backup target")
+		return
+	}
+	payload, err := orchestrator.MarshalBackupSitePayload(orchestrator.BackupSitePayload{
+		SiteID:        site.ID,
+		TargetID:      targetID,
+		RetentionDays: req.RetentionDays,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue site backup: marshal payload")
+		return
+	}
+	job, err := bh.jobStore.CreateJob(r.Context(), orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindBackupSite),
+		ServerID: site.ServerID,
+		Payload:  payload,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue site backup: "+err.Error())
+		return
+	}
+	_, _ = bh.jobStore.AppendEvent(r.Context(), job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   "Site backup accepted and queued",
+	})
+	respondJSON(w, http.StatusAccepted, apitypes.CreateSiteBackupResponse{
+		SiteID:    apitypes.FormatAppID(site.ID),
+		JobID:     apitypes.FormatAppID(job.ID),
+		JobStatus: job.Status,
+	})
+}
+
+// handleRestoreForSite queues a restore of a completed backup onto the site.
+// The job runs against the target site's server, so a backup taken elsewhere
+// can be restored here to migrate or clone a site.
+func (bh *backupsHandler) handleRestoreForSite(w http.ResponseWriter, r *http.Request, siteID string) {
+	var req apitypes.CreateSiteRestoreRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	backupID, err := apitypes.ParseAppID(req.BackupID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid backup_id")
+		return
+	}
+	site, err := bh.siteStore.GetByID(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if site.DeploymentState != SiteDeploymentStateReady {
+		respondError(w, http.StatusConflict, "site must be deployed before a backup can be restored onto it")
+		return
+	}
+	backup, err := bh.store.GetBackup(r.Context(), backupID)
+	if err != nil {
+		respondBackupError(w, err, "failed to read backup")
+		return
+	}
+	if backup.Status != SiteBackupStatusCompleted {
+		respondError(w, http.StatusConflict, fmt.Sprintf("backup is %s, only completed backups can be restored", backup.Status))
+		return
+	}
+	payload, err := orchestrator.MarshalRestoreSitePayload(orchestrator.RestoreSitePayload{
+		SiteID:   site.ID,
+		BackupID: backup.ID,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue site restore: marshal payload")
+		return
+	}
+	job, err := bh.jobStore.CreateJob(r.Context(), orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindRestoreSite),
+		ServerID: site.ServerID,
+		Payload:  payload,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue site restore: "+err.Error())
+		return
+	}
+	_, _ = bh.jobStore.AppendEvent(r.Context(), job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   "Site restore accepted and queued",
+	})
+	respondJSON(w, http.StatusAccepted, apitypes.CreateSiteRestoreResponse{
+		SiteID:    apitypes.FormatAppID(site.ID),
+		BackupID:  apitypes.FormatAppID(backup.ID),
+		JobID:     apitypes.FormatAppID(job.ID),
+		JobStatus: job.Status,
+	})
+}
+
+func (bh *backupsHandler) emitTargetActivity(r *http.Request, eventType activity.EventType, target *StoredBackupTarget, title, message string) {
+	if bh.activityStore == nil || target == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	_, _ = bh.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategoryBackup,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceBackupTarget,
+		ResourceID:   target.ID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        title,
+		Message:      message,
+	})
+}
+
+// probeBackupTarget checks that the bucket exists and the credentials are
+// accepted so a typo is reported when the target is saved rather than on the
+// first scheduled backup.
+func probeBackupTarget(ctx context.Context, target StoredBackupTarget) error {
+	client, err := s3.NewClient(target.S3Config(), nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, backupTargetProbeTimeout)
+	defer cancel()
+	return client.HeadBucket(ctx)
+}
+
+func apiBackupTarget(in StoredBackupTarget) apitypes.BackupTarget {
+	return apitypes.BackupTarget{
+		ID:            apitypes.FormatAppID(in.ID),
+		Name:          in.Name,
+		Endpoint:      in.Endpoint,
+		Region:        in.Region,
+		Bucket:        in.Bucket,
+		PathPrefix:    in.PathPrefix,
+		PathStyle:     in.PathStyle,
+		AccessKeyID:   in.AccessKeyID,
+		RetentionDays: in.RetentionDays,
+		CreatedAt:     in.CreatedAt,
+		UpdatedAt:     in.UpdatedAt,
+	}
+}
+
+func apiSiteBackup(in StoredSiteBackup) apitypes.SiteBackup {
+	return apitypes.SiteBackup{
+		ID:             apitypes.FormatAppID(in.ID),
+		SiteID:         apitypes.FormatAppID(in.SiteID),
+		ServerID:       apitypes.FormatAppID(in.ServerID),
+		TargetID:       apitypes.FormatAppID(in.TargetID),
+		TargetName:     in.TargetName,
+		JobID:          apitypes.FormatAppID(in.JobID),
+		Status:         in.Status,
+		ObjectKey:      in.ObjectKey,
+		SizeBytes:      in.SizeBytes,
+		ChecksumSHA256: in.ChecksumSHA256,
+		RetentionDays:  in.RetentionDays,
+		ExpiresAt:      in.ExpiresAt,
+		LastError:      in.LastError,
+		StartedAt:      in.StartedAt,
+		CompletedAt:    in.CompletedAt,
+		CreatedAt:      in.CreatedAt,
+		UpdatedAt:      in.UpdatedAt,
+	}
+}
+
+func respondBackupError(w http.ResponseWriter, err error, prefix string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "still holds"):
+		respondError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "must"), strings.Contains(err.Error(), "invalid"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, prefix+": "+err.Error())
+	}
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/infra/s3/s3test"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+func TestBackupTargetsCreateListDeleteEndpoints(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	bucket := s3test.NewServer("agency-backups")
+	defer bucket.Close()
+	db := mustOpenServerHandlerDB(t)
+	handler := NewHandler(db)
+
+	missingRes := postBackupJSON(t, handler, "/api/backup-targets", map[string]any{
+		"name": "Missing", "endpoint": bucket.URL, "bucket": "no-such-bucket", "path_style": true,
+		"access_key_id": s3test.DefaultAccessKeyID, "secret_access_key": s3test.DefaultSecretAccessKey,
+	})
+	if missingRes.Code != http.StatusBadRequest || !strings.Contains(missingRes.Body.String(), "not reachable") {
+		t.Fatalf("missing bucket status = %d, body = %s; want unreachable 400", missingRes.Code, missingRes.Body.String())
+	}
+
+	createRes := postBackupJSON(t, handler, "/api/backup-targets", map[string]any{
+		"name": "Offsite", "endpoint": bucket.URL, "bucket": "agency-backups", "path_style": true,
+		"access_key_id": s3test.DefaultAccessKeyID, "secret_access_key": s3test.DefaultSecretAccessKey, "retention_days": 14,
+	})
+	if createRes.Code != http.StatusCreated {
+		t.Fatalf("create target status = %d, want %d; body = %s", createRes.Code, http.StatusCreated, createRes.Body.String())
+	}
+	if strings.Contains(createRes.Body.String(), s3test.DefaultSecretAccessKey) {
+		t.Fatal("create response leaked secret access key")
+	}
+	var created map[string]any
+	if err := json.Unmarshal(createRes.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode target: %v", err)
+	}
+	targetID, _ := created["id"].(string)
+
+	listRes := httptest.NewRecorder()
+	handler.ServeHTTP(listRes, httptest.NewRequest(http.MethodGet, "/api/backup-targets", nil))
+	if listRes.Code != http.StatusOK || !strings.Contains(listRes.Body.String(), targetID) {
+		t.Fatalf("list targets status = %d, body = %s", listRes.Code, listRes.Body.String())
+	}
+
+	activities, _, err := activity.NewStore(db).List(context.Background(), activity.ListFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(activities) != 1 || activities[0].EventType != activity.EventBackupTargetAdded {
+		t.Fatalf("activities = %+v, want backup.target_added", activities)
+	}
+
+	deleteRes := httptest.NewRecorder()
+	handler.ServeHTTP(deleteRes, httptest.NewRequest(http.MethodDelete, "/api/backup-targets/"+targetID, nil))
+	if deleteRes.Code != http.StatusOK {
+		t.Fatalf("delete target status = %d, want %d; body = %s", deleteRes.Code, http.StatusOK, deleteRes.Body.String())
+	}
+}
+
+func TestSiteBackupsQueueJobForDeployedSite(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	handler := NewHandler(db)
+
+	siteStore := NewSiteStore(db)
+	siteID, err := siteStore.Create(context.Background(), CreateSiteInput{ServerID: serverID, Name: "Agency Site", WordPressAdminEmail: "owner@example.test", Status: SiteStatusDraft})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	targetID, err := NewBackupStore(db).CreateTarget(context.Background(), CreateBackupTargetInput{
+		Name: "Offsite", Endpoint: "https://s3.example.test", Bucket: "agency-backups", AccessKeyID: "key", SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+
+	undeployedRes := postBackupJSON(t, handler, "/api/sites/"+siteID+"/backups", map[string]any{"target_id": targetID})
+	if undeployedRes.Code != http.StatusConflict {
+		t.Fatalf("undeployed backup status = %d, want %d; body = %s", undeployedRes.Code, http.StatusConflict, undeployedRes.Body.String())
+	}
+
+	if err := siteStore.UpdateDeployment(context.Background(), siteID, SiteDeploymentStateReady, "Site is live.", "", "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("mark site deployed: %v", err)
+	}
+	queueRes := postBackupJSON(t, handler, "/api/sites/"+siteID+"/backups", map[string]any{"target_id": targetID, "retention_days": 7})
+	if queueRes.Code != http.StatusAccepted {
+		t.Fatalf("queue backup status = %d, want %d; body = %s", queueRes.Code, http.StatusAccepted, queueRes.Body.String())
+	}
+	var queued map[string]any
+	if err := json.Unmarshal(queueRes.Body.Bytes(), &queued); err != nil {
+		t.Fatalf("decode queue response: %v", err)
+	}
+	jobID, _ := queued["job_id"].(string)
+	job, err := orchestrator.NewStore(db).GetJob(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	payload, err := orchestrator.UnmarshalBackupSitePayload(job.Payload)
+	if err != nil {
+		t.Fatalf("decode job payload: %v", err)
+	}
+	if job.Kind != string(orchestrator.JobKindBackupSite) || job.ServerID != serverID || payload.SiteID != siteID || payload.TargetID != targetID || payload.RetentionDays != 7 {
+		t.Fatalf("job = %+v, payload = %+v", job, payload)
+	}
+
+	listRes := httptest.NewRecorder()
+	handler.ServeHTTP(listRes, httptest.NewRequest(http.MethodGet, "/api/sites/"+siteID+"/backups", nil))
+	if listRes.Code != http.StatusOK || strings.TrimSpace(listRes.Body.String()) != "[]" {
+		t.Fatalf("list backups status = %d, body = %s; want empty list until the job runs", listRes.Code, listRes.Body.String())
+	}
+}
+
+func TestSiteRestoreQueuesJobForCompletedBackup(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	handler := NewHandler(db)
+
+	ctx := context.Background()
+	siteStore := NewSiteStore(db)
+	sourceID, err := siteStore.Create(ctx, CreateSiteInput{ServerID: serverID, Name: "Agency Site", WordPressAdminEmail: "owner@example.test", Status: SiteStatusDraft})
+	if err != nil {
+		t.Fatalf("create source site: %v", err)
+	}
+	cloneID, err := siteStore.Create(ctx, CreateSiteInput{ServerID: serverID, Name: "Agency Clone", WordPressAdminEmail: "owner@example.test", Status: SiteStatusDraft})
+	if err != nil {
+		t.Fatalf("create clone site: %v", err)
+	}
+	if err := siteStore.UpdateDeployment(ctx, cloneID, SiteDeploymentStateReady, "Site is live.", "", "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("mark clone deployed: %v", err)
+	}
+	backupStore := NewBackupStore(db)
+	targetID, err := backupStore.CreateTarget(ctx, CreateBackupTargetInput{
+		Name: "Offsite", Endpoint: "https://s3.example.test", Bucket: "agency-backups", AccessKeyID: "key", SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+	backupID, err := backupStore.CreateBackup(ctx, CreateSiteBackupInput{SiteID: sourceID, ServerID: serverID, TargetID: targetID})
+	if err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+
+	pendingRes := postBackupJSON(t, handler, "/api/sites/"+cloneID+"/restore", map[string]any{"backup_id": backupID})
+	if pendingRes.Code != http.StatusConflict {
+		t.Fatalf("pending backup restore status = %d, want %d; body = %s", pendingRes.Code, http.StatusConflict, pendingRes.Body.String())
+	}
+
+	if err := backupStore.Complete(ctx, backupID, 2048, strings.Repeat("a", 64)); err != nil {
+		t.Fatalf("complete backup: %v", err)
+	}
+	queueRes := postBackupJSON(t, handler, "/api/sites/"+cloneID+"/restore", map[string]any{"backup_id": backupID})
+	if queueRes.Code != http.StatusAccepted {
+		t.Fatalf("queue restore status = %d, want %d; body = %s", queueRes.Code, http.StatusAccepted, queueRes.Body.String())
+	}
+	var queued map[string]any
+	if err := json.Unmarshal(queueRes.Body.Bytes(), &queued); err != nil {
+		t.Fatalf("decode queue response: %v", err)
+	}
+	jobID, _ := queued["job_id"].(string)
+	job, err := orchestrator.NewStore(db).GetJob(ctx, jobID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	payload, err := orchestrator.UnmarshalRestoreSitePayload(job.Payload)
+	if err != nil {
+		t.Fatalf("decode job payload: %v", err)
+	}
+	if job.Kind != string(orchestrator.JobKindRestoreSite) || job.ServerID != serverID || payload.SiteID != cloneID || payload.BackupID != backupID {
+		t.Fatalf("job = %+v, payload = %+v", job, payload)
+	}
+}
+
+func postBackupJSON(t *testing.T, handler http.Handler, path string, body map[string]any) *httptest.ResponseRecorder {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal body: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/shared/ws"
+
+	"github.com/google/uuid"
+)
+
+func (sh *sitesHandler) handleListComponents(w http.ResponseWriter, r *http.Request, siteID string) {
+	if _, err := sh.store.GetByID(r.Context(), siteID); err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	components, err := sh.componentStore.ListBySite(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list site components: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, apiSiteComponents(siteID, components))
+}
+
+// handleRefreshComponents asks the site's agent for a fresh inventory and
+// stores it before responding.
+func (sh *sitesHandler) handleRefreshComponents(w http.ResponseWriter, r *http.Request, siteID string) {
+	site, err := sh.store.GetByID(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if site.DeploymentState != SiteDeploymentStateReady {
+		respondError(w, http.StatusConflict, "site must be deployed before its components can be listed")
+		return
+	}
+	if sh.hub == nil || !sh.hub.GetAgentInfo(site.ServerID).Connected {
+		respondError(w, http.StatusConflict, "server agent is not connected")
+		return
+	}
+	timeout := agentcommand.Timeout(agentcommand.TypeWPInventory)
+	if timeout <= 0 {
+		timeout = 90 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	payload, err := json.Marshal(agentcommand.WPInventoryParams{
+		SiteID:   site.ID,
+		SitePath: siteWordPressRootPath(*site),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to build inventory request")
+		return
+	}
+	result, err := sh.hub.SendCommandAndWait(ctx, site.ServerID, ws.Command{
+		ID:      uuid.NewString(),
+		Type:    agentcommand.TypeWPInventory,
+		Payload: payload,
+	})
+	if err != nil {
+		respondError(w, http.StatusBadGateway, "failed to fetch site inventory: "+err.Error())
+		return
+	}
+	if !result.Success {
+		respondError(w, http.StatusBadGateway, "failed to fetch site inventory: "+result.Error)
+		return
+	}
+	var inventory agentcommand.WPInventory
+	if err := json.Unmarshal(result.Payload, &inventory); err != nil {
+		respondError(w, http.StatusBadGateway, "invalid site inventory response")
+		return
+	}
+	if err := sh.recordInventory(r.Context(), site.ID, SiteComponentInputs(inventory.Components)); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to store site inventory: "+err.Error())
+		return
+	}
+	components, err := sh.componentStore.ListBySite(r.Context(), site.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list site components: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, apiSiteComponents(site.ID, components))
+}
+
+// recordInventory stores a site's inventory, rescanning it for known
+// vulnerabilities when a scanner is configured.
+func (sh *sitesHandler) recordInventory(ctx context.Context, siteID string, components []SiteComponentInput) error {
+	if sh.vulnScanner != nil {
+		return sh.vulnScanner.ReplaceForSite(ctx, siteID, components, time.Now())
+	}
+	return sh.componentStore.ReplaceForSite(ctx, siteID, components, time.Now())
+}
+
+func (sh *sitesHandler) handleCreateUpdate(w http.ResponseWriter, r *http.Request, siteID string) {
+	if sh.jobStore == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var req apitypes.CreateSiteUpdateRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	site, err := sh.store.GetByID(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if site.DeploymentState != SiteDeploymentStateReady {
+		respondError(w, http.StatusConflict, "site must be deployed before it can be updated")
+		return
+	}
+
+	raw, err := json.Marshal(orchestrator.UpdateSiteComponentsPayload{
+		SiteID:     site.ID,
+		Core:       req.Core,
+		Plugins:    req.Plugins,
+		Themes:     req.Themes,
+		CheckPaths: req.CheckPaths,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue update: marshal payload")
+		return
+	}
+	payload, err := orchestrator.ValidatePayload(string(orchestrator.JobKindUpdateSiteComponents), raw, site.ServerID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	job, err := sh.jobStore.CreateJob(r.Context(), orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindUpdateSiteComponents),
+		ServerID: site.ServerID,
+		Payload:  payload,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue update: "+err.Error())
+		return
+	}
+	_, _ = sh.jobStore.AppendEvent(r.Context(), job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   "Site update accepted and queued",
+	})
+
+	respondJSON(w, http.StatusAccepted, apitypes.CreateSiteUpdateResponse{
+		SiteID:    apitypes.FormatAppID(site.ID),
+		JobID:     apitypes.FormatAppID(job.ID),
+		JobStatus: job.Status,
+	})
+}
+
+// handleListOutdated serves GET /api/components/outdated.
+func (sh *sitesHandler) handleListOutdated(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	components, err := sh.componentStore.ListOutdated(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list outdated components: "+err.Error())
+		return
+	}
+	payload := make([]apitypes.SiteComponent, 0, len(components))
+	for _, component := range components {
+		payload = append(payload, apiSiteComponent(component))
+	}
+	respondJSON(w, http.StatusOK, payload)
+}
+
+func apiSiteComponents(siteID string, components []StoredSiteComponent) apitypes.SiteComponentsResponse {
+	response := apitypes.SiteComponentsResponse{
+		SiteID:     apitypes.FormatAppID(siteID),
+		Components: make([]apitypes.SiteComponent, 0, len(components)),
+	}
+	for _, component := range components {
+		response.CheckedAt = component.CheckedAt
+		response.Components = append(response.Components, apiSiteComponent(component))
+	}
+	return response
+}
+
+func apiSiteComponent(component StoredSiteComponent) apitypes.SiteComponent {
+	return apitypes.SiteComponent{
+		SiteID:        apitypes.FormatAppID(component.SiteID),
+		SiteName:      component.SiteName,
+		ServerID:      apitypes.FormatAppID(component.ServerID),
+		Type:          component.Type,
+		Name:          component.Name,
+		Title:         component.Title,
+		Status:        component.Status,
+		Version:       component.Version,
+		UpdateVersion: component.UpdateVersion,
+		CheckedAt:     component.CheckedAt,
+	}
+}
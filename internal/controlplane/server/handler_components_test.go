@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+func TestSiteComponentEndpointsListInventoryAndQueueUpdates(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	siteStore := NewSiteStore(db)
+	siteID, err := siteStore.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	if err := NewComponentStore(db).ReplaceForSite(context.Background(), siteID, []SiteComponentInput{
+		{Type: SiteComponentTypeCore, Name: "wordpress", Version: "6.7.2", UpdateVersion: "6.8"},
+		{Type: SiteComponentTypePlugin, Name: "akismet", Status: "active", Version: "5.3"},
+	}, time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("store inventory: %v", err)
+	}
+	handler := NewHandler(db)
+
+	listRes := httptest.NewRecorder()
+	handler.ServeHTTP(listRes, httptest.NewRequest(http.MethodGet, "/api/sites/"+siteID+"/components", nil))
+	var listed apitypes.SiteComponentsResponse
+	if err := json.Unmarshal(listRes.Body.Bytes(), &listed); err != nil || len(listed.Components) != 2 {
+		t.Fatalf("list components status = %d, body = %s", listRes.Code, listRes.Body.String())
+	}
+	if listed.CheckedAt != "2026-10-01T08:00:00Z" || listed.Components[0].Name != "wordpress" {
+		t.Fatalf("components = %+v, want core first with check time", listed)
+	}
+
+	outdatedRes := httptest.NewRecorder()
+	handler.ServeHTTP(outdatedRes, httptest.NewRequest(http.MethodGet, "/api/components/outdated", nil))
+	var outdated []apitypes.SiteComponent
+	if err := json.Unmarshal(outdatedRes.Body.Bytes(), &outdated); err != nil || len(outdated) != 1 {
+		t.Fatalf("outdated status = %d, body = %s", outdatedRes.Code, outdatedRes.Body.String())
+	}
+	if outdated[0].SiteName != "Shop" || outdated[0].UpdateVersion != "6.8" {
+		t.Fatalf("outdated = %+v, want the core update for Shop", outdated[0])
+	}
+
+	if res := postBackupJSON(t, handler, "/api/sites/"+siteID+"/updates", map[string]any{"core": true}); res.Code != http.StatusConflict {
+		t.Fatalf("update of undeployed site status = %d, want %d", res.Code, http.StatusConflict)
+	}
+	if err := siteStore.UpdateDeployment(context.Background(), siteID, SiteDeploymentStateReady, "live", "", "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("mark site deployed: %v", err)
+	}
+	if res := postBackupJSON(t, handler, "/api/sites/"+siteID+"/components/refresh", map[string]any{}); res.Code != http.StatusConflict {
+		t.Fatalf("refresh without agent status = %d, want %d", res.Code, http.StatusConflict)
+	}
+	if res := postBackupJSON(t, handler, "/api/sites/"+siteID+"/updates", map[string]any{"plugins": []string{"*", "akismet"}}); res.Code != http.StatusBadRequest {
+		t.Fatalf("mixed wildcard update status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+
+	res := postBackupJSON(t, handler, "/api/sites/"+siteID+"/updates", map[string]any{"core": true, "plugins": []string{"akismet"}})
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("update status = %d, want %d; body = %s", res.Code, http.StatusAccepted, res.Body.String())
+	}
+	var created apitypes.CreateSiteUpdateResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode update response: %v", err)
+	}
+	job, err := orchestrator.NewStore(db).GetJob(context.Background(), created.JobID)
+	if err != nil {
+		t.Fatalf("get update job: %v", err)
+	}
+	payload, err := orchestrator.UnmarshalUpdateSiteComponentsPayload(job.Payload)
+	if err != nil {
+		t.Fatalf("decode update payload: %v", err)
+	}
+	if job.Kind != string(orchestrator.JobKindUpdateSiteComponents) || payload.SiteID != siteID || !payload.Core || len(payload.CheckPaths) != 1 {
+		t.Fatalf("job = %s %+v, want core and plugin update with default check path", job.Kind, payload)
+	}
+}
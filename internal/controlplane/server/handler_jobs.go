@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -11,13 +12,65 @@ import (
 
 	"pressluft/internal/controlplane/activity"
 	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/infra/provider"
 	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/shared/ws"
 )
 
 type jobsHandler struct {
 	store         *orchestrator.Store
 	serverStore   *ServerStore
+	references    payloadReferences
 	activityStore *activity.Store
+	hub           *ws.Hub
+	canceller     JobCanceller
+}
+
+// JobCanceller stops a job the in-process worker is executing.
+type JobCanceller interface {
+	CancelJob(jobID string) bool
+}
+
+// WorkerSlotReporter exposes the job worker's concurrency slot usage.
+type WorkerSlotReporter interface {
+	SlotUsage() apitypes.WorkerSlotUsage
+}
+
+type workerSlotsHandler struct {
+	slots         WorkerSlotReporter
+	serverStore   *ServerStore
+	providerStore *provider.Store
+}
+
+// handleSlots serves GET /api/worker/slots. The worker runs jobs for every
+// workspace, so the per-server and per-provider counts are limited to the
+// ones the caller's workspace owns.
+func (wh *workerSlotsHandler) handleSlots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	usage := wh.slots.SlotUsage()
+	servers := make(map[string]int, len(usage.Servers))
+	for id, n := range usage.Servers {
+		if _, err := wh.serverStore.GetByID(r.Context(), id); err == nil {
+			servers[id] = n
+		}
+	}
+	owned, err := wh.providerStore.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list providers")
+		return
+	}
+	providers := make(map[string]int, len(usage.Providers))
+	for _, p := range owned {
+		if n, ok := usage.Providers[p.ID]; ok {
+			providers[p.ID] = n
+		}
+	}
+	usage.Servers, usage.Providers = servers, providers
+	respondJSON(w, http.StatusOK, usage)
 }
 
 func (jh *jobsHandler) route(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +95,16 @@ func (jh *jobsHandler) handleList(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	actor := auth.ActorFromContext(r.Context())
+	if actor.Scoped {
+		visible := make([]orchestrator.Job, 0, len(jobs))
+		for _, job := range jobs {
+			if canAccessJob(actor, job) {
+				visible = append(visible, job)
+			}
+		}
+		jobs = visible
+	}
 	respondJSON(w, http.StatusOK, apitypes.APIJobs(jobs))
 }
 
@@ -58,6 +121,13 @@ func (jh *jobsHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "job id is required")
 		return
 	}
+	if actor := auth.ActorFromContext(r.Context()); actor.Scoped {
+		job, err := jh.store.GetJob(r.Context(), jobID)
+		if err != nil || !canAccessJob(actor, job) {
+			respondError(w, http.StatusNotFound, "job not found")
+			return
+		}
+	}
 
 	if len(parts) == 1 {
 		if r.Method != http.MethodGet {
@@ -77,6 +147,24 @@ func (jh *jobsHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 2 && parts[1] == "cancel" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		jh.handleCancel(w, r, jobID)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "attempts" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		jh.handleAttempts(w, r, jobID)
+		return
+	}
+
 	// /api/jobs/{id}/events/history - get all events as JSON (for completed jobs)
 	if len(parts) == 3 && parts[1] == "events" && parts[2] == "history" {
 		if r.Method != http.MethodGet {
@@ -100,11 +188,19 @@ func (jh *jobsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	slog.Default().Info("job action requested", "job_kind", req.Kind, "server_id", req.ServerID)
+	if actor := auth.ActorFromContext(r.Context()); actor.IsAuthenticated() && !auth.HasCapability(actor, jobKindCapability(req.Kind)) {
+		respondError(w, http.StatusForbidden, "forbidden")
+		return
+	}
 	payload, err := validateJobPayload(req)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := jh.references.check(r.Context(), payload); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	serverID := ""
 	if strings.TrimSpace(req.ServerID) != "" {
@@ -183,6 +279,90 @@ func (jh *jobsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
 	slog.Default().Info("job action queued", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID, "job_status", job.Status)
 }
 
+// handleCancel removes a queued job or stops a running one. Running worker
+// jobs have their context cancelled, which kills the runner's process group;
+// running agent jobs get a cancel request sent to the agent, whose late
+// result is then ignored. Restores, pushes and component updates cancelled
+// after changing the site still roll it back to their snapshot.
+func (jh *jobsHandler) handleCancel(w http.ResponseWriter, r *http.Request, jobID string) {
+	job, err := jh.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if orchestrator.IsTerminalStatus(job.Status) {
+		respondError(w, http.StatusConflict, fmt.Sprintf("job is already %s", job.Status))
+		return
+	}
+
+	started := job.Status == orchestrator.JobStatusRunning
+	if started {
+		jh.stopRunningJob(r, job)
+	}
+
+	actorType, actorID := activityActorFromRequest(r)
+	message := "Job cancelled by operator"
+	cancelled, changed, err := jh.store.CancelJob(r.Context(), job.ID, message)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to cancel job: "+err.Error())
+		return
+	}
+	if !changed {
+		respondError(w, http.StatusConflict, fmt.Sprintf("job is already %s", cancelled.Status))
+		return
+	}
+
+	if job.ServerID != "" && jh.serverStore != nil {
+		status, rolledBack, err := jh.serverStore.RollbackCancelledJob(r.Context(), job, started)
+		if err != nil {
+			slog.Default().Error("server status rollback after cancel failed", "job_id", job.ID, "server_id", job.ServerID, "error", err)
+		} else if rolledBack {
+			slog.Default().Info("server status rolled back after cancel", "job_id", job.ID, "server_id", job.ServerID, "server_status", status)
+		}
+	}
+
+	if jh.activityStore != nil {
+		input := activity.EmitInput{
+			EventType:    activity.EventJobCancelled,
+			Category:     activity.CategoryJob,
+			Level:        activity.LevelWarning,
+			ResourceType: activity.ResourceJob,
+			ResourceID:   job.ID,
+			ActorType:    actorType,
+			ActorID:      actorID,
+			Title:        fmt.Sprintf("%s cancelled", orchestrator.JobKindLabel(job.Kind)),
+			Message:      message,
+		}
+		if job.ServerID != "" {
+			input.ParentResourceType = activity.ResourceServer
+			input.ParentResourceID = job.ServerID
+		}
+		_, _ = jh.activityStore.Emit(r.Context(), input)
+	}
+
+	respondJSON(w, http.StatusOK, apitypes.APIJob(cancelled))
+	slog.Default().Info("job cancelled", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID, "was_running", started)
+}
+
+// stopRunningJob asks whichever side executes a running job to stop it.
+func (jh *jobsHandler) stopRunningJob(r *http.Request, job orchestrator.Job) {
+	if jh.canceller != nil && jh.canceller.CancelJob(job.ID) {
+		return
+	}
+	policy, ok := orchestrator.JobKindPolicy(job.Kind)
+	if !ok || policy.ExecutionPath != "agent" || job.CommandID == nil || jh.hub == nil {
+		return
+	}
+	if err := jh.hub.CancelCommand(r.Context(), job.ServerID, ws.CommandCancel{
+		CommandID: *job.CommandID,
+		JobID:     job.ID,
+	}); err != nil {
+		// The job is cancelled regardless; a result the agent sends later is
+		// ignored because the job is terminal by then.
+		slog.Default().Warn("agent command cancel failed", "job_id", job.ID, "server_id", job.ServerID, "command_id", *job.CommandID, "error", err)
+	}
+}
+
 // jobKindLabel returns a human-readable label for a job kind.
 func jobKindLabel(kind string) string {
 	return orchestrator.JobKindLabel(kind)
@@ -200,6 +380,56 @@ func validateJobPayload(req apitypes.CreateJobRequest) (string, error) {
 	return orchestrator.ValidatePayload(req.Kind, req.Payload, serverID)
 }
 
+// payloadReferences resolves the sites, backups and backup targets a job
+// payload names through the workspace-scoped stores. The worker runs jobs
+// unscoped, so this is what keeps a job from acting on another workspace's
+// records.
+type payloadReferences struct {
+	sites   *SiteStore
+	backups *BackupStore
+}
+
+func (p payloadReferences) check(ctx context.Context, payload string) error {
+	if strings.TrimSpace(payload) == "" {
+		return nil
+	}
+	var refs struct {
+		SiteID       string `json:"site_id"`
+		SourceSiteID string `json:"source_site_id"`
+		TargetSiteID string `json:"target_site_id"`
+		BackupID     string `json:"backup_id"`
+		TargetID     string `json:"target_id"`
+	}
+	// Payloads are validated by kind before this; one that is not an object
+	// names no records.
+	if err := json.Unmarshal([]byte(payload), &refs); err != nil {
+		return nil
+	}
+	for _, site := range []struct{ field, id string }{
+		{"site_id", refs.SiteID},
+		{"source_site_id", refs.SourceSiteID},
+		{"target_site_id", refs.TargetSiteID},
+	} {
+		if strings.TrimSpace(site.id) == "" {
+			continue
+		}
+		if _, err := p.sites.GetByID(ctx, site.id); err != nil {
+			return fmt.Errorf("%s does not reference a known site", site.field)
+		}
+	}
+	if strings.TrimSpace(refs.BackupID) != "" {
+		if _, err := p.backups.GetBackup(ctx, refs.BackupID); err != nil {
+			return fmt.Errorf("backup_id does not reference a known backup")
+		}
+	}
+	if strings.TrimSpace(refs.TargetID) != "" {
+		if _, err := p.backups.GetTarget(ctx, refs.TargetID); err != nil {
+			return fmt.Errorf("target_id does not reference a known backup target")
+		}
+	}
+	return nil
+}
+
 func (jh *jobsHandler) handleGet(w http.ResponseWriter, r *http.Request, jobID string) {
 	job, err := jh.store.GetJob(r.Context(), jobID)
 	if err != nil {
@@ -220,6 +450,18 @@ func (jh *jobsHandler) handleEventHistory(w http.ResponseWriter, r *http.Request
 	respondJSON(w, http.StatusOK, events)
 }
 
+// handleAttempts returns the job timeline grouped by attempt, so retried jobs
+// show what each attempt did and how it ended.
+func (jh *jobsHandler) handleAttempts(w http.ResponseWriter, r *http.Request, jobID string) {
+	events, err := jh.store.ListAllEvents(r.Context(), jobID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, orchestrator.GroupEventsByAttempt(events))
+}
+
 func (jh *jobsHandler) handleEventStream(w http.ResponseWriter, r *http.Request, jobID string) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"strings"
 	"testing"
 
+	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 
 	_ "modernc.org/sqlite"
@@ -315,6 +317,156 @@ func TestJobsCreateRejectsDisallowedRestartServiceName(t *testing.T) {
 	}
 }
 
+func TestJobsCancelQueuedJobRestoresServerStatus(t *testing.T) {
+	db := mustOpenJobsHandlerDB(t)
+	handler := NewHandler(db)
+	serverID := mustInsertJobServer(t, db, string(platform.ServerStatusFailed))
+	jobID := mustQueueRebuildJob(t, handler, serverID)
+	if status := jobServerStatus(t, db, serverID); status != platform.ServerStatusRebuilding {
+		t.Fatalf("queued server status = %q, want %q", status, platform.ServerStatusRebuilding)
+	}
+
+	res := postJobCancel(handler, jobID)
+	if res.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusOK, res.Body.String())
+	}
+	var cancelled struct {
+		Status     string `json:"status"`
+		FinishedAt string `json:"finished_at"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &cancelled); err != nil {
+		t.Fatalf("decode cancel response: %v", err)
+	}
+	if cancelled.Status != "cancelled" || cancelled.FinishedAt == "" {
+		t.Fatalf("cancelled job = %+v, want a finished cancelled job", cancelled)
+	}
+	// The rebuild never ran, so the server returns to the status it had.
+	if status := jobServerStatus(t, db, serverID); status != platform.ServerStatusFailed {
+		t.Fatalf("server status = %q, want %q", status, platform.ServerStatusFailed)
+	}
+
+	if again := postJobCancel(handler, jobID); again.Code != http.StatusConflict {
+		t.Fatalf("second cancel status = %d, want %d", again.Code, http.StatusConflict)
+	}
+}
+
+func TestJobsCancelRunningJobStopsWorker(t *testing.T) {
+	db := mustOpenJobsHandlerDB(t)
+	canceller := &recordingJobCanceller{}
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{JobCanceller: canceller})
+	serverID := mustInsertJobServer(t, db, string(platform.ServerStatusReady))
+	jobID := mustQueueRebuildJob(t, handler, serverID)
+	if _, err := orchestrator.NewStore(db).ClaimNextJob(context.Background()); err != nil {
+		t.Fatalf("claim job: %v", err)
+	}
+
+	res := postJobCancel(handler, jobID)
+	if res.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusOK, res.Body.String())
+	}
+	if len(canceller.cancelled) != 1 || canceller.cancelled[0] != jobID {
+		t.Fatalf("worker cancellations = %v, want [%s]", canceller.cancelled, jobID)
+	}
+	// A rebuild stopped mid-run may have left the machine half-changed.
+	if status := jobServerStatus(t, db, serverID); status != platform.ServerStatusFailed {
+		t.Fatalf("server status = %q, want %q", status, platform.ServerStatusFailed)
+	}
+	events, err := orchestrator.NewStore(db).ListAllEvents(context.Background(), jobID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if last := events[len(events)-1]; last.EventType != orchestrator.JobEventTypeCancelled {
+		t.Fatalf("last event = %+v, want the cancellation", last)
+	}
+}
+
+func TestJobsAttemptsGroupsRetriedTimeline(t *testing.T) {
+	db := mustOpenJobsHandlerDB(t)
+	handler := NewHandler(db)
+	store := orchestrator.NewStore(db)
+	serverID := mustInsertJobServer(t, db, string(platform.ServerStatusReady))
+	jobID := mustQueueRebuildJob(t, handler, serverID)
+	if _, err := store.ClaimNextJob(context.Background()); err != nil {
+		t.Fatalf("claim job: %v", err)
+	}
+	if _, _, err := store.RetryJob(context.Background(), jobID, "connection reset by peer", 0); err != nil {
+		t.Fatalf("retry job: %v", err)
+	}
+	if _, err := store.ClaimNextJob(context.Background()); err != nil {
+		t.Fatalf("claim retried job: %v", err)
+	}
+	if res := postJobCancel(handler, jobID); res.Code != http.StatusOK {
+		t.Fatalf("cancel status = %d, want %d; body = %s", res.Code, http.StatusOK, res.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/"+jobID+"/attempts", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusOK, res.Body.String())
+	}
+	var attempts []orchestrator.JobAttempt
+	if err := json.Unmarshal(res.Body.Bytes(), &attempts); err != nil {
+		t.Fatalf("decode attempts: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %+v, want 2", attempts)
+	}
+	if attempts[0].Attempt != 1 || attempts[0].Outcome != "retried" {
+		t.Fatalf("first attempt = %+v, want a retried attempt 1", attempts[0])
+	}
+	if attempts[1].Attempt != 2 || attempts[1].Outcome != "cancelled" {
+		t.Fatalf("second attempt = %+v, want a cancelled attempt 2", attempts[1])
+	}
+}
+
+type recordingJobCanceller struct {
+	cancelled []string
+}
+
+func (c *recordingJobCanceller) CancelJob(jobID string) bool {
+	c.cancelled = append(c.cancelled, jobID)
+	return true
+}
+
+func mustQueueRebuildJob(t *testing.T, handler http.Handler, serverID string) string {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{
+		"kind":      "rebuild_server",
+		"server_id": serverID,
+		"payload":   map[string]any{"server_image": "ubuntu-24.04"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/jobs", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("queue rebuild status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	return created.ID
+}
+
+func postJobCancel(handler http.Handler, jobID string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/api/jobs/"+jobID+"/cancel", nil))
+	return res
+}
+
+func jobServerStatus(t *testing.T, db *sql.DB, serverID string) platform.ServerStatus {
+	t.Helper()
+	var status string
+	if err := db.QueryRow(`SELECT status FROM servers WHERE id = ?`, serverID).Scan(&status); err != nil {
+		t.Fatalf("read server status: %v", err)
+	}
+	return platform.ServerStatus(status)
+}
+
 func mustOpenJobsHandlerDB(t *testing.T) *sql.DB {
 	t.Helper()
 
@@ -335,6 +487,7 @@ func mustOpenJobsHandlerDB(t *testing.T) *sql.DB {
 
 		CREATE TABLE servers (
 			id                 TEXT PRIMARY KEY,
+			workspace_id       TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			provider_id        TEXT,
 			provider_type      TEXT,
 			provider_server_id TEXT,
@@ -353,6 +506,9 @@ func mustOpenJobsHandlerDB(t *testing.T) *sql.DB {
 			node_status        TEXT DEFAULT 'unknown',
 			node_last_seen     TEXT,
 			node_version       TEXT,
+			ssh_port           INTEGER NOT NULL DEFAULT 22,
+			ssh_user           TEXT    NOT NULL DEFAULT 'root',
+			ssh_host_key_fingerprint TEXT,
 			created_at         TEXT    NOT NULL DEFAULT '2026-01-01T00:00:00Z',
 			updated_at         TEXT    NOT NULL DEFAULT '2026-01-01T00:00:00Z'
 		);
@@ -369,6 +525,7 @@ func mustOpenJobsHandlerDB(t *testing.T) *sql.DB {
 
 		CREATE TABLE jobs (
 			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id    TEXT,
 			kind         TEXT    NOT NULL,
 			status       TEXT    NOT NULL,
@@ -380,6 +537,8 @@ func mustOpenJobsHandlerDB(t *testing.T) *sql.DB {
 			finished_at  TEXT,
 			timeout_at   TEXT,
 			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
 			created_at   TEXT    NOT NULL,
 			updated_at   TEXT    NOT NULL,
 			FOREIGN KEY (server_id) REFERENCES servers(id)
@@ -389,6 +548,7 @@ func mustOpenJobsHandlerDB(t *testing.T) *sql.DB {
 			id         TEXT PRIMARY KEY,
 			job_id     TEXT    NOT NULL,
 			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
 			event_type TEXT    NOT NULL,
 			level      TEXT    NOT NULL,
 			step_key   TEXT,
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+)
+
+// costMonthLayout is the format of the month query parameter.
+const costMonthLayout = "2006-01"
+
+type reportsHandler struct {
+	costStore *CostStore
+}
+
+// handleCosts serves GET /api/reports/costs?month=YYYY-MM. The month
+// defaults to the running one; format=csv downloads one row per site, plus
+// one per server without sites, so the amounts add up to the month's total.
+func (rh *reportsHandler) handleCosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	now := time.Now().UTC()
+	month := now
+	if raw := strings.TrimSpace(r.URL.Query().Get("month")); raw != "" {
+		parsed, err := time.Parse(costMonthLayout, raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "month must be formatted YYYY-MM")
+			return
+		}
+		month = parsed
+	}
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "json" && format != "csv" {
+		respondError(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	report, err := rh.costStore.MonthlyReport(r.Context(), month, now)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to build cost report: "+err.Error())
+		return
+	}
+	if format == "csv" {
+		writeCostCSV(w, report)
+		return
+	}
+	respondJSON(w, http.StatusOK, apiCostReport(report, now))
+}
+
+func apiCostReport(report *CostReport, generatedAt time.Time) apitypes.CostReport {
+	out := apitypes.CostReport{
+		Month:       report.Month.Format(costMonthLayout),
+		GeneratedAt: generatedAt.Format(time.RFC3339),
+		Totals:      make([]apitypes.CostTotal, 0, len(report.Totals)),
+		Workspaces:  make([]apitypes.WorkspaceCost, 0, len(report.Workspaces)),
+		Servers:     make([]apitypes.ServerCost, 0, len(report.Servers)),
+		Sites:       make([]apitypes.SiteCost, 0, len(report.Sites)),
+	}
+	for _, total := range report.Totals {
+		out.Totals = append(out.Totals, apitypes.CostTotal{Currency: total.Currency, Amount: formatCents(total.AmountCents)})
+	}
+	for _, cost := range report.Workspaces {
+		out.Workspaces = append(out.Workspaces, apitypes.WorkspaceCost{
+			WorkspaceID:   apitypes.FormatAppID(cost.WorkspaceID),
+			WorkspaceName: cost.WorkspaceName,
+			Currency:      cost.Currency,
+			Servers:       cost.Servers,
+			Sites:         cost.Sites,
+			Amount:        formatCents(cost.AmountCents),
+		})
+	}
+	for _, cost := range report.Servers {
+		out.Servers = append(out.Servers, apitypes.ServerCost{
+			ServerID:      apitypes.FormatAppID(cost.ServerID),
+			ServerName:    cost.ServerName,
+			ProviderType:  cost.ProviderType,
+			ServerType:    cost.ServerType,
+			WorkspaceID:   apitypes.FormatAppID(cost.WorkspaceID),
+			WorkspaceName: cost.WorkspaceName,
+			Currency:      cost.Currency,
+			Hours:         cost.Hours,
+			Amount:        formatCents(cost.AmountCents),
+			Sites:         cost.Sites,
+		})
+	}
+	for _, cost := range report.Sites {
+		out.Sites = append(out.Sites, apitypes.SiteCost{
+			SiteID:        apitypes.FormatAppID(cost.SiteID),
+			SiteName:      cost.SiteName,
+			ServerID:      apitypes.FormatAppID(cost.ServerID),
+			ServerName:    cost.ServerName,
+			WorkspaceID:   apitypes.FormatAppID(cost.WorkspaceID),
+			WorkspaceName: cost.WorkspaceName,
+			Currency:      cost.Currency,
+			Amount:        formatCents(cost.AmountCents),
+		})
+	}
+	return out
+}
+
+func writeCostCSV(w http.ResponseWriter, report *CostReport) {
+	month := report.Month.Format(costMonthLayout)
+	sitesByServer := map[string][]SiteCost{}
+	for _, site := range report.Sites {
+		key := site.ServerID + "\x00" + site.Currency
+		sitesByServer[key] = append(sitesByServer[key], site)
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="pressluft-costs-%s.csv"`, month))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"month", "workspace_id", "workspace", "server_id", "server", "server_type", "hours", "site_id", "site", "currency", "amount"})
+	for _, server := range report.Servers {
+		row := []string{
+			month,
+			apitypes.FormatAppID(server.WorkspaceID),
+			server.WorkspaceName,
+			apitypes.FormatAppID(server.ServerID),
+			server.ServerName,
+			server.ServerType,
+			strconv.FormatInt(server.Hours, 10),
+		}
+		sites := sitesByServer[server.ServerID+"\x00"+server.Currency]
+		if len(sites) == 0 {
+			_ = out.Write(append(row, "", "", server.Currency, formatCents(server.AmountCents)))
+			continue
+		}
+		for _, site := range sites {
+			_ = out.Write(append(row[:len(row):len(row)], apitypes.FormatAppID(site.SiteID), site.SiteName, site.Currency, formatCents(site.AmountCents)))
+		}
+	}
+	out.Flush()
+}
+
+// formatCents formats an amount in cents with two decimals, e.g. "12.34".
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
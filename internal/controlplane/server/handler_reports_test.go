@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+)
+
+func TestCostReportEndpointServesJSONAndCSV(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	siteID, err := NewSiteStore(db).Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	if err := NewCostStore(db).RecordPrice(context.Background(), ServerPriceInput{
+		ServerID:      serverID,
+		ServerType:    "cx22",
+		Location:      "fsn1",
+		HourlyGross:   "0.0070",
+		MonthlyGross:  "4.5100",
+		Currency:      "EUR",
+		EffectiveFrom: time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("record price: %v", err)
+	}
+	handler := NewHandler(db)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/reports/costs?month=2026-03", nil))
+	var report apitypes.CostReport
+	if err := json.Unmarshal(res.Body.Bytes(), &report); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("cost report status = %d, body = %s", res.Code, res.Body.String())
+	}
+	if report.Month != "2026-03" || len(report.Totals) != 1 || report.Totals[0].Amount != "4.51" || report.Totals[0].Currency != "EUR" {
+		t.Fatalf("report = %+v, want the monthly cap", report)
+	}
+	if len(report.Servers) != 1 || report.Servers[0].ServerID != serverID || report.Servers[0].Hours != 744 {
+		t.Fatalf("servers = %+v", report.Servers)
+	}
+	if len(report.Sites) != 1 || report.Sites[0].SiteID != siteID || report.Sites[0].Amount != "4.51" {
+		t.Fatalf("sites = %+v", report.Sites)
+	}
+	if len(report.Workspaces) != 1 || report.Workspaces[0].WorkspaceName != "Default" {
+		t.Fatalf("workspaces = %+v", report.Workspaces)
+	}
+
+	csvRes := httptest.NewRecorder()
+	handler.ServeHTTP(csvRes, httptest.NewRequest(http.MethodGet, "/api/reports/costs?month=2026-03&format=csv", nil))
+	if got := csvRes.Header().Get("Content-Disposition"); !strings.Contains(got, "pressluft-costs-2026-03.csv") {
+		t.Fatalf("Content-Disposition = %q", got)
+	}
+	rows, err := csv.NewReader(csvRes.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 2 || rows[0][0] != "month" || rows[1][4] != "agency-prod-01" || rows[1][8] != "Shop" || rows[1][10] != "4.51" {
+		t.Fatalf("csv rows = %q", rows)
+	}
+
+	for _, query := range []string{"month=March", "format=xml"} {
+		bad := httptest.NewRecorder()
+		handler.ServeHTTP(bad, httptest.NewRequest(http.MethodGet, "/api/reports/costs?"+query, nil))
+		if bad.Code != http.StatusBadRequest {
+			t.Fatalf("%s status = %d, want 400", query, bad.Code)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+type schedulesHandler struct {
+	store         *ScheduleStore
+	serverStore   *ServerStore
+	references    payloadReferences
+	activityStore *activity.Store
+}
+
+func (sh *schedulesHandler) route(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/schedules" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		sh.handleList(w, r)
+	case http.MethodPost:
+		sh.handleCreate(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (sh *schedulesHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
+	tail := strings.TrimPrefix(r.URL.Path, "/api/schedules/")
+	parts := strings.Split(strings.Trim(tail, "/"), "/")
+	if len(parts) == 0 || strings.TrimSpace(parts[0]) == "" || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	scheduleID, err := apitypes.ParseAppID(parts[0])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid schedule id")
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			sh.handleGet(w, r, scheduleID)
+		case http.MethodPatch:
+			sh.handleUpdate(w, r, scheduleID)
+		case http.MethodDelete:
+			sh.handleDelete(w, r, scheduleID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch parts[1] {
+	case "pause", "resume":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.handleSetPaused(w, r, scheduleID, parts[1] == "pause")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (sh *schedulesHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	schedules, err := sh.store.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list schedules: "+err.Error())
+		return
+	}
+	payload := make([]apitypes.Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		payload = append(payload, apiSchedule(schedule))
+	}
+	respondJSON(w, http.StatusOK, payload)
+}
+
+func (sh *schedulesHandler) handleGet(w http.ResponseWriter, r *http.Request, scheduleID string) {
+	schedule, err := sh.store.Get(r.Context(), scheduleID)
+	if err != nil {
+		respondScheduleError(w, err, "failed to read schedule")
+		return
+	}
+	respondJSON(w, http.StatusOK, apiSchedule(*schedule))
+}
+
+func (sh *schedulesHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.CreateScheduleRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	serverID := ""
+	if req.ServerID != "" {
+		parsedID, err := apitypes.ParseAppID(req.ServerID)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "server_id must be a valid app id")
+			return
+		}
+		if _, err := sh.serverStore.GetByID(r.Context(), parsedID); err != nil {
+			respondError(w, http.StatusBadRequest, "server_id does not reference a known server")
+			return
+		}
+		serverID = parsedID
+	}
+	payload, err := orchestrator.ValidatePayload(req.JobKind, req.Payload, serverID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := sh.references.check(r.Context(), payload); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := sh.store.Create(r.Context(), CreateScheduleInput{
+		Name:            req.Name,
+		JobKind:         req.JobKind,
+		ServerID:        serverID,
+		Payload:         payload,
+		CronExpression:  req.CronExpression,
+		Timezone:        req.Timezone,
+		JitterSeconds:   req.JitterSeconds,
+		MissedRunPolicy: req.MissedRunPolicy,
+		Paused:          req.Paused,
+	})
+	if err != nil {
+		respondScheduleError(w, err, "failed to create schedule")
+		return
+	}
+	schedule, err := sh.store.Get(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sh.emitActivity(r, activity.EventScheduleCreated, schedule,
+		fmt.Sprintf("Schedule '%s' created", schedule.Name),
+		fmt.Sprintf("%s runs on '%s' (%s).", orchestrator.JobKindLabel(schedule.JobKind), schedule.CronExpression, schedule.Timezone))
+	respondJSON(w, http.StatusCreated, apiSchedule(*schedule))
+}
+
+func (sh *schedulesHandler) handleUpdate(w http.ResponseWriter, r *http.Request, scheduleID string) {
+	var req apitypes.UpdateScheduleRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	current, err := sh.store.Get(r.Context(), scheduleID)
+	if err != nil {
+		respondScheduleError(w, err, "failed to read schedule")
+		return
+	}
+
+	in := UpdateScheduleInput{
+		Name:            req.Name,
+		CronExpression:  req.CronExpression,
+		Timezone:        req.Timezone,
+		JitterSeconds:   req.JitterSeconds,
+		MissedRunPolicy: req.MissedRunPolicy,
+	}
+	if req.Payload != nil {
+		payload, err := orchestrator.ValidatePayload(current.JobKind, req.Payload, current.ServerID)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := sh.references.check(r.Context(), payload); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		in.Payload = &payload
+	}
+	if err := sh.store.Update(r.Context(), scheduleID, in); err != nil {
+		respondScheduleError(w, err, "failed to update schedule")
+		return
+	}
+	schedule, err := sh.store.Get(r.Context(), scheduleID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sh.emitActivity(r, activity.EventScheduleUpdated, schedule, fmt.Sprintf("Schedule '%s' updated", schedule.Name), "")
+	respondJSON(w, http.StatusOK, apiSchedule(*schedule))
+}
+
+func (sh *schedulesHandler) handleSetPaused(w http.ResponseWriter, r *http.Request, scheduleID string, paused bool) {
+	if err := sh.store.SetPaused(r.Context(), scheduleID, paused); err != nil {
+		respondScheduleError(w, err, "failed to update schedule")
+		return
+	}
+	schedule, err := sh.store.Get(r.Context(), scheduleID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if paused {
+		sh.emitActivity(r, activity.EventSchedulePaused, schedule, fmt.Sprintf("Schedule '%s' paused", schedule.Name), "No jobs will be queued until the schedule is resumed.")
+	} else {
+		sh.emitActivity(r, activity.EventScheduleResumed, schedule, fmt.Sprintf("Schedule '%s' resumed", schedule.Name), fmt.Sprintf("Next run at %s.", schedule.NextRunAt))
+	}
+	respondJSON(w, http.StatusOK, apiSchedule(*schedule))
+}
+
+func (sh *schedulesHandler) handleDelete(w http.ResponseWriter, r *http.Request, scheduleID string) {
+	schedule, err := sh.store.Get(r.Context(), scheduleID)
+	if err != nil {
+		respondScheduleError(w, err, "failed to read schedule")
+		return
+	}
+	if err := sh.store.Delete(r.Context(), scheduleID); err != nil {
+		respondScheduleError(w, err, "failed to delete schedule")
+		return
+	}
+	sh.emitActivity(r, activity.EventScheduleDeleted, schedule, fmt.Sprintf("Schedule '%s' deleted", schedule.Name), "Jobs already queued by this schedule are not affected.")
+	respondJSON(w, http.StatusOK, apitypes.DeleteScheduleResponse{ScheduleID: apitypes.FormatAppID(schedule.ID), Deleted: true, Description: "Schedule deleted"})
+}
+
+func (sh *schedulesHandler) emitActivity(r *http.Request, eventType activity.EventType, schedule *StoredSchedule, title, message string) {
+	if sh.activityStore == nil || schedule == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	input := activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategorySchedule,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceSchedule,
+		ResourceID:   schedule.ID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        title,
+		Message:      message,
+	}
+	if schedule.ServerID != "" {
+		input.ParentResourceType = activity.ResourceServer
+		input.ParentResourceID = schedule.ServerID
+	}
+	_, _ = sh.activityStore.Emit(r.Context(), input)
+}
+
+func apiSchedule(in StoredSchedule) apitypes.Schedule {
+	return apitypes.Schedule{
+		ID:              apitypes.FormatAppID(in.ID),
+		Name:            in.Name,
+		JobKind:         in.JobKind,
+		ServerID:        apitypes.FormatAppID(in.ServerID),
+		Payload:         in.Payload,
+		CronExpression:  in.CronExpression,
+		Timezone:        in.Timezone,
+		JitterSeconds:   in.JitterSeconds,
+		MissedRunPolicy: in.MissedRunPolicy,
+		Paused:          in.Paused,
+		NextRunAt:       in.NextRunAt,
+		LastRunAt:       in.LastRunAt,
+		LastJobID:       apitypes.FormatAppID(in.LastJobID),
+		LastError:       in.LastError,
+		CreatedAt:       in.CreatedAt,
+		UpdatedAt:       in.UpdatedAt,
+	}
+}
+
+func respondScheduleError(w http.ResponseWriter, err error, prefix string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "must"), strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "unsupported"), strings.Contains(err.Error(), "cannot"), strings.Contains(err.Error(), "never fires"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, prefix+": "+err.Error())
+	}
+}
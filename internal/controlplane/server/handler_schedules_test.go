@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+)
+
+func TestSchedulesCRUDAndPauseResumeEndpoints(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	handler := NewHandler(db)
+
+	targetID, err := NewBackupStore(db).CreateTarget(context.Background(), CreateBackupTargetInput{
+		Name: "Offsite", Endpoint: "https://s3.example.test", Bucket: "backups", AccessKeyID: "key", SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("create backup target: %v", err)
+	}
+	payload := map[string]any{
+		"site_id":   mustCreateAuthorizationTestSite(t, db, serverID, "Scheduled Site"),
+		"target_id": targetID,
+	}
+	badCronRes := postBackupJSON(t, handler, "/api/schedules", map[string]any{
+		"name": "Nightly backup", "job_kind": "backup_site", "server_id": serverID, "payload": payload, "cron_expression": "0 25 * * *",
+	})
+	if badCronRes.Code != http.StatusBadRequest || !strings.Contains(badCronRes.Body.String(), "cron_expression") {
+		t.Fatalf("bad cron status = %d, body = %s; want 400", badCronRes.Code, badCronRes.Body.String())
+	}
+	badPayloadRes := postBackupJSON(t, handler, "/api/schedules", map[string]any{
+		"name": "Nightly backup", "job_kind": "backup_site", "server_id": serverID, "payload": map[string]any{}, "cron_expression": "0 3 * * *",
+	})
+	if badPayloadRes.Code != http.StatusBadRequest {
+		t.Fatalf("bad payload status = %d, body = %s; want 400", badPayloadRes.Code, badPayloadRes.Body.String())
+	}
+
+	createRes := postBackupJSON(t, handler, "/api/schedules", map[string]any{
+		"name": "Nightly backup", "job_kind": "backup_site", "server_id": serverID, "payload": payload,
+		"cron_expression": "0 3 * * *", "timezone": "UTC", "jitter_seconds": 300, "missed_run_policy": "catch_up",
+	})
+	if createRes.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d; body = %s", createRes.Code, http.StatusCreated, createRes.Body.String())
+	}
+	var created apitypes.Schedule
+	if err := json.Unmarshal(createRes.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode schedule: %v", err)
+	}
+	if created.ServerID != serverID || created.MissedRunPolicy != "catch_up" || created.NextRunAt == "" || created.Paused {
+		t.Fatalf("created = %+v", created)
+	}
+
+	listRes := httptest.NewRecorder()
+	handler.ServeHTTP(listRes, httptest.NewRequest(http.MethodGet, "/api/schedules", nil))
+	if listRes.Code != http.StatusOK || !strings.Contains(listRes.Body.String(), created.ID) {
+		t.Fatalf("list status = %d, body = %s", listRes.Code, listRes.Body.String())
+	}
+
+	pauseRes := postBackupJSON(t, handler, "/api/schedules/"+created.ID+"/pause", map[string]any{})
+	if pauseRes.Code != http.StatusOK || !strings.Contains(pauseRes.Body.String(), `"paused":true`) {
+		t.Fatalf("pause status = %d, body = %s", pauseRes.Code, pauseRes.Body.String())
+	}
+	resumeRes := postBackupJSON(t, handler, "/api/schedules/"+created.ID+"/resume", map[string]any{})
+	if resumeRes.Code != http.StatusOK || !strings.Contains(resumeRes.Body.String(), `"paused":false`) {
+		t.Fatalf("resume status = %d, body = %s", resumeRes.Code, resumeRes.Body.String())
+	}
+
+	patchReq := httptest.NewRequest(http.MethodPatch, "/api/schedules/"+created.ID, bytes.NewReader([]byte(`{"cron_expression":"0 4 * * 0","jitter_seconds":0}`)))
+	patchReq.Header.Set("Content-Type", "application/json")
+	patchRes := httptest.NewRecorder()
+	handler.ServeHTTP(patchRes, patchReq)
+	if patchRes.Code != http.StatusOK {
+		t.Fatalf("patch status = %d, body = %s", patchRes.Code, patchRes.Body.String())
+	}
+	var updated apitypes.Schedule
+	if err := json.Unmarshal(patchRes.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("decode updated schedule: %v", err)
+	}
+	if updated.CronExpression != "0 4 * * 0" || !strings.HasSuffix(updated.NextRunAt, "T04:00:00Z") {
+		t.Fatalf("updated = %+v, want Sunday 04:00", updated)
+	}
+
+	deleteRes := httptest.NewRecorder()
+	handler.ServeHTTP(deleteRes, httptest.NewRequest(http.MethodDelete, "/api/schedules/"+created.ID, nil))
+	if deleteRes.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body = %s", deleteRes.Code, deleteRes.Body.String())
+	}
+	getRes := httptest.NewRecorder()
+	handler.ServeHTTP(getRes, httptest.NewRequest(http.MethodGet, "/api/schedules/"+created.ID, nil))
+	if getRes.Code != http.StatusNotFound {
+		t.Fatalf("get after delete status = %d, want %d", getRes.Code, http.StatusNotFound)
+	}
+
+	activities, _, err := activity.NewStore(db).List(context.Background(), activity.ListFilter{Category: activity.CategorySchedule, Limit: 10})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(activities) != 5 {
+		t.Fatalf("schedule activities = %d, want created, paused, resumed, updated, deleted", len(activities))
+	}
+}
@@ -134,6 +134,20 @@ func (sh *serversHandler) routeWithPath(w http.ResponseWriter, r *http.Request)
 				}
 				sh.handleListSites(w, r, serverID)
 				return
+			case "connect":
+				if r.Method != http.MethodPost {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				sh.handleConnect(w, r, serverID)
+				return
+			case "ssh-key":
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				sh.handleSSHKey(w, r, serverID)
+				return
 			}
 		}
 
@@ -290,6 +304,14 @@ func (sh *serversHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if provider.AdoptsExistingServers(storedProvider.Type) {
+		sh.handleCreateAdopted(w, r, req, profile, storedProvider)
+		return
+	}
+	if err := validateProvisionedServerFields(req); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if _, ok := provider.GetServerProvider(storedProvider.Type); !ok {
 		respondError(w, http.StatusBadRequest, "provider does not support server provisioning: "+storedProvider.Type)
 		return
@@ -0,0 +1,339 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/server/profiles"
+	"pressluft/internal/infra/provider"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/sshutil"
+)
+
+const (
+	// adoptedServerLocation and adoptedServerType fill the cloud-only
+	// columns of servers Pressluft did not create.
+	adoptedServerLocation = "self-managed"
+	adoptedServerType     = "existing"
+
+	// passwordInstallTimeout bounds the one-time password login that
+	// installs Pressluft's key.
+	passwordInstallTimeout = 30 * time.Second
+)
+
+var sshUserPattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// hostKeyFingerprintPattern matches a SHA256 fingerprint as ssh-keygen -l
+// prints it.
+var hostKeyFingerprintPattern = regexp.MustCompile(`^SHA256:[A-Za-z0-9+/]{43}$`)
+
+// adoptedServerFields is the validated SSH endpoint of a server to adopt.
+type adoptedServerFields struct {
+	location           string
+	ipv4               string
+	sshPort            int
+	sshUser            string
+	hostKeyFingerprint string
+}
+
+func validateAdoptedServerFields(req apitypes.CreateServerRequest) (adoptedServerFields, error) {
+	out := adoptedServerFields{
+		location:           strings.TrimSpace(req.Location),
+		ipv4:               strings.TrimSpace(req.IPv4),
+		sshPort:            req.SSHPort,
+		sshUser:            strings.TrimSpace(req.SSHUser),
+		hostKeyFingerprint: strings.TrimSpace(req.SSHHostKeyFingerprint),
+	}
+	if out.ipv4 == "" {
+		return out, fmt.Errorf("ipv4 is required")
+	}
+	if ip := net.ParseIP(out.ipv4); ip == nil || ip.To4() == nil {
+		return out, fmt.Errorf("ipv4 must be an IPv4 address")
+	}
+	if out.sshPort == 0 {
+		out.sshPort = sshutil.DefaultPort
+	}
+	if out.sshPort < 1 || out.sshPort > 65535 {
+		return out, fmt.Errorf("ssh_port must be between 1 and 65535")
+	}
+	if out.sshUser == "" {
+		out.sshUser = "root"
+	}
+	if !sshUserPattern.MatchString(out.sshUser) {
+		return out, fmt.Errorf("ssh_user must be a valid Linux user name")
+	}
+	if err := validateHostKeyFingerprint(out.hostKeyFingerprint, req.Password); err != nil {
+		return out, err
+	}
+	if out.location == "" {
+		out.location = adoptedServerLocation
+	}
+	return out, nil
+}
+
+// validateHostKeyFingerprint checks an operator-supplied fingerprint. One is
+// required with a password: without it the password could go to whichever
+// host answers at the address.
+func validateHostKeyFingerprint(fingerprint, password string) error {
+	if fingerprint == "" {
+		if password != "" {
+			return fmt.Errorf("ssh_host_key_fingerprint is required with a password; read it with ssh-keygen -lf /etc/ssh/ssh_host_ed25519_key.pub on the server")
+		}
+		return nil
+	}
+	if !hostKeyFingerprintPattern.MatchString(fingerprint) {
+		return fmt.Errorf("ssh_host_key_fingerprint must be a SHA256 fingerprint such as SHA256:%s", strings.Repeat("x", 43))
+	}
+	return nil
+}
+
+// handleCreateAdopted records an existing server and generates the key
+// Pressluft will use for it. With a password the key is installed right away
+// and the connection job queued; otherwise the operator installs the key and
+// connects the server afterwards.
+func (sh *serversHandler) handleCreateAdopted(w http.ResponseWriter, r *http.Request, req apitypes.CreateServerRequest, profile profiles.Profile, storedProvider *provider.StoredProvider) {
+	fields, err := validateAdoptedServerFields(req)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	serverID, err := sh.serverStore.Create(r.Context(), CreateServerNodeInput{
+		ProviderID:            storedProvider.ID,
+		ProviderType:          storedProvider.Type,
+		Name:                  req.Name,
+		Location:              fields.location,
+		ServerType:            adoptedServerType,
+		Image:                 profile.Image,
+		ProfileKey:            req.ProfileKey,
+		Status:                platform.ServerStatusPending,
+		IPv4:                  fields.ipv4,
+		SSHPort:               fields.sshPort,
+		SSHUser:               fields.sshUser,
+		SSHHostKeyFingerprint: fields.hostKeyFingerprint,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to create server record: "+err.Error())
+		return
+	}
+
+	publicKey, err := sh.createServerKey(r.Context(), serverID)
+	if err != nil {
+		_ = sh.serverStore.UpdateStatus(r.Context(), serverID, platform.ServerStatusFailed)
+		respondError(w, http.StatusInternalServerError, "failed to create SSH key: "+err.Error())
+		return
+	}
+
+	if sh.activityStore != nil {
+		actorType, actorID := activityActorFromRequest(r)
+		_, _ = sh.activityStore.Emit(r.Context(), activity.EmitInput{
+			EventType:    activity.EventServerCreated,
+			Category:     activity.CategoryServer,
+			Level:        activity.LevelInfo,
+			ResourceType: activity.ResourceServer,
+			ResourceID:   serverID,
+			ActorType:    actorType,
+			ActorID:      actorID,
+			Title:        fmt.Sprintf("Server '%s' added", req.Name),
+			Message:      fmt.Sprintf("Existing server at %s will be connected over SSH as %s.", fields.ipv4, fields.sshUser),
+		})
+	}
+
+	response := apitypes.CreateServerResponse{
+		ServerID:     apitypes.FormatAppID(serverID),
+		Status:       platform.ServerStatusPending,
+		SSHPublicKey: publicKey,
+	}
+	if req.Password == "" {
+		respondJSON(w, http.StatusCreated, response)
+		slog.Default().Info("server action completed", "action", "create_server", "server_id", serverID, "server_status", platform.ServerStatusPending, "awaiting", "ssh_key_install")
+		return
+	}
+
+	server, err := sh.serverStore.GetByID(r.Context(), serverID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := installServerKeyWithPassword(r.Context(), server, publicKey, req.Password); err != nil {
+		respondError(w, http.StatusBadGateway, fmt.Sprintf("server %s was added, but installing its SSH key failed: %v", apitypes.FormatAppID(serverID), err))
+		return
+	}
+	job, err := sh.queueConnectJob(r.Context(), serverID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.JobID = job.ID
+	respondJSON(w, http.StatusAccepted, response)
+	slog.Default().Info("server action queued", "action", "create_server", "server_id", serverID, "job_id", job.ID, "server_status", platform.ServerStatusPending)
+}
+
+// handleConnect starts connecting an adopted server, first installing the
+// key with a one-time password when one is given.
+func (sh *serversHandler) handleConnect(w http.ResponseWriter, r *http.Request, serverID string) {
+	var req apitypes.ConnectServerRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	server, err := sh.serverStore.GetByID(r.Context(), serverID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if !provider.AdoptsExistingServers(server.ProviderType) {
+		respondError(w, http.StatusBadRequest, "provider does not adopt existing servers: "+server.ProviderType)
+		return
+	}
+	if server.Status != platform.ServerStatusPending && server.Status != platform.ServerStatusFailed {
+		respondError(w, http.StatusConflict, fmt.Sprintf("server is %s; only pending or failed servers can be connected", server.Status))
+		return
+	}
+	slog.Default().Info("server action requested", "action", "connect_server", "server_id", serverID, "with_password", req.Password != "")
+
+	// A recorded host key is authoritative; the request may only supply one
+	// for a server that has never been reached.
+	fingerprint := strings.TrimSpace(req.SSHHostKeyFingerprint)
+	if server.SSHHostKeyFingerprint != "" {
+		if fingerprint != "" && fingerprint != server.SSHHostKeyFingerprint {
+			respondError(w, http.StatusConflict, "ssh_host_key_fingerprint does not match the host key recorded for this server")
+			return
+		}
+		fingerprint = server.SSHHostKeyFingerprint
+	}
+	if err := validateHostKeyFingerprint(fingerprint, req.Password); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if fingerprint != server.SSHHostKeyFingerprint {
+		if err := sh.serverStore.UpdateSSHHostKey(r.Context(), serverID, fingerprint); err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to record host key: "+err.Error())
+			return
+		}
+		server.SSHHostKeyFingerprint = fingerprint
+	}
+
+	if req.Password != "" {
+		storedKey, err := sh.serverStore.GetKey(r.Context(), serverID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "failed to read SSH key: "+err.Error())
+			return
+		}
+		if storedKey == nil {
+			respondError(w, http.StatusConflict, "server has no SSH key")
+			return
+		}
+		if err := installServerKeyWithPassword(r.Context(), server, storedKey.PublicKey, req.Password); err != nil {
+			respondError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+	}
+
+	job, err := sh.queueConnectJob(r.Context(), serverID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusAccepted, apitypes.CreateServerResponse{
+		ServerID: apitypes.FormatAppID(serverID),
+		JobID:    job.ID,
+		Status:   server.Status,
+	})
+	slog.Default().Info("server action queued", "action", "connect_server", "server_id", serverID, "job_id", job.ID)
+}
+
+// handleSSHKey returns the public key an adopted server must trust.
+func (sh *serversHandler) handleSSHKey(w http.ResponseWriter, r *http.Request, serverID string) {
+	server, err := sh.serverStore.GetByID(r.Context(), serverID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	storedKey, err := sh.serverStore.GetKey(r.Context(), serverID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to read SSH key: "+err.Error())
+		return
+	}
+	if storedKey == nil {
+		respondError(w, http.StatusNotFound, "server has no SSH key")
+		return
+	}
+	respondJSON(w, http.StatusOK, apitypes.ServerSSHKeyResponse{
+		ServerID:       apitypes.FormatAppID(serverID),
+		PublicKey:      storedKey.PublicKey,
+		SSHUser:        server.SSHUser,
+		SSHPort:        server.SSHPort,
+		InstallCommand: authorizedKeyInstallCommand(storedKey.PublicKey),
+	})
+}
+
+// createServerKey generates and stores the key pair for a server and returns
+// its public half.
+func (sh *serversHandler) createServerKey(ctx context.Context, serverID string) (string, error) {
+	publicKey, privateKey, err := sshutil.GenerateKeyPair(fmt.Sprintf("pressluft-server-%s", serverID))
+	if err != nil {
+		return "", err
+	}
+	encryptedKey, keyID, err := security.Encrypt([]byte(privateKey))
+	if err != nil {
+		return "", fmt.Errorf("encrypt SSH key: %w", err)
+	}
+	if err := sh.serverStore.CreateKey(ctx, CreateServerKeyInput{
+		ServerID:            serverID,
+		PublicKey:           publicKey,
+		PrivateKeyEncrypted: encryptedKey,
+		EncryptionKeyID:     keyID,
+	}); err != nil {
+		return "", err
+	}
+	return publicKey, nil
+}
+
+func (sh *serversHandler) queueConnectJob(ctx context.Context, serverID string) (orchestrator.Job, error) {
+	job, err := sh.jobStore.CreateJob(ctx, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindConnectServer),
+		ServerID: serverID,
+	})
+	if err != nil {
+		return orchestrator.Job{}, fmt.Errorf("failed to create connection job: %w", err)
+	}
+	_, _ = sh.jobStore.AppendEvent(ctx, job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   "Server connection job queued",
+	})
+	return job, nil
+}
+
+// installServerKeyWithPassword logs in once with password and adds
+// publicKey to the login user's authorized_keys. The password is not kept,
+// and is only sent once the host has presented its recorded key.
+func installServerKeyWithPassword(ctx context.Context, server *StoredServer, publicKey, password string) error {
+	if strings.TrimSpace(server.SSHHostKeyFingerprint) == "" {
+		return fmt.Errorf("refusing to send a password to a host with no recorded host key")
+	}
+	ctx, cancel := context.WithTimeout(ctx, passwordInstallTimeout)
+	defer cancel()
+	target := sshutil.Target{Host: server.IPv4, Port: server.SSHPort, User: server.SSHUser, HostKeyFingerprint: server.SSHHostKeyFingerprint}
+	client, err := sshutil.Dial(ctx, target, sshutil.PasswordAuth(password)...)
+	if err != nil {
+		return fmt.Errorf("password login failed: %w", err)
+	}
+	defer client.Close()
+	return sshutil.InstallAuthorizedKey(ctx, client, publicKey)
+}
+
+func authorizedKeyInstallCommand(publicKey string) string {
+	return fmt.Sprintf("mkdir -p ~/.ssh && chmod 700 ~/.ssh && echo '%s' >> ~/.ssh/authorized_keys && chmod 600 ~/.ssh/authorized_keys", strings.TrimSpace(publicKey))
+}
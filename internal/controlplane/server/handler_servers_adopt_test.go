@@ -0,0 +1,240 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/sshutil/sshtest"
+
+	"golang.org/x/crypto/ssh"
+
+	_ "pressluft/internal/infra/provider/custom"
+)
+
+func TestServersCreateAdoptedServerAwaitsKeyInstall(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	providerID, _ := mustInsertProviderRecord(t, db, "custom", "own hardware", "")
+	handler := NewHandler(db)
+
+	res := serveAdoptJSON(handler, http.MethodPost, "/api/servers", map[string]any{
+		"provider_id": providerID,
+		"name":        "office-box",
+		"profile_key": "nginx-stack",
+		"ipv4":        "203.0.113.20",
+		"ssh_port":    2222,
+		"ssh_user":    "deploy",
+	})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+	var created apitypes.CreateServerResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if created.JobID != "" || !strings.HasPrefix(created.SSHPublicKey, "ssh-ed25519 ") {
+		t.Fatalf("response = %+v, want a public key and no job", created)
+	}
+
+	servers, err := NewServerStore(db).List(context.Background())
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("list servers = %+v, %v", servers, err)
+	}
+	stored := servers[0]
+	if stored.SSHPort != 2222 || stored.SSHUser != "deploy" || stored.Location != adoptedServerLocation || !stored.HasKey {
+		t.Fatalf("stored server = %+v", stored)
+	}
+
+	res = serveAdoptJSON(handler, http.MethodGet, "/api/servers/"+stored.ID+"/ssh-key", nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("ssh-key status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var key apitypes.ServerSSHKeyResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &key); err != nil {
+		t.Fatalf("decode ssh-key response: %v", err)
+	}
+	if key.PublicKey != created.SSHPublicKey || !strings.Contains(key.InstallCommand, "authorized_keys") {
+		t.Fatalf("ssh-key response = %+v", key)
+	}
+
+	res = serveAdoptJSON(handler, http.MethodPost, "/api/servers/"+stored.ID+"/connect", map[string]any{})
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("connect status = %d; body = %s", res.Code, res.Body.String())
+	}
+	jobs, err := orchestrator.NewStore(db).ListJobsByServer(context.Background(), stored.ID)
+	if err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Kind != string(orchestrator.JobKindConnectServer) {
+		t.Fatalf("jobs = %+v, want one connect_server job", jobs)
+	}
+}
+
+func TestServersCreateAdoptedServerInstallsKeyWithPassword(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	host := sshtest.NewServer(nil)
+	t.Cleanup(host.Close)
+	host.SetPassword("one-time")
+
+	db := mustOpenServerHandlerDB(t)
+	providerID, _ := mustInsertProviderRecord(t, db, "custom", "own hardware", "")
+	handler := NewHandler(db)
+
+	res := serveAdoptJSON(handler, http.MethodPost, "/api/servers", map[string]any{
+		"provider_id":              providerID,
+		"name":                     "office-box",
+		"profile_key":              "nginx-stack",
+		"ipv4":                     host.Host(),
+		"ssh_port":                 host.Port(),
+		"password":                 "one-time",
+		"ssh_host_key_fingerprint": ssh.FingerprintSHA256(host.HostKey()),
+	})
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusAccepted, res.Body.String())
+	}
+	var created apitypes.CreateServerResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if created.JobID == "" {
+		t.Fatalf("response = %+v, want a connection job", created)
+	}
+	commands := host.Commands()
+	if len(commands) != 1 || !strings.Contains(commands[0], created.SSHPublicKey) {
+		t.Fatalf("commands = %q, want the key installed", commands)
+	}
+}
+
+func TestServersCreateAdoptedServerKeepsPasswordFromUnknownHosts(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	host := sshtest.NewServer(nil)
+	t.Cleanup(host.Close)
+	host.SetPassword("one-time")
+
+	db := mustOpenServerHandlerDB(t)
+	providerID, _ := mustInsertProviderRecord(t, db, "custom", "own hardware", "")
+	handler := NewHandler(db)
+
+	for _, tc := range []struct {
+		name        string
+		fingerprint string
+		want        int
+	}{
+		{name: "missing", want: http.StatusBadRequest},
+		{name: "malformed", fingerprint: "aa:bb:cc", want: http.StatusBadRequest},
+		{name: "mismatched", fingerprint: "SHA256:" + strings.Repeat("A", 43), want: http.StatusBadGateway},
+	} {
+		res := serveAdoptJSON(handler, http.MethodPost, "/api/servers", map[string]any{
+			"provider_id":              providerID,
+			"name":                     "office-box-" + tc.name,
+			"profile_key":              "nginx-stack",
+			"ipv4":                     host.Host(),
+			"ssh_port":                 host.Port(),
+			"password":                 "one-time",
+			"ssh_host_key_fingerprint": tc.fingerprint,
+		})
+		if res.Code != tc.want {
+			t.Fatalf("%s: status = %d, want %d; body = %s", tc.name, res.Code, tc.want, res.Body.String())
+		}
+	}
+	if attempts := host.PasswordAttempts(); attempts != 0 {
+		t.Fatalf("password attempts = %d, want the password never sent", attempts)
+	}
+}
+
+func TestServersConnectKeepsRecordedHostKey(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	providerID, _ := mustInsertProviderRecord(t, db, "custom", "own hardware", "")
+	handler := NewHandler(db)
+	recorded := "SHA256:" + strings.Repeat("A", 43)
+
+	res := serveAdoptJSON(handler, http.MethodPost, "/api/servers", map[string]any{
+		"provider_id":              providerID,
+		"name":                     "office-box",
+		"profile_key":              "nginx-stack",
+		"ipv4":                     "203.0.113.20",
+		"ssh_host_key_fingerprint": recorded,
+	})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusCreated, res.Body.String())
+	}
+	servers, err := NewServerStore(db).List(context.Background())
+	if err != nil || len(servers) != 1 {
+		t.Fatalf("list servers = %+v, %v", servers, err)
+	}
+
+	res = serveAdoptJSON(handler, http.MethodPost, "/api/servers/"+servers[0].ID+"/connect", map[string]any{
+		"ssh_host_key_fingerprint": "SHA256:" + strings.Repeat("B", 43),
+	})
+	if res.Code != http.StatusConflict {
+		t.Fatalf("connect status = %d, want %d; body = %s", res.Code, http.StatusConflict, res.Body.String())
+	}
+	stored, err := NewServerStore(db).GetByID(context.Background(), servers[0].ID)
+	if err != nil {
+		t.Fatalf("get server: %v", err)
+	}
+	if stored.SSHHostKeyFingerprint != recorded {
+		t.Fatalf("host key = %q, want %q kept", stored.SSHHostKeyFingerprint, recorded)
+	}
+}
+
+func TestServersCreateAdoptedServerRequiresIPv4(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	providerID, _ := mustInsertProviderRecord(t, db, "custom", "own hardware", "")
+	handler := NewHandler(db)
+
+	res := serveAdoptJSON(handler, http.MethodPost, "/api/servers", map[string]any{
+		"provider_id": providerID,
+		"name":        "office-box",
+		"profile_key": "nginx-stack",
+	})
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusBadRequest, res.Body.String())
+	}
+	servers, err := NewServerStore(db).List(context.Background())
+	if err != nil {
+		t.Fatalf("list servers: %v", err)
+	}
+	if len(servers) != 0 {
+		t.Fatalf("server count = %d, want 0", len(servers))
+	}
+}
+
+func TestServersConnectRejectsCloudServers(t *testing.T) {
+	registerTestServerProvider()
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, string(platform.ServerStatusPending))
+	handler := NewHandler(db)
+
+	res := serveAdoptJSON(handler, http.MethodPost, "/api/servers/"+serverID+"/connect", map[string]any{})
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body = %s", res.Code, http.StatusBadRequest, res.Body.String())
+	}
+}
+
+func serveAdoptJSON(handler http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		raw, _ := json.Marshal(body)
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
@@ -14,28 +14,31 @@ import (
 
 func apiStoredServer(in StoredServer) apitypes.StoredServer {
 	return apitypes.StoredServer{
-		ID:               apitypes.FormatAppID(in.ID),
-		ProviderID:       in.ProviderID,
-		ProviderType:     in.ProviderType,
-		ProviderServerID: in.ProviderServerID,
-		IPv4:             in.IPv4,
-		IPv6:             in.IPv6,
-		Name:             in.Name,
-		Location:         in.Location,
-		ServerType:       in.ServerType,
-		Image:            in.Image,
-		ProfileKey:       in.ProfileKey,
-		Status:           in.Status,
-		SetupState:       in.SetupState,
-		SetupLastError:   in.SetupLastError,
-		ActionID:         in.ActionID,
-		ActionStatus:     in.ActionStatus,
-		HasKey:           in.HasKey,
-		NodeStatus:       in.NodeStatus,
-		NodeLastSeen:     in.NodeLastSeen,
-		NodeVersion:      in.NodeVersion,
-		CreatedAt:        in.CreatedAt,
-		UpdatedAt:        in.UpdatedAt,
+		ID:                    apitypes.FormatAppID(in.ID),
+		ProviderID:            in.ProviderID,
+		ProviderType:          in.ProviderType,
+		ProviderServerID:      in.ProviderServerID,
+		IPv4:                  in.IPv4,
+		IPv6:                  in.IPv6,
+		Name:                  in.Name,
+		Location:              in.Location,
+		ServerType:            in.ServerType,
+		Image:                 in.Image,
+		ProfileKey:            in.ProfileKey,
+		Status:                in.Status,
+		SetupState:            in.SetupState,
+		SetupLastError:        in.SetupLastError,
+		ActionID:              in.ActionID,
+		ActionStatus:          in.ActionStatus,
+		HasKey:                in.HasKey,
+		NodeStatus:            in.NodeStatus,
+		NodeLastSeen:          in.NodeLastSeen,
+		NodeVersion:           in.NodeVersion,
+		SSHPort:               in.SSHPort,
+		SSHUser:               in.SSHUser,
+		SSHHostKeyFingerprint: in.SSHHostKeyFingerprint,
+		CreatedAt:             in.CreatedAt,
+		UpdatedAt:             in.UpdatedAt,
 	}
 }
 
@@ -120,14 +123,20 @@ func validateCreateServerHTTPRequest(req apitypes.CreateServerRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
 		return fmt.Errorf("name is required")
 	}
+	if strings.TrimSpace(req.ProfileKey) == "" {
+		return fmt.Errorf("profile_key is required")
+	}
+	return nil
+}
+
+// validateProvisionedServerFields checks the fields only servers created
+// through a provider API need.
+func validateProvisionedServerFields(req apitypes.CreateServerRequest) error {
 	if strings.TrimSpace(req.Location) == "" {
 		return fmt.Errorf("location is required")
 	}
 	if strings.TrimSpace(req.ServerType) == "" {
 		return fmt.Errorf("server_type is required")
 	}
-	if strings.TrimSpace(req.ProfileKey) == "" {
-		return fmt.Errorf("profile_key is required")
-	}
 	return nil
 }
@@ -13,6 +13,7 @@ import (
 	"pressluft/internal/controlplane/activity"
 	"pressluft/internal/controlplane/apitypes"
 	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/controlplane/vulnscan"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 	"pressluft/internal/shared/ws"
@@ -27,7 +28,13 @@ type sitesHandler struct {
 	domainStore     *DomainStore
 	activityStore   *activity.Store
 	activityHandler *activityHandler
-	hub             *ws.Hub
+	backupsHandler  *backupsHandler
+	componentStore  *ComponentStore
+	// vulnScanner, when set, stores refreshed inventories and rescans them.
+	vulnScanner        *vulnscan.Scanner
+	vulnerabilityStore *VulnerabilityStore
+	uptimeStore        *UptimeStore
+	hub                *ws.Hub
 }
 
 type deploySiteJobPayload struct {
@@ -87,6 +94,92 @@ func (sh *sitesHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if len(parts) == 2 && parts[1] == "backups" {
+		if sh.backupsHandler == nil {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			sh.backupsHandler.handleListBySite(w, r, siteID)
+		case http.MethodPost:
+			sh.backupsHandler.handleCreateForSite(w, r, siteID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if len(parts) == 2 && parts[1] == "restore" {
+		if sh.backupsHandler == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.backupsHandler.handleRestoreForSite(w, r, siteID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "staging" {
+		switch r.Method {
+		case http.MethodGet:
+			sh.handleListStaging(w, r, siteID)
+		case http.MethodPost:
+			sh.handleCreateStaging(w, r, siteID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+	if len(parts) == 2 && parts[1] == "push" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.handleCreatePush(w, r, siteID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "components" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.handleListComponents(w, r, siteID)
+		return
+	}
+	if len(parts) == 3 && parts[1] == "components" && parts[2] == "refresh" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.handleRefreshComponents(w, r, siteID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "updates" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.handleCreateUpdate(w, r, siteID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "vulnerabilities" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		sh.handleListVulnerabilities(w, r, siteID)
+		return
+	}
+	if (len(parts) == 2 || len(parts) == 3) && parts[1] == "uptime" {
+		sub := ""
+		if len(parts) == 3 {
+			sub = parts[2]
+		}
+		sh.routeUptime(w, r, siteID, sub)
+		return
+	}
 	if len(parts) == 2 && parts[1] == "health" {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -125,8 +218,12 @@ func (sh *sitesHandler) handleList(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, []apitypes.StoredSite{})
 		return
 	}
+	actor := auth.ActorFromContext(r.Context())
 	payload := make([]apitypes.StoredSite, 0, len(sites))
 	for _, site := range sites {
+		if !actor.CanAccessSite(site.ID) {
+			continue
+		}
 		payload = append(payload, apiStoredSite(site))
 	}
 	respondJSON(w, http.StatusOK, payload)
@@ -396,6 +493,10 @@ func (sh *sitesHandler) handleDelete(w http.ResponseWriter, r *http.Request, sit
 			respondError(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if strings.Contains(err.Error(), "staging environment") {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
 		respondError(w, http.StatusInternalServerError, "failed to delete site: "+err.Error())
 		return
 	}
@@ -441,6 +542,9 @@ func apiStoredSite(in StoredSite) apitypes.StoredSite {
 		WordPressPath:       in.WordPressPath,
 		PHPVersion:          in.PHPVersion,
 		WordPressVersion:    in.WordPressVersion,
+		Environment:         in.Environment,
+		ParentSiteID:        apitypes.FormatAppID(in.ParentSiteID),
+		BasicAuthUsername:   in.BasicAuthUsername,
 		CreatedAt:           in.CreatedAt,
 		UpdatedAt:           in.UpdatedAt,
 	}
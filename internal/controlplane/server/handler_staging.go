@@ -0,0 +1,250 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+// stagingBasicAuthUsername is the basic auth user generated for every staging
+// site. Only the password is secret.
+const stagingBasicAuthUsername = "pressluft"
+
+func (sh *sitesHandler) handleListStaging(w http.ResponseWriter, r *http.Request, siteID string) {
+	if _, err := sh.store.GetByID(r.Context(), siteID); err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	sites, err := sh.store.ListByParent(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list staging sites: "+err.Error())
+		return
+	}
+	payload := make([]apitypes.StoredSite, 0, len(sites))
+	for _, site := range sites {
+		payload = append(payload, apiStoredSite(site))
+	}
+	respondJSON(w, http.StatusOK, payload)
+}
+
+func (sh *sitesHandler) handleCreateStaging(w http.ResponseWriter, r *http.Request, siteID string) {
+	if sh.jobStore == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var req apitypes.CreateStagingRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	parent, err := sh.store.GetByID(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if parent.Environment != SiteEnvironmentProduction {
+		respondError(w, http.StatusBadRequest, "staging environments can only be created from production sites")
+		return
+	}
+	if parent.DeploymentState != SiteDeploymentStateReady {
+		respondError(w, http.StatusConflict, "site must be deployed before a staging environment can be created")
+		return
+	}
+
+	name := req.Name
+	if name == "" {
+		name = parent.Name + " (staging)"
+	}
+	label := req.Label
+	if label == "" {
+		label = "staging-" + parent.Name
+	}
+	password, err := randomStagingPassword()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to generate basic auth password")
+		return
+	}
+	id, err := sh.store.Create(r.Context(), CreateSiteInput{
+		ServerID:            parent.ServerID,
+		Name:                name,
+		WordPressAdminEmail: parent.WordPressAdminEmail,
+		PrimaryHostnameConfig: &CreateSitePrimaryHostnameInput{
+			Source: DomainSourceFallbackResolver,
+			Label:  label,
+		},
+		Status:            SiteStatusDraft,
+		PHPVersion:        parent.PHPVersion,
+		WordPressVersion:  parent.WordPressVersion,
+		Environment:       SiteEnvironmentStaging,
+		ParentSiteID:      parent.ID,
+		BasicAuthUsername: stagingBasicAuthUsername,
+		BasicAuthPassword: password,
+	})
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			respondError(w, http.StatusNotFound, err.Error())
+		case strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "primary_hostname_config") || strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "must") || strings.Contains(err.Error(), "fallback resolver hostnames"):
+			respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondError(w, http.StatusInternalServerError, "failed to create staging site: "+err.Error())
+		}
+		return
+	}
+	site, err := sh.store.GetByID(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sh.activityStore != nil {
+		actorType, actorID := activityActorFromRequest(r)
+		_, _ = sh.activityStore.Emit(r.Context(), activity.EmitInput{
+			EventType:          activity.EventSiteCreated,
+			Category:           activity.CategorySite,
+			Level:              activity.LevelInfo,
+			ResourceType:       activity.ResourceSite,
+			ResourceID:         site.ID,
+			ParentResourceType: activity.ResourceSite,
+			ParentResourceID:   parent.ID,
+			ActorType:          actorType,
+			ActorID:            actorID,
+			Title:              fmt.Sprintf("Staging '%s' created", site.Name),
+			Message:            fmt.Sprintf("A staging copy of '%s' will be served at https://%s/.", parent.Name, site.PrimaryDomain),
+		})
+	}
+
+	actor := auth.ActorFromContext(r.Context())
+	payload, err := orchestrator.MarshalCreateStagingPayload(orchestrator.CreateStagingPayload{
+		SiteID:          site.ID,
+		SourceSiteID:    parent.ID,
+		TLSContactEmail: actor.Email,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue staging creation: marshal payload")
+		return
+	}
+	job, err := sh.jobStore.CreateJob(r.Context(), orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindCreateStaging),
+		ServerID: site.ServerID,
+		Payload:  payload,
+	})
+	if err != nil {
+		_ = sh.store.UpdateDeployment(r.Context(), site.ID, SiteDeploymentStateFailed, "Failed to queue staging creation.", "", "")
+		respondError(w, http.StatusInternalServerError, "failed to queue staging creation: "+err.Error())
+		return
+	}
+	_, _ = sh.jobStore.AppendEvent(r.Context(), job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   "Staging creation accepted and queued",
+	})
+	_ = sh.store.UpdateDeployment(r.Context(), site.ID, SiteDeploymentStateDeploying, "Staging creation queued.", job.ID, "")
+	site, _ = sh.store.GetByID(r.Context(), id)
+
+	respondJSON(w, http.StatusAccepted, apitypes.CreateStagingResponse{
+		Site:              apiStoredSite(*site),
+		ParentSiteID:      apitypes.FormatAppID(parent.ID),
+		JobID:             apitypes.FormatAppID(job.ID),
+		JobStatus:         job.Status,
+		BasicAuthUsername: stagingBasicAuthUsername,
+		BasicAuthPassword: password,
+	})
+}
+
+func (sh *sitesHandler) handleCreatePush(w http.ResponseWriter, r *http.Request, siteID string) {
+	if sh.jobStore == nil {
+		http.NotFound(w, r)
+		return
+	}
+	var req apitypes.CreateSitePushRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	staging, err := sh.store.GetByID(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if staging.Environment != SiteEnvironmentStaging || staging.ParentSiteID == "" {
+		respondError(w, http.StatusBadRequest, "only staging sites can be pushed to live")
+		return
+	}
+	live, err := sh.store.GetByID(r.Context(), staging.ParentSiteID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if staging.DeploymentState != SiteDeploymentStateReady || live.DeploymentState != SiteDeploymentStateReady {
+		respondError(w, http.StatusConflict, "staging and live sites must both be deployed before pushing")
+		return
+	}
+
+	raw, err := json.Marshal(orchestrator.PushSitePayload{
+		SiteID:        staging.ID,
+		TargetSiteID:  live.ID,
+		Scope:         req.Scope,
+		IncludeTables: req.IncludeTables,
+		ExcludeTables: req.ExcludeTables,
+		DryRun:        req.DryRun,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue push: marshal payload")
+		return
+	}
+	payload, err := orchestrator.ValidatePayload(string(orchestrator.JobKindPushSite), raw, staging.ServerID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	job, err := sh.jobStore.CreateJob(r.Context(), orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindPushSite),
+		ServerID: staging.ServerID,
+		Payload:  payload,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to queue push: "+err.Error())
+		return
+	}
+	message := "Push to live accepted and queued"
+	if req.DryRun {
+		message = "Push dry run accepted and queued"
+	}
+	_, _ = sh.jobStore.AppendEvent(r.Context(), job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   message,
+	})
+
+	respondJSON(w, http.StatusAccepted, apitypes.CreateSitePushResponse{
+		SiteID:       apitypes.FormatAppID(staging.ID),
+		TargetSiteID: apitypes.FormatAppID(live.ID),
+		JobID:        apitypes.FormatAppID(job.ID),
+		JobStatus:    job.Status,
+		DryRun:       req.DryRun,
+	})
+}
+
+func randomStagingPassword() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
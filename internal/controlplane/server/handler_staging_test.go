@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/shared/security"
+)
+
+func TestSitesStagingEndpointQueuesCloneOfDeployedSite(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	siteStore := NewSiteStore(db)
+	parentID, err := siteStore.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+		PHPVersion:          "8.3",
+	})
+	if err != nil {
+		t.Fatalf("create parent site: %v", err)
+	}
+	handler := NewHandler(db)
+
+	notDeployedRes := postBackupJSON(t, handler, "/api/sites/"+parentID+"/staging", map[string]any{})
+	if notDeployedRes.Code != http.StatusConflict {
+		t.Fatalf("staging of undeployed site status = %d, want %d; body = %s", notDeployedRes.Code, http.StatusConflict, notDeployedRes.Body.String())
+	}
+	if err := siteStore.UpdateDeployment(context.Background(), parentID, SiteDeploymentStateReady, "live", "", "2026-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("mark parent deployed: %v", err)
+	}
+
+	res := postBackupJSON(t, handler, "/api/sites/"+parentID+"/staging", map[string]any{})
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("create staging status = %d, want %d; body = %s", res.Code, http.StatusAccepted, res.Body.String())
+	}
+	var created apitypes.CreateStagingResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode staging response: %v", err)
+	}
+	if created.Site.Environment != SiteEnvironmentStaging || created.Site.ParentSiteID != parentID || created.ParentSiteID != parentID {
+		t.Fatalf("staging site = %+v, want staging linked to %s", created.Site, parentID)
+	}
+	if created.Site.PrimaryDomain != "staging-shop.203-0-113-10.sslip.io" {
+		t.Fatalf("primary_domain = %q, want fallback resolver hostname", created.Site.PrimaryDomain)
+	}
+	if created.BasicAuthUsername == "" || created.BasicAuthPassword == "" || created.Site.BasicAuthUsername != created.BasicAuthUsername {
+		t.Fatalf("basic auth = %q/%q, want generated credentials", created.BasicAuthUsername, created.BasicAuthPassword)
+	}
+	if created.Site.DeploymentState != SiteDeploymentStateDeploying || created.Site.LastDeployJobID != created.JobID {
+		t.Fatalf("staging deployment = %q job %q, want deploying with job %q", created.Site.DeploymentState, created.Site.LastDeployJobID, created.JobID)
+	}
+	stored, err := siteStore.GetByID(context.Background(), created.Site.ID)
+	if err != nil {
+		t.Fatalf("get staging site: %v", err)
+	}
+	password, err := security.DecryptSiteBasicAuthPassword(stored.BasicAuthPasswordEncrypted)
+	if err != nil || password != created.BasicAuthPassword {
+		t.Fatalf("stored password = %q (err %v), want the revealed password", password, err)
+	}
+
+	job, err := orchestrator.NewStore(db).GetJob(context.Background(), created.JobID)
+	if err != nil {
+		t.Fatalf("get staging job: %v", err)
+	}
+	payload, err := orchestrator.UnmarshalCreateStagingPayload(job.Payload)
+	if err != nil {
+		t.Fatalf("decode staging payload: %v", err)
+	}
+	if job.Kind != string(orchestrator.JobKindCreateStaging) || payload.SiteID != created.Site.ID || payload.SourceSiteID != parentID {
+		t.Fatalf("job = %s %+v, want create_staging from parent", job.Kind, payload)
+	}
+
+	listRes := httptest.NewRecorder()
+	handler.ServeHTTP(listRes, httptest.NewRequest(http.MethodGet, "/api/sites/"+parentID+"/staging", nil))
+	var listed []apitypes.StoredSite
+	if err := json.Unmarshal(listRes.Body.Bytes(), &listed); err != nil || len(listed) != 1 || listed[0].ID != created.Site.ID {
+		t.Fatalf("list staging status = %d, body = %s", listRes.Code, listRes.Body.String())
+	}
+
+	nestedRes := postBackupJSON(t, handler, "/api/sites/"+created.Site.ID+"/staging", map[string]any{})
+	if nestedRes.Code != http.StatusBadRequest {
+		t.Fatalf("staging of staging status = %d, want %d", nestedRes.Code, http.StatusBadRequest)
+	}
+	deleteRes := httptest.NewRecorder()
+	handler.ServeHTTP(deleteRes, httptest.NewRequest(http.MethodDelete, "/api/sites/"+parentID, nil))
+	if deleteRes.Code != http.StatusConflict {
+		t.Fatalf("delete parent status = %d, want %d; body = %s", deleteRes.Code, http.StatusConflict, deleteRes.Body.String())
+	}
+}
+
+func TestSitesPushEndpointQueuesPushToParent(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	siteStore := NewSiteStore(db)
+	parentID, err := siteStore.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create parent site: %v", err)
+	}
+	stagingID, err := siteStore.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop (staging)",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "staging.shop.example.test",
+		Status:              SiteStatusActive,
+		Environment:         SiteEnvironmentStaging,
+		ParentSiteID:        parentID,
+		BasicAuthUsername:   stagingBasicAuthUsername,
+		BasicAuthPassword:   "staging-secret",
+	})
+	if err != nil {
+		t.Fatalf("create staging site: %v", err)
+	}
+	handler := NewHandler(db)
+
+	if res := postBackupJSON(t, handler, "/api/sites/"+parentID+"/push", map[string]any{}); res.Code != http.StatusBadRequest {
+		t.Fatalf("push of production site status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := postBackupJSON(t, handler, "/api/sites/"+stagingID+"/push", map[string]any{}); res.Code != http.StatusConflict {
+		t.Fatalf("push of undeployed sites status = %d, want %d; body = %s", res.Code, http.StatusConflict, res.Body.String())
+	}
+	for _, id := range []string{parentID, stagingID} {
+		if err := siteStore.UpdateDeployment(context.Background(), id, SiteDeploymentStateReady, "live", "", "2026-01-01T00:00:00Z"); err != nil {
+			t.Fatalf("mark site deployed: %v", err)
+		}
+	}
+	if res := postBackupJSON(t, handler, "/api/sites/"+stagingID+"/push", map[string]any{"scope": "files", "exclude_tables": []string{"wp_users"}}); res.Code != http.StatusBadRequest {
+		t.Fatalf("files push with table filter status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+
+	res := postBackupJSON(t, handler, "/api/sites/"+stagingID+"/push", map[string]any{
+		"scope":          "database",
+		"exclude_tables": []string{" wp_users ", "wp_woocommerce_*"},
+		"dry_run":        true,
+	})
+	if res.Code != http.StatusAccepted {
+		t.Fatalf("push status = %d, want %d; body = %s", res.Code, http.StatusAccepted, res.Body.String())
+	}
+	var created apitypes.CreateSitePushResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode push response: %v", err)
+	}
+	if created.SiteID != stagingID || created.TargetSiteID != parentID || !created.DryRun {
+		t.Fatalf("push response = %+v, want dry run from staging to parent", created)
+	}
+	job, err := orchestrator.NewStore(db).GetJob(context.Background(), created.JobID)
+	if err != nil {
+		t.Fatalf("get push job: %v", err)
+	}
+	payload, err := orchestrator.UnmarshalPushSitePayload(job.Payload)
+	if err != nil {
+		t.Fatalf("decode push payload: %v", err)
+	}
+	if job.Kind != string(orchestrator.JobKindPushSite) || payload.Scope != orchestrator.PushScopeDatabase || len(payload.ExcludeTables) != 2 || payload.ExcludeTables[0] != "wp_users" {
+		t.Fatalf("job = %s %+v, want database push without users", job.Kind, payload)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"pressluft/internal/controlplane/apitypes"
 	"pressluft/internal/controlplane/auth"
 )
 
@@ -31,13 +34,69 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestHealthEndpointReportsWorkerSlotCounts(t *testing.T) {
+	handler := NewHandlerWithOptions(nil, nil, nil, nil, HandlerOptions{WorkerSlots: staticWorkerSlots{usage: apitypes.WorkerSlotUsage{
+		Running:          1,
+		MaxJobs:          4,
+		MaxJobsPerServer: 1,
+		Servers:          map[string]int{"00000000-0000-7000-8000-000000000001": 1},
+	}}})
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	var payload apitypes.HealthResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if payload.Worker == nil || payload.Worker.Running != 1 || payload.Worker.MaxJobs != 4 || payload.Worker.BusyServers != 1 {
+		t.Fatalf("worker slots = %+v, want the reported counts", payload.Worker)
+	}
+	if strings.Contains(res.Body.String(), "00000000-0000-7000-8000-000000000001") {
+		t.Fatalf("health body = %s, want no server IDs", res.Body.String())
+	}
+}
+
+func TestWorkerSlotsEndpointListsOwnedServersAndProviders(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	providerID, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	unknownID := "00000000-0000-7000-8000-0000000000ff"
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{WorkerSlots: staticWorkerSlots{usage: apitypes.WorkerSlotUsage{
+		Running:   2,
+		MaxJobs:   4,
+		Providers: map[string]int{providerID: 1, unknownID: 1},
+		Servers:   map[string]int{serverID: 1, unknownID: 1},
+	}}})
+	req := httptest.NewRequest(http.MethodGet, "/api/worker/slots", nil)
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	var usage apitypes.WorkerSlotUsage
+	if err := json.Unmarshal(res.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("decode response: %v; body = %s", err, res.Body.String())
+	}
+	if usage.Running != 2 || len(usage.Servers) != 1 || usage.Servers[serverID] != 1 || len(usage.Providers) != 1 || usage.Providers[providerID] != 1 {
+		t.Fatalf("usage = %+v, want only the stored server and provider", usage)
+	}
+}
+
+type staticWorkerSlots struct {
+	usage apitypes.WorkerSlotUsage
+}
+
+func (s staticWorkerSlots) SlotUsage() apitypes.WorkerSlotUsage { return s.usage }
+
 func TestOperatorRoutesRequireCapabilitiesWhenAuthenticatorConfigured(t *testing.T) {
 	db := mustOpenServerHandlerDB(t)
 	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
 		Authenticator: staticAuthenticator{actor: auth.Actor{
 			ID:            "user-1",
 			Type:          auth.ActorTypeOperator,
-			Role:          auth.Role("viewer"),
+			Role:          auth.Role("guest"),
 			Authenticated: true,
 		}},
 	})
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+type tokensHandler struct {
+	service       *auth.Service
+	activityStore *activity.Store
+}
+
+func (th *tokensHandler) route(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/tokens" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		th.handleList(w, r)
+	case http.MethodPost:
+		th.handleCreate(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (th *tokensHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
+	tail := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tokens/"), "/")
+	if tail == "" || strings.Contains(tail, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	tokenID, err := apitypes.ParseAppID(tail)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid token id")
+		return
+	}
+	switch r.Method {
+	case http.MethodDelete:
+		th.handleRevoke(w, r, tokenID)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (th *tokensHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	tokens, err := th.service.ListAPITokens(r.Context(), auth.ActorFromContext(r.Context()))
+	if err != nil {
+		respondTokenError(w, err, "failed to list api tokens")
+		return
+	}
+	out := make([]apitypes.APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		out = append(out, apiToken(token))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (th *tokensHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.CreateAPITokenRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	token, secret, err := th.service.CreateAPIToken(r.Context(), auth.ActorFromContext(r.Context()), auth.CreateAPITokenInput{
+		Kind:     auth.TokenKind(req.Kind),
+		Name:     req.Name,
+		Role:     req.Role,
+		Scopes:   req.Scopes,
+		Lifetime: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		respondTokenError(w, err, "failed to create api token")
+		return
+	}
+	th.emitActivity(r, activity.EventSecurityAPIKeyCreated, token, fmt.Sprintf("API token '%s' created", token.Name), fmt.Sprintf("%s token %s... expires at %s.", token.Kind, token.Prefix, token.ExpiresAt))
+	respondJSON(w, http.StatusCreated, apitypes.CreateAPITokenResponse{Token: apiToken(token), Secret: secret})
+}
+
+func (th *tokensHandler) handleRevoke(w http.ResponseWriter, r *http.Request, tokenID string) {
+	token, err := th.service.RevokeAPIToken(r.Context(), auth.ActorFromContext(r.Context()), tokenID)
+	if err != nil {
+		respondTokenError(w, err, "failed to revoke api token")
+		return
+	}
+	th.emitActivity(r, activity.EventSecurityAPIKeyRevoked, token, fmt.Sprintf("API token '%s' revoked", token.Name), "Requests using this token are rejected from now on.")
+	respondJSON(w, http.StatusOK, apiToken(token))
+}
+
+func (th *tokensHandler) emitActivity(r *http.Request, eventType activity.EventType, token auth.APIToken, title, message string) {
+	if th.activityStore == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	_, _ = th.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategorySecurity,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceAPIKey,
+		ResourceID:   token.ID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        title,
+		Message:      message,
+	})
+}
+
+func respondTokenError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAPIToken):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		respondError(w, http.StatusForbidden, "forbidden")
+	case errors.Is(err, auth.ErrAPITokenNotFound):
+		respondError(w, http.StatusNotFound, "api token not found")
+	default:
+		slog.Default().Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func apiToken(in auth.APIToken) apitypes.APIToken {
+	scopes := in.Scopes
+	if scopes == nil {
+		scopes = []auth.Capability{}
+	}
+	return apitypes.APIToken{
+		ID:         apitypes.FormatAppID(in.ID),
+		UserID:     apitypes.FormatAppID(in.UserID),
+		Kind:       string(in.Kind),
+		Name:       in.Name,
+		Role:       in.Role,
+		Scopes:     scopes,
+		Prefix:     in.Prefix,
+		CreatedAt:  in.CreatedAt,
+		ExpiresAt:  in.ExpiresAt,
+		LastUsedAt: in.LastUsedAt,
+		LastUsedIP: in.LastUsedIP,
+		RevokedAt:  in.RevokedAt,
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+func TestAPITokenEndpoints(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	store := auth.NewStore(db)
+	user, err := store.CreateUser(context.Background(), "ci-owner@example.test", "correct horse battery staple", auth.RoleAdmin)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	service := auth.NewService(store, []byte("test-session-secret"), time.Hour, 2*time.Hour, true)
+	sessionActor := auth.Actor{ID: user.ID, Type: auth.ActorTypeOperator, Email: user.Email, Role: user.Role, Authenticated: true}
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator: auth.NewTokenAuthenticator(service, staticAuthenticator{actor: sessionActor}),
+		AuthService:   service,
+	})
+	serve := func(method, path, body, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodPost, "/api/tokens", `{"name":"ci","scopes":["read_sites","manage_backups","queue_jobs"],"expires_in_days":30}`, "")
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create token status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var created apitypes.CreateAPITokenResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode created token: %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, auth.APITokenPrefix) || created.Token.Kind != string(auth.TokenKindPersonal) {
+		t.Fatalf("created token = %+v, want a personal token with a %s secret", created, auth.APITokenPrefix)
+	}
+
+	if res := serve(http.MethodGet, "/api/sites", "", created.Secret); res.Code != http.StatusOK {
+		t.Fatalf("token GET /api/sites status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/providers", "", created.Secret); res.Code != http.StatusForbidden {
+		t.Fatalf("out-of-scope GET /api/providers status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+	if res := serve(http.MethodPost, "/api/tokens", `{"name":"nested"}`, created.Secret); res.Code != http.StatusForbidden {
+		t.Fatalf("token creating token status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+	if res := serve(http.MethodPost, "/api/tokens", `{"name":"bad","scopes":["not_a_capability"]}`, ""); res.Code != http.StatusBadRequest {
+		t.Fatalf("unknown scope status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+
+	res = serve(http.MethodGet, "/api/tokens", "", "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("list tokens status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var tokens []apitypes.APIToken
+	if err := json.Unmarshal(res.Body.Bytes(), &tokens); err != nil {
+		t.Fatalf("decode tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != created.Token.ID || tokens[0].LastUsedAt == "" {
+		t.Fatalf("tokens = %+v, want %s with last use recorded", tokens, created.Token.ID)
+	}
+
+	if res := serve(http.MethodDelete, "/api/tokens/"+created.Token.ID, "", created.Secret); res.Code != http.StatusOK {
+		t.Fatalf("revoke token status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/sites", "", created.Secret); res.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked token status = %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+	if res := serve(http.MethodDelete, "/api/tokens/"+testPublicID(960), "", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("unknown token status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+
+	activities, _, err := activity.NewStore(db).List(context.Background(), activity.ListFilter{Category: activity.CategorySecurity, Limit: 10})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	actors := map[activity.EventType]activity.ActorType{}
+	for _, entry := range activities {
+		actors[entry.EventType] = entry.ActorType
+	}
+	if actors[activity.EventSecurityAPIKeyCreated] != activity.ActorUser || actors[activity.EventSecurityAPIKeyRevoked] != activity.ActorAPI {
+		t.Fatalf("security activity actors = %v, want user-created and API-revoked token", actors)
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+)
+
+// maxUptimeChecksPerRequest caps one page of the probe time series.
+const maxUptimeChecksPerRequest = 1000
+
+// uptimeWindows are the trailing windows uptime is reported over, keyed by
+// the name the API uses for them.
+var uptimeWindows = []struct {
+	name   string
+	length time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+func uptimeWindowLength(name string) (time.Duration, bool) {
+	for _, window := range uptimeWindows {
+		if window.name == name {
+			return window.length, true
+		}
+	}
+	return 0, false
+}
+
+// routeUptime serves /api/sites/{id}/uptime and its sub-resources.
+func (sh *sitesHandler) routeUptime(w http.ResponseWriter, r *http.Request, siteID string, sub string) {
+	if sh.uptimeStore == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := sh.store.GetByID(r.Context(), siteID); err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	switch {
+	case sub == "" && r.Method == http.MethodGet:
+		sh.handleGetUptime(w, r, siteID)
+	case sub == "" && r.Method == http.MethodPut:
+		sh.handleUpdateUptime(w, r, siteID)
+	case sub == "checks" && r.Method == http.MethodGet:
+		sh.handleListUptimeChecks(w, r, siteID)
+	case sub == "incidents" && r.Method == http.MethodGet:
+		sh.handleListUptimeIncidents(w, r, siteID)
+	case sub == "" || sub == "checks" || sub == "incidents":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (sh *sitesHandler) handleGetUptime(w http.ResponseWriter, r *http.Request, siteID string) {
+	response, err := sh.siteUptime(r, siteID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load site uptime: "+err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+func (sh *sitesHandler) handleUpdateUptime(w http.ResponseWriter, r *http.Request, siteID string) {
+	var req apitypes.UpdateUptimeSettingsRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	current, err := sh.uptimeStore.GetSettings(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to load uptime settings: "+err.Error())
+		return
+	}
+	enabled := current.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	if _, err := sh.uptimeStore.SaveSettings(r.Context(), siteID, enabled, req.Keyword); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to save uptime settings: "+err.Error())
+		return
+	}
+	sh.handleGetUptime(w, r, siteID)
+}
+
+func (sh *sitesHandler) handleListUptimeChecks(w http.ResponseWriter, r *http.Request, siteID string) {
+	query := r.URL.Query()
+	window := strings.TrimSpace(query.Get("window"))
+	if window == "" {
+		window = "24h"
+	}
+	length, ok := uptimeWindowLength(window)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "window must be 24h, 7d or 30d")
+		return
+	}
+	limit := 0
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = min(parsed, maxUptimeChecksPerRequest)
+	}
+	checks, err := sh.uptimeStore.ListChecks(r.Context(), siteID, query.Get("hostname"), time.Now().UTC().Add(-length), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list uptime checks: "+err.Error())
+		return
+	}
+	out := make([]apitypes.UptimeCheck, 0, len(checks))
+	for _, check := range checks {
+		out = append(out, apiUptimeCheck(check))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (sh *sitesHandler) handleListUptimeIncidents(w http.ResponseWriter, r *http.Request, siteID string) {
+	incidents, err := sh.uptimeStore.ListIncidents(r.Context(), siteID, 0)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list uptime incidents: "+err.Error())
+		return
+	}
+	out := make([]apitypes.UptimeIncident, 0, len(incidents))
+	for _, incident := range incidents {
+		out = append(out, apiUptimeIncident(incident))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+// siteUptime builds the uptime overview: settings, per-hostname windows and
+// the last probe, and the incidents still open.
+func (sh *sitesHandler) siteUptime(r *http.Request, siteID string) (apitypes.SiteUptimeResponse, error) {
+	ctx := r.Context()
+	settings, err := sh.uptimeStore.GetSettings(ctx, siteID)
+	if err != nil {
+		return apitypes.SiteUptimeResponse{}, err
+	}
+	response := apitypes.SiteUptimeResponse{
+		SiteID:        apitypes.FormatAppID(siteID),
+		Enabled:       settings.Enabled,
+		Keyword:       settings.Keyword,
+		Hostnames:     make([]apitypes.UptimeHostname, 0),
+		OpenIncidents: make([]apitypes.UptimeIncident, 0),
+	}
+
+	now := time.Now().UTC()
+	byHostname := map[string]int{}
+	for _, window := range uptimeWindows {
+		summaries, err := sh.uptimeStore.Summaries(ctx, siteID, now.Add(-window.length))
+		if err != nil {
+			return apitypes.SiteUptimeResponse{}, err
+		}
+		for _, summary := range summaries {
+			i, ok := byHostname[summary.Hostname]
+			if !ok {
+				i = len(response.Hostnames)
+				byHostname[summary.Hostname] = i
+				response.Hostnames = append(response.Hostnames, apitypes.UptimeHostname{Hostname: summary.Hostname})
+			}
+			response.Hostnames[i].Windows = append(response.Hostnames[i].Windows, apitypes.UptimeWindow{
+				Window:        window.name,
+				Checks:        summary.Checks,
+				UptimePercent: summary.UptimePercent,
+				AvgLatencyMS:  summary.AvgLatencyMS,
+			})
+		}
+	}
+	for i := range response.Hostnames {
+		entry := &response.Hostnames[i]
+		latest, err := sh.uptimeStore.ListChecks(ctx, siteID, entry.Hostname, time.Time{}, 1)
+		if err != nil {
+			return apitypes.SiteUptimeResponse{}, err
+		}
+		if len(latest) == 1 {
+			check := apiUptimeCheck(latest[0])
+			entry.LastCheck = &check
+		}
+	}
+
+	incidents, err := sh.uptimeStore.ListIncidents(ctx, siteID, 0)
+	if err != nil {
+		return apitypes.SiteUptimeResponse{}, err
+	}
+	for _, incident := range incidents {
+		if incident.ResolvedAt == "" {
+			response.OpenIncidents = append(response.OpenIncidents, apiUptimeIncident(incident))
+		}
+	}
+	return response, nil
+}
+
+func apiUptimeCheck(check StoredUptimeCheck) apitypes.UptimeCheck {
+	return apitypes.UptimeCheck{
+		Hostname:     check.Hostname,
+		CheckedAt:    check.CheckedAt,
+		Up:           check.Up,
+		StatusCode:   check.StatusCode,
+		LatencyMS:    check.LatencyMS,
+		TLSExpiresAt: check.TLSExpiresAt,
+		KeywordMatch: check.KeywordMatch,
+		Error:        check.Error,
+	}
+}
+
+func apiUptimeIncident(incident StoredUptimeIncident) apitypes.UptimeIncident {
+	return apitypes.UptimeIncident{
+		ID:         apitypes.FormatAppID(incident.ID),
+		Hostname:   incident.Hostname,
+		Cause:      incident.Cause,
+		StartedAt:  incident.StartedAt,
+		ResolvedAt: incident.ResolvedAt,
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/apitypes"
+)
+
+func TestUptimeEndpointsReportChecksAndIncidents(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	siteID, err := NewSiteStore(db).Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	store := NewUptimeStore(db)
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, up := range []bool{true, true, true, false} {
+		in := UptimeCheckInput{SiteID: siteID, Hostname: "shop.example.test", CheckedAt: now.Add(time.Duration(i-4) * time.Minute), Up: up, StatusCode: http.StatusOK, Latency: 120 * time.Millisecond}
+		if !up {
+			in.StatusCode = http.StatusBadGateway
+			in.Error = "unexpected HTTPS status 502"
+		}
+		if err := store.RecordCheck(context.Background(), in); err != nil {
+			t.Fatalf("record check: %v", err)
+		}
+	}
+	if _, err := store.OpenIncident(context.Background(), siteID, "shop.example.test", "unexpected HTTPS status 502", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("open incident: %v", err)
+	}
+
+	handler := NewHandler(db)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	uptimePath := "/api/sites/" + siteID + "/uptime"
+
+	res := serve(http.MethodGet, uptimePath, "")
+	var overview apitypes.SiteUptimeResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &overview); err != nil {
+		t.Fatalf("decode uptime: %v; body = %s", err, res.Body.String())
+	}
+	if !overview.Enabled || len(overview.Hostnames) != 1 || len(overview.OpenIncidents) != 1 {
+		t.Fatalf("uptime = %+v, want one probed hostname and one open incident", overview)
+	}
+	hostname := overview.Hostnames[0]
+	if len(hostname.Windows) != 3 || hostname.Windows[0].Window != "24h" || hostname.Windows[0].UptimePercent != 75 || hostname.Windows[0].AvgLatencyMS != 120 {
+		t.Fatalf("windows = %+v, want 75%% uptime over 24h", hostname.Windows)
+	}
+	if hostname.LastCheck == nil || hostname.LastCheck.Up || hostname.LastCheck.StatusCode != http.StatusBadGateway {
+		t.Fatalf("last check = %+v, want the failed probe", hostname.LastCheck)
+	}
+
+	res = serve(http.MethodGet, uptimePath+"/checks?limit=2", "")
+	var checks []apitypes.UptimeCheck
+	if err := json.Unmarshal(res.Body.Bytes(), &checks); err != nil || len(checks) != 2 || checks[0].Up {
+		t.Fatalf("checks status = %d, body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, uptimePath+"/checks?window=1y", ""); res.Code != http.StatusBadRequest {
+		t.Fatalf("unknown window status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+
+	res = serve(http.MethodPut, uptimePath, `{"keyword":"Add to cart"}`)
+	if err := json.Unmarshal(res.Body.Bytes(), &overview); err != nil || overview.Keyword != "Add to cart" || !overview.Enabled {
+		t.Fatalf("update status = %d, body = %s", res.Code, res.Body.String())
+	}
+	res = serve(http.MethodPut, uptimePath, `{"enabled":false,"keyword":"Add to cart"}`)
+	if err := json.Unmarshal(res.Body.Bytes(), &overview); err != nil || overview.Enabled {
+		t.Fatalf("disable status = %d, body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodPut, uptimePath, `{"keyword":"`+strings.Repeat("x", 201)+`"}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("long keyword status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+
+	res = serve(http.MethodGet, uptimePath+"/incidents", "")
+	var incidents []apitypes.UptimeIncident
+	if err := json.Unmarshal(res.Body.Bytes(), &incidents); err != nil || len(incidents) != 1 || incidents[0].ResolvedAt != "" {
+		t.Fatalf("incidents status = %d, body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/sites/"+testPublicID(990)+"/uptime", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("unknown site status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+}
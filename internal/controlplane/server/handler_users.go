@@ -0,0 +1,371 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+type usersHandler struct {
+	store         *auth.Store
+	siteStore     *SiteStore
+	activityStore *activity.Store
+	// service is nil when the handler runs without session auth; account
+	// management routes then answer 404.
+	service *auth.Service
+	// publicURL prefixes invitation and password reset links. Without it
+	// the links are relative to the dashboard.
+	publicURL string
+}
+
+func (uh *usersHandler) route(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/users" || uh.service == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		uh.handleList(w, r)
+	case http.MethodPost:
+		uh.handleInvite(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (uh *usersHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
+	tail := strings.TrimPrefix(r.URL.Path, "/api/users/")
+	parts := strings.Split(strings.Trim(tail, "/"), "/")
+	if len(parts) > 2 || strings.TrimSpace(parts[0]) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	userID, err := apitypes.ParseAppID(parts[0])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if len(parts) == 1 {
+		if uh.service == nil {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			uh.handleGet(w, r, userID)
+		case http.MethodPatch:
+			uh.handleUpdate(w, r, userID)
+		case http.MethodDelete:
+			uh.handleDelete(w, r, userID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch parts[1] {
+	case "invitation", "password-reset":
+		if uh.service == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if parts[1] == "invitation" {
+			uh.handleReissueInvitation(w, r, userID)
+		} else {
+			uh.handleIssuePasswordReset(w, r, userID)
+		}
+	case "site-grants":
+		switch r.Method {
+		case http.MethodGet:
+			uh.handleGetSiteGrants(w, r, userID)
+		case http.MethodPut:
+			uh.handleUpdateSiteGrants(w, r, userID)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "totp":
+		if uh.service == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		uh.handleResetTOTP(w, r, userID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (uh *usersHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	users, err := uh.service.ListUsers(r.Context(), auth.ActorFromContext(r.Context()))
+	if err != nil {
+		respondUserError(w, err, "failed to list users")
+		return
+	}
+	out := make([]apitypes.User, 0, len(users))
+	for _, user := range users {
+		out = append(out, apiUser(&user))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (uh *usersHandler) handleGet(w http.ResponseWriter, r *http.Request, userID string) {
+	user, err := uh.store.GetWorkspaceUser(r.Context(), auth.ActorFromContext(r.Context()).Workspace(), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to load user")
+		return
+	}
+	respondJSON(w, http.StatusOK, apiUser(user))
+}
+
+func (uh *usersHandler) handleInvite(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.InviteUserRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, token, err := uh.service.InviteUser(r.Context(), auth.ActorFromContext(r.Context()), auth.InviteUserInput{
+		Email:    req.Email,
+		Role:     req.Role,
+		Lifetime: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		respondUserError(w, err, "failed to invite user")
+		return
+	}
+	uh.emitActivity(r, activity.EventSecurityUserInvited, activity.LevelInfo, user, fmt.Sprintf("%s invited as %s", user.Email, user.Role), fmt.Sprintf("The invitation expires at %s.", token.ExpiresAt.Format(time.RFC3339)))
+	respondJSON(w, http.StatusCreated, uh.accountLink(user, token, "/invite"))
+}
+
+func (uh *usersHandler) handleReissueInvitation(w http.ResponseWriter, r *http.Request, userID string) {
+	user, token, err := uh.service.ReissueInvitation(r.Context(), auth.ActorFromContext(r.Context()), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to reissue invitation")
+		return
+	}
+	uh.emitActivity(r, activity.EventSecurityUserInvited, activity.LevelInfo, user, fmt.Sprintf("Invitation for %s reissued", user.Email), "Earlier invitation links no longer work.")
+	respondJSON(w, http.StatusOK, uh.accountLink(user, token, "/invite"))
+}
+
+func (uh *usersHandler) handleIssuePasswordReset(w http.ResponseWriter, r *http.Request, userID string) {
+	user, token, err := uh.service.IssuePasswordReset(r.Context(), auth.ActorFromContext(r.Context()), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to issue password reset")
+		return
+	}
+	uh.emitActivity(r, activity.EventSecurityPasswordResetIssued, activity.LevelWarning, user, fmt.Sprintf("Password reset issued for %s", user.Email), fmt.Sprintf("The reset link expires at %s.", token.ExpiresAt.Format(time.RFC3339)))
+	respondJSON(w, http.StatusOK, uh.accountLink(user, token, "/reset-password"))
+}
+
+func (uh *usersHandler) handleUpdate(w http.ResponseWriter, r *http.Request, userID string) {
+	var req apitypes.UpdateUserRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before, err := uh.store.GetWorkspaceUser(r.Context(), auth.ActorFromContext(r.Context()).Workspace(), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to load user")
+		return
+	}
+	user, err := uh.service.UpdateUser(r.Context(), auth.ActorFromContext(r.Context()), userID, auth.UpdateUserInput{Role: req.Role, Status: req.Status})
+	if err != nil {
+		respondUserError(w, err, "failed to update user")
+		return
+	}
+	if user.Role != before.Role {
+		uh.emitActivity(r, activity.EventSecurityUserRoleChanged, activity.LevelInfo, user, fmt.Sprintf("%s is now %s", user.Email, user.Role), fmt.Sprintf("Role changed from %s to %s.", before.Role, user.Role))
+	}
+	if user.Status != before.Status {
+		switch user.Status {
+		case auth.UserStatusSuspended:
+			uh.emitActivity(r, activity.EventSecurityUserSuspended, activity.LevelWarning, user, fmt.Sprintf("%s suspended", user.Email), "All sessions of this user were signed out.")
+		case auth.UserStatusActive:
+			uh.emitActivity(r, activity.EventSecurityUserReactivated, activity.LevelInfo, user, fmt.Sprintf("%s reactivated", user.Email), "")
+		}
+	}
+	respondJSON(w, http.StatusOK, apiUser(user))
+}
+
+func (uh *usersHandler) handleDelete(w http.ResponseWriter, r *http.Request, userID string) {
+	user, err := uh.service.DeleteUser(r.Context(), auth.ActorFromContext(r.Context()), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to delete user")
+		return
+	}
+	uh.emitActivity(r, activity.EventSecurityUserDeleted, activity.LevelWarning, user, fmt.Sprintf("%s deleted", user.Email), "Their sessions, API tokens and site grants were removed with the account.")
+	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
+}
+
+// accountLink builds the one-time link the admin hands to the user; page is
+// the dashboard page that redeems it.
+func (uh *usersHandler) accountLink(user *auth.User, token auth.AccountToken, page string) apitypes.UserAccountLink {
+	link := strings.TrimRight(uh.publicURL, "/") + page + "?" + url.Values{"token": {token.Token}}.Encode()
+	return apitypes.UserAccountLink{
+		User:      apiUser(user),
+		Token:     token.Token,
+		URL:       link,
+		ExpiresAt: token.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+func (uh *usersHandler) emitActivity(r *http.Request, eventType activity.EventType, level activity.Level, user *auth.User, title, message string) {
+	if uh.activityStore == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	_, _ = uh.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategorySecurity,
+		Level:        level,
+		ResourceType: activity.ResourceAccount,
+		ResourceID:   user.ID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        title,
+		Message:      message,
+	})
+}
+
+// handleResetTOTP removes a user's second factor so they can enroll again,
+// for when both their device and their recovery codes are lost.
+func (uh *usersHandler) handleResetTOTP(w http.ResponseWriter, r *http.Request, userID string) {
+	user, err := uh.service.ResetTOTP(r.Context(), auth.ActorFromContext(r.Context()), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to reset two-factor authentication")
+		return
+	}
+	if uh.activityStore != nil {
+		actorType, actorID := activityActorFromRequest(r)
+		_, _ = uh.activityStore.Emit(r.Context(), activity.EmitInput{
+			EventType:         activity.EventSecurityTOTPDisabled,
+			Category:          activity.CategorySecurity,
+			Level:             activity.LevelWarning,
+			ResourceType:      activity.ResourceAccount,
+			ResourceID:        user.ID,
+			ActorType:         actorType,
+			ActorID:           actorID,
+			Title:             fmt.Sprintf("Two-factor authentication reset for %s", user.Email),
+			RequiresAttention: true,
+		})
+	}
+	respondJSON(w, http.StatusOK, apitypes.StatusResponse{Status: "ok"})
+}
+
+func (uh *usersHandler) handleGetSiteGrants(w http.ResponseWriter, r *http.Request, userID string) {
+	if _, err := uh.store.GetWorkspaceUser(r.Context(), auth.ActorFromContext(r.Context()).Workspace(), userID); err != nil {
+		respondUserError(w, err, "failed to load user")
+		return
+	}
+	siteIDs, err := uh.store.ListSiteGrants(r.Context(), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to list site grants")
+		return
+	}
+	respondJSON(w, http.StatusOK, apiSiteGrants(userID, siteIDs))
+}
+
+func (uh *usersHandler) handleUpdateSiteGrants(w http.ResponseWriter, r *http.Request, userID string) {
+	var req apitypes.UpdateSiteGrantsRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := uh.store.GetWorkspaceUser(r.Context(), auth.ActorFromContext(r.Context()).Workspace(), userID)
+	if err != nil {
+		respondUserError(w, err, "failed to load user")
+		return
+	}
+	for _, siteID := range req.SiteIDs {
+		if _, err := uh.siteStore.GetByID(r.Context(), siteID); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("site %s not found", apitypes.FormatAppID(siteID)))
+			return
+		}
+	}
+
+	siteIDs, err := uh.store.ReplaceSiteGrants(r.Context(), user.ID, req.SiteIDs)
+	if err != nil {
+		respondUserError(w, err, "failed to update site grants")
+		return
+	}
+	uh.emitSiteGrantsActivity(r, user, siteIDs)
+	respondJSON(w, http.StatusOK, apiSiteGrants(user.ID, siteIDs))
+}
+
+func (uh *usersHandler) emitSiteGrantsActivity(r *http.Request, user *auth.User, siteIDs []string) {
+	if uh.activityStore == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	_, _ = uh.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    activity.EventSecuritySiteGrantsUpdated,
+		Category:     activity.CategorySecurity,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceAccount,
+		ResourceID:   user.ID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        fmt.Sprintf("Site access updated for %s", user.Email),
+		Message:      fmt.Sprintf("%s now has %d site grant(s).", user.Email, len(siteIDs)),
+	})
+}
+
+func respondUserError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		respondError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, auth.ErrInvalidUser), errors.Is(err, auth.ErrInvalidPassword):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrUserExists), errors.Is(err, auth.ErrLastOwner):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		respondError(w, http.StatusForbidden, "forbidden")
+	default:
+		slog.Default().Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func apiUser(in *auth.User) apitypes.User {
+	return apitypes.User{
+		ID:          apitypes.FormatAppID(in.ID),
+		Email:       in.Email,
+		Role:        in.Role,
+		Status:      in.Status,
+		CreatedAt:   in.CreatedAt,
+		UpdatedAt:   in.UpdatedAt,
+		LastLoginAt: in.LastLoginAt,
+	}
+}
+
+func apiSiteGrants(userID string, siteIDs []string) apitypes.SiteGrants {
+	out := apitypes.SiteGrants{UserID: apitypes.FormatAppID(userID), SiteIDs: make([]string, 0, len(siteIDs))}
+	for _, siteID := range siteIDs {
+		out.SiteIDs = append(out.SiteIDs, apitypes.FormatAppID(siteID))
+	}
+	return out
+}
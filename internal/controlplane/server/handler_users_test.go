@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+)
+
+func TestUserSiteGrantsEndpoints(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	_, providerID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerID, "ready")
+	siteID := mustCreateAuthorizationTestSite(t, db, serverID, "Client Site")
+	user, err := auth.NewStore(db).CreateUser(context.Background(), "client@example.test", "correct horse battery staple", auth.RoleClient)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	handler := NewHandler(db)
+	grantsPath := "/api/users/" + user.ID + "/site-grants"
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, grantsPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := put(`{"site_ids":["` + siteID + `"]}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("put grants status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	getRes := httptest.NewRecorder()
+	handler.ServeHTTP(getRes, httptest.NewRequest(http.MethodGet, grantsPath, nil))
+	if getRes.Code != http.StatusOK {
+		t.Fatalf("get grants status = %d; body = %s", getRes.Code, getRes.Body.String())
+	}
+	var grants apitypes.SiteGrants
+	if err := json.Unmarshal(getRes.Body.Bytes(), &grants); err != nil {
+		t.Fatalf("decode grants: %v", err)
+	}
+	if grants.UserID != user.ID || len(grants.SiteIDs) != 1 || grants.SiteIDs[0] != siteID {
+		t.Fatalf("grants = %+v, want %s on %s", grants, user.ID, siteID)
+	}
+
+	activities, _, err := activity.NewStore(db).List(context.Background(), activity.ListFilter{Category: activity.CategorySecurity, Limit: 10})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(activities) != 1 || activities[0].EventType != activity.EventSecuritySiteGrantsUpdated {
+		t.Fatalf("security activity = %+v, want one site grants update", activities)
+	}
+
+	if res := put(`{"site_ids":["` + testPublicID(950) + `"]}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("unknown site status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := put(`{"site_ids":["not-an-id"]}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("invalid site id status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+
+	missingReq := httptest.NewRequest(http.MethodGet, "/api/users/"+testPublicID(951)+"/site-grants", nil)
+	missingRes := httptest.NewRecorder()
+	handler.ServeHTTP(missingRes, missingReq)
+	if missingRes.Code != http.StatusNotFound {
+		t.Fatalf("unknown user status = %d, want %d", missingRes.Code, http.StatusNotFound)
+	}
+
+	if res := put(`{"site_ids":[]}`); res.Code != http.StatusOK {
+		t.Fatalf("clear grants status = %d; body = %s", res.Code, res.Body.String())
+	}
+	siteIDs, err := auth.NewStore(db).ListSiteGrants(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("list grants: %v", err)
+	}
+	if len(siteIDs) != 0 {
+		t.Fatalf("grants after clear = %v, want none", siteIDs)
+	}
+}
+
+func TestUserInvitationAndAccountEndpoints(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	store := auth.NewStore(db)
+	if _, err := store.CreateUser(context.Background(), "owner@agency.test", "correct horse battery staple", auth.RoleOwner); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	service := auth.NewService(store, []byte("test-session-secret"), time.Hour, 2*time.Hour, false)
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator:   auth.NewSessionAuthenticator(service),
+		AuthService:     service,
+		ControlPlaneURL: "https://panel.example.test/",
+	})
+
+	serve := func(method, target, body string, session *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if session != nil {
+			req.AddCookie(session)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	sessionCookie := func(res *httptest.ResponseRecorder) *http.Cookie {
+		t.Helper()
+		for _, cookie := range res.Result().Cookies() {
+			if cookie.Name == auth.SessionCookieName {
+				return cookie
+			}
+		}
+		t.Fatalf("no session cookie; status = %d body = %s", res.Code, res.Body.String())
+		return nil
+	}
+
+	owner := sessionCookie(serve(http.MethodPost, "/api/auth/login", `{"email":"owner@agency.test","password":"correct horse battery staple"}`, nil))
+
+	res := serve(http.MethodPost, "/api/users", `{"email":"dev@agency.test","role":"developer"}`, owner)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("invite status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var invitation apitypes.UserAccountLink
+	if err := json.Unmarshal(res.Body.Bytes(), &invitation); err != nil {
+		t.Fatalf("decode invitation: %v", err)
+	}
+	if invitation.User.Status != auth.UserStatusInvited || invitation.URL != "https://panel.example.test/invite?token="+invitation.Token {
+		t.Fatalf("invitation = %+v, want invited user with panel link", invitation)
+	}
+	if res := serve(http.MethodPost, "/api/users", `{"email":"dev@agency.test","role":"viewer"}`, owner); res.Code != http.StatusConflict {
+		t.Fatalf("duplicate invite status = %d, want %d", res.Code, http.StatusConflict)
+	}
+
+	if res := serve(http.MethodPost, "/api/auth/invitations/accept", `{"token":"`+invitation.Token+`","password":"short"}`, nil); res.Code != http.StatusBadRequest {
+		t.Fatalf("weak password status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	res = serve(http.MethodPost, "/api/auth/invitations/accept", `{"token":"`+invitation.Token+`","password":"a much longer passphrase"}`, nil)
+	if res.Code != http.StatusOK {
+		t.Fatalf("accept status = %d; body = %s", res.Code, res.Body.String())
+	}
+	developer := sessionCookie(res)
+
+	res = serve(http.MethodGet, "/api/users", "", owner)
+	var users []apitypes.User
+	if err := json.Unmarshal(res.Body.Bytes(), &users); err != nil {
+		t.Fatalf("decode users: %v", err)
+	}
+	if len(users) != 2 || users[0].Email != "dev@agency.test" || users[0].Status != auth.UserStatusActive {
+		t.Fatalf("users = %+v, want active developer and owner", users)
+	}
+	if res := serve(http.MethodGet, "/api/users", "", developer); res.Code != http.StatusForbidden {
+		t.Fatalf("developer list users status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+
+	res = serve(http.MethodGet, "/api/users/me/sessions", "", developer)
+	var sessions []apitypes.UserSession
+	if err := json.Unmarshal(res.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("decode sessions: %v", err)
+	}
+	if len(sessions) != 1 || !sessions[0].Current || sessions[0].AuthMethod != "password" {
+		t.Fatalf("sessions = %+v, want the current password session", sessions)
+	}
+	if res := serve(http.MethodPost, "/api/users/me/password", `{"current_password":"wrong","new_password":"another long passphrase"}`, developer); res.Code != http.StatusBadRequest {
+		t.Fatalf("wrong current password status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := serve(http.MethodPost, "/api/users/me/password", `{"current_password":"a much longer passphrase","new_password":"another long passphrase"}`, developer); res.Code != http.StatusOK {
+		t.Fatalf("change password status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	userPath := "/api/users/" + invitation.User.ID
+	if res := serve(http.MethodDelete, "/api/users/"+users[1].ID, "", owner); res.Code != http.StatusBadRequest {
+		t.Fatalf("delete self status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := serve(http.MethodPatch, userPath, `{"status":"suspended"}`, owner); res.Code != http.StatusOK {
+		t.Fatalf("suspend status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/auth/me", "", developer); res.Code != http.StatusUnauthorized {
+		t.Fatalf("suspended session status = %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+	if res := serve(http.MethodPost, userPath+"/password-reset", "", owner); res.Code != http.StatusBadRequest {
+		t.Fatalf("reset for suspended user status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := serve(http.MethodPatch, userPath, `{"status":"active","role":"viewer"}`, owner); res.Code != http.StatusOK {
+		t.Fatalf("reactivate status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	res = serve(http.MethodPost, userPath+"/password-reset", "", owner)
+	var reset apitypes.UserAccountLink
+	if err := json.Unmarshal(res.Body.Bytes(), &reset); err != nil {
+		t.Fatalf("decode reset: %v", err)
+	}
+	if res := serve(http.MethodPost, "/api/auth/password/reset", `{"token":"`+reset.Token+`","password":"a reset passphrase"}`, nil); res.Code != http.StatusOK {
+		t.Fatalf("reset password status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodPost, "/api/auth/password/reset", `{"token":"`+reset.Token+`","password":"a reset passphrase"}`, nil); res.Code != http.StatusBadRequest {
+		t.Fatalf("replayed reset status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	viewer := sessionCookie(serve(http.MethodPost, "/api/auth/login", `{"email":"dev@agency.test","password":"a reset passphrase"}`, nil))
+	if res := serve(http.MethodDelete, "/api/users/me/sessions", "", viewer); res.Code != http.StatusOK {
+		t.Fatalf("revoke other sessions status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	if res := serve(http.MethodDelete, userPath, "", owner); res.Code != http.StatusOK {
+		t.Fatalf("delete status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, userPath, "", owner); res.Code != http.StatusNotFound {
+		t.Fatalf("deleted user status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+
+	activities, _, err := activity.NewStore(db).List(context.Background(), activity.ListFilter{Category: activity.CategorySecurity, Limit: 50})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	seen := map[activity.EventType]bool{}
+	for _, entry := range activities {
+		seen[entry.EventType] = true
+	}
+	for _, event := range []activity.EventType{
+		activity.EventSecurityUserInvited,
+		activity.EventSecurityInvitationAccepted,
+		activity.EventSecurityPasswordChanged,
+		activity.EventSecurityUserSuspended,
+		activity.EventSecurityUserReactivated,
+		activity.EventSecurityUserRoleChanged,
+		activity.EventSecurityPasswordResetIssued,
+		activity.EventSecurityUserDeleted,
+	} {
+		if !seen[event] {
+			t.Errorf("missing %s activity", event)
+		}
+	}
+}
+
+func TestNotificationPreferencesEndpoints(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	store := auth.NewStore(db)
+	if _, err := store.CreateUser(context.Background(), "owner@agency.test", "correct horse battery staple", auth.RoleOwner); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	service := auth.NewService(store, []byte("test-session-secret"), time.Hour, 2*time.Hour, false)
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator:   auth.NewSessionAuthenticator(service),
+		AuthService:     service,
+		EmailConfigured: true,
+	})
+	serve := func(method, body string, session *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/users/me/notifications", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if session != nil {
+			req.AddCookie(session)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	login := httptest.NewRecorder()
+	handler.ServeHTTP(login, httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"email":"owner@agency.test","password":"correct horse battery staple"}`)))
+	var owner *http.Cookie
+	for _, cookie := range login.Result().Cookies() {
+		if cookie.Name == auth.SessionCookieName {
+			owner = cookie
+		}
+	}
+	if owner == nil {
+		t.Fatalf("no session cookie; status = %d body = %s", login.Code, login.Body.String())
+	}
+
+	res := serve(http.MethodGet, "", owner)
+	var prefs apitypes.NotificationPreferences
+	if err := json.Unmarshal(res.Body.Bytes(), &prefs); err != nil {
+		t.Fatalf("decode preferences: %v; body = %s", err, res.Body.String())
+	}
+	if prefs.EmailEnabled || prefs.Delivery != "immediate" || !prefs.EmailConfigured || len(prefs.EventTypes) == 0 || len(prefs.AvailableEventTypes) < len(prefs.EventTypes) {
+		t.Fatalf("default preferences = %+v", prefs)
+	}
+
+	res = serve(http.MethodPut, `{"email_enabled":true,"event_types":["job.failed"],"delivery":"daily"}`, owner)
+	if res.Code != http.StatusOK {
+		t.Fatalf("update status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &prefs); err != nil {
+		t.Fatalf("decode preferences: %v", err)
+	}
+	if !prefs.EmailEnabled || prefs.Delivery != "daily" || len(prefs.EventTypes) != 1 || prefs.EventTypes[0] != activity.EventJobFailed {
+		t.Fatalf("updated preferences = %+v", prefs)
+	}
+
+	if res := serve(http.MethodPut, `{"email_enabled":true,"event_types":["job.succeeded"]}`, owner); res.Code != http.StatusBadRequest {
+		t.Fatalf("unknown event status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := serve(http.MethodPut, `{"email_enabled":true,"event_types":["job.failed"],"delivery":"weekly"}`, owner); res.Code != http.StatusBadRequest {
+		t.Fatalf("unknown delivery status = %d, want %d", res.Code, http.StatusBadRequest)
+	}
+	if res := serve(http.MethodGet, "", nil); res.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d, want %d", res.Code, http.StatusUnauthorized)
+	}
+}
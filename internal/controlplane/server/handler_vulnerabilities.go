@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/vulnscan"
+)
+
+func (sh *sitesHandler) handleListVulnerabilities(w http.ResponseWriter, r *http.Request, siteID string) {
+	if _, err := sh.store.GetByID(r.Context(), siteID); err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	vulnerabilities, err := sh.vulnerabilityStore.ListBySite(r.Context(), siteID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list site vulnerabilities: "+err.Error())
+		return
+	}
+	response := apitypes.SiteVulnerabilitiesResponse{
+		SiteID:          apitypes.FormatAppID(siteID),
+		Vulnerabilities: make([]apitypes.SiteVulnerability, 0, len(vulnerabilities)),
+	}
+	for _, vulnerability := range vulnerabilities {
+		response.ScannedAt = vulnerability.ScannedAt
+		response.Vulnerabilities = append(response.Vulnerabilities, apiSiteVulnerability(vulnerability))
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// handleVulnerabilityReport serves GET /api/vulnerabilities.
+func (sh *sitesHandler) handleVulnerabilityReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	vulnerabilities, err := sh.vulnerabilityStore.ListAll(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to list vulnerabilities: "+err.Error())
+		return
+	}
+	report := apitypes.VulnerabilityReport{
+		Vulnerabilities: make([]apitypes.SiteVulnerability, 0, len(vulnerabilities)),
+	}
+	sites := map[string]bool{}
+	for _, vulnerability := range vulnerabilities {
+		sites[vulnerability.SiteID] = true
+		switch vulnerability.Severity {
+		case vulnscan.SeverityCritical:
+			report.Counts.Critical++
+		case vulnscan.SeverityHigh:
+			report.Counts.High++
+		case vulnscan.SeverityMedium:
+			report.Counts.Medium++
+		case vulnscan.SeverityLow:
+			report.Counts.Low++
+		default:
+			report.Counts.Unknown++
+		}
+		report.Vulnerabilities = append(report.Vulnerabilities, apiSiteVulnerability(vulnerability))
+	}
+	report.AffectedSites = len(sites)
+	respondJSON(w, http.StatusOK, report)
+}
+
+func apiSiteVulnerability(vulnerability StoredSiteVulnerability) apitypes.SiteVulnerability {
+	return apitypes.SiteVulnerability{
+		SiteID:           apitypes.FormatAppID(vulnerability.SiteID),
+		SiteName:         vulnerability.SiteName,
+		ServerID:         apitypes.FormatAppID(vulnerability.ServerID),
+		VulnerabilityID:  vulnerability.VulnerabilityID,
+		ComponentType:    vulnerability.ComponentType,
+		ComponentName:    vulnerability.ComponentName,
+		InstalledVersion: vulnerability.InstalledVersion,
+		Title:            vulnerability.Title,
+		Description:      vulnerability.Description,
+		Severity:         vulnerability.Severity,
+		Score:            vulnerability.Score,
+		FixedIn:          vulnerability.FixedIn,
+		References:       vulnerability.References,
+		FirstSeenAt:      vulnerability.FirstSeenAt,
+		ScannedAt:        vulnerability.ScannedAt,
+	}
+}
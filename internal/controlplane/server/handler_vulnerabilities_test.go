@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/vulnscan"
+)
+
+const vulnerabilityTestFeed = `{"plugins": {"woocommerce": {"error": 0, "data": {"name": "WooCommerce", "vulnerability": [
+	{"uuid": "woo-sqli", "name": "WooCommerce < 8.9.3 - SQL Injection",
+	 "operator": {"max_version": "8.9.3", "max_operator": "lt"},
+	 "source": [{"id": "CVE-2024-0003"}], "impact": {"cvss": {"score": "8.8", "severity": "h"}}}
+]}}}}`
+
+func TestVulnerabilityEndpointsReportScannedInventory(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	_, providerDBID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerDBID, "ready")
+	siteStore := NewSiteStore(db)
+	siteID, err := siteStore.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	feedPath := filepath.Join(t.TempDir(), "vulnerabilities.json")
+	if err := os.WriteFile(feedPath, []byte(vulnerabilityTestFeed), 0o600); err != nil {
+		t.Fatalf("write feed: %v", err)
+	}
+	activityStore := activity.NewStore(db)
+	scanner := vulnscan.NewScanner(vulnscan.NewFileSource(feedPath), siteStore, NewComponentStore(db), NewVulnerabilityStore(db), activityStore, nil)
+
+	if err := scanner.ReplaceForSite(context.Background(), siteID, []SiteComponentInput{
+		{Type: SiteComponentTypeCore, Name: "wordpress", Version: "6.8"},
+		{Type: SiteComponentTypePlugin, Name: "woocommerce", Version: "8.9.2", UpdateVersion: "8.9.3"},
+	}, time.Now()); err != nil {
+		t.Fatalf("record inventory: %v", err)
+	}
+	// A rescan of an unchanged inventory must not report the finding again.
+	if changes, err := scanner.ScanSite(context.Background(), siteID); err != nil || len(changes.New) != 0 {
+		t.Fatalf("rescan changes = %+v (err %v), want nothing new", changes, err)
+	}
+	events, _, err := activityStore.List(context.Background(), activity.ListFilter{ResourceType: activity.ResourceSite, ResourceID: siteID})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	detected := 0
+	for _, event := range events {
+		if event.EventType == activity.EventSecurityVulnerabilityDetected {
+			detected++
+			if event.Level != activity.LevelError || !event.RequiresAttention {
+				t.Fatalf("detected event = %+v, want attention-worthy error for a high severity finding", event)
+			}
+		}
+	}
+	if detected != 1 {
+		t.Fatalf("vulnerability_detected events = %d, want 1", detected)
+	}
+
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{VulnerabilityScanner: scanner})
+	siteRes := httptest.NewRecorder()
+	handler.ServeHTTP(siteRes, httptest.NewRequest(http.MethodGet, "/api/sites/"+siteID+"/vulnerabilities", nil))
+	var siteReport apitypes.SiteVulnerabilitiesResponse
+	if err := json.Unmarshal(siteRes.Body.Bytes(), &siteReport); err != nil || len(siteReport.Vulnerabilities) != 1 {
+		t.Fatalf("site vulnerabilities status = %d, body = %s", siteRes.Code, siteRes.Body.String())
+	}
+	if got := siteReport.Vulnerabilities[0]; got.VulnerabilityID != "woo-sqli" || got.FixedIn != "8.9.3" || got.Severity != vulnscan.SeverityHigh {
+		t.Fatalf("site vulnerability = %+v", got)
+	}
+
+	fleetRes := httptest.NewRecorder()
+	handler.ServeHTTP(fleetRes, httptest.NewRequest(http.MethodGet, "/api/vulnerabilities", nil))
+	var fleet apitypes.VulnerabilityReport
+	if err := json.Unmarshal(fleetRes.Body.Bytes(), &fleet); err != nil {
+		t.Fatalf("decode fleet report: %v; body = %s", err, fleetRes.Body.String())
+	}
+	if fleet.AffectedSites != 1 || fleet.Counts.High != 1 || len(fleet.Vulnerabilities) != 1 || fleet.Vulnerabilities[0].SiteName != "Shop" {
+		t.Fatalf("fleet report = %+v", fleet)
+	}
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/webhooks"
+)
+
+// webhooksHandler serves /api/webhooks: the webhook subscriptions of the
+// workspace, their delivery logs and test deliveries.
+type webhooksHandler struct {
+	store         *webhooks.Store
+	activityStore *activity.Store
+}
+
+func (wh *webhooksHandler) route(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/webhooks" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		wh.handleList(w, r)
+	case http.MethodPost:
+		wh.handleCreate(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (wh *webhooksHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
+	tail := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/webhooks/"), "/")
+	parts := strings.Split(tail, "/")
+	if tail == "" || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := apitypes.ParseAppID(parts[0])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+	if len(parts) == 2 {
+		switch {
+		case parts[1] == "deliveries" && r.Method == http.MethodGet:
+			wh.handleListDeliveries(w, r, id)
+		case parts[1] == "test" && r.Method == http.MethodPost:
+			wh.handleSendTest(w, r, id)
+		case parts[1] == "deliveries" || parts[1] == "test":
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		wh.handleGet(w, r, id)
+	case http.MethodPut:
+		wh.handleUpdate(w, r, id)
+	case http.MethodDelete:
+		wh.handleDelete(w, r, id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (wh *webhooksHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	subs, err := wh.store.List(r.Context())
+	if err != nil {
+		respondWebhookError(w, err, "failed to list webhooks")
+		return
+	}
+	out := make([]apitypes.WebhookSubscription, 0, len(subs))
+	for _, sub := range subs {
+		out = append(out, apiWebhookSubscription(sub))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (wh *webhooksHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.WebhookSubscriptionRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sub, secret, err := wh.store.Create(r.Context(), webhookInput(req))
+	if err != nil {
+		respondWebhookError(w, err, "failed to create webhook")
+		return
+	}
+	wh.emitActivity(r, activity.EventSecurityWebhookCreated, sub, fmt.Sprintf("Webhook '%s' created", sub.Name), fmt.Sprintf("Events are sent to %s.", sub.URL))
+	respondJSON(w, http.StatusCreated, apitypes.CreateWebhookSubscriptionResponse{
+		Subscription: apiWebhookSubscription(sub),
+		Secret:       secret,
+	})
+}
+
+func (wh *webhooksHandler) handleGet(w http.ResponseWriter, r *http.Request, id string) {
+	sub, err := wh.store.Get(r.Context(), id)
+	if err != nil {
+		respondWebhookError(w, err, "failed to get webhook")
+		return
+	}
+	respondJSON(w, http.StatusOK, apiWebhookSubscription(sub))
+}
+
+func (wh *webhooksHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id string) {
+	var req apitypes.WebhookSubscriptionRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sub, err := wh.store.Update(r.Context(), id, webhookInput(req))
+	if err != nil {
+		respondWebhookError(w, err, "failed to update webhook")
+		return
+	}
+	respondJSON(w, http.StatusOK, apiWebhookSubscription(sub))
+}
+
+func (wh *webhooksHandler) handleDelete(w http.ResponseWriter, r *http.Request, id string) {
+	sub, err := wh.store.Delete(r.Context(), id)
+	if err != nil {
+		respondWebhookError(w, err, "failed to delete webhook")
+		return
+	}
+	wh.emitActivity(r, activity.EventSecurityWebhookDeleted, sub, fmt.Sprintf("Webhook '%s' deleted", sub.Name), "Its delivery log was removed as well.")
+	respondJSON(w, http.StatusOK, apiWebhookSubscription(sub))
+}
+
+func (wh *webhooksHandler) handleListDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = parsed
+	}
+	deliveries, err := wh.store.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		respondWebhookError(w, err, "failed to list webhook deliveries")
+		return
+	}
+	out := make([]apitypes.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		out = append(out, apiWebhookDelivery(delivery))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+// handleSendTest queues a test delivery; the dispatcher sends it within a
+// few seconds and the outcome shows up in the delivery log.
+func (wh *webhooksHandler) handleSendTest(w http.ResponseWriter, r *http.Request, id string) {
+	delivery, err := wh.store.SendTest(r.Context(), id)
+	if err != nil {
+		respondWebhookError(w, err, "failed to queue test delivery")
+		return
+	}
+	respondJSON(w, http.StatusAccepted, apiWebhookDelivery(delivery))
+}
+
+func (wh *webhooksHandler) emitActivity(r *http.Request, eventType activity.EventType, sub webhooks.Subscription, title, message string) {
+	if wh.activityStore == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	_, _ = wh.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategorySecurity,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceWebhook,
+		ResourceID:   sub.ID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        title,
+		Message:      message,
+	})
+}
+
+func respondWebhookError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidSubscription):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, webhooks.ErrSubscriptionNotFound):
+		respondError(w, http.StatusNotFound, "webhook not found")
+	default:
+		slog.Default().Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func webhookInput(req apitypes.WebhookSubscriptionRequest) webhooks.SubscriptionInput {
+	enabled := req.Enabled == nil || *req.Enabled
+	return webhooks.SubscriptionInput{
+		Name:         req.Name,
+		URL:          req.URL,
+		EventTypes:   req.EventTypes,
+		Categories:   req.Categories,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		Enabled:      enabled,
+	}
+}
+
+func apiWebhookSubscription(in webhooks.Subscription) apitypes.WebhookSubscription {
+	return apitypes.WebhookSubscription{
+		ID:           apitypes.FormatAppID(in.ID),
+		Name:         in.Name,
+		URL:          in.URL,
+		EventTypes:   in.EventTypes,
+		Categories:   in.Categories,
+		ResourceType: in.ResourceType,
+		ResourceID:   apitypes.FormatAppID(in.ResourceID),
+		Enabled:      in.Enabled,
+		CreatedAt:    in.CreatedAt,
+		UpdatedAt:    in.UpdatedAt,
+	}
+}
+
+func apiWebhookDelivery(in webhooks.Delivery) apitypes.WebhookDelivery {
+	return apitypes.WebhookDelivery{
+		ID:             apitypes.FormatAppID(in.ID),
+		SubscriptionID: apitypes.FormatAppID(in.SubscriptionID),
+		ActivityID:     apitypes.FormatAppID(in.ActivityID),
+		EventType:      in.EventType,
+		Status:         in.Status,
+		Attempts:       in.Attempts,
+		NextAttemptAt:  in.NextAttemptAt,
+		LastAttemptAt:  in.LastAttemptAt,
+		ResponseStatus: in.ResponseStatus,
+		LastError:      in.LastError,
+		CreatedAt:      in.CreatedAt,
+		DeliveredAt:    in.DeliveredAt,
+	}
+}
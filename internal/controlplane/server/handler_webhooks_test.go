@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/webhooks"
+)
+
+func TestWebhookEndpoints(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenServerHandlerDB(t)
+	handler := NewHandler(db)
+	serve := func(method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var reader *bytes.Reader
+		if body != nil {
+			raw, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("marshal body: %v", err)
+			}
+			reader = bytes.NewReader(raw)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	if res := serve(http.MethodPost, "/api/webhooks", map[string]any{"name": "Chat", "url": "not a url"}); res.Code != http.StatusBadRequest {
+		t.Fatalf("invalid url status = %d; body = %s", res.Code, res.Body.String())
+	}
+	res := serve(http.MethodPost, "/api/webhooks", map[string]any{
+		"name":        "Chat",
+		"url":         "https://hooks.example.test/pressluft",
+		"event_types": []string{"site.created", "backup.failed"},
+	})
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var created apitypes.CreateWebhookSubscriptionResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode create response: %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, webhooks.SecretPrefix) || !created.Subscription.Enabled || len(created.Subscription.EventTypes) != 2 {
+		t.Fatalf("created = %+v", created)
+	}
+	id := created.Subscription.ID
+
+	res = serve(http.MethodGet, "/api/webhooks", nil)
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), id) || strings.Contains(res.Body.String(), created.Secret) {
+		t.Fatalf("list status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	res = serve(http.MethodPut, "/api/webhooks/"+id, map[string]any{
+		"name":       "Chat",
+		"url":        "https://hooks.example.test/pressluft",
+		"categories": []string{"backup"},
+		"enabled":    false,
+	})
+	var updated apitypes.WebhookSubscription
+	if err := json.Unmarshal(res.Body.Bytes(), &updated); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("update status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if updated.Enabled || len(updated.EventTypes) != 0 || len(updated.Categories) != 1 {
+		t.Fatalf("updated = %+v", updated)
+	}
+
+	if res := serve(http.MethodPost, "/api/webhooks/"+id+"/test", nil); res.Code != http.StatusAccepted {
+		t.Fatalf("test status = %d; body = %s", res.Code, res.Body.String())
+	}
+	res = serve(http.MethodGet, "/api/webhooks/"+id+"/deliveries", nil)
+	var deliveries []apitypes.WebhookDelivery
+	if err := json.Unmarshal(res.Body.Bytes(), &deliveries); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("deliveries status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if len(deliveries) != 1 || deliveries[0].EventType != webhooks.TestEventType || deliveries[0].Status != webhooks.DeliveryStatusPending {
+		t.Fatalf("deliveries = %+v, want one pending test delivery", deliveries)
+	}
+
+	if res := serve(http.MethodDelete, "/api/webhooks/"+id, nil); res.Code != http.StatusOK {
+		t.Fatalf("delete status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(http.MethodGet, "/api/webhooks/"+id+"/deliveries", nil); res.Code != http.StatusNotFound {
+		t.Fatalf("deliveries after delete status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+}
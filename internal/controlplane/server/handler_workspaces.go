@@ -0,0 +1,160 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/shared/workspace"
+)
+
+// workspacesHandler serves /api/workspaces: the workspaces the signed-in
+// user belongs to, creating new ones and adding members to the current one.
+type workspacesHandler struct {
+	service       *auth.Service
+	activityStore *activity.Store
+}
+
+func (wh *workspacesHandler) route(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/workspaces" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		wh.handleList(w, r)
+	case http.MethodPost:
+		wh.handleCreate(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (wh *workspacesHandler) routeWithID(w http.ResponseWriter, r *http.Request) {
+	tail := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/workspaces/"), "/")
+	parts := strings.Split(tail, "/")
+	if len(parts) != 2 || parts[1] != "members" {
+		http.NotFound(w, r)
+		return
+	}
+	workspaceID, err := apitypes.ParseAppID(parts[0])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid workspace id")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	wh.handleAddMember(w, r, workspaceID)
+}
+
+func (wh *workspacesHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	workspaces, err := wh.service.ListWorkspaces(r.Context(), auth.ActorFromContext(r.Context()))
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to list workspaces")
+		return
+	}
+	out := make([]apitypes.Workspace, 0, len(workspaces))
+	for _, ws := range workspaces {
+		out = append(out, apiWorkspace(ws))
+	}
+	respondJSON(w, http.StatusOK, out)
+}
+
+func (wh *workspacesHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req apitypes.CreateWorkspaceRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	actor := auth.ActorFromContext(r.Context())
+	ws, err := wh.service.CreateWorkspace(r.Context(), actor, req.Name)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to create workspace")
+		return
+	}
+	// The event belongs to the new workspace, where its owner will look.
+	wh.emitActivity(r.WithContext(workspace.WithID(r.Context(), ws.ID)), activity.EventSecurityWorkspaceCreated, actor.ID, fmt.Sprintf("Workspace '%s' created", ws.Name), fmt.Sprintf("%s is its owner.", actor.Email))
+	respondJSON(w, http.StatusCreated, apiWorkspace(ws))
+}
+
+// handleAddMember adds an existing user to the workspace the request acts
+// in. Other workspaces are reported as not found, whatever the user's role
+// there.
+func (wh *workspacesHandler) handleAddMember(w http.ResponseWriter, r *http.Request, workspaceID string) {
+	actor := auth.ActorFromContext(r.Context())
+	if workspaceID != actor.Workspace() {
+		respondError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if !auth.RequireUnscopedCapability(auth.CapabilityManageUsers)(actor) {
+		respondError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	var req apitypes.AddWorkspaceMemberRequest
+	if err := decodeJSONBody(w, r, defaultJSONBodyLimit, &req); err != nil {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := wh.service.AddWorkspaceMember(r.Context(), actor, req.Email, req.Role)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to add workspace member")
+		return
+	}
+	wh.emitActivity(r, activity.EventSecurityMemberAdded, user.ID, fmt.Sprintf("%s added to the workspace", user.Email), fmt.Sprintf("They joined as %s.", user.Role))
+	respondJSON(w, http.StatusCreated, apiUser(user))
+}
+
+func (wh *workspacesHandler) emitActivity(r *http.Request, eventType activity.EventType, userID, title, message string) {
+	if wh.activityStore == nil {
+		return
+	}
+	actorType, actorID := activityActorFromRequest(r)
+	_, _ = wh.activityStore.Emit(r.Context(), activity.EmitInput{
+		EventType:    eventType,
+		Category:     activity.CategorySecurity,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceAccount,
+		ResourceID:   userID,
+		ActorType:    actorType,
+		ActorID:      actorID,
+		Title:        title,
+		Message:      message,
+	})
+}
+
+func respondWorkspaceError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidWorkspace), errors.Is(err, auth.ErrInvalidUser):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		respondError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, auth.ErrForbidden):
+		respondError(w, http.StatusForbidden, "forbidden")
+	default:
+		slog.Default().Error(message, "error", err)
+		respondError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func apiWorkspace(in auth.Workspace) apitypes.Workspace {
+	return apitypes.Workspace{
+		ID:        apitypes.FormatAppID(in.ID),
+		Name:      in.Name,
+		Slug:      in.Slug,
+		Role:      in.Role,
+		CreatedAt: in.CreatedAt,
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/shared/workspace"
+)
+
+func TestWorkspacesIsolateResources(t *testing.T) {
+	db := mustOpenServerHandlerDB(t)
+	store := auth.NewStore(db)
+	const password = "correct horse battery staple"
+	if _, err := store.CreateUser(context.Background(), "agency@example.test", password, auth.RoleOwner); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := store.CreateUser(context.Background(), "staff@example.test", password, auth.RoleDeveloper); err != nil {
+		t.Fatalf("create staff user: %v", err)
+	}
+	service := auth.NewService(store, []byte("test-session-secret"), time.Hour, 2*time.Hour, false)
+	handler := NewHandlerWithOptions(db, nil, nil, nil, HandlerOptions{
+		Authenticator: auth.NewSessionAuthenticator(service),
+		AuthService:   service,
+	})
+
+	// Everything below is created in the default workspace, where the
+	// agency owner lives.
+	_, providerID := mustInsertProviderRecord(t, db, "test-server-provider", "agency", "token-ok")
+	serverID := mustInsertServerRecord(t, db, providerID, "ready")
+	siteID := mustCreateAuthorizationTestSite(t, db, serverID, "Agency Site")
+	domainID, err := NewDomainStore(db).Create(context.Background(), CreateDomainInput{
+		Hostname: "agency.dev",
+		Kind:     DomainKindBaseDomain,
+		Source:   DomainSourceUser,
+		DNSState: DomainDNSStateReady,
+	})
+	if err != nil {
+		t.Fatalf("create domain: %v", err)
+	}
+	job := mustCreateSiteJob(t, orchestrator.NewStore(db), serverID, siteID)
+	if _, err := activity.NewStore(db).Emit(context.Background(), activity.EmitInput{
+		EventType:    activity.EventSiteCreated,
+		Category:     activity.CategorySite,
+		Level:        activity.LevelInfo,
+		ResourceType: activity.ResourceSite,
+		ResourceID:   siteID,
+		ActorType:    activity.ActorSystem,
+		Title:        "Site created",
+	}); err != nil {
+		t.Fatalf("emit activity: %v", err)
+	}
+
+	login := func(email string) []*http.Cookie {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(`{"email":"`+email+`","password":"`+password+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("login %s status = %d; body = %s", email, res.Code, res.Body.String())
+		}
+		return res.Result().Cookies()
+	}
+	serve := func(cookies []*http.Cookie, workspaceID, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if workspaceID != "" {
+			req.Header.Set(auth.WorkspaceHeader, workspaceID)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	agency := login("agency@example.test")
+	res := serve(agency, "", http.MethodPost, "/api/workspaces", `{"name":"Client B"}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("create workspace status = %d; body = %s", res.Code, res.Body.String())
+	}
+	var clientWorkspace apitypes.Workspace
+	if err := json.Unmarshal(res.Body.Bytes(), &clientWorkspace); err != nil {
+		t.Fatalf("decode workspace: %v", err)
+	}
+	if clientWorkspace.Slug != "client-b" || clientWorkspace.Role != auth.RoleOwner {
+		t.Fatalf("workspace = %+v, want slug client-b owned by its creator", clientWorkspace)
+	}
+
+	// The client owner only belongs to the new workspace.
+	if _, err := store.CreateUser(workspace.WithID(context.Background(), clientWorkspace.ID), "client@example.test", password, auth.RoleOwner); err != nil {
+		t.Fatalf("create client user: %v", err)
+	}
+	client := login("client@example.test")
+
+	for _, path := range []string{"/api/providers", "/api/servers", "/api/sites", "/api/domains", "/api/jobs"} {
+		res := serve(client, "", http.MethodGet, path, "")
+		if res.Code != http.StatusOK {
+			t.Fatalf("client GET %s status = %d; body = %s", path, res.Code, res.Body.String())
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(res.Body.Bytes(), &items); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		if len(items) != 0 {
+			t.Fatalf("client GET %s = %s, want nothing from the other workspace", path, res.Body.String())
+		}
+	}
+	for _, path := range []string{
+		"/api/servers/" + serverID,
+		"/api/sites/" + siteID,
+		"/api/domains/" + domainID,
+		"/api/jobs/" + job.ID,
+	} {
+		if res := serve(client, "", http.MethodGet, path, ""); res.Code != http.StatusNotFound {
+			t.Fatalf("client GET %s status = %d, want %d", path, res.Code, http.StatusNotFound)
+		}
+	}
+	if res := serve(client, "", http.MethodDelete, "/api/providers/"+providerID, ""); res.Code != http.StatusNotFound {
+		t.Fatalf("client DELETE provider status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+	if res := serve(client, "", http.MethodPost, "/api/sites", `{"server_id":"`+serverID+`","name":"Intruder","wordpress_admin_email":"x@example.test"}`); res.Code == http.StatusCreated {
+		t.Fatalf("client created a site on another workspace's server: %s", res.Body.String())
+	}
+
+	// Jobs and schedules may neither target another workspace's server nor
+	// name its sites, backups or backup targets in their payload.
+	backupStore := NewBackupStore(db)
+	targetID, err := backupStore.CreateTarget(context.Background(), CreateBackupTargetInput{
+		Name: "Agency S3", Endpoint: "https://s3.example.test", Bucket: "agency-backups", AccessKeyID: "key", SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("create backup target: %v", err)
+	}
+	backupID, err := backupStore.CreateBackup(context.Background(), CreateSiteBackupInput{SiteID: siteID, ServerID: serverID, TargetID: targetID})
+	if err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	clientServerID := testPublicID(3)
+	if _, err := db.Exec(
+		`INSERT INTO servers (id, workspace_id, provider_id, provider_type, ipv4, name, location, server_type, image, profile_key, status, setup_state, created_at, updated_at)
+		 VALUES (?, ?, ?, 'test-server-provider', '203.0.113.20', 'client-prod-01', 'fsn1', 'cx22', 'ubuntu-24.04', 'nginx-stack', 'ready', 'ready', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`,
+		clientServerID, clientWorkspace.ID, providerID,
+	); err != nil {
+		t.Fatalf("insert client server: %v", err)
+	}
+	clientSiteID := mustCreateAuthorizationTestSite(t, db, clientServerID, "Client Site")
+	for _, body := range []string{
+		`{"kind":"deploy_site","server_id":"` + serverID + `","payload":{"site_id":"` + siteID + `"}}`,
+		`{"kind":"deploy_site","server_id":"` + clientServerID + `","payload":{"site_id":"` + siteID + `"}}`,
+		`{"kind":"backup_site","server_id":"` + clientServerID + `","payload":{"site_id":"` + clientSiteID + `","target_id":"` + targetID + `"}}`,
+		`{"kind":"restore_site","server_id":"` + clientServerID + `","payload":{"site_id":"` + clientSiteID + `","backup_id":"` + backupID + `"}}`,
+	} {
+		if res := serve(client, "", http.MethodPost, "/api/jobs", body); res.Code != http.StatusBadRequest {
+			t.Fatalf("client POST /api/jobs %s status = %d, want %d; body = %s", body, res.Code, http.StatusBadRequest, res.Body.String())
+		}
+	}
+	for _, body := range []string{
+		`{"name":"Intruder","job_kind":"backup_site","server_id":"` + serverID + `","cron_expression":"0 3 * * *","payload":{"site_id":"` + siteID + `","target_id":"` + targetID + `"}}`,
+		`{"name":"Intruder","job_kind":"backup_site","server_id":"` + clientServerID + `","cron_expression":"0 3 * * *","payload":{"site_id":"` + clientSiteID + `","target_id":"` + targetID + `"}}`,
+		`{"name":"Intruder","job_kind":"deploy_site","server_id":"` + clientServerID + `","cron_expression":"0 3 * * *","payload":{"site_id":"` + siteID + `"}}`,
+	} {
+		if res := serve(client, "", http.MethodPost, "/api/schedules", body); res.Code != http.StatusBadRequest {
+			t.Fatalf("client POST /api/schedules %s status = %d, want %d; body = %s", body, res.Code, http.StatusBadRequest, res.Body.String())
+		}
+	}
+	// The same requests within the client's own workspace are accepted.
+	if res := serve(client, "", http.MethodPost, "/api/jobs", `{"kind":"deploy_site","server_id":"`+clientServerID+`","payload":{"site_id":"`+clientSiteID+`"}}`); res.Code != http.StatusAccepted {
+		t.Fatalf("client POST /api/jobs on own site status = %d; body = %s", res.Code, res.Body.String())
+	}
+	// The login policy covers every workspace, so the client's owner may
+	// read it but not change it.
+	if res := serve(client, "", http.MethodPut, "/api/auth/policy", `{"require_totp":true}`); res.Code != http.StatusForbidden {
+		t.Fatalf("client PUT /api/auth/policy status = %d, want %d; body = %s", res.Code, http.StatusForbidden, res.Body.String())
+	}
+
+	res = serve(client, "", http.MethodGet, "/api/activity", "")
+	var activities apitypes.ActivityListResponse
+	if err := json.Unmarshal(res.Body.Bytes(), &activities); err != nil {
+		t.Fatalf("decode activity: %v", err)
+	}
+	for _, item := range activities.Data {
+		if item.ResourceID == siteID {
+			t.Fatalf("client activity = %+v, want nothing about the agency site", activities.Data)
+		}
+	}
+
+	res = serve(client, "", http.MethodGet, "/api/users", "")
+	var users []apitypes.User
+	if err := json.Unmarshal(res.Body.Bytes(), &users); err != nil {
+		t.Fatalf("decode users: %v", err)
+	}
+	// The agency owner created the workspace and is a member; agency staff
+	// are not.
+	if len(users) != 2 || users[0].Email != "agency@example.test" || users[1].Email != "client@example.test" {
+		t.Fatalf("client workspace users = %+v, want the agency owner and the client", users)
+	}
+
+	if res := serve(client, workspace.DefaultID, http.MethodGet, "/api/sites", ""); res.Code != http.StatusForbidden {
+		t.Fatalf("client selecting the agency workspace status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+	if res := serve(client, "", http.MethodPost, "/api/workspaces/"+workspace.DefaultID+"/members", `{"email":"client@example.test","role":"owner"}`); res.Code != http.StatusNotFound {
+		t.Fatalf("client adding itself to the agency workspace status = %d, want %d", res.Code, http.StatusNotFound)
+	}
+
+	// The agency owner sees the new workspace when selecting it, and only
+	// the agency's data without.
+	res = serve(agency, clientWorkspace.ID, http.MethodGet, "/api/sites", "")
+	if res.Code != http.StatusOK || strings.Contains(res.Body.String(), siteID) {
+		t.Fatalf("agency GET /api/sites in client workspace status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(agency, "", http.MethodGet, "/api/sites/"+siteID, ""); res.Code != http.StatusOK {
+		t.Fatalf("agency GET own site status = %d; body = %s", res.Code, res.Body.String())
+	}
+
+	// Once added as a viewer the client sees the agency's sites there.
+	if res := serve(agency, "", http.MethodPost, "/api/workspaces/"+workspace.DefaultID+"/members", `{"email":"client@example.test","role":"viewer"}`); res.Code != http.StatusCreated {
+		t.Fatalf("add member status = %d; body = %s", res.Code, res.Body.String())
+	}
+	res = serve(client, workspace.DefaultID, http.MethodGet, "/api/sites/"+siteID, "")
+	if res.Code != http.StatusOK {
+		t.Fatalf("member GET site status = %d; body = %s", res.Code, res.Body.String())
+	}
+	if res := serve(client, workspace.DefaultID, http.MethodDelete, "/api/sites/"+siteID, ""); res.Code != http.StatusForbidden {
+		t.Fatalf("viewer DELETE site status = %d, want %d", res.Code, http.StatusForbidden)
+	}
+
+	res = serve(client, "", http.MethodGet, "/api/workspaces", "")
+	var workspaces []apitypes.Workspace
+	if err := json.Unmarshal(res.Body.Bytes(), &workspaces); err != nil {
+		t.Fatalf("decode workspaces: %v", err)
+	}
+	if len(workspaces) != 2 {
+		t.Fatalf("client workspaces = %+v, want two memberships", workspaces)
+	}
+}
@@ -87,12 +87,16 @@ func (m *SiteHealthMonitor) reconcileSite(ctx context.Context, site stores.Store
 	runtimeState := stores.SiteRuntimeHealthStateHealthy
 	runtimeMessage := "Public runtime checks passed."
 
-	if err := VerifyPublicSiteRouting(ctx, site.ID, site.PrimaryDomain); err != nil {
+	auth, err := SiteBasicAuth(site)
+	if err != nil {
+		runtimeState = stores.SiteRuntimeHealthStateUnknown
+		runtimeMessage = "Public checks skipped: " + err.Error()
+	} else if err := VerifyPublicSiteRoutingWithAuth(ctx, site.ID, site.PrimaryDomain, auth); err != nil {
 		routingState = stores.DomainRoutingStateIssue
 		routingMessage = err.Error()
 		runtimeState = stores.SiteRuntimeHealthStateIssue
 		runtimeMessage = "Public routing check failed: " + err.Error()
-	} else if err := VerifyPublicWordPressRuntime(ctx, site.PrimaryDomain); err != nil {
+	} else if err := VerifyPublicWordPressRuntimeWithAuth(ctx, site.PrimaryDomain, auth); err != nil {
 		runtimeState = stores.SiteRuntimeHealthStateIssue
 		runtimeMessage = "Public runtime check failed: " + err.Error()
 	}
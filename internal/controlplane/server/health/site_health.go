@@ -10,10 +10,36 @@ import (
 
 	"pressluft/internal/agent/agentcommand"
 	"pressluft/internal/controlplane/server/stores"
+	"pressluft/internal/shared/security"
 )
 
+// BasicAuth holds the HTTP basic auth credentials that guard a staging site.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// SiteBasicAuth returns the decrypted basic auth credentials for site, or nil
+// when the site is publicly reachable.
+func SiteBasicAuth(site stores.StoredSite) (*BasicAuth, error) {
+	if strings.TrimSpace(site.BasicAuthUsername) == "" {
+		return nil, nil
+	}
+	password, err := security.DecryptSiteBasicAuthPassword(site.BasicAuthPasswordEncrypted)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt basic auth password: %w", err)
+	}
+	return &BasicAuth{Username: site.BasicAuthUsername, Password: password}, nil
+}
+
 func VerifyPublicSiteRouting(ctx context.Context, siteID, hostname string) error {
-	resp, bodyPreview, err := fetchPublicSite(ctx, hostname, "/")
+	return VerifyPublicSiteRoutingWithAuth(ctx, siteID, hostname, nil)
+}
+
+// VerifyPublicSiteRoutingWithAuth is VerifyPublicSiteRouting for sites that
+// sit behind HTTP basic auth. A nil auth sends no credentials.
+func VerifyPublicSiteRoutingWithAuth(ctx context.Context, siteID, hostname string, auth *BasicAuth) error {
+	resp, bodyPreview, err := fetchPublicSite(ctx, hostname, "/", auth)
 	if err != nil {
 		return err
 	}
@@ -31,10 +57,16 @@ func VerifyPublicSiteRouting(ctx context.Context, siteID, hostname string) error
 }
 
 func VerifyPublicWordPressRuntime(ctx context.Context, hostname string) error {
-	if err := verifyPublicWordPressPath(ctx, hostname, "/"); err != nil {
+	return VerifyPublicWordPressRuntimeWithAuth(ctx, hostname, nil)
+}
+
+// VerifyPublicWordPressRuntimeWithAuth is VerifyPublicWordPressRuntime for
+// sites that sit behind HTTP basic auth. A nil auth sends no credentials.
+func VerifyPublicWordPressRuntimeWithAuth(ctx context.Context, hostname string, auth *BasicAuth) error {
+	if err := verifyPublicWordPressPath(ctx, hostname, "/", auth); err != nil {
 		return err
 	}
-	if err := verifyPublicWordPressPath(ctx, hostname, "/wp-login.php"); err != nil {
+	if err := verifyPublicWordPressPath(ctx, hostname, "/wp-login.php", auth); err != nil {
 		return err
 	}
 	return nil
@@ -64,8 +96,8 @@ func RuntimeHealthFromAgentSnapshot(snapshot *agentcommand.SiteHealthSnapshot) (
 	return stores.SiteRuntimeHealthStateIssue, message
 }
 
-func verifyPublicWordPressPath(ctx context.Context, hostname, path string) error {
-	resp, bodyPreview, err := fetchPublicSite(ctx, hostname, path)
+func verifyPublicWordPressPath(ctx context.Context, hostname, path string, auth *BasicAuth) error {
+	resp, bodyPreview, err := fetchPublicSite(ctx, hostname, path, auth)
 	if err != nil {
 		return err
 	}
@@ -79,7 +111,7 @@ func verifyPublicWordPressPath(ctx context.Context, hostname, path string) error
 	return nil
 }
 
-func fetchPublicSite(ctx context.Context, hostname, path string) (*http.Response, string, error) {
+func fetchPublicSite(ctx context.Context, hostname, path string, auth *BasicAuth) (*http.Response, string, error) {
 	client := &http.Client{Timeout: 20 * time.Second}
 	if path == "" {
 		path = "/"
@@ -88,6 +120,9 @@ func fetchPublicSite(ctx context.Context, hostname, path string) (*http.Response
 	if err != nil {
 		return nil, "", err
 	}
+	if auth != nil {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, "", err
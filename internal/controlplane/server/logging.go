@@ -7,7 +7,10 @@ import (
 	"pressluft/internal/controlplane/server/middleware"
 )
 
+// RequestObserver is told about every completed request.
+type RequestObserver = middleware.RequestObserver
+
 // WithRequestLogging wraps an HTTP handler with request logging.
-func WithRequestLogging(next http.Handler, logger *slog.Logger) http.Handler {
-	return middleware.WithRequestLogging(next, logger)
+func WithRequestLogging(next http.Handler, logger *slog.Logger, observers ...RequestObserver) http.Handler {
+	return middleware.WithRequestLogging(next, logger, observers...)
 }
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bufio"
+	"context"
 	"log/slog"
 	"net"
 	"net/http"
@@ -36,7 +37,35 @@ func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return hijacker.Hijack()
 }
 
-func WithRequestLogging(next http.Handler, logger *slog.Logger) http.Handler {
+// RequestObserver is told about every completed request, for request
+// metrics. The request's Pattern is the registered route that served it, or
+// empty when no route matched.
+type RequestObserver interface {
+	ObserveRequest(r *http.Request, status int, duration time.Duration)
+}
+
+type routeKey struct{}
+
+// routeRecord carries the matched pattern back out of nested muxes, which
+// see copies of the logged request.
+type routeRecord struct {
+	pattern string
+}
+
+// RecordRoute serves requests through mux and reports the pattern it matched
+// to WithRequestLogging. With nested muxes the innermost match wins.
+func RecordRoute(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if record, ok := r.Context().Value(routeKey{}).(*routeRecord); ok && record.pattern == "" {
+			record.pattern = r.Pattern
+		}
+	})
+}
+
+// WithRequestLogging logs every request next serves and reports it to the
+// observers, if any.
+func WithRequestLogging(next http.Handler, logger *slog.Logger, observers ...RequestObserver) http.Handler {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -44,14 +73,21 @@ func WithRequestLogging(next http.Handler, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		started := time.Now()
 		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		route := &routeRecord{}
+		r = r.WithContext(context.WithValue(r.Context(), routeKey{}, route))
 
 		next.ServeHTTP(recorder, r)
+		r.Pattern = route.pattern
 
+		duration := time.Since(started)
 		logger.Info("request completed",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", recorder.status,
-			"duration_ms", time.Since(started).Milliseconds(),
+			"duration_ms", duration.Milliseconds(),
 		)
+		for _, observer := range observers {
+			observer.ObserveRequest(r, recorder.status, duration)
+		}
 	})
 }
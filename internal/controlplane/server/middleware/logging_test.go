@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestWithRequestLoggingPreservesStatus(t *testing.T) {
@@ -30,3 +31,49 @@ func TestWithRequestLoggingPreservesStatus(t *testing.T) {
 		t.Fatal("expected request log output")
 	}
 }
+
+type recordingObserver struct {
+	path    string
+	pattern string
+	status  int
+}
+
+func (o *recordingObserver) ObserveRequest(r *http.Request, status int, _ time.Duration) {
+	o.path = r.URL.Path
+	o.pattern = r.Pattern
+	o.status = status
+}
+
+func TestWithRequestLoggingReportsToObservers(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	observer := &recordingObserver{}
+	handler := WithRequestLogging(next, slog.New(slog.DiscardHandler), observer)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/servers/42", nil))
+
+	if observer.path != "/api/servers/42" || observer.status != http.StatusNotFound {
+		t.Fatalf("observed %q %d, want /api/servers/42 404", observer.path, observer.status)
+	}
+}
+
+func TestWithRequestLoggingReportsTheMatchedRoute(t *testing.T) {
+	inner := http.NewServeMux()
+	inner.HandleFunc("/api/sites/", func(http.ResponseWriter, *http.Request) {})
+	outer := http.NewServeMux()
+	outer.Handle("/api/", RecordRoute(inner))
+	observer := &recordingObserver{}
+	handler := WithRequestLogging(RecordRoute(outer), slog.New(slog.DiscardHandler), observer)
+
+	for path, want := range map[string]string{
+		"/api/sites/42/uptime": "/api/sites/",
+		"/api/unknown":         "",
+		"/elsewhere":           "",
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if observer.pattern != want {
+			t.Fatalf("%s observed pattern %q, want %q", path, observer.pattern, want)
+		}
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	"pressluft/internal/controlplane/activity"
 	"pressluft/internal/controlplane/auth"
+	"pressluft/internal/controlplane/vulnscan"
+	"pressluft/internal/shared/workspace"
 )
 
 type HandlerOptions struct {
@@ -20,6 +22,18 @@ type HandlerOptions struct {
 	Logger          loggerLike
 	IsDev           bool
 	ControlPlaneURL string
+	// VulnerabilityScanner rescans a site whenever its inventory is refreshed.
+	VulnerabilityScanner *vulnscan.Scanner
+	// JobCanceller stops running worker jobs on POST /api/jobs/{id}/cancel.
+	JobCanceller JobCanceller
+	// WorkerSlots reports worker concurrency slot usage on /api/health.
+	WorkerSlots WorkerSlotReporter
+	// EmailConfigured tells users whether their notification preferences
+	// will actually send email.
+	EmailConfigured bool
+	// MetricsHandler serves /metrics. It does its own token auth; nil
+	// leaves the endpoint unmounted.
+	MetricsHandler http.Handler
 }
 
 type ActivityEmitter interface {
@@ -36,11 +50,19 @@ func withOperatorAuth(next http.Handler, authenticator auth.Authenticator) http.
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		actor, err := authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrWorkspaceAccess) {
+			respondError(w, http.StatusForbidden, "no access to this workspace")
+			return
+		}
 		if err != nil {
 			respondError(w, http.StatusUnauthorized, "authentication required")
 			return
 		}
-		next.ServeHTTP(w, r.WithContext(auth.ContextWithActor(r.Context(), actor)))
+		if actor.TOTPEnrollmentRequired {
+			respondError(w, http.StatusForbidden, "two-factor enrollment required")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(contextWithActor(r.Context(), actor)))
 	})
 }
 
@@ -50,24 +72,45 @@ func withOptionalActor(next http.Handler, authenticator auth.Authenticator) http
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		actor, err := authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrWorkspaceAccess) {
+			respondError(w, http.StatusForbidden, "no access to this workspace")
+			return
+		}
 		if err != nil && !errors.Is(err, auth.ErrUnauthenticated) {
 			respondError(w, http.StatusUnauthorized, "authentication required")
 			return
 		}
 		if actor.IsAuthenticated() {
-			r = r.WithContext(auth.ContextWithActor(r.Context(), actor))
+			r = r.WithContext(contextWithActor(r.Context(), actor))
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// contextWithActor attaches the actor and scopes every store query of the
+// request to the actor's workspace.
+func contextWithActor(ctx context.Context, actor auth.Actor) context.Context {
+	return workspace.WithID(auth.ContextWithActor(ctx, actor), actor.Workspace())
+}
+
 func withAuthorization(next http.Handler, allow func(auth.Actor) bool) http.Handler {
+	if allow == nil {
+		return next
+	}
+	return withRequestAuthorization(next, func(actor auth.Actor, _ *http.Request) bool {
+		return allow(actor)
+	})
+}
+
+// withRequestAuthorization is withAuthorization for rules that also depend on
+// the request, such as the method or the site named in the path.
+func withRequestAuthorization(next http.Handler, allow requestRule) http.Handler {
 	if allow == nil {
 		return next
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		actor := auth.ActorFromContext(r.Context())
-		if !allow(actor) {
+		if !allow(actor, r) {
 			respondError(w, http.StatusForbidden, "forbidden")
 			return
 		}
@@ -17,6 +17,24 @@ func VerifyPublicWordPressRuntime(ctx context.Context, hostname string) error {
 	return health.VerifyPublicWordPressRuntime(ctx, hostname)
 }
 
+// SiteBasicAuth is a re-export of health.BasicAuth.
+type SiteBasicAuth = health.BasicAuth
+
+// SiteBasicAuthCredentials returns the decrypted basic auth credentials for a site, or nil when it is public.
+func SiteBasicAuthCredentials(site StoredSite) (*SiteBasicAuth, error) {
+	return health.SiteBasicAuth(site)
+}
+
+// VerifyPublicSiteRoutingWithAuth is VerifyPublicSiteRouting for sites behind HTTP basic auth.
+func VerifyPublicSiteRoutingWithAuth(ctx context.Context, siteID, hostname string, auth *SiteBasicAuth) error {
+	return health.VerifyPublicSiteRoutingWithAuth(ctx, siteID, hostname, auth)
+}
+
+// VerifyPublicWordPressRuntimeWithAuth is VerifyPublicWordPressRuntime for sites behind HTTP basic auth.
+func VerifyPublicWordPressRuntimeWithAuth(ctx context.Context, hostname string, auth *SiteBasicAuth) error {
+	return health.VerifyPublicWordPressRuntimeWithAuth(ctx, hostname, auth)
+}
+
 // RuntimeHealthFromAgentSnapshot extracts runtime health state from an agent health snapshot.
 func RuntimeHealthFromAgentSnapshot(snapshot *agentcommand.SiteHealthSnapshot) (string, string) {
 	return health.RuntimeHealthFromAgentSnapshot(snapshot)
@@ -0,0 +1,28 @@
+package server
+
+import "pressluft/internal/controlplane/server/stores"
+
+// Re-export backup types for backward compatibility.
+type StoredBackupTarget = stores.StoredBackupTarget
+type CreateBackupTargetInput = stores.CreateBackupTargetInput
+type StoredSiteBackup = stores.StoredSiteBackup
+type CreateSiteBackupInput = stores.CreateSiteBackupInput
+type BackupStore = stores.BackupStore
+
+// Re-export backup constants for backward compatibility.
+const (
+	SiteBackupStatusPending   = stores.SiteBackupStatusPending
+	SiteBackupStatusRunning   = stores.SiteBackupStatusRunning
+	SiteBackupStatusCompleted = stores.SiteBackupStatusCompleted
+	SiteBackupStatusFailed    = stores.SiteBackupStatusFailed
+	SiteBackupStatusExpired   = stores.SiteBackupStatusExpired
+
+	DefaultBackupRetentionDays = stores.DefaultBackupRetentionDays
+)
+
+// Re-export backup functions for backward compatibility.
+var (
+	NewBackupStore            = stores.NewBackupStore
+	AllSiteBackupStatuses     = stores.AllSiteBackupStatuses
+	NormalizeSiteBackupStatus = stores.NormalizeSiteBackupStatus
+)
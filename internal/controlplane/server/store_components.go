@@ -0,0 +1,37 @@
+package server
+
+import (
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/server/stores"
+)
+
+// Re-export site component types for backward compatibility.
+type StoredSiteComponent = stores.StoredSiteComponent
+type SiteComponentInput = stores.SiteComponentInput
+type ComponentStore = stores.ComponentStore
+
+// Re-export site component constants for backward compatibility.
+const (
+	SiteComponentTypeCore   = stores.SiteComponentTypeCore
+	SiteComponentTypePlugin = stores.SiteComponentTypePlugin
+	SiteComponentTypeTheme  = stores.SiteComponentTypeTheme
+)
+
+// Re-export site component functions for backward compatibility.
+var NewComponentStore = stores.NewComponentStore
+
+// SiteComponentInputs converts an agent inventory report into store inputs.
+func SiteComponentInputs(components []agentcommand.WPComponent) []SiteComponentInput {
+	out := make([]SiteComponentInput, 0, len(components))
+	for _, component := range components {
+		out = append(out, SiteComponentInput{
+			Type:          component.Type,
+			Name:          component.Name,
+			Title:         component.Title,
+			Status:        component.Status,
+			Version:       component.Version,
+			UpdateVersion: component.UpdateVersion,
+		})
+	}
+	return out
+}
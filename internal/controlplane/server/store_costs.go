@@ -0,0 +1,15 @@
+package server
+
+import "pressluft/internal/controlplane/server/stores"
+
+// Re-export cost types for consistency with the other server stores.
+type ServerPriceInput = stores.ServerPriceInput
+type ServerCost = stores.ServerCost
+type SiteCost = stores.SiteCost
+type WorkspaceCost = stores.WorkspaceCost
+type CostTotal = stores.CostTotal
+type CostReport = stores.CostReport
+type CostStore = stores.CostStore
+
+// Re-export cost functions for consistency with the other server stores.
+var NewCostStore = stores.NewCostStore
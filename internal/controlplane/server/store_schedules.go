@@ -0,0 +1,23 @@
+package server
+
+import "pressluft/internal/controlplane/server/stores"
+
+// Re-export schedule types for backward compatibility.
+type StoredSchedule = stores.StoredSchedule
+type CreateScheduleInput = stores.CreateScheduleInput
+type UpdateScheduleInput = stores.UpdateScheduleInput
+type ScheduleStore = stores.ScheduleStore
+
+// Re-export schedule constants for backward compatibility.
+const (
+	ScheduleMissedRunSkip    = stores.ScheduleMissedRunSkip
+	ScheduleMissedRunCatchUp = stores.ScheduleMissedRunCatchUp
+)
+
+// Re-export schedule functions for backward compatibility.
+var (
+	NewScheduleStore                 = stores.NewScheduleStore
+	NextScheduleRun                  = stores.NextScheduleRun
+	AllScheduleMissedRunPolicies     = stores.AllScheduleMissedRunPolicies
+	NormalizeScheduleMissedRunPolicy = stores.NormalizeScheduleMissedRunPolicy
+)
@@ -29,6 +29,9 @@ const (
 	SiteRuntimeHealthStateHealthy = stores.SiteRuntimeHealthStateHealthy
 	SiteRuntimeHealthStateIssue   = stores.SiteRuntimeHealthStateIssue
 	SiteRuntimeHealthStateUnknown = stores.SiteRuntimeHealthStateUnknown
+
+	SiteEnvironmentProduction = stores.SiteEnvironmentProduction
+	SiteEnvironmentStaging    = stores.SiteEnvironmentStaging
 )
 
 // Re-export site functions for backward compatibility.
@@ -40,6 +43,8 @@ var (
 	NormalizeSiteStatus             = stores.NormalizeSiteStatus
 	NormalizeSiteDeploymentState    = stores.NormalizeSiteDeploymentState
 	NormalizeSiteRuntimeHealthState = stores.NormalizeSiteRuntimeHealthState
+	AllSiteEnvironments             = stores.AllSiteEnvironments
+	NormalizeSiteEnvironment        = stores.NormalizeSiteEnvironment
 )
 
 // NewSiteStoreFromDB is an alias for NewSiteStore.
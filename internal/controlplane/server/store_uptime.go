@@ -0,0 +1,14 @@
+package server
+
+import "pressluft/internal/controlplane/server/stores"
+
+// Re-export uptime types for consistency with the other site stores.
+type UptimeSettings = stores.UptimeSettings
+type UptimeCheckInput = stores.UptimeCheckInput
+type StoredUptimeCheck = stores.StoredUptimeCheck
+type UptimeSummary = stores.UptimeSummary
+type StoredUptimeIncident = stores.StoredUptimeIncident
+type UptimeStore = stores.UptimeStore
+
+// Re-export uptime functions for consistency with the other site stores.
+var NewUptimeStore = stores.NewUptimeStore
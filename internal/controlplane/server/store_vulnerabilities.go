@@ -0,0 +1,12 @@
+package server
+
+import "pressluft/internal/controlplane/server/stores"
+
+// Re-export vulnerability types for backward compatibility.
+type StoredSiteVulnerability = stores.StoredSiteVulnerability
+type SiteVulnerabilityInput = stores.SiteVulnerabilityInput
+type VulnerabilityChanges = stores.VulnerabilityChanges
+type VulnerabilityStore = stores.VulnerabilityStore
+
+// Re-export vulnerability functions for backward compatibility.
+var NewVulnerabilityStore = stores.NewVulnerabilityStore
@@ -0,0 +1,504 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"pressluft/internal/infra/s3"
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/workspace"
+)
+
+const (
+	SiteBackupStatusPending   = "pending"
+	SiteBackupStatusRunning   = "running"
+	SiteBackupStatusCompleted = "completed"
+	SiteBackupStatusFailed    = "failed"
+	SiteBackupStatusExpired   = "expired"
+
+	DefaultBackupRetentionDays = 30
+	MaxBackupRetentionDays     = 3650
+)
+
+// StoredBackupTarget is an S3-compatible bucket that receives site backups.
+// The secret access key is age-encrypted at rest like provider tokens.
+type StoredBackupTarget struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Endpoint           string `json:"endpoint"`
+	Region             string `json:"region"`
+	Bucket             string `json:"bucket"`
+	PathPrefix         string `json:"path_prefix,omitempty"`
+	PathStyle          bool   `json:"path_style"`
+	AccessKeyID        string `json:"access_key_id"`
+	SecretAccessKey    string `json:"-"`
+	SecretKeyEncrypted string `json:"-"`
+	SecretKeyKeyID     string `json:"-"`
+	SecretKeyVersion   int    `json:"-"`
+	RetentionDays      int    `json:"retention_days"`
+	CreatedAt          string `json:"created_at"`
+	UpdatedAt          string `json:"updated_at"`
+}
+
+// S3Config returns the client configuration for the target. The secret is
+// only populated on targets loaded through GetTarget.
+func (t StoredBackupTarget) S3Config() s3.Config {
+	return s3.Config{
+		Endpoint:        t.Endpoint,
+		Region:          t.Region,
+		Bucket:          t.Bucket,
+		AccessKeyID:     t.AccessKeyID,
+		SecretAccessKey: t.SecretAccessKey,
+		PathStyle:       t.PathStyle,
+	}
+}
+
+type CreateBackupTargetInput struct {
+	Name            string
+	Endpoint        string
+	Region          string
+	Bucket          string
+	PathPrefix      string
+	PathStyle       bool
+	AccessKeyID     string
+	SecretAccessKey string
+	RetentionDays   int
+}
+
+// StoredSiteBackup is one archive of a site's database and wp-content.
+type StoredSiteBackup struct {
+	ID             string `json:"id"`
+	SiteID         string `json:"site_id"`
+	ServerID       string `json:"server_id"`
+	TargetID       string `json:"target_id"`
+	TargetName     string `json:"target_name"`
+	JobID          string `json:"job_id,omitempty"`
+	Status         string `json:"status"`
+	ObjectKey      string `json:"object_key"`
+	SizeBytes      int64  `json:"size_bytes"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+	RetentionDays  int    `json:"retention_days"`
+	ExpiresAt      string `json:"expires_at,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	StartedAt      string `json:"started_at,omitempty"`
+	CompletedAt    string `json:"completed_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+type CreateSiteBackupInput struct {
+	SiteID        string
+	ServerID      string
+	TargetID      string
+	JobID         string
+	RetentionDays int
+}
+
+type BackupStore struct {
+	db *sql.DB
+}
+
+func NewBackupStore(db *sql.DB) *BackupStore {
+	return &BackupStore{db: db}
+}
+
+func AllSiteBackupStatuses() []string {
+	return []string{SiteBackupStatusPending, SiteBackupStatusRunning, SiteBackupStatusCompleted, SiteBackupStatusFailed, SiteBackupStatusExpired}
+}
+
+func NormalizeSiteBackupStatus(raw string) (string, error) {
+	status := strings.TrimSpace(raw)
+	switch status {
+	case SiteBackupStatusPending, SiteBackupStatusRunning, SiteBackupStatusCompleted, SiteBackupStatusFailed, SiteBackupStatusExpired:
+		return status, nil
+	default:
+		return "", fmt.Errorf("unsupported site backup status %q", raw)
+	}
+}
+
+func (s *BackupStore) CreateTarget(ctx context.Context, in CreateBackupTargetInput) (string, error) {
+	in, err := normalizeCreateBackupTargetInput(in)
+	if err != nil {
+		return "", err
+	}
+	targetID, err := idutil.New()
+	if err != nil {
+		return "", err
+	}
+	encrypted, keyID, version, err := security.EncryptBackupTargetSecret(in.SecretAccessKey)
+	if err != nil {
+		return "", fmt.Errorf("encrypt backup target secret: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO backup_targets (id, workspace_id, name, endpoint, region, bucket, path_prefix, path_style, access_key_id, secret_key_encrypted, secret_key_key_id, secret_key_version, retention_days, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		targetID, workspace.ForWrite(ctx), in.Name, in.Endpoint, in.Region, in.Bucket, in.PathPrefix, boolToInt(in.PathStyle), in.AccessKeyID, encrypted, keyID, version, in.RetentionDays, now, now,
+	)
+	if err != nil {
+		return "", fmt.Errorf("insert backup target: %w", err)
+	}
+	return targetID, nil
+}
+
+// ListTargets returns the backup targets of the current workspace. Secrets
+// are NOT included.
+func (s *BackupStore) ListTargets(ctx context.Context) ([]StoredBackupTarget, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, endpoint, region, bucket, path_prefix, path_style, access_key_id, retention_days, created_at, updated_at
+		 FROM backup_targets
+		 WHERE (? = '' OR workspace_id = ?)
+		 ORDER BY created_at DESC`,
+		workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list backup targets: %w", err)
+	}
+	defer rows.Close()
+
+	var out []StoredBackupTarget
+	for rows.Next() {
+		var (
+			target    StoredBackupTarget
+			pathStyle int
+		)
+		if err := rows.Scan(&target.ID, &target.Name, &target.Endpoint, &target.Region, &target.Bucket, &target.PathPrefix, &pathStyle, &target.AccessKeyID, &target.RetentionDays, &target.CreatedAt, &target.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan backup target: %w", err)
+		}
+		target.PathStyle = pathStyle != 0
+		out = append(out, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate backup targets: %w", err)
+	}
+	return out, nil
+}
+
+// GetTarget returns a backup target including its decrypted secret.
+func (s *BackupStore) GetTarget(ctx context.Context, id string) (*StoredBackupTarget, error) {
+	targetID, err := idutil.Normalize(id)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		target    StoredBackupTarget
+		pathStyle int
+	)
+	err = s.db.QueryRowContext(ctx,
+		`SELECT id, name, endpoint, region, bucket, path_prefix, path_style, access_key_id, secret_key_encrypted, secret_key_key_id, secret_key_version, retention_days, created_at, updated_at
+		 FROM backup_targets
+		 WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		targetID, workspace.Filter(ctx), workspace.Filter(ctx),
+	).Scan(&target.ID, &target.Name, &target.Endpoint, &target.Region, &target.Bucket, &target.PathPrefix, &pathStyle, &target.AccessKeyID, &target.SecretKeyEncrypted, &target.SecretKeyKeyID, &target.SecretKeyVersion, &target.RetentionDays, &target.CreatedAt, &target.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("backup target %s not found", targetID)
+		}
+		return nil, fmt.Errorf("get backup target: %w", err)
+	}
+	target.PathStyle = pathStyle != 0
+	if target.SecretKeyEncrypted != "" {
+		secret, err := security.DecryptBackupTargetSecret(target.SecretKeyEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt backup target secret: %w", err)
+		}
+		target.SecretAccessKey = secret
+	}
+	return &target, nil
+}
+
+// DeleteTarget removes a backup target that no longer holds live backups.
+func (s *BackupStore) DeleteTarget(ctx context.Context, id string) error {
+	targetID, err := idutil.Normalize(id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.GetTarget(ctx, targetID); err != nil {
+		return err
+	}
+	var inUse int
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM site_backups WHERE target_id = ? AND status != ?`,
+		targetID, SiteBackupStatusExpired,
+	).Scan(&inUse); err != nil {
+		return fmt.Errorf("count target backups: %w", err)
+	}
+	if inUse > 0 {
+		return fmt.Errorf("backup target %s still holds %d backups", targetID, inUse)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin delete backup target tx: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM site_backups WHERE target_id = ?`, targetID); err != nil {
+		return fmt.Errorf("delete expired target backups: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM backup_targets WHERE id = ?`, targetID)
+	if err != nil {
+		return fmt.Errorf("delete backup target: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("backup target %s not found", targetID)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete backup target tx: %w", err)
+	}
+	return nil
+}
+
+// CreateBackup records a pending backup. The object key is derived from the
+// target's path prefix so archives of one site stay grouped in the bucket.
+func (s *BackupStore) CreateBackup(ctx context.Context, in CreateSiteBackupInput) (string, error) {
+	siteID, err := idutil.Normalize(in.SiteID)
+	if err != nil {
+		return "", fmt.Errorf("site_id: %w", err)
+	}
+	serverID, err := idutil.Normalize(in.ServerID)
+	if err != nil {
+		return "", fmt.Errorf("server_id: %w", err)
+	}
+	targetID, err := idutil.Normalize(in.TargetID)
+	if err != nil {
+		return "", fmt.Errorf("target_id: %w", err)
+	}
+	var (
+		pathPrefix       string
+		defaultRetention int
+	)
+	scope := workspace.Filter(ctx)
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT path_prefix, retention_days FROM backup_targets WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		targetID, scope, scope,
+	).Scan(&pathPrefix, &defaultRetention); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("backup target %s not found", targetID)
+		}
+		return "", fmt.Errorf("lookup backup target: %w", err)
+	}
+	retentionDays := in.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = defaultRetention
+	}
+	if retentionDays > MaxBackupRetentionDays {
+		return "", fmt.Errorf("retention_days cannot exceed %d", MaxBackupRetentionDays)
+	}
+	backupID, err := idutil.New()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	objectKey := path.Join(pathPrefix, "sites", siteID, now.Format("20060102T150405Z")+"-"+backupID+".tar.gz")
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO site_backups (id, site_id, server_id, target_id, job_id, status, object_key, retention_days, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		backupID, siteID, serverID, targetID, nullableString(in.JobID), SiteBackupStatusPending, objectKey, retentionDays, now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return "", fmt.Errorf("insert site backup: %w", err)
+	}
+	return backupID, nil
+}
+
+func (s *BackupStore) GetBackup(ctx context.Context, id string) (*StoredSiteBackup, error) {
+	backupID, err := idutil.Normalize(id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, siteBackupSelect+` WHERE b.id = ? AND `+siteBackupWorkspaceCondition, backupID, workspace.Filter(ctx), workspace.Filter(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get site backup: %w", err)
+	}
+	defer rows.Close()
+	backups, err := scanSiteBackups(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("site backup %s not found", backupID)
+	}
+	return &backups[0], nil
+}
+
+func (s *BackupStore) ListBySite(ctx context.Context, siteID string) ([]StoredSiteBackup, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, siteBackupSelect+` WHERE b.site_id = ? AND `+siteBackupWorkspaceCondition+` ORDER BY b.created_at DESC, b.id DESC`, normalized, workspace.Filter(ctx), workspace.Filter(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list site backups: %w", err)
+	}
+	defer rows.Close()
+	return scanSiteBackups(rows)
+}
+
+// ListExpired returns completed backups of a site whose retention window
+// ended at or before now.
+func (s *BackupStore) ListExpired(ctx context.Context, siteID string, now time.Time) ([]StoredSiteBackup, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		siteBackupSelect+` WHERE b.site_id = ? AND b.status = ? AND b.expires_at IS NOT NULL AND b.expires_at <= ? ORDER BY b.expires_at ASC`,
+		normalized, SiteBackupStatusCompleted, now.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list expired site backups: %w", err)
+	}
+	defer rows.Close()
+	return scanSiteBackups(rows)
+}
+
+func (s *BackupStore) MarkRunning(ctx context.Context, id, jobID string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return s.updateBackup(ctx, id,
+		`UPDATE site_backups SET status = ?, job_id = COALESCE(?, job_id), started_at = ?, updated_at = ? WHERE id = ?`,
+		SiteBackupStatusRunning, nullableString(jobID), now, now,
+	)
+}
+
+// Complete stores the archive metadata reported by the server and starts the
+// retention window.
+func (s *BackupStore) Complete(ctx context.Context, id string, sizeBytes int64, checksumSHA256 string) error {
+	if sizeBytes <= 0 {
+		return fmt.Errorf("size_bytes must be positive")
+	}
+	checksumSHA256 = strings.ToLower(strings.TrimSpace(checksumSHA256))
+	if len(checksumSHA256) != 64 {
+		return fmt.Errorf("checksum_sha256 must be a hex-encoded SHA-256 digest")
+	}
+	backup, err := s.GetBackup(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	expiresAt := now.AddDate(0, 0, backup.RetentionDays).Format(time.RFC3339)
+	return s.updateBackup(ctx, backup.ID,
+		`UPDATE site_backups SET status = ?, size_bytes = ?, checksum_sha256 = ?, expires_at = ?, last_error = NULL, completed_at = ?, updated_at = ? WHERE id = ?`,
+		SiteBackupStatusCompleted, sizeBytes, checksumSHA256, expiresAt, now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+}
+
+func (s *BackupStore) Fail(ctx context.Context, id, lastError string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return s.updateBackup(ctx, id,
+		`UPDATE site_backups SET status = ?, last_error = ?, completed_at = ?, updated_at = ? WHERE id = ?`,
+		SiteBackupStatusFailed, nullableString(lastError), now, now,
+	)
+}
+
+func (s *BackupStore) MarkExpired(ctx context.Context, id string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return s.updateBackup(ctx, id,
+		`UPDATE site_backups SET status = ?, updated_at = ? WHERE id = ?`,
+		SiteBackupStatusExpired, now,
+	)
+}
+
+func (s *BackupStore) updateBackup(ctx context.Context, id, query string, args ...any) error {
+	backupID, err := idutil.Normalize(id)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, query, append(args, backupID)...)
+	if err != nil {
+		return fmt.Errorf("update site backup: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("site backup %s not found", backupID)
+	}
+	return nil
+}
+
+const siteBackupSelect = `SELECT b.id, b.site_id, b.server_id, b.target_id, COALESCE(t.name, ''), COALESCE(b.job_id, ''), b.status, b.object_key, b.size_bytes, COALESCE(b.checksum_sha256, ''), b.retention_days, COALESCE(b.expires_at, ''), COALESCE(b.last_error, ''), COALESCE(b.started_at, ''), COALESCE(b.completed_at, ''), b.created_at, b.updated_at
+		 FROM site_backups b
+		 LEFT JOIN backup_targets t ON t.id = b.target_id`
+
+// siteBackupWorkspaceCondition scopes backups through their site; it takes
+// workspace.Filter twice.
+const siteBackupWorkspaceCondition = `(? = '' OR b.site_id IN (SELECT id FROM sites WHERE workspace_id = ?))`
+
+func scanSiteBackups(rows *sql.Rows) ([]StoredSiteBackup, error) {
+	var out []StoredSiteBackup
+	for rows.Next() {
+		var backup StoredSiteBackup
+		if err := rows.Scan(
+			&backup.ID,
+			&backup.SiteID,
+			&backup.ServerID,
+			&backup.TargetID,
+			&backup.TargetName,
+			&backup.JobID,
+			&backup.Status,
+			&backup.ObjectKey,
+			&backup.SizeBytes,
+			&backup.ChecksumSHA256,
+			&backup.RetentionDays,
+			&backup.ExpiresAt,
+			&backup.LastError,
+			&backup.StartedAt,
+			&backup.CompletedAt,
+			&backup.CreatedAt,
+			&backup.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan site backup: %w", err)
+		}
+		if _, err := NormalizeSiteBackupStatus(backup.Status); err != nil {
+			return nil, fmt.Errorf("scan site backup status: %w", err)
+		}
+		out = append(out, backup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate site backups: %w", err)
+	}
+	return out, nil
+}
+
+func normalizeCreateBackupTargetInput(in CreateBackupTargetInput) (CreateBackupTargetInput, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Endpoint = strings.TrimRight(strings.TrimSpace(in.Endpoint), "/")
+	in.Region = strings.TrimSpace(in.Region)
+	in.Bucket = strings.TrimSpace(in.Bucket)
+	in.PathPrefix = strings.Trim(strings.TrimSpace(in.PathPrefix), "/")
+	in.AccessKeyID = strings.TrimSpace(in.AccessKeyID)
+	if in.Name == "" {
+		return in, fmt.Errorf("name is required")
+	}
+	if in.Endpoint == "" {
+		return in, fmt.Errorf("endpoint is required")
+	}
+	parsed, err := url.Parse(in.Endpoint)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return in, fmt.Errorf("endpoint must be an http or https URL")
+	}
+	if in.Region == "" {
+		in.Region = "us-east-1"
+	}
+	if in.Bucket == "" {
+		return in, fmt.Errorf("bucket is required")
+	}
+	if strings.Contains(in.PathPrefix, "..") {
+		return in, fmt.Errorf("path_prefix cannot contain '..'")
+	}
+	if in.AccessKeyID == "" {
+		return in, fmt.Errorf("access_key_id is required")
+	}
+	if strings.TrimSpace(in.SecretAccessKey) == "" {
+		return in, fmt.Errorf("secret_access_key is required")
+	}
+	if in.RetentionDays == 0 {
+		in.RetentionDays = DefaultBackupRetentionDays
+	}
+	if in.RetentionDays < 1 || in.RetentionDays > MaxBackupRetentionDays {
+		return in, fmt.Errorf("retention_days must be between 1 and %d", MaxBackupRetentionDays)
+	}
+	return in, nil
+}
@@ -0,0 +1,157 @@
+package stores
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupStoreTargetSecretIsEncryptedAtRest(t *testing.T) {
+	db := mustOpenTestDB(t)
+	store := NewBackupStore(db)
+
+	targetID, err := store.CreateTarget(context.Background(), CreateBackupTargetInput{
+		Name:            "Offsite",
+		Endpoint:        "https://s3.example.test/",
+		Bucket:          "agency-backups",
+		PathPrefix:      "/pressluft/",
+		PathStyle:       true,
+		AccessKeyID:     "AKIAEXAMPLE",
+		SecretAccessKey: "super-secret",
+	})
+	if err != nil {
+		t.Fatalf("create target: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow(`SELECT secret_key_encrypted FROM backup_targets WHERE id = ?`, targetID).Scan(&stored); err != nil {
+		t.Fatalf("read stored secret: %v", err)
+	}
+	if strings.Contains(stored, "super-secret") {
+		t.Fatal("secret access key stored in plaintext")
+	}
+
+	target, err := store.GetTarget(context.Background(), targetID)
+	if err != nil {
+		t.Fatalf("get target: %v", err)
+	}
+	if target.SecretAccessKey != "super-secret" {
+		t.Fatalf("secret = %q, want decrypted secret", target.SecretAccessKey)
+	}
+	if target.Endpoint != "https://s3.example.test" || target.PathPrefix != "pressluft" {
+		t.Fatalf("endpoint/prefix = %q/%q, want normalized values", target.Endpoint, target.PathPrefix)
+	}
+	if target.Region != "us-east-1" || target.RetentionDays != DefaultBackupRetentionDays {
+		t.Fatalf("region/retention = %q/%d, want defaults", target.Region, target.RetentionDays)
+	}
+
+	targets, err := store.ListTargets(context.Background())
+	if err != nil {
+		t.Fatalf("list targets: %v", err)
+	}
+	if len(targets) != 1 || targets[0].SecretAccessKey != "" {
+		t.Fatalf("targets = %+v, want one target without secret", targets)
+	}
+}
+
+func TestBackupStoreLifecycleAndRetention(t *testing.T) {
+	db := mustOpenTestDB(t)
+	store := NewBackupStore(db)
+	serverID := mustInsertServerWithStatus(t, db, "ready")
+	siteID := "00000000-0000-7000-8000-0000000000aa"
+	targetID := mustCreateBackupTarget(t, store, 7)
+
+	backupID, err := store.CreateBackup(context.Background(), CreateSiteBackupInput{SiteID: siteID, ServerID: serverID, TargetID: targetID})
+	if err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	backup, err := store.GetBackup(context.Background(), backupID)
+	if err != nil {
+		t.Fatalf("get backup: %v", err)
+	}
+	if backup.Status != SiteBackupStatusPending || backup.RetentionDays != 7 || backup.TargetName != "Offsite" {
+		t.Fatalf("backup = %+v, want pending backup inheriting target retention", backup)
+	}
+	if !strings.HasPrefix(backup.ObjectKey, "pressluft/sites/"+siteID+"/") || !strings.HasSuffix(backup.ObjectKey, backupID+".tar.gz") {
+		t.Fatalf("object_key = %q, want prefixed per-site key", backup.ObjectKey)
+	}
+
+	if err := store.MarkRunning(context.Background(), backupID, "job-1"); err != nil {
+		t.Fatalf("mark running: %v", err)
+	}
+	if err := store.Complete(context.Background(), backupID, 0, strings.Repeat("a", 64)); err == nil {
+		t.Fatal("expected zero-size completion to fail")
+	}
+	if err := store.Complete(context.Background(), backupID, 2048, strings.Repeat("A", 64)); err != nil {
+		t.Fatalf("complete backup: %v", err)
+	}
+	backup, err = store.GetBackup(context.Background(), backupID)
+	if err != nil {
+		t.Fatalf("get completed backup: %v", err)
+	}
+	if backup.Status != SiteBackupStatusCompleted || backup.SizeBytes != 2048 || backup.ChecksumSHA256 != strings.Repeat("a", 64) || backup.JobID != "job-1" {
+		t.Fatalf("completed backup = %+v", backup)
+	}
+
+	expired, err := store.ListExpired(context.Background(), siteID, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("list expired now: %v", err)
+	}
+	if len(expired) != 0 {
+		t.Fatalf("expired now = %d, want 0", len(expired))
+	}
+	expired, err = store.ListExpired(context.Background(), siteID, time.Now().UTC().AddDate(0, 0, 8))
+	if err != nil {
+		t.Fatalf("list expired later: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != backupID {
+		t.Fatalf("expired later = %+v, want backup %s", expired, backupID)
+	}
+
+	if err := store.DeleteTarget(context.Background(), targetID); err == nil || !strings.Contains(err.Error(), "still holds") {
+		t.Fatalf("delete in-use target error = %v, want still holds", err)
+	}
+	if err := store.MarkExpired(context.Background(), backupID); err != nil {
+		t.Fatalf("mark expired: %v", err)
+	}
+	if err := store.DeleteTarget(context.Background(), targetID); err != nil {
+		t.Fatalf("delete target after expiry: %v", err)
+	}
+}
+
+func TestBackupStoreRejectsInvalidTargets(t *testing.T) {
+	db := mustOpenTestDB(t)
+	store := NewBackupStore(db)
+	cases := []CreateBackupTargetInput{
+		{Endpoint: "https://s3.example.test", Bucket: "b", AccessKeyID: "a", SecretAccessKey: "s"},
+		{Name: "x", Endpoint: "s3.example.test", Bucket: "b", AccessKeyID: "a", SecretAccessKey: "s"},
+		{Name: "x", Endpoint: "https://s3.example.test", AccessKeyID: "a", SecretAccessKey: "s"},
+		{Name: "x", Endpoint: "https://s3.example.test", Bucket: "b", SecretAccessKey: "s"},
+		{Name: "x", Endpoint: "https://s3.example.test", Bucket: "b", AccessKeyID: "a"},
+		{Name: "x", Endpoint: "https://s3.example.test", Bucket: "b", AccessKeyID: "a", SecretAccessKey: "s", RetentionDays: -1},
+	}
+	for i, in := range cases {
+		if _, err := store.CreateTarget(context.Background(), in); err == nil {
+			t.Fatalf("case %d: expected validation error", i)
+		}
+	}
+}
+
+func mustCreateBackupTarget(t *testing.T, store *BackupStore, retentionDays int) string {
+	t.Helper()
+	targetID, err := store.CreateTarget(context.Background(), CreateBackupTargetInput{
+		Name:            "Offsite",
+		Endpoint:        "https://s3.example.test",
+		Bucket:          "agency-backups",
+		PathPrefix:      "pressluft",
+		PathStyle:       true,
+		AccessKeyID:     "AKIAEXAMPLE",
+		SecretAccessKey: "super-secret",
+		RetentionDays:   retentionDays,
+	})
+	if err != nil {
+		t.Fatalf("create backup target: %v", err)
+	}
+	return targetID
+}
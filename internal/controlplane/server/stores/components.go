@@ -0,0 +1,152 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+const (
+	SiteComponentTypeCore   = "core"
+	SiteComponentTypePlugin = "plugin"
+	SiteComponentTypeTheme  = "theme"
+)
+
+// StoredSiteComponent is the last known version of a WordPress core, plugin,
+// or theme install on a site. UpdateVersion is empty when it is current.
+type StoredSiteComponent struct {
+	SiteID        string `json:"site_id"`
+	SiteName      string `json:"site_name"`
+	ServerID      string `json:"server_id"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	Title         string `json:"title,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Version       string `json:"version"`
+	UpdateVersion string `json:"update_version,omitempty"`
+	CheckedAt     string `json:"checked_at"`
+}
+
+type SiteComponentInput struct {
+	Type          string
+	Name          string
+	Title         string
+	Status        string
+	Version       string
+	UpdateVersion string
+}
+
+// ComponentStore persists the WordPress inventory reported by each site's
+// agent so outdated components can be listed without asking every server.
+type ComponentStore struct {
+	db *sql.DB
+}
+
+func NewComponentStore(db *sql.DB) *ComponentStore {
+	return &ComponentStore{db: db}
+}
+
+// ReplaceForSite swaps a site's inventory for a fresh report.
+func (s *ComponentStore) ReplaceForSite(ctx context.Context, siteID string, components []SiteComponentInput, checkedAt time.Time) error {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return fmt.Errorf("site_id: %w", err)
+	}
+	checked := checkedAt.UTC().Format(time.RFC3339)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin replace site components tx: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM site_components WHERE site_id = ?`, normalized); err != nil {
+		return fmt.Errorf("clear site components: %w", err)
+	}
+	for _, component := range components {
+		componentType := strings.TrimSpace(component.Type)
+		switch componentType {
+		case SiteComponentTypeCore, SiteComponentTypePlugin, SiteComponentTypeTheme:
+		default:
+			return fmt.Errorf("unsupported site component type %q", component.Type)
+		}
+		name := strings.TrimSpace(component.Name)
+		if name == "" {
+			return fmt.Errorf("site component name is required")
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO site_components (site_id, component_type, name, title, status, version, update_version, checked_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			normalized, componentType, name, strings.TrimSpace(component.Title), strings.TrimSpace(component.Status),
+			strings.TrimSpace(component.Version), strings.TrimSpace(component.UpdateVersion), checked,
+		); err != nil {
+			return fmt.Errorf("insert site component: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit replace site components tx: %w", err)
+	}
+	return nil
+}
+
+func (s *ComponentStore) ListBySite(ctx context.Context, siteID string) ([]StoredSiteComponent, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, siteComponentSelect+` WHERE c.site_id = ? ORDER BY `+siteComponentOrder, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("list site components: %w", err)
+	}
+	defer rows.Close()
+	return scanSiteComponents(rows)
+}
+
+// ListOutdated returns every component with an available update across the
+// workspace's sites, grouped by site.
+func (s *ComponentStore) ListOutdated(ctx context.Context) ([]StoredSiteComponent, error) {
+	rows, err := s.db.QueryContext(ctx,
+		siteComponentSelect+` WHERE c.update_version != '' AND (? = '' OR s.workspace_id = ?) ORDER BY s.name ASC, c.site_id ASC, `+siteComponentOrder,
+		workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list outdated site components: %w", err)
+	}
+	defer rows.Close()
+	return scanSiteComponents(rows)
+}
+
+const siteComponentSelect = `SELECT c.site_id, s.name, s.server_id, c.component_type, c.name, c.title, c.status, c.version, c.update_version, c.checked_at
+		 FROM site_components c
+		 JOIN sites s ON s.id = c.site_id`
+
+const siteComponentOrder = `CASE c.component_type WHEN 'core' THEN 0 WHEN 'plugin' THEN 1 ELSE 2 END, c.name ASC`
+
+func scanSiteComponents(rows *sql.Rows) ([]StoredSiteComponent, error) {
+	var out []StoredSiteComponent
+	for rows.Next() {
+		var component StoredSiteComponent
+		if err := rows.Scan(
+			&component.SiteID,
+			&component.SiteName,
+			&component.ServerID,
+			&component.Type,
+			&component.Name,
+			&component.Title,
+			&component.Status,
+			&component.Version,
+			&component.UpdateVersion,
+			&component.CheckedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan site component: %w", err)
+		}
+		out = append(out, component)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate site components: %w", err)
+	}
+	return out, nil
+}
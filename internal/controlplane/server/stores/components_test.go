@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestComponentStoreReplacesInventoryAndListsOutdated(t *testing.T) {
+	db := mustOpenTestDB(t)
+	sites := NewSiteStore(db)
+	store := NewComponentStore(db)
+	serverID := mustInsertServerWithStatus(t, db, "ready")
+	siteID, err := sites.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+
+	checkedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.ReplaceForSite(context.Background(), siteID, []SiteComponentInput{
+		{Type: SiteComponentTypeTheme, Name: "storefront", Version: "4.5.0"},
+		{Type: SiteComponentTypePlugin, Name: "woocommerce", Title: "WooCommerce", Status: "active", Version: "8.6.0", UpdateVersion: "8.7.0"},
+		{Type: SiteComponentTypeCore, Name: "wordpress", Version: "6.4.3", UpdateVersion: "6.5.2"},
+	}, checkedAt); err != nil {
+		t.Fatalf("replace inventory: %v", err)
+	}
+
+	components, err := store.ListBySite(context.Background(), siteID)
+	if err != nil {
+		t.Fatalf("list components: %v", err)
+	}
+	if len(components) != 3 || components[0].Type != SiteComponentTypeCore || components[1].Name != "woocommerce" || components[2].Type != SiteComponentTypeTheme {
+		t.Fatalf("components = %+v, want core, plugin, theme order", components)
+	}
+	if components[0].SiteName != "Shop" || components[0].ServerID != serverID || components[0].CheckedAt != "2026-03-01T12:00:00Z" {
+		t.Fatalf("core = %+v, want site metadata and checked_at", components[0])
+	}
+
+	outdated, err := store.ListOutdated(context.Background())
+	if err != nil {
+		t.Fatalf("list outdated: %v", err)
+	}
+	if len(outdated) != 2 {
+		t.Fatalf("outdated = %+v, want core and woocommerce", outdated)
+	}
+
+	if err := store.ReplaceForSite(context.Background(), siteID, []SiteComponentInput{
+		{Type: SiteComponentTypeCore, Name: "wordpress", Version: "6.5.2"},
+	}, checkedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("replace inventory again: %v", err)
+	}
+	outdated, err = store.ListOutdated(context.Background())
+	if err != nil || len(outdated) != 0 {
+		t.Fatalf("outdated after update = %+v (err %v), want none", outdated, err)
+	}
+	if err := store.ReplaceForSite(context.Background(), siteID, []SiteComponentInput{{Type: "mu-plugin", Name: "x"}}, checkedAt); err == nil {
+		t.Fatal("expected unsupported component type to fail")
+	}
+}
@@ -0,0 +1,371 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+// ServerPriceInput is the list price a server is billed at from
+// EffectiveFrom on. Prices are the provider's decimal strings, e.g. "0.0080".
+type ServerPriceInput struct {
+	ServerID      string
+	ServerType    string
+	Location      string
+	HourlyGross   string
+	MonthlyGross  string
+	Currency      string
+	EffectiveFrom time.Time
+}
+
+// ServerCost is a server's estimated cost in one month and currency.
+// ServerType is the type it had last in that month.
+type ServerCost struct {
+	ServerID      string
+	ServerName    string
+	ProviderType  string
+	ServerType    string
+	WorkspaceID   string
+	WorkspaceName string
+	Currency      string
+	Hours         int64
+	AmountCents   int64
+	Sites         int
+}
+
+// SiteCost is a site's share of its server's cost.
+type SiteCost struct {
+	SiteID        string
+	SiteName      string
+	ServerID      string
+	ServerName    string
+	WorkspaceID   string
+	WorkspaceName string
+	Currency      string
+	AmountCents   int64
+}
+
+// WorkspaceCost is the cost of a workspace's servers in one currency.
+type WorkspaceCost struct {
+	WorkspaceID   string
+	WorkspaceName string
+	Currency      string
+	Servers       int
+	Sites         int
+	AmountCents   int64
+}
+
+// CostTotal is the cost of every reported server in one currency.
+type CostTotal struct {
+	Currency    string
+	AmountCents int64
+}
+
+// CostReport estimates what servers cost in one calendar month (UTC).
+type CostReport struct {
+	Month      time.Time
+	Servers    []ServerCost
+	Sites      []SiteCost
+	Workspaces []WorkspaceCost
+	Totals     []CostTotal
+}
+
+// CostStore records the list prices servers are billed at and estimates
+// their cost from them.
+type CostStore struct {
+	db *sql.DB
+}
+
+func NewCostStore(db *sql.DB) *CostStore {
+	return &CostStore{db: db}
+}
+
+// RecordPrice starts a price period for the server and ends the previous
+// one. Recording the price the server already has is a no-op, so retried
+// jobs do not split periods.
+func (s *CostStore) RecordPrice(ctx context.Context, in ServerPriceInput) error {
+	normalized, err := idutil.Normalize(in.ServerID)
+	if err != nil {
+		return fmt.Errorf("server_id: %w", err)
+	}
+	if _, err := parsePrice(in.HourlyGross); err != nil {
+		return fmt.Errorf("hourly price: %w", err)
+	}
+	if _, err := parsePrice(in.MonthlyGross); err != nil {
+		return fmt.Errorf("monthly price: %w", err)
+	}
+	currency := strings.ToUpper(strings.TrimSpace(in.Currency))
+	if currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	from := in.EffectiveFrom.UTC()
+	if from.IsZero() {
+		from = time.Now().UTC()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var serverType, location, hourly, monthly, openCurrency string
+	err = tx.QueryRowContext(ctx,
+		`SELECT server_type, location, hourly_gross, monthly_gross, currency FROM server_prices WHERE server_id = ? AND effective_until IS NULL`,
+		normalized,
+	).Scan(&serverType, &location, &hourly, &monthly, &openCurrency)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("get open server price: %w", err)
+	case serverType == in.ServerType && location == in.Location && hourly == in.HourlyGross && monthly == in.MonthlyGross && openCurrency == currency:
+		return nil
+	default:
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE server_prices SET effective_until = ? WHERE server_id = ? AND effective_until IS NULL`,
+			from.Format(time.RFC3339), normalized,
+		); err != nil {
+			return fmt.Errorf("close server price: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO server_prices (server_id, server_type, location, hourly_gross, monthly_gross, currency, effective_from)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		normalized, in.ServerType, in.Location, in.HourlyGross, in.MonthlyGross, currency, from.Format(time.RFC3339),
+	); err != nil {
+		return fmt.Errorf("record server price: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit server price: %w", err)
+	}
+	return nil
+}
+
+// ClosePrice ends the server's open price period at at, e.g. when the
+// server is deleted. A server without one is not an error.
+func (s *CostStore) ClosePrice(ctx context.Context, serverID string, at time.Time) error {
+	normalized, err := idutil.Normalize(serverID)
+	if err != nil {
+		return fmt.Errorf("server_id: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE server_prices SET effective_until = ? WHERE server_id = ? AND effective_until IS NULL`,
+		at.UTC().Format(time.RFC3339), normalized,
+	); err != nil {
+		return fmt.Errorf("close server price: %w", err)
+	}
+	return nil
+}
+
+// MonthlyReport estimates the cost of every server in the month containing
+// month, up to now for the running month.
+//
+// Like the providers, a price period is billed per started hour and capped
+// at its monthly price. A server's cost is split evenly across the sites it
+// hosts; servers without sites only count towards their workspace.
+func (s *CostStore) MonthlyReport(ctx context.Context, month, now time.Time) (*CostReport, error) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	if now = now.UTC(); now.Before(end) {
+		end = now
+	}
+	report := &CostReport{Month: start}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT p.server_id, s.name, s.provider_type, s.workspace_id, COALESCE(w.name, ''),
+		        p.server_type, p.hourly_gross, p.monthly_gross, p.currency, p.effective_from, p.effective_until
+		 FROM server_prices p
+		 JOIN servers s ON s.id = p.server_id
+		 LEFT JOIN workspaces w ON w.id = s.workspace_id
+		 WHERE p.effective_from < ? AND (p.effective_until IS NULL OR p.effective_until > ?)
+		   AND (? = '' OR s.workspace_id = ?)
+		 ORDER BY COALESCE(w.name, ''), s.workspace_id, s.name, s.id, p.effective_from`,
+		end.Format(time.RFC3339), start.Format(time.RFC3339), workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list server prices: %w", err)
+	}
+	defer rows.Close()
+
+	var servers []*ServerCost
+	amounts := map[*ServerCost]*big.Rat{}
+	byKey := map[string]*ServerCost{}
+	for rows.Next() {
+		var cost ServerCost
+		var hourlyRaw, monthlyRaw, fromRaw string
+		var untilRaw sql.NullString
+		if err := rows.Scan(&cost.ServerID, &cost.ServerName, &cost.ProviderType, &cost.WorkspaceID, &cost.WorkspaceName,
+			&cost.ServerType, &hourlyRaw, &monthlyRaw, &cost.Currency, &fromRaw, &untilRaw); err != nil {
+			return nil, fmt.Errorf("scan server price: %w", err)
+		}
+		hourly, err := parsePrice(hourlyRaw)
+		if err != nil {
+			return nil, fmt.Errorf("server %s hourly price: %w", cost.ServerID, err)
+		}
+		monthly, err := parsePrice(monthlyRaw)
+		if err != nil {
+			return nil, fmt.Errorf("server %s monthly price: %w", cost.ServerID, err)
+		}
+		from, err := time.Parse(time.RFC3339, fromRaw)
+		if err != nil {
+			return nil, fmt.Errorf("server %s price start: %w", cost.ServerID, err)
+		}
+		until := end
+		if untilRaw.Valid {
+			parsed, err := time.Parse(time.RFC3339, untilRaw.String)
+			if err != nil {
+				return nil, fmt.Errorf("server %s price end: %w", cost.ServerID, err)
+			}
+			if parsed.Before(until) {
+				until = parsed
+			}
+		}
+		if from.Before(start) {
+			from = start
+		}
+		if !until.After(from) {
+			continue
+		}
+
+		key := cost.ServerID + "\x00" + cost.Currency
+		server := byKey[key]
+		if server == nil {
+			server = &cost
+			byKey[key] = server
+			amounts[server] = new(big.Rat)
+			servers = append(servers, server)
+		}
+		server.ServerType = cost.ServerType
+		hours := int64((until.Sub(from) + time.Hour - 1) / time.Hour)
+		server.Hours += hours
+		amount := new(big.Rat).Mul(hourly, new(big.Rat).SetInt64(hours))
+		if monthly.Sign() > 0 && amount.Cmp(monthly) > 0 {
+			amount = monthly
+		}
+		amounts[server].Add(amounts[server], amount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate server prices: %w", err)
+	}
+
+	sites, err := s.listSitesByServer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := map[string]*WorkspaceCost{}
+	var workspaceOrder []string
+	totals := map[string]int64{}
+	for _, server := range servers {
+		server.AmountCents = roundCents(amounts[server])
+		hosted := sites[server.ServerID]
+		server.Sites = len(hosted)
+		report.Servers = append(report.Servers, *server)
+		totals[server.Currency] += server.AmountCents
+
+		key := server.WorkspaceID + "\x00" + server.Currency
+		rollup := workspaces[key]
+		if rollup == nil {
+			rollup = &WorkspaceCost{WorkspaceID: server.WorkspaceID, WorkspaceName: server.WorkspaceName, Currency: server.Currency}
+			workspaces[key] = rollup
+			workspaceOrder = append(workspaceOrder, key)
+		}
+		rollup.Servers++
+		rollup.Sites += len(hosted)
+		rollup.AmountCents += server.AmountCents
+
+		for i, share := range splitCents(server.AmountCents, len(hosted)) {
+			report.Sites = append(report.Sites, SiteCost{
+				SiteID:        hosted[i].id,
+				SiteName:      hosted[i].name,
+				ServerID:      server.ServerID,
+				ServerName:    server.ServerName,
+				WorkspaceID:   server.WorkspaceID,
+				WorkspaceName: server.WorkspaceName,
+				Currency:      server.Currency,
+				AmountCents:   share,
+			})
+		}
+	}
+	for _, key := range workspaceOrder {
+		report.Workspaces = append(report.Workspaces, *workspaces[key])
+	}
+	for currency, cents := range totals {
+		report.Totals = append(report.Totals, CostTotal{Currency: currency, AmountCents: cents})
+	}
+	slices.SortFunc(report.Totals, func(a, b CostTotal) int { return strings.Compare(a.Currency, b.Currency) })
+	return report, nil
+}
+
+type costSite struct {
+	id   string
+	name string
+}
+
+func (s *CostStore) listSitesByServer(ctx context.Context) (map[string][]costSite, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, server_id FROM sites WHERE (? = '' OR workspace_id = ?) ORDER BY name, id`,
+		workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list sites: %w", err)
+	}
+	defer rows.Close()
+	out := map[string][]costSite{}
+	for rows.Next() {
+		var site costSite
+		var serverID string
+		if err := rows.Scan(&site.id, &site.name, &serverID); err != nil {
+			return nil, fmt.Errorf("scan site: %w", err)
+		}
+		out[serverID] = append(out[serverID], site)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sites: %w", err)
+	}
+	return out, nil
+}
+
+// parsePrice parses a non-negative decimal price such as "0.0080".
+func parsePrice(raw string) (*big.Rat, error) {
+	price, ok := new(big.Rat).SetString(strings.TrimSpace(raw))
+	if !ok || price.Sign() < 0 {
+		return nil, fmt.Errorf("invalid price %q", raw)
+	}
+	return price, nil
+}
+
+// roundCents rounds amount to whole cents, halves away from zero.
+func roundCents(amount *big.Rat) int64 {
+	scaled := new(big.Rat).Mul(amount, big.NewRat(100, 1))
+	num := new(big.Int).Mul(scaled.Num(), big.NewInt(2))
+	num.Add(num, scaled.Denom())
+	den := new(big.Int).Mul(scaled.Denom(), big.NewInt(2))
+	return new(big.Int).Quo(num, den).Int64()
+}
+
+// splitCents divides cents into n shares that differ by at most one cent and
+// add up to cents. The first shares get the remainder.
+func splitCents(cents int64, n int) []int64 {
+	if n <= 0 {
+		return nil
+	}
+	shares := make([]int64, n)
+	base, remainder := cents/int64(n), cents%int64(n)
+	for i := range shares {
+		shares[i] = base
+		if int64(i) < remainder {
+			shares[i]++
+		}
+	}
+	return shares
+}
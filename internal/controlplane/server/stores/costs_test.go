@@ -0,0 +1,83 @@
+package stores
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCostStoreProratesPricePeriodsAndSplitsAcrossSites(t *testing.T) {
+	db := mustOpenTestDB(t)
+	ctx := context.Background()
+	store := NewCostStore(db)
+	resized := mustInsertServerWithStatus(t, db, "deleted")
+	capped := mustInsertServerWithStatus(t, db, "ready")
+	for _, name := range []string{"Shop", "Blog"} {
+		if _, err := NewSiteStore(db).Create(ctx, CreateSiteInput{
+			ServerID:            resized,
+			Name:                name,
+			WordPressAdminEmail: "owner@example.test",
+			Status:              SiteStatusActive,
+		}); err != nil {
+			t.Fatalf("create site %s: %v", name, err)
+		}
+	}
+
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	small := ServerPriceInput{ServerID: resized, ServerType: "cx22", Location: "fsn1", HourlyGross: "0.0080", MonthlyGross: "5.0000", Currency: "eur", EffectiveFrom: march}
+	for range 2 {
+		// The second, unchanged price is a retried job and keeps the period.
+		if err := store.RecordPrice(ctx, small); err != nil {
+			t.Fatalf("record price: %v", err)
+		}
+	}
+	large := small
+	large.ServerType, large.HourlyGross, large.MonthlyGross, large.EffectiveFrom = "cx32", "0.0160", "10.0000", march.AddDate(0, 0, 10)
+	if err := store.RecordPrice(ctx, large); err != nil {
+		t.Fatalf("record resized price: %v", err)
+	}
+	if err := store.ClosePrice(ctx, resized, march.AddDate(0, 0, 10).Add(12*time.Hour+30*time.Minute)); err != nil {
+		t.Fatalf("close price: %v", err)
+	}
+	if err := store.RecordPrice(ctx, ServerPriceInput{ServerID: capped, ServerType: "s-2vcpu", Location: "fra1", HourlyGross: "0.05", MonthlyGross: "20.00", Currency: "USD", EffectiveFrom: march.AddDate(0, 0, -14)}); err != nil {
+		t.Fatalf("record capped price: %v", err)
+	}
+	var periods int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM server_prices`).Scan(&periods); err != nil || periods != 3 {
+		t.Fatalf("price periods = %d (err %v), want 3", periods, err)
+	}
+
+	report, err := store.MonthlyReport(ctx, march, march.AddDate(0, 1, 1))
+	if err != nil {
+		t.Fatalf("MonthlyReport() error = %v", err)
+	}
+	// 240 hours at 0.008 and 13 started hours at 0.016 make 2.128 EUR; a
+	// whole month at 0.05 USD is capped at the monthly 20 USD.
+	if len(report.Servers) != 2 {
+		t.Fatalf("servers = %+v", report.Servers)
+	}
+	if got := report.Servers[0]; got.ServerID != resized || got.ServerType != "cx32" || got.Hours != 253 || got.AmountCents != 213 || got.Currency != "EUR" || got.Sites != 2 {
+		t.Fatalf("resized server cost = %+v", got)
+	}
+	if got := report.Servers[1]; got.ServerID != capped || got.Hours != 744 || got.AmountCents != 2000 || got.Sites != 0 {
+		t.Fatalf("capped server cost = %+v", got)
+	}
+	if len(report.Sites) != 2 || report.Sites[0].SiteName != "Blog" || report.Sites[0].AmountCents != 107 || report.Sites[1].AmountCents != 106 {
+		t.Fatalf("sites = %+v, want the server cost split with the odd cent first", report.Sites)
+	}
+	if len(report.Workspaces) != 2 || report.Workspaces[0].WorkspaceName != "Default" || report.Workspaces[0].Sites != 2 || report.Workspaces[1].AmountCents != 2000 {
+		t.Fatalf("workspaces = %+v, want one roll-up per currency", report.Workspaces)
+	}
+	if len(report.Totals) != 2 || report.Totals[0] != (CostTotal{Currency: "EUR", AmountCents: 213}) || report.Totals[1] != (CostTotal{Currency: "USD", AmountCents: 2000}) {
+		t.Fatalf("totals = %+v", report.Totals)
+	}
+
+	// The running month only counts the hours up to now.
+	running, err := store.MonthlyReport(ctx, march, march.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("MonthlyReport() for the running month error = %v", err)
+	}
+	if len(running.Servers) != 2 || running.Servers[0].AmountCents != 19 || running.Servers[1].AmountCents != 120 {
+		t.Fatalf("running month servers = %+v", running.Servers)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 )
 
 const (
@@ -171,12 +172,14 @@ func (s *DomainStore) createTx(ctx context.Context, tx *sql.Tx, in CreateDomainI
 	}
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO domains (
-			id, hostname, kind, source, dns_state, routing_state, dns_status_message, routing_status_message,
+			id, workspace_id, hostname, kind, source, dns_state, routing_state, dns_status_message, routing_status_message,
 			last_checked_at, site_id, parent_domain_id, is_primary, created_at, updated_at
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, COALESCE((SELECT workspace_id FROM sites WHERE id = ?), ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		publicID,
+		prepared.SiteID,
+		workspace.ForWrite(ctx),
 		prepared.Hostname,
 		prepared.Kind,
 		prepared.Source,
@@ -204,7 +207,7 @@ func (s *DomainStore) createTx(ctx context.Context, tx *sql.Tx, in CreateDomainI
 }
 
 func (s *DomainStore) List(ctx context.Context) ([]StoredDomain, error) {
-	rows, err := s.db.QueryContext(ctx, domainSelectQuery+` ORDER BY d.created_at DESC`)
+	rows, err := s.db.QueryContext(ctx, domainSelectQuery+` WHERE `+domainWorkspaceCondition+` ORDER BY d.created_at DESC`, workspace.Filter(ctx), workspace.Filter(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("list domains: %w", err)
 	}
@@ -217,7 +220,7 @@ func (s *DomainStore) ListBySite(ctx context.Context, siteID string) ([]StoredDo
 	if err != nil {
 		return nil, fmt.Errorf("site_id: %w", err)
 	}
-	rows, err := s.db.QueryContext(ctx, domainSelectQuery+` WHERE d.site_id = ? ORDER BY d.is_primary DESC, d.created_at ASC`, normalized)
+	rows, err := s.db.QueryContext(ctx, domainSelectQuery+` WHERE d.site_id = ? AND `+domainWorkspaceCondition+` ORDER BY d.is_primary DESC, d.created_at ASC`, normalized, workspace.Filter(ctx), workspace.Filter(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("list domains by site: %w", err)
 	}
@@ -230,19 +233,7 @@ func (s *DomainStore) GetByID(ctx context.Context, id string) (*StoredDomain, er
 	if err != nil {
 		return nil, err
 	}
-	rows, err := s.db.QueryContext(ctx, domainSelectQuery+` WHERE d.id = ?`, publicID)
-	if err != nil {
-		return nil, fmt.Errorf("get domain: %w", err)
-	}
-	defer rows.Close()
-	domains, err := scanDomains(rows)
-	if err != nil {
-		return nil, err
-	}
-	if len(domains) == 0 {
-		return nil, fmt.Errorf("domain %s not found", publicID)
-	}
-	return &domains[0], nil
+	return s.getByIDTx(ctx, nil, publicID)
 }
 
 func (s *DomainStore) UpdateRoutingStatus(ctx context.Context, domainID, routingState, routingStatusMessage string, checkedAt time.Time) error {
@@ -313,7 +304,8 @@ func (s *DomainStore) updateTx(ctx context.Context, tx *sql.Tx, id string, in Up
 	res, err := tx.ExecContext(ctx, `
 		UPDATE domains
 		SET hostname = ?, kind = ?, source = ?, dns_state = ?, routing_state = ?, dns_status_message = ?,
-			routing_status_message = ?, last_checked_at = ?, site_id = ?, parent_domain_id = ?, is_primary = ?, updated_at = ?
+			routing_status_message = ?, last_checked_at = ?, site_id = ?, parent_domain_id = ?, is_primary = ?, updated_at = ?,
+			workspace_id = COALESCE((SELECT workspace_id FROM sites WHERE id = ?), workspace_id)
 		WHERE id = ?
 	`,
 		prepared.Hostname,
@@ -328,6 +320,7 @@ func (s *DomainStore) updateTx(ctx context.Context, tx *sql.Tx, id string, in Up
 		nullableString(prepared.ParentDomainID),
 		boolToInt(prepared.IsPrimary),
 		now,
+		prepared.SiteID,
 		publicID,
 	)
 	if err != nil {
@@ -423,6 +416,10 @@ const domainSelectQuery = `SELECT d.id, d.hostname, d.kind, d.source, d.dns_stat
 	LEFT JOIN sites si ON si.id = d.site_id
 	LEFT JOIN domains parent ON parent.id = d.parent_domain_id`
 
+// domainWorkspaceCondition keeps domain queries inside the request's
+// workspace; it takes workspace.Filter twice.
+const domainWorkspaceCondition = `(? = '' OR d.workspace_id = ?)`
+
 func scanDomains(rows *sql.Rows) ([]StoredDomain, error) {
 	var out []StoredDomain
 	for rows.Next() {
@@ -462,11 +459,13 @@ func (s *DomainStore) getByIDTx(ctx context.Context, tx *sql.Tx, id string) (*St
 	if err != nil {
 		return nil, err
 	}
+	query := domainSelectQuery + ` WHERE d.id = ? AND ` + domainWorkspaceCondition
+	scope := workspace.Filter(ctx)
 	var rows *sql.Rows
 	if tx != nil {
-		rows, err = tx.QueryContext(ctx, domainSelectQuery+` WHERE d.id = ?`, publicID)
+		rows, err = tx.QueryContext(ctx, query, publicID, scope, scope)
 	} else {
-		rows, err = s.db.QueryContext(ctx, domainSelectQuery+` WHERE d.id = ?`, publicID)
+		rows, err = s.db.QueryContext(ctx, query, publicID, scope, scope)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get domain: %w", err)
@@ -487,10 +486,12 @@ func (s *DomainStore) getByHostnameTx(ctx context.Context, tx *sql.Tx, hostname
 		rows *sql.Rows
 		err  error
 	)
+	query := domainSelectQuery + ` WHERE d.hostname = ? AND ` + domainWorkspaceCondition + ` LIMIT 1`
+	scope := workspace.Filter(ctx)
 	if tx != nil {
-		rows, err = tx.QueryContext(ctx, domainSelectQuery+` WHERE d.hostname = ? LIMIT 1`, hostname)
+		rows, err = tx.QueryContext(ctx, query, hostname, scope, scope)
 	} else {
-		rows, err = s.db.QueryContext(ctx, domainSelectQuery+` WHERE d.hostname = ? LIMIT 1`, hostname)
+		rows, err = s.db.QueryContext(ctx, query, hostname, scope, scope)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("get domain by hostname: %w", err)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 )
 
 func shouldPromotePrimaryTx(ctx context.Context, tx *sql.Tx, siteID string) (bool, error) {
@@ -70,12 +71,14 @@ func syncLegacySitePrimaryDomainTx(ctx context.Context, tx *sql.Tx, siteID strin
 }
 
 func ensureSiteExists(ctx context.Context, db *sql.DB, tx *sql.Tx, siteID string) error {
+	const query = `SELECT id FROM sites WHERE id = ? AND (? = '' OR workspace_id = ?)`
+	scope := workspace.Filter(ctx)
 	var exists string
 	var err error
 	if tx != nil {
-		err = tx.QueryRowContext(ctx, `SELECT id FROM sites WHERE id = ?`, siteID).Scan(&exists)
+		err = tx.QueryRowContext(ctx, query, siteID, scope, scope).Scan(&exists)
 	} else {
-		err = db.QueryRowContext(ctx, `SELECT id FROM sites WHERE id = ?`, siteID).Scan(&exists)
+		err = db.QueryRowContext(ctx, query, siteID, scope, scope).Scan(&exists)
 	}
 	if err != nil {
 		if err == sql.ErrNoRows {
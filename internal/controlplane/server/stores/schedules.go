@@ -0,0 +1,398 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/shared/cronexpr"
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+const (
+	// ScheduleMissedRunSkip drops runs that were due while the control plane
+	// was down and waits for the next regular occurrence.
+	ScheduleMissedRunSkip = "skip"
+	// ScheduleMissedRunCatchUp queues one run as soon as the control plane is
+	// back, no matter how many occurrences were missed.
+	ScheduleMissedRunCatchUp = "catch_up"
+
+	MaxScheduleJitterSeconds = 3600
+)
+
+// StoredSchedule is a recurring job definition evaluated by the scheduler.
+type StoredSchedule struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	JobKind         string `json:"job_kind"`
+	ServerID        string `json:"server_id,omitempty"`
+	Payload         string `json:"payload,omitempty"`
+	CronExpression  string `json:"cron_expression"`
+	Timezone        string `json:"timezone"`
+	JitterSeconds   int    `json:"jitter_seconds"`
+	MissedRunPolicy string `json:"missed_run_policy"`
+	Paused          bool   `json:"paused"`
+	NextRunAt       string `json:"next_run_at,omitempty"`
+	LastRunAt       string `json:"last_run_at,omitempty"`
+	LastJobID       string `json:"last_job_id,omitempty"`
+	LastError       string `json:"last_error,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+	// WorkspaceID is the workspace the scheduler queues this schedule's jobs
+	// in.
+	WorkspaceID string `json:"-"`
+}
+
+// CreateScheduleInput describes a new schedule. Payload must already be
+// normalized by orchestrator.ValidatePayload for JobKind.
+type CreateScheduleInput struct {
+	Name            string
+	JobKind         string
+	ServerID        string
+	Payload         string
+	CronExpression  string
+	Timezone        string
+	JitterSeconds   int
+	MissedRunPolicy string
+	Paused          bool
+}
+
+// UpdateScheduleInput changes the timing or payload of a schedule. Nil
+// fields are left untouched.
+type UpdateScheduleInput struct {
+	Name            *string
+	Payload         *string
+	CronExpression  *string
+	Timezone        *string
+	JitterSeconds   *int
+	MissedRunPolicy *string
+}
+
+type ScheduleStore struct {
+	db *sql.DB
+}
+
+func NewScheduleStore(db *sql.DB) *ScheduleStore {
+	return &ScheduleStore{db: db}
+}
+
+func AllScheduleMissedRunPolicies() []string {
+	return []string{ScheduleMissedRunSkip, ScheduleMissedRunCatchUp}
+}
+
+func NormalizeScheduleMissedRunPolicy(raw string) (string, error) {
+	policy := strings.TrimSpace(raw)
+	switch policy {
+	case "":
+		return ScheduleMissedRunSkip, nil
+	case ScheduleMissedRunSkip, ScheduleMissedRunCatchUp:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("missed_run_policy must be one of %s", strings.Join(AllScheduleMissedRunPolicies(), ", "))
+	}
+}
+
+// NextScheduleRun returns the first occurrence of cronExpression after the
+// given time in timezone, delayed by a random jitter of up to jitterSeconds
+// so that many schedules on the same expression do not fire at once.
+func NextScheduleRun(cronExpression, timezone string, jitterSeconds int, after time.Time) (time.Time, error) {
+	expr, err := cronexpr.Parse(cronExpression)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timezone %q", timezone)
+	}
+	next := expr.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron expression %q never fires", cronExpression)
+	}
+	if jitterSeconds > 0 {
+		next = next.Add(time.Duration(rand.IntN(jitterSeconds+1)) * time.Second)
+	}
+	return next.UTC(), nil
+}
+
+func (s *ScheduleStore) Create(ctx context.Context, in CreateScheduleInput) (string, error) {
+	in, err := normalizeCreateScheduleInput(in)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	nextRunAt, err := NextScheduleRun(in.CronExpression, in.Timezone, in.JitterSeconds, now)
+	if err != nil {
+		return "", err
+	}
+	id, err := idutil.New()
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO schedules (id, workspace_id, name, job_kind, server_id, payload, cron_expression, timezone, jitter_seconds, missed_run_policy, paused, next_run_at, created_at, updated_at)
+		 VALUES (?, COALESCE((SELECT workspace_id FROM servers WHERE id = ?), ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, in.ServerID, workspace.ForWrite(ctx), in.Name, in.JobKind, nullableString(in.ServerID), nullableString(in.Payload), in.CronExpression, in.Timezone, in.JitterSeconds, in.MissedRunPolicy, boolToInt(in.Paused),
+		nextRunAt.Format(time.RFC3339), now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return "", fmt.Errorf("insert schedule: %w", err)
+	}
+	return id, nil
+}
+
+func (s *ScheduleStore) Get(ctx context.Context, id string) (*StoredSchedule, error) {
+	scheduleID, err := idutil.Normalize(id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, scheduleSelect+` WHERE id = ? AND (? = '' OR workspace_id = ?)`, scheduleID, workspace.Filter(ctx), workspace.Filter(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get schedule: %w", err)
+	}
+	defer rows.Close()
+	schedules, err := scanSchedules(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, fmt.Errorf("schedule %s not found", scheduleID)
+	}
+	return &schedules[0], nil
+}
+
+func (s *ScheduleStore) List(ctx context.Context) ([]StoredSchedule, error) {
+	rows, err := s.db.QueryContext(ctx, scheduleSelect+` WHERE (? = '' OR workspace_id = ?) ORDER BY name ASC, created_at ASC`, workspace.Filter(ctx), workspace.Filter(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list schedules: %w", err)
+	}
+	defer rows.Close()
+	return scanSchedules(rows)
+}
+
+// ListDue returns active schedules whose next run is at or before now.
+func (s *ScheduleStore) ListDue(ctx context.Context, now time.Time) ([]StoredSchedule, error) {
+	rows, err := s.db.QueryContext(ctx,
+		scheduleSelect+` WHERE paused = 0 AND next_run_at IS NOT NULL AND next_run_at <= ? ORDER BY next_run_at ASC`,
+		now.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list due schedules: %w", err)
+	}
+	defer rows.Close()
+	return scanSchedules(rows)
+}
+
+// Update applies in and recomputes the next run when the timing changed.
+func (s *ScheduleStore) Update(ctx context.Context, id string, in UpdateScheduleInput) error {
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	next := CreateScheduleInput{
+		Name:            current.Name,
+		JobKind:         current.JobKind,
+		ServerID:        current.ServerID,
+		Payload:         current.Payload,
+		CronExpression:  current.CronExpression,
+		Timezone:        current.Timezone,
+		JitterSeconds:   current.JitterSeconds,
+		MissedRunPolicy: current.MissedRunPolicy,
+		Paused:          current.Paused,
+	}
+	if in.Name != nil {
+		next.Name = *in.Name
+	}
+	if in.Payload != nil {
+		next.Payload = *in.Payload
+	}
+	if in.CronExpression != nil {
+		next.CronExpression = *in.CronExpression
+	}
+	if in.Timezone != nil {
+		next.Timezone = *in.Timezone
+	}
+	if in.JitterSeconds != nil {
+		next.JitterSeconds = *in.JitterSeconds
+	}
+	if in.MissedRunPolicy != nil {
+		next.MissedRunPolicy = *in.MissedRunPolicy
+	}
+	next, err = normalizeCreateScheduleInput(next)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	nextRunAt := current.NextRunAt
+	if next.CronExpression != current.CronExpression || next.Timezone != current.Timezone || next.JitterSeconds != current.JitterSeconds {
+		computed, err := NextScheduleRun(next.CronExpression, next.Timezone, next.JitterSeconds, now)
+		if err != nil {
+			return err
+		}
+		nextRunAt = computed.Format(time.RFC3339)
+	}
+	return s.update(ctx, current.ID,
+		`UPDATE schedules SET name = ?, payload = ?, cron_expression = ?, timezone = ?, jitter_seconds = ?, missed_run_policy = ?, next_run_at = ?, updated_at = ? WHERE id = ?`,
+		next.Name, nullableString(next.Payload), next.CronExpression, next.Timezone, next.JitterSeconds, next.MissedRunPolicy, nullableString(nextRunAt), now.Format(time.RFC3339),
+	)
+}
+
+// SetPaused pauses or resumes a schedule. Resuming computes the next run from
+// now so the paused period is never treated as missed runs.
+func (s *ScheduleStore) SetPaused(ctx context.Context, id string, paused bool) error {
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	nextRunAt := current.NextRunAt
+	if !paused && current.Paused {
+		computed, err := NextScheduleRun(current.CronExpression, current.Timezone, current.JitterSeconds, now)
+		if err != nil {
+			return err
+		}
+		nextRunAt = computed.Format(time.RFC3339)
+	}
+	return s.update(ctx, current.ID,
+		`UPDATE schedules SET paused = ?, next_run_at = ?, updated_at = ? WHERE id = ?`,
+		boolToInt(paused), nullableString(nextRunAt), now.Format(time.RFC3339),
+	)
+}
+
+func (s *ScheduleStore) Delete(ctx context.Context, id string) error {
+	scheduleID, err := idutil.Normalize(id)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM schedules WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		scheduleID, workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("delete schedule: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("schedule %s not found", scheduleID)
+	}
+	return nil
+}
+
+// RecordRun stores the job queued for the current occurrence and advances
+// the schedule to nextRunAt.
+func (s *ScheduleStore) RecordRun(ctx context.Context, id, jobID string, ranAt, nextRunAt time.Time) error {
+	return s.update(ctx, id,
+		`UPDATE schedules SET last_run_at = ?, last_job_id = ?, last_error = NULL, next_run_at = ?, updated_at = ? WHERE id = ?`,
+		ranAt.UTC().Format(time.RFC3339), jobID, nextRunAt.UTC().Format(time.RFC3339), time.Now().UTC().Format(time.RFC3339),
+	)
+}
+
+// RecordMissedRun advances the schedule without a job, keeping the reason
+// the occurrence did not run.
+func (s *ScheduleStore) RecordMissedRun(ctx context.Context, id, reason string, nextRunAt time.Time) error {
+	return s.update(ctx, id,
+		`UPDATE schedules SET last_error = ?, next_run_at = ?, updated_at = ? WHERE id = ?`,
+		nullableString(strings.TrimSpace(reason)), nextRunAt.UTC().Format(time.RFC3339), time.Now().UTC().Format(time.RFC3339),
+	)
+}
+
+func (s *ScheduleStore) update(ctx context.Context, id, query string, args ...any) error {
+	scheduleID, err := idutil.Normalize(id)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, query, append(args, scheduleID)...)
+	if err != nil {
+		return fmt.Errorf("update schedule: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("schedule %s not found", scheduleID)
+	}
+	return nil
+}
+
+const scheduleSelect = `SELECT id, name, job_kind, COALESCE(server_id, ''), COALESCE(payload, ''), cron_expression, timezone, jitter_seconds, missed_run_policy, paused, COALESCE(next_run_at, ''), COALESCE(last_run_at, ''), COALESCE(last_job_id, ''), COALESCE(last_error, ''), created_at, updated_at, workspace_id
+		 FROM schedules`
+
+func scanSchedules(rows *sql.Rows) ([]StoredSchedule, error) {
+	var out []StoredSchedule
+	for rows.Next() {
+		var (
+			schedule StoredSchedule
+			paused   int
+		)
+		if err := rows.Scan(
+			&schedule.ID,
+			&schedule.Name,
+			&schedule.JobKind,
+			&schedule.ServerID,
+			&schedule.Payload,
+			&schedule.CronExpression,
+			&schedule.Timezone,
+			&schedule.JitterSeconds,
+			&schedule.MissedRunPolicy,
+			&paused,
+			&schedule.NextRunAt,
+			&schedule.LastRunAt,
+			&schedule.LastJobID,
+			&schedule.LastError,
+			&schedule.CreatedAt,
+			&schedule.UpdatedAt,
+			&schedule.WorkspaceID,
+		); err != nil {
+			return nil, fmt.Errorf("scan schedule: %w", err)
+		}
+		schedule.Paused = paused != 0
+		out = append(out, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate schedules: %w", err)
+	}
+	return out, nil
+}
+
+func normalizeCreateScheduleInput(in CreateScheduleInput) (CreateScheduleInput, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	in.JobKind = strings.TrimSpace(in.JobKind)
+	in.ServerID = strings.TrimSpace(in.ServerID)
+	in.Payload = strings.TrimSpace(in.Payload)
+	in.CronExpression = strings.Join(strings.Fields(in.CronExpression), " ")
+	in.Timezone = strings.TrimSpace(in.Timezone)
+	if in.Name == "" {
+		return in, fmt.Errorf("name is required")
+	}
+	if !orchestrator.IsKnownJobKind(in.JobKind) {
+		return in, fmt.Errorf("unsupported job kind: %s", in.JobKind)
+	}
+	if in.JobKind == string(orchestrator.JobKindProvisionServer) {
+		return in, fmt.Errorf("provision_server jobs cannot be scheduled")
+	}
+	if in.ServerID != "" {
+		serverID, err := idutil.Normalize(in.ServerID)
+		if err != nil {
+			return in, fmt.Errorf("invalid server_id: %w", err)
+		}
+		in.ServerID = serverID
+	}
+	if _, err := cronexpr.Parse(in.CronExpression); err != nil {
+		return in, fmt.Errorf("invalid cron_expression: %w", err)
+	}
+	if in.Timezone == "" {
+		in.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(in.Timezone); err != nil {
+		return in, fmt.Errorf("invalid timezone %q", in.Timezone)
+	}
+	if in.JitterSeconds < 0 || in.JitterSeconds > MaxScheduleJitterSeconds {
+		return in, fmt.Errorf("jitter_seconds must be between 0 and %d", MaxScheduleJitterSeconds)
+	}
+	policy, err := NormalizeScheduleMissedRunPolicy(in.MissedRunPolicy)
+	if err != nil {
+		return in, err
+	}
+	in.MissedRunPolicy = policy
+	return in, nil
+}
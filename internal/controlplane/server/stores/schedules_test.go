@@ -0,0 +1,129 @@
+package stores
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduleStoreCreateNormalizesAndComputesNextRun(t *testing.T) {
+	db := mustOpenTestDB(t)
+	store := NewScheduleStore(db)
+
+	before := time.Now().UTC()
+	id, err := store.Create(context.Background(), CreateScheduleInput{
+		Name:           " Nightly backup ",
+		JobKind:        "backup_site",
+		ServerID:       "00000000-0000-7000-8000-000000000001",
+		Payload:        `{"site_id":"00000000-0000-7000-8000-0000000000aa","target_id":"00000000-0000-7000-8000-0000000000bb"}`,
+		CronExpression: "30  2 * * *",
+		JitterSeconds:  120,
+	})
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	schedule, err := store.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	if schedule.Name != "Nightly backup" || schedule.CronExpression != "30 2 * * *" || schedule.Timezone != "UTC" || schedule.MissedRunPolicy != ScheduleMissedRunSkip {
+		t.Fatalf("schedule = %+v, want normalized defaults", schedule)
+	}
+	nextRunAt, err := time.Parse(time.RFC3339, schedule.NextRunAt)
+	if err != nil {
+		t.Fatalf("parse next_run_at: %v", err)
+	}
+	if !nextRunAt.After(before) || nextRunAt.Sub(before) > 24*time.Hour+2*time.Minute+time.Second {
+		t.Fatalf("next_run_at = %s, want within the next day", schedule.NextRunAt)
+	}
+	if nextRunAt.Hour() != 2 || nextRunAt.Minute() < 30 || nextRunAt.Minute() > 32 {
+		t.Fatalf("next_run_at = %s, want 02:30 plus at most two minutes jitter", schedule.NextRunAt)
+	}
+
+	for _, in := range []CreateScheduleInput{
+		{Name: "Bad cron", JobKind: "backup_site", CronExpression: "* * *"},
+		{Name: "Bad kind", JobKind: "make_coffee", CronExpression: "@daily"},
+		{Name: "Provision", JobKind: "provision_server", CronExpression: "@daily"},
+		{Name: "Bad zone", JobKind: "backup_site", CronExpression: "@daily", Timezone: "Mars/Olympus"},
+		{Name: "Bad policy", JobKind: "backup_site", CronExpression: "@daily", MissedRunPolicy: "sometimes"},
+		{Name: "Bad jitter", JobKind: "backup_site", CronExpression: "@daily", JitterSeconds: MaxScheduleJitterSeconds + 1},
+	} {
+		if _, err := store.Create(context.Background(), in); err == nil {
+			t.Errorf("create %q: expected validation error", in.Name)
+		}
+	}
+}
+
+func TestScheduleStorePauseResumeAndDue(t *testing.T) {
+	db := mustOpenTestDB(t)
+	store := NewScheduleStore(db)
+	ctx := context.Background()
+
+	id, err := store.Create(ctx, CreateScheduleInput{Name: "Hourly", JobKind: "backup_site", CronExpression: "@hourly", MissedRunPolicy: ScheduleMissedRunCatchUp})
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE schedules SET next_run_at = ? WHERE id = ?`, "2026-01-01T00:00:00Z", id); err != nil {
+		t.Fatalf("backdate schedule: %v", err)
+	}
+	due, err := store.ListDue(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("list due: %v", err)
+	}
+	if len(due) != 1 || due[0].ID != id {
+		t.Fatalf("due = %+v, want backdated schedule", due)
+	}
+
+	if err := store.SetPaused(ctx, id, true); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if due, _ := store.ListDue(ctx, time.Now()); len(due) != 0 {
+		t.Fatalf("due while paused = %+v, want none", due)
+	}
+
+	if err := store.SetPaused(ctx, id, false); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	resumed, err := store.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	if resumed.Paused || resumed.NextRunAt <= time.Now().UTC().Format(time.RFC3339) {
+		t.Fatalf("resumed = %+v, want active with a future next run", resumed)
+	}
+
+	if err := store.RecordMissedRun(ctx, id, "server is being deleted", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("record missed run: %v", err)
+	}
+	jobID := "00000000-0000-7000-8000-0000000000cc"
+	if err := store.RecordRun(ctx, id, jobID, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("record run: %v", err)
+	}
+	ran, err := store.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	if ran.LastJobID != jobID || ran.LastError != "" || ran.LastRunAt == "" {
+		t.Fatalf("schedule after run = %+v", ran)
+	}
+
+	cron := "0 4 * * 1"
+	if err := store.Update(ctx, id, UpdateScheduleInput{CronExpression: &cron}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	updated, err := store.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	if updated.CronExpression != cron || !strings.HasSuffix(updated.NextRunAt, "T04:00:00Z") {
+		t.Fatalf("updated = %+v, want Monday 04:00 next run", updated)
+	}
+
+	if err := store.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get(ctx, id); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("get after delete err = %v, want not found", err)
+	}
+}
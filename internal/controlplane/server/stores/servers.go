@@ -10,6 +10,7 @@ import (
 
 	"pressluft/internal/platform"
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 )
 
 var (
@@ -20,28 +21,31 @@ var (
 
 // StoredServer is a persisted server node record.
 type StoredServer struct {
-	ID               string                `json:"id"`
-	ProviderID       string                `json:"provider_id"`
-	ProviderType     string                `json:"provider_type"`
-	ProviderServerID string                `json:"provider_server_id,omitempty"`
-	IPv4             string                `json:"ipv4,omitempty"`
-	IPv6             string                `json:"ipv6,omitempty"`
-	Name             string                `json:"name"`
-	Location         string                `json:"location"`
-	ServerType       string                `json:"server_type"`
-	Image            string                `json:"image"`
-	ProfileKey       string                `json:"profile_key"`
-	Status           platform.ServerStatus `json:"status"`
-	SetupState       platform.SetupState   `json:"setup_state"`
-	SetupLastError   string                `json:"setup_last_error,omitempty"`
-	ActionID         string                `json:"action_id,omitempty"`
-	ActionStatus     string                `json:"action_status,omitempty"`
-	HasKey           bool                  `json:"has_key"`
-	NodeStatus       platform.NodeStatus   `json:"node_status,omitempty"`
-	NodeLastSeen     string                `json:"node_last_seen,omitempty"`
-	NodeVersion      string                `json:"node_version,omitempty"`
-	CreatedAt        string                `json:"created_at"`
-	UpdatedAt        string                `json:"updated_at"`
+	ID                    string                `json:"id"`
+	ProviderID            string                `json:"provider_id"`
+	ProviderType          string                `json:"provider_type"`
+	ProviderServerID      string                `json:"provider_server_id,omitempty"`
+	IPv4                  string                `json:"ipv4,omitempty"`
+	IPv6                  string                `json:"ipv6,omitempty"`
+	Name                  string                `json:"name"`
+	Location              string                `json:"location"`
+	ServerType            string                `json:"server_type"`
+	Image                 string                `json:"image"`
+	ProfileKey            string                `json:"profile_key"`
+	Status                platform.ServerStatus `json:"status"`
+	SetupState            platform.SetupState   `json:"setup_state"`
+	SetupLastError        string                `json:"setup_last_error,omitempty"`
+	ActionID              string                `json:"action_id,omitempty"`
+	ActionStatus          string                `json:"action_status,omitempty"`
+	HasKey                bool                  `json:"has_key"`
+	NodeStatus            platform.NodeStatus   `json:"node_status,omitempty"`
+	NodeLastSeen          string                `json:"node_last_seen,omitempty"`
+	NodeVersion           string                `json:"node_version,omitempty"`
+	SSHPort               int                   `json:"ssh_port"`
+	SSHUser               string                `json:"ssh_user"`
+	SSHHostKeyFingerprint string                `json:"ssh_host_key_fingerprint,omitempty"`
+	CreatedAt             string                `json:"created_at"`
+	UpdatedAt             string                `json:"updated_at"`
 }
 
 // CreateServerNodeInput is required to create a server record.
@@ -54,8 +58,21 @@ type CreateServerNodeInput struct {
 	Image        string
 	ProfileKey   string
 	Status       platform.ServerStatus
+	// IPv4, SSHPort and SSHUser are set for servers that already exist and
+	// are adopted over SSH. Zero values mean port 22 and root.
+	IPv4    string
+	SSHPort int
+	SSHUser string
+	// SSHHostKeyFingerprint pins the host key the operator expects the
+	// adopted server to present.
+	SSHHostKeyFingerprint string
 }
 
+const (
+	defaultSSHPort = 22
+	defaultSSHUser = "root"
+)
+
 // ServerStore provides persistence for server records.
 type ServerStore struct {
 	db *sql.DB
@@ -81,12 +98,22 @@ func (s *ServerStore) Create(ctx context.Context, in CreateServerNodeInput) (str
 	if err != nil {
 		return "", err
 	}
+	sshPort := in.SSHPort
+	if sshPort == 0 {
+		sshPort = defaultSSHPort
+	}
+	sshUser := strings.TrimSpace(in.SSHUser)
+	if sshUser == "" {
+		sshUser = defaultSSHUser
+	}
 	_, err = s.db.ExecContext(ctx,
 		`INSERT INTO servers (
-			id, provider_id, provider_type, name, location, server_type, image, profile_key, status, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, workspace_id, provider_id, provider_type, name, location, server_type, image, profile_key, status, ipv4, ssh_port, ssh_user, ssh_host_key_fingerprint, created_at, updated_at
+		) VALUES (?, COALESCE((SELECT workspace_id FROM providers WHERE id = ?), ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		publicID,
 		in.ProviderID,
+		workspace.ForWrite(ctx),
+		in.ProviderID,
 		in.ProviderType,
 		in.Name,
 		in.Location,
@@ -94,6 +121,10 @@ func (s *ServerStore) Create(ctx context.Context, in CreateServerNodeInput) (str
 		in.Image,
 		in.ProfileKey,
 		in.Status,
+		nullableString(in.IPv4),
+		sshPort,
+		sshUser,
+		nullableString(in.SSHHostKeyFingerprint),
 		now,
 		now,
 	)
@@ -105,12 +136,14 @@ func (s *ServerStore) Create(ctx context.Context, in CreateServerNodeInput) (str
 
 func (s *ServerStore) List(ctx context.Context) ([]StoredServer, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT s.id, p.id, s.provider_type, s.provider_server_id, s.ipv4, s.ipv6, s.name, s.location, s.server_type, s.image, s.profile_key, s.status, s.setup_state, s.setup_last_error, s.action_id, s.action_status, s.node_status, s.node_last_seen, s.node_version, s.created_at, s.updated_at,
+		`SELECT s.id, p.id, s.provider_type, s.provider_server_id, s.ipv4, s.ipv6, s.name, s.location, s.server_type, s.image, s.profile_key, s.status, s.setup_state, s.setup_last_error, s.action_id, s.action_status, s.node_status, s.node_last_seen, s.node_version, s.ssh_port, s.ssh_user, s.ssh_host_key_fingerprint, s.created_at, s.updated_at,
 		 CASE WHEN k.server_id IS NULL THEN 0 ELSE 1 END AS has_key
 		 FROM servers s
 		 JOIN providers p ON p.id = s.provider_id
 		 LEFT JOIN server_keys k ON k.server_id = s.id
+		 WHERE (? = '' OR s.workspace_id = ?)
 		 ORDER BY s.created_at DESC`,
+		workspace.Filter(ctx), workspace.Filter(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list servers: %w", err)
@@ -132,6 +165,7 @@ func (s *ServerStore) List(ctx context.Context) ([]StoredServer, error) {
 			nodeStatus       sql.NullString
 			nodeLastSeen     sql.NullString
 			nodeVersion      sql.NullString
+			hostKey          sql.NullString
 			hasKey           int
 		)
 		if err := rows.Scan(
@@ -154,6 +188,9 @@ func (s *ServerStore) List(ctx context.Context) ([]StoredServer, error) {
 			&nodeStatus,
 			&nodeLastSeen,
 			&nodeVersion,
+			&srv.SSHPort,
+			&srv.SSHUser,
+			&hostKey,
 			&srv.CreatedAt,
 			&srv.UpdatedAt,
 			&hasKey,
@@ -182,6 +219,7 @@ func (s *ServerStore) List(ctx context.Context) ([]StoredServer, error) {
 		}
 		srv.NodeLastSeen = nullStringValue(nodeLastSeen)
 		srv.NodeVersion = nullStringValue(nodeVersion)
+		srv.SSHHostKeyFingerprint = nullStringValue(hostKey)
 		srv.HasKey = hasKey != 0
 		out = append(out, srv)
 	}
@@ -253,6 +291,9 @@ func validateCreateServerNodeInput(in CreateServerNodeInput) error {
 	if _, err := platform.NormalizeServerStatus(string(in.Status)); err != nil {
 		return err
 	}
+	if in.SSHPort < 0 || in.SSHPort > 65535 {
+		return fmt.Errorf("ssh_port must be between 1 and 65535")
+	}
 	return nil
 }
 
@@ -276,16 +317,17 @@ func (s *ServerStore) GetByID(ctx context.Context, id string) (*StoredServer, er
 		nodeStatus       sql.NullString
 		nodeLastSeen     sql.NullString
 		nodeVersion      sql.NullString
+		hostKey          sql.NullString
 		hasKey           int
 	)
 	err = s.db.QueryRowContext(ctx,
-		`SELECT s.id, p.id, s.provider_type, s.provider_server_id, s.ipv4, s.ipv6, s.name, s.location, s.server_type, s.image, s.profile_key, s.status, s.setup_state, s.setup_last_error, s.action_id, s.action_status, s.node_status, s.node_last_seen, s.node_version, s.created_at, s.updated_at,
+		`SELECT s.id, p.id, s.provider_type, s.provider_server_id, s.ipv4, s.ipv6, s.name, s.location, s.server_type, s.image, s.profile_key, s.status, s.setup_state, s.setup_last_error, s.action_id, s.action_status, s.node_status, s.node_last_seen, s.node_version, s.ssh_port, s.ssh_user, s.ssh_host_key_fingerprint, s.created_at, s.updated_at,
 		 CASE WHEN k.server_id IS NULL THEN 0 ELSE 1 END AS has_key
 		 FROM servers s
 		 JOIN providers p ON p.id = s.provider_id
 		 LEFT JOIN server_keys k ON k.server_id = s.id
-		 WHERE s.id = ?`,
-		publicID,
+		 WHERE s.id = ? AND (? = '' OR s.workspace_id = ?)`,
+		publicID, workspace.Filter(ctx), workspace.Filter(ctx),
 	).Scan(
 		&srv.ID,
 		&srv.ProviderID,
@@ -306,6 +348,9 @@ func (s *ServerStore) GetByID(ctx context.Context, id string) (*StoredServer, er
 		&nodeStatus,
 		&nodeLastSeen,
 		&nodeVersion,
+		&srv.SSHPort,
+		&srv.SSHUser,
+		&hostKey,
 		&srv.CreatedAt,
 		&srv.UpdatedAt,
 		&hasKey,
@@ -337,6 +382,7 @@ func (s *ServerStore) GetByID(ctx context.Context, id string) (*StoredServer, er
 	}
 	srv.NodeLastSeen = nullStringValue(nodeLastSeen)
 	srv.NodeVersion = nullStringValue(nodeVersion)
+	srv.SSHHostKeyFingerprint = nullStringValue(hostKey)
 	srv.HasKey = hasKey != 0
 
 	return &srv, nil
@@ -400,6 +446,26 @@ func (s *ServerStore) UpdateSetupState(ctx context.Context, id string, setupStat
 	return nil
 }
 
+// UpdateSSHHostKey records the host key fingerprint seen when connecting to
+// a server over SSH.
+func (s *ServerStore) UpdateSSHHostKey(ctx context.Context, id string, fingerprint string) error {
+	serverID, err := s.lookupServerID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE servers SET ssh_host_key_fingerprint = ?, updated_at = ? WHERE id = ?`,
+		nullableString(fingerprint),
+		now,
+		serverID,
+	); err != nil {
+		return fmt.Errorf("update server ssh host key: %w", err)
+	}
+	return nil
+}
+
 // UpdateServerType updates the server_type field of a server.
 func (s *ServerStore) UpdateServerType(ctx context.Context, id string, serverType string) error {
 	serverID, err := s.lookupServerID(ctx, id)
@@ -496,16 +562,19 @@ func (s *ServerStore) UpdateNodeStatus(ctx context.Context, id string, status pl
 	return nil
 }
 
-func (s *ServerStore) MarkNodesOfflineBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+// MarkNodesOfflineBefore marks nodes not seen since cutoff offline and
+// returns the IDs of the servers it changed.
+func (s *ServerStore) MarkNodesOfflineBefore(ctx context.Context, cutoff time.Time) ([]string, error) {
 	cutoffText := cutoff.UTC().Format(time.RFC3339)
 	now := time.Now().UTC().Format(time.RFC3339)
-	res, err := s.db.ExecContext(ctx,
+	rows, err := s.db.QueryContext(ctx,
 		`UPDATE servers
 		 SET node_status = ?, updated_at = ?
 		 WHERE node_status IN (?, ?)
 		   AND node_last_seen IS NOT NULL
 		   AND node_last_seen != ''
-		   AND node_last_seen < ?`,
+		   AND node_last_seen < ?
+		 RETURNING id`,
 		string(platform.NodeStatusOffline),
 		now,
 		string(platform.NodeStatusOnline),
@@ -513,13 +582,22 @@ func (s *ServerStore) MarkNodesOfflineBefore(ctx context.Context, cutoff time.Ti
 		cutoffText,
 	)
 	if err != nil {
-		return 0, fmt.Errorf("mark nodes offline: %w", err)
+		return nil, fmt.Errorf("mark nodes offline: %w", err)
 	}
-	rows, err := res.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("mark nodes offline rows affected: %w", err)
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan node marked offline: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate nodes marked offline: %w", err)
 	}
-	return rows, nil
+	return ids, nil
 }
 
 func (s *ServerStore) lookupServerID(ctx context.Context, id string) (string, error) {
@@ -528,7 +606,11 @@ func (s *ServerStore) lookupServerID(ctx context.Context, id string) (string, er
 		return "", err
 	}
 	var serverID string
-	if err := s.db.QueryRowContext(ctx, `SELECT id FROM servers WHERE id = ?`, publicID).Scan(&serverID); err != nil {
+	scope := workspace.Filter(ctx)
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM servers WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		publicID, scope, scope,
+	).Scan(&serverID); err != nil {
 		if err == sql.ErrNoRows {
 			return "", fmt.Errorf("server %s not found", publicID)
 		}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
 
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 )
 
 func (s *ServerStore) QueueServerJob(ctx context.Context, in QueueServerJobInput) (StoredServer, orchestrator.Job, error) {
@@ -26,8 +28,12 @@ func (s *ServerStore) QueueServerJob(ctx context.Context, in QueueServerJobInput
 	}
 	defer tx.Rollback()
 
-	var serverStatusRaw string
-	if err := tx.QueryRowContext(ctx, `SELECT status FROM servers WHERE id = ?`, serverPublicID).Scan(&serverStatusRaw); err != nil {
+	var serverStatusRaw, workspaceID string
+	scope := workspace.Filter(ctx)
+	if err := tx.QueryRowContext(ctx,
+		`SELECT status, workspace_id FROM servers WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		serverPublicID, scope, scope,
+	).Scan(&serverStatusRaw, &workspaceID); err != nil {
 		if err == sql.ErrNoRows {
 			return StoredServer{}, orchestrator.Job{}, fmt.Errorf("server %s not found", serverPublicID)
 		}
@@ -46,6 +52,7 @@ func (s *ServerStore) QueueServerJob(ctx context.Context, in QueueServerJobInput
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
+	priorStatus := ""
 	if queuedStatus, ok := orchestrator.QueuedServerStatusForKind(in.Kind); ok {
 		var activeJobID string
 		var activeJobKind string
@@ -83,6 +90,7 @@ func (s *ServerStore) QueueServerJob(ctx context.Context, in QueueServerJobInput
 		if rows, _ := res.RowsAffected(); rows == 0 {
 			return StoredServer{}, orchestrator.Job{}, fmt.Errorf("server %s not found", serverPublicID)
 		}
+		priorStatus = string(serverStatus)
 	}
 	jobPublicID, err := idutil.New()
 	if err != nil {
@@ -90,13 +98,15 @@ func (s *ServerStore) QueueServerJob(ctx context.Context, in QueueServerJobInput
 	}
 
 	_, err = tx.ExecContext(ctx,
-		`INSERT INTO jobs (id, server_id, kind, status, current_step, retry_count, payload, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, '', 0, ?, ?, ?)`,
+		`INSERT INTO jobs (id, workspace_id, server_id, kind, status, current_step, retry_count, payload, prior_server_status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, '', 0, ?, ?, ?, ?)`,
 		jobPublicID,
+		workspaceID,
 		serverPublicID,
 		in.Kind,
 		orchestrator.JobStatusQueued,
 		nullableString(in.Payload),
+		nullableString(priorStatus),
 		now,
 		now,
 	)
@@ -119,3 +129,74 @@ func (s *ServerStore) QueueServerJob(ctx context.Context, in QueueServerJobInput
 
 	return *server, job, nil
 }
+
+// RollbackCancelledJob settles the server a cancelled job was working on. A
+// job that never started puts the server back into the status it held before
+// the job was queued. A job cancelled mid-run may have left the machine
+// half-changed, so a server still in an in-progress status is marked failed.
+// Servers in a settled status are left alone. It returns the server's
+// resulting status and whether this call changed it.
+func (s *ServerStore) RollbackCancelledJob(ctx context.Context, job orchestrator.Job, started bool) (platform.ServerStatus, bool, error) {
+	serverID, err := idutil.Normalize(job.ServerID)
+	if err != nil {
+		return "", false, fmt.Errorf("server_id: %w", err)
+	}
+
+	var currentRaw string
+	var priorRaw sql.NullString
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT s.status, j.prior_server_status
+		 FROM servers s
+		 LEFT JOIN jobs j ON j.id = ?
+		 WHERE s.id = ?`,
+		job.ID,
+		serverID,
+	).Scan(&currentRaw, &priorRaw); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, fmt.Errorf("server %s not found", serverID)
+		}
+		return "", false, fmt.Errorf("read server status: %w", err)
+	}
+	current := platform.ServerStatus(currentRaw)
+	if !slices.Contains(platform.InProgressServerStatuses(), current) {
+		return current, false, nil
+	}
+
+	next := platform.ServerStatusFailed
+	if queuedStatus, ok := orchestrator.QueuedServerStatusForKind(job.Kind); ok && !started && current == queuedStatus && priorRaw.Valid {
+		prior, err := platform.NormalizeServerStatus(priorRaw.String)
+		if err != nil {
+			return "", false, fmt.Errorf("normalize prior server status: %w", err)
+		}
+		next = prior
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE servers SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		string(next),
+		now,
+		serverID,
+		currentRaw,
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("roll back server status: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		// The status moved on concurrently; leave it to whoever moved it.
+		return current, false, nil
+	}
+	if job.Kind == string(orchestrator.JobKindConfigureServer) {
+		if _, err := s.db.ExecContext(ctx,
+			`UPDATE servers SET setup_state = ?, setup_last_error = ?, updated_at = ? WHERE id = ? AND setup_state = ?`,
+			string(platform.SetupStateDegraded),
+			"setup cancelled",
+			now,
+			serverID,
+			string(platform.SetupStateRunning),
+		); err != nil {
+			return "", false, fmt.Errorf("roll back setup state: %w", err)
+		}
+	}
+	return next, true, nil
+}
@@ -212,12 +212,12 @@ func TestServerStoreMarkNodesOfflineBeforeOnlyUpdatesStaleNodes(t *testing.T) {
 		t.Fatalf("seed fresh node: %v", err)
 	}
 
-	rows, err := store.MarkNodesOfflineBefore(context.Background(), time.Now().Add(-150*time.Second))
+	marked, err := store.MarkNodesOfflineBefore(context.Background(), time.Now().Add(-150*time.Second))
 	if err != nil {
 		t.Fatalf("mark nodes offline: %v", err)
 	}
-	if rows != 1 {
-		t.Fatalf("rows = %d, want 1", rows)
+	if len(marked) != 1 || marked[0] != staleID {
+		t.Fatalf("marked = %v, want [%s]", marked, staleID)
 	}
 
 	staleServer, err := store.GetByID(context.Background(), staleID)
@@ -255,6 +255,7 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 	if _, err := db.Exec(`
 		CREATE TABLE providers (
 			id         TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			type       TEXT    NOT NULL,
 			name       TEXT    NOT NULL,
 			api_token_encrypted TEXT NOT NULL,
@@ -271,6 +272,7 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 	if _, err := db.Exec(`
 		CREATE TABLE servers (
 			id                 TEXT PRIMARY KEY,
+			workspace_id       TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			provider_id        TEXT    NOT NULL,
 			provider_type      TEXT    NOT NULL,
 			provider_server_id TEXT,
@@ -289,6 +291,9 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 			node_status        TEXT DEFAULT 'unknown',
 			node_last_seen     TEXT,
 			node_version       TEXT,
+			ssh_port           INTEGER NOT NULL DEFAULT 22,
+			ssh_user           TEXT    NOT NULL DEFAULT 'root',
+			ssh_host_key_fingerprint TEXT,
 			created_at         TEXT    NOT NULL,
 			updated_at         TEXT    NOT NULL,
 			FOREIGN KEY (provider_id) REFERENCES providers(id)
@@ -300,6 +305,7 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 	if _, err := db.Exec(`
 		CREATE TABLE sites (
 			id                TEXT PRIMARY KEY,
+			workspace_id      TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id         TEXT    NOT NULL,
 			name              TEXT    NOT NULL,
 			wordpress_admin_email TEXT,
@@ -315,6 +321,10 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 			wordpress_path    TEXT,
 			php_version       TEXT,
 			wordpress_version TEXT,
+			environment       TEXT    NOT NULL DEFAULT 'production',
+			parent_site_id    TEXT,
+			basic_auth_username TEXT,
+			basic_auth_password_encrypted TEXT,
 			created_at        TEXT    NOT NULL,
 			updated_at        TEXT    NOT NULL,
 			FOREIGN KEY (server_id) REFERENCES servers(id)
@@ -326,6 +336,7 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 	if _, err := db.Exec(`
 		CREATE TABLE domains (
 			id                     TEXT PRIMARY KEY,
+			workspace_id           TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			hostname               TEXT    NOT NULL,
 			kind                   TEXT    NOT NULL,
 			source                 TEXT    NOT NULL,
@@ -375,6 +386,7 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 	if _, err := db.Exec(`
 		CREATE TABLE jobs (
 			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id    TEXT,
 			kind         TEXT    NOT NULL,
 			status       TEXT    NOT NULL,
@@ -386,6 +398,8 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 			finished_at  TEXT,
 			timeout_at   TEXT,
 			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
 			created_at   TEXT    NOT NULL,
 			updated_at   TEXT    NOT NULL,
 			FOREIGN KEY (server_id) REFERENCES servers(id)
@@ -394,6 +408,7 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 			id         TEXT PRIMARY KEY,
 			job_id     TEXT    NOT NULL,
 			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
 			event_type TEXT    NOT NULL,
 			level      TEXT    NOT NULL,
 			step_key   TEXT,
@@ -407,6 +422,153 @@ func mustOpenTestDB(t *testing.T) *sql.DB {
 		t.Fatalf("create jobs tables: %v", err)
 	}
 
+	if _, err := db.Exec(`
+		CREATE TABLE backup_targets (
+			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			name                 TEXT    NOT NULL,
+			endpoint             TEXT    NOT NULL,
+			region               TEXT    NOT NULL,
+			bucket               TEXT    NOT NULL,
+			path_prefix          TEXT    NOT NULL DEFAULT '',
+			path_style           INTEGER NOT NULL DEFAULT 1,
+			access_key_id        TEXT    NOT NULL,
+			secret_key_encrypted TEXT    NOT NULL,
+			secret_key_key_id    TEXT    NOT NULL,
+			secret_key_version   INTEGER NOT NULL DEFAULT 0,
+			retention_days       INTEGER NOT NULL DEFAULT 30,
+			created_at           TEXT    NOT NULL,
+			updated_at           TEXT    NOT NULL
+		);
+		CREATE TABLE site_backups (
+			id              TEXT PRIMARY KEY,
+			site_id         TEXT    NOT NULL,
+			server_id       TEXT    NOT NULL,
+			target_id       TEXT    NOT NULL,
+			job_id          TEXT,
+			status          TEXT    NOT NULL DEFAULT 'pending',
+			object_key      TEXT    NOT NULL,
+			size_bytes      INTEGER NOT NULL DEFAULT 0,
+			checksum_sha256 TEXT,
+			retention_days  INTEGER NOT NULL,
+			expires_at      TEXT,
+			last_error      TEXT,
+			started_at      TEXT,
+			completed_at    TEXT,
+			created_at      TEXT    NOT NULL,
+			updated_at      TEXT    NOT NULL,
+			FOREIGN KEY (target_id) REFERENCES backup_targets(id)
+		);
+		CREATE TABLE schedules (
+			id                TEXT PRIMARY KEY,
+			workspace_id      TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			name              TEXT    NOT NULL,
+			job_kind          TEXT    NOT NULL,
+			server_id         TEXT,
+			payload           TEXT,
+			cron_expression   TEXT    NOT NULL,
+			timezone          TEXT    NOT NULL DEFAULT 'UTC',
+			jitter_seconds    INTEGER NOT NULL DEFAULT 0,
+			missed_run_policy TEXT    NOT NULL DEFAULT 'skip',
+			paused            INTEGER NOT NULL DEFAULT 0,
+			next_run_at       TEXT,
+			last_run_at       TEXT,
+			last_job_id       TEXT,
+			last_error        TEXT,
+			created_at        TEXT    NOT NULL,
+			updated_at        TEXT    NOT NULL
+		);
+		CREATE TABLE site_components (
+			site_id        TEXT NOT NULL,
+			component_type TEXT NOT NULL,
+			name           TEXT NOT NULL,
+			title          TEXT NOT NULL DEFAULT '',
+			status         TEXT NOT NULL DEFAULT '',
+			version        TEXT NOT NULL DEFAULT '',
+			update_version TEXT NOT NULL DEFAULT '',
+			checked_at     TEXT NOT NULL,
+			PRIMARY KEY (site_id, component_type, name),
+			FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+		);
+		CREATE TABLE site_vulnerabilities (
+			site_id           TEXT NOT NULL,
+			vulnerability_id  TEXT NOT NULL,
+			component_type    TEXT NOT NULL,
+			component_name    TEXT NOT NULL,
+			installed_version TEXT NOT NULL DEFAULT '',
+			title             TEXT NOT NULL DEFAULT '',
+			description       TEXT NOT NULL DEFAULT '',
+			severity          TEXT NOT NULL DEFAULT 'unknown',
+			score             REAL NOT NULL DEFAULT 0,
+			fixed_in          TEXT NOT NULL DEFAULT '',
+			refs              TEXT NOT NULL DEFAULT '[]',
+			first_seen_at     TEXT NOT NULL,
+			scanned_at        TEXT NOT NULL,
+			PRIMARY KEY (site_id, vulnerability_id, component_type, component_name),
+			FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+		);
+	`); err != nil {
+		t.Fatalf("create backup, schedule, component, and vulnerability tables: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE uptime_monitors (
+			site_id    TEXT PRIMARY KEY REFERENCES sites(id) ON DELETE CASCADE,
+			enabled    INTEGER NOT NULL DEFAULT 1,
+			keyword    TEXT NOT NULL DEFAULT '',
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE uptime_checks (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			site_id        TEXT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+			hostname       TEXT NOT NULL,
+			checked_at     TEXT NOT NULL,
+			up             INTEGER NOT NULL,
+			status_code    INTEGER,
+			latency_ms     INTEGER NOT NULL DEFAULT 0,
+			tls_expires_at TEXT,
+			keyword_match  INTEGER,
+			error          TEXT
+		);
+		CREATE TABLE uptime_incidents (
+			id          TEXT PRIMARY KEY,
+			site_id     TEXT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+			hostname    TEXT NOT NULL,
+			cause       TEXT NOT NULL,
+			started_at  TEXT NOT NULL,
+			resolved_at TEXT
+		);
+		CREATE UNIQUE INDEX idx_uptime_incidents_open ON uptime_incidents(site_id, hostname) WHERE resolved_at IS NULL;
+	`); err != nil {
+		t.Fatalf("create uptime tables: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE workspaces (
+			id         TEXT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			slug       TEXT NOT NULL UNIQUE,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		INSERT INTO workspaces (id, name, slug, created_at, updated_at)
+		VALUES ('00000000-0000-7000-8000-000000000001', 'Default', 'default', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z');
+		CREATE TABLE server_prices (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			server_id       TEXT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+			server_type     TEXT NOT NULL,
+			location        TEXT NOT NULL,
+			hourly_gross    TEXT NOT NULL,
+			monthly_gross   TEXT NOT NULL,
+			currency        TEXT NOT NULL,
+			effective_from  TEXT NOT NULL,
+			effective_until TEXT
+		);
+		CREATE UNIQUE INDEX idx_server_prices_open ON server_prices(server_id) WHERE effective_until IS NULL;
+	`); err != nil {
+		t.Fatalf("create workspace and server price tables: %v", err)
+	}
+
 	return db
 }
 
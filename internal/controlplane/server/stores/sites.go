@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/workspace"
 )
 
 const (
@@ -25,6 +27,9 @@ const (
 	SiteRuntimeHealthStateHealthy = "healthy"
 	SiteRuntimeHealthStateIssue   = "issue"
 	SiteRuntimeHealthStateUnknown = "unknown"
+
+	SiteEnvironmentProduction = "production"
+	SiteEnvironmentStaging    = "staging"
 )
 
 type StoredSite struct {
@@ -45,8 +50,14 @@ type StoredSite struct {
 	WordPressPath       string `json:"wordpress_path,omitempty"`
 	PHPVersion          string `json:"php_version,omitempty"`
 	WordPressVersion    string `json:"wordpress_version,omitempty"`
-	CreatedAt           string `json:"created_at"`
-	UpdatedAt           string `json:"updated_at"`
+	Environment         string `json:"environment"`
+	ParentSiteID        string `json:"parent_site_id,omitempty"`
+	BasicAuthUsername   string `json:"basic_auth_username,omitempty"`
+	// BasicAuthPasswordEncrypted is the age-encrypted HTTP basic auth
+	// password; decrypt it with security.DecryptSiteBasicAuthPassword.
+	BasicAuthPasswordEncrypted string `json:"-"`
+	CreatedAt                  string `json:"created_at"`
+	UpdatedAt                  string `json:"updated_at"`
 }
 
 type CreateSiteInput struct {
@@ -59,6 +70,12 @@ type CreateSiteInput struct {
 	WordPressPath         string
 	PHPVersion            string
 	WordPressVersion      string
+	// Environment defaults to production. Staging sites must name the
+	// production site they were cloned from in ParentSiteID.
+	Environment       string
+	ParentSiteID      string
+	BasicAuthUsername string
+	BasicAuthPassword string
 }
 
 type CreateSitePrimaryHostnameInput struct {
@@ -99,6 +116,22 @@ func AllSiteRuntimeHealthStates() []string {
 	return []string{SiteRuntimeHealthStatePending, SiteRuntimeHealthStateHealthy, SiteRuntimeHealthStateIssue, SiteRuntimeHealthStateUnknown}
 }
 
+func AllSiteEnvironments() []string {
+	return []string{SiteEnvironmentProduction, SiteEnvironmentStaging}
+}
+
+func NormalizeSiteEnvironment(raw string) (string, error) {
+	environment := strings.TrimSpace(raw)
+	switch environment {
+	case "":
+		return SiteEnvironmentProduction, nil
+	case SiteEnvironmentProduction, SiteEnvironmentStaging:
+		return environment, nil
+	default:
+		return "", fmt.Errorf("unsupported site environment %q", raw)
+	}
+}
+
 func NormalizeSiteStatus(raw string) (string, error) {
 	status := strings.TrimSpace(raw)
 	switch status {
@@ -140,6 +173,21 @@ func (s *SiteStore) Create(ctx context.Context, in CreateSiteInput) (string, err
 	if err := s.ensureServerExists(ctx, serverID); err != nil {
 		return "", err
 	}
+	environment, _ := NormalizeSiteEnvironment(in.Environment)
+	parentSiteID := ""
+	if environment == SiteEnvironmentStaging {
+		parentSiteID, err = s.ensureStagingParent(ctx, in.ParentSiteID, serverID)
+		if err != nil {
+			return "", err
+		}
+	}
+	basicAuthPasswordEncrypted := ""
+	if in.BasicAuthPassword != "" {
+		basicAuthPasswordEncrypted, err = security.EncryptSiteBasicAuthPassword(in.BasicAuthPassword)
+		if err != nil {
+			return "", fmt.Errorf("encrypt basic auth password: %w", err)
+		}
+	}
 	now := time.Now().UTC().Format(time.RFC3339)
 	publicID, err := idutil.New()
 	if err != nil {
@@ -151,10 +199,11 @@ func (s *SiteStore) Create(ctx context.Context, in CreateSiteInput) (string, err
 	}
 	defer tx.Rollback()
 	_, err = tx.ExecContext(ctx,
-		`INSERT INTO sites (id, server_id, name, wordpress_admin_email, primary_domain, status, deployment_state, deployment_status_message, last_deploy_job_id, last_deployed_at, runtime_health_state, runtime_health_status_message, last_health_check_at, wordpress_path, php_version, wordpress_version, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO sites (id, workspace_id, server_id, name, wordpress_admin_email, primary_domain, status, deployment_state, deployment_status_message, last_deploy_job_id, last_deployed_at, runtime_health_state, runtime_health_status_message, last_health_check_at, wordpress_path, php_version, wordpress_version, environment, parent_site_id, basic_auth_username, basic_auth_password_encrypted, created_at, updated_at)
+		 VALUES (?, (SELECT workspace_id FROM servers WHERE id = ?), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		publicID,
 		serverID,
+		serverID,
 		strings.TrimSpace(in.Name),
 		strings.TrimSpace(in.WordPressAdminEmail),
 		nil,
@@ -169,6 +218,10 @@ func (s *SiteStore) Create(ctx context.Context, in CreateSiteInput) (string, err
 		nullableString(in.WordPressPath),
 		nullableString(in.PHPVersion),
 		nullableString(in.WordPressVersion),
+		environment,
+		nullableString(parentSiteID),
+		nullableString(in.BasicAuthUsername),
+		nullableString(basicAuthPasswordEncrypted),
 		now,
 		now,
 	)
@@ -188,11 +241,13 @@ func (s *SiteStore) Create(ctx context.Context, in CreateSiteInput) (string, err
 
 func (s *SiteStore) List(ctx context.Context) ([]StoredSite, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.created_at, si.updated_at
+		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.environment, COALESCE(si.parent_site_id, ''), COALESCE(si.basic_auth_username, ''), COALESCE(si.basic_auth_password_encrypted, ''), si.created_at, si.updated_at
 		 FROM sites si
 		 JOIN servers srv ON srv.id = si.server_id
 		 LEFT JOIN domains dom ON dom.site_id = si.id AND dom.is_primary = 1
+		 WHERE (? = '' OR si.workspace_id = ?)
 		 ORDER BY si.created_at DESC`,
+		workspace.Filter(ctx), workspace.Filter(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list sites: %w", err)
@@ -207,13 +262,13 @@ func (s *SiteStore) ListByServer(ctx context.Context, serverID string) ([]Stored
 		return nil, fmt.Errorf("server_id: %w", err)
 	}
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.created_at, si.updated_at
+		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.environment, COALESCE(si.parent_site_id, ''), COALESCE(si.basic_auth_username, ''), COALESCE(si.basic_auth_password_encrypted, ''), si.created_at, si.updated_at
 		 FROM sites si
 		 JOIN servers srv ON srv.id = si.server_id
 		 LEFT JOIN domains dom ON dom.site_id = si.id AND dom.is_primary = 1
-		 WHERE si.server_id = ?
+		 WHERE si.server_id = ? AND (? = '' OR si.workspace_id = ?)
 		 ORDER BY si.created_at DESC`,
-		normalized,
+		normalized, workspace.Filter(ctx), workspace.Filter(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list sites by server: %w", err)
@@ -222,6 +277,28 @@ func (s *SiteStore) ListByServer(ctx context.Context, serverID string) ([]Stored
 	return scanSites(rows)
 }
 
+// ListByParent returns the non-production environments cloned from a site.
+func (s *SiteStore) ListByParent(ctx context.Context, parentSiteID string) ([]StoredSite, error) {
+	normalized, err := idutil.Normalize(parentSiteID)
+	if err != nil {
+		return nil, fmt.Errorf("parent_site_id: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.environment, COALESCE(si.parent_site_id, ''), COALESCE(si.basic_auth_username, ''), COALESCE(si.basic_auth_password_encrypted, ''), si.created_at, si.updated_at
+		 FROM sites si
+		 JOIN servers srv ON srv.id = si.server_id
+		 LEFT JOIN domains dom ON dom.site_id = si.id AND dom.is_primary = 1
+		 WHERE si.parent_site_id = ? AND (? = '' OR si.workspace_id = ?)
+		 ORDER BY si.created_at DESC`,
+		normalized, workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list sites by parent: %w", err)
+	}
+	defer rows.Close()
+	return scanSites(rows)
+}
+
 func (s *SiteStore) GetByID(ctx context.Context, id string) (*StoredSite, error) {
 	publicID, err := idutil.Normalize(id)
 	if err != nil {
@@ -241,12 +318,12 @@ func (s *SiteStore) GetByID(ctx context.Context, id string) (*StoredSite, error)
 		wordpressVersion    sql.NullString
 	)
 	err = s.db.QueryRowContext(ctx,
-		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.created_at, si.updated_at
+		`SELECT si.id, si.server_id, srv.name, si.name, COALESCE(si.wordpress_admin_email, ''), COALESCE(dom.hostname, si.primary_domain), si.status, si.deployment_state, COALESCE(si.deployment_status_message, ''), COALESCE(si.last_deploy_job_id, ''), COALESCE(si.last_deployed_at, ''), COALESCE(si.runtime_health_state, 'pending'), COALESCE(si.runtime_health_status_message, ''), COALESCE(si.last_health_check_at, ''), si.wordpress_path, si.php_version, si.wordpress_version, si.environment, COALESCE(si.parent_site_id, ''), COALESCE(si.basic_auth_username, ''), COALESCE(si.basic_auth_password_encrypted, ''), si.created_at, si.updated_at
 		 FROM sites si
 		 JOIN servers srv ON srv.id = si.server_id
 		 LEFT JOIN domains dom ON dom.site_id = si.id AND dom.is_primary = 1
-		 WHERE si.id = ?`,
-		publicID,
+		 WHERE si.id = ? AND (? = '' OR si.workspace_id = ?)`,
+		publicID, workspace.Filter(ctx), workspace.Filter(ctx),
 	).Scan(
 		&site.ID,
 		&site.ServerID,
@@ -265,6 +342,10 @@ func (s *SiteStore) GetByID(ctx context.Context, id string) (*StoredSite, error)
 		&wordpressPath,
 		&phpVersion,
 		&wordpressVersion,
+		&site.Environment,
+		&site.ParentSiteID,
+		&site.BasicAuthUsername,
+		&site.BasicAuthPasswordEncrypted,
 		&site.CreatedAt,
 		&site.UpdatedAt,
 	)
@@ -418,9 +499,10 @@ func (s *SiteStore) Update(ctx context.Context, id string, in UpdateSiteInput) (
 	defer tx.Rollback()
 	res, err := tx.ExecContext(ctx,
 		`UPDATE sites
-		 SET server_id = ?, name = ?, wordpress_admin_email = ?, primary_domain = ?, status = ?, wordpress_path = ?, php_version = ?, wordpress_version = ?, updated_at = ?
+		 SET server_id = ?, workspace_id = (SELECT workspace_id FROM servers WHERE id = ?), name = ?, wordpress_admin_email = ?, primary_domain = ?, status = ?, wordpress_path = ?, php_version = ?, wordpress_version = ?, updated_at = ?
 		 WHERE id = ?`,
 		serverID,
+		serverID,
 		name,
 		wordpressAdminEmail,
 		nullableString(primaryDomain),
@@ -461,15 +543,28 @@ func (s *SiteStore) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
+	if _, err := s.GetByID(ctx, publicID); err != nil {
+		return err
+	}
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin delete site tx: %w", err)
 	}
 	defer tx.Rollback()
+	var children int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sites WHERE parent_site_id = ?`, publicID).Scan(&children); err != nil {
+		return fmt.Errorf("count staging sites: %w", err)
+	}
+	if children > 0 {
+		return fmt.Errorf("site %s still has %d staging environment(s); delete them first", publicID, children)
+	}
 	if _, err := tx.ExecContext(ctx, `DELETE FROM domains WHERE site_id = ?`, publicID); err != nil {
 		return fmt.Errorf("delete site domains: %w", err)
 	}
-	res, err := tx.ExecContext(ctx, `DELETE FROM sites WHERE id = ?`, publicID)
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM sites WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		publicID, workspace.Filter(ctx), workspace.Filter(ctx),
+	)
 	if err != nil {
 		return fmt.Errorf("delete site: %w", err)
 	}
@@ -484,7 +579,11 @@ func (s *SiteStore) Delete(ctx context.Context, id string) error {
 
 func (s *SiteStore) ensureServerExists(ctx context.Context, serverID string) error {
 	var exists string
-	if err := s.db.QueryRowContext(ctx, `SELECT id FROM servers WHERE id = ?`, serverID).Scan(&exists); err != nil {
+	scope := workspace.Filter(ctx)
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM servers WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		serverID, scope, scope,
+	).Scan(&exists); err != nil {
 		if err == sql.ErrNoRows {
 			return fmt.Errorf("server %s not found", serverID)
 		}
@@ -493,6 +592,22 @@ func (s *SiteStore) ensureServerExists(ctx context.Context, serverID string) err
 	return nil
 }
 
+// ensureStagingParent checks that a staging site is cloned from a production
+// site on the same server, since the clone is made on the server itself.
+func (s *SiteStore) ensureStagingParent(ctx context.Context, parentSiteID, serverID string) (string, error) {
+	parent, err := s.GetByID(ctx, parentSiteID)
+	if err != nil {
+		return "", fmt.Errorf("parent_site_id: %w", err)
+	}
+	if parent.Environment != SiteEnvironmentProduction {
+		return "", fmt.Errorf("parent_site_id must reference a production site")
+	}
+	if parent.ServerID != serverID {
+		return "", fmt.Errorf("staging sites must run on the same server as their parent site")
+	}
+	return parent.ID, nil
+}
+
 func scanSites(rows *sql.Rows) ([]StoredSite, error) {
 	var out []StoredSite
 	for rows.Next() {
@@ -528,6 +643,10 @@ func scanSites(rows *sql.Rows) ([]StoredSite, error) {
 			&wordpressPath,
 			&phpVersion,
 			&wordpressVersion,
+			&site.Environment,
+			&site.ParentSiteID,
+			&site.BasicAuthUsername,
+			&site.BasicAuthPasswordEncrypted,
 			&site.CreatedAt,
 			&site.UpdatedAt,
 		); err != nil {
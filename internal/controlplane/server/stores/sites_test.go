@@ -2,6 +2,7 @@ package stores
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -321,6 +322,79 @@ func TestSiteStoreDelete(t *testing.T) {
 	}
 }
 
+func TestSiteStoreStagingEnvironmentLinkedToParent(t *testing.T) {
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", filepath.Join(t.TempDir(), "age.key"))
+	db := mustOpenTestDB(t)
+	store := NewSiteStore(db)
+	serverID := mustInsertServerWithStatus(t, db, "ready")
+	otherServerID := mustInsertServerWithStatus(t, db, "ready")
+	parentID, err := store.Create(context.Background(), CreateSiteInput{ServerID: serverID, Name: "Shop", WordPressAdminEmail: "owner@example.test", Status: SiteStatusActive})
+	if err != nil {
+		t.Fatalf("create parent site: %v", err)
+	}
+
+	staging := CreateSiteInput{
+		ServerID:              serverID,
+		Name:                  "Shop (staging)",
+		WordPressAdminEmail:   "owner@example.test",
+		PrimaryHostnameConfig: &CreateSitePrimaryHostnameInput{Source: DomainSourceFallbackResolver, Label: "staging-shop"},
+		Status:                SiteStatusDraft,
+		Environment:           SiteEnvironmentStaging,
+		BasicAuthUsername:     "pressluft",
+		BasicAuthPassword:     "s3cret",
+	}
+	if _, err := store.Create(context.Background(), staging); err == nil {
+		t.Fatal("expected staging without parent to be rejected")
+	}
+	staging.ParentSiteID = parentID
+	staging.ServerID = otherServerID
+	if _, err := store.Create(context.Background(), staging); err == nil {
+		t.Fatal("expected staging on another server to be rejected")
+	}
+	staging.ServerID = serverID
+	stagingID, err := store.Create(context.Background(), staging)
+	if err != nil {
+		t.Fatalf("create staging site: %v", err)
+	}
+
+	site, err := store.GetByID(context.Background(), stagingID)
+	if err != nil {
+		t.Fatalf("get staging site: %v", err)
+	}
+	if site.Environment != SiteEnvironmentStaging || site.ParentSiteID != parentID || site.BasicAuthUsername != "pressluft" {
+		t.Fatalf("staging site = %+v, want linked staging environment", site)
+	}
+	if site.PrimaryDomain != "staging-shop.203-0-113-10.sslip.io" {
+		t.Fatalf("primary_domain = %q, want fallback resolver hostname", site.PrimaryDomain)
+	}
+	if site.BasicAuthPasswordEncrypted == "" || site.BasicAuthPasswordEncrypted == "s3cret" {
+		t.Fatalf("basic auth password stored as %q, want ciphertext", site.BasicAuthPasswordEncrypted)
+	}
+	if _, err := store.Create(context.Background(), CreateSiteInput{
+		ServerID: serverID, Name: "Nested", WordPressAdminEmail: "owner@example.test", Status: SiteStatusDraft,
+		Environment: SiteEnvironmentStaging, ParentSiteID: stagingID,
+	}); err == nil {
+		t.Fatal("expected staging of a staging site to be rejected")
+	}
+
+	children, err := store.ListByParent(context.Background(), parentID)
+	if err != nil {
+		t.Fatalf("list by parent: %v", err)
+	}
+	if len(children) != 1 || children[0].ID != stagingID {
+		t.Fatalf("children = %+v, want the staging site", children)
+	}
+	if err := store.Delete(context.Background(), parentID); err == nil || !strings.Contains(err.Error(), "staging environment") {
+		t.Fatalf("delete parent err = %v, want staging guard", err)
+	}
+	if err := store.Delete(context.Background(), stagingID); err != nil {
+		t.Fatalf("delete staging site: %v", err)
+	}
+	if err := store.Delete(context.Background(), parentID); err != nil {
+		t.Fatalf("delete parent after staging: %v", err)
+	}
+}
+
 func TestSiteStoreValidationAndNotFound(t *testing.T) {
 	db := mustOpenTestDB(t)
 	store := NewSiteStore(db)
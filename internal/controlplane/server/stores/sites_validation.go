@@ -40,6 +40,22 @@ func validateCreateSiteInput(in CreateSiteInput) error {
 	if _, err := NormalizeSiteStatus(in.Status); err != nil {
 		return err
 	}
+	environment, err := NormalizeSiteEnvironment(in.Environment)
+	if err != nil {
+		return err
+	}
+	if environment == SiteEnvironmentStaging && strings.TrimSpace(in.ParentSiteID) == "" {
+		return fmt.Errorf("parent_site_id is required for staging sites")
+	}
+	if environment == SiteEnvironmentProduction && strings.TrimSpace(in.ParentSiteID) != "" {
+		return fmt.Errorf("parent_site_id is only supported for staging sites")
+	}
+	if (strings.TrimSpace(in.BasicAuthUsername) == "") != (in.BasicAuthPassword == "") {
+		return fmt.Errorf("basic auth requires both a username and a password")
+	}
+	if strings.ContainsAny(in.BasicAuthUsername, ":\r\n") {
+		return fmt.Errorf("basic auth username must not contain colons or line breaks")
+	}
 	return nil
 }
 
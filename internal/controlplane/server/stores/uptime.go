@@ -0,0 +1,344 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+)
+
+// MaxUptimeKeywordLength bounds the keyword probes look for in response
+// bodies.
+const MaxUptimeKeywordLength = 200
+
+// UptimeSettings are a site's external probe settings.
+type UptimeSettings struct {
+	SiteID  string `json:"site_id"`
+	Enabled bool   `json:"enabled"`
+	// Keyword, when set, must appear in the response body for a probe to
+	// count as up.
+	Keyword   string `json:"keyword,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// UptimeCheckInput is the outcome of one probe of one hostname.
+type UptimeCheckInput struct {
+	SiteID     string
+	Hostname   string
+	CheckedAt  time.Time
+	Up         bool
+	StatusCode int
+	Latency    time.Duration
+	// TLSExpiresAt is the leaf certificate's expiry; zero when the TLS
+	// handshake did not complete.
+	TLSExpiresAt time.Time
+	// KeywordMatch is nil when no keyword is configured.
+	KeywordMatch *bool
+	Error        string
+}
+
+// StoredUptimeCheck is one recorded probe.
+type StoredUptimeCheck struct {
+	Hostname     string `json:"hostname"`
+	CheckedAt    string `json:"checked_at"`
+	Up           bool   `json:"up"`
+	StatusCode   int    `json:"status_code,omitempty"`
+	LatencyMS    int64  `json:"latency_ms"`
+	TLSExpiresAt string `json:"tls_expires_at,omitempty"`
+	KeywordMatch *bool  `json:"keyword_match,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// UptimeSummary aggregates a hostname's probes since a point in time.
+type UptimeSummary struct {
+	Hostname      string
+	Checks        int
+	UpChecks      int
+	UptimePercent float64
+	AvgLatencyMS  int64
+}
+
+// StoredUptimeIncident is an outage of one hostname. ResolvedAt is empty
+// while it is open.
+type StoredUptimeIncident struct {
+	ID         string `json:"id"`
+	SiteID     string `json:"site_id"`
+	Hostname   string `json:"hostname"`
+	Cause      string `json:"cause"`
+	StartedAt  string `json:"started_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+}
+
+// UptimeStore persists external probe settings, results and incidents.
+type UptimeStore struct {
+	db *sql.DB
+}
+
+func NewUptimeStore(db *sql.DB) *UptimeStore {
+	return &UptimeStore{db: db}
+}
+
+// GetSettings returns the site's probe settings, or the defaults when none
+// were saved.
+func (s *UptimeStore) GetSettings(ctx context.Context, siteID string) (UptimeSettings, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return UptimeSettings{}, fmt.Errorf("site_id: %w", err)
+	}
+	settings := UptimeSettings{SiteID: normalized}
+	var enabled int
+	err = s.db.QueryRowContext(ctx, `SELECT enabled, keyword, updated_at FROM uptime_monitors WHERE site_id = ?`, normalized).
+		Scan(&enabled, &settings.Keyword, &settings.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		settings.Enabled = true
+		return settings, nil
+	}
+	if err != nil {
+		return UptimeSettings{}, fmt.Errorf("get uptime settings: %w", err)
+	}
+	settings.Enabled = enabled == 1
+	return settings, nil
+}
+
+func (s *UptimeStore) SaveSettings(ctx context.Context, siteID string, enabled bool, keyword string) (UptimeSettings, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return UptimeSettings{}, fmt.Errorf("site_id: %w", err)
+	}
+	keyword = strings.TrimSpace(keyword)
+	if len(keyword) > MaxUptimeKeywordLength {
+		return UptimeSettings{}, fmt.Errorf("keyword must be at most %d characters", MaxUptimeKeywordLength)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO uptime_monitors (site_id, enabled, keyword, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(site_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			keyword = excluded.keyword,
+			updated_at = excluded.updated_at
+	`, normalized, boolToInt(enabled), keyword, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return UptimeSettings{}, fmt.Errorf("save uptime settings: %w", err)
+	}
+	return s.GetSettings(ctx, normalized)
+}
+
+func (s *UptimeStore) RecordCheck(ctx context.Context, in UptimeCheckInput) error {
+	normalized, err := idutil.Normalize(in.SiteID)
+	if err != nil {
+		return fmt.Errorf("site_id: %w", err)
+	}
+	var statusCode, tlsExpiresAt, keywordMatch any
+	if in.StatusCode > 0 {
+		statusCode = in.StatusCode
+	}
+	if !in.TLSExpiresAt.IsZero() {
+		tlsExpiresAt = in.TLSExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if in.KeywordMatch != nil {
+		keywordMatch = boolToInt(*in.KeywordMatch)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO uptime_checks (site_id, hostname, checked_at, up, status_code, latency_ms, tls_expires_at, keyword_match, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, normalized, strings.ToLower(strings.TrimSpace(in.Hostname)), in.CheckedAt.UTC().Format(time.RFC3339),
+		boolToInt(in.Up), statusCode, in.Latency.Milliseconds(), tlsExpiresAt, keywordMatch, nullableString(in.Error),
+	); err != nil {
+		return fmt.Errorf("record uptime check: %w", err)
+	}
+	return nil
+}
+
+// ListChecks returns a site's probes since the given time, newest first. An
+// empty hostname lists every hostname of the site.
+func (s *UptimeStore) ListChecks(ctx context.Context, siteID, hostname string, since time.Time, limit int) ([]StoredUptimeCheck, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	hostname = strings.ToLower(strings.TrimSpace(hostname))
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT hostname, checked_at, up, status_code, latency_ms, tls_expires_at, keyword_match, error
+		FROM uptime_checks
+		WHERE site_id = ? AND (? = '' OR hostname = ?) AND checked_at >= ?
+		ORDER BY id DESC
+		LIMIT ?
+	`, normalized, hostname, hostname, since.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list uptime checks: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]StoredUptimeCheck, 0)
+	for rows.Next() {
+		var (
+			check        StoredUptimeCheck
+			up           int
+			statusCode   sql.NullInt64
+			tlsExpiresAt sql.NullString
+			keywordMatch sql.NullInt64
+			checkError   sql.NullString
+		)
+		if err := rows.Scan(&check.Hostname, &check.CheckedAt, &up, &statusCode, &check.LatencyMS, &tlsExpiresAt, &keywordMatch, &checkError); err != nil {
+			return nil, fmt.Errorf("scan uptime check: %w", err)
+		}
+		check.Up = up == 1
+		check.StatusCode = int(statusCode.Int64)
+		check.TLSExpiresAt = nullStringValue(tlsExpiresAt)
+		if keywordMatch.Valid {
+			matched := keywordMatch.Int64 == 1
+			check.KeywordMatch = &matched
+		}
+		check.Error = nullStringValue(checkError)
+		out = append(out, check)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate uptime checks: %w", err)
+	}
+	return out, nil
+}
+
+// Summaries aggregates each of the site's hostnames since the given time.
+func (s *UptimeStore) Summaries(ctx context.Context, siteID string, since time.Time) ([]UptimeSummary, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT hostname, COUNT(*), COALESCE(SUM(up), 0), CAST(COALESCE(AVG(CASE WHEN up = 1 THEN latency_ms END), 0) AS INTEGER)
+		FROM uptime_checks
+		WHERE site_id = ? AND checked_at >= ?
+		GROUP BY hostname
+		ORDER BY hostname ASC
+	`, normalized, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("summarize uptime checks: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]UptimeSummary, 0)
+	for rows.Next() {
+		var summary UptimeSummary
+		if err := rows.Scan(&summary.Hostname, &summary.Checks, &summary.UpChecks, &summary.AvgLatencyMS); err != nil {
+			return nil, fmt.Errorf("scan uptime summary: %w", err)
+		}
+		if summary.Checks > 0 {
+			summary.UptimePercent = float64(summary.UpChecks) * 100 / float64(summary.Checks)
+		}
+		out = append(out, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate uptime summaries: %w", err)
+	}
+	return out, nil
+}
+
+// PruneChecks deletes probes recorded before the cutoff.
+func (s *UptimeStore) PruneChecks(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM uptime_checks WHERE checked_at < ?`, before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("prune uptime checks: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetOpenIncident returns the hostname's open incident, or nil.
+func (s *UptimeStore) GetOpenIncident(ctx context.Context, siteID, hostname string) (*StoredUptimeIncident, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	incident, err := scanUptimeIncident(s.db.QueryRowContext(ctx,
+		uptimeIncidentSelect+` WHERE site_id = ? AND hostname = ? AND resolved_at IS NULL`,
+		normalized, strings.ToLower(strings.TrimSpace(hostname)),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get open uptime incident: %w", err)
+	}
+	return &incident, nil
+}
+
+func (s *UptimeStore) OpenIncident(ctx context.Context, siteID, hostname, cause string, startedAt time.Time) (StoredUptimeIncident, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return StoredUptimeIncident{}, fmt.Errorf("site_id: %w", err)
+	}
+	id, err := idutil.New()
+	if err != nil {
+		return StoredUptimeIncident{}, err
+	}
+	incident := StoredUptimeIncident{
+		ID:        id,
+		SiteID:    normalized,
+		Hostname:  strings.ToLower(strings.TrimSpace(hostname)),
+		Cause:     strings.TrimSpace(cause),
+		StartedAt: startedAt.UTC().Format(time.RFC3339),
+	}
+	if _, err := s.db.ExecContext(ctx,
+		`INSERT INTO uptime_incidents (id, site_id, hostname, cause, started_at) VALUES (?, ?, ?, ?, ?)`,
+		incident.ID, incident.SiteID, incident.Hostname, incident.Cause, incident.StartedAt,
+	); err != nil {
+		return StoredUptimeIncident{}, fmt.Errorf("open uptime incident: %w", err)
+	}
+	return incident, nil
+}
+
+func (s *UptimeStore) ResolveIncident(ctx context.Context, id string, resolvedAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE uptime_incidents SET resolved_at = ? WHERE id = ? AND resolved_at IS NULL`,
+		resolvedAt.UTC().Format(time.RFC3339), id,
+	); err != nil {
+		return fmt.Errorf("resolve uptime incident: %w", err)
+	}
+	return nil
+}
+
+// ListIncidents returns the site's incidents, newest first.
+func (s *UptimeStore) ListIncidents(ctx context.Context, siteID string, limit int) ([]StoredUptimeIncident, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, uptimeIncidentSelect+` WHERE site_id = ? ORDER BY started_at DESC, id DESC LIMIT ?`, normalized, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list uptime incidents: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]StoredUptimeIncident, 0)
+	for rows.Next() {
+		incident, err := scanUptimeIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan uptime incident: %w", err)
+		}
+		out = append(out, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate uptime incidents: %w", err)
+	}
+	return out, nil
+}
+
+const uptimeIncidentSelect = `SELECT id, site_id, hostname, cause, started_at, resolved_at FROM uptime_incidents`
+
+func scanUptimeIncident(scanner interface{ Scan(dest ...any) error }) (StoredUptimeIncident, error) {
+	var incident StoredUptimeIncident
+	var resolvedAt sql.NullString
+	if err := scanner.Scan(&incident.ID, &incident.SiteID, &incident.Hostname, &incident.Cause, &incident.StartedAt, &resolvedAt); err != nil {
+		return StoredUptimeIncident{}, err
+	}
+	incident.ResolvedAt = nullStringValue(resolvedAt)
+	return incident, nil
+}
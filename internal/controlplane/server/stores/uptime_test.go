@@ -0,0 +1,65 @@
+package stores
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUptimeStoreKeepsOneOpenIncidentAndPrunesOldChecks(t *testing.T) {
+	db := mustOpenTestDB(t)
+	ctx := context.Background()
+	serverID := mustInsertServerWithStatus(t, db, "ready")
+	siteID, err := NewSiteStore(db).Create(ctx, CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+	store := NewUptimeStore(db)
+
+	settings, err := store.GetSettings(ctx, siteID)
+	if err != nil || !settings.Enabled || settings.Keyword != "" {
+		t.Fatalf("default settings = %+v (err %v), want enabled without keyword", settings, err)
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	incident, err := store.OpenIncident(ctx, siteID, "Shop.Example.Test", "request timed out", start)
+	if err != nil {
+		t.Fatalf("open incident: %v", err)
+	}
+	if _, err := store.OpenIncident(ctx, siteID, "shop.example.test", "request timed out", start.Add(time.Minute)); err == nil {
+		t.Fatal("second open incident for the same hostname succeeded")
+	}
+	if err := store.ResolveIncident(ctx, incident.ID, start.Add(5*time.Minute)); err != nil {
+		t.Fatalf("resolve incident: %v", err)
+	}
+	if _, err := store.OpenIncident(ctx, siteID, "shop.example.test", "TLS handshake failed", start.Add(time.Hour)); err != nil {
+		t.Fatalf("open incident after resolving: %v", err)
+	}
+	incidents, err := store.ListIncidents(ctx, siteID, 0)
+	if err != nil {
+		t.Fatalf("list incidents: %v", err)
+	}
+	if len(incidents) != 2 || incidents[0].Cause != "TLS handshake failed" || incidents[1].ResolvedAt != "2026-03-01T12:05:00Z" {
+		t.Fatalf("incidents = %+v, want the open one first and the resolved one after it", incidents)
+	}
+
+	for _, checkedAt := range []time.Time{start.Add(-48 * time.Hour), start} {
+		if err := store.RecordCheck(ctx, UptimeCheckInput{SiteID: siteID, Hostname: "shop.example.test", CheckedAt: checkedAt, Up: true, StatusCode: 200}); err != nil {
+			t.Fatalf("record check: %v", err)
+		}
+	}
+	pruned, err := store.PruneChecks(ctx, start.Add(-24*time.Hour))
+	if err != nil || pruned != 1 {
+		t.Fatalf("PruneChecks() = %d, %v; want 1", pruned, err)
+	}
+	checks, err := store.ListChecks(ctx, siteID, "", time.Time{}, 0)
+	if err != nil || len(checks) != 1 || checks[0].CheckedAt != "2026-03-01T12:00:00Z" || checks[0].KeywordMatch != nil {
+		t.Fatalf("checks after prune = %+v (err %v)", checks, err)
+	}
+}
@@ -0,0 +1,226 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
+)
+
+// StoredSiteVulnerability is a known vulnerability affecting a component
+// installed on a site, as of the site's last scan.
+type StoredSiteVulnerability struct {
+	SiteID           string   `json:"site_id"`
+	SiteName         string   `json:"site_name"`
+	ServerID         string   `json:"server_id"`
+	VulnerabilityID  string   `json:"vulnerability_id"`
+	ComponentType    string   `json:"component_type"`
+	ComponentName    string   `json:"component_name"`
+	InstalledVersion string   `json:"installed_version"`
+	Title            string   `json:"title"`
+	Description      string   `json:"description,omitempty"`
+	Severity         string   `json:"severity"`
+	Score            float64  `json:"score,omitempty"`
+	FixedIn          string   `json:"fixed_in,omitempty"`
+	References       []string `json:"references,omitempty"`
+	FirstSeenAt      string   `json:"first_seen_at"`
+	ScannedAt        string   `json:"scanned_at"`
+}
+
+type SiteVulnerabilityInput struct {
+	VulnerabilityID  string
+	ComponentType    string
+	ComponentName    string
+	InstalledVersion string
+	Title            string
+	Description      string
+	Severity         string
+	Score            float64
+	FixedIn          string
+	References       []string
+}
+
+// VulnerabilityChanges reports how a scan differs from the previous one.
+type VulnerabilityChanges struct {
+	New      []SiteVulnerabilityInput
+	Resolved int
+}
+
+// VulnerabilityStore persists per-site scan results. Rows are replaced on
+// every scan; first_seen_at survives so operators can see how long a
+// vulnerability has been open.
+type VulnerabilityStore struct {
+	db *sql.DB
+}
+
+func NewVulnerabilityStore(db *sql.DB) *VulnerabilityStore {
+	return &VulnerabilityStore{db: db}
+}
+
+type vulnerabilityKey struct {
+	id, componentType, componentName string
+}
+
+// ReplaceForSite swaps a site's findings for a fresh scan.
+func (s *VulnerabilityStore) ReplaceForSite(ctx context.Context, siteID string, findings []SiteVulnerabilityInput, scannedAt time.Time) (VulnerabilityChanges, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return VulnerabilityChanges{}, fmt.Errorf("site_id: %w", err)
+	}
+	scanned := scannedAt.UTC().Format(time.RFC3339)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return VulnerabilityChanges{}, fmt.Errorf("begin replace site vulnerabilities tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT vulnerability_id, component_type, component_name, first_seen_at FROM site_vulnerabilities WHERE site_id = ?`, normalized)
+	if err != nil {
+		return VulnerabilityChanges{}, fmt.Errorf("list previous site vulnerabilities: %w", err)
+	}
+	previous := map[vulnerabilityKey]string{}
+	for rows.Next() {
+		var key vulnerabilityKey
+		var firstSeen string
+		if err := rows.Scan(&key.id, &key.componentType, &key.componentName, &firstSeen); err != nil {
+			rows.Close()
+			return VulnerabilityChanges{}, fmt.Errorf("scan previous site vulnerability: %w", err)
+		}
+		previous[key] = firstSeen
+	}
+	if err := rows.Close(); err != nil {
+		return VulnerabilityChanges{}, fmt.Errorf("close previous site vulnerabilities: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM site_vulnerabilities WHERE site_id = ?`, normalized); err != nil {
+		return VulnerabilityChanges{}, fmt.Errorf("clear site vulnerabilities: %w", err)
+	}
+	var changes VulnerabilityChanges
+	current := map[vulnerabilityKey]bool{}
+	for _, finding := range findings {
+		key := vulnerabilityKey{
+			id:            strings.TrimSpace(finding.VulnerabilityID),
+			componentType: strings.TrimSpace(finding.ComponentType),
+			componentName: strings.TrimSpace(finding.ComponentName),
+		}
+		if key.id == "" || key.componentType == "" || key.componentName == "" {
+			return VulnerabilityChanges{}, fmt.Errorf("site vulnerability id and component are required")
+		}
+		if current[key] {
+			continue
+		}
+		current[key] = true
+		firstSeen, seen := previous[key]
+		if !seen {
+			firstSeen = scanned
+			changes.New = append(changes.New, finding)
+		}
+		refs, err := json.Marshal(nonNilStrings(finding.References))
+		if err != nil {
+			return VulnerabilityChanges{}, fmt.Errorf("encode vulnerability references: %w", err)
+		}
+		severity := strings.TrimSpace(finding.Severity)
+		if severity == "" {
+			severity = "unknown"
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO site_vulnerabilities (site_id, vulnerability_id, component_type, component_name, installed_version, title, description, severity, score, fixed_in, refs, first_seen_at, scanned_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			normalized, key.id, key.componentType, key.componentName, strings.TrimSpace(finding.InstalledVersion),
+			strings.TrimSpace(finding.Title), strings.TrimSpace(finding.Description), severity, finding.Score,
+			strings.TrimSpace(finding.FixedIn), string(refs), firstSeen, scanned,
+		); err != nil {
+			return VulnerabilityChanges{}, fmt.Errorf("insert site vulnerability: %w", err)
+		}
+	}
+	for key := range previous {
+		if !current[key] {
+			changes.Resolved++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return VulnerabilityChanges{}, fmt.Errorf("commit replace site vulnerabilities tx: %w", err)
+	}
+	return changes, nil
+}
+
+func (s *VulnerabilityStore) ListBySite(ctx context.Context, siteID string) ([]StoredSiteVulnerability, error) {
+	normalized, err := idutil.Normalize(siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site_id: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, siteVulnerabilitySelect+` WHERE v.site_id = ? ORDER BY `+siteVulnerabilityOrder, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("list site vulnerabilities: %w", err)
+	}
+	defer rows.Close()
+	return scanSiteVulnerabilities(rows)
+}
+
+// ListAll returns every open finding across the workspace's sites, most
+// severe first.
+func (s *VulnerabilityStore) ListAll(ctx context.Context) ([]StoredSiteVulnerability, error) {
+	rows, err := s.db.QueryContext(ctx,
+		siteVulnerabilitySelect+` WHERE (? = '' OR s.workspace_id = ?) ORDER BY `+siteVulnerabilityOrder+`, s.name ASC`,
+		workspace.Filter(ctx), workspace.Filter(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list vulnerabilities: %w", err)
+	}
+	defer rows.Close()
+	return scanSiteVulnerabilities(rows)
+}
+
+const siteVulnerabilitySelect = `SELECT v.site_id, s.name, s.server_id, v.vulnerability_id, v.component_type, v.component_name, v.installed_version,
+			v.title, v.description, v.severity, v.score, v.fixed_in, v.refs, v.first_seen_at, v.scanned_at
+		 FROM site_vulnerabilities v
+		 JOIN sites s ON s.id = v.site_id`
+
+const siteVulnerabilityOrder = `CASE v.severity WHEN 'critical' THEN 0 WHEN 'high' THEN 1 WHEN 'medium' THEN 2 WHEN 'low' THEN 3 ELSE 4 END, v.score DESC, v.component_name ASC`
+
+func scanSiteVulnerabilities(rows *sql.Rows) ([]StoredSiteVulnerability, error) {
+	var out []StoredSiteVulnerability
+	for rows.Next() {
+		var vulnerability StoredSiteVulnerability
+		var refs string
+		if err := rows.Scan(
+			&vulnerability.SiteID,
+			&vulnerability.SiteName,
+			&vulnerability.ServerID,
+			&vulnerability.VulnerabilityID,
+			&vulnerability.ComponentType,
+			&vulnerability.ComponentName,
+			&vulnerability.InstalledVersion,
+			&vulnerability.Title,
+			&vulnerability.Description,
+			&vulnerability.Severity,
+			&vulnerability.Score,
+			&vulnerability.FixedIn,
+			&refs,
+			&vulnerability.FirstSeenAt,
+			&vulnerability.ScannedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan site vulnerability: %w", err)
+		}
+		if err := json.Unmarshal([]byte(refs), &vulnerability.References); err != nil {
+			return nil, fmt.Errorf("decode vulnerability references: %w", err)
+		}
+		out = append(out, vulnerability)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate site vulnerabilities: %w", err)
+	}
+	return out, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,66 @@
+package stores
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestVulnerabilityStoreKeepsFirstSeenAndReportsChanges(t *testing.T) {
+	db := mustOpenTestDB(t)
+	sites := NewSiteStore(db)
+	store := NewVulnerabilityStore(db)
+	serverID := mustInsertServerWithStatus(t, db, "ready")
+	siteID, err := sites.Create(context.Background(), CreateSiteInput{
+		ServerID:            serverID,
+		Name:                "Shop",
+		WordPressAdminEmail: "owner@example.test",
+		PrimaryDomain:       "shop.example.test",
+		Status:              SiteStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("create site: %v", err)
+	}
+
+	xss := SiteVulnerabilityInput{VulnerabilityID: "vuln-xss", ComponentType: "plugin", ComponentName: "woocommerce", InstalledVersion: "8.6.0", Title: "Reflected XSS", Severity: "medium", Score: 6.1, FixedIn: "8.6.1", References: []string{"CVE-2024-0001"}}
+	sqli := SiteVulnerabilityInput{VulnerabilityID: "vuln-sqli", ComponentType: "plugin", ComponentName: "woocommerce", InstalledVersion: "8.6.0", Title: "SQL injection", Severity: "critical", Score: 9.8}
+	firstScan := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	changes, err := store.ReplaceForSite(context.Background(), siteID, []SiteVulnerabilityInput{xss}, firstScan)
+	if err != nil {
+		t.Fatalf("first scan: %v", err)
+	}
+	if len(changes.New) != 1 || changes.Resolved != 0 {
+		t.Fatalf("first scan changes = %+v, want one new", changes)
+	}
+
+	changes, err = store.ReplaceForSite(context.Background(), siteID, []SiteVulnerabilityInput{xss, sqli}, firstScan.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("second scan: %v", err)
+	}
+	if len(changes.New) != 1 || changes.New[0].VulnerabilityID != "vuln-sqli" {
+		t.Fatalf("second scan changes = %+v, want only the SQL injection as new", changes)
+	}
+
+	listed, err := store.ListBySite(context.Background(), siteID)
+	if err != nil {
+		t.Fatalf("list site vulnerabilities: %v", err)
+	}
+	if len(listed) != 2 || listed[0].Severity != "critical" || listed[1].FirstSeenAt != "2026-03-01T12:00:00Z" || listed[1].ScannedAt != "2026-03-01T13:00:00Z" {
+		t.Fatalf("listed = %+v, want critical first and original first_seen_at", listed)
+	}
+	if listed[1].SiteName != "Shop" || len(listed[1].References) != 1 || listed[1].FixedIn != "8.6.1" {
+		t.Fatalf("xss = %+v, want site name, references, and fix version", listed[1])
+	}
+
+	changes, err = store.ReplaceForSite(context.Background(), siteID, nil, firstScan.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("clean scan: %v", err)
+	}
+	if len(changes.New) != 0 || changes.Resolved != 2 {
+		t.Fatalf("clean scan changes = %+v, want two resolved", changes)
+	}
+	all, err := store.ListAll(context.Background())
+	if err != nil || len(all) != 0 {
+		t.Fatalf("list all = %+v (err %v), want none", all, err)
+	}
+}
@@ -0,0 +1,363 @@
+// Package uptime probes sites from the control plane the way visitors reach
+// them. The agent-side health checks run on the same box as the site and
+// cannot see DNS, TLS or network failures; these probes can.
+package uptime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/server/health"
+	"pressluft/internal/controlplane/server/stores"
+)
+
+// maxBodyBytes bounds how much of a response is searched for the keyword.
+const maxBodyBytes = 1 << 20
+
+// Config holds prober configuration.
+type Config struct {
+	// Interval is how often every hostname is probed.
+	Interval time.Duration
+	// Timeout bounds one probe, including the body read.
+	Timeout time.Duration
+	// Concurrency is how many probes run at once.
+	Concurrency int
+	// FailureThreshold is how many failed probes in a row open an incident,
+	// so one dropped packet does not page anyone.
+	FailureThreshold int
+	// Retention is how long probe results are kept.
+	Retention time.Duration
+	// Client, when set, sends the probes. Its timeout is left alone.
+	Client *http.Client
+}
+
+// DefaultConfig returns sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		Interval:         time.Minute,
+		Timeout:          15 * time.Second,
+		Concurrency:      8,
+		FailureThreshold: 2,
+		Retention:        90 * 24 * time.Hour,
+	}
+}
+
+// Prober checks every ready site's hostnames over HTTPS, records the results
+// and opens and resolves incidents.
+type Prober struct {
+	siteStore     *stores.SiteStore
+	domainStore   *stores.DomainStore
+	uptimeStore   *stores.UptimeStore
+	activityStore *activity.Store
+	client        *http.Client
+	config        Config
+	logger        *slog.Logger
+}
+
+// NewProber creates a prober with the given dependencies.
+func NewProber(siteStore *stores.SiteStore, domainStore *stores.DomainStore, uptimeStore *stores.UptimeStore, activityStore *activity.Store, logger *slog.Logger, config Config) *Prober {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	defaults := DefaultConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+	client := config.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	return &Prober{
+		siteStore:     siteStore,
+		domainStore:   domainStore,
+		uptimeStore:   uptimeStore,
+		activityStore: activityStore,
+		client:        client,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// Run starts the probe loop. It blocks until ctx is cancelled.
+func (p *Prober) Run(ctx context.Context) {
+	p.logger.Info("uptime prober started", "interval", p.config.Interval)
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("uptime prober shutting down")
+			return
+		case <-ticker.C:
+			p.Tick(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// target is one hostname of one site.
+type target struct {
+	site     stores.StoredSite
+	hostname string
+	keyword  string
+	auth     *health.BasicAuth
+}
+
+// Tick probes every target once and prunes results past retention.
+func (p *Prober) Tick(ctx context.Context, now time.Time) {
+	targets, err := p.targets(ctx)
+	if err != nil {
+		p.logger.Error("uptime probe failed to list sites", "error", err)
+		return
+	}
+
+	sem := make(chan struct{}, p.config.Concurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			p.check(ctx, t, now)
+		}()
+	}
+	wg.Wait()
+
+	if _, err := p.uptimeStore.PruneChecks(ctx, now.Add(-p.config.Retention)); err != nil {
+		p.logger.Warn("uptime check pruning failed", "error", err)
+	}
+}
+
+// targets lists the primary and alias hostnames of every ready site with
+// probing enabled.
+func (p *Prober) targets(ctx context.Context) ([]target, error) {
+	sites, err := p.siteStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]target, 0, len(sites))
+	for _, site := range sites {
+		if site.DeploymentState != stores.SiteDeploymentStateReady {
+			continue
+		}
+		settings, err := p.uptimeStore.GetSettings(ctx, site.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !settings.Enabled {
+			continue
+		}
+		auth, err := health.SiteBasicAuth(site)
+		if err != nil {
+			p.logger.Warn("uptime probe sends no basic auth", "site_id", site.ID, "error", err)
+			auth = nil
+		}
+		hostnames := make([]string, 0, 2)
+		if primary := strings.ToLower(strings.TrimSpace(site.PrimaryDomain)); primary != "" {
+			hostnames = append(hostnames, primary)
+		}
+		if p.domainStore != nil {
+			domains, err := p.domainStore.ListBySite(ctx, site.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, domain := range domains {
+				hostname := strings.ToLower(strings.TrimSpace(domain.Hostname))
+				if hostname != "" && !slices.Contains(hostnames, hostname) {
+					hostnames = append(hostnames, hostname)
+				}
+			}
+		}
+		for _, hostname := range hostnames {
+			out = append(out, target{site: site, hostname: hostname, keyword: settings.Keyword, auth: auth})
+		}
+	}
+	return out, nil
+}
+
+func (p *Prober) check(ctx context.Context, t target, now time.Time) {
+	result := p.Probe(ctx, t.hostname, t.keyword, t.auth)
+	result.SiteID = t.site.ID
+	result.CheckedAt = now
+	if err := p.uptimeStore.RecordCheck(ctx, result); err != nil {
+		p.logger.Error("record uptime check failed", "site_id", t.site.ID, "hostname", t.hostname, "error", err)
+		return
+	}
+	if err := p.reconcileIncident(ctx, t, result); err != nil {
+		p.logger.Error("uptime incident update failed", "site_id", t.site.ID, "hostname", t.hostname, "error", err)
+	}
+}
+
+// Probe fetches https://hostname/ once and reports what a visitor would see.
+// The result's SiteID and CheckedAt are left for the caller.
+func (p *Prober) Probe(ctx context.Context, hostname, keyword string, auth *health.BasicAuth) stores.UptimeCheckInput {
+	result := stores.UptimeCheckInput{Hostname: hostname}
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+hostname+"/", nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("User-Agent", "Pressluft-Uptime/1.0")
+	if auth != nil {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	started := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		result.Latency = time.Since(started)
+		result.Error = describeProbeError(err)
+		return result
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	result.Latency = time.Since(started)
+	result.StatusCode = resp.StatusCode
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		result.TLSExpiresAt = resp.TLS.PeerCertificates[0].NotAfter
+	}
+	if err != nil {
+		result.Error = "read response: " + describeProbeError(err)
+		return result
+	}
+	if keyword != "" {
+		matched := strings.Contains(string(body), keyword)
+		result.KeywordMatch = &matched
+	}
+
+	switch {
+	case resp.StatusCode < 200 || resp.StatusCode >= 400:
+		result.Error = fmt.Sprintf("unexpected HTTPS status %d", resp.StatusCode)
+	case result.KeywordMatch != nil && !*result.KeywordMatch:
+		result.Error = fmt.Sprintf("keyword %q not found in the response", keyword)
+	default:
+		result.Up = true
+	}
+	return result
+}
+
+// describeProbeError names the layer a probe failed at, which is what an
+// operator needs to know first.
+func describeProbeError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return "DNS lookup failed: " + dnsErr.Error()
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return "request timed out"
+	case strings.Contains(err.Error(), "tls:") || strings.Contains(err.Error(), "x509:"):
+		return "TLS handshake failed: " + err.Error()
+	default:
+		return err.Error()
+	}
+}
+
+// reconcileIncident opens an incident once FailureThreshold probes in a row
+// failed, and resolves it on the next successful probe.
+func (p *Prober) reconcileIncident(ctx context.Context, t target, result stores.UptimeCheckInput) error {
+	open, err := p.uptimeStore.GetOpenIncident(ctx, t.site.ID, t.hostname)
+	if err != nil {
+		return err
+	}
+	if result.Up {
+		if open == nil {
+			return nil
+		}
+		if err := p.uptimeStore.ResolveIncident(ctx, open.ID, result.CheckedAt); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("%s is reachable again.", t.hostname)
+		if started, err := time.Parse(time.RFC3339, open.StartedAt); err == nil {
+			message = fmt.Sprintf("%s is reachable again after %s of downtime.", t.hostname, result.CheckedAt.Sub(started).Round(time.Second))
+		}
+		p.emit(ctx, t, *open, activity.EmitInput{
+			EventType: activity.EventSiteDowntimeResolved,
+			Level:     activity.LevelSuccess,
+			Title:     fmt.Sprintf("Site '%s' is back up", t.site.Name),
+			Message:   message,
+		})
+		return nil
+	}
+	if open != nil {
+		return nil
+	}
+
+	recent, err := p.uptimeStore.ListChecks(ctx, t.site.ID, t.hostname, time.Time{}, p.config.FailureThreshold)
+	if err != nil {
+		return err
+	}
+	if len(recent) < p.config.FailureThreshold {
+		return nil
+	}
+	for _, check := range recent {
+		if check.Up {
+			return nil
+		}
+	}
+	startedAt := result.CheckedAt
+	if first, err := time.Parse(time.RFC3339, recent[len(recent)-1].CheckedAt); err == nil {
+		startedAt = first
+	}
+	incident, err := p.uptimeStore.OpenIncident(ctx, t.site.ID, t.hostname, result.Error, startedAt)
+	if err != nil {
+		return err
+	}
+	p.emit(ctx, t, incident, activity.EmitInput{
+		EventType:         activity.EventSiteDowntimeStarted,
+		Level:             activity.LevelError,
+		Title:             fmt.Sprintf("Site '%s' is down", t.site.Name),
+		Message:           fmt.Sprintf("%s failed %d probes in a row: %s", t.hostname, len(recent), result.Error),
+		RequiresAttention: true,
+	})
+	return nil
+}
+
+func (p *Prober) emit(ctx context.Context, t target, incident stores.StoredUptimeIncident, in activity.EmitInput) {
+	if p.activityStore == nil {
+		return
+	}
+	payload, _ := json.Marshal(map[string]string{"incident_id": incident.ID, "hostname": incident.Hostname})
+	in.Category = activity.CategorySite
+	in.ResourceType = activity.ResourceSite
+	in.ResourceID = t.site.ID
+	in.ParentResourceType = activity.ResourceServer
+	in.ParentResourceID = t.site.ServerID
+	in.ActorType = activity.ActorSystem
+	in.Payload = string(payload)
+	if _, err := p.activityStore.Emit(ctx, in); err != nil {
+		p.logger.Warn("emit uptime activity failed", "site_id", t.site.ID, "error", err)
+	}
+}
@@ -0,0 +1,294 @@
+package uptime
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/server/stores"
+
+	_ "modernc.org/sqlite"
+)
+
+const testSiteID = "00000000-0000-7000-8000-000000000101"
+
+type proberHarness struct {
+	db            *sql.DB
+	uptimeStore   *stores.UptimeStore
+	activityStore *activity.Store
+	prober        *Prober
+	// status is what the stand-in site answers with.
+	status atomic.Int32
+}
+
+// newProberHarness serves one site over TLS. Every hostname dials the same
+// test server, whose certificate covers example.com and 127.0.0.1.
+func newProberHarness(t *testing.T) *proberHarness {
+	t.Helper()
+	h := &proberHarness{db: mustOpenUptimeDB(t)}
+	h.status.Store(http.StatusOK)
+	site := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(h.status.Load()))
+		_, _ = io.WriteString(w, "<html><body>Welcome to the shop</body></html>")
+	}))
+	t.Cleanup(site.Close)
+
+	client := site.Client()
+	transport := client.Transport.(*http.Transport)
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, site.Listener.Addr().String())
+	}
+
+	h.uptimeStore = stores.NewUptimeStore(h.db)
+	h.activityStore = activity.NewStore(h.db)
+	h.prober = NewProber(
+		stores.NewSiteStore(h.db),
+		stores.NewDomainStore(h.db),
+		h.uptimeStore,
+		h.activityStore,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config{Client: client, FailureThreshold: 2},
+	)
+	return h
+}
+
+func (h *proberHarness) activityOf(t *testing.T, eventType activity.EventType) []activity.Activity {
+	t.Helper()
+	entries, _, err := h.activityStore.List(context.Background(), activity.ListFilter{Category: activity.CategorySite, Limit: 50})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	out := make([]activity.Activity, 0, len(entries))
+	for _, entry := range entries {
+		if entry.EventType == eventType {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+func TestProberRecordsChecksAndTracksIncidents(t *testing.T) {
+	h := newProberHarness(t)
+	ctx := context.Background()
+	if _, err := h.uptimeStore.SaveSettings(ctx, testSiteID, true, "Welcome"); err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+	start := time.Now().UTC().Truncate(time.Second)
+
+	h.prober.Tick(ctx, start)
+	checks, err := h.uptimeStore.ListChecks(ctx, testSiteID, "", start.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListChecks() error = %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("checks = %+v, want the primary and the alias hostname", checks)
+	}
+	for _, check := range checks {
+		if !check.Up || check.StatusCode != http.StatusOK || check.TLSExpiresAt == "" || check.KeywordMatch == nil || !*check.KeywordMatch {
+			t.Fatalf("check = %+v, want up with TLS expiry and a keyword match", check)
+		}
+	}
+
+	h.status.Store(http.StatusServiceUnavailable)
+	h.prober.Tick(ctx, start.Add(time.Minute))
+	if got := h.activityOf(t, activity.EventSiteDowntimeStarted); len(got) != 0 {
+		t.Fatalf("downtime opened after a single failed probe: %+v", got)
+	}
+	h.prober.Tick(ctx, start.Add(2*time.Minute))
+	started := h.activityOf(t, activity.EventSiteDowntimeStarted)
+	if len(started) != 2 || !started[0].RequiresAttention || !strings.Contains(started[0].Message, "unexpected HTTPS status 503") {
+		t.Fatalf("downtime activity = %+v, want one entry per hostname", started)
+	}
+	incident, err := h.uptimeStore.GetOpenIncident(ctx, testSiteID, "example.com")
+	if err != nil || incident == nil {
+		t.Fatalf("GetOpenIncident() = %+v, %v", incident, err)
+	}
+	if incident.StartedAt != start.Add(time.Minute).Format(time.RFC3339) {
+		t.Fatalf("incident started at %s, want the first failed probe", incident.StartedAt)
+	}
+
+	h.prober.Tick(ctx, start.Add(3*time.Minute))
+	if got := h.activityOf(t, activity.EventSiteDowntimeStarted); len(got) != 2 {
+		t.Fatalf("an open incident was opened again: %d entries", len(got))
+	}
+
+	h.status.Store(http.StatusOK)
+	h.prober.Tick(ctx, start.Add(4*time.Minute))
+	resolved := h.activityOf(t, activity.EventSiteDowntimeResolved)
+	if len(resolved) != 2 || !strings.Contains(resolved[0].Message, "after 3m0s of downtime") {
+		t.Fatalf("recovery activity = %+v, want one entry per hostname", resolved)
+	}
+	if incident, err := h.uptimeStore.GetOpenIncident(ctx, testSiteID, "example.com"); err != nil || incident != nil {
+		t.Fatalf("GetOpenIncident() after recovery = %+v, %v", incident, err)
+	}
+
+	summaries, err := h.uptimeStore.Summaries(ctx, testSiteID, start.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Summaries() error = %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Checks != 5 || summaries[0].UpChecks != 2 || summaries[0].UptimePercent != 40 {
+		t.Fatalf("summaries = %+v, want 2 of 5 probes up per hostname", summaries)
+	}
+}
+
+func TestProberChecksKeywordAndSkipsDisabledSites(t *testing.T) {
+	h := newProberHarness(t)
+	ctx := context.Background()
+	if _, err := h.uptimeStore.SaveSettings(ctx, testSiteID, true, "Checkout"); err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+	now := time.Now().UTC()
+
+	h.prober.Tick(ctx, now)
+	checks, err := h.uptimeStore.ListChecks(ctx, testSiteID, "example.com", now.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListChecks() error = %v", err)
+	}
+	if len(checks) != 1 || checks[0].Up || checks[0].KeywordMatch == nil || *checks[0].KeywordMatch || !strings.Contains(checks[0].Error, `keyword "Checkout" not found`) {
+		t.Fatalf("checks = %+v, want a keyword miss counted as down", checks)
+	}
+
+	if _, err := h.uptimeStore.SaveSettings(ctx, testSiteID, false, ""); err != nil {
+		t.Fatalf("SaveSettings() error = %v", err)
+	}
+	h.prober.Tick(ctx, now.Add(time.Minute))
+	checks, err = h.uptimeStore.ListChecks(ctx, testSiteID, "", now.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListChecks() error = %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("got %d checks, want no probes while disabled", len(checks))
+	}
+}
+
+func mustOpenUptimeDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "uptime.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	if _, err := db.Exec(`
+		CREATE TABLE servers (
+			id   TEXT PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE sites (
+			id                            TEXT PRIMARY KEY,
+			workspace_id                  TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			server_id                     TEXT NOT NULL,
+			name                          TEXT NOT NULL,
+			wordpress_admin_email         TEXT,
+			primary_domain                TEXT,
+			status                        TEXT NOT NULL DEFAULT 'draft',
+			deployment_state              TEXT NOT NULL DEFAULT 'pending',
+			deployment_status_message     TEXT,
+			last_deploy_job_id            TEXT,
+			last_deployed_at              TEXT,
+			runtime_health_state          TEXT NOT NULL DEFAULT 'pending',
+			runtime_health_status_message TEXT,
+			last_health_check_at          TEXT,
+			wordpress_path                TEXT,
+			php_version                   TEXT,
+			wordpress_version             TEXT,
+			environment                   TEXT NOT NULL DEFAULT 'production',
+			parent_site_id                TEXT,
+			basic_auth_username           TEXT,
+			basic_auth_password_encrypted TEXT,
+			created_at                    TEXT NOT NULL,
+			updated_at                    TEXT NOT NULL
+		);
+		CREATE TABLE domains (
+			id                     TEXT PRIMARY KEY,
+			workspace_id           TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			hostname               TEXT NOT NULL,
+			kind                   TEXT NOT NULL,
+			source                 TEXT NOT NULL,
+			dns_state              TEXT NOT NULL DEFAULT 'pending',
+			routing_state          TEXT NOT NULL DEFAULT 'not_configured',
+			dns_status_message     TEXT,
+			routing_status_message TEXT,
+			last_checked_at        TEXT,
+			site_id                TEXT,
+			parent_domain_id       TEXT,
+			is_primary             INTEGER NOT NULL DEFAULT 0,
+			created_at             TEXT NOT NULL,
+			updated_at             TEXT NOT NULL
+		);
+		CREATE TABLE uptime_monitors (
+			site_id    TEXT PRIMARY KEY,
+			enabled    INTEGER NOT NULL DEFAULT 1,
+			keyword    TEXT NOT NULL DEFAULT '',
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE uptime_checks (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			site_id        TEXT NOT NULL,
+			hostname       TEXT NOT NULL,
+			checked_at     TEXT NOT NULL,
+			up             INTEGER NOT NULL,
+			status_code    INTEGER,
+			latency_ms     INTEGER NOT NULL DEFAULT 0,
+			tls_expires_at TEXT,
+			keyword_match  INTEGER,
+			error          TEXT
+		);
+		CREATE TABLE uptime_incidents (
+			id          TEXT PRIMARY KEY,
+			site_id     TEXT NOT NULL,
+			hostname    TEXT NOT NULL,
+			cause       TEXT NOT NULL,
+			started_at  TEXT NOT NULL,
+			resolved_at TEXT
+		);
+		CREATE UNIQUE INDEX idx_uptime_incidents_open ON uptime_incidents(site_id, hostname) WHERE resolved_at IS NULL;
+		CREATE TABLE activity (
+			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			event_type           TEXT NOT NULL,
+			category             TEXT NOT NULL,
+			level                TEXT NOT NULL,
+			resource_type        TEXT,
+			resource_id          TEXT,
+			parent_resource_type TEXT,
+			parent_resource_id   TEXT,
+			actor_type           TEXT NOT NULL,
+			actor_id             TEXT,
+			title                TEXT NOT NULL,
+			message              TEXT,
+			payload              TEXT,
+			requires_attention   INTEGER NOT NULL DEFAULT 0,
+			read_at              TEXT,
+			created_at           TEXT NOT NULL
+		);
+	`); err != nil {
+		t.Fatalf("create uptime schema: %v", err)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO servers (id, name) VALUES ('00000000-0000-7000-8000-000000000100', 'web-1');
+		INSERT INTO sites (id, server_id, name, primary_domain, status, deployment_state, created_at, updated_at)
+		VALUES (?, '00000000-0000-7000-8000-000000000100', 'Shop', 'example.com', 'active', 'ready', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z');
+		INSERT INTO sites (id, server_id, name, primary_domain, status, deployment_state, created_at, updated_at)
+		VALUES ('00000000-0000-7000-8000-000000000102', '00000000-0000-7000-8000-000000000100', 'Draft', 'draft.example.com', 'draft', 'pending', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z');
+		INSERT INTO domains (id, hostname, kind, source, site_id, is_primary, created_at, updated_at)
+		VALUES ('00000000-0000-7000-8000-000000000201', 'example.com', 'direct', 'user', ?, 1, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z'),
+		       ('00000000-0000-7000-8000-000000000202', '127.0.0.1', 'direct', 'user', ?, 0, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z');
+	`, testSiteID, testSiteID, testSiteID); err != nil {
+		t.Fatalf("insert site: %v", err)
+	}
+	return db
+}
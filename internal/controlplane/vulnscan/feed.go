@@ -0,0 +1,251 @@
+// Package vulnscan matches site inventories against a WordPress vulnerability
+// feed in the wpvulnerability.com API format.
+//
+// The feed is a single local JSON file so scans work offline and in tests:
+//
+//	{
+//	  "core":    {"6.4.1": <wpvulnerability response>, ...},
+//	  "plugins": {"woocommerce": <wpvulnerability response>, ...},
+//	  "themes":  {"astra": <wpvulnerability response>, ...}
+//	}
+//
+// Each response is stored exactly as returned by the API, for example
+// https://www.wpvulnerability.net/plugin/woocommerce/.
+package vulnscan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severities normalized from the feed's single-letter CVSS ratings.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityUnknown  = "unknown"
+)
+
+// Vulnerability is one feed entry for a core version, plugin, or theme.
+type Vulnerability struct {
+	ID          string
+	Title       string
+	Description string
+	Severity    string
+	Score       float64
+	References  []string
+
+	minVersion  string
+	minOperator string
+	maxVersion  string
+	maxOperator string
+	// exactVersion is set for core entries that carry no bounds; they apply
+	// to the version they were listed under only.
+	exactVersion string
+}
+
+// Feed is a parsed vulnerability feed keyed by component.
+type Feed struct {
+	core    []Vulnerability
+	plugins map[string][]Vulnerability
+	themes  map[string][]Vulnerability
+}
+
+type feedFile struct {
+	Core    map[string]apiResponse `json:"core"`
+	Plugins map[string]apiResponse `json:"plugins"`
+	Themes  map[string]apiResponse `json:"themes"`
+}
+
+type apiResponse struct {
+	Error int      `json:"error"`
+	Data  *apiData `json:"data"`
+}
+
+type apiData struct {
+	Name          string             `json:"name"`
+	Vulnerability []apiVulnerability `json:"vulnerability"`
+}
+
+type apiVulnerability struct {
+	UUID        string      `json:"uuid"`
+	Name        string      `json:"name"`
+	Description looseString `json:"description"`
+	Operator    struct {
+		MinVersion  looseString `json:"min_version"`
+		MinOperator looseString `json:"min_operator"`
+		MaxVersion  looseString `json:"max_version"`
+		MaxOperator looseString `json:"max_operator"`
+	} `json:"operator"`
+	Source []struct {
+		ID   looseString `json:"id"`
+		Link looseString `json:"link"`
+	} `json:"source"`
+	Impact struct {
+		CVSS struct {
+			Score    looseString `json:"score"`
+			Severity looseString `json:"severity"`
+		} `json:"cvss"`
+	} `json:"impact"`
+}
+
+// looseString accepts the strings, numbers, and nulls the API mixes freely.
+type looseString string
+
+func (s *looseString) UnmarshalJSON(raw []byte) error {
+	raw = bytes.TrimSpace(raw)
+	if bytes.Equal(raw, []byte("null")) {
+		*s = ""
+		return nil
+	}
+	if len(raw) > 0 && raw[0] == '"' {
+		var out string
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return err
+		}
+		*s = looseString(strings.TrimSpace(out))
+		return nil
+	}
+	*s = looseString(raw)
+	return nil
+}
+
+// ParseFeed decodes a feed file.
+func ParseFeed(raw []byte) (*Feed, error) {
+	var file feedFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("decode vulnerability feed: %w", err)
+	}
+	feed := &Feed{
+		plugins: make(map[string][]Vulnerability, len(file.Plugins)),
+		themes:  make(map[string][]Vulnerability, len(file.Themes)),
+	}
+	seenCore := map[string]bool{}
+	for version, response := range file.Core {
+		for _, vulnerability := range response.vulnerabilities() {
+			if vulnerability.minVersion == "" && vulnerability.maxVersion == "" {
+				vulnerability.exactVersion = strings.TrimSpace(version)
+			} else if seenCore[vulnerability.ID] {
+				continue
+			}
+			seenCore[vulnerability.ID] = true
+			feed.core = append(feed.core, vulnerability)
+		}
+	}
+	for slug, response := range file.Plugins {
+		feed.plugins[strings.ToLower(strings.TrimSpace(slug))] = response.vulnerabilities()
+	}
+	for slug, response := range file.Themes {
+		feed.themes[strings.ToLower(strings.TrimSpace(slug))] = response.vulnerabilities()
+	}
+	return feed, nil
+}
+
+// LoadFeed reads and parses a feed file.
+func LoadFeed(path string) (*Feed, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read vulnerability feed: %w", err)
+	}
+	return ParseFeed(raw)
+}
+
+func (r apiResponse) vulnerabilities() []Vulnerability {
+	if r.Error != 0 || r.Data == nil {
+		return nil
+	}
+	out := make([]Vulnerability, 0, len(r.Data.Vulnerability))
+	for _, entry := range r.Data.Vulnerability {
+		vulnerability := Vulnerability{
+			ID:          strings.TrimSpace(entry.UUID),
+			Title:       strings.TrimSpace(entry.Name),
+			Description: string(entry.Description),
+			Severity:    normalizeSeverity(string(entry.Impact.CVSS.Severity)),
+			minVersion:  string(entry.Operator.MinVersion),
+			minOperator: string(entry.Operator.MinOperator),
+			maxVersion:  string(entry.Operator.MaxVersion),
+			maxOperator: string(entry.Operator.MaxOperator),
+		}
+		if vulnerability.ID == "" {
+			continue
+		}
+		if score, err := strconv.ParseFloat(string(entry.Impact.CVSS.Score), 64); err == nil {
+			vulnerability.Score = score
+		}
+		for _, source := range entry.Source {
+			switch {
+			case source.ID != "":
+				vulnerability.References = append(vulnerability.References, string(source.ID))
+			case source.Link != "":
+				vulnerability.References = append(vulnerability.References, string(source.Link))
+			}
+		}
+		out = append(out, vulnerability)
+	}
+	return out
+}
+
+func normalizeSeverity(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "c", "critical":
+		return SeverityCritical
+	case "h", "high":
+		return SeverityHigh
+	case "m", "medium":
+		return SeverityMedium
+	case "l", "low", "n", "none":
+		return SeverityLow
+	default:
+		return SeverityUnknown
+	}
+}
+
+// Source supplies the current feed. Scans are skipped while it returns an
+// error, for example before an operator has downloaded a feed.
+type Source interface {
+	Feed() (*Feed, error)
+}
+
+// FileSource loads the feed from disk and reloads it whenever the file's
+// modification time changes, so dropping in a new file takes effect on the
+// next scan without a restart.
+type FileSource struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	feed    *Feed
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: strings.TrimSpace(path)}
+}
+
+func (s *FileSource) Feed() (*Feed, error) {
+	if s == nil || s.path == "" {
+		return nil, fmt.Errorf("vulnerability feed path not configured")
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("stat vulnerability feed: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.feed != nil && info.ModTime().Equal(s.modTime) {
+		return s.feed, nil
+	}
+	feed, err := LoadFeed(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.feed = feed
+	s.modTime = info.ModTime()
+	return feed, nil
+}
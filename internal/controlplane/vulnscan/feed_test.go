@@ -0,0 +1,105 @@
+package vulnscan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testFeed = `{
+	"core": {
+		"6.4.1": {"error": 0, "data": {"name": "6.4.1", "vulnerability": [
+			{"uuid": "core-pop-chain", "name": "WordPress 6.4.1 - POP chain", "operator": {"min_version": null, "min_operator": null, "max_version": null, "max_operator": null, "unfixed": "0"},
+			 "source": [{"id": "CVE-2023-0001", "link": "https://example.test/cve"}], "impact": {"cvss": {"score": "9.8", "severity": "c"}}}
+		]}}
+	},
+	"plugins": {
+		"WooCommerce": {"error": 0, "data": {"name": "WooCommerce", "plugin": "woocommerce", "vulnerability": [
+			{"uuid": "woo-xss", "name": "WooCommerce < 8.9.3 - Reflected XSS", "description": null,
+			 "operator": {"min_version": null, "min_operator": null, "max_version": "8.9.3", "max_operator": "lt", "unfixed": "0", "closed": "0"},
+			 "source": [{"id": "CVE-2024-0002"}], "impact": {"cvss": {"version": "3.1", "score": 6.1, "severity": "m"}}},
+			{"uuid": "woo-old", "name": "WooCommerce 2.x - CSRF",
+			 "operator": {"min_version": "2.0", "min_operator": "ge", "max_version": "2.9.9", "max_operator": "le"},
+			 "source": [], "impact": {}}
+		]}}
+	},
+	"themes": {
+		"astra": {"error": 1, "message": "not found", "data": null}
+	}
+}`
+
+func TestFeedMatchesVersionRanges(t *testing.T) {
+	feed, err := ParseFeed([]byte(testFeed))
+	if err != nil {
+		t.Fatalf("parse feed: %v", err)
+	}
+	findings := feed.Match([]Component{
+		{Type: ComponentPlugin, Name: "woocommerce", Version: "8.9.2"},
+		{Type: ComponentCore, Name: "wordpress", Version: "6.4.1"},
+		{Type: ComponentTheme, Name: "astra", Version: "4.0.0"},
+		{Type: ComponentPlugin, Name: "akismet", Version: "5.0"},
+	})
+	if len(findings) != 2 {
+		t.Fatalf("findings = %+v, want core and woocommerce", findings)
+	}
+	if findings[0].ID != "core-pop-chain" || findings[0].Severity != SeverityCritical || findings[0].Score != 9.8 || findings[0].References[0] != "CVE-2023-0001" {
+		t.Fatalf("first finding = %+v, want the critical core issue", findings[0])
+	}
+	if findings[1].ID != "woo-xss" || findings[1].FixedIn != "8.9.3" || findings[1].Severity != SeverityMedium || findings[1].ComponentName != "woocommerce" {
+		t.Fatalf("second finding = %+v, want the woocommerce XSS fixed in 8.9.3", findings[1])
+	}
+
+	if got := feed.Match([]Component{{Type: ComponentPlugin, Name: "woocommerce", Version: "8.9.3"}, {Type: ComponentCore, Name: "wordpress", Version: "6.4.2"}}); len(got) != 0 {
+		t.Fatalf("findings for patched versions = %+v, want none", got)
+	}
+	if got := feed.Match([]Component{{Type: ComponentPlugin, Name: "woocommerce", Version: "2.5.1"}}); len(got) != 2 || got[1].ID != "woo-old" || got[1].FixedIn != "" {
+		t.Fatalf("findings for 2.5.1 = %+v, want both woocommerce entries", got)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"6.5", "6.5.0", -1},
+		{"6.5.1", "6.5", 1},
+		{"6.10", "6.9.9", 1},
+		{"6.5-RC1", "6.5", -1},
+		{"6.5-beta2", "6.5-rc1", -1},
+		{"8.9.2", "8.9.2", 0},
+		{"1.0.0-dev", "1.0.0-alpha", -1},
+	}
+	for _, tc := range cases {
+		if got := CompareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestFileSourceReloadsChangedFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vulnerabilities.json")
+	source := NewFileSource(path)
+	if _, err := source.Feed(); err == nil {
+		t.Fatal("expected error for missing feed file")
+	}
+	if err := os.WriteFile(path, []byte(`{"plugins": {}}`), 0o600); err != nil {
+		t.Fatalf("write feed: %v", err)
+	}
+	feed, err := source.Feed()
+	if err != nil || len(feed.Match([]Component{{Type: ComponentPlugin, Name: "woocommerce", Version: "8.9.2"}})) != 0 {
+		t.Fatalf("empty feed = %v (err %v)", feed, err)
+	}
+	if err := os.WriteFile(path, []byte(testFeed), 0o600); err != nil {
+		t.Fatalf("rewrite feed: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("touch feed: %v", err)
+	}
+	feed, err = source.Feed()
+	if err != nil || len(feed.Match([]Component{{Type: ComponentPlugin, Name: "woocommerce", Version: "8.9.2"}})) != 1 {
+		t.Fatalf("reloaded feed did not match (err %v)", err)
+	}
+}
@@ -0,0 +1,250 @@
+package vulnscan
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Component types match the inventory the agent reports.
+const (
+	ComponentCore   = "core"
+	ComponentPlugin = "plugin"
+	ComponentTheme  = "theme"
+)
+
+// Component is one installed version to check.
+type Component struct {
+	Type    string
+	Name    string
+	Version string
+}
+
+// Finding is a vulnerability that applies to an installed component.
+type Finding struct {
+	ID               string
+	ComponentType    string
+	ComponentName    string
+	InstalledVersion string
+	Title            string
+	Description      string
+	Severity         string
+	Score            float64
+	// FixedIn is the first safe version when the feed names one.
+	FixedIn    string
+	References []string
+}
+
+// Match returns the findings for the given components, most severe first.
+// Components without a version are skipped because no range can be checked.
+func (f *Feed) Match(components []Component) []Finding {
+	if f == nil {
+		return nil
+	}
+	var out []Finding
+	for _, component := range components {
+		version := strings.TrimSpace(component.Version)
+		if version == "" {
+			continue
+		}
+		var candidates []Vulnerability
+		switch component.Type {
+		case ComponentCore:
+			candidates = f.core
+		case ComponentPlugin:
+			candidates = f.plugins[strings.ToLower(component.Name)]
+		case ComponentTheme:
+			candidates = f.themes[strings.ToLower(component.Name)]
+		}
+		for _, vulnerability := range candidates {
+			if !vulnerability.affects(version) {
+				continue
+			}
+			finding := Finding{
+				ID:               vulnerability.ID,
+				ComponentType:    component.Type,
+				ComponentName:    component.Name,
+				InstalledVersion: version,
+				Title:            vulnerability.Title,
+				Description:      vulnerability.Description,
+				Severity:         vulnerability.Severity,
+				Score:            vulnerability.Score,
+				References:       vulnerability.References,
+			}
+			if vulnerability.maxOperator == "lt" {
+				finding.FixedIn = vulnerability.maxVersion
+			}
+			out = append(out, finding)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if SeverityRank(out[i].Severity) != SeverityRank(out[j].Severity) {
+			return SeverityRank(out[i].Severity) > SeverityRank(out[j].Severity)
+		}
+		return out[i].Score > out[j].Score
+	})
+	return out
+}
+
+// SeverityRank orders severities from unknown (0) to critical (4).
+func SeverityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (v Vulnerability) affects(version string) bool {
+	if v.exactVersion != "" {
+		return CompareVersions(version, v.exactVersion) == 0
+	}
+	if v.minVersion != "" && !satisfies(version, defaultOperator(v.minOperator, "ge"), v.minVersion) {
+		return false
+	}
+	if v.maxVersion != "" && !satisfies(version, defaultOperator(v.maxOperator, "le"), v.maxVersion) {
+		return false
+	}
+	return true
+}
+
+func defaultOperator(operator, fallback string) string {
+	if operator == "" {
+		return fallback
+	}
+	return operator
+}
+
+func satisfies(version, operator, bound string) bool {
+	cmp := CompareVersions(version, bound)
+	switch operator {
+	case "lt", "<":
+		return cmp < 0
+	case "le", "<=":
+		return cmp <= 0
+	case "gt", ">":
+		return cmp > 0
+	case "ge", ">=":
+		return cmp >= 0
+	case "eq", "=", "==":
+		return cmp == 0
+	default:
+		return false
+	}
+}
+
+// CompareVersions compares WordPress-style versions the way PHP's
+// version_compare does for the cases plugins use: numeric segments compare
+// numerically, and pre-release tags sort before the release they precede
+// (6.5-beta1 < 6.5-RC1 < 6.5 < 6.5.1).
+func CompareVersions(a, b string) int {
+	left, right := versionTokens(a), versionTokens(b)
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		if cmp := compareToken(l, r); cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+func versionTokens(version string) []string {
+	var tokens []string
+	var current strings.Builder
+	lastDigit := false
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, strings.ToLower(current.String()))
+			current.Reset()
+		}
+	}
+	for _, r := range strings.TrimSpace(version) {
+		switch {
+		case unicode.IsDigit(r):
+			if !lastDigit {
+				flush()
+			}
+			lastDigit = true
+			current.WriteRune(r)
+		case unicode.IsLetter(r):
+			if lastDigit {
+				flush()
+			}
+			lastDigit = false
+			current.WriteRune(r)
+		default:
+			flush()
+			lastDigit = false
+		}
+	}
+	flush()
+	return tokens
+}
+
+// compareToken ranks a missing token between pre-release tags and numbers,
+// so 6.5 > 6.5-rc1 but 6.5 < 6.5.1.
+func compareToken(a, b string) int {
+	ra, rb := tokenRank(a), tokenRank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	if ra == rankNumber {
+		na, _ := strconv.Atoi(a)
+		nb, _ := strconv.Atoi(b)
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+	}
+	return 0
+}
+
+const (
+	rankDev = iota
+	rankAlpha
+	rankBeta
+	rankRC
+	rankMissing
+	rankPatch
+	rankNumber
+)
+
+func tokenRank(token string) int {
+	switch {
+	case token == "":
+		return rankMissing
+	case unicode.IsDigit(rune(token[0])):
+		return rankNumber
+	case token == "dev":
+		return rankDev
+	case token == "a" || token == "alpha":
+		return rankAlpha
+	case token == "b" || token == "beta":
+		return rankBeta
+	case token == "rc":
+		return rankRC
+	case token == "p" || token == "pl":
+		return rankPatch
+	default:
+		return rankDev
+	}
+}
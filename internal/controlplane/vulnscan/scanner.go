@@ -0,0 +1,202 @@
+package vulnscan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/controlplane/server/stores"
+)
+
+// Scanner matches stored site inventories against the feed and records the
+// results. Scans work from the stored inventory without asking any agent, so
+// they are cheap enough to run for every site whenever the feed changes.
+type Scanner struct {
+	source        Source
+	siteStore     *stores.SiteStore
+	components    *stores.ComponentStore
+	findings      *stores.VulnerabilityStore
+	activityStore *activity.Store
+	logger        *slog.Logger
+	interval      time.Duration
+}
+
+func NewScanner(source Source, siteStore *stores.SiteStore, components *stores.ComponentStore, findings *stores.VulnerabilityStore, activityStore *activity.Store, logger *slog.Logger) *Scanner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Scanner{
+		source:        source,
+		siteStore:     siteStore,
+		components:    components,
+		findings:      findings,
+		activityStore: activityStore,
+		logger:        logger,
+		interval:      1 * time.Hour,
+	}
+}
+
+// Start rescans every site on an interval so feed updates are picked up
+// without waiting for the next inventory refresh.
+func (s *Scanner) Start(ctx context.Context) {
+	if s == nil || s.siteStore == nil {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	s.ScanAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ScanAll(ctx)
+		}
+	}
+}
+
+// ScanAll rescans every site with a stored inventory.
+func (s *Scanner) ScanAll(ctx context.Context) {
+	if _, err := s.feed(); err != nil {
+		s.logger.Debug("vulnerability scan skipped", "error", err)
+		return
+	}
+	sites, err := s.siteStore.List(ctx)
+	if err != nil {
+		s.logger.Error("vulnerability scan failed to list sites", "error", err)
+		return
+	}
+	for _, site := range sites {
+		if _, err := s.ScanSite(ctx, site.ID); err != nil {
+			s.logger.Warn("vulnerability scan failed", "site_id", site.ID, "error", err)
+		}
+	}
+}
+
+// ReplaceForSite stores a fresh inventory and rescans the site. It matches
+// the component store's signature so inventory writers can use either.
+func (s *Scanner) ReplaceForSite(ctx context.Context, siteID string, components []stores.SiteComponentInput, checkedAt time.Time) error {
+	if err := s.components.ReplaceForSite(ctx, siteID, components, checkedAt); err != nil {
+		return err
+	}
+	if _, err := s.ScanSite(ctx, siteID); err != nil {
+		s.logger.Warn("vulnerability scan after inventory refresh failed", "site_id", siteID, "error", err)
+	}
+	return nil
+}
+
+// ScanSite matches one site's stored inventory and records the findings.
+func (s *Scanner) ScanSite(ctx context.Context, siteID string) (stores.VulnerabilityChanges, error) {
+	feed, err := s.feed()
+	if err != nil {
+		return stores.VulnerabilityChanges{}, err
+	}
+	site, err := s.siteStore.GetByID(ctx, siteID)
+	if err != nil {
+		return stores.VulnerabilityChanges{}, err
+	}
+	installed, err := s.components.ListBySite(ctx, site.ID)
+	if err != nil {
+		return stores.VulnerabilityChanges{}, err
+	}
+	components := make([]Component, 0, len(installed))
+	for _, component := range installed {
+		components = append(components, Component{Type: component.Type, Name: component.Name, Version: component.Version})
+	}
+	matched := feed.Match(components)
+	inputs := make([]stores.SiteVulnerabilityInput, 0, len(matched))
+	for _, finding := range matched {
+		inputs = append(inputs, stores.SiteVulnerabilityInput{
+			VulnerabilityID:  finding.ID,
+			ComponentType:    finding.ComponentType,
+			ComponentName:    finding.ComponentName,
+			InstalledVersion: finding.InstalledVersion,
+			Title:            finding.Title,
+			Description:      finding.Description,
+			Severity:         finding.Severity,
+			Score:            finding.Score,
+			FixedIn:          finding.FixedIn,
+			References:       finding.References,
+		})
+	}
+	changes, err := s.findings.ReplaceForSite(ctx, site.ID, inputs, time.Now())
+	if err != nil {
+		return stores.VulnerabilityChanges{}, err
+	}
+	s.emitChanges(ctx, site, changes)
+	return changes, nil
+}
+
+func (s *Scanner) feed() (*Feed, error) {
+	if s.source == nil {
+		return nil, fmt.Errorf("vulnerability feed not configured")
+	}
+	return s.source.Feed()
+}
+
+func (s *Scanner) emitChanges(ctx context.Context, site *stores.StoredSite, changes stores.VulnerabilityChanges) {
+	if s.activityStore == nil {
+		return
+	}
+	if len(changes.New) > 0 {
+		level := activity.LevelWarning
+		lines := make([]string, 0, len(changes.New))
+		ids := make([]string, 0, len(changes.New))
+		for _, finding := range changes.New {
+			ids = append(ids, finding.VulnerabilityID)
+			if SeverityRank(finding.Severity) >= SeverityRank(SeverityHigh) {
+				level = activity.LevelError
+			}
+			lines = append(lines, fmt.Sprintf("%s %s %s: %s (%s)", finding.ComponentType, finding.ComponentName, finding.InstalledVersion, finding.Title, finding.Severity))
+		}
+		payload, _ := json.Marshal(map[string]any{"vulnerability_ids": ids})
+		s.emit(ctx, activity.EmitInput{
+			EventType:          activity.EventSecurityVulnerabilityDetected,
+			Category:           activity.CategorySecurity,
+			Level:              level,
+			ResourceType:       activity.ResourceSite,
+			ResourceID:         site.ID,
+			ParentResourceType: activity.ResourceServer,
+			ParentResourceID:   site.ServerID,
+			ActorType:          activity.ActorSystem,
+			Title:              fmt.Sprintf("%s found on site '%s'", pluralVulnerabilities(len(changes.New), "new"), site.Name),
+			Message:            strings.Join(lines, "\n"),
+			Payload:            string(payload),
+			RequiresAttention:  true,
+		})
+	}
+	if changes.Resolved > 0 {
+		s.emit(ctx, activity.EmitInput{
+			EventType:          activity.EventSecurityVulnerabilityResolved,
+			Category:           activity.CategorySecurity,
+			Level:              activity.LevelSuccess,
+			ResourceType:       activity.ResourceSite,
+			ResourceID:         site.ID,
+			ParentResourceType: activity.ResourceServer,
+			ParentResourceID:   site.ServerID,
+			ActorType:          activity.ActorSystem,
+			Title:              fmt.Sprintf("%s resolved on site '%s'", pluralVulnerabilities(changes.Resolved, ""), site.Name),
+		})
+	}
+}
+
+func (s *Scanner) emit(ctx context.Context, input activity.EmitInput) {
+	if _, err := s.activityStore.Emit(ctx, input); err != nil {
+		s.logger.Warn("failed to emit vulnerability activity", "site_id", input.ResourceID, "error", err)
+	}
+}
+
+func pluralVulnerabilities(count int, adjective string) string {
+	noun := "vulnerabilities"
+	if count == 1 {
+		noun = "vulnerability"
+	}
+	if adjective == "" {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %s %s", count, adjective, noun)
+}
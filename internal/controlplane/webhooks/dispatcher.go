@@ -0,0 +1,269 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/shared/security"
+)
+
+const (
+	HeaderEvent     = "X-Pressluft-Event"
+	HeaderDelivery  = "X-Pressluft-Delivery"
+	HeaderTimestamp = "X-Pressluft-Timestamp"
+	HeaderSignature = "X-Pressluft-Signature"
+
+	// fanOutBatch caps how many activity entries one tick fans out.
+	fanOutBatch = 200
+	// sendBatch caps how many due deliveries one tick sends.
+	sendBatch = 50
+)
+
+// errForbiddenAddress is returned for deliveries to addresses inside the
+// control plane's own network.
+var errForbiddenAddress = errors.New("address is not allowed for webhook deliveries")
+
+// Sign returns the X-Pressluft-Signature value for a body sent at timestamp:
+// the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription
+// secret. Receivers recompute it and should reject stale timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Config holds dispatcher configuration.
+type Config struct {
+	// PollInterval is how often new activity is fanned out and due
+	// deliveries are sent.
+	PollInterval time.Duration
+	// MaxAttempts is how often a delivery is tried before it is marked
+	// failed.
+	MaxAttempts int
+	// BaseBackoff is the wait after the first failed attempt; it doubles
+	// with every further failure up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout bounds a single HTTP attempt.
+	Timeout time.Duration
+}
+
+// DefaultConfig returns sensible defaults. Eight attempts spread a delivery
+// over about an hour before it is given up.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 5 * time.Second,
+		MaxAttempts:  8,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   6 * time.Hour,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// Dispatcher queues deliveries for new activity and sends them.
+type Dispatcher struct {
+	store         *Store
+	activityStore *activity.Store
+	client        *http.Client
+	config        Config
+	logger        *slog.Logger
+}
+
+// NewDispatcher creates a dispatcher with the given dependencies. A nil
+// client uses one with the configured timeout that only reaches public
+// addresses; see deliveryClient.
+func NewDispatcher(store *Store, activityStore *activity.Store, client *http.Client, logger *slog.Logger, config Config) *Dispatcher {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if client == nil {
+		client = deliveryClient(config.Timeout, checkDeliveryAddress)
+	}
+	return &Dispatcher{
+		store:         store,
+		activityStore: activityStore,
+		client:        client,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// deliveryClient returns the client deliveries go out through. Any workspace
+// admin can choose the URL, so control vets every address the URL resolves
+// to right before connecting, and redirects are not followed: a 3xx answer is
+// a failed attempt. Proxies from the environment are ignored, as they would
+// connect on the dispatcher's behalf.
+func deliveryClient(timeout time.Duration, control func(network, address string, conn syscall.RawConn) error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: control}).DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkDeliveryAddress is the dialer control of deliveryClient. It runs after
+// DNS resolution and refuses loopback, private, link-local and unspecified
+// addresses, so receivers cannot point deliveries at the control plane's
+// own network or a cloud metadata service.
+func checkDeliveryAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// Run starts the delivery loop. It blocks until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.logger.Info("webhook dispatcher started", "poll_interval", d.config.PollInterval, "max_attempts", d.config.MaxAttempts)
+
+	d.Tick(ctx, time.Now().UTC())
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("webhook dispatcher shutting down")
+			return
+		case <-ticker.C:
+			d.Tick(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// Tick fans out new activity and sends every delivery due at now.
+func (d *Dispatcher) Tick(ctx context.Context, now time.Time) {
+	for {
+		n, err := d.store.QueueNewActivity(ctx, d.activityStore, fanOutBatch)
+		if err != nil {
+			d.logger.Error("queue webhook deliveries failed", "error", err)
+			break
+		}
+		if n < fanOutBatch {
+			break
+		}
+	}
+
+	due, err := d.store.listDue(ctx, now, sendBatch)
+	if err != nil {
+		d.logger.Error("list due webhook deliveries failed", "error", err)
+		return
+	}
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		result := d.send(ctx, delivery, now)
+		if err := d.store.recordAttempt(ctx, delivery.ID, result); err != nil {
+			d.logger.Error("record webhook delivery failed", "delivery_id", delivery.ID, "error", err)
+			continue
+		}
+		if result.Status == DeliveryStatusFailed {
+			d.logger.Warn("webhook delivery gave up", "delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID, "attempts", delivery.Attempts+1, "error", result.Err)
+		}
+	}
+}
+
+// send makes one attempt. Its outcome is recorded as of now, the tick time,
+// so retries are scheduled relative to the tick that tried them.
+func (d *Dispatcher) send(ctx context.Context, delivery pendingDelivery, now time.Time) deliveryResult {
+	secret, err := security.DecryptWebhookSecret(delivery.SecretEncrypted)
+	if err != nil {
+		return d.failure(delivery, now, 0, fmt.Sprintf("decrypt signing secret: %v", err))
+	}
+
+	sentAt := time.Now().UTC()
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return d.failure(delivery, now, 0, fmt.Sprintf("build request: %v", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Pressluft-Webhooks/1")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(sentAt.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(secret, sentAt.Unix(), body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return d.failure(delivery, now, 0, err.Error())
+	}
+	// The response body is never read: it is the receiver's, and storing it
+	// would hand whatever answered at the URL to anyone who can list
+	// deliveries.
+	_ = res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return deliveryResult{At: now, ResponseStatus: res.StatusCode, Status: DeliveryStatusDelivered}
+	}
+	return d.failure(delivery, now, res.StatusCode, fmt.Sprintf("unexpected status %d", res.StatusCode))
+}
+
+// failure schedules the next attempt with exponential backoff, or gives the
+// delivery up once it has used all its attempts.
+func (d *Dispatcher) failure(delivery pendingDelivery, now time.Time, status int, message string) deliveryResult {
+	result := deliveryResult{At: now, ResponseStatus: status, Err: message, Status: DeliveryStatusPending}
+	attempts := delivery.Attempts + 1
+	if attempts >= d.config.MaxAttempts {
+		result.Status = DeliveryStatusFailed
+		return result
+	}
+	result.NextAttemptAt = now.Add(d.backoff(attempts))
+	return result
+}
+
+// backoff is the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.config.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+	return wait
+}
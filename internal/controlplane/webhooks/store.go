@@ -0,0 +1,582 @@
+// Package webhooks sends activity events to external HTTP endpoints. Each
+// activity entry is matched against the subscriptions of its workspace and
+// queued as a delivery; the Dispatcher signs and sends queued deliveries and
+// retries failures with exponential backoff.
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/workspace"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+
+	// SecretPrefix marks webhook signing secrets.
+	SecretPrefix = "whsec_"
+
+	// TestEventType is the event type of deliveries queued by SendTest. It
+	// is not an activity event type.
+	TestEventType = "webhook.test"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+)
+
+// Subscription sends the activity events matching its filters to URL. Empty
+// filters match everything.
+type Subscription struct {
+	ID           string                `json:"id"`
+	Name         string                `json:"name"`
+	URL          string                `json:"url"`
+	EventTypes   []activity.EventType  `json:"event_types"`
+	Categories   []activity.Category   `json:"categories"`
+	ResourceType activity.ResourceType `json:"resource_type,omitempty"`
+	ResourceID   string                `json:"resource_id,omitempty"`
+	Enabled      bool                  `json:"enabled"`
+	CreatedAt    string                `json:"created_at"`
+	UpdatedAt    string                `json:"updated_at"`
+	WorkspaceID  string                `json:"-"`
+	// Secret is only populated on subscriptions loaded for delivery.
+	Secret string `json:"-"`
+}
+
+// Matches reports whether an activity entry passes the subscription's
+// filters. The workspace is matched by the store, not here.
+func (s Subscription) Matches(a activity.Activity) bool {
+	if len(s.EventTypes) > 0 && !containsValue(s.EventTypes, a.EventType) {
+		return false
+	}
+	if len(s.Categories) > 0 && !containsValue(s.Categories, a.Category) {
+		return false
+	}
+	if s.ResourceType != "" {
+		matchesResource := a.ResourceType == s.ResourceType && (s.ResourceID == "" || a.ResourceID == s.ResourceID)
+		matchesParent := a.ParentResourceType == s.ResourceType && (s.ResourceID == "" || a.ParentResourceID == s.ResourceID)
+		if !matchesResource && !matchesParent {
+			return false
+		}
+	}
+	return true
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type SubscriptionInput struct {
+	Name         string
+	URL          string
+	EventTypes   []activity.EventType
+	Categories   []activity.Category
+	ResourceType activity.ResourceType
+	ResourceID   string
+	Enabled      bool
+}
+
+// Delivery is one attempt sequence of sending an event to a subscription.
+type Delivery struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	ActivityID     string `json:"activity_id,omitempty"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string `json:"last_attempt_at,omitempty"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	Payload        string `json:"-"`
+}
+
+// Event is the JSON body of every delivery.
+type Event struct {
+	ID        string             `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt string             `json:"created_at"`
+	Activity  *activity.Activity `json:"activity,omitempty"`
+}
+
+// Store persists webhook subscriptions and their delivery queue.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const subscriptionColumns = `id, workspace_id, name, url, event_types, categories,
+		COALESCE(resource_type, ''), COALESCE(resource_id, ''), enabled, created_at, updated_at`
+
+func scanSubscription(scanner interface{ Scan(dest ...any) error }) (Subscription, error) {
+	var (
+		sub          Subscription
+		eventTypes   string
+		categories   string
+		resourceType string
+		enabled      int
+	)
+	if err := scanner.Scan(&sub.ID, &sub.WorkspaceID, &sub.Name, &sub.URL, &eventTypes, &categories, &resourceType, &sub.ResourceID, &enabled, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		return Subscription{}, err
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &sub.EventTypes); err != nil {
+		return Subscription{}, fmt.Errorf("decode event types: %w", err)
+	}
+	if err := json.Unmarshal([]byte(categories), &sub.Categories); err != nil {
+		return Subscription{}, fmt.Errorf("decode categories: %w", err)
+	}
+	sub.ResourceType = activity.ResourceType(resourceType)
+	sub.Enabled = enabled == 1
+	return sub, nil
+}
+
+// validateInput normalizes a subscription and rejects filters that could
+// never match.
+func validateInput(in SubscriptionInput) (SubscriptionInput, error) {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" || len(in.Name) > 100 {
+		return in, fmt.Errorf("%w: name must be between 1 and 100 characters", ErrInvalidSubscription)
+	}
+	in.URL = strings.TrimSpace(in.URL)
+	parsed, err := url.Parse(in.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return in, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+	if parsed.User != nil {
+		return in, fmt.Errorf("%w: url must not contain credentials", ErrInvalidSubscription)
+	}
+	for _, eventType := range in.EventTypes {
+		if err := activity.ValidateEventType(eventType); err != nil {
+			return in, fmt.Errorf("%w: %v", ErrInvalidSubscription, err)
+		}
+	}
+	for _, category := range in.Categories {
+		if strings.TrimSpace(string(category)) == "" {
+			return in, fmt.Errorf("%w: empty category", ErrInvalidSubscription)
+		}
+	}
+	in.ResourceID = strings.TrimSpace(in.ResourceID)
+	if in.ResourceID != "" {
+		if in.ResourceType == "" {
+			return in, fmt.Errorf("%w: resource_id requires resource_type", ErrInvalidSubscription)
+		}
+		normalized, err := idutil.Normalize(in.ResourceID)
+		if err != nil {
+			return in, fmt.Errorf("%w: invalid resource_id", ErrInvalidSubscription)
+		}
+		in.ResourceID = normalized
+	}
+	if in.EventTypes == nil {
+		in.EventTypes = []activity.EventType{}
+	}
+	if in.Categories == nil {
+		in.Categories = []activity.Category{}
+	}
+	return in, nil
+}
+
+// Create stores a subscription in the workspace of ctx and returns it with
+// its signing secret. The secret is not shown again.
+func (s *Store) Create(ctx context.Context, in SubscriptionInput) (Subscription, string, error) {
+	in, err := validateInput(in)
+	if err != nil {
+		return Subscription{}, "", err
+	}
+	id, err := idutil.New()
+	if err != nil {
+		return Subscription{}, "", err
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return Subscription{}, "", err
+	}
+	encrypted, err := security.EncryptWebhookSecret(secret)
+	if err != nil {
+		return Subscription{}, "", fmt.Errorf("encrypt webhook secret: %w", err)
+	}
+	eventTypes, _ := json.Marshal(in.EventTypes)
+	categories, _ := json.Marshal(in.Categories)
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (id, workspace_id, name, url, secret_encrypted, event_types, categories, resource_type, resource_id, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, workspace.ForWrite(ctx), in.Name, in.URL, encrypted, string(eventTypes), string(categories),
+		nullableString(string(in.ResourceType)), nullableString(in.ResourceID), boolInt(in.Enabled), now, now); err != nil {
+		return Subscription{}, "", fmt.Errorf("insert webhook subscription: %w", err)
+	}
+	sub, err := s.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, "", err
+	}
+	return sub, secret, nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return SecretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *Store) List(ctx context.Context) ([]Subscription, error) {
+	scope := workspace.Filter(ctx)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE (? = '' OR workspace_id = ?)
+		ORDER BY created_at ASC, id ASC
+	`, scope, scope)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := make([]Subscription, 0)
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+func (s *Store) Get(ctx context.Context, id string) (Subscription, error) {
+	normalized, err := idutil.Normalize(id)
+	if err != nil {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	scope := workspace.Filter(ctx)
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE id = ? AND (? = '' OR workspace_id = ?)
+	`, normalized, scope, scope))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return Subscription{}, fmt.Errorf("get webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// Update replaces a subscription's name, URL, filters and enabled state. The
+// signing secret stays the same.
+func (s *Store) Update(ctx context.Context, id string, in SubscriptionInput) (Subscription, error) {
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, err
+	}
+	in, err = validateInput(in)
+	if err != nil {
+		return Subscription{}, err
+	}
+	eventTypes, _ := json.Marshal(in.EventTypes)
+	categories, _ := json.Marshal(in.Categories)
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_subscriptions
+		SET name = ?, url = ?, event_types = ?, categories = ?, resource_type = ?, resource_id = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, in.Name, in.URL, string(eventTypes), string(categories), nullableString(string(in.ResourceType)), nullableString(in.ResourceID),
+		boolInt(in.Enabled), time.Now().UTC().Format(time.RFC3339), existing.ID); err != nil {
+		return Subscription{}, fmt.Errorf("update webhook subscription: %w", err)
+	}
+	return s.Get(ctx, existing.ID)
+}
+
+// Delete removes a subscription together with its delivery log.
+func (s *Store) Delete(ctx context.Context, id string) (Subscription, error) {
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE subscription_id = ?`, existing.ID); err != nil {
+		return Subscription{}, fmt.Errorf("delete webhook deliveries: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = ?`, existing.ID); err != nil {
+		return Subscription{}, fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	return existing, nil
+}
+
+const deliveryColumns = `id, subscription_id, COALESCE(activity_id, ''), event_type, status, attempts,
+		COALESCE(next_attempt_at, ''), COALESCE(last_attempt_at, ''), COALESCE(response_status, 0),
+		COALESCE(last_error, ''), created_at, COALESCE(delivered_at, ''), payload`
+
+func scanDelivery(scanner interface{ Scan(dest ...any) error }) (Delivery, error) {
+	var d Delivery
+	err := scanner.Scan(&d.ID, &d.SubscriptionID, &d.ActivityID, &d.EventType, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt, &d.Payload)
+	return d, err
+}
+
+// ListDeliveries returns the newest deliveries of a subscription first.
+func (s *Store) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	sub, err := s.Get(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, sub.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows *sql.Rows) ([]Delivery, error) {
+	deliveries := make([]Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// SendTest queues a test event for a subscription, whether or not it is
+// enabled. The dispatcher sends it on its next tick.
+func (s *Store) SendTest(ctx context.Context, subscriptionID string) (Delivery, error) {
+	sub, err := s.Get(ctx, subscriptionID)
+	if err != nil {
+		return Delivery{}, err
+	}
+	id, err := idutil.New()
+	if err != nil {
+		return Delivery{}, err
+	}
+	now := time.Now().UTC()
+	payload, err := json.Marshal(Event{ID: id, Type: TestEventType, CreatedAt: now.Format(time.RFC3339)})
+	if err != nil {
+		return Delivery{}, fmt.Errorf("encode test event: %w", err)
+	}
+	return s.enqueue(ctx, id, sub.ID, "", TestEventType, string(payload), now)
+}
+
+func (s *Store) enqueue(ctx context.Context, id, subscriptionID, activityID, eventType, payload string, now time.Time) (Delivery, error) {
+	stamp := now.Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, subscription_id, activity_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, ?, ?)
+	`, id, subscriptionID, nullableString(activityID), eventType, payload, DeliveryStatusPending, stamp, stamp); err != nil {
+		return Delivery{}, fmt.Errorf("insert webhook delivery: %w", err)
+	}
+	return scanDelivery(s.db.QueryRowContext(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id))
+}
+
+// QueueNewActivity fans activity recorded since the last call out to the
+// matching enabled subscriptions of each entry's workspace and returns how
+// many entries it looked at.
+func (s *Store) QueueNewActivity(ctx context.Context, activityStore *activity.Store, limit int) (int, error) {
+	var cursor string
+	if err := s.db.QueryRowContext(ctx, `SELECT last_activity_id FROM webhook_cursor WHERE id = 1`).Scan(&cursor); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("read webhook cursor: %w", err)
+		}
+		// Without a cursor nothing has been fanned out yet; start from now.
+		latest, err := activityStore.GetLatestID(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return 0, s.saveCursor(ctx, latest)
+	}
+
+	entries, err := activityStore.ListSince(ctx, cursor, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	subscriptions, err := s.listEnabled(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		for _, sub := range subscriptions {
+			if sub.WorkspaceID != entry.WorkspaceID || !sub.Matches(entry) {
+				continue
+			}
+			id, err := idutil.New()
+			if err != nil {
+				return 0, err
+			}
+			event := entry
+			payload, err := json.Marshal(Event{ID: id, Type: string(entry.EventType), CreatedAt: entry.CreatedAt, Activity: &event})
+			if err != nil {
+				return 0, fmt.Errorf("encode webhook event: %w", err)
+			}
+			if _, err := s.enqueue(ctx, id, sub.ID, entry.ID, string(entry.EventType), string(payload), now); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(entries), s.saveCursor(ctx, entries[len(entries)-1].ID)
+}
+
+func (s *Store) saveCursor(ctx context.Context, activityID string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_cursor (id, last_activity_id) VALUES (1, ?)
+		ON CONFLICT(id) DO UPDATE SET last_activity_id = excluded.last_activity_id
+	`, activityID); err != nil {
+		return fmt.Errorf("save webhook cursor: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) listEnabled(ctx context.Context) ([]Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE enabled = 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list enabled webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := make([]Subscription, 0)
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// pendingDelivery is a due delivery with what the dispatcher needs to send
+// it.
+type pendingDelivery struct {
+	Delivery
+	URL             string
+	SecretEncrypted string
+}
+
+// listDue returns pending deliveries whose next attempt is due, oldest
+// first.
+func (s *Store) listDue(ctx context.Context, now time.Time, limit int) ([]pendingDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.id, d.subscription_id, COALESCE(d.activity_id, ''), d.event_type, d.status, d.attempts,
+			COALESCE(d.next_attempt_at, ''), COALESCE(d.last_attempt_at, ''), COALESCE(d.response_status, 0),
+			COALESCE(d.last_error, ''), d.created_at, COALESCE(d.delivered_at, ''), d.payload,
+			s.url, s.secret_encrypted
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at ASC, d.id ASC
+		LIMIT ?
+	`, DeliveryStatusPending, now.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list due webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	due := make([]pendingDelivery, 0)
+	for rows.Next() {
+		var p pendingDelivery
+		if err := rows.Scan(&p.ID, &p.SubscriptionID, &p.ActivityID, &p.EventType, &p.Status, &p.Attempts,
+			&p.NextAttemptAt, &p.LastAttemptAt, &p.ResponseStatus, &p.LastError, &p.CreatedAt, &p.DeliveredAt, &p.Payload,
+			&p.URL, &p.SecretEncrypted); err != nil {
+			return nil, fmt.Errorf("scan due webhook delivery: %w", err)
+		}
+		due = append(due, p)
+	}
+	return due, rows.Err()
+}
+
+// deliveryResult is the outcome of one attempt.
+type deliveryResult struct {
+	At             time.Time
+	ResponseStatus int
+	Err            string
+	// NextAttemptAt is zero when the delivery succeeded or gave up.
+	NextAttemptAt time.Time
+	Status        string
+}
+
+func (s *Store) recordAttempt(ctx context.Context, id string, result deliveryResult) error {
+	at := result.At.UTC().Format(time.RFC3339)
+	var nextAttemptAt, deliveredAt any
+	if !result.NextAttemptAt.IsZero() {
+		nextAttemptAt = result.NextAttemptAt.UTC().Format(time.RFC3339)
+	}
+	if result.Status == DeliveryStatusDelivered {
+		deliveredAt = at
+	}
+	var responseStatus any
+	if result.ResponseStatus != 0 {
+		responseStatus = result.ResponseStatus
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, last_attempt_at = ?, next_attempt_at = ?,
+			response_status = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`, result.Status, at, nextAttemptAt, responseStatus, nullableString(result.Err), deliveredAt, id); err != nil {
+		return fmt.Errorf("record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+func nullableString(v string) any {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+func boolInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
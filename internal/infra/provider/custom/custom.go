@@ -0,0 +1,50 @@
+// Package custom implements the provider.Provider interface for servers the
+// operator already runs elsewhere, such as dedicated machines or other
+// hosting. There is no cloud API: Pressluft reaches them over SSH.
+package custom
+
+import (
+	"context"
+
+	"pressluft/internal/infra/provider"
+)
+
+const (
+	providerType = "custom"
+	displayName  = "Your own server"
+	abbreviation = "SSH"
+	description  = "Any Ubuntu machine reachable over SSH"
+	docsURL      = "https://documentation.ubuntu.com/server/how-to/security/openssh-server/"
+)
+
+// Custom implements provider.Provider and provider.ExistingServerProvider.
+type Custom struct{}
+
+func init() {
+	provider.Register(&Custom{})
+}
+
+// Info returns metadata about this provider.
+func (c *Custom) Info() provider.Info {
+	return provider.Info{
+		Type:         providerType,
+		Name:         displayName,
+		DocsURL:      docsURL,
+		Abbreviation: abbreviation,
+		Description:  description,
+	}
+}
+
+func (c *Custom) AdoptsExistingServers() bool {
+	return true
+}
+
+// Validate always succeeds: there is no token to check. Whether a server is
+// reachable is verified per server when it is connected.
+func (c *Custom) Validate(ctx context.Context, token string) (*provider.ValidationResult, error) {
+	return &provider.ValidationResult{
+		Valid:     true,
+		ReadWrite: true,
+		Message:   "No API token needed; servers are connected over SSH",
+	}, nil
+}
@@ -0,0 +1,66 @@
+package custom
+
+import (
+	"context"
+	"testing"
+
+	"pressluft/internal/infra/provider"
+)
+
+func TestRegistersAsCustom(t *testing.T) {
+	registered, ok := provider.Get(providerType).(*Custom)
+	if !ok {
+		t.Fatalf("provider.Get(%q) = %T, want *Custom", providerType, provider.Get(providerType))
+	}
+	info := registered.Info()
+	if info.Type != "custom" || info.Name == "" || info.Abbreviation != "SSH" || info.DocsURL == "" {
+		t.Fatalf("Info() = %+v", info)
+	}
+}
+
+func TestValidateNeedsNoToken(t *testing.T) {
+	result, err := (&Custom{}).Validate(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Valid || !result.ReadWrite || result.Message == "" {
+		t.Fatalf("Validate() = %+v, want a valid read-write result", result)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	if !provider.AdoptsExistingServers(providerType) || provider.RequiresAPIToken(providerType) {
+		t.Fatal("expected custom servers to be adopted without an API token")
+	}
+	if provider.SimulatesServers(providerType) {
+		t.Fatal("expected custom servers to be real machines")
+	}
+	if _, ok := provider.GetServerProvider(providerType); ok {
+		t.Fatal("expected no server catalog: adopted servers keep their own size and location")
+	}
+	if _, ok := provider.GetServerImageProvider(providerType); ok {
+		t.Fatal("expected no image options")
+	}
+	if _, ok := provider.GetFirewallProvider(providerType); ok {
+		t.Fatal("expected no cloud firewalls")
+	}
+	if _, ok := provider.GetVolumeProvider(providerType); ok {
+		t.Fatal("expected no cloud volumes")
+	}
+}
+
+// Creating a custom server adopts it over SSH and deleting one only stops
+// managing it, so the provider must never offer a lifecycle that could
+// provision, rebuild or destroy the machine.
+func TestCreateAndDeleteHaveNoLifecycle(t *testing.T) {
+	if _, ok := provider.GetServerLifecycle(providerType); ok {
+		t.Fatal("expected custom provider to have no server lifecycle")
+	}
+	var p provider.Provider = &Custom{}
+	if _, ok := p.(provider.ServerLifecycle); ok {
+		t.Fatal("expected *Custom not to implement provider.ServerLifecycle")
+	}
+	if _, ok := p.(provider.ExistingServerProvider); !ok {
+		t.Fatal("expected *Custom to implement provider.ExistingServerProvider")
+	}
+}
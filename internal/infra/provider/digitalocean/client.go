@@ -0,0 +1,144 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBaseURL = "https://api.digitalocean.com/v2"
+	userAgent      = "pressluft/1.0.0"
+	// perPage is the largest page the DigitalOcean API hands out.
+	perPage = 200
+	// maxPages stops pagination from running away on a misbehaving API.
+	maxPages = 50
+)
+
+// apiError is an error response from the DigitalOcean API.
+type apiError struct {
+	StatusCode int
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, e.ID, e.Message)
+	}
+	return fmt.Sprintf("status %d", e.StatusCode)
+}
+
+// client is a minimal DigitalOcean API v2 client covering the calls the
+// adapter makes.
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func (d *DigitalOcean) newClient(token string) *client {
+	baseURL := strings.TrimRight(strings.TrimSpace(d.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	httpClient := d.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &client{baseURL: baseURL, token: token, http: httpClient}
+}
+
+// do sends one request and decodes a JSON response into out, which may be
+// nil. Paths are relative to the API root.
+func (c *client) do(ctx context.Context, method, path string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apiError{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// pageLinks is the pagination envelope of list responses.
+type pageLinks struct {
+	Links struct {
+		Pages struct {
+			Next string `json:"next"`
+		} `json:"pages"`
+	} `json:"links"`
+}
+
+// listAll walks every page of a list endpoint. decode receives each raw page
+// and appends its items; path may carry its own query parameters.
+func (c *client) listAll(ctx context.Context, path string, decode func(page json.RawMessage) error) error {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	for page := 1; page <= maxPages; page++ {
+		var raw json.RawMessage
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s%spage=%d&per_page=%d", path, separator, page, perPage), nil, &raw); err != nil {
+			return err
+		}
+		if err := decode(raw); err != nil {
+			return err
+		}
+		var links pageLinks
+		if err := json.Unmarshal(raw, &links); err != nil {
+			return err
+		}
+		if links.Links.Pages.Next == "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s has more than %d pages", path, maxPages)
+}
+
+func isStatus(err error, status int) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+func mapDigitalOceanAPIError(err error) error {
+	switch {
+	case isStatus(err, http.StatusUnauthorized):
+		return fmt.Errorf("invalid DigitalOcean API token")
+	case isStatus(err, http.StatusForbidden):
+		return fmt.Errorf("insufficient DigitalOcean permissions")
+	case isStatus(err, http.StatusTooManyRequests):
+		return fmt.Errorf("DigitalOcean API rate limit exceeded")
+	case isStatus(err, http.StatusUnprocessableEntity):
+		return fmt.Errorf("invalid DigitalOcean server configuration")
+	default:
+		return fmt.Errorf("digitalocean api error: %w", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package digitalocean implements the provider.Provider interface for
+// DigitalOcean.
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"pressluft/internal/infra/provider"
+)
+
+const (
+	providerType = "digitalocean"
+	displayName  = "DigitalOcean"
+	abbreviation = "DO"
+	description  = "Developer cloud with global regions"
+	docsURL      = "https://docs.digitalocean.com/reference/api/create-personal-access-token/"
+)
+
+// tagName labels everything Pressluft creates in a DigitalOcean account.
+const tagName = "pressluft"
+
+// DigitalOcean implements provider.Provider. The zero value talks to the
+// public API; tests point BaseURL at a fake.
+type DigitalOcean struct {
+	// BaseURL overrides the API root, e.g. "https://api.digitalocean.com/v2".
+	BaseURL string
+	// HTTPClient overrides the client used for API calls.
+	HTTPClient *http.Client
+	// PollInterval overrides how often running actions are polled.
+	PollInterval time.Duration
+}
+
+func init() {
+	provider.Register(&DigitalOcean{})
+}
+
+// Info returns metadata about this provider.
+func (d *DigitalOcean) Info() provider.Info {
+	return provider.Info{
+		Type:         providerType,
+		Name:         displayName,
+		DocsURL:      docsURL,
+		Abbreviation: abbreviation,
+		Description:  description,
+	}
+}
+
+type accountResponse struct {
+	Account struct {
+		Email  string `json:"email"`
+		Status string `json:"status"`
+		Team   *struct {
+			Name string `json:"name"`
+		} `json:"team"`
+	} `json:"account"`
+}
+
+// Validate checks whether the given API token is valid and has write
+// access. DigitalOcean cannot report a token's scopes, so write access is
+// probed by creating the pressluft tag, which is idempotent and harmless.
+func (d *DigitalOcean) Validate(ctx context.Context, token string) (*provider.ValidationResult, error) {
+	if token == "" {
+		return &provider.ValidationResult{
+			Valid:   false,
+			Message: "API token must not be empty",
+		}, nil
+	}
+
+	client := d.newClient(token)
+
+	var account accountResponse
+	if err := client.do(ctx, http.MethodGet, "/account", nil, &account); err != nil {
+		if isStatus(err, http.StatusUnauthorized) {
+			return &provider.ValidationResult{
+				Valid:   false,
+				Message: "Invalid API token. Please check the token and try again.",
+			}, nil
+		}
+		if isStatus(err, http.StatusForbidden) {
+			return &provider.ValidationResult{
+				Valid:   false,
+				Message: "Token does not have sufficient permissions.",
+			}, nil
+		}
+		return nil, fmt.Errorf("digitalocean api error: %w", err)
+	}
+	projectName := ""
+	if account.Account.Team != nil {
+		projectName = account.Account.Team.Name
+	}
+	if account.Account.Status != "" && account.Account.Status != "active" {
+		return &provider.ValidationResult{
+			Valid:       false,
+			Message:     fmt.Sprintf("DigitalOcean account is %s and cannot create droplets.", account.Account.Status),
+			ProjectName: projectName,
+		}, nil
+	}
+
+	if err := client.do(ctx, http.MethodPost, "/tags", strings.NewReader(`{"name":"`+tagName+`"}`), nil); err != nil {
+		if isStatus(err, http.StatusForbidden) || isStatus(err, http.StatusUnauthorized) {
+			return &provider.ValidationResult{
+				Valid:       true,
+				ReadWrite:   false,
+				Message:     "Token is valid but appears to be read-only. A token with write scope is required for server management.",
+				ProjectName: projectName,
+			}, nil
+		}
+		return nil, fmt.Errorf("digitalocean api error: %w", err)
+	}
+
+	return &provider.ValidationResult{
+		Valid:       true,
+		ReadWrite:   true,
+		Message:     "Token is valid with read and write access.",
+		ProjectName: projectName,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package digitalocean
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeAPI serves canned DigitalOcean API responses keyed by method and path.
+// Unknown routes answer 404 like the real API.
+type fakeAPI struct {
+	t        *testing.T
+	token    string
+	routes   map[string]func(w http.ResponseWriter, r *http.Request)
+	requests []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *DigitalOcean) {
+	t.Helper()
+	api := &fakeAPI{t: t, token: "do-token", routes: map[string]func(http.ResponseWriter, *http.Request){}}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return api, &DigitalOcean{BaseURL: server.URL + "/v2", HTTPClient: server.Client()}
+}
+
+func (f *fakeAPI) handle(method, path string, fn func(w http.ResponseWriter, r *http.Request)) {
+	f.routes[method+" "+path] = fn
+}
+
+func (f *fakeAPI) json(method, path string, status int, body string) {
+	f.handle(method, path, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI())
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"id":"unauthorized","message":"Unable to authenticate you."}`))
+		return
+	}
+	fn, ok := f.routes[r.Method+" "+strings.TrimPrefix(r.URL.Path, "/v2")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"id":"not_found","message":"The resource you were accessing could not be found."}`))
+		return
+	}
+	fn(w, r)
+}
+
+func TestValidate(t *testing.T) {
+	api, do := newFakeAPI(t)
+	api.json(http.MethodGet, "/account", http.StatusOK, `{"account":{"email":"ops@example.com","status":"active","team":{"name":"Agency"}}}`)
+	api.json(http.MethodPost, "/tags", http.StatusCreated, `{"tag":{"name":"pressluft"}}`)
+
+	result, err := do.Validate(context.Background(), "do-token")
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !result.Valid || !result.ReadWrite || result.ProjectName != "Agency" {
+		t.Fatalf("Validate() = %+v, want valid read-write token of team Agency", result)
+	}
+
+	result, err = do.Validate(context.Background(), "wrong-token")
+	if err != nil {
+		t.Fatalf("Validate(wrong) error = %v", err)
+	}
+	if result.Valid {
+		t.Fatalf("Validate(wrong) = %+v, want invalid", result)
+	}
+
+	api.json(http.MethodPost, "/tags", http.StatusForbidden, `{"id":"forbidden","message":"You do not have access for the attempted action."}`)
+	result, err = do.Validate(context.Background(), "do-token")
+	if err != nil {
+		t.Fatalf("Validate(read-only) error = %v", err)
+	}
+	if !result.Valid || result.ReadWrite {
+		t.Fatalf("Validate(read-only) = %+v, want valid read-only token", result)
+	}
+}
+
+func TestListServerCatalog(t *testing.T) {
+	api, do := newFakeAPI(t)
+	api.handle(http.MethodGet, "/regions", func(w http.ResponseWriter, r *http.Request) {
+		// Two pages, to exercise pagination.
+		if r.URL.Query().Get("page") == "1" {
+			_, _ = w.Write([]byte(`{"regions":[{"slug":"fra1","name":"Frankfurt 1","available":true,"sizes":["s-1vcpu-1gb","s-2vcpu-4gb"]}],"links":{"pages":{"next":"https://api.digitalocean.com/v2/regions?page=2"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"regions":[{"slug":"nyc3","name":"New York 3","available":true,"sizes":["s-1vcpu-1gb"]},{"slug":"sfo1","name":"San Francisco 1","available":false,"sizes":["s-1vcpu-1gb"]}],"links":{}}`))
+	})
+	api.json(http.MethodGet, "/sizes", http.StatusOK, `{"sizes":[
+		{"slug":"s-2vcpu-4gb","description":"Basic","memory":4096,"vcpus":2,"disk":80,"price_monthly":24,"price_hourly":0.03571,"regions":["fra1"],"available":true},
+		{"slug":"s-1vcpu-1gb","description":"Basic","memory":1024,"vcpus":1,"disk":25,"price_monthly":6,"price_hourly":0.00893,"regions":["fra1","nyc3","sfo1"],"available":true},
+		{"slug":"s-8vcpu-16gb","description":"Basic","memory":16384,"vcpus":8,"disk":320,"price_monthly":96,"price_hourly":0.14286,"regions":[],"available":false}
+	],"links":{}}`)
+
+	catalog, err := do.ListServerCatalog(context.Background(), "do-token")
+	if err != nil {
+		t.Fatalf("ListServerCatalog() error = %v", err)
+	}
+
+	if len(catalog.Locations) != 2 || catalog.Locations[0].Name != "fra1" || catalog.Locations[1].Name != "nyc3" {
+		t.Fatalf("locations = %+v, want available fra1 and nyc3", catalog.Locations)
+	}
+	if got := catalog.Locations[0]; got.City != "Frankfurt" || got.Country != "DE" {
+		t.Fatalf("fra1 place = %s, %s; want Frankfurt, DE", got.City, got.Country)
+	}
+	if len(catalog.ServerTypes) != 2 {
+		t.Fatalf("server types = %+v, want the two available sizes", catalog.ServerTypes)
+	}
+	small := catalog.ServerTypes[0]
+	if small.Name != "s-1vcpu-1gb" || small.Cores != 1 || small.MemoryGB != 1 || small.DiskGB != 25 || small.Architecture != "x86" {
+		t.Fatalf("small size = %+v", small)
+	}
+	if strings.Join(small.AvailableAt, ",") != "fra1,nyc3" {
+		t.Fatalf("small size available at %v, want fra1,nyc3", small.AvailableAt)
+	}
+	if len(small.Prices) != 2 || small.Prices[0].MonthlyGross != "6.00" || small.Prices[0].HourlyGross != "0.00893" || small.Prices[0].Currency != "USD" {
+		t.Fatalf("small size prices = %+v", small.Prices)
+	}
+	if small.Description != "Basic 1 vCPU / 1 GB" {
+		t.Fatalf("small size description = %q", small.Description)
+	}
+}
+
+func TestListOptions(t *testing.T) {
+	api, do := newFakeAPI(t)
+	api.json(http.MethodGet, "/images", http.StatusOK, `{"images":[
+		{"id":2,"name":"24.04 (LTS) x64","distribution":"Ubuntu","slug":"ubuntu-24-04-x64","type":"base","status":"available"},
+		{"id":1,"name":"12 x64","distribution":"Debian","slug":"debian-12-x64","type":"base","status":"available"},
+		{"id":3,"name":"custom","distribution":"Unknown","slug":"","type":"custom","status":"available"}
+	],"links":{}}`)
+	api.json(http.MethodGet, "/firewalls", http.StatusOK, `{"firewalls":[{"id":"bb4b2611-3d72-467b-8602-280330ecd65c","name":"web"},{"id":"fb6045f1-cf1d-4ca3-bfac-18832663025b","name":"ssh"}],"links":{}}`)
+	api.json(http.MethodGet, "/volumes", http.StatusOK, `{"volumes":[{"id":"506f78a4-e098-11e5-ad9f-000f53306ae1","name":"uploads","size_gigabytes":100,"droplet_ids":[3164494],"region":{"slug":"fra1"}}],"links":{}}`)
+
+	images, err := do.ListServerImages(context.Background(), "do-token", "x86")
+	if err != nil {
+		t.Fatalf("ListServerImages() error = %v", err)
+	}
+	if len(images) != 2 || images[0].Name != "debian-12-x64" || images[1].Name != "ubuntu-24-04-x64" {
+		t.Fatalf("images = %+v, want the two distribution slugs sorted", images)
+	}
+	if !strings.Contains(api.requests[0], "type=distribution") {
+		t.Fatalf("image request = %q, want a distribution filter", api.requests[0])
+	}
+	arm, err := do.ListServerImages(context.Background(), "do-token", "arm")
+	if err != nil || len(arm) != 0 {
+		t.Fatalf("ListServerImages(arm) = %+v, %v; want none", arm, err)
+	}
+
+	firewalls, err := do.ListFirewalls(context.Background(), "do-token")
+	if err != nil {
+		t.Fatalf("ListFirewalls() error = %v", err)
+	}
+	if len(firewalls) != 2 || firewalls[0].Name != "ssh" || firewalls[1].Name != "web" {
+		t.Fatalf("firewalls = %+v", firewalls)
+	}
+
+	volumes, err := do.ListVolumes(context.Background(), "do-token")
+	if err != nil {
+		t.Fatalf("ListVolumes() error = %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].SizeGB != 100 || volumes[0].Location != "fra1" || volumes[0].ServerID != 3164494 || volumes[0].Status != "attached" {
+		t.Fatalf("volumes = %+v", volumes)
+	}
+}
+
+func TestAPIErrorsAreMapped(t *testing.T) {
+	api, do := newFakeAPI(t)
+	api.json(http.MethodGet, "/firewalls", http.StatusTooManyRequests, `{"id":"too_many_requests","message":"API Rate limit exceeded."}`)
+
+	_, err := do.ListFirewalls(context.Background(), "do-token")
+	if err == nil || err.Error() != "DigitalOcean API rate limit exceeded" {
+		t.Fatalf("ListFirewalls() error = %v, want rate limit error", err)
+	}
+
+	_, err = do.ListVolumes(context.Background(), "wrong-token")
+	if err == nil || err.Error() != "invalid DigitalOcean API token" {
+		t.Fatalf("ListVolumes(wrong) error = %v, want invalid token error", err)
+	}
+}
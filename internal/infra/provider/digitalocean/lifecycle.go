@@ -0,0 +1,459 @@
+package digitalocean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"pressluft/internal/infra/provider"
+)
+
+// defaultPollInterval is how often running actions are polled.
+const defaultPollInterval = 5 * time.Second
+
+type droplet struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Region struct {
+		Slug string `json:"slug"`
+	} `json:"region"`
+	Networks struct {
+		V4 []dropletNetwork `json:"v4"`
+		V6 []dropletNetwork `json:"v6"`
+	} `json:"networks"`
+}
+
+type dropletNetwork struct {
+	IPAddress string `json:"ip_address"`
+	Type      string `json:"type"`
+}
+
+func publicIP(networks []dropletNetwork) string {
+	for _, network := range networks {
+		if network.Type == "public" {
+			return network.IPAddress
+		}
+	}
+	return ""
+}
+
+type action struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	Type   string `json:"type"`
+}
+
+type actionResponse struct {
+	Action action `json:"action"`
+}
+
+type firewall struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	DropletIDs []int64 `json:"droplet_ids"`
+}
+
+type volume struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	DropletIDs []int64 `json:"droplet_ids"`
+}
+
+type sshKey struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+func (d *DigitalOcean) pollInterval() time.Duration {
+	if d.PollInterval > 0 {
+		return d.PollInterval
+	}
+	return defaultPollInterval
+}
+
+// CreateServer creates the droplet and waits until it is active. A droplet
+// with the same name is returned as is, so a retried job picks up the
+// droplet its first attempt created.
+func (d *DigitalOcean) CreateServer(ctx context.Context, token string, spec provider.ServerSpec, report provider.ActionReporter) (*provider.CreatedServer, error) {
+	c := d.newClient(token)
+	existing, err := c.findDroplet(ctx, provider.ServerRef{Name: spec.Name})
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return createdServer(existing, ""), nil
+	}
+
+	keyID, err := c.ensureSSHKey(ctx, spec.SSHKeyName, spec.SSHPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	var created struct {
+		Droplet droplet `json:"droplet"`
+		Links   struct {
+			Actions []struct {
+				ID  int64  `json:"id"`
+				Rel string `json:"rel"`
+			} `json:"actions"`
+		} `json:"links"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/droplets", map[string]any{
+		"name":     spec.Name,
+		"region":   spec.Location,
+		"size":     spec.ServerType,
+		"image":    dropletImage(spec.Image),
+		"ssh_keys": []int64{keyID},
+		"ipv6":     true,
+		"tags":     []string{tagName},
+	}, &created); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+
+	actionID := ""
+	for _, link := range created.Links.Actions {
+		if link.Rel != "create" {
+			continue
+		}
+		actionID = strconv.FormatInt(link.ID, 10)
+		running := provider.Action{ID: actionID, Command: "create", Status: provider.ActionRunning}
+		if err := provider.WaitForActions(ctx, d, token, d.pollInterval(), report, running); err != nil {
+			return nil, err
+		}
+	}
+
+	var current struct {
+		Droplet droplet `json:"droplet"`
+	}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/droplets/%d", created.Droplet.ID), nil, &current); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+	return createdServer(&current.Droplet, actionID), nil
+}
+
+// DeleteServer deletes the droplet. A droplet that no longer exists counts
+// as deleted.
+func (d *DigitalOcean) DeleteServer(ctx context.Context, token string, ref provider.ServerRef, _ provider.ActionReporter) error {
+	c := d.newClient(token)
+	target, err := c.findDroplet(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return nil
+	}
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/droplets/%d", target.ID), nil, nil); err != nil && !isStatus(err, http.StatusNotFound) {
+		return mapDigitalOceanAPIError(err)
+	}
+	return nil
+}
+
+// RebuildServer reinstalls the droplet from image, which may be a profile
+// image name, a droplet image slug or an image ID.
+func (d *DigitalOcean) RebuildServer(ctx context.Context, token string, ref provider.ServerRef, image string, report provider.ActionReporter) error {
+	c := d.newClient(token)
+	target, err := c.mustFindDroplet(ctx, ref)
+	if err != nil {
+		return err
+	}
+	return d.dropletAction(ctx, c, token, target.ID, map[string]any{"type": "rebuild", "image": dropletImage(image)}, report)
+}
+
+// ResizeServer powers the droplet off, resizes it and powers it on again;
+// droplets can only be resized while off.
+func (d *DigitalOcean) ResizeServer(ctx context.Context, token string, ref provider.ServerRef, serverType string, upgradeDisk bool, report provider.ActionReporter) error {
+	c := d.newClient(token)
+	target, err := c.mustFindDroplet(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if target.Status != "off" {
+		if err := d.dropletAction(ctx, c, token, target.ID, map[string]any{"type": "power_off"}, report); err != nil {
+			return err
+		}
+	}
+	if err := d.dropletAction(ctx, c, token, target.ID, map[string]any{"type": "resize", "size": serverType, "disk": upgradeDisk}, report); err != nil {
+		return err
+	}
+	return d.dropletAction(ctx, c, token, target.ID, map[string]any{"type": "power_on"}, report)
+}
+
+// ApplyFirewalls adds the droplet to the named firewalls and removes it
+// from every other firewall.
+func (d *DigitalOcean) ApplyFirewalls(ctx context.Context, token string, ref provider.ServerRef, firewalls []string, _ provider.ActionReporter) error {
+	c := d.newClient(token)
+	target, err := c.mustFindDroplet(ctx, ref)
+	if err != nil {
+		return err
+	}
+	var all []firewall
+	if err := c.listAll(ctx, "/firewalls", func(page json.RawMessage) error {
+		var body struct {
+			Firewalls []firewall `json:"firewalls"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		all = append(all, body.Firewalls...)
+		return nil
+	}); err != nil {
+		return mapDigitalOceanAPIError(err)
+	}
+	var unknown []string
+	for _, name := range firewalls {
+		if !slices.ContainsFunc(all, func(fw firewall) bool { return fw.Name == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown firewalls: %s", strings.Join(unknown, ", "))
+	}
+
+	body := map[string]any{"droplet_ids": []int64{target.ID}}
+	for _, fw := range all {
+		wanted := slices.Contains(firewalls, fw.Name)
+		applied := slices.Contains(fw.DropletIDs, target.ID)
+		var method string
+		switch {
+		case wanted && !applied:
+			method = http.MethodPost
+		case !wanted && applied:
+			method = http.MethodDelete
+		default:
+			continue
+		}
+		if err := c.send(ctx, method, "/firewalls/"+fw.ID+"/droplets", body, nil); err != nil {
+			return mapDigitalOceanAPIError(err)
+		}
+	}
+	return nil
+}
+
+// AttachVolume creates the volume in the droplet's region if it does not
+// exist and attaches it to the droplet.
+func (d *DigitalOcean) AttachVolume(ctx context.Context, token string, ref provider.ServerRef, spec provider.VolumeSpec, report provider.ActionReporter) error {
+	c := d.newClient(token)
+	target, err := c.mustFindDroplet(ctx, ref)
+	if err != nil {
+		return err
+	}
+	region := target.Region.Slug
+	vol, err := c.findVolume(ctx, spec.Name, region)
+	if err != nil {
+		return err
+	}
+	if vol == nil {
+		body := map[string]any{
+			"name":           spec.Name,
+			"size_gigabytes": spec.SizeGB,
+			"region":         region,
+			"tags":           []string{tagName},
+		}
+		if spec.Automount {
+			// Automounting needs a file system to mount.
+			body["filesystem_type"] = "ext4"
+		}
+		var created struct {
+			Volume volume `json:"volume"`
+		}
+		if err := c.send(ctx, http.MethodPost, "/volumes", body, &created); err != nil {
+			return mapDigitalOceanAPIError(err)
+		}
+		vol = &created.Volume
+	}
+	if slices.Contains(vol.DropletIDs, target.ID) {
+		return nil
+	}
+	return d.volumeAction(ctx, c, token, vol.ID, map[string]any{"type": "attach", "droplet_id": target.ID, "region": region}, report)
+}
+
+// DeleteVolume detaches the named volume and deletes it. A volume that no
+// longer exists counts as deleted.
+func (d *DigitalOcean) DeleteVolume(ctx context.Context, token string, ref provider.ServerRef, name string, report provider.ActionReporter) error {
+	c := d.newClient(token)
+	target, err := c.mustFindDroplet(ctx, ref)
+	if err != nil {
+		return err
+	}
+	region := target.Region.Slug
+	vol, err := c.findVolume(ctx, name, region)
+	if err != nil || vol == nil {
+		return err
+	}
+	for _, dropletID := range vol.DropletIDs {
+		if err := d.volumeAction(ctx, c, token, vol.ID, map[string]any{"type": "detach", "droplet_id": dropletID, "region": region}, report); err != nil {
+			return err
+		}
+	}
+	if err := c.do(ctx, http.MethodDelete, "/volumes/"+vol.ID, nil, nil); err != nil && !isStatus(err, http.StatusNotFound) {
+		return mapDigitalOceanAPIError(err)
+	}
+	return nil
+}
+
+// PollAction returns the current state of a DigitalOcean action.
+func (d *DigitalOcean) PollAction(ctx context.Context, token string, current provider.Action) (provider.Action, error) {
+	var body actionResponse
+	if err := d.newClient(token).do(ctx, http.MethodGet, "/actions/"+url.PathEscape(current.ID), nil, &body); err != nil {
+		return current, mapDigitalOceanAPIError(err)
+	}
+	return toAction(body.Action), nil
+}
+
+func (d *DigitalOcean) dropletAction(ctx context.Context, c *client, token string, dropletID int64, body map[string]any, report provider.ActionReporter) error {
+	var started actionResponse
+	if err := c.send(ctx, http.MethodPost, fmt.Sprintf("/droplets/%d/actions", dropletID), body, &started); err != nil {
+		return mapDigitalOceanAPIError(err)
+	}
+	return provider.WaitForActions(ctx, d, token, d.pollInterval(), report, toAction(started.Action))
+}
+
+func (d *DigitalOcean) volumeAction(ctx context.Context, c *client, token, volumeID string, body map[string]any, report provider.ActionReporter) error {
+	var started actionResponse
+	if err := c.send(ctx, http.MethodPost, "/volumes/"+url.PathEscape(volumeID)+"/actions", body, &started); err != nil {
+		return mapDigitalOceanAPIError(err)
+	}
+	return provider.WaitForActions(ctx, d, token, d.pollInterval(), report, toAction(started.Action))
+}
+
+func toAction(a action) provider.Action {
+	out := provider.Action{ID: strconv.FormatInt(a.ID, 10), Command: a.Type}
+	switch a.Status {
+	case "completed":
+		out.Status = provider.ActionSucceeded
+		out.Progress = 100
+	case "errored":
+		out.Status = provider.ActionFailed
+	default:
+		out.Status = provider.ActionRunning
+	}
+	return out
+}
+
+// send is do with a JSON request body.
+func (c *client) send(ctx context.Context, method, path string, body, out any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, path, bytes.NewReader(raw), out)
+}
+
+// findDroplet looks the droplet up by ID, or by name when no ID is known.
+// It returns nil when there is no such droplet.
+func (c *client) findDroplet(ctx context.Context, ref provider.ServerRef) (*droplet, error) {
+	if id, err := strconv.ParseInt(strings.TrimSpace(ref.ID), 10, 64); err == nil {
+		var body struct {
+			Droplet droplet `json:"droplet"`
+		}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/droplets/%d", id), nil, &body); err != nil {
+			if isStatus(err, http.StatusNotFound) {
+				return nil, nil
+			}
+			return nil, mapDigitalOceanAPIError(err)
+		}
+		return &body.Droplet, nil
+	}
+
+	var body struct {
+		Droplets []droplet `json:"droplets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/droplets?name="+url.QueryEscape(ref.Name), nil, &body); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+	switch len(body.Droplets) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &body.Droplets[0], nil
+	default:
+		return nil, fmt.Errorf("expected exactly one droplet named %s, found %d", ref.Name, len(body.Droplets))
+	}
+}
+
+func (c *client) mustFindDroplet(ctx context.Context, ref provider.ServerRef) (*droplet, error) {
+	found, err := c.findDroplet(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("digitalocean droplet %s not found", ref)
+	}
+	return found, nil
+}
+
+func (c *client) findVolume(ctx context.Context, name, region string) (*volume, error) {
+	var body struct {
+		Volumes []volume `json:"volumes"`
+	}
+	query := url.Values{"name": {name}, "region": {region}}
+	if err := c.do(ctx, http.MethodGet, "/volumes?"+query.Encode(), nil, &body); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+	if len(body.Volumes) == 0 {
+		return nil, nil
+	}
+	return &body.Volumes[0], nil
+}
+
+// ensureSSHKey returns the ID of the account key with the given name or
+// public key, uploading it when the account has neither.
+func (c *client) ensureSSHKey(ctx context.Context, name, publicKey string) (int64, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	var keys []sshKey
+	if err := c.listAll(ctx, "/account/keys", func(page json.RawMessage) error {
+		var body struct {
+			SSHKeys []sshKey `json:"ssh_keys"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		keys = append(keys, body.SSHKeys...)
+		return nil
+	}); err != nil {
+		return 0, mapDigitalOceanAPIError(err)
+	}
+	for _, key := range keys {
+		if key.Name == name || strings.TrimSpace(key.PublicKey) == publicKey {
+			return key.ID, nil
+		}
+	}
+
+	var created struct {
+		SSHKey sshKey `json:"ssh_key"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/account/keys", map[string]string{"name": name, "public_key": publicKey}, &created); err != nil {
+		return 0, mapDigitalOceanAPIError(err)
+	}
+	return created.SSHKey.ID, nil
+}
+
+var dropletImageSlug = regexp.MustCompile(`-x64$|^[0-9]+$`)
+
+// dropletImage translates a profile image name such as ubuntu-24.04 into
+// the droplet slug ubuntu-24-04-x64. Slugs and image IDs pass through.
+func dropletImage(image string) string {
+	image = strings.TrimSpace(image)
+	if dropletImageSlug.MatchString(image) {
+		return image
+	}
+	return strings.ReplaceAll(image, ".", "-") + "-x64"
+}
+
+func createdServer(d *droplet, actionID string) *provider.CreatedServer {
+	return &provider.CreatedServer{
+		ID:       strconv.FormatInt(d.ID, 10),
+		IPv4:     publicIP(d.Networks.V4),
+		IPv6:     publicIP(d.Networks.V6),
+		ActionID: actionID,
+	}
+}
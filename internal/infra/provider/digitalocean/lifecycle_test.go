@@ -0,0 +1,141 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/infra/provider"
+)
+
+func TestCreateServerWaitsForCreateAction(t *testing.T) {
+	api, do := newFakeAPI(t)
+	do.PollInterval = time.Millisecond
+	api.json(http.MethodGet, "/droplets", http.StatusOK, `{"droplets":[]}`)
+	api.json(http.MethodGet, "/account/keys", http.StatusOK, `{"ssh_keys":[{"id":512,"name":"pressluft-web-1","public_key":"ssh-ed25519 AAAA"}]}`)
+	var createBody map[string]any
+	api.handle(http.MethodPost, "/droplets", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&createBody)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"droplet":{"id":3164494,"name":"web-1","status":"new"},"links":{"actions":[{"id":36804636,"rel":"create"}]}}`))
+	})
+	api.json(http.MethodGet, "/actions/36804636", http.StatusOK, `{"action":{"id":36804636,"status":"completed","type":"create"}}`)
+	api.json(http.MethodGet, "/droplets/3164494", http.StatusOK, `{"droplet":{"id":3164494,"name":"web-1","status":"active","networks":{"v4":[{"ip_address":"10.110.0.2","type":"private"},{"ip_address":"203.0.113.10","type":"public"}],"v6":[{"ip_address":"2001:db8::10","type":"public"}]}}}`)
+
+	var reported []provider.Action
+	created, err := do.CreateServer(context.Background(), "do-token", provider.ServerSpec{
+		Name:         "web-1",
+		Location:     "fra1",
+		ServerType:   "s-1vcpu-1gb",
+		Image:        "ubuntu-24.04",
+		SSHKeyName:   "pressluft-web-1",
+		SSHPublicKey: "ssh-ed25519 AAAA",
+	}, func(a provider.Action) { reported = append(reported, a) })
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if created.ID != "3164494" || created.IPv4 != "203.0.113.10" || created.IPv6 != "2001:db8::10" || created.ActionID != "36804636" {
+		t.Fatalf("created = %+v", created)
+	}
+	if createBody["image"] != "ubuntu-24-04-x64" || createBody["ipv6"] != true {
+		t.Fatalf("create body = %+v", createBody)
+	}
+	if keys, _ := createBody["ssh_keys"].([]any); len(keys) != 1 || keys[0] != float64(512) {
+		t.Fatalf("ssh_keys = %v, want the existing key", createBody["ssh_keys"])
+	}
+	if len(reported) != 2 || reported[1].Status != provider.ActionSucceeded {
+		t.Fatalf("reported = %+v", reported)
+	}
+}
+
+func TestResizeServerRunsPowerCycle(t *testing.T) {
+	api, do := newFakeAPI(t)
+	do.PollInterval = time.Millisecond
+	api.json(http.MethodGet, "/droplets/3164494", http.StatusOK, `{"droplet":{"id":3164494,"name":"web-1","status":"active"}}`)
+	var types []string
+	api.handle(http.MethodPost, "/droplets/3164494/actions", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Type string `json:"type"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		types = append(types, body.Type)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"action":{"id":1,"status":"in-progress","type":"` + body.Type + `"}}`))
+	})
+	api.json(http.MethodGet, "/actions/1", http.StatusOK, `{"action":{"id":1,"status":"completed"}}`)
+
+	if err := do.ResizeServer(context.Background(), "do-token", provider.ServerRef{ID: "3164494"}, "s-2vcpu-4gb", true, nil); err != nil {
+		t.Fatalf("ResizeServer() error = %v", err)
+	}
+	if !slices.Equal(types, []string{"power_off", "resize", "power_on"}) {
+		t.Fatalf("actions = %v", types)
+	}
+}
+
+func TestRebuildServerReportsErroredAction(t *testing.T) {
+	api, do := newFakeAPI(t)
+	do.PollInterval = time.Millisecond
+	api.json(http.MethodGet, "/droplets", http.StatusOK, `{"droplets":[{"id":3164494,"name":"web-1","status":"active"}]}`)
+	api.json(http.MethodPost, "/droplets/3164494/actions", http.StatusCreated, `{"action":{"id":2,"status":"in-progress","type":"rebuild"}}`)
+	api.json(http.MethodGet, "/actions/2", http.StatusOK, `{"action":{"id":2,"status":"errored","type":"rebuild"}}`)
+
+	err := do.RebuildServer(context.Background(), "do-token", provider.ServerRef{Name: "web-1"}, "ubuntu-24.04", nil)
+	if err == nil || !strings.Contains(err.Error(), "rebuild action 2 failed") {
+		t.Fatalf("RebuildServer() error = %v, want failed action", err)
+	}
+}
+
+func TestApplyFirewallsSyncsDroplets(t *testing.T) {
+	api, do := newFakeAPI(t)
+	api.json(http.MethodGet, "/droplets/3164494", http.StatusOK, `{"droplet":{"id":3164494,"name":"web-1","status":"active"}}`)
+	api.json(http.MethodGet, "/firewalls", http.StatusOK, `{"firewalls":[{"id":"fw-web","name":"web","droplet_ids":[]},{"id":"fw-old","name":"legacy","droplet_ids":[3164494]}]}`)
+	api.json(http.MethodPost, "/firewalls/fw-web/droplets", http.StatusNoContent, ``)
+	api.json(http.MethodDelete, "/firewalls/fw-old/droplets", http.StatusNoContent, ``)
+
+	ref := provider.ServerRef{ID: "3164494"}
+	if err := do.ApplyFirewalls(context.Background(), "do-token", ref, []string{"web"}, nil); err != nil {
+		t.Fatalf("ApplyFirewalls() error = %v", err)
+	}
+	if !slices.Contains(api.requests, "POST /v2/firewalls/fw-web/droplets") || !slices.Contains(api.requests, "DELETE /v2/firewalls/fw-old/droplets") {
+		t.Fatalf("requests = %q", api.requests)
+	}
+
+	err := do.ApplyFirewalls(context.Background(), "do-token", ref, []string{"missing"}, nil)
+	if err == nil || err.Error() != "unknown firewalls: missing" {
+		t.Fatalf("ApplyFirewalls() error = %v, want unknown firewall", err)
+	}
+}
+
+func TestDeleteVolumeDetachesFirst(t *testing.T) {
+	api, do := newFakeAPI(t)
+	do.PollInterval = time.Millisecond
+	api.json(http.MethodGet, "/droplets/3164494", http.StatusOK, `{"droplet":{"id":3164494,"name":"web-1","status":"active","region":{"slug":"fra1"}}}`)
+	api.json(http.MethodGet, "/volumes", http.StatusOK, `{"volumes":[{"id":"vol-1","name":"data","droplet_ids":[3164494]}]}`)
+	api.json(http.MethodPost, "/volumes/vol-1/actions", http.StatusAccepted, `{"action":{"id":3,"status":"in-progress","type":"detach"}}`)
+	api.json(http.MethodGet, "/actions/3", http.StatusOK, `{"action":{"id":3,"status":"completed","type":"detach"}}`)
+	api.json(http.MethodDelete, "/volumes/vol-1", http.StatusNoContent, ``)
+
+	if err := do.DeleteVolume(context.Background(), "do-token", provider.ServerRef{ID: "3164494"}, "data", nil); err != nil {
+		t.Fatalf("DeleteVolume() error = %v", err)
+	}
+	last := api.requests[len(api.requests)-1]
+	if last != "DELETE /v2/volumes/vol-1" || !slices.Contains(api.requests, "GET /v2/volumes?name=data&region=fra1") {
+		t.Fatalf("requests = %q", api.requests)
+	}
+}
+
+func TestDropletImage(t *testing.T) {
+	for image, want := range map[string]string{
+		"ubuntu-24.04":     "ubuntu-24-04-x64",
+		"ubuntu-24-04-x64": "ubuntu-24-04-x64",
+		"123456":           "123456",
+	} {
+		if got := dropletImage(image); got != want {
+			t.Fatalf("dropletImage(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"pressluft/internal/infra/provider"
+)
+
+type image struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Distribution string `json:"distribution"`
+	Slug         string `json:"slug"`
+	Type         string `json:"type"`
+	Status       string `json:"status"`
+}
+
+// ListServerImages returns the distribution images droplets can be rebuilt
+// from. Every droplet is x86, so other architectures have no images.
+func (d *DigitalOcean) ListServerImages(ctx context.Context, token, arch string) ([]provider.ServerImageOption, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("api token must not be empty")
+	}
+	arch = strings.TrimSpace(arch)
+	if arch == "" {
+		return nil, fmt.Errorf("architecture is required")
+	}
+	if arch != architecture {
+		return []provider.ServerImageOption{}, nil
+	}
+
+	client := d.newClient(token)
+	var images []image
+	if err := client.listAll(ctx, "/images?type=distribution", func(page json.RawMessage) error {
+		var body struct {
+			Images []image `json:"images"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		images = append(images, body.Images...)
+		return nil
+	}); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+
+	options := make([]provider.ServerImageOption, 0, len(images))
+	for _, img := range images {
+		if img.Slug == "" || img.Status == "deleted" {
+			continue
+		}
+		options = append(options, provider.ServerImageOption{
+			ID:           img.ID,
+			Name:         img.Slug,
+			Type:         img.Type,
+			Architecture: architecture,
+			Deprecated:   img.Status == "retired",
+			Status:       img.Status,
+		})
+	}
+
+	slices.SortFunc(options, func(a, b provider.ServerImageOption) int {
+		if cmp := strings.Compare(a.Name, b.Name); cmp != 0 {
+			return cmp
+		}
+		if a.ID < b.ID {
+			return -1
+		}
+		if a.ID > b.ID {
+			return 1
+		}
+		return 0
+	})
+
+	return options, nil
+}
+
+// ListFirewalls returns all cloud firewalls in the DigitalOcean account.
+// Their IDs are UUIDs, so options carry only the name the workflows use.
+func (d *DigitalOcean) ListFirewalls(ctx context.Context, token string) ([]provider.FirewallOption, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("api token must not be empty")
+	}
+
+	client := d.newClient(token)
+	options := make([]provider.FirewallOption, 0)
+	if err := client.listAll(ctx, "/firewalls", func(page json.RawMessage) error {
+		var body struct {
+			Firewalls []struct {
+				Name string `json:"name"`
+			} `json:"firewalls"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		for _, fw := range body.Firewalls {
+			options = append(options, provider.FirewallOption{Name: fw.Name})
+		}
+		return nil
+	}); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+
+	slices.SortFunc(options, func(a, b provider.FirewallOption) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return options, nil
+}
+
+// ListVolumes returns all block storage volumes in the DigitalOcean account.
+// Like firewalls, volumes are identified by name.
+func (d *DigitalOcean) ListVolumes(ctx context.Context, token string) ([]provider.VolumeOption, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("api token must not be empty")
+	}
+
+	client := d.newClient(token)
+	options := make([]provider.VolumeOption, 0)
+	if err := client.listAll(ctx, "/volumes", func(page json.RawMessage) error {
+		var body struct {
+			Volumes []struct {
+				Name          string  `json:"name"`
+				SizeGigabytes int     `json:"size_gigabytes"`
+				DropletIDs    []int64 `json:"droplet_ids"`
+				Region        struct {
+					Slug string `json:"slug"`
+				} `json:"region"`
+			} `json:"volumes"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		for _, vol := range body.Volumes {
+			opt := provider.VolumeOption{
+				Name:     vol.Name,
+				SizeGB:   vol.SizeGigabytes,
+				Location: vol.Region.Slug,
+				Status:   "available",
+			}
+			if len(vol.DropletIDs) > 0 {
+				opt.ServerID = vol.DropletIDs[0]
+				opt.Status = "attached"
+			}
+			options = append(options, opt)
+		}
+		return nil
+	}); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+
+	slices.SortFunc(options, func(a, b provider.VolumeOption) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return options, nil
+}
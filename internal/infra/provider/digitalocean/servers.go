@@ -0,0 +1,162 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"pressluft/internal/infra/provider"
+)
+
+// architecture is the only CPU architecture DigitalOcean droplets run on.
+const architecture = "x86"
+
+type region struct {
+	Slug      string   `json:"slug"`
+	Name      string   `json:"name"`
+	Available bool     `json:"available"`
+	Sizes     []string `json:"sizes"`
+}
+
+type size struct {
+	Slug         string   `json:"slug"`
+	Description  string   `json:"description"`
+	Memory       int      `json:"memory"`
+	VCPUs        int      `json:"vcpus"`
+	Disk         int      `json:"disk"`
+	PriceMonthly float64  `json:"price_monthly"`
+	PriceHourly  float64  `json:"price_hourly"`
+	Regions      []string `json:"regions"`
+	Available    bool     `json:"available"`
+}
+
+// regionPlaces maps the city prefix of a region slug to where it is.
+var regionPlaces = map[string]struct{ city, country string }{
+	"ams": {"Amsterdam", "NL"},
+	"atl": {"Atlanta", "US"},
+	"blr": {"Bangalore", "IN"},
+	"fra": {"Frankfurt", "DE"},
+	"lon": {"London", "GB"},
+	"nyc": {"New York", "US"},
+	"sfo": {"San Francisco", "US"},
+	"sgp": {"Singapore", "SG"},
+	"syd": {"Sydney", "AU"},
+	"tor": {"Toronto", "CA"},
+}
+
+func (d *DigitalOcean) ListServerCatalog(ctx context.Context, token string) (*provider.ServerCatalog, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, fmt.Errorf("api token must not be empty")
+	}
+
+	client := d.newClient(token)
+
+	var regions []region
+	if err := client.listAll(ctx, "/regions", func(page json.RawMessage) error {
+		var body struct {
+			Regions []region `json:"regions"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		regions = append(regions, body.Regions...)
+		return nil
+	}); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+
+	var sizes []size
+	if err := client.listAll(ctx, "/sizes", func(page json.RawMessage) error {
+		var body struct {
+			Sizes []size `json:"sizes"`
+		}
+		if err := json.Unmarshal(page, &body); err != nil {
+			return err
+		}
+		sizes = append(sizes, body.Sizes...)
+		return nil
+	}); err != nil {
+		return nil, mapDigitalOceanAPIError(err)
+	}
+
+	// Regions list the sizes that can be created there now, which is the
+	// authoritative source for availability.
+	availableIn := make(map[string][]string)
+	locations := make([]provider.ServerLocation, 0, len(regions))
+	for _, r := range regions {
+		if !r.Available {
+			continue
+		}
+		location := provider.ServerLocation{
+			Name:        r.Slug,
+			Description: r.Name,
+		}
+		if place, ok := regionPlaces[strings.TrimRight(r.Slug, "0123456789")]; ok {
+			location.City = place.city
+			location.Country = place.country
+		}
+		locations = append(locations, location)
+		for _, sizeSlug := range r.Sizes {
+			availableIn[sizeSlug] = append(availableIn[sizeSlug], r.Slug)
+		}
+	}
+
+	serverTypes := make([]provider.ServerTypeOption, 0, len(sizes))
+	for _, s := range sizes {
+		availableAt := availableIn[s.Slug]
+		if !s.Available || len(availableAt) == 0 {
+			// Skip sizes that can't be created anywhere
+			continue
+		}
+		slices.Sort(availableAt)
+
+		prices := make([]provider.ServerTypePrice, 0, len(availableAt))
+		for _, location := range availableAt {
+			prices = append(prices, provider.ServerTypePrice{
+				LocationName: location,
+				HourlyGross:  strconv.FormatFloat(s.PriceHourly, 'f', 5, 64),
+				MonthlyGross: strconv.FormatFloat(s.PriceMonthly, 'f', 2, 64),
+				Currency:     "USD",
+			})
+		}
+
+		serverTypes = append(serverTypes, provider.ServerTypeOption{
+			Name:         s.Slug,
+			Description:  sizeDescription(s),
+			Cores:        s.VCPUs,
+			MemoryGB:     float64(s.Memory) / 1024,
+			DiskGB:       s.Disk,
+			Architecture: architecture,
+			AvailableAt:  availableAt,
+			Prices:       prices,
+		})
+	}
+
+	catalog := &provider.ServerCatalog{
+		Locations:   locations,
+		ServerTypes: serverTypes,
+	}
+
+	slices.SortFunc(catalog.Locations, func(a, b provider.ServerLocation) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	slices.SortFunc(catalog.ServerTypes, func(a, b provider.ServerTypeOption) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return catalog, nil
+}
+
+// sizeDescription names a size the way the DigitalOcean control panel does,
+// e.g. "Basic 2 vCPU / 4 GB".
+func sizeDescription(s size) string {
+	memory := strconv.FormatFloat(float64(s.Memory)/1024, 'f', -1, 64)
+	label := fmt.Sprintf("%d vCPU / %s GB", s.VCPUs, memory)
+	if family := strings.TrimSpace(s.Description); family != "" {
+		return family + " " + label
+	}
+	return label
+}
@@ -0,0 +1,223 @@
+// Package fake implements an in-memory cloud provider for end-to-end tests
+// and demos. Servers, volumes and actions only exist inside the process;
+// nothing is billed and nothing is reachable.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pressluft/internal/infra/provider"
+)
+
+const (
+	providerType = "fake"
+	displayName  = "Simulated cloud"
+	abbreviation = "Sim"
+	description  = "In-memory servers for tests and demos"
+
+	architecture = "x86"
+	currency     = "EUR"
+)
+
+// Firewalls are the firewalls every fake project has.
+var Firewalls = []string{"web", "ssh-only"}
+
+var locations = []provider.ServerLocation{
+	{Name: "sim-1", Description: "Simulated region 1", NetworkZone: "sim"},
+	{Name: "sim-2", Description: "Simulated region 2", NetworkZone: "sim"},
+}
+
+var serverTypes = []struct {
+	name     string
+	cores    int
+	memoryGB float64
+	diskGB   int
+	hourly   string
+	monthly  string
+}{
+	{name: "sim-small", cores: 2, memoryGB: 4, diskGB: 40, hourly: "0.0080", monthly: "5.0000"},
+	{name: "sim-medium", cores: 4, memoryGB: 8, diskGB: 80, hourly: "0.0160", monthly: "10.0000"},
+	{name: "sim-large", cores: 8, memoryGB: 16, diskGB: 160, hourly: "0.0320", monthly: "20.0000"},
+}
+
+// Fake implements provider.Provider, provider.ServerProvider,
+// provider.ServerLifecycle and provider.SimulatedProvider in memory.
+//
+// Every operation applies its change at once and starts actions that finish
+// ActionDelay later. An injected failure makes the next action with that
+// command fail; the change it stands for has still been applied, as when a
+// real provider leaves a half-created server behind.
+type Fake struct {
+	// ActionDelay is how long every action runs. Zero finishes actions on
+	// their first poll.
+	ActionDelay time.Duration
+	// PollInterval is how often lifecycle calls poll their actions.
+	PollInterval time.Duration
+
+	mu       sync.Mutex
+	nextID   int64
+	nextIP   int
+	servers  map[string]*server
+	volumes  map[string]*volume
+	actions  map[string]*action
+	failures map[string][]string
+}
+
+type server struct {
+	id         string
+	name       string
+	location   string
+	serverType string
+	image      string
+	ipv4       string
+	ipv6       string
+	firewalls  []string
+}
+
+type volume struct {
+	id       string
+	name     string
+	sizeGB   int
+	location string
+	serverID string
+}
+
+type action struct {
+	command string
+	started time.Time
+	err     string
+}
+
+// New returns an empty fake cloud.
+func New() *Fake {
+	return &Fake{
+		servers:  map[string]*server{},
+		volumes:  map[string]*volume{},
+		actions:  map[string]*action{},
+		failures: map[string][]string{},
+	}
+}
+
+// Register adds a new Fake to the provider registry and returns it so the
+// caller can tune delays and inject failures. Unlike the real providers it
+// is not registered on import: the control plane only registers it when the
+// fake cloud is explicitly enabled.
+func Register() *Fake {
+	f := New()
+	provider.Register(f)
+	return f
+}
+
+// Info returns metadata about this provider. There are no token docs: any
+// token works.
+func (f *Fake) Info() provider.Info {
+	return provider.Info{
+		Type:         providerType,
+		Name:         displayName,
+		Abbreviation: abbreviation,
+		Description:  description,
+	}
+}
+
+// SimulatesServers is always true: fake servers cannot be reached.
+func (f *Fake) SimulatesServers() bool {
+	return true
+}
+
+// Validate accepts any non-empty token.
+func (f *Fake) Validate(ctx context.Context, token string) (*provider.ValidationResult, error) {
+	if strings.TrimSpace(token) == "" {
+		return &provider.ValidationResult{
+			Valid:   false,
+			Message: "API token must not be empty",
+		}, nil
+	}
+	return &provider.ValidationResult{
+		Valid:       true,
+		ReadWrite:   true,
+		Message:     "Simulated cloud ready; servers only exist in memory",
+		ProjectName: "Simulated project",
+	}, nil
+}
+
+// FailNext makes the next action with command fail with message, e.g.
+// FailNext("create_server", "no capacity"). Failures queue up per command.
+func (f *Fake) FailNext(command, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[command] = append(f.failures[command], message)
+}
+
+// ListServerCatalog returns the fixed fake locations and server types.
+func (f *Fake) ListServerCatalog(ctx context.Context, token string) (*provider.ServerCatalog, error) {
+	catalog := &provider.ServerCatalog{
+		Locations:   slices.Clone(locations),
+		ServerTypes: make([]provider.ServerTypeOption, 0, len(serverTypes)),
+	}
+	locationNames := make([]string, 0, len(locations))
+	for _, location := range locations {
+		locationNames = append(locationNames, location.Name)
+	}
+	for _, t := range serverTypes {
+		option := provider.ServerTypeOption{
+			Name:         t.name,
+			Description:  fmt.Sprintf("%d vCPU, %g GB RAM", t.cores, t.memoryGB),
+			Cores:        t.cores,
+			MemoryGB:     t.memoryGB,
+			DiskGB:       t.diskGB,
+			Architecture: architecture,
+			AvailableAt:  locationNames,
+		}
+		for _, location := range locationNames {
+			option.Prices = append(option.Prices, provider.ServerTypePrice{
+				LocationName: location,
+				HourlyGross:  t.hourly,
+				MonthlyGross: t.monthly,
+				Currency:     currency,
+			})
+		}
+		catalog.ServerTypes = append(catalog.ServerTypes, option)
+	}
+	return catalog, nil
+}
+
+// ListServerImages returns the one image fake servers run.
+func (f *Fake) ListServerImages(ctx context.Context, token, arch string) ([]provider.ServerImageOption, error) {
+	if strings.TrimSpace(arch) != architecture {
+		return []provider.ServerImageOption{}, nil
+	}
+	return []provider.ServerImageOption{{ID: 1, Name: "ubuntu-24.04", Type: "system", Architecture: architecture, Status: "available"}}, nil
+}
+
+// ListFirewalls returns Firewalls.
+func (f *Fake) ListFirewalls(ctx context.Context, token string) ([]provider.FirewallOption, error) {
+	out := make([]provider.FirewallOption, 0, len(Firewalls))
+	for i, name := range Firewalls {
+		out = append(out, provider.FirewallOption{ID: int64(i + 1), Name: name})
+	}
+	return out, nil
+}
+
+// ListVolumes returns every fake volume.
+func (f *Fake) ListVolumes(ctx context.Context, token string) ([]provider.VolumeOption, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]provider.VolumeOption, 0, len(f.volumes))
+	for _, v := range f.volumes {
+		id, _ := strconv.ParseInt(v.id, 10, 64)
+		serverID, _ := strconv.ParseInt(v.serverID, 10, 64)
+		status := "available"
+		if v.serverID != "" {
+			status = "attached"
+		}
+		out = append(out, provider.VolumeOption{ID: id, Name: v.name, SizeGB: v.sizeGB, Location: v.location, Status: status, ServerID: serverID})
+	}
+	slices.SortFunc(out, func(a, b provider.VolumeOption) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package fake
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/infra/provider"
+)
+
+func newTestFake() *Fake {
+	f := New()
+	f.PollInterval = time.Millisecond
+	return f
+}
+
+func TestCreateServerAllocatesAddresses(t *testing.T) {
+	f := newTestFake()
+
+	var reported []provider.Action
+	first, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", Location: "sim-1", ServerType: "sim-small"}, func(a provider.Action) { reported = append(reported, a) })
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	second, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-2", Location: "sim-1", ServerType: "sim-small"}, nil)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if first.IPv4 != "198.18.0.2" || second.IPv4 != "198.18.0.3" || first.IPv6 == second.IPv6 || first.ActionID == "" {
+		t.Fatalf("created = %+v and %+v, want distinct addresses", first, second)
+	}
+	// Create and start are each reported running and then succeeded.
+	if len(reported) != 4 || reported[0].Command != "create_server" || reported[3].Status != provider.ActionSucceeded {
+		t.Fatalf("reported = %+v", reported)
+	}
+
+	again, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", ServerType: "sim-small"}, nil)
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("CreateServer() = %+v, %v, want the existing server", again, err)
+	}
+}
+
+func TestActionDelayReportsProgress(t *testing.T) {
+	f := newTestFake()
+	f.ActionDelay = 20 * time.Millisecond
+
+	var reported []provider.Action
+	if _, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", ServerType: "sim-small"}, func(a provider.Action) { reported = append(reported, a) }); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	var partial bool
+	for _, a := range reported {
+		if a.Status == provider.ActionRunning && a.Progress > 0 {
+			partial = true
+		}
+	}
+	if !partial {
+		t.Fatalf("reported = %+v, want progress while running", reported)
+	}
+}
+
+func TestFailNextFailsOneAction(t *testing.T) {
+	f := newTestFake()
+	if _, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", ServerType: "sim-small"}, nil); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	f.FailNext("rebuild_server", "image unavailable")
+	ref := provider.ServerRef{Name: "web-1"}
+
+	err := f.RebuildServer(context.Background(), "token", ref, "ubuntu-24.04", nil)
+	if err == nil || !strings.Contains(err.Error(), "rebuild_server action") || !strings.Contains(err.Error(), "image unavailable") {
+		t.Fatalf("RebuildServer() error = %v, want the injected failure", err)
+	}
+	if err := f.RebuildServer(context.Background(), "token", ref, "ubuntu-24.04", nil); err != nil {
+		t.Fatalf("second RebuildServer() error = %v, want success", err)
+	}
+}
+
+func TestResizeRejectsUnknownType(t *testing.T) {
+	f := newTestFake()
+	if _, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", ServerType: "sim-small"}, nil); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	err := f.ResizeServer(context.Background(), "token", provider.ServerRef{Name: "web-1"}, "cx22", false, nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown server type "cx22"`) {
+		t.Fatalf("ResizeServer() error = %v", err)
+	}
+}
+
+func TestVolumesFollowTheirServer(t *testing.T) {
+	f := newTestFake()
+	created, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", Location: "sim-2", ServerType: "sim-small"}, nil)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	ref := provider.ServerRef{ID: created.ID}
+	if err := f.AttachVolume(context.Background(), "token", ref, provider.VolumeSpec{Name: "data", SizeGB: 10}, nil); err != nil {
+		t.Fatalf("AttachVolume() error = %v", err)
+	}
+	volumes, _ := f.ListVolumes(context.Background(), "token")
+	if len(volumes) != 1 || volumes[0].Status != "attached" || volumes[0].Location != "sim-2" {
+		t.Fatalf("volumes = %+v", volumes)
+	}
+
+	if err := f.DeleteServer(context.Background(), "token", ref, nil); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+	volumes, _ = f.ListVolumes(context.Background(), "token")
+	if len(volumes) != 1 || volumes[0].Status != "available" {
+		t.Fatalf("volumes = %+v, want the volume detached", volumes)
+	}
+	if err := f.DeleteVolume(context.Background(), "token", ref, "data", nil); err != nil {
+		t.Fatalf("DeleteVolume() error = %v", err)
+	}
+	if err := f.DeleteServer(context.Background(), "token", ref, nil); err != nil {
+		t.Fatalf("DeleteServer() of a deleted server error = %v", err)
+	}
+}
+
+func TestApplyFirewallsRejectsUnknownNames(t *testing.T) {
+	f := newTestFake()
+	if _, err := f.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", ServerType: "sim-small"}, nil); err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	err := f.ApplyFirewalls(context.Background(), "token", provider.ServerRef{Name: "web-1"}, []string{"web", "db"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown firewalls: db") {
+		t.Fatalf("ApplyFirewalls() error = %v", err)
+	}
+}
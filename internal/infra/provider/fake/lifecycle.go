@@ -0,0 +1,272 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"pressluft/internal/infra/provider"
+)
+
+const defaultPollInterval = 100 * time.Millisecond
+
+// CreateServer creates a server with the next free addresses. A server with
+// the same name is returned as is, so retried provisioning is idempotent.
+func (f *Fake) CreateServer(ctx context.Context, token string, spec provider.ServerSpec, report provider.ActionReporter) (*provider.CreatedServer, error) {
+	if strings.TrimSpace(spec.Name) == "" {
+		return nil, fmt.Errorf("server name is required")
+	}
+	if !knownServerType(spec.ServerType) {
+		return nil, fmt.Errorf("unknown server type %q", spec.ServerType)
+	}
+
+	f.mu.Lock()
+	if existing := f.findServer(provider.ServerRef{Name: spec.Name}); existing != nil {
+		created := &provider.CreatedServer{ID: existing.id, IPv4: existing.ipv4, IPv6: existing.ipv6}
+		f.mu.Unlock()
+		return created, nil
+	}
+	f.nextIP++
+	s := &server{
+		id:         f.newID(),
+		name:       spec.Name,
+		location:   spec.Location,
+		serverType: spec.ServerType,
+		image:      spec.Image,
+		ipv4:       fmt.Sprintf("198.18.%d.%d", f.nextIP/254, f.nextIP%254+1),
+		ipv6:       fmt.Sprintf("2001:db8:%x::/64", f.nextIP),
+	}
+	f.servers[s.id] = s
+	actions := []provider.Action{f.startAction("create_server"), f.startAction("start_server")}
+	f.mu.Unlock()
+
+	if err := f.wait(ctx, token, report, actions...); err != nil {
+		return nil, err
+	}
+	return &provider.CreatedServer{ID: s.id, IPv4: s.ipv4, IPv6: s.ipv6, ActionID: actions[0].ID}, nil
+}
+
+// DeleteServer deletes the server and detaches its volumes. A server that
+// is already gone is not an error.
+func (f *Fake) DeleteServer(ctx context.Context, token string, ref provider.ServerRef, report provider.ActionReporter) error {
+	f.mu.Lock()
+	s := f.findServer(ref)
+	if s == nil {
+		f.mu.Unlock()
+		return nil
+	}
+	delete(f.servers, s.id)
+	for _, v := range f.volumes {
+		if v.serverID == s.id {
+			v.serverID = ""
+		}
+	}
+	deleted := f.startAction("delete_server")
+	f.mu.Unlock()
+	return f.wait(ctx, token, report, deleted)
+}
+
+// RebuildServer reinstalls the server from image.
+func (f *Fake) RebuildServer(ctx context.Context, token string, ref provider.ServerRef, image string, report provider.ActionReporter) error {
+	f.mu.Lock()
+	s, err := f.mustFindServer(ref)
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	s.image = image
+	rebuilt := f.startAction("rebuild_server")
+	f.mu.Unlock()
+	return f.wait(ctx, token, report, rebuilt)
+}
+
+// ResizeServer powers the server off, changes its type and starts it again.
+func (f *Fake) ResizeServer(ctx context.Context, token string, ref provider.ServerRef, serverType string, upgradeDisk bool, report provider.ActionReporter) error {
+	if !knownServerType(serverType) {
+		return fmt.Errorf("unknown server type %q", serverType)
+	}
+	f.mu.Lock()
+	s, err := f.mustFindServer(ref)
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	s.serverType = serverType
+	actions := []provider.Action{f.startAction("stop_server"), f.startAction("change_server_type"), f.startAction("start_server")}
+	f.mu.Unlock()
+	return f.wait(ctx, token, report, actions...)
+}
+
+// ApplyFirewalls leaves the server in exactly the named firewalls, which
+// must all be in Firewalls.
+func (f *Fake) ApplyFirewalls(ctx context.Context, token string, ref provider.ServerRef, firewalls []string, report provider.ActionReporter) error {
+	var missing []string
+	for _, name := range firewalls {
+		if !slices.Contains(Firewalls, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("unknown firewalls: %s", strings.Join(missing, ", "))
+	}
+
+	f.mu.Lock()
+	s, err := f.mustFindServer(ref)
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	var actions []provider.Action
+	for _, name := range firewalls {
+		if !slices.Contains(s.firewalls, name) {
+			actions = append(actions, f.startAction("apply_firewall"))
+		}
+	}
+	for _, name := range s.firewalls {
+		if !slices.Contains(firewalls, name) {
+			actions = append(actions, f.startAction("remove_firewall"))
+		}
+	}
+	s.firewalls = slices.Clone(firewalls)
+	f.mu.Unlock()
+	return f.wait(ctx, token, report, actions...)
+}
+
+// AttachVolume creates the volume if needed, grows it to spec.SizeGB and
+// attaches it to the server.
+func (f *Fake) AttachVolume(ctx context.Context, token string, ref provider.ServerRef, spec provider.VolumeSpec, report provider.ActionReporter) error {
+	if strings.TrimSpace(spec.Name) == "" {
+		return fmt.Errorf("volume name is required")
+	}
+	f.mu.Lock()
+	s, err := f.mustFindServer(ref)
+	if err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	var actions []provider.Action
+	v := f.volumes[spec.Name]
+	switch {
+	case v == nil:
+		v = &volume{id: f.newID(), name: spec.Name, sizeGB: spec.SizeGB, location: s.location}
+		f.volumes[v.name] = v
+		actions = append(actions, f.startAction("create_volume"))
+	case v.serverID != "" && v.serverID != s.id:
+		f.mu.Unlock()
+		return fmt.Errorf("volume %s is attached to server %s", v.name, v.serverID)
+	case spec.SizeGB > v.sizeGB:
+		v.sizeGB = spec.SizeGB
+		actions = append(actions, f.startAction("resize_volume"))
+	}
+	if v.serverID != s.id {
+		v.serverID = s.id
+		actions = append(actions, f.startAction("attach_volume"))
+	}
+	f.mu.Unlock()
+	return f.wait(ctx, token, report, actions...)
+}
+
+// DeleteVolume detaches and deletes the named volume. A volume that is
+// already gone is not an error.
+func (f *Fake) DeleteVolume(ctx context.Context, token string, ref provider.ServerRef, name string, report provider.ActionReporter) error {
+	f.mu.Lock()
+	v := f.volumes[name]
+	if v == nil {
+		f.mu.Unlock()
+		return nil
+	}
+	var actions []provider.Action
+	if v.serverID != "" {
+		actions = append(actions, f.startAction("detach_volume"))
+	}
+	delete(f.volumes, name)
+	actions = append(actions, f.startAction("delete_volume"))
+	f.mu.Unlock()
+	return f.wait(ctx, token, report, actions...)
+}
+
+// PollAction reports how far an action has come. Progress grows linearly
+// over ActionDelay.
+func (f *Fake) PollAction(ctx context.Context, token string, current provider.Action) (provider.Action, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a := f.actions[current.ID]
+	if a == nil {
+		return provider.Action{}, fmt.Errorf("action %s not found", current.ID)
+	}
+	out := provider.Action{ID: current.ID, Command: a.command, Status: provider.ActionRunning}
+	elapsed := time.Since(a.started)
+	switch {
+	case elapsed < f.ActionDelay:
+		out.Progress = int(elapsed * 100 / f.ActionDelay)
+	case a.err != "":
+		out.Status = provider.ActionFailed
+		out.Error = a.err
+	default:
+		out.Status = provider.ActionSucceeded
+		out.Progress = 100
+	}
+	return out, nil
+}
+
+// startAction starts an action, failing it when a failure was injected for
+// its command. f.mu must be held.
+func (f *Fake) startAction(command string) provider.Action {
+	a := &action{command: command, started: time.Now()}
+	if queued := f.failures[command]; len(queued) > 0 {
+		a.err = queued[0]
+		f.failures[command] = queued[1:]
+	}
+	id := f.newID()
+	f.actions[id] = a
+	return provider.Action{ID: id, Command: command, Status: provider.ActionRunning}
+}
+
+func (f *Fake) wait(ctx context.Context, token string, report provider.ActionReporter, actions ...provider.Action) error {
+	interval := f.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return provider.WaitForActions(ctx, f, token, interval, report, actions...)
+}
+
+// newID returns the next ID shared by servers, volumes and actions. f.mu
+// must be held.
+func (f *Fake) newID() string {
+	f.nextID++
+	return strconv.FormatInt(f.nextID, 10)
+}
+
+// findServer looks a server up by ID, or by name when ref has no ID. f.mu
+// must be held.
+func (f *Fake) findServer(ref provider.ServerRef) *server {
+	if id := strings.TrimSpace(ref.ID); id != "" {
+		return f.servers[id]
+	}
+	for _, s := range f.servers {
+		if s.name == ref.Name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (f *Fake) mustFindServer(ref provider.ServerRef) (*server, error) {
+	s := f.findServer(ref)
+	if s == nil {
+		return nil, fmt.Errorf("server %s not found", ref)
+	}
+	return s, nil
+}
+
+func knownServerType(name string) bool {
+	for _, t := range serverTypes {
+		if t.name == name {
+			return true
+		}
+	}
+	return false
+}
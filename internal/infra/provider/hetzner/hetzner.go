@@ -4,6 +4,7 @@ package hetzner
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 
@@ -18,8 +19,14 @@ const (
 	docsURL      = "https://docs.hetzner.com/cloud/api/getting-started/generating-api-token"
 )
 
-// Hetzner implements provider.Provider.
-type Hetzner struct{}
+// Hetzner implements provider.Provider. The zero value talks to the public
+// API; tests point Endpoint at a fake.
+type Hetzner struct {
+	// Endpoint overrides the API root, e.g. "https://api.hetzner.cloud/v1".
+	Endpoint string
+	// PollInterval overrides how often provider actions are polled.
+	PollInterval time.Duration
+}
 
 func init() {
 	provider.Register(&Hetzner{})
@@ -36,14 +43,6 @@ func (h *Hetzner) Info() provider.Info {
 	}
 }
 
-func (h *Hetzner) SupportsProvisioningWorkflow() bool {
-	return true
-}
-
-func (h *Hetzner) SupportsServerMutationWorkflow() bool {
-	return true
-}
-
 // Validate checks whether the given API token is valid and has read-write
 // permissions by making a lightweight API call (list locations).
 func (h *Hetzner) Validate(ctx context.Context, token string) (*provider.ValidationResult, error) {
@@ -54,10 +53,7 @@ func (h *Hetzner) Validate(ctx context.Context, token string) (*provider.Validat
 		}, nil
 	}
 
-	client := hcloud.NewClient(
-		hcloud.WithToken(token),
-		hcloud.WithApplication("pressluft", "1.0.0"),
-	)
+	client := h.newClient(token)
 
 	// Use a lightweight read call to verify the token is valid.
 	_, _, err := client.Location.List(ctx, hcloud.LocationListOpts{
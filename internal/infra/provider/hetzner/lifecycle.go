@@ -0,0 +1,397 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"golang.org/x/crypto/ssh"
+
+	"pressluft/internal/infra/provider"
+)
+
+// defaultPollInterval is how often running actions are polled.
+const defaultPollInterval = 2 * time.Second
+
+func (h *Hetzner) pollInterval() time.Duration {
+	if h.PollInterval > 0 {
+		return h.PollInterval
+	}
+	return defaultPollInterval
+}
+
+// CreateServer creates the server and waits until it is running. A server
+// with the same name is returned as is, so a retried job picks up the
+// server its first attempt created.
+func (h *Hetzner) CreateServer(ctx context.Context, token string, spec provider.ServerSpec, report provider.ActionReporter) (*provider.CreatedServer, error) {
+	client := h.newClient(token)
+	existing, _, err := client.Server.GetByName(ctx, spec.Name)
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	if existing != nil {
+		return createdServer(existing, ""), nil
+	}
+
+	key, err := ensureSSHKey(ctx, client, spec.SSHKeyName, spec.SSHPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := client.Server.Create(ctx, hcloud.ServerCreateOpts{
+		Name:       spec.Name,
+		ServerType: &hcloud.ServerType{Name: spec.ServerType},
+		Image:      imageRef(spec.Image),
+		Location:   &hcloud.Location{Name: spec.Location},
+		SSHKeys:    []*hcloud.SSHKey{key},
+	})
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	if err := h.wait(ctx, token, report, append([]*hcloud.Action{result.Action}, result.NextActions...)...); err != nil {
+		return nil, err
+	}
+
+	server, _, err := client.Server.GetByID(ctx, result.Server.ID)
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server %d disappeared after it was created", result.Server.ID)
+	}
+	actionID := ""
+	if result.Action != nil {
+		actionID = strconv.FormatInt(result.Action.ID, 10)
+	}
+	return createdServer(server, actionID), nil
+}
+
+// DeleteServer deletes the server. A server that no longer exists counts
+// as deleted.
+func (h *Hetzner) DeleteServer(ctx context.Context, token string, ref provider.ServerRef, report provider.ActionReporter) error {
+	client := h.newClient(token)
+	server, err := findServer(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	if server == nil {
+		return nil
+	}
+	result, _, err := client.Server.DeleteWithResult(ctx, server)
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	return h.wait(ctx, token, report, result.Action)
+}
+
+// RebuildServer reinstalls the server from image, which may be an image
+// name or ID.
+func (h *Hetzner) RebuildServer(ctx context.Context, token string, ref provider.ServerRef, image string, report provider.ActionReporter) error {
+	client := h.newClient(token)
+	server, err := mustFindServer(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	result, _, err := client.Server.RebuildWithResult(ctx, server, hcloud.ServerRebuildOpts{Image: imageRef(image)})
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	return h.wait(ctx, token, report, result.Action)
+}
+
+// ResizeServer powers the server off, changes its type and powers it on
+// again; Hetzner only changes the type of stopped servers.
+func (h *Hetzner) ResizeServer(ctx context.Context, token string, ref provider.ServerRef, serverType string, upgradeDisk bool, report provider.ActionReporter) error {
+	client := h.newClient(token)
+	server, err := mustFindServer(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	if server.Status != hcloud.ServerStatusOff {
+		action, _, err := client.Server.Poweroff(ctx, server)
+		if err != nil {
+			return mapHetznerAPIError(err)
+		}
+		if err := h.wait(ctx, token, report, action); err != nil {
+			return err
+		}
+	}
+	action, _, err := client.Server.ChangeType(ctx, server, hcloud.ServerChangeTypeOpts{
+		ServerType:  &hcloud.ServerType{Name: serverType},
+		UpgradeDisk: upgradeDisk,
+	})
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	if err := h.wait(ctx, token, report, action); err != nil {
+		return err
+	}
+	action, _, err = client.Server.Poweron(ctx, server)
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	return h.wait(ctx, token, report, action)
+}
+
+// ApplyFirewalls applies the named firewalls to the server and removes it
+// from every other firewall.
+func (h *Hetzner) ApplyFirewalls(ctx context.Context, token string, ref provider.ServerRef, firewalls []string, report provider.ActionReporter) error {
+	client := h.newClient(token)
+	server, err := mustFindServer(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	all, err := client.Firewall.All(ctx)
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	var unknown []string
+	for _, name := range firewalls {
+		if !slices.ContainsFunc(all, func(fw *hcloud.Firewall) bool { return fw.Name == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown firewalls: %s", strings.Join(unknown, ", "))
+	}
+
+	resource := []hcloud.FirewallResource{{Type: hcloud.FirewallResourceTypeServer, Server: &hcloud.FirewallResourceServer{ID: server.ID}}}
+	for _, fw := range all {
+		wanted := slices.Contains(firewalls, fw.Name)
+		applied := firewallAppliesTo(fw, server.ID)
+		var actions []*hcloud.Action
+		switch {
+		case wanted && !applied:
+			actions, _, err = client.Firewall.ApplyResources(ctx, fw, resource)
+		case !wanted && applied:
+			actions, _, err = client.Firewall.RemoveResources(ctx, fw, resource)
+		default:
+			continue
+		}
+		if err != nil {
+			return mapHetznerAPIError(err)
+		}
+		if err := h.wait(ctx, token, report, actions...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachVolume creates the volume next to the server if it does not exist,
+// grows it to the requested size and attaches it to the server.
+func (h *Hetzner) AttachVolume(ctx context.Context, token string, ref provider.ServerRef, spec provider.VolumeSpec, report provider.ActionReporter) error {
+	client := h.newClient(token)
+	server, err := mustFindServer(ctx, client, ref)
+	if err != nil {
+		return err
+	}
+	volume, _, err := client.Volume.GetByName(ctx, spec.Name)
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	if volume == nil {
+		opts := hcloud.VolumeCreateOpts{
+			Name:      spec.Name,
+			Size:      spec.SizeGB,
+			Server:    server,
+			Automount: hcloud.Ptr(spec.Automount),
+		}
+		if spec.Automount {
+			// Automounting needs a file system to mount.
+			opts.Format = hcloud.Ptr("ext4")
+		}
+		result, _, err := client.Volume.Create(ctx, opts)
+		if err != nil {
+			return mapHetznerAPIError(err)
+		}
+		return h.wait(ctx, token, report, append([]*hcloud.Action{result.Action}, result.NextActions...)...)
+	}
+
+	if spec.SizeGB > volume.Size {
+		action, _, err := client.Volume.Resize(ctx, volume, spec.SizeGB)
+		if err != nil {
+			return mapHetznerAPIError(err)
+		}
+		if err := h.wait(ctx, token, report, action); err != nil {
+			return err
+		}
+	}
+	if volume.Server != nil && volume.Server.ID == server.ID {
+		return nil
+	}
+	if volume.Server != nil {
+		action, _, err := client.Volume.Detach(ctx, volume)
+		if err != nil {
+			return mapHetznerAPIError(err)
+		}
+		if err := h.wait(ctx, token, report, action); err != nil {
+			return err
+		}
+	}
+	action, _, err := client.Volume.AttachWithOpts(ctx, volume, hcloud.VolumeAttachOpts{Server: server, Automount: hcloud.Ptr(spec.Automount)})
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	return h.wait(ctx, token, report, action)
+}
+
+// DeleteVolume detaches the named volume and deletes it. A volume that no
+// longer exists counts as deleted.
+func (h *Hetzner) DeleteVolume(ctx context.Context, token string, ref provider.ServerRef, name string, report provider.ActionReporter) error {
+	client := h.newClient(token)
+	volume, _, err := client.Volume.GetByName(ctx, name)
+	if err != nil {
+		return mapHetznerAPIError(err)
+	}
+	if volume == nil {
+		return nil
+	}
+	if volume.Server != nil {
+		action, _, err := client.Volume.Detach(ctx, volume)
+		if err != nil {
+			return mapHetznerAPIError(err)
+		}
+		if err := h.wait(ctx, token, report, action); err != nil {
+			return err
+		}
+	}
+	if _, err := client.Volume.Delete(ctx, volume); err != nil {
+		return mapHetznerAPIError(err)
+	}
+	return nil
+}
+
+// PollAction returns the current state of a Hetzner action.
+func (h *Hetzner) PollAction(ctx context.Context, token string, action provider.Action) (provider.Action, error) {
+	id, err := strconv.ParseInt(action.ID, 10, 64)
+	if err != nil {
+		return action, fmt.Errorf("invalid Hetzner action id %q", action.ID)
+	}
+	current, _, err := h.newClient(token).Action.GetByID(ctx, id)
+	if err != nil {
+		return action, mapHetznerAPIError(err)
+	}
+	if current == nil {
+		return action, fmt.Errorf("hetzner action %d not found", id)
+	}
+	return toAction(current), nil
+}
+
+func (h *Hetzner) wait(ctx context.Context, token string, report provider.ActionReporter, actions ...*hcloud.Action) error {
+	converted := make([]provider.Action, 0, len(actions))
+	for _, action := range actions {
+		if action != nil {
+			converted = append(converted, toAction(action))
+		}
+	}
+	return provider.WaitForActions(ctx, h, token, h.pollInterval(), report, converted...)
+}
+
+func toAction(action *hcloud.Action) provider.Action {
+	out := provider.Action{
+		ID:       strconv.FormatInt(action.ID, 10),
+		Command:  action.Command,
+		Progress: action.Progress,
+		Error:    action.ErrorMessage,
+	}
+	switch action.Status {
+	case hcloud.ActionStatusSuccess:
+		out.Status = provider.ActionSucceeded
+	case hcloud.ActionStatusError:
+		out.Status = provider.ActionFailed
+	default:
+		out.Status = provider.ActionRunning
+	}
+	return out
+}
+
+// findServer looks the server up by ID, or by name when no ID is known. It
+// returns nil when there is no such server.
+func findServer(ctx context.Context, client *hcloud.Client, ref provider.ServerRef) (*hcloud.Server, error) {
+	var (
+		server *hcloud.Server
+		err    error
+	)
+	if id, parseErr := strconv.ParseInt(strings.TrimSpace(ref.ID), 10, 64); parseErr == nil {
+		server, _, err = client.Server.GetByID(ctx, id)
+	} else {
+		server, _, err = client.Server.GetByName(ctx, ref.Name)
+	}
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	return server, nil
+}
+
+func mustFindServer(ctx context.Context, client *hcloud.Client, ref provider.ServerRef) (*hcloud.Server, error) {
+	server, err := findServer(ctx, client, ref)
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fmt.Errorf("hetzner server %s not found", ref)
+	}
+	return server, nil
+}
+
+// ensureSSHKey returns the project key with the given name or public key,
+// uploading it when the project has neither.
+func ensureSSHKey(ctx context.Context, client *hcloud.Client, name, publicKey string) (*hcloud.SSHKey, error) {
+	key, _, err := client.SSHKey.GetByName(ctx, name)
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	if key != nil {
+		return key, nil
+	}
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("parse SSH public key: %w", err)
+	}
+	key, _, err = client.SSHKey.GetByFingerprint(ctx, ssh.FingerprintLegacyMD5(parsed))
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	if key != nil {
+		return key, nil
+	}
+	key, _, err = client.SSHKey.Create(ctx, hcloud.SSHKeyCreateOpts{Name: name, PublicKey: strings.TrimSpace(publicKey)})
+	if err != nil {
+		return nil, mapHetznerAPIError(err)
+	}
+	return key, nil
+}
+
+// imageRef refers to an image by ID when image is numeric and by name
+// otherwise.
+func imageRef(image string) *hcloud.Image {
+	image = strings.TrimSpace(image)
+	if id, err := strconv.ParseInt(image, 10, 64); err == nil {
+		return &hcloud.Image{ID: id}
+	}
+	return &hcloud.Image{Name: image}
+}
+
+func firewallAppliesTo(fw *hcloud.Firewall, serverID int64) bool {
+	for _, resource := range fw.AppliedTo {
+		if resource.Type == hcloud.FirewallResourceTypeServer && resource.Server != nil && resource.Server.ID == serverID {
+			return true
+		}
+	}
+	return false
+}
+
+func createdServer(server *hcloud.Server, actionID string) *provider.CreatedServer {
+	out := &provider.CreatedServer{ID: strconv.FormatInt(server.ID, 10), ActionID: actionID}
+	if ip := server.PublicNet.IPv4.IP; ip != nil && !ip.IsUnspecified() {
+		out.IPv4 = ip.String()
+	}
+	if network := server.PublicNet.IPv6.Network; network != nil {
+		out.IPv6 = network.String()
+	}
+	return out
+}
@@ -0,0 +1,284 @@
+package hetzner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+
+	"pressluft/internal/infra/provider"
+)
+
+const testPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJ1nRz5vAZ0cK7xp1o2rT0QwQXxF0d7Q6u3N2b7mP9yR pressluft-test"
+
+// fakeHcloud is just enough of the Hetzner Cloud API for the lifecycle.
+// Actions start running and finish on their first poll.
+type fakeHcloud struct {
+	mu        sync.Mutex
+	nextID    int64
+	servers   map[int64]*schema.Server
+	actions   map[int64]*schema.Action
+	firewalls []*schema.Firewall
+	keys      []schema.SSHKey
+	// failCommand makes actions with this command fail when polled.
+	failCommand string
+	calls       []string
+}
+
+func newFakeHcloud(t *testing.T) (*fakeHcloud, *Hetzner) {
+	t.Helper()
+	f := &fakeHcloud{nextID: 100, servers: map[int64]*schema.Server{}, actions: map[int64]*schema.Action{}}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, &Hetzner{Endpoint: srv.URL, PollInterval: time.Millisecond}
+}
+
+func (f *fakeHcloud) addServer(name, status string) *schema.Server {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.newServer(name, status)
+}
+
+func (f *fakeHcloud) newServer(name, status string) *schema.Server {
+	f.nextID++
+	server := &schema.Server{ID: f.nextID, Name: name, Status: status}
+	server.PublicNet.IPv4.IP = "203.0.113.10"
+	server.PublicNet.IPv6.IP = "2001:db8::/64"
+	f.servers[server.ID] = server
+	return server
+}
+
+func (f *fakeHcloud) newAction(command string) schema.Action {
+	f.nextID++
+	action := &schema.Action{ID: f.nextID, Command: command, Status: "running"}
+	f.actions[action.ID] = action
+	return *action
+}
+
+func (f *fakeHcloud) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if r.Method != http.MethodGet || parts[0] != "actions" {
+		f.calls = append(f.calls, r.Method+" /"+path)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "servers":
+		var out schema.ServerListResponse
+		for _, server := range f.servers {
+			if server.Name == r.URL.Query().Get("name") {
+				out.Servers = append(out.Servers, *server)
+			}
+		}
+		writeJSON(w, out)
+	case r.Method == http.MethodPost && path == "servers":
+		var body struct {
+			Name string `json:"name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		server := f.newServer(body.Name, "initializing")
+		writeJSON(w, schema.ServerCreateResponse{
+			Server:      *server,
+			Action:      f.newAction("create_server"),
+			NextActions: []schema.Action{f.newAction("start_server")},
+		})
+	case parts[0] == "servers" && len(parts) == 2:
+		server := f.serverByID(parts[1])
+		if server == nil {
+			writeError(w, http.StatusNotFound, "not_found")
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.servers, server.ID)
+			writeJSON(w, schema.ServerDeleteResponse{Action: f.newAction("delete_server")})
+			return
+		}
+		writeJSON(w, schema.ServerGetResponse{Server: *server})
+	case r.Method == http.MethodPost && parts[0] == "servers" && len(parts) == 4:
+		command := map[string]string{"poweroff": "stop_server", "poweron": "start_server", "rebuild": "rebuild_server", "change_type": "change_server_type"}[parts[3]]
+		writeJSON(w, map[string]any{"action": f.newAction(command)})
+	case r.Method == http.MethodGet && parts[0] == "actions" && len(parts) == 2:
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+		action := f.actions[id]
+		if action.Command == f.failCommand {
+			action.Status = "error"
+			action.Error = &schema.ActionError{Code: "action_failed", Message: "Action failed"}
+		} else {
+			action.Status = "success"
+			action.Progress = 100
+		}
+		writeJSON(w, schema.ActionGetResponse{Action: *action})
+	case r.Method == http.MethodGet && path == "ssh_keys":
+		var out schema.SSHKeyListResponse
+		for _, key := range f.keys {
+			if key.Name == r.URL.Query().Get("name") || key.Fingerprint == r.URL.Query().Get("fingerprint") {
+				out.SSHKeys = append(out.SSHKeys, key)
+			}
+		}
+		writeJSON(w, out)
+	case r.Method == http.MethodPost && path == "ssh_keys":
+		var body schema.SSHKey
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.nextID++
+		body.ID = f.nextID
+		f.keys = append(f.keys, body)
+		writeJSON(w, schema.SSHKeyCreateResponse{SSHKey: body})
+	case r.Method == http.MethodGet && path == "firewalls":
+		var out schema.FirewallListResponse
+		for _, fw := range f.firewalls {
+			out.Firewalls = append(out.Firewalls, *fw)
+		}
+		writeJSON(w, out)
+	case r.Method == http.MethodPost && parts[0] == "firewalls" && len(parts) == 4:
+		var body struct {
+			ApplyTo    []schema.FirewallResource `json:"apply_to"`
+			RemoveFrom []schema.FirewallResource `json:"remove_from"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+		for _, fw := range f.firewalls {
+			if fw.ID != id {
+				continue
+			}
+			fw.AppliedTo = append(fw.AppliedTo, body.ApplyTo...)
+			for _, removed := range body.RemoveFrom {
+				for i, resource := range fw.AppliedTo {
+					if resource.Server != nil && resource.Server.ID == removed.Server.ID {
+						fw.AppliedTo = append(fw.AppliedTo[:i], fw.AppliedTo[i+1:]...)
+						break
+					}
+				}
+			}
+		}
+		writeJSON(w, schema.FirewallActionApplyToResourcesResponse{Actions: []schema.Action{f.newAction(parts[3])}})
+	default:
+		writeError(w, http.StatusNotFound, "not_found")
+	}
+}
+
+func (f *fakeHcloud) serverByID(raw string) *schema.Server {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return f.servers[id]
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"code": code, "message": code}})
+}
+
+func TestCreateServerUploadsKeyAndWaitsForActions(t *testing.T) {
+	api, h := newFakeHcloud(t)
+
+	var reported []provider.Action
+	created, err := h.CreateServer(context.Background(), "token", provider.ServerSpec{
+		Name:         "web-1",
+		Location:     "fsn1",
+		ServerType:   "cx22",
+		Image:        "ubuntu-24.04",
+		SSHKeyName:   "pressluft-web-1",
+		SSHPublicKey: testPublicKey,
+	}, func(a provider.Action) { reported = append(reported, a) })
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if created.IPv4 != "203.0.113.10" || created.IPv6 != "2001:db8::/64" || created.ActionID == "" {
+		t.Fatalf("created = %+v", created)
+	}
+	if len(api.keys) != 1 || api.keys[0].Name != "pressluft-web-1" {
+		t.Fatalf("keys = %+v, want the server key uploaded", api.keys)
+	}
+	// Both the create action and its follow-up are reported running and done.
+	if len(reported) != 4 || reported[0].Command != "create_server" || reported[3].Status != provider.ActionSucceeded {
+		t.Fatalf("reported = %+v", reported)
+	}
+}
+
+func TestCreateServerReturnsExistingServer(t *testing.T) {
+	api, h := newFakeHcloud(t)
+	existing := api.addServer("web-1", "running")
+
+	created, err := h.CreateServer(context.Background(), "token", provider.ServerSpec{Name: "web-1", SSHKeyName: "k", SSHPublicKey: testPublicKey}, nil)
+	if err != nil {
+		t.Fatalf("CreateServer() error = %v", err)
+	}
+	if created.ID != strconv.FormatInt(existing.ID, 10) {
+		t.Fatalf("created ID = %q, want %d", created.ID, existing.ID)
+	}
+	if len(api.calls) != 1 {
+		t.Fatalf("calls = %q, want only the lookup", api.calls)
+	}
+}
+
+func TestResizeServerPowersOffFirst(t *testing.T) {
+	api, h := newFakeHcloud(t)
+	server := api.addServer("web-1", "running")
+	ref := provider.ServerRef{ID: strconv.FormatInt(server.ID, 10)}
+
+	if err := h.ResizeServer(context.Background(), "token", ref, "cx32", false, nil); err != nil {
+		t.Fatalf("ResizeServer() error = %v", err)
+	}
+	prefix := "POST /servers/" + ref.ID + "/actions/"
+	want := []string{"GET /servers/" + ref.ID, prefix + "poweroff", prefix + "change_type", prefix + "poweron"}
+	if strings.Join(api.calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("calls = %q, want %q", api.calls, want)
+	}
+}
+
+func TestApplyFirewallsSyncsMembership(t *testing.T) {
+	api, h := newFakeHcloud(t)
+	server := api.addServer("web-1", "running")
+	member := []schema.FirewallResource{{Type: "server", Server: &schema.FirewallResourceServer{ID: server.ID}}}
+	api.firewalls = []*schema.Firewall{
+		{ID: 1, Name: "web"},
+		{ID: 2, Name: "legacy", AppliedTo: member},
+	}
+	ref := provider.ServerRef{ID: strconv.FormatInt(server.ID, 10)}
+
+	if err := h.ApplyFirewalls(context.Background(), "token", ref, []string{"web"}, nil); err != nil {
+		t.Fatalf("ApplyFirewalls() error = %v", err)
+	}
+	if len(api.firewalls[0].AppliedTo) != 1 || len(api.firewalls[1].AppliedTo) != 0 {
+		t.Fatalf("firewalls = %+v, want the server only in web", api.firewalls)
+	}
+
+	err := h.ApplyFirewalls(context.Background(), "token", ref, []string{"web", "missing"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown firewalls: missing") {
+		t.Fatalf("ApplyFirewalls() error = %v, want unknown firewall", err)
+	}
+}
+
+func TestDeleteServerIgnoresMissingServer(t *testing.T) {
+	_, h := newFakeHcloud(t)
+	if err := h.DeleteServer(context.Background(), "token", provider.ServerRef{Name: "gone"}, nil); err != nil {
+		t.Fatalf("DeleteServer() error = %v", err)
+	}
+}
+
+func TestRebuildServerReportsFailedAction(t *testing.T) {
+	api, h := newFakeHcloud(t)
+	server := api.addServer("web-1", "running")
+	api.failCommand = "rebuild_server"
+
+	err := h.RebuildServer(context.Background(), "token", provider.ServerRef{Name: server.Name}, "ubuntu-24.04", nil)
+	if err == nil || !strings.Contains(err.Error(), "rebuild_server action") || !strings.Contains(err.Error(), "Action failed") {
+		t.Fatalf("RebuildServer() error = %v, want the failed action", err)
+	}
+}
@@ -21,7 +21,7 @@ func (h *Hetzner) ListServerImages(ctx context.Context, token, architecture stri
 		return nil, fmt.Errorf("architecture is required")
 	}
 
-	client := newClient(token)
+	client := h.newClient(token)
 	images, err := client.Image.AllWithOpts(ctx, hcloud.ImageListOpts{
 		ListOpts:          hcloud.ListOpts{PerPage: 50},
 		Architecture:      []hcloud.Architecture{hcloud.Architecture(arch)},
@@ -68,7 +68,7 @@ func (h *Hetzner) ListFirewalls(ctx context.Context, token string) ([]provider.F
 		return nil, fmt.Errorf("api token must not be empty")
 	}
 
-	client := newClient(token)
+	client := h.newClient(token)
 	firewalls, err := client.Firewall.All(ctx)
 	if err != nil {
 		return nil, mapHetznerAPIError(err)
@@ -98,7 +98,7 @@ func (h *Hetzner) ListVolumes(ctx context.Context, token string) ([]provider.Vol
 		return nil, fmt.Errorf("api token must not be empty")
 	}
 
-	client := newClient(token)
+	client := h.newClient(token)
 	volumes, err := client.Volume.All(ctx)
 	if err != nil {
 		return nil, mapHetznerAPIError(err)
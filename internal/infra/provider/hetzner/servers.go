@@ -16,7 +16,7 @@ func (h *Hetzner) ListServerCatalog(ctx context.Context, token string) (*provide
 		return nil, fmt.Errorf("api token must not be empty")
 	}
 
-	client := newClient(token)
+	client := h.newClient(token)
 
 	// Fetch datacenters - this is the authoritative source for availability.
 	// dc.ServerTypes.Available contains only server types that can be created NOW.
@@ -126,11 +126,15 @@ func (h *Hetzner) ListServerCatalog(ctx context.Context, token string) (*provide
 	return catalog, nil
 }
 
-func newClient(token string) *hcloud.Client {
-	return hcloud.NewClient(
+func (h *Hetzner) newClient(token string) *hcloud.Client {
+	opts := []hcloud.ClientOption{
 		hcloud.WithToken(token),
 		hcloud.WithApplication("pressluft", "1.0.0"),
-	)
+	}
+	if endpoint := strings.TrimSpace(h.Endpoint); endpoint != "" {
+		opts = append(opts, hcloud.WithEndpoint(endpoint))
+	}
+	return hcloud.NewClient(opts...)
 }
 
 func mapHetznerAPIError(err error) error {
@@ -36,16 +36,6 @@ type Provider interface {
 	Validate(ctx context.Context, token string) (*ValidationResult, error)
 }
 
-type ProvisioningWorkflowProvider interface {
-	Provider
-	SupportsProvisioningWorkflow() bool
-}
-
-type ServerMutationWorkflowProvider interface {
-	Provider
-	SupportsServerMutationWorkflow() bool
-}
-
 // registry holds all registered provider implementations keyed by type.
 var registry = map[string]Provider{}
 
@@ -72,24 +62,51 @@ func All() []Info {
 	return out
 }
 
-func SupportsProvisioningWorkflow(providerType string) bool {
+// ExistingServerProvider is implemented by providers whose servers already
+// exist outside Pressluft. Such providers have no cloud API: servers are
+// adopted over SSH instead of provisioned, and deleting one only stops
+// managing it.
+type ExistingServerProvider interface {
+	Provider
+	AdoptsExistingServers() bool
+}
+
+// AdoptsExistingServers reports whether servers of providerType are adopted
+// over SSH rather than created through a provider API.
+func AdoptsExistingServers(providerType string) bool {
 	p := Get(providerType)
 	if p == nil {
 		return false
 	}
-	if workflowProvider, ok := p.(ProvisioningWorkflowProvider); ok {
-		return workflowProvider.SupportsProvisioningWorkflow()
+	if existing, ok := p.(ExistingServerProvider); ok {
+		return existing.AdoptsExistingServers()
 	}
 	return false
 }
 
-func SupportsServerMutationWorkflow(providerType string) bool {
+// RequiresAPIToken reports whether a provider of providerType must be saved
+// with an API token. Unknown types require one.
+func RequiresAPIToken(providerType string) bool {
+	return !AdoptsExistingServers(providerType)
+}
+
+// SimulatedProvider is implemented by providers whose servers exist only in
+// memory, for end-to-end tests and demos. Nothing can reach such servers
+// over SSH or HTTPS, so work on them is simulated rather than run.
+type SimulatedProvider interface {
+	Provider
+	SimulatesServers() bool
+}
+
+// SimulatesServers reports whether servers of providerType exist only in a
+// simulated provider.
+func SimulatesServers(providerType string) bool {
 	p := Get(providerType)
 	if p == nil {
 		return false
 	}
-	if workflowProvider, ok := p.(ServerMutationWorkflowProvider); ok {
-		return workflowProvider.SupportsServerMutationWorkflow()
+	if simulated, ok := p.(SimulatedProvider); ok {
+		return simulated.SimulatesServers()
 	}
 	return false
 }
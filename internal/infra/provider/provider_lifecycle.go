@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ServerSpec describes a server to create.
+type ServerSpec struct {
+	Name       string
+	Location   string
+	ServerType string
+	// Image is the base image in server profile form, e.g. "ubuntu-24.04".
+	// Providers translate it to their own image names.
+	Image string
+	// SSHKeyName and SSHPublicKey are the key installed for the root user.
+	SSHKeyName   string
+	SSHPublicKey string
+}
+
+// ServerRef identifies a server at the provider. ID is the provider's own
+// server ID; Name is used when no ID was recorded.
+type ServerRef struct {
+	ID   string
+	Name string
+}
+
+// String returns the ID when known and the name otherwise.
+func (r ServerRef) String() string {
+	if strings.TrimSpace(r.ID) != "" {
+		return r.ID
+	}
+	return r.Name
+}
+
+// CreatedServer is a server the provider has finished creating.
+type CreatedServer struct {
+	ID   string
+	IPv4 string
+	IPv6 string
+	// ActionID is the provider action that created the server, if the
+	// provider reports one.
+	ActionID string
+}
+
+// VolumeSpec describes a volume to create and attach.
+type VolumeSpec struct {
+	Name   string
+	SizeGB int
+	// Location is only used by providers that create volumes apart from
+	// the server they attach to.
+	Location  string
+	Automount bool
+}
+
+// ActionStatus is where a provider action stands.
+type ActionStatus string
+
+const (
+	ActionRunning   ActionStatus = "running"
+	ActionSucceeded ActionStatus = "succeeded"
+	ActionFailed    ActionStatus = "failed"
+)
+
+// Action is one asynchronous operation at the provider, such as creating a
+// server or powering it off.
+type Action struct {
+	ID       string       `json:"id"`
+	Command  string       `json:"command"`
+	Status   ActionStatus `json:"status"`
+	Progress int          `json:"progress"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// Done reports whether the action has finished either way.
+func (a Action) Done() bool {
+	return a.Status == ActionSucceeded || a.Status == ActionFailed
+}
+
+// ActionReporter is told when a lifecycle call starts a provider action and
+// whenever polling finds it changed. A nil reporter is allowed.
+type ActionReporter func(Action)
+
+// ServerLifecycle is implemented by providers that create and change
+// servers through their API. Every call returns once the provider has
+// finished the work, reporting each action it waited for on the way.
+type ServerLifecycle interface {
+	Provider
+	CreateServer(ctx context.Context, token string, spec ServerSpec, report ActionReporter) (*CreatedServer, error)
+	DeleteServer(ctx context.Context, token string, ref ServerRef, report ActionReporter) error
+	RebuildServer(ctx context.Context, token string, ref ServerRef, image string, report ActionReporter) error
+	ResizeServer(ctx context.Context, token string, ref ServerRef, serverType string, upgradeDisk bool, report ActionReporter) error
+	// ApplyFirewalls leaves the server in exactly the named firewalls.
+	ApplyFirewalls(ctx context.Context, token string, ref ServerRef, firewalls []string, report ActionReporter) error
+	// AttachVolume creates the volume if it does not exist and attaches it.
+	AttachVolume(ctx context.Context, token string, ref ServerRef, spec VolumeSpec, report ActionReporter) error
+	// DeleteVolume detaches the named volume and deletes it.
+	DeleteVolume(ctx context.Context, token string, ref ServerRef, name string, report ActionReporter) error
+	// PollAction returns the current state of an action.
+	PollAction(ctx context.Context, token string, action Action) (Action, error)
+}
+
+// GetServerLifecycle returns a provider that manages servers natively.
+func GetServerLifecycle(providerType string) (ServerLifecycle, bool) {
+	p := Get(providerType)
+	if p == nil {
+		return nil, false
+	}
+	lc, ok := p.(ServerLifecycle)
+	return lc, ok
+}
+
+// WaitForActions polls lc every interval until all actions are done. It
+// reports each action when it starts waiting and again whenever its status
+// or progress changes, and fails on the first action that failed.
+func WaitForActions(ctx context.Context, lc ServerLifecycle, token string, interval time.Duration, report ActionReporter, actions ...Action) error {
+	if report == nil {
+		report = func(Action) {}
+	}
+	for _, action := range actions {
+		report(action)
+		for !action.Done() {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for %s action %s: %w", action.Command, action.ID, ctx.Err())
+			case <-time.After(interval):
+			}
+			next, err := lc.PollAction(ctx, token, action)
+			if err != nil {
+				return err
+			}
+			if next.Command == "" {
+				next.Command = action.Command
+			}
+			if next.Status != action.Status || next.Progress != action.Progress {
+				report(next)
+			}
+			action = next
+		}
+		if action.Status == ActionFailed {
+			if action.Error != "" {
+				return fmt.Errorf("%s action %s failed: %s", action.Command, action.ID, action.Error)
+			}
+			return fmt.Errorf("%s action %s failed", action.Command, action.ID)
+		}
+	}
+	return nil
+}
@@ -13,12 +13,15 @@ type ServerImageOption struct {
 }
 
 // FirewallOption describes a firewall that can be attached to servers.
+// Workflows address firewalls by name; ID is zero for providers whose IDs
+// are not numeric.
 type FirewallOption struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
 }
 
 // VolumeOption describes a storage volume available to attach or manage.
+// Like firewalls, volumes are addressed by name and ID may be zero.
 type VolumeOption struct {
 	ID       int64  `json:"id"`
 	Name     string `json:"name"`
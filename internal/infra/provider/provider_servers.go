@@ -38,11 +38,28 @@ type ServerCatalog struct {
 	ServerTypes []ServerTypeOption `json:"server_types"`
 }
 
+// Price returns the list price of serverType in location.
+func (c *ServerCatalog) Price(serverType, location string) (ServerTypePrice, bool) {
+	if c == nil {
+		return ServerTypePrice{}, false
+	}
+	for _, option := range c.ServerTypes {
+		if option.Name != serverType {
+			continue
+		}
+		for _, price := range option.Prices {
+			if price.LocationName == location {
+				return price, true
+			}
+		}
+	}
+	return ServerTypePrice{}, false
+}
+
 // ServerProvider is implemented by providers that expose a catalog of
 // available server locations and types for the provisioning UI.
-// Actual server lifecycle operations (create, delete, rebuild, resize) are
-// handled by provider-specific Ansible playbooks under
-// ops/ansible/playbooks/<provider-type>/.
+// Server lifecycle operations (create, delete, rebuild, resize) are
+// provided by ServerLifecycle.
 type ServerProvider interface {
 	Provider
 	ListServerCatalog(ctx context.Context, token string) (*ServerCatalog, error)
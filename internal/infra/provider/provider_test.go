@@ -1,20 +1,95 @@
 package provider_test
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"pressluft/internal/infra/provider"
+	_ "pressluft/internal/infra/provider/custom"
+	_ "pressluft/internal/infra/provider/digitalocean"
+	"pressluft/internal/infra/provider/fake"
 	_ "pressluft/internal/infra/provider/hetzner"
 )
 
-func TestWorkflowCapabilities(t *testing.T) {
-	if !provider.SupportsProvisioningWorkflow("hetzner") {
-		t.Fatal("expected hetzner to support provisioning workflow")
+func TestServerLifecycleCapabilities(t *testing.T) {
+	for _, providerType := range []string{"hetzner", "digitalocean"} {
+		if _, ok := provider.GetServerLifecycle(providerType); !ok {
+			t.Fatalf("expected %s to manage servers natively", providerType)
+		}
 	}
-	if !provider.SupportsServerMutationWorkflow("hetzner") {
-		t.Fatal("expected hetzner to support server mutation workflows")
+	if _, ok := provider.GetServerLifecycle("unknown"); ok {
+		t.Fatal("expected unknown provider type to have no server lifecycle")
 	}
-	if provider.SupportsProvisioningWorkflow("unknown") {
-		t.Fatal("expected unknown provider type to reject provisioning workflow")
+	if _, ok := provider.GetServerLifecycle("custom"); ok {
+		t.Fatal("expected custom provider to have no server lifecycle")
+	}
+}
+
+func TestSimulatesServers(t *testing.T) {
+	fake.Register()
+	if !provider.SimulatesServers("fake") {
+		t.Fatal("expected the fake provider to simulate its servers")
+	}
+	for _, providerType := range []string{"hetzner", "digitalocean", "custom", "unknown"} {
+		if provider.SimulatesServers(providerType) {
+			t.Fatalf("expected %s servers to be real", providerType)
+		}
+	}
+}
+
+// scriptedLifecycle answers PollAction with the next scripted state.
+type scriptedLifecycle struct {
+	provider.ServerLifecycle
+	polls []provider.Action
+}
+
+func (s *scriptedLifecycle) PollAction(context.Context, string, provider.Action) (provider.Action, error) {
+	next := s.polls[0]
+	s.polls = s.polls[1:]
+	return next, nil
+}
+
+func TestWaitForActionsReportsProgressUntilDone(t *testing.T) {
+	lc := &scriptedLifecycle{polls: []provider.Action{
+		{ID: "1", Status: provider.ActionRunning, Progress: 0},
+		{ID: "1", Status: provider.ActionRunning, Progress: 50},
+		{ID: "1", Status: provider.ActionSucceeded, Progress: 100},
+	}}
+	var reported []provider.Action
+	err := provider.WaitForActions(context.Background(), lc, "token", time.Millisecond, func(a provider.Action) {
+		reported = append(reported, a)
+	}, provider.Action{ID: "1", Command: "create_server", Status: provider.ActionRunning})
+	if err != nil {
+		t.Fatalf("WaitForActions() error = %v", err)
+	}
+	if len(reported) != 3 {
+		t.Fatalf("reported = %+v, want start, 50%% and done", reported)
+	}
+	if last := reported[2]; last.Command != "create_server" || last.Status != provider.ActionSucceeded {
+		t.Fatalf("last report = %+v", last)
+	}
+}
+
+func TestWaitForActionsFailsOnFailedAction(t *testing.T) {
+	lc := &scriptedLifecycle{polls: []provider.Action{
+		{ID: "7", Status: provider.ActionFailed, Error: "image not found"},
+	}}
+	err := provider.WaitForActions(context.Background(), lc, "token", time.Millisecond, nil,
+		provider.Action{ID: "7", Command: "rebuild_server", Status: provider.ActionRunning})
+	if err == nil || !strings.Contains(err.Error(), "rebuild_server action 7 failed: image not found") {
+		t.Fatalf("WaitForActions() error = %v", err)
+	}
+}
+
+func TestExistingServerCapabilities(t *testing.T) {
+	if !provider.AdoptsExistingServers("custom") || provider.RequiresAPIToken("custom") {
+		t.Fatal("expected custom provider to adopt existing servers without an API token")
+	}
+	for _, providerType := range []string{"hetzner", "digitalocean", "unknown"} {
+		if provider.AdoptsExistingServers(providerType) || !provider.RequiresAPIToken(providerType) {
+			t.Fatalf("expected %s to require an API token", providerType)
+		}
 	}
 }
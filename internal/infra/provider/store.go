@@ -8,6 +8,7 @@ import (
 
 	"pressluft/internal/shared/idutil"
 	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/workspace"
 )
 
 // StoredProvider represents a provider row persisted in the database.
@@ -46,9 +47,9 @@ func (s *Store) Create(ctx context.Context, providerType, name, apiToken string)
 		return "", fmt.Errorf("encrypt provider token: %w", err)
 	}
 	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO providers (id, type, name, api_token_encrypted, api_token_key_id, api_token_version, status, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, 'active', ?, ?)`,
-		providerID, providerType, name, encrypted, keyID, version, now, now,
+		`INSERT INTO providers (id, workspace_id, type, name, api_token_encrypted, api_token_key_id, api_token_version, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, 'active', ?, ?)`,
+		providerID, workspace.ForWrite(ctx), providerType, name, encrypted, keyID, version, now, now,
 	)
 	if err != nil {
 		return "", fmt.Errorf("insert provider: %w", err)
@@ -56,11 +57,16 @@ func (s *Store) Create(ctx context.Context, providerType, name, apiToken string)
 	return providerID, nil
 }
 
-// List returns all providers. API tokens are NOT included in the result.
+// List returns the providers of the current workspace. API tokens are NOT
+// included in the result.
 func (s *Store) List(ctx context.Context) ([]StoredProvider, error) {
+	scope := workspace.Filter(ctx)
 	rows, err := s.db.QueryContext(ctx,
 		`SELECT id, type, name, status, created_at, updated_at
-		 FROM providers ORDER BY created_at DESC`,
+		 FROM providers
+		 WHERE (? = '' OR workspace_id = ?)
+		 ORDER BY created_at DESC`,
+		scope, scope,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list providers: %w", err)
@@ -84,7 +90,11 @@ func (s *Store) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
-	res, err := s.db.ExecContext(ctx, `DELETE FROM providers WHERE id = ?`, providerID)
+	scope := workspace.Filter(ctx)
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM providers WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		providerID, scope, scope,
+	)
 	if err != nil {
 		return fmt.Errorf("delete provider: %w", err)
 	}
@@ -104,8 +114,8 @@ func (s *Store) GetByID(ctx context.Context, id string) (*StoredProvider, error)
 	row := s.db.QueryRowContext(ctx,
 		`SELECT id, type, name, api_token_encrypted, api_token_key_id, api_token_version, status, created_at, updated_at
 		 FROM providers
-		 WHERE id = ?`,
-		providerID,
+		 WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		providerID, workspace.Filter(ctx), workspace.Filter(ctx),
 	)
 
 	var p StoredProvider
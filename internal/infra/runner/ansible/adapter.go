@@ -9,10 +9,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"pressluft/internal/infra/runner"
 )
 
+// processWaitDelay bounds how long a cancelled run waits for leftover
+// processes to release its output pipes.
+const processWaitDelay = 10 * time.Second
+
 // Adapter executes Ansible playbooks with strict command guardrails.
 type Adapter struct {
 	binaryPath       string
@@ -23,8 +28,8 @@ type Adapter struct {
 
 // NewAdapter creates an Ansible adapter that restricts playbook execution to
 // explicitly allowed paths and path prefixes. Prefixes ending in "/" allow
-// any playbook under that directory tree (used for provider-scoped playbooks
-// like "ops/ansible/playbooks/hetzner/provision.yml").
+// any playbook under that directory tree (used for the site playbooks
+// like "ops/ansible/playbooks/deploy-site.yml").
 func NewAdapter(binaryPath, workingDirectory string, allowedPlaybooks []string) *Adapter {
 	allow := make(map[string]struct{}, len(allowedPlaybooks))
 	var prefixes []string
@@ -124,6 +129,7 @@ func (a *Adapter) buildSyntaxCheckCommand(ctx context.Context, req runner.Reques
 
 	cmd := exec.CommandContext(ctx, a.binaryPath, args...)
 	a.configureCommand(cmd)
+	killProcessGroupOnCancel(cmd)
 	return cmd
 }
 
@@ -136,6 +142,7 @@ func (a *Adapter) buildApplyCommand(ctx context.Context, req runner.Request) *ex
 
 	cmd := exec.CommandContext(ctx, a.binaryPath, args...)
 	a.configureCommand(cmd)
+	killProcessGroupOnCancel(cmd)
 	return cmd
 }
 
@@ -181,6 +188,9 @@ func (a *Adapter) runCommand(ctx context.Context, cmd *exec.Cmd, sink runner.Eve
 	}
 
 	err := cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+		err = fmt.Errorf("%w: %w", ctxErr, err)
+	}
 	stdoutText := strings.TrimSpace(stdout.String())
 	stderrText := strings.TrimSpace(stderr.String())
 	if sink != nil {
@@ -268,7 +278,7 @@ func redactExtraVarsArg(arg string) string {
 	}
 	for key := range payload {
 		lower := strings.ToLower(key)
-		if strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret") {
+		if strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret") || strings.Contains(lower, "presigned") {
 			payload[key] = "<redacted>"
 		}
 	}
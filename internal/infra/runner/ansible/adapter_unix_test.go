@@ -0,0 +1,39 @@
+//go:build unix
+
+package ansible
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunCommandKillsProcessGroupOnCancel(t *testing.T) {
+	adapter := NewAdapter("/bin/sh", t.TempDir(), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The backgrounded sleep keeps the output pipe open; only killing the
+	// whole process group lets the run return before the wait delay.
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", "sleep 30 & wait")
+	killProcessGroupOnCancel(cmd)
+
+	done := make(chan error, 1)
+	started := time.Now()
+	go func() { done <- adapter.runCommand(ctx, cmd, nil, "apply", "ansible playbook run") }()
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("runCommand error = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(started); elapsed >= processWaitDelay/2 {
+			t.Fatalf("cancelled run took %v, want the process group killed promptly", elapsed)
+		}
+	case <-time.After(processWaitDelay + 5*time.Second):
+		t.Fatal("cancelled run did not return")
+	}
+}
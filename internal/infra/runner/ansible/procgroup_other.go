@@ -0,0 +1,11 @@
+//go:build !unix
+
+package ansible
+
+import "os/exec"
+
+// killProcessGroupOnCancel falls back to killing only the direct child on
+// platforms without process groups.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.WaitDelay = processWaitDelay
+}
@@ -0,0 +1,132 @@
+// Package simulated implements runner.Runner for servers of simulated
+// providers. Playbooks are not run: each one succeeds after a short delay,
+// and the configure playbook starts an in-process simulated agent instead of
+// installing the real one.
+package simulated
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"pressluft/internal/agent"
+	"pressluft/internal/infra/runner"
+)
+
+// Runner simulates playbook runs. Simulated agents run until the context
+// passed to New is done.
+type Runner struct {
+	ctx               context.Context
+	configurePlaybook string
+	delay             time.Duration
+	logger            *slog.Logger
+
+	// runAgent runs one simulated agent until ctx is done. Tests replace it.
+	runAgent func(ctx context.Context, config *agent.Config) error
+
+	mu       sync.Mutex
+	agents   map[string]context.CancelFunc
+	failures map[string][]string
+}
+
+// New returns a runner whose runs take delay. Runs of configurePlaybook
+// start a simulated agent for the configured server.
+func New(ctx context.Context, configurePlaybook string, delay time.Duration, logger *slog.Logger) *Runner {
+	r := &Runner{
+		ctx:               ctx,
+		configurePlaybook: filepath.Clean(configurePlaybook),
+		delay:             delay,
+		logger:            logger,
+		agents:            map[string]context.CancelFunc{},
+		failures:          map[string][]string{},
+	}
+	r.runAgent = func(ctx context.Context, config *agent.Config) error {
+		return agent.NewSimulated(config, logger).Run(ctx)
+	}
+	return r
+}
+
+func (r *Runner) Name() string {
+	return "simulated"
+}
+
+// FailNext makes the next run of the playbook with this file name, e.g.
+// "deploy-site.yml", fail with message. Failures queue up per playbook.
+func (r *Runner) FailNext(playbook, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[playbook] = append(r.failures[playbook], message)
+}
+
+func (r *Runner) Run(ctx context.Context, req runner.Request, sink runner.EventSink) error {
+	playbook := filepath.Base(req.PlaybookPath)
+	if sink != nil {
+		_ = sink.Emit(ctx, runner.Event{Type: "runner_apply", Level: "info", Message: "simulating " + playbook})
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(r.delay):
+	}
+
+	if message := r.nextFailure(playbook); message != "" {
+		if sink != nil {
+			_ = sink.Emit(ctx, runner.Event{Type: "runner_output", Level: "error", StepKey: "apply", Message: "stderr", Payload: message})
+		}
+		return fmt.Errorf("simulated %s failed: %s", playbook, message)
+	}
+	if !req.CheckOnly && filepath.Clean(req.PlaybookPath) == r.configurePlaybook {
+		if err := r.startAgent(req.ExtraVars); err != nil {
+			return err
+		}
+	}
+
+	if sink != nil {
+		_ = sink.Emit(ctx, runner.Event{Type: "runner_complete", Level: "info", Message: "simulated run complete"})
+	}
+	return nil
+}
+
+func (r *Runner) nextFailure(playbook string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued := r.failures[playbook]
+	if len(queued) == 0 {
+		return ""
+	}
+	r.failures[playbook] = queued[1:]
+	return queued[0]
+}
+
+// startAgent starts the simulated agent of the configured server, replacing
+// one started by an earlier configure run.
+func (r *Runner) startAgent(vars map[string]string) error {
+	config := &agent.Config{
+		ServerID:     strings.TrimSpace(vars["server_id"]),
+		ControlPlane: strings.TrimSpace(vars["control_plane_url"]),
+		DevWSToken:   strings.TrimSpace(vars["dev_ws_token"]),
+	}
+	if config.ServerID == "" || config.ControlPlane == "" {
+		return fmt.Errorf("server_id and control_plane_url are required to start a simulated agent")
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.mu.Lock()
+	if previous, ok := r.agents[config.ServerID]; ok {
+		previous()
+	}
+	r.agents[config.ServerID] = cancel
+	r.mu.Unlock()
+
+	go func() {
+		if err := r.runAgent(ctx, config); err != nil && ctx.Err() == nil {
+			r.logger.Error("simulated agent stopped", "server_id", config.ServerID, "error", err)
+		}
+	}()
+	return nil
+}
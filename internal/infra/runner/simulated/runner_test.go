@@ -0,0 +1,99 @@
+package simulated
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/agent"
+	"pressluft/internal/infra/runner"
+)
+
+type recordingSink struct {
+	events []runner.Event
+}
+
+func (s *recordingSink) Emit(ctx context.Context, event runner.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func newTestRunner(t *testing.T) (*Runner, chan *agent.Config, chan struct{}) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r := New(ctx, "ops/ansible/playbooks/configure.yml", 0, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	started := make(chan *agent.Config, 2)
+	stopped := make(chan struct{}, 2)
+	r.runAgent = func(ctx context.Context, config *agent.Config) error {
+		started <- config
+		<-ctx.Done()
+		stopped <- struct{}{}
+		return ctx.Err()
+	}
+	return r, started, stopped
+}
+
+func configureRequest() runner.Request {
+	return runner.Request{
+		JobID:        "job-1",
+		PlaybookPath: "ops/ansible/playbooks/configure.yml",
+		ExtraVars:    map[string]string{"server_id": "srv-1", "control_plane_url": "http://127.0.0.1:8081", "dev_ws_token": "tok"},
+	}
+}
+
+func TestConfigureStartsSimulatedAgent(t *testing.T) {
+	r, started, stopped := newTestRunner(t)
+	sink := &recordingSink{}
+
+	if err := r.Run(context.Background(), configureRequest(), sink); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	config := <-started
+	if config.ServerID != "srv-1" || config.ControlPlane != "http://127.0.0.1:8081" || config.DevWSToken != "tok" {
+		t.Fatalf("agent config = %+v", config)
+	}
+	if len(sink.events) != 2 || sink.events[1].Type != "runner_complete" {
+		t.Fatalf("events = %+v", sink.events)
+	}
+
+	// Configuring again replaces the running agent.
+	if err := r.Run(context.Background(), configureRequest(), nil); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	<-started
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the first agent to stop")
+	}
+}
+
+func TestSitePlaybooksDoNotStartAgents(t *testing.T) {
+	r, started, _ := newTestRunner(t)
+	if err := r.Run(context.Background(), runner.Request{PlaybookPath: "ops/ansible/playbooks/deploy-site.yml"}, nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	select {
+	case config := <-started:
+		t.Fatalf("unexpected agent %+v", config)
+	default:
+	}
+}
+
+func TestFailNextFailsOneRun(t *testing.T) {
+	r, _, _ := newTestRunner(t)
+	r.FailNext("deploy-site.yml", "nginx: configuration test failed")
+	req := runner.Request{PlaybookPath: "ops/ansible/playbooks/deploy-site.yml"}
+
+	err := r.Run(context.Background(), req, nil)
+	if err == nil || !strings.Contains(err.Error(), "nginx: configuration test failed") {
+		t.Fatalf("Run() error = %v, want the injected failure", err)
+	}
+	if err := r.Run(context.Background(), req, nil); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+}
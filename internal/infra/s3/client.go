@@ -0,0 +1,366 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	signingService   = "s3"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDateFormat    = "20060102T150405Z"
+	amzDayFormat     = "20060102"
+
+	// MaxPresignExpiry is the longest validity accepted for presigned URLs.
+	MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// Config describes an S3-compatible bucket endpoint and its credentials.
+type Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key>. Most
+	// self-hosted S3 implementations (MinIO, Garage, Ceph) require it.
+	PathStyle bool
+}
+
+// ObjectInfo is the metadata returned by HeadObject.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// Client speaks the subset of the S3 REST API needed for offsite backups.
+// Requests are signed with AWS Signature Version 4 so any S3-compatible
+// service can be used.
+type Client struct {
+	endpoint   *url.URL
+	config     Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient validates cfg and returns a client. When httpClient is nil a
+// client with a conservative timeout is used.
+func NewClient(cfg Config, httpClient *http.Client) (*Client, error) {
+	cfg.Endpoint = strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	cfg.Region = strings.TrimSpace(cfg.Region)
+	cfg.Bucket = strings.TrimSpace(cfg.Bucket)
+	cfg.AccessKeyID = strings.TrimSpace(cfg.AccessKeyID)
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("s3 endpoint is required")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "https" && endpoint.Scheme != "http" {
+		return nil, fmt.Errorf("s3 endpoint must be an http or https URL")
+	}
+	if endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint host is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 access key id and secret access key are required")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{
+		endpoint:   endpoint,
+		config:     cfg,
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+// Bucket returns the configured bucket name.
+func (c *Client) Bucket() string {
+	return c.config.Bucket
+}
+
+// HeadBucket verifies that the bucket exists and the credentials can reach it.
+func (c *Client) HeadBucket(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodHead, "", nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("head bucket", resp)
+	}
+	return nil
+}
+
+// PutObject uploads body as key. size must be the exact body length.
+func (c *Client) PutObject(ctx context.Context, key string, body io.Reader, size int64) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPut, key, body, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("put object", resp)
+	}
+	return nil
+}
+
+// HeadObject returns the stored size and ETag of key.
+func (c *Client) HeadObject(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, http.MethodHead, key, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("object %s not found", key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError("head object", resp)
+	}
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("head object: invalid content length %q", resp.Header.Get("Content-Length"))
+	}
+	info := &ObjectInfo{
+		Key:  key,
+		Size: size,
+		ETag: strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = modified.UTC()
+	}
+	return info, nil
+}
+
+// DeleteObject removes key. Deleting a missing object is not an error.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError("delete object", resp)
+	}
+	return nil
+}
+
+// PresignPut returns a URL that allows a single PUT of key until expiry.
+// Managed servers upload archives with it so bucket credentials never leave
+// the control plane.
+func (c *Client) PresignPut(key string, expiry time.Duration) (string, error) {
+	return c.presign(http.MethodPut, key, expiry)
+}
+
+// PresignGet returns a URL that allows downloading key until expiry.
+func (c *Client) PresignGet(key string, expiry time.Duration) (string, error) {
+	return c.presign(http.MethodGet, key, expiry)
+}
+
+func (c *Client) presign(method, key string, expiry time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if expiry <= 0 || expiry > MaxPresignExpiry {
+		return "", fmt.Errorf("presign expiry must be between 1s and %s", MaxPresignExpiry)
+	}
+	now := c.now().UTC()
+	target := c.objectURL(key)
+	query := target.Query()
+	query.Set("X-Amz-Algorithm", signingAlgorithm)
+	query.Set("X-Amz-Credential", c.config.AccessKeyID+"/"+c.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	target.RawQuery = canonicalQuery(query)
+
+	headers := http.Header{}
+	headers.Set("Host", target.Host)
+	signature := c.signature(now, method, target, headers, []string{"host"}, unsignedPayload)
+	target.RawQuery += "&X-Amz-Signature=" + signature
+	return target.String(), nil
+}
+
+func (c *Client) do(ctx context.Context, method, key string, body io.Reader, size int64) (*http.Response, error) {
+	target := c.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build s3 request: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	now := c.now().UTC()
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		payloadHash = unsignedPayload
+	}
+	req.Header.Set("Host", target.Host)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	signature := c.signature(now, method, target, req.Header, signed, payloadHash)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, c.config.AccessKeyID, c.scope(now), strings.Join(signed, ";"), signature))
+	req.Header.Del("Host")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s request: %w", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+func (c *Client) objectURL(key string) *url.URL {
+	target := *c.endpoint
+	basePath := strings.TrimRight(target.Path, "/")
+	if c.config.PathStyle {
+		target.Path = basePath + "/" + c.config.Bucket
+	} else {
+		target.Host = c.config.Bucket + "." + target.Host
+		target.Path = basePath
+	}
+	if key != "" {
+		target.Path += "/" + strings.TrimLeft(key, "/")
+	}
+	if target.Path == "" {
+		target.Path = "/"
+	}
+	target.RawPath = encodePath(target.Path)
+	target.RawQuery = ""
+	return &target
+}
+
+func (c *Client) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(amzDayFormat), c.config.Region, signingService, "aws4_request"}, "/")
+}
+
+func (c *Client) signature(now time.Time, method string, target *url.URL, headers http.Header, signedHeaders []string, payloadHash string) string {
+	canonicalHeaders := make([]string, 0, len(signedHeaders))
+	for _, name := range signedHeaders {
+		value := headers.Get(name)
+		if name == "host" {
+			value = target.Host
+		}
+		canonicalHeaders = append(canonicalHeaders, name+":"+strings.TrimSpace(value)+"\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		method,
+		encodePath(target.Path),
+		target.RawQuery,
+		strings.Join(canonicalHeaders, ""),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		now.Format(amzDateFormat),
+		c.scope(now),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.config.SecretAccessKey), now.Format(amzDayFormat))
+	key = hmacSHA256(key, c.config.Region)
+	key = hmacSHA256(key, signingService)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key using the strict
+// RFC 3986 escaping SigV4 expects.
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, value := range vals {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func encodePath(path string) string {
+	return uriEncode(path, false)
+}
+
+func uriEncode(value string, encodeSlash bool) string {
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch >= 'A' && ch <= 'Z', ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9', ch == '-', ch == '_', ch == '.', ch == '~':
+			out.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			out.WriteByte(ch)
+		default:
+			fmt.Fprintf(&out, "%%%02X", ch)
+		}
+	}
+	return out.String()
+}
+
+func validateKey(key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("s3 object key is required")
+	}
+	if strings.Contains(key, "..") {
+		return fmt.Errorf("s3 object key must not contain '..'")
+	}
+	return nil
+}
+
+func responseError(operation string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return fmt.Errorf("%s: unexpected status %d", operation, resp.StatusCode)
+	}
+	return fmt.Errorf("%s: unexpected status %d: %s", operation, resp.StatusCode, message)
+}
@@ -0,0 +1,197 @@
+// Package s3test provides an in-process, MinIO-style stand-in for an
+// S3-compatible object store. It implements the handful of bucket and object
+// operations Pressluft uses and is intended for tests only.
+package s3test
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAccessKeyID     = "minioadmin"
+	DefaultSecretAccessKey = "minioadmin-secret"
+	DefaultRegion          = "us-east-1"
+)
+
+type object struct {
+	data     []byte
+	etag     string
+	modified time.Time
+}
+
+// Server is an httptest-backed path-style S3 endpoint.
+type Server struct {
+	*httptest.Server
+
+	AccessKeyID string
+
+	mu      sync.Mutex
+	buckets map[string]map[string]object
+}
+
+// NewServer starts a stand-in that accepts requests signed by
+// DefaultAccessKeyID and serves the given buckets.
+func NewServer(buckets ...string) *Server {
+	s := &Server{
+		AccessKeyID: DefaultAccessKeyID,
+		buckets:     make(map[string]map[string]object, len(buckets)),
+	}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = map[string]object{}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Object returns a copy of the stored bytes for bucket/key.
+func (s *Server) Object(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		return nil, false
+	}
+	obj, ok := objects[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), obj.data...), true
+}
+
+// PutObject seeds bucket/key without going through the HTTP API.
+func (s *Server) PutObject(bucket, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		objects = map[string]object{}
+		s.buckets[bucket] = objects
+	}
+	objects[key] = newObject(data)
+}
+
+// Keys lists the object keys stored in bucket in lexical order.
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if err := s.authorize(r); err != nil {
+		writeError(w, http.StatusForbidden, "AccessDenied", err.Error())
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+	if key == "" {
+		if r.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "bucket operation not supported")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		obj := newObject(data)
+		objects[key] = obj
+		w.Header().Set("ETag", `"`+obj.etag+`"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := objects[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+			return
+		}
+		w.Header().Set("ETag", `"`+obj.etag+`"`)
+		w.Header().Set("Last-Modified", obj.modified.Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "object operation not supported")
+	}
+}
+
+// authorize accepts header-signed and presigned SigV4 requests whose
+// credential scope names the configured access key. Signatures are not
+// recomputed; the stand-in only guards against unsigned or expired requests.
+func (s *Server) authorize(r *http.Request) error {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if !strings.HasPrefix(header, "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/") {
+			return fmt.Errorf("invalid access key")
+		}
+		if !strings.Contains(header, "Signature=") {
+			return fmt.Errorf("missing signature")
+		}
+		return nil
+	}
+	query := r.URL.Query()
+	if !strings.HasPrefix(query.Get("X-Amz-Credential"), s.AccessKeyID+"/") {
+		return fmt.Errorf("invalid access key")
+	}
+	if query.Get("X-Amz-Signature") == "" {
+		return fmt.Errorf("missing signature")
+	}
+	signedAt, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return fmt.Errorf("invalid X-Amz-Date")
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil {
+		return fmt.Errorf("invalid X-Amz-Expires")
+	}
+	if time.Now().UTC().After(signedAt.Add(time.Duration(expires) * time.Second)) {
+		return fmt.Errorf("request has expired")
+	}
+	return nil
+}
+
+func newObject(data []byte) object {
+	sum := md5.Sum(data)
+	return object{
+		data:     append([]byte(nil), data...),
+		etag:     hex.EncodeToString(sum[:]),
+		modified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
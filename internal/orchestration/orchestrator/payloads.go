@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
 
 	"pressluft/internal/agent/agentcommand"
@@ -13,6 +15,8 @@ type ConfigureServerPayload struct {
 	IPv4 string `json:"ipv4,omitempty"`
 }
 
+type ConnectServerPayload struct{}
+
 type DeleteServerPayload struct{}
 
 type RebuildServerPayload struct {
@@ -41,6 +45,130 @@ type DeploySitePayload struct {
 	TLSContactEmail string `json:"tls_contact_email,omitempty"`
 }
 
+type BackupSitePayload struct {
+	SiteID        string `json:"site_id"`
+	TargetID      string `json:"target_id"`
+	RetentionDays int    `json:"retention_days,omitempty"`
+}
+
+// RestoreSitePayload restores backup BackupID onto site SiteID. The target
+// site may live on a different server than the one the backup was taken on.
+type RestoreSitePayload struct {
+	SiteID   string `json:"site_id"`
+	BackupID string `json:"backup_id"`
+}
+
+// CreateStagingPayload clones production site SourceSiteID into the already
+// recorded staging site SiteID on the same server.
+type CreateStagingPayload struct {
+	SiteID          string `json:"site_id"`
+	SourceSiteID    string `json:"source_site_id"`
+	TLSContactEmail string `json:"tls_contact_email,omitempty"`
+}
+
+// Push scopes select what push_site copies from a staging site to its
+// production parent.
+const (
+	PushScopeAll      = "all"
+	PushScopeFiles    = "files"
+	PushScopeDatabase = "database"
+)
+
+// PushSitePayload pushes staging site SiteID onto its production parent
+// TargetSiteID. IncludeTables and ExcludeTables take table names or `*`
+// globs such as `wp_woocommerce_*`; an empty IncludeTables means every table.
+// DryRun reports the diff summary without changing the live site.
+type PushSitePayload struct {
+	SiteID        string   `json:"site_id"`
+	TargetSiteID  string   `json:"target_site_id"`
+	Scope         string   `json:"scope"`
+	IncludeTables []string `json:"include_tables,omitempty"`
+	ExcludeTables []string `json:"exclude_tables,omitempty"`
+	DryRun        bool     `json:"dry_run,omitempty"`
+}
+
+// IncludesFiles reports whether the push copies site files.
+func (p PushSitePayload) IncludesFiles() bool {
+	return p.Scope == PushScopeAll || p.Scope == PushScopeFiles
+}
+
+// IncludesDatabase reports whether the push copies database tables.
+func (p PushSitePayload) IncludesDatabase() bool {
+	return p.Scope == PushScopeAll || p.Scope == PushScopeDatabase
+}
+
+// SelectsTable reports whether table is copied by this push.
+func (p PushSitePayload) SelectsTable(table string) bool {
+	if !p.IncludesDatabase() {
+		return false
+	}
+	if len(p.IncludeTables) > 0 && !matchesAnyTablePattern(p.IncludeTables, table) {
+		return false
+	}
+	return !matchesAnyTablePattern(p.ExcludeTables, table)
+}
+
+// ProviderActionEvent is the JobEvent payload for one provider action,
+// such as creating or powering off a server.
+type ProviderActionEvent struct {
+	Provider string `json:"provider"`
+	Action   string `json:"action"`
+	ActionID string `json:"action_id"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PushSiteDiff is the JobEvent payload push_site reports before it applies
+// anything.
+type PushSiteDiff struct {
+	DryRun bool                `json:"dry_run"`
+	Files  *PushSiteFileDiff   `json:"files,omitempty"`
+	Tables []PushSiteTableDiff `json:"tables,omitempty"`
+}
+
+type PushSiteFileDiff struct {
+	Added   int      `json:"added"`
+	Changed int      `json:"changed"`
+	Deleted int      `json:"deleted"`
+	Sample  []string `json:"sample,omitempty"`
+}
+
+type PushSiteTableDiff struct {
+	Name       string `json:"name"`
+	SourceRows int64  `json:"source_rows"`
+	TargetRows int64  `json:"target_rows"`
+	Selected   bool   `json:"selected"`
+}
+
+var tablePatternRE = regexp.MustCompile(`^[A-Za-z0-9_$*]+$`)
+
+// UpdateAllComponents in Plugins or Themes updates every installed component
+// of that type that has an update available.
+const UpdateAllComponents = "*"
+
+// UpdateSiteComponentsPayload updates WordPress core, plugins, and themes on
+// site SiteID. CheckPaths are fetched before and after the update and
+// compared; an empty list checks the home page only.
+type UpdateSiteComponentsPayload struct {
+	SiteID     string   `json:"site_id"`
+	Core       bool     `json:"core,omitempty"`
+	Plugins    []string `json:"plugins,omitempty"`
+	Themes     []string `json:"themes,omitempty"`
+	CheckPaths []string `json:"check_paths,omitempty"`
+}
+
+var componentSlugRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func matchesAnyTablePattern(patterns []string, table string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, table); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func MarshalConfigureServerPayload(in ConfigureServerPayload) (string, error) {
 	return marshalNormalizedPayload(in)
 }
@@ -54,6 +182,15 @@ func UnmarshalConfigureServerPayload(raw string) (ConfigureServerPayload, error)
 	return out, nil
 }
 
+func MarshalConnectServerPayload() (string, error) {
+	return marshalNormalizedPayload(ConnectServerPayload{})
+}
+
+func UnmarshalConnectServerPayload(raw string) (ConnectServerPayload, error) {
+	var out ConnectServerPayload
+	return out, unmarshalNormalizedPayload(raw, &out)
+}
+
 func MarshalDeleteServerPayload() (string, error) {
 	return marshalNormalizedPayload(DeleteServerPayload{})
 }
@@ -156,6 +293,153 @@ func UnmarshalDeploySitePayload(raw string) (DeploySitePayload, error) {
 	return out, nil
 }
 
+func MarshalBackupSitePayload(in BackupSitePayload) (string, error) {
+	in.SiteID = strings.TrimSpace(in.SiteID)
+	in.TargetID = strings.TrimSpace(in.TargetID)
+	return marshalNormalizedPayload(in)
+}
+
+func UnmarshalBackupSitePayload(raw string) (BackupSitePayload, error) {
+	var out BackupSitePayload
+	if err := unmarshalNormalizedPayload(raw, &out); err != nil {
+		return BackupSitePayload{}, err
+	}
+	out.SiteID = strings.TrimSpace(out.SiteID)
+	out.TargetID = strings.TrimSpace(out.TargetID)
+	return out, nil
+}
+
+func MarshalRestoreSitePayload(in RestoreSitePayload) (string, error) {
+	in.SiteID = strings.TrimSpace(in.SiteID)
+	in.BackupID = strings.TrimSpace(in.BackupID)
+	return marshalNormalizedPayload(in)
+}
+
+func UnmarshalRestoreSitePayload(raw string) (RestoreSitePayload, error) {
+	var out RestoreSitePayload
+	if err := unmarshalNormalizedPayload(raw, &out); err != nil {
+		return RestoreSitePayload{}, err
+	}
+	out.SiteID = strings.TrimSpace(out.SiteID)
+	out.BackupID = strings.TrimSpace(out.BackupID)
+	return out, nil
+}
+
+func MarshalCreateStagingPayload(in CreateStagingPayload) (string, error) {
+	in.SiteID = strings.TrimSpace(in.SiteID)
+	in.SourceSiteID = strings.TrimSpace(in.SourceSiteID)
+	in.TLSContactEmail = strings.TrimSpace(in.TLSContactEmail)
+	return marshalNormalizedPayload(in)
+}
+
+func UnmarshalCreateStagingPayload(raw string) (CreateStagingPayload, error) {
+	var out CreateStagingPayload
+	if err := unmarshalNormalizedPayload(raw, &out); err != nil {
+		return CreateStagingPayload{}, err
+	}
+	out.SiteID = strings.TrimSpace(out.SiteID)
+	out.SourceSiteID = strings.TrimSpace(out.SourceSiteID)
+	out.TLSContactEmail = strings.TrimSpace(out.TLSContactEmail)
+	return out, nil
+}
+
+func MarshalPushSitePayload(in PushSitePayload) (string, error) {
+	return marshalNormalizedPayload(normalizePushSitePayload(in))
+}
+
+func UnmarshalPushSitePayload(raw string) (PushSitePayload, error) {
+	var out PushSitePayload
+	if err := unmarshalNormalizedPayload(raw, &out); err != nil {
+		return PushSitePayload{}, err
+	}
+	return normalizePushSitePayload(out), nil
+}
+
+func normalizePushSitePayload(in PushSitePayload) PushSitePayload {
+	in.SiteID = strings.TrimSpace(in.SiteID)
+	in.TargetSiteID = strings.TrimSpace(in.TargetSiteID)
+	in.Scope = strings.TrimSpace(in.Scope)
+	if in.Scope == "" {
+		in.Scope = PushScopeAll
+	}
+	in.IncludeTables = trimNonEmpty(in.IncludeTables)
+	in.ExcludeTables = trimNonEmpty(in.ExcludeTables)
+	return in
+}
+
+func MarshalUpdateSiteComponentsPayload(in UpdateSiteComponentsPayload) (string, error) {
+	return marshalNormalizedPayload(normalizeUpdateSiteComponentsPayload(in))
+}
+
+func UnmarshalUpdateSiteComponentsPayload(raw string) (UpdateSiteComponentsPayload, error) {
+	var out UpdateSiteComponentsPayload
+	if err := unmarshalNormalizedPayload(raw, &out); err != nil {
+		return UpdateSiteComponentsPayload{}, err
+	}
+	return normalizeUpdateSiteComponentsPayload(out), nil
+}
+
+func normalizeUpdateSiteComponentsPayload(in UpdateSiteComponentsPayload) UpdateSiteComponentsPayload {
+	in.SiteID = strings.TrimSpace(in.SiteID)
+	in.Plugins = trimNonEmpty(in.Plugins)
+	in.Themes = trimNonEmpty(in.Themes)
+	in.CheckPaths = trimNonEmpty(in.CheckPaths)
+	if len(in.CheckPaths) == 0 {
+		in.CheckPaths = []string{"/"}
+	}
+	return in
+}
+
+func trimNonEmpty(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// WithScheduleID tags a normalized job payload with the schedule that queued
+// it. Executors ignore the extra field; it only links the run back to its
+// schedule.
+func WithScheduleID(payload, scheduleID string) (string, error) {
+	fields := map[string]json.RawMessage{}
+	if err := unmarshalNormalizedPayload(payload, &fields); err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(strings.TrimSpace(scheduleID))
+	if err != nil {
+		return "", fmt.Errorf("marshal schedule id: %w", err)
+	}
+	fields["schedule_id"] = encoded
+	return marshalNormalizedPayload(fields)
+}
+
+// ScheduleIDFromPayload returns the schedule that queued a job, or "" for
+// jobs queued directly.
+func ScheduleIDFromPayload(payload string) string {
+	var parsed struct {
+		ScheduleID string `json:"schedule_id"`
+	}
+	if err := unmarshalNormalizedPayload(payload, &parsed); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(parsed.ScheduleID)
+}
+
+// SiteIDFromPayload returns the site a job acts on, or "" for jobs that are
+// not tied to a site.
+func SiteIDFromPayload(payload string) string {
+	var parsed struct {
+		SiteID string `json:"site_id"`
+	}
+	if err := unmarshalNormalizedPayload(payload, &parsed); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(parsed.SiteID)
+}
+
 func marshalNormalizedPayload(value any) (string, error) {
 	data, err := json.Marshal(value)
 	if err != nil {
@@ -213,6 +497,20 @@ func validateConfigureServerPayload(payload json.RawMessage, serverID string) (s
 	return MarshalConfigureServerPayload(ConfigureServerPayload{IPv4: parsed.IPv4})
 }
 
+func validateConnectServerPayload(payload json.RawMessage, serverID string) (string, error) {
+	if err := requireServerID(serverID, JobKindConnectServer); err != nil {
+		return "", err
+	}
+	if normalizeArbitraryPayload(payload) == "" {
+		return "", nil
+	}
+	var parsed ConnectServerPayload
+	if err := json.Unmarshal(bytes.TrimSpace(payload), &parsed); err != nil {
+		return "", fmt.Errorf("invalid connect_server payload: %w", err)
+	}
+	return MarshalConnectServerPayload()
+}
+
 func validateDeleteServerPayload(payload json.RawMessage, serverID string) (string, error) {
 	if err := requireServerID(serverID, JobKindDeleteServer); err != nil {
 		return "", err
@@ -337,6 +635,136 @@ func validateDeploySitePayload(payload json.RawMessage, serverID string) (string
 	return MarshalDeploySitePayload(parsed)
 }
 
+func validateBackupSitePayload(payload json.RawMessage, serverID string) (string, error) {
+	if err := requireServerID(serverID, JobKindBackupSite); err != nil {
+		return "", err
+	}
+	var parsed BackupSitePayload
+	if err := json.Unmarshal(bytes.TrimSpace(defaultPayloadObject(payload)), &parsed); err != nil {
+		return "", fmt.Errorf("invalid backup_site payload: %w", err)
+	}
+	if strings.TrimSpace(parsed.SiteID) == "" {
+		return "", fmt.Errorf("site_id is required for backup_site job")
+	}
+	if strings.TrimSpace(parsed.TargetID) == "" {
+		return "", fmt.Errorf("target_id is required for backup_site job")
+	}
+	if parsed.RetentionDays < 0 {
+		return "", fmt.Errorf("retention_days cannot be negative for backup_site job")
+	}
+	return MarshalBackupSitePayload(parsed)
+}
+
+func validateRestoreSitePayload(payload json.RawMessage, serverID string) (string, error) {
+	if err := requireServerID(serverID, JobKindRestoreSite); err != nil {
+		return "", err
+	}
+	var parsed RestoreSitePayload
+	if err := json.Unmarshal(bytes.TrimSpace(defaultPayloadObject(payload)), &parsed); err != nil {
+		return "", fmt.Errorf("invalid restore_site payload: %w", err)
+	}
+	if strings.TrimSpace(parsed.SiteID) == "" {
+		return "", fmt.Errorf("site_id is required for restore_site job")
+	}
+	if strings.TrimSpace(parsed.BackupID) == "" {
+		return "", fmt.Errorf("backup_id is required for restore_site job")
+	}
+	return MarshalRestoreSitePayload(parsed)
+}
+
+func validateCreateStagingPayload(payload json.RawMessage, serverID string) (string, error) {
+	if err := requireServerID(serverID, JobKindCreateStaging); err != nil {
+		return "", err
+	}
+	var parsed CreateStagingPayload
+	if err := json.Unmarshal(bytes.TrimSpace(defaultPayloadObject(payload)), &parsed); err != nil {
+		return "", fmt.Errorf("invalid create_staging payload: %w", err)
+	}
+	if strings.TrimSpace(parsed.SiteID) == "" {
+		return "", fmt.Errorf("site_id is required for create_staging job")
+	}
+	if strings.TrimSpace(parsed.SourceSiteID) == "" {
+		return "", fmt.Errorf("source_site_id is required for create_staging job")
+	}
+	if strings.TrimSpace(parsed.SiteID) == strings.TrimSpace(parsed.SourceSiteID) {
+		return "", fmt.Errorf("source_site_id must differ from site_id for create_staging job")
+	}
+	return MarshalCreateStagingPayload(parsed)
+}
+
+func validatePushSitePayload(payload json.RawMessage, serverID string) (string, error) {
+	if err := requireServerID(serverID, JobKindPushSite); err != nil {
+		return "", err
+	}
+	var parsed PushSitePayload
+	if err := json.Unmarshal(bytes.TrimSpace(defaultPayloadObject(payload)), &parsed); err != nil {
+		return "", fmt.Errorf("invalid push_site payload: %w", err)
+	}
+	parsed = normalizePushSitePayload(parsed)
+	if parsed.SiteID == "" {
+		return "", fmt.Errorf("site_id is required for push_site job")
+	}
+	if parsed.TargetSiteID == "" {
+		return "", fmt.Errorf("target_site_id is required for push_site job")
+	}
+	if parsed.SiteID == parsed.TargetSiteID {
+		return "", fmt.Errorf("target_site_id must differ from site_id for push_site job")
+	}
+	switch parsed.Scope {
+	case PushScopeAll, PushScopeFiles, PushScopeDatabase:
+	default:
+		return "", fmt.Errorf("scope must be one of all, files, database for push_site job")
+	}
+	if !parsed.IncludesDatabase() && (len(parsed.IncludeTables) > 0 || len(parsed.ExcludeTables) > 0) {
+		return "", fmt.Errorf("table filters cannot be used with scope files for push_site job")
+	}
+	for _, pattern := range append(append([]string{}, parsed.IncludeTables...), parsed.ExcludeTables...) {
+		if !tablePatternRE.MatchString(pattern) {
+			return "", fmt.Errorf("invalid table pattern %q for push_site job", pattern)
+		}
+	}
+	return MarshalPushSitePayload(parsed)
+}
+
+func validateUpdateSiteComponentsPayload(payload json.RawMessage, serverID string) (string, error) {
+	if err := requireServerID(serverID, JobKindUpdateSiteComponents); err != nil {
+		return "", err
+	}
+	var parsed UpdateSiteComponentsPayload
+	if err := json.Unmarshal(bytes.TrimSpace(defaultPayloadObject(payload)), &parsed); err != nil {
+		return "", fmt.Errorf("invalid update_site_components payload: %w", err)
+	}
+	parsed = normalizeUpdateSiteComponentsPayload(parsed)
+	if parsed.SiteID == "" {
+		return "", fmt.Errorf("site_id is required for update_site_components job")
+	}
+	if !parsed.Core && len(parsed.Plugins) == 0 && len(parsed.Themes) == 0 {
+		return "", fmt.Errorf("select core, plugins, or themes to update for update_site_components job")
+	}
+	for field, slugs := range map[string][]string{"plugins": parsed.Plugins, "themes": parsed.Themes} {
+		for _, slug := range slugs {
+			if slug == UpdateAllComponents {
+				if len(slugs) > 1 {
+					return "", fmt.Errorf("%s cannot mix %q with named components for update_site_components job", field, UpdateAllComponents)
+				}
+				continue
+			}
+			if !componentSlugRE.MatchString(slug) {
+				return "", fmt.Errorf("invalid %s slug %q for update_site_components job", field, slug)
+			}
+		}
+	}
+	if len(parsed.CheckPaths) > agentcommand.MaxSiteHealthPaths {
+		return "", fmt.Errorf("at most %d check_paths are allowed for update_site_components job", agentcommand.MaxSiteHealthPaths)
+	}
+	for _, checkPath := range parsed.CheckPaths {
+		if !strings.HasPrefix(checkPath, "/") || strings.ContainsAny(checkPath, " \t\r\n") {
+			return "", fmt.Errorf("check_path %q must be an absolute URL path for update_site_components job", checkPath)
+		}
+	}
+	return MarshalUpdateSiteComponentsPayload(parsed)
+}
+
 func defaultPayloadObject(payload json.RawMessage) []byte {
 	if normalizeArbitraryPayload(payload) == "" {
 		return []byte("{}")
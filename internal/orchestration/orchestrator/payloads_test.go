@@ -57,3 +57,159 @@ func TestUnmarshalResizeServerPayloadRejectsInvalidJSON(t *testing.T) {
 		t.Fatal("expected invalid normalized payload error")
 	}
 }
+
+func TestValidateBackupSitePayloadRequiresSiteAndTarget(t *testing.T) {
+	if _, err := ValidatePayload(string(JobKindBackupSite), []byte(`{"site_id":"site-1","target_id":"target-1"}`), ""); err == nil {
+		t.Fatal("expected server_id error")
+	}
+	if _, err := ValidatePayload(string(JobKindBackupSite), []byte(`{"target_id":"target-1"}`), "server-1"); err == nil {
+		t.Fatal("expected site_id error")
+	}
+	if _, err := ValidatePayload(string(JobKindBackupSite), []byte(`{"site_id":"site-1"}`), "server-1"); err == nil {
+		t.Fatal("expected target_id error")
+	}
+
+	raw, err := ValidatePayload(string(JobKindBackupSite), []byte(`{"site_id":" site-1 ","target_id":" target-1 ","retention_days":14}`), "server-1")
+	if err != nil {
+		t.Fatalf("ValidatePayload() error = %v", err)
+	}
+	decoded, err := UnmarshalBackupSitePayload(raw)
+	if err != nil {
+		t.Fatalf("UnmarshalBackupSitePayload() error = %v", err)
+	}
+	if decoded.SiteID != "site-1" || decoded.TargetID != "target-1" || decoded.RetentionDays != 14 {
+		t.Fatalf("decoded = %+v, want trimmed ids and retention", decoded)
+	}
+}
+
+func TestValidateRestoreSitePayloadRequiresSiteAndBackup(t *testing.T) {
+	if _, err := ValidatePayload(string(JobKindRestoreSite), []byte(`{"backup_id":"backup-1"}`), "server-1"); err == nil {
+		t.Fatal("expected site_id error")
+	}
+	if _, err := ValidatePayload(string(JobKindRestoreSite), []byte(`{"site_id":"site-1"}`), "server-1"); err == nil {
+		t.Fatal("expected backup_id error")
+	}
+
+	raw, err := ValidatePayload(string(JobKindRestoreSite), []byte(`{"site_id":" site-1 ","backup_id":" backup-1 "}`), "server-1")
+	if err != nil {
+		t.Fatalf("ValidatePayload() error = %v", err)
+	}
+	decoded, err := UnmarshalRestoreSitePayload(raw)
+	if err != nil {
+		t.Fatalf("UnmarshalRestoreSitePayload() error = %v", err)
+	}
+	if decoded.SiteID != "site-1" || decoded.BackupID != "backup-1" {
+		t.Fatalf("decoded = %+v, want trimmed ids", decoded)
+	}
+}
+
+func TestValidateCreateStagingPayloadRequiresDistinctSites(t *testing.T) {
+	if _, err := ValidatePayload(string(JobKindCreateStaging), []byte(`{"site_id":"site-2"}`), "server-1"); err == nil {
+		t.Fatal("expected source_site_id error")
+	}
+	if _, err := ValidatePayload(string(JobKindCreateStaging), []byte(`{"site_id":"site-1","source_site_id":"site-1"}`), "server-1"); err == nil {
+		t.Fatal("expected distinct site error")
+	}
+
+	raw, err := ValidatePayload(string(JobKindCreateStaging), []byte(`{"site_id":" site-2 ","source_site_id":" site-1 "}`), "server-1")
+	if err != nil {
+		t.Fatalf("ValidatePayload() error = %v", err)
+	}
+	decoded, err := UnmarshalCreateStagingPayload(raw)
+	if err != nil {
+		t.Fatalf("UnmarshalCreateStagingPayload() error = %v", err)
+	}
+	if decoded.SiteID != "site-2" || decoded.SourceSiteID != "site-1" {
+		t.Fatalf("decoded = %+v, want trimmed ids", decoded)
+	}
+}
+
+func TestValidatePushSitePayloadNormalizesScopeAndTables(t *testing.T) {
+	for _, raw := range []string{
+		`{"target_site_id":"site-1"}`,
+		`{"site_id":"site-2","target_site_id":"site-2"}`,
+		`{"site_id":"site-2","target_site_id":"site-1","scope":"plugins"}`,
+		`{"site_id":"site-2","target_site_id":"site-1","scope":"files","exclude_tables":["wp_users"]}`,
+		`{"site_id":"site-2","target_site_id":"site-1","exclude_tables":["wp_users; DROP"]}`,
+	} {
+		if _, err := ValidatePayload(string(JobKindPushSite), []byte(raw), "server-1"); err == nil {
+			t.Fatalf("ValidatePayload(%s) succeeded, want error", raw)
+		}
+	}
+
+	raw, err := ValidatePayload(string(JobKindPushSite), []byte(`{"site_id":"site-2","target_site_id":"site-1","exclude_tables":[" wp_users ","wp_woocommerce_*",""],"dry_run":true}`), "server-1")
+	if err != nil {
+		t.Fatalf("ValidatePayload() error = %v", err)
+	}
+	decoded, err := UnmarshalPushSitePayload(raw)
+	if err != nil {
+		t.Fatalf("UnmarshalPushSitePayload() error = %v", err)
+	}
+	if decoded.Scope != PushScopeAll || !decoded.DryRun || len(decoded.ExcludeTables) != 2 {
+		t.Fatalf("decoded = %+v, want scope all, dry run, two exclusions", decoded)
+	}
+	for table, want := range map[string]bool{"wp_posts": true, "wp_users": false, "wp_woocommerce_order_items": false} {
+		if got := decoded.SelectsTable(table); got != want {
+			t.Fatalf("SelectsTable(%q) = %v, want %v", table, got, want)
+		}
+	}
+	onlyPosts := PushSitePayload{Scope: PushScopeDatabase, IncludeTables: []string{"wp_posts", "wp_postmeta"}}
+	if !onlyPosts.SelectsTable("wp_postmeta") || onlyPosts.SelectsTable("wp_options") || onlyPosts.IncludesFiles() {
+		t.Fatalf("include filter = %+v, want only posts tables and no files", onlyPosts)
+	}
+}
+
+func TestValidateUpdateSiteComponentsPayloadRequiresSelection(t *testing.T) {
+	for _, raw := range []string{
+		`{"core":true}`,
+		`{"site_id":"site-1"}`,
+		`{"site_id":"site-1","plugins":["*","akismet"]}`,
+		`{"site_id":"site-1","themes":["../twentyten"]}`,
+		`{"site_id":"site-1","core":true,"check_paths":["shop"]}`,
+	} {
+		if _, err := ValidatePayload(string(JobKindUpdateSiteComponents), []byte(raw), "server-1"); err == nil {
+			t.Fatalf("ValidatePayload(%s) succeeded, want error", raw)
+		}
+	}
+
+	raw, err := ValidatePayload(string(JobKindUpdateSiteComponents), []byte(`{"site_id":" site-1 ","plugins":[" woocommerce ",""],"themes":["*"]}`), "server-1")
+	if err != nil {
+		t.Fatalf("ValidatePayload() error = %v", err)
+	}
+	decoded, err := UnmarshalUpdateSiteComponentsPayload(raw)
+	if err != nil {
+		t.Fatalf("UnmarshalUpdateSiteComponentsPayload() error = %v", err)
+	}
+	if decoded.SiteID != "site-1" || decoded.Core || len(decoded.Plugins) != 1 || decoded.Plugins[0] != "woocommerce" || decoded.Themes[0] != UpdateAllComponents {
+		t.Fatalf("decoded = %+v, want trimmed plugin and all themes", decoded)
+	}
+	if len(decoded.CheckPaths) != 1 || decoded.CheckPaths[0] != "/" {
+		t.Fatalf("check_paths = %v, want home page default", decoded.CheckPaths)
+	}
+}
+
+func TestWithScheduleIDTagsPayload(t *testing.T) {
+	payload, err := MarshalBackupSitePayload(BackupSitePayload{SiteID: "site-1", TargetID: "target-1"})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	tagged, err := WithScheduleID(payload, "schedule-1")
+	if err != nil {
+		t.Fatalf("WithScheduleID() error = %v", err)
+	}
+	if got := ScheduleIDFromPayload(tagged); got != "schedule-1" {
+		t.Fatalf("ScheduleIDFromPayload() = %q, want schedule-1", got)
+	}
+	decoded, err := UnmarshalBackupSitePayload(tagged)
+	if err != nil || decoded.SiteID != "site-1" || decoded.TargetID != "target-1" {
+		t.Fatalf("decoded = %+v, err = %v; want original fields preserved", decoded, err)
+	}
+
+	empty, err := WithScheduleID("", "schedule-2")
+	if err != nil || ScheduleIDFromPayload(empty) != "schedule-2" {
+		t.Fatalf("empty payload tag = %q, err = %v", empty, err)
+	}
+	if got := ScheduleIDFromPayload(payload); got != "" {
+		t.Fatalf("ScheduleIDFromPayload(untagged) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"strings"
+	"time"
+)
+
+// ErrorClass tells the worker whether a failed attempt is worth retrying.
+type ErrorClass string
+
+const (
+	// ErrorClassTransient covers failures a later attempt can plausibly get
+	// past: network trouble, provider rate limits and outages.
+	ErrorClassTransient ErrorClass = "transient"
+	// ErrorClassPermanent covers everything else; retrying would only repeat
+	// the failure.
+	ErrorClassPermanent ErrorClass = "permanent"
+)
+
+// maxRetryDelay caps the exponential backoff between attempts.
+const maxRetryDelay = 30 * time.Minute
+
+// permanentMarkers win over transientMarkers: a message that mentions both a
+// timeout and bad credentials will not get better by retrying.
+var permanentMarkers = []string{
+	"permission denied",
+	"unauthorized",
+	"forbidden",
+	"invalid",
+	"not found",
+	"is required",
+	"not allowlisted",
+	"unsupported",
+}
+
+var transientMarkers = []string{
+	"timeout",
+	"timed out",
+	"deadline exceeded",
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"no route to host",
+	"network is unreachable",
+	"unreachable!",
+	"temporary failure in name resolution",
+	"tls handshake",
+	"unexpected eof",
+	"too many requests",
+	"rate limit",
+	"rate_limit_exceeded",
+	"service unavailable",
+	"service_error",
+	"bad gateway",
+	"gateway timeout",
+	"temporarily unavailable",
+	"try again",
+}
+
+// ClassifyMessage classifies a failure from its message. Runner, agent and
+// provider errors reach the worker as text, so the text is all there is.
+func ClassifyMessage(message string) ErrorClass {
+	lower := strings.ToLower(message)
+	for _, marker := range permanentMarkers {
+		if strings.Contains(lower, marker) {
+			return ErrorClassPermanent
+		}
+	}
+	for _, marker := range transientMarkers {
+		if strings.Contains(lower, marker) {
+			return ErrorClassTransient
+		}
+	}
+	return ErrorClassPermanent
+}
+
+// RetryDelay returns how long to wait before retrying a job of the given kind
+// that has already been retried retryCount times, and whether a retry is
+// allowed at all.
+func RetryDelay(kind string, retryCount int) (time.Duration, bool) {
+	spec, ok := JobKindPolicy(kind)
+	if !ok || retryCount >= spec.RetryLimit {
+		return 0, false
+	}
+	delay := spec.RetryBackoff
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	for i := 0; i < retryCount && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay, true
+}
+
+// JobAttempt groups the timeline events recorded during one attempt of a job.
+type JobAttempt struct {
+	Attempt   int        `json:"attempt"`
+	StartedAt string     `json:"started_at"`
+	EndedAt   string     `json:"ended_at,omitempty"`
+	Outcome   string     `json:"outcome"`
+	Events    []JobEvent `json:"events"`
+}
+
+// GroupEventsByAttempt splits a seq-ordered timeline into attempts. The
+// outcome of each attempt comes from the status its last event recorded;
+// an attempt without a closing event is still in progress.
+func GroupEventsByAttempt(events []JobEvent) []JobAttempt {
+	out := make([]JobAttempt, 0)
+	for _, event := range events {
+		attempt := event.Attempt
+		if attempt <= 0 {
+			attempt = 1
+		}
+		if len(out) == 0 || out[len(out)-1].Attempt != attempt {
+			out = append(out, JobAttempt{Attempt: attempt, StartedAt: event.OccurredAt, Outcome: "running"})
+		}
+		current := &out[len(out)-1]
+		current.Events = append(current.Events, event)
+		switch event.EventType {
+		case JobEventTypeRetrying, JobEventTypeRecovered, JobEventTypeFailed, JobEventTypeTimedOut, JobEventTypeSucceeded, JobEventTypeCancelled:
+			current.EndedAt = event.OccurredAt
+			current.Outcome = attemptOutcome(event)
+		}
+	}
+	return out
+}
+
+func attemptOutcome(event JobEvent) string {
+	switch JobStatus(event.Status) {
+	case JobStatusQueued:
+		return "retried"
+	case JobStatusSucceeded:
+		return "succeeded"
+	case JobStatusCancelled:
+		return "cancelled"
+	default:
+		return "failed"
+	}
+}
@@ -0,0 +1,44 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyMessage(t *testing.T) {
+	cases := []struct {
+		name    string
+		message string
+		want    ErrorClass
+	}{
+		{name: "connection refused", message: "dial tcp 203.0.113.10:22: connect: connection refused", want: ErrorClassTransient},
+		{name: "rate limited", message: "hcloud: rate limit exceeded (rate_limit_exceeded)", want: ErrorClassTransient},
+		{name: "bad credentials", message: "hcloud: unauthorized (unauthorized)", want: ErrorClassPermanent},
+		{name: "timeout with bad input", message: "request timed out: invalid server type", want: ErrorClassPermanent},
+		{name: "unknown", message: "playbook exited with status 2", want: ErrorClassPermanent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClassifyMessage(tc.message); got != tc.want {
+				t.Fatalf("ClassifyMessage(%q) = %q, want %q", tc.message, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelayDoublesUntilLimit(t *testing.T) {
+	kind := string(JobKindUpdateFirewalls)
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute}
+	for retryCount, expected := range want {
+		delay, ok := RetryDelay(kind, retryCount)
+		if !ok || delay != expected {
+			t.Fatalf("RetryDelay(%q, %d) = %s, %v; want %s, true", kind, retryCount, delay, ok, expected)
+		}
+	}
+	if _, ok := RetryDelay(kind, len(want)); ok {
+		t.Fatalf("RetryDelay(%q, %d) allowed a retry past the limit", kind, len(want))
+	}
+	if _, ok := RetryDelay(string(JobKindDeleteServer), 0); ok {
+		t.Fatal("RetryDelay allowed a retry for a kind without retries")
+	}
+}
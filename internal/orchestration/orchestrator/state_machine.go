@@ -4,12 +4,14 @@ import "fmt"
 
 var allowedTransitions = map[JobStatus]map[JobStatus]struct{}{
 	JobStatusQueued: {
-		JobStatusRunning: {},
-		JobStatusFailed:  {},
+		JobStatusRunning:   {},
+		JobStatusFailed:    {},
+		JobStatusCancelled: {},
 	},
 	JobStatusRunning: {
 		JobStatusSucceeded: {},
 		JobStatusFailed:    {},
+		JobStatusCancelled: {},
 	},
 }
 
@@ -37,7 +39,7 @@ func ValidateTransition(from, to JobStatus) error {
 // IsTerminalStatus reports whether the status can no longer transition.
 func IsTerminalStatus(status JobStatus) bool {
 	switch status {
-	case JobStatusSucceeded, JobStatusFailed:
+	case JobStatusSucceeded, JobStatusFailed, JobStatusCancelled:
 		return true
 	default:
 		return false
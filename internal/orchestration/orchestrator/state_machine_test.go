@@ -9,6 +9,12 @@ func TestCanTransition(t *testing.T) {
 	if CanTransition(JobStatusSucceeded, JobStatusRunning) {
 		t.Fatal("expected succeeded -> running to be invalid")
 	}
+	if !CanTransition(JobStatusRunning, JobStatusCancelled) {
+		t.Fatal("expected running -> cancelled to be valid")
+	}
+	if CanTransition(JobStatusCancelled, JobStatusQueued) {
+		t.Fatal("expected cancelled -> queued to be invalid")
+	}
 }
 
 func TestValidateTransition(t *testing.T) {
@@ -24,6 +30,9 @@ func TestIsTerminalStatus(t *testing.T) {
 	if !IsTerminalStatus(JobStatusSucceeded) {
 		t.Fatal("expected succeeded to be terminal")
 	}
+	if !IsTerminalStatus(JobStatusCancelled) {
+		t.Fatal("expected cancelled to be terminal")
+	}
 	if IsTerminalStatus(JobStatusRunning) {
 		t.Fatal("expected running to be non-terminal")
 	}
@@ -31,10 +40,10 @@ func TestIsTerminalStatus(t *testing.T) {
 
 func TestAllowedStatusesForKind(t *testing.T) {
 	statuses := AllowedStatusesForKind(string(JobKindDeleteServer))
-	if len(statuses) != 4 {
-		t.Fatalf("len(statuses) = %d, want 4", len(statuses))
+	if len(statuses) != 5 {
+		t.Fatalf("len(statuses) = %d, want 5", len(statuses))
 	}
-	if statuses[0] != JobStatusQueued || statuses[len(statuses)-1] != JobStatusFailed {
+	if statuses[0] != JobStatusQueued || statuses[len(statuses)-1] != JobStatusCancelled {
 		t.Fatalf("unexpected statuses: %#v", statuses)
 	}
 }
@@ -3,11 +3,13 @@ package orchestrator
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"pressluft/internal/shared/idutil"
+	"pressluft/internal/shared/workspace"
 )
 
 // Store persists orchestration jobs and timeline events.
@@ -27,15 +29,30 @@ func (s *Store) CreateJob(ctx context.Context, in CreateJobInput) (Job, error) {
 		return Job{}, fmt.Errorf("unsupported job kind: %s", in.Kind)
 	}
 
+	// A job scoped to a workspace may only target that workspace's servers
+	// and is filed under it. Unscoped control-plane jobs follow their server.
+	var scopedWorkspace any
+	if workspace.Filter(ctx) != "" {
+		if strings.TrimSpace(in.ServerID) != "" {
+			if _, err := lookupServerID(ctx, s.db, in.ServerID); err != nil {
+				return Job{}, err
+			}
+		}
+		scopedWorkspace = workspace.ForWrite(ctx)
+	}
+
 	now := nowRFC3339().Format(time.RFC3339)
 	publicID, err := idutil.New()
 	if err != nil {
 		return Job{}, err
 	}
 	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO jobs (id, server_id, kind, status, current_step, retry_count, last_error, payload, started_at, finished_at, timeout_at, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, '', 0, NULL, ?, NULL, NULL, NULL, ?, ?)`,
+		`INSERT INTO jobs (id, workspace_id, server_id, kind, status, current_step, retry_count, last_error, payload, started_at, finished_at, timeout_at, created_at, updated_at)
+		 VALUES (?, COALESCE(?, (SELECT workspace_id FROM servers WHERE id = ?), ?), ?, ?, ?, '', 0, NULL, ?, NULL, NULL, NULL, ?, ?)`,
 		publicID,
+		scopedWorkspace,
+		nullableString(in.ServerID),
+		workspace.ForWrite(ctx),
 		nullableString(in.ServerID),
 		in.Kind,
 		JobStatusQueued,
@@ -56,11 +73,11 @@ func (s *Store) GetJob(ctx context.Context, id string) (Job, error) {
 	}
 
 	row := s.db.QueryRowContext(ctx,
-		`SELECT j.id, COALESCE(s.id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id
+		`SELECT j.id, COALESCE(s.id, ''), COALESCE(s.provider_id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id, j.next_attempt_at
 		 FROM jobs j
 		 LEFT JOIN servers s ON s.id = j.server_id
-		 WHERE j.id = ?`,
-		publicID,
+		 WHERE j.id = ? AND (? = '' OR j.workspace_id = ?)`,
+		publicID, workspace.Filter(ctx), workspace.Filter(ctx),
 	)
 	job, err := scanJob(row)
 	if err != nil {
@@ -86,7 +103,7 @@ func (s *Store) SetCommandID(ctx context.Context, jobID string, commandID string
 
 func (s *Store) GetJobByCommandID(ctx context.Context, commandID string) (*Job, error) {
 	row := s.db.QueryRowContext(ctx,
-		`SELECT j.id, COALESCE(s.id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id
+		`SELECT j.id, COALESCE(s.id, ''), COALESCE(s.provider_id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id, j.next_attempt_at
 		 FROM jobs j
 		 LEFT JOIN servers s ON s.id = j.server_id
 		 WHERE j.command_id = ?`,
@@ -126,6 +143,11 @@ func (s *Store) TransitionJob(ctx context.Context, id string, in TransitionInput
 	startedAt := nullableString(current.StartedAt)
 	finishedAt := nullableString(current.FinishedAt)
 	timeoutAt := nullableString(current.TimeoutAt)
+	// retry_count is advanced by RetryJob; a transition never winds it back.
+	retryCount := current.RetryCount
+	if in.RetryCount > retryCount {
+		retryCount = in.RetryCount
+	}
 
 	switch in.ToStatus {
 	case JobStatusRunning:
@@ -139,7 +161,7 @@ func (s *Store) TransitionJob(ctx context.Context, id string, in TransitionInput
 			timeoutAt = nil
 		}
 		lastError = ""
-	case JobStatusSucceeded, JobStatusFailed:
+	case JobStatusSucceeded, JobStatusFailed, JobStatusCancelled:
 		finishedAt = nowText
 		timeoutAt = nil
 	}
@@ -150,7 +172,7 @@ func (s *Store) TransitionJob(ctx context.Context, id string, in TransitionInput
 		 WHERE id = ?`,
 		in.ToStatus,
 		currentStep,
-		in.RetryCount,
+		retryCount,
 		nullableString(lastError),
 		startedAt,
 		finishedAt,
@@ -166,27 +188,76 @@ func (s *Store) TransitionJob(ctx context.Context, id string, in TransitionInput
 }
 
 func (s *Store) MarkJobTimedOut(ctx context.Context, id string, message string) (Job, bool, error) {
-	return s.failActiveJob(ctx, id, JobEventTypeTimedOut, "error", message)
+	return s.finishActiveJob(ctx, id, JobStatusFailed, JobEventTypeTimedOut, "error", message)
+}
+
+// CancelJob moves a queued or running job to cancelled. The boolean reports
+// whether this call made the change; a job that already reached a terminal
+// status is returned unchanged.
+func (s *Store) CancelJob(ctx context.Context, id string, message string) (Job, bool, error) {
+	return s.finishActiveJob(ctx, id, JobStatusCancelled, JobEventTypeCancelled, "warning", message)
 }
 
 // ClaimNextJob atomically claims the oldest queued job by transitioning it to running.
+// Retries wait in the queue until their next_attempt_at has passed.
 // Returns nil, nil if no jobs are available.
 func (s *Store) ClaimNextJob(ctx context.Context) (*Job, error) {
+	return s.ClaimNextJobWithin(ctx, ClaimFilter{})
+}
+
+// ClaimFilter skips queued jobs the worker has no free slot for.
+type ClaimFilter struct {
+	BusyServerIDs   []string
+	BusyProviderIDs []string
+	// OccupiedServerIDs still have a free slot but already run a job, so
+	// destructive jobs, which need their server to themselves, wait.
+	OccupiedServerIDs []string
+}
+
+// ClaimNextJobWithin claims the oldest queued job that does not target a busy
+// server or a server of a busy provider, and no destructive job for an
+// occupied server. Jobs without a server are never skipped.
+func (s *Store) ClaimNextJobWithin(ctx context.Context, filter ClaimFilter) (*Job, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("begin claim tx: %w", err)
 	}
 	defer tx.Rollback()
 
-	row := tx.QueryRowContext(ctx,
-		`SELECT j.id, COALESCE(s.id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id
+	query := `SELECT j.id, COALESCE(s.id, ''), COALESCE(s.provider_id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id, j.next_attempt_at
 		 FROM jobs j
 		 LEFT JOIN servers s ON s.id = j.server_id
-		 WHERE j.status = ?
+		 WHERE j.status = ? AND (j.next_attempt_at IS NULL OR j.next_attempt_at <= ?)`
+	args := []any{JobStatusQueued, nowRFC3339().Format(time.RFC3339)}
+	if len(filter.BusyServerIDs) > 0 {
+		query += ` AND (j.server_id IS NULL OR j.server_id NOT IN (` + placeholders(len(filter.BusyServerIDs)) + `))`
+		for _, id := range filter.BusyServerIDs {
+			args = append(args, id)
+		}
+	}
+	if len(filter.BusyProviderIDs) > 0 {
+		query += ` AND (s.provider_id IS NULL OR s.provider_id NOT IN (` + placeholders(len(filter.BusyProviderIDs)) + `))`
+		for _, id := range filter.BusyProviderIDs {
+			args = append(args, id)
+		}
+	}
+	if len(filter.OccupiedServerIDs) > 0 {
+		var destructive []any
+		for _, spec := range supportedJobKinds {
+			if spec.Destructive {
+				destructive = append(destructive, string(spec.Kind))
+			}
+		}
+		query += ` AND NOT (j.server_id IN (` + placeholders(len(filter.OccupiedServerIDs)) + `) AND j.kind IN (` + placeholders(len(destructive)) + `))`
+		for _, id := range filter.OccupiedServerIDs {
+			args = append(args, id)
+		}
+		args = append(args, destructive...)
+	}
+	query += `
 		 ORDER BY j.created_at ASC
-		 LIMIT 1`,
-		JobStatusQueued,
-	)
+		 LIMIT 1`
+	row := tx.QueryRowContext(ctx, query, args...)
 	job, err := scanJob(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -207,7 +278,7 @@ func (s *Store) ClaimNextJob(ctx context.Context) (*Job, error) {
 	}
 	res, err := tx.ExecContext(ctx,
 		`UPDATE jobs
-		 SET status = ?, started_at = COALESCE(started_at, ?), finished_at = NULL, timeout_at = ?, updated_at = ?
+		 SET status = ?, started_at = COALESCE(started_at, ?), finished_at = NULL, timeout_at = ?, next_attempt_at = NULL, updated_at = ?
 		 WHERE id = ? AND status = ?`,
 		JobStatusRunning,
 		now.Format(time.RFC3339),
@@ -233,13 +304,16 @@ func (s *Store) ClaimNextJob(ctx context.Context) (*Job, error) {
 	return &claimed, nil
 }
 
-// ListAllJobs returns all jobs, ordered by created_at DESC.
+// ListAllJobs returns all jobs of the current workspace, ordered by
+// created_at DESC.
 func (s *Store) ListAllJobs(ctx context.Context) ([]Job, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT j.id, COALESCE(s.id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id
+		`SELECT j.id, COALESCE(s.id, ''), COALESCE(s.provider_id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id, j.next_attempt_at
 		 FROM jobs j
 		 LEFT JOIN servers s ON s.id = j.server_id
+		 WHERE (? = '' OR j.workspace_id = ?)
 		 ORDER BY j.created_at DESC`,
+		workspace.Filter(ctx), workspace.Filter(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list all jobs: %w", err)
@@ -257,7 +331,7 @@ func (s *Store) ListJobsByServer(ctx context.Context, serverID string) ([]Job, e
 	}
 
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT j.id, COALESCE(s.id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id
+		`SELECT j.id, COALESCE(s.id, ''), COALESCE(s.provider_id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id, j.next_attempt_at
 		 FROM jobs j
 		 LEFT JOIN servers s ON s.id = j.server_id
 		 WHERE j.server_id = ?
@@ -280,7 +354,7 @@ func (s *Store) GetLatestJobForServer(ctx context.Context, serverID string) (*Jo
 	}
 
 	row := s.db.QueryRowContext(ctx,
-		`SELECT j.id, COALESCE(s.id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id
+		`SELECT j.id, COALESCE(s.id, ''), COALESCE(s.provider_id, ''), j.kind, j.status, j.current_step, j.retry_count, j.last_error, j.payload, j.started_at, j.finished_at, j.timeout_at, j.created_at, j.updated_at, j.command_id, j.next_attempt_at
 		 FROM jobs j
 		 LEFT JOIN servers s ON s.id = j.server_id
 		 WHERE j.server_id = ?
@@ -298,12 +372,15 @@ func (s *Store) GetLatestJobForServer(ctx context.Context, serverID string) (*Jo
 	return &job, nil
 }
 
-// RecoverStuckJobs marks previously in-flight jobs as failed with an explicit recovery event.
+// RecoverStuckJobs settles previously in-flight jobs with an explicit recovery
+// event. Kinds with retries left are queued again after their backoff; the
+// rest are marked failed.
 func (s *Store) RecoverStuckJobs(ctx context.Context) (int64, error) {
 	const message = "worker restarted before job completion; outcome may be incomplete; inspect state before retrying"
+	const retryMessage = "worker restarted before job completion; retrying"
 
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id FROM jobs WHERE status = ? ORDER BY created_at ASC`,
+		`SELECT id, kind, retry_count FROM jobs WHERE status = ? ORDER BY created_at ASC`,
 		JobStatusRunning,
 	)
 	if err != nil {
@@ -311,30 +388,111 @@ func (s *Store) RecoverStuckJobs(ctx context.Context) (int64, error) {
 	}
 	defer rows.Close()
 
-	var ids []string
+	type recoverable struct {
+		id         string
+		kind       string
+		retryCount int
+	}
+	var jobs []recoverable
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var job recoverable
+		if err := rows.Scan(&job.id, &job.kind, &job.retryCount); err != nil {
 			return 0, fmt.Errorf("scan recoverable job id: %w", err)
 		}
-		ids = append(ids, id)
+		jobs = append(jobs, job)
 	}
 	if err := rows.Err(); err != nil {
 		return 0, fmt.Errorf("iterate recoverable jobs: %w", err)
 	}
 
 	var count int64
-	for _, id := range ids {
-		if _, changed, err := s.failActiveJob(ctx, id, JobEventTypeRecovered, "error", message); err != nil {
+	for _, job := range jobs {
+		var changed bool
+		var err error
+		if delay, ok := RetryDelay(job.kind, job.retryCount); ok {
+			_, changed, err = s.requeueRunningJob(ctx, job.id, JobEventTypeRecovered, retryMessage, delay)
+		} else {
+			_, changed, err = s.finishActiveJob(ctx, job.id, JobStatusFailed, JobEventTypeRecovered, "error", message)
+		}
+		if err != nil {
 			return count, err
-		} else if changed {
+		}
+		if changed {
 			count++
 		}
 	}
 	return count, nil
 }
 
-func (s *Store) failActiveJob(ctx context.Context, id string, eventType, level, message string) (Job, bool, error) {
+// RetryJob puts a running job back in the queue after a transient failure.
+// The job is claimed again once delay has passed. The boolean reports
+// whether this call made the change.
+func (s *Store) RetryJob(ctx context.Context, id string, lastError string, delay time.Duration) (Job, bool, error) {
+	return s.requeueRunningJob(ctx, id, JobEventTypeRetrying, lastError, delay)
+}
+
+func (s *Store) requeueRunningJob(ctx context.Context, id string, eventType, message string, delay time.Duration) (Job, bool, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return Job{}, false, err
+	}
+	if job.Status != JobStatusRunning {
+		return job, false, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Job{}, false, fmt.Errorf("begin job retry tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := nowRFC3339()
+	nextAttemptAt := now.Add(delay).Format(time.RFC3339)
+	// The event is appended first so it is grouped with the attempt that
+	// failed rather than the one being scheduled.
+	payload, _ := json.Marshal(map[string]any{"next_attempt": job.RetryCount + 2, "next_attempt_at": nextAttemptAt})
+	if _, err := appendEventTx(ctx, tx, job.ID, CreateEventInput{
+		EventType: eventType,
+		Level:     "warning",
+		StepKey:   job.CurrentStep,
+		Status:    string(JobStatusQueued),
+		Message:   message,
+		Payload:   string(payload),
+	}); err != nil {
+		return Job{}, false, err
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE jobs
+		 SET status = ?, current_step = '', retry_count = retry_count + 1, last_error = ?, timeout_at = NULL, next_attempt_at = ?, updated_at = ?
+		 WHERE id = ? AND status = ?`,
+		JobStatusQueued,
+		nullableString(message),
+		nextAttemptAt,
+		now.Format(time.RFC3339),
+		job.ID,
+		JobStatusRunning,
+	)
+	if err != nil {
+		return Job{}, false, fmt.Errorf("requeue job: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		current, getErr := s.GetJob(ctx, id)
+		if getErr != nil {
+			return Job{}, false, getErr
+		}
+		return current, false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return Job{}, false, fmt.Errorf("commit job retry tx: %w", err)
+	}
+	updated, err := s.GetJob(ctx, id)
+	if err != nil {
+		return Job{}, false, err
+	}
+	return updated, true, nil
+}
+
+func (s *Store) finishActiveJob(ctx context.Context, id string, status JobStatus, eventType, level, message string) (Job, bool, error) {
 	job, err := s.GetJob(ctx, id)
 	if err != nil {
 		return Job{}, false, err
@@ -345,7 +503,7 @@ func (s *Store) failActiveJob(ctx context.Context, id string, eventType, level,
 
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return Job{}, false, fmt.Errorf("begin job failure tx: %w", err)
+		return Job{}, false, fmt.Errorf("begin job finish tx: %w", err)
 	}
 	defer tx.Rollback()
 
@@ -354,7 +512,7 @@ func (s *Store) failActiveJob(ctx context.Context, id string, eventType, level,
 		`UPDATE jobs
 		 SET status = ?, last_error = ?, finished_at = ?, timeout_at = NULL, updated_at = ?
 		 WHERE id = ? AND status IN (?, ?)`,
-		JobStatusFailed,
+		status,
 		message,
 		now,
 		now,
@@ -363,7 +521,7 @@ func (s *Store) failActiveJob(ctx context.Context, id string, eventType, level,
 		JobStatusRunning,
 	)
 	if err != nil {
-		return Job{}, false, fmt.Errorf("mark job failed: %w", err)
+		return Job{}, false, fmt.Errorf("mark job %s: %w", status, err)
 	}
 	if rows, _ := res.RowsAffected(); rows == 0 {
 		current, getErr := s.GetJob(ctx, id)
@@ -377,14 +535,14 @@ func (s *Store) failActiveJob(ctx context.Context, id string, eventType, level,
 		EventType: eventType,
 		Level:     level,
 		StepKey:   job.CurrentStep,
-		Status:    string(JobStatusFailed),
+		Status:    string(status),
 		Message:   message,
 	}); err != nil {
 		return Job{}, false, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return Job{}, false, fmt.Errorf("commit job failure tx: %w", err)
+		return Job{}, false, fmt.Errorf("commit job finish tx: %w", err)
 	}
 	updated, err := s.GetJob(ctx, id)
 	if err != nil {
@@ -422,10 +580,12 @@ func scanJob(scanner rowScanner) (Job, error) {
 		finishedAt sql.NullString
 		timeoutAt  sql.NullString
 		commandID  sql.NullString
+		nextAt     sql.NullString
 	)
 	err := scanner.Scan(
 		&job.ID,
 		&serverID,
+		&job.ProviderID,
 		&job.Kind,
 		&job.Status,
 		&job.CurrentStep,
@@ -438,6 +598,7 @@ func scanJob(scanner rowScanner) (Job, error) {
 		&job.CreatedAt,
 		&job.UpdatedAt,
 		&commandID,
+		&nextAt,
 	)
 	if err != nil {
 		return Job{}, err
@@ -448,12 +609,17 @@ func scanJob(scanner rowScanner) (Job, error) {
 	job.StartedAt = nullString(startedAt)
 	job.FinishedAt = nullString(finishedAt)
 	job.TimeoutAt = nullString(timeoutAt)
+	job.NextAttemptAt = nullString(nextAt)
 	if commandID.Valid {
 		job.CommandID = &commandID.String
 	}
 	return job, nil
 }
 
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
 func nowRFC3339() time.Time {
 	return time.Now().UTC()
 }
@@ -486,7 +652,11 @@ func (s *Store) lookupJobID(ctx context.Context, id string) (string, error) {
 		return "", err
 	}
 	var jobID string
-	if err := s.db.QueryRowContext(ctx, `SELECT id FROM jobs WHERE id = ?`, publicID).Scan(&jobID); err != nil {
+	scope := workspace.Filter(ctx)
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT id FROM jobs WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		publicID, scope, scope,
+	).Scan(&jobID); err != nil {
 		if err == sql.ErrNoRows {
 			return "", fmt.Errorf("job %s not found", publicID)
 		}
@@ -501,7 +671,11 @@ func lookupServerID(ctx context.Context, db *sql.DB, id string) (string, error)
 		return "", err
 	}
 	var serverID string
-	if err := db.QueryRowContext(ctx, `SELECT id FROM servers WHERE id = ?`, publicID).Scan(&serverID); err != nil {
+	scope := workspace.Filter(ctx)
+	if err := db.QueryRowContext(ctx,
+		`SELECT id FROM servers WHERE id = ? AND (? = '' OR workspace_id = ?)`,
+		publicID, scope, scope,
+	).Scan(&serverID); err != nil {
 		if err == sql.ErrNoRows {
 			return "", fmt.Errorf("server %s not found", publicID)
 		}
@@ -51,7 +51,7 @@ func (s *Store) ListEvents(ctx context.Context, jobID string, afterSeq int64, li
 	}
 
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT e.id, j.id, e.seq, e.event_type, e.level, e.step_key, e.status, e.message, e.payload, e.created_at
+		`SELECT e.id, j.id, e.seq, e.attempt, e.event_type, e.level, e.step_key, e.status, e.message, e.payload, e.created_at
 		 FROM job_events e
 		 JOIN jobs j ON j.id = e.job_id
 		 WHERE e.job_id = ? AND e.seq > ?
@@ -77,7 +77,7 @@ func (s *Store) ListAllEvents(ctx context.Context, jobID string) ([]JobEvent, er
 	}
 
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT e.id, j.id, e.seq, e.event_type, e.level, e.step_key, e.status, e.message, e.payload, e.created_at
+		`SELECT e.id, j.id, e.seq, e.attempt, e.event_type, e.level, e.step_key, e.status, e.message, e.payload, e.created_at
 		 FROM job_events e
 		 JOIN jobs j ON j.id = e.job_id
 		 WHERE e.job_id = ?
@@ -92,6 +92,8 @@ func (s *Store) ListAllEvents(ctx context.Context, jobID string) ([]JobEvent, er
 	return scanEvents(rows)
 }
 
+// appendEventTx records an event against the job's current attempt, so the
+// timeline can be grouped by attempt once a job has been retried.
 func appendEventTx(ctx context.Context, tx *sql.Tx, jobID string, in CreateEventInput) (JobEvent, error) {
 	var seq int64
 	if err := tx.QueryRowContext(ctx,
@@ -100,6 +102,13 @@ func appendEventTx(ctx context.Context, tx *sql.Tx, jobID string, in CreateEvent
 	).Scan(&seq); err != nil {
 		return JobEvent{}, fmt.Errorf("next event seq: %w", err)
 	}
+	var attempt int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT retry_count + 1 FROM jobs WHERE id = ?`,
+		jobID,
+	).Scan(&attempt); err != nil {
+		return JobEvent{}, fmt.Errorf("current job attempt: %w", err)
+	}
 
 	eventID, err := idutil.New()
 	if err != nil {
@@ -107,11 +116,12 @@ func appendEventTx(ctx context.Context, tx *sql.Tx, jobID string, in CreateEvent
 	}
 	now := nowRFC3339().Format(time.RFC3339)
 	_, err = tx.ExecContext(ctx,
-		`INSERT INTO job_events (id, job_id, seq, event_type, level, step_key, status, message, payload, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO job_events (id, job_id, seq, attempt, event_type, level, step_key, status, message, payload, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		eventID,
 		jobID,
 		seq,
+		attempt,
 		in.EventType,
 		in.Level,
 		nullableString(in.StepKey),
@@ -128,6 +138,7 @@ func appendEventTx(ctx context.Context, tx *sql.Tx, jobID string, in CreateEvent
 		ID:         eventID,
 		JobID:      jobID,
 		Seq:        seq,
+		Attempt:    attempt,
 		EventType:  in.EventType,
 		Level:      in.Level,
 		StepKey:    strings.TrimSpace(in.StepKey),
@@ -151,6 +162,7 @@ func scanEvents(rows *sql.Rows) ([]JobEvent, error) {
 			&e.ID,
 			&e.JobID,
 			&e.Seq,
+			&e.Attempt,
 			&e.EventType,
 			&e.Level,
 			&stepKey,
@@ -112,6 +112,121 @@ func TestMarkJobTimedOutAppendsTimeoutEvent(t *testing.T) {
 	}
 }
 
+func TestCancelJobOnlyChangesActiveJobs(t *testing.T) {
+	store := newTestStore(t)
+	queued, err := store.CreateJob(context.Background(), CreateJobInput{
+		Kind:     string(JobKindRestartService),
+		ServerID: "00000000-0000-7000-8000-000000000012",
+	})
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	cancelled, changed, err := store.CancelJob(context.Background(), queued.ID, "cancelled by operator")
+	if err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	if !changed || cancelled.Status != JobStatusCancelled || cancelled.FinishedAt == "" {
+		t.Fatalf("cancelled job = %+v (changed %v), want a finished cancelled job", cancelled, changed)
+	}
+	events, err := store.ListAllEvents(context.Background(), queued.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != JobEventTypeCancelled {
+		t.Fatalf("events = %+v, want a single cancellation event", events)
+	}
+
+	if _, changed, err := store.CancelJob(context.Background(), queued.ID, "cancelled again"); err != nil || changed {
+		t.Fatalf("second cancel changed = %v (err %v), want a no-op", changed, err)
+	}
+	if _, changed, err := store.MarkJobTimedOut(context.Background(), queued.ID, "late timeout"); err != nil || changed {
+		t.Fatalf("timeout after cancel changed = %v (err %v), want a no-op", changed, err)
+	}
+}
+
+func TestRetryJobWaitsForBackoffAndGroupsEventsByAttempt(t *testing.T) {
+	store := newTestStore(t)
+	job := mustCreateAndClaimJob(t, store, CreateJobInput{
+		Kind:     string(JobKindUpdateFirewalls),
+		ServerID: "00000000-0000-7000-8000-000000000007",
+	})
+	if _, err := store.AppendEvent(context.Background(), job.ID, CreateEventInput{EventType: JobEventTypeStepStarted, Level: "info", StepKey: "update_firewalls", Message: "Updating firewalls"}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+
+	retried, changed, err := store.RetryJob(context.Background(), job.ID, "hetzner api: service unavailable", time.Hour)
+	if err != nil {
+		t.Fatalf("retry job: %v", err)
+	}
+	if !changed || retried.Status != JobStatusQueued || retried.RetryCount != 1 || retried.NextAttemptAt == "" {
+		t.Fatalf("retried job = %+v (changed %v), want a queued job with a scheduled next attempt", retried, changed)
+	}
+	if claimed, err := store.ClaimNextJob(context.Background()); err != nil || claimed != nil {
+		t.Fatalf("claim during backoff = %+v (err %v), want nothing claimable", claimed, err)
+	}
+
+	if _, err := store.db.Exec(`UPDATE jobs SET next_attempt_at = ? WHERE id = ?`, time.Now().Add(-time.Second).UTC().Format(time.RFC3339), job.ID); err != nil {
+		t.Fatalf("expire backoff: %v", err)
+	}
+	claimed, err := store.ClaimNextJob(context.Background())
+	if err != nil || claimed == nil {
+		t.Fatalf("claim after backoff = %+v (err %v), want the retried job", claimed, err)
+	}
+	if claimed.NextAttemptAt != "" || claimed.RetryCount != 1 {
+		t.Fatalf("claimed job = %+v, want cleared next_attempt_at and retry_count 1", claimed)
+	}
+	if _, err := store.TransitionJob(context.Background(), job.ID, TransitionInput{ToStatus: JobStatusSucceeded}); err != nil {
+		t.Fatalf("complete job: %v", err)
+	}
+	if _, err := store.AppendEvent(context.Background(), job.ID, CreateEventInput{EventType: JobEventTypeSucceeded, Level: "info", Status: string(JobStatusSucceeded), Message: "done"}); err != nil {
+		t.Fatalf("append event: %v", err)
+	}
+
+	completed, err := store.GetJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if completed.RetryCount != 1 {
+		t.Fatalf("retry_count after transition = %d, want 1", completed.RetryCount)
+	}
+
+	events, err := store.ListAllEvents(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	attempts := GroupEventsByAttempt(events)
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %+v, want 2", attempts)
+	}
+	if attempts[0].Attempt != 1 || attempts[0].Outcome != "retried" || len(attempts[0].Events) != 2 || attempts[0].Events[1].EventType != JobEventTypeRetrying {
+		t.Fatalf("first attempt = %+v, want the step and the retry event", attempts[0])
+	}
+	if attempts[1].Attempt != 2 || attempts[1].Outcome != "succeeded" {
+		t.Fatalf("second attempt = %+v, want a succeeded attempt 2", attempts[1])
+	}
+}
+
+func TestRecoverStuckJobsRequeuesRetryableKinds(t *testing.T) {
+	store := newTestStore(t)
+	job := mustCreateAndClaimJob(t, store, CreateJobInput{Kind: string(JobKindConfigureServer)})
+
+	recovered, err := store.RecoverStuckJobs(context.Background())
+	if err != nil {
+		t.Fatalf("recover stuck jobs: %v", err)
+	}
+	if recovered != 1 {
+		t.Fatalf("recovered = %d, want 1", recovered)
+	}
+	updated, err := store.GetJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if updated.Status != JobStatusQueued || updated.RetryCount != 1 || updated.NextAttemptAt == "" || updated.FinishedAt != "" {
+		t.Fatalf("recovered job = %+v, want a queued retry", updated)
+	}
+}
+
 func newTestStore(t *testing.T) *Store {
 	t.Helper()
 	db, err := sql.Open("sqlite", "file::memory:?cache=shared")
@@ -121,10 +236,13 @@ func newTestStore(t *testing.T) *Store {
 	t.Cleanup(func() { _ = db.Close() })
 	if _, err := db.Exec(`
 		CREATE TABLE servers (
-			id TEXT PRIMARY KEY
+			id          TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			provider_id TEXT
 		);
 		CREATE TABLE jobs (
 			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id    TEXT,
 			kind         TEXT    NOT NULL,
 			status       TEXT    NOT NULL,
@@ -136,6 +254,8 @@ func newTestStore(t *testing.T) *Store {
 			finished_at  TEXT,
 			timeout_at   TEXT,
 			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
 			created_at   TEXT    NOT NULL,
 			updated_at   TEXT    NOT NULL
 		);
@@ -143,6 +263,7 @@ func newTestStore(t *testing.T) *Store {
 			id         TEXT PRIMARY KEY,
 			job_id     TEXT    NOT NULL,
 			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
 			event_type TEXT    NOT NULL,
 			level      TEXT    NOT NULL,
 			step_key   TEXT,
@@ -16,6 +16,7 @@ const (
 	JobStatusRunning   JobStatus = "running"
 	JobStatusSucceeded JobStatus = "succeeded"
 	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
 )
 
 const (
@@ -27,21 +28,33 @@ const (
 	JobEventTypeFailed       = "job_failed"
 	JobEventTypeRecovered    = "job_recovered"
 	JobEventTypeTimedOut     = "job_timed_out"
+	JobEventTypeCancelled    = "job_cancelled"
+	JobEventTypeRetrying     = "job_retry_scheduled"
+	JobEventTypeDiffSummary  = "diff_summary"
+	// JobEventTypeProviderAction reports progress of an action running at
+	// the server's cloud provider; its payload is a ProviderActionEvent.
+	JobEventTypeProviderAction = "provider_action"
 )
 
 // JobKind is the canonical identifier for a supported orchestration workflow.
 type JobKind string
 
 const (
-	JobKindProvisionServer JobKind = "provision_server"
-	JobKindConfigureServer JobKind = "configure_server"
-	JobKindDeleteServer    JobKind = "delete_server"
-	JobKindRebuildServer   JobKind = "rebuild_server"
-	JobKindResizeServer    JobKind = "resize_server"
-	JobKindUpdateFirewalls JobKind = "update_firewalls"
-	JobKindManageVolume    JobKind = "manage_volume"
-	JobKindRestartService  JobKind = "restart_service"
-	JobKindDeploySite      JobKind = "deploy_site"
+	JobKindProvisionServer      JobKind = "provision_server"
+	JobKindConfigureServer      JobKind = "configure_server"
+	JobKindConnectServer        JobKind = "connect_server"
+	JobKindDeleteServer         JobKind = "delete_server"
+	JobKindRebuildServer        JobKind = "rebuild_server"
+	JobKindResizeServer         JobKind = "resize_server"
+	JobKindUpdateFirewalls      JobKind = "update_firewalls"
+	JobKindManageVolume         JobKind = "manage_volume"
+	JobKindRestartService       JobKind = "restart_service"
+	JobKindDeploySite           JobKind = "deploy_site"
+	JobKindBackupSite           JobKind = "backup_site"
+	JobKindRestoreSite          JobKind = "restore_site"
+	JobKindCreateStaging        JobKind = "create_staging"
+	JobKindPushSite             JobKind = "push_site"
+	JobKindUpdateSiteComponents JobKind = "update_site_components"
 )
 
 type JobKindSpec struct {
@@ -54,6 +67,9 @@ type JobKindSpec struct {
 	DispatchPolicy  DispatchPolicy
 	Timeout         time.Duration
 	RetryLimit      int
+	// RetryBackoff is the delay before the first retry; each further retry
+	// doubles it. Only transient failures are retried.
+	RetryBackoff    time.Duration
 	Recovery        string
 	QueuedStatus    platform.ServerStatus
 	Steps           []WorkflowStep
@@ -72,15 +88,21 @@ type WorkflowStep struct {
 type JobPayloadValidator func(json.RawMessage, string) (string, error)
 
 var supportedJobKinds = []JobKindSpec{
-	{Kind: JobKindProvisionServer, Label: "Server infrastructure provisioning", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 30 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect provider state before retrying manually", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "provision", Label: "Provisioning infrastructure"}}, ValidatePayload: validateProvisionServerPayload},
-	{Kind: JobKindConfigureServer, Label: "Server setup", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 30 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; retry setup manually after inspection", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "configure", Label: "Configuring server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateConfigureServerPayload},
-	{Kind: JobKindDeleteServer, Label: "Server deletion", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, Destructive: true, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 20 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; verify provider-side deletion before retrying manually", QueuedStatus: platform.ServerStatusDeleting, Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "delete", Label: "Deleting server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateDeleteServerPayload},
-	{Kind: JobKindRebuildServer, Label: "Server rebuild", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, Destructive: true, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 45 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect machine state before retrying manually", QueuedStatus: platform.ServerStatusRebuilding, Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "rebuild", Label: "Rebuilding server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateRebuildServerPayload},
-	{Kind: JobKindResizeServer, Label: "Server resize", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, Destructive: true, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 20 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect provider-side resize state before retrying manually", QueuedStatus: platform.ServerStatusResizing, Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "resize", Label: "Resizing server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateResizeServerPayload},
-	{Kind: JobKindUpdateFirewalls, Label: "Firewall update", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 15 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; retry manually after inspection", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "update_firewalls", Label: "Updating firewalls"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateUpdateFirewallsPayload},
-	{Kind: JobKindManageVolume, Label: "Volume management", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 20 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; retry manually after inspection", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "manage_volume", Label: "Managing volume"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateManageVolumePayload},
-	{Kind: JobKindRestartService, Label: "Service restart", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, Experimental: true, ExecutionPath: "agent", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 2 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption or timeout; late agent results are ignored", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "restart_service", Label: "Restarting service"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateRestartServicePayload},
-	{Kind: JobKindDeploySite, Label: "Site deployment", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 25 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect site files, database, and routing before retrying manually", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "deploy", Label: "Deploying site"}, {Key: "verify", Label: "Verifying site routing"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateDeploySitePayload},
+	{Kind: JobKindProvisionServer, Label: "Server infrastructure provisioning", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 30 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect provider state before retrying manually", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "provision", Label: "Provisioning infrastructure"}}, ValidatePayload: validateProvisionServerPayload},
+	{Kind: JobKindConfigureServer, Label: "Server setup", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 30 * time.Minute, RetryLimit: 2, RetryBackoff: 1 * time.Minute, Recovery: "retried with backoff on transient errors; otherwise mark failed and retry setup manually after inspection", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "configure", Label: "Configuring server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateConfigureServerPayload},
+	{Kind: JobKindConnectServer, Label: "Server connection", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 5 * time.Minute, RetryLimit: 2, RetryBackoff: 30 * time.Second, Recovery: "retried with backoff when the server is unreachable; otherwise mark failed, fix SSH access or the operating system and connect again", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "connect", Label: "Connecting over SSH"}, {Key: "verify", Label: "Checking operating system and privileges"}, {Key: "finalize", Label: "Queueing server setup"}}, ValidatePayload: validateConnectServerPayload},
+	{Kind: JobKindDeleteServer, Label: "Server deletion", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, Destructive: true, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 20 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; verify provider-side deletion before retrying manually", QueuedStatus: platform.ServerStatusDeleting, Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "delete", Label: "Deleting server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateDeleteServerPayload},
+	{Kind: JobKindRebuildServer, Label: "Server rebuild", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, Destructive: true, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 45 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect machine state before retrying manually", QueuedStatus: platform.ServerStatusRebuilding, Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "rebuild", Label: "Rebuilding server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateRebuildServerPayload},
+	{Kind: JobKindResizeServer, Label: "Server resize", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, Destructive: true, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 20 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; inspect provider-side resize state before retrying manually", QueuedStatus: platform.ServerStatusResizing, Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "resize", Label: "Resizing server"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateResizeServerPayload},
+	{Kind: JobKindUpdateFirewalls, Label: "Firewall update", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 15 * time.Minute, RetryLimit: 3, RetryBackoff: 30 * time.Second, Recovery: "retried with backoff on transient provider errors; otherwise mark failed and retry manually after inspection", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "update_firewalls", Label: "Updating firewalls"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateUpdateFirewallsPayload},
+	{Kind: JobKindManageVolume, Label: "Volume management", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, Experimental: true, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 20 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; retry manually after inspection", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "manage_volume", Label: "Managing volume"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateManageVolumePayload},
+	{Kind: JobKindRestartService, Label: "Service restart", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, Experimental: true, ExecutionPath: "agent", DispatchPolicy: DispatchPolicy{QueueServer: true}, Timeout: 2 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption or timeout; late agent results are ignored", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "restart_service", Label: "Restarting service"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateRestartServicePayload},
+	{Kind: JobKindDeploySite, Label: "Site deployment", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 25 * time.Minute, RetryLimit: 2, RetryBackoff: 1 * time.Minute, Recovery: "retried with backoff on transient errors; otherwise mark failed and inspect site files, database, and routing before retrying manually", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "deploy", Label: "Deploying site"}, {Key: "verify", Label: "Verifying site routing"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateDeploySitePayload},
+	{Kind: JobKindBackupSite, Label: "Site backup", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 60 * time.Minute, RetryLimit: 2, RetryBackoff: 2 * time.Minute, Recovery: "retried with backoff on transient errors; the partial archive is never recorded as a usable backup", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "backup", Label: "Archiving and uploading site"}, {Key: "verify", Label: "Verifying uploaded archive"}, {Key: "finalize", Label: "Applying retention"}}, ValidatePayload: validateBackupSitePayload},
+	{Kind: JobKindRestoreSite, Label: "Site restore", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 90 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; the pre-restore snapshot stays on the server for manual rollback", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "snapshot", Label: "Taking pre-restore snapshot"}, {Key: "restore", Label: "Restoring files and database"}, {Key: "verify", Label: "Verifying restored site"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateRestoreSitePayload},
+	{Kind: JobKindCreateStaging, Label: "Staging environment creation", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 60 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; the production site is only read, so delete the staging site and create it again", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "clone", Label: "Cloning files and database"}, {Key: "verify", Label: "Verifying staging routing"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateCreateStagingPayload},
+	{Kind: JobKindPushSite, Label: "Push to live", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 90 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; the pre-push backup stays on the server for manual rollback", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "diff", Label: "Comparing staging with live"}, {Key: "backup", Label: "Backing up live site"}, {Key: "push", Label: "Pushing changes to live"}, {Key: "verify", Label: "Verifying live site"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validatePushSitePayload},
+	{Kind: JobKindUpdateSiteComponents, Label: "Site component update", AllowedStatuses: []JobStatus{JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled}, ExecutionPath: "worker", DispatchPolicy: DispatchPolicy{QueueServer: false}, Timeout: 60 * time.Minute, RetryLimit: 0, Recovery: "mark failed on worker interruption; the pre-update snapshot stays on the server for manual rollback", Steps: []WorkflowStep{{Key: "validate", Label: "Validating request"}, {Key: "snapshot", Label: "Taking pre-update snapshot"}, {Key: "update", Label: "Applying updates"}, {Key: "verify", Label: "Comparing site before and after"}, {Key: "finalize", Label: "Finalizing"}}, ValidatePayload: validateUpdateSiteComponentsPayload},
 }
 
 // SupportedJobKinds returns the current canonical job-kind contract.
@@ -164,20 +186,22 @@ func ValidatePayload(kind string, payload json.RawMessage, serverID string) (str
 
 // Job is the persisted orchestration unit.
 type Job struct {
-	ID          string    `json:"id"`
-	ServerID    string    `json:"server_id,omitempty"`
-	Kind        string    `json:"kind"`
-	Status      JobStatus `json:"status"`
-	CurrentStep string    `json:"current_step"`
-	RetryCount  int       `json:"retry_count"`
-	LastError   string    `json:"last_error,omitempty"`
-	Payload     string    `json:"payload,omitempty"`
-	StartedAt   string    `json:"started_at,omitempty"`
-	FinishedAt  string    `json:"finished_at,omitempty"`
-	TimeoutAt   string    `json:"timeout_at,omitempty"`
-	CreatedAt   string    `json:"created_at"`
-	UpdatedAt   string    `json:"updated_at"`
-	CommandID   *string   `json:"command_id,omitempty"`
+	ID            string    `json:"id"`
+	ServerID      string    `json:"server_id,omitempty"`
+	ProviderID    string    `json:"provider_id,omitempty"`
+	Kind          string    `json:"kind"`
+	Status        JobStatus `json:"status"`
+	CurrentStep   string    `json:"current_step"`
+	RetryCount    int       `json:"retry_count"`
+	LastError     string    `json:"last_error,omitempty"`
+	Payload       string    `json:"payload,omitempty"`
+	StartedAt     string    `json:"started_at,omitempty"`
+	FinishedAt    string    `json:"finished_at,omitempty"`
+	TimeoutAt     string    `json:"timeout_at,omitempty"`
+	CreatedAt     string    `json:"created_at"`
+	UpdatedAt     string    `json:"updated_at"`
+	CommandID     *string   `json:"command_id,omitempty"`
+	NextAttemptAt string    `json:"next_attempt_at,omitempty"`
 }
 
 // JobEvent is an ordered event entry consumed by the dashboard.
@@ -185,6 +209,7 @@ type JobEvent struct {
 	ID         string `json:"id"`
 	JobID      string `json:"job_id"`
 	Seq        int64  `json:"seq"`
+	Attempt    int    `json:"attempt"`
 	EventType  string `json:"event_type"`
 	Level      string `json:"level"`
 	StepKey    string `json:"step_key,omitempty"`
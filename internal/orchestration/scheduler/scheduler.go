@@ -0,0 +1,273 @@
+// Package scheduler turns recurring schedules into queued jobs.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/shared/cronexpr"
+	"pressluft/internal/shared/workspace"
+)
+
+// maxCountedMissedRuns caps how far back a missed-run count is computed for
+// schedules that were overdue for a long time.
+const maxCountedMissedRuns = 1000
+
+// ScheduleStore defines the schedule persistence interface needed by the scheduler.
+type ScheduleStore interface {
+	ListDue(ctx context.Context, now time.Time) ([]serverpkg.StoredSchedule, error)
+	RecordRun(ctx context.Context, id, jobID string, ranAt, nextRunAt time.Time) error
+	RecordMissedRun(ctx context.Context, id, reason string, nextRunAt time.Time) error
+}
+
+// ServerJobQueuer queues jobs whose dispatch policy reserves the server, so
+// scheduled runs get the same conflict checks as jobs queued over the API.
+type ServerJobQueuer interface {
+	QueueServerJob(ctx context.Context, in serverpkg.QueueServerJobInput) (serverpkg.StoredServer, orchestrator.Job, error)
+}
+
+// Config holds scheduler configuration.
+type Config struct {
+	// PollInterval is how often due schedules are evaluated.
+	PollInterval time.Duration
+	// MisfireGrace is how late a run may start before it counts as missed
+	// and the schedule's missed-run policy applies.
+	MisfireGrace time.Duration
+}
+
+// DefaultConfig returns sensible defaults.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: 30 * time.Second,
+		MisfireGrace: 5 * time.Minute,
+	}
+}
+
+// Scheduler queues jobs for due schedules.
+type Scheduler struct {
+	scheduleStore ScheduleStore
+	jobStore      *orchestrator.Store
+	serverQueuer  ServerJobQueuer
+	activityStore *activity.Store
+	config        Config
+	logger        *slog.Logger
+}
+
+// New creates a scheduler with the given dependencies.
+func New(scheduleStore ScheduleStore, jobStore *orchestrator.Store, serverQueuer ServerJobQueuer, activityStore *activity.Store, logger *slog.Logger, config Config) *Scheduler {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.MisfireGrace <= 0 {
+		config.MisfireGrace = defaults.MisfireGrace
+	}
+	return &Scheduler{
+		scheduleStore: scheduleStore,
+		jobStore:      jobStore,
+		serverQueuer:  serverQueuer,
+		activityStore: activityStore,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// Run starts the scheduling loop. It blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("scheduler started", "poll_interval", s.config.PollInterval, "misfire_grace", s.config.MisfireGrace)
+
+	// Evaluate immediately so runs missed during downtime are handled on
+	// startup rather than one interval later.
+	s.tick(ctx, time.Now().UTC())
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("scheduler shutting down")
+			return
+		case <-ticker.C:
+			s.tick(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	due, err := s.scheduleStore.ListDue(ctx, now)
+	if err != nil {
+		s.logger.Error("list due schedules failed", "error", err)
+		return
+	}
+	for _, schedule := range due {
+		s.runSchedule(ctx, schedule, now)
+	}
+}
+
+func (s *Scheduler) runSchedule(ctx context.Context, schedule serverpkg.StoredSchedule, now time.Time) {
+	// Jobs and activity of a schedule belong to the workspace it was
+	// created in.
+	if schedule.WorkspaceID != "" {
+		ctx = workspace.WithID(ctx, schedule.WorkspaceID)
+	}
+	nextRunAt, err := serverpkg.NextScheduleRun(schedule.CronExpression, schedule.Timezone, schedule.JitterSeconds, now)
+	if err != nil {
+		s.logger.Error("schedule next run computation failed", "schedule_id", schedule.ID, "error", err)
+		return
+	}
+	dueAt, err := time.Parse(time.RFC3339, schedule.NextRunAt)
+	if err != nil {
+		s.logger.Error("schedule next_run_at is invalid", "schedule_id", schedule.ID, "next_run_at", schedule.NextRunAt, "error", err)
+		return
+	}
+
+	missedRuns := 0
+	if now.Sub(dueAt) > s.config.MisfireGrace {
+		missedRuns = countMissedRuns(schedule, dueAt, now)
+		if schedule.MissedRunPolicy != serverpkg.ScheduleMissedRunCatchUp {
+			reason := fmt.Sprintf("Skipped %d missed run(s) due since %s.", missedRuns, dueAt.Format(time.RFC3339))
+			if err := s.scheduleStore.RecordMissedRun(ctx, schedule.ID, reason, nextRunAt); err != nil {
+				s.logger.Error("schedule missed run persistence failed", "schedule_id", schedule.ID, "error", err)
+			}
+			s.emitActivity(ctx, activity.EmitInput{
+				EventType:    activity.EventScheduleRunSkipped,
+				Category:     activity.CategorySchedule,
+				Level:        activity.LevelWarning,
+				ResourceType: activity.ResourceSchedule,
+				ResourceID:   schedule.ID,
+				ActorType:    activity.ActorSystem,
+				Title:        fmt.Sprintf("Schedule '%s' skipped missed runs", schedule.Name),
+				Message:      reason,
+			})
+			s.logger.Warn("schedule missed runs skipped", "schedule_id", schedule.ID, "missed_runs", missedRuns, "due_at", dueAt)
+			return
+		}
+	}
+
+	job, err := s.enqueue(ctx, schedule)
+	if err != nil {
+		reason := fmt.Sprintf("Failed to queue %s: %v", orchestrator.JobKindLabel(schedule.JobKind), err)
+		if err := s.scheduleStore.RecordMissedRun(ctx, schedule.ID, reason, nextRunAt); err != nil {
+			s.logger.Error("schedule missed run persistence failed", "schedule_id", schedule.ID, "error", err)
+		}
+		s.emitActivity(ctx, activity.EmitInput{
+			EventType:         activity.EventScheduleRunFailed,
+			Category:          activity.CategorySchedule,
+			Level:             activity.LevelError,
+			ResourceType:      activity.ResourceSchedule,
+			ResourceID:        schedule.ID,
+			ActorType:         activity.ActorSystem,
+			Title:             fmt.Sprintf("Schedule '%s' could not queue its job", schedule.Name),
+			Message:           reason,
+			RequiresAttention: true,
+		})
+		s.logger.Error("scheduled job enqueue failed", "schedule_id", schedule.ID, "job_kind", schedule.JobKind, "error", err)
+		return
+	}
+
+	if err := s.scheduleStore.RecordRun(ctx, schedule.ID, job.ID, now, nextRunAt); err != nil {
+		s.logger.Error("schedule run persistence failed", "schedule_id", schedule.ID, "job_id", job.ID, "error", err)
+	}
+	title := fmt.Sprintf("Schedule '%s' queued %s", schedule.Name, orchestrator.JobKindLabel(schedule.JobKind))
+	if missedRuns > 0 {
+		title = fmt.Sprintf("Schedule '%s' caught up %d missed run(s)", schedule.Name, missedRuns)
+	}
+	s.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventScheduleTriggered,
+		Category:           activity.CategorySchedule,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceSchedule,
+		ParentResourceID:   schedule.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              title,
+		Payload:            triggerActivityPayload(schedule.ID, missedRuns),
+	})
+	s.logger.Info("scheduled job queued", "schedule_id", schedule.ID, "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID, "next_run_at", nextRunAt)
+}
+
+func (s *Scheduler) enqueue(ctx context.Context, schedule serverpkg.StoredSchedule) (orchestrator.Job, error) {
+	payload, err := orchestrator.WithScheduleID(schedule.Payload, schedule.ID)
+	if err != nil {
+		return orchestrator.Job{}, err
+	}
+	policy, ok := orchestrator.DispatchPolicyForKind(schedule.JobKind)
+	if !ok {
+		return orchestrator.Job{}, fmt.Errorf("unsupported job kind: %s", schedule.JobKind)
+	}
+
+	var job orchestrator.Job
+	if schedule.ServerID != "" && policy.QueueServer && s.serverQueuer != nil {
+		_, job, err = s.serverQueuer.QueueServerJob(ctx, serverpkg.QueueServerJobInput{
+			ServerID: schedule.ServerID,
+			Kind:     schedule.JobKind,
+			Payload:  payload,
+		})
+	} else {
+		job, err = s.jobStore.CreateJob(ctx, orchestrator.CreateJobInput{
+			Kind:     schedule.JobKind,
+			ServerID: schedule.ServerID,
+			Payload:  payload,
+		})
+	}
+	if err != nil {
+		return orchestrator.Job{}, err
+	}
+
+	_, _ = s.jobStore.AppendEvent(ctx, job.ID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeCreated,
+		Level:     "info",
+		Status:    string(job.Status),
+		Message:   fmt.Sprintf("Job queued by schedule '%s'", schedule.Name),
+	})
+	return job, nil
+}
+
+// emitActivity emits an activity event if the activity store is configured.
+func (s *Scheduler) emitActivity(ctx context.Context, input activity.EmitInput) {
+	if s.activityStore == nil {
+		return
+	}
+	if _, err := s.activityStore.Emit(ctx, input); err != nil {
+		s.logger.Error("activity emit failed", "event_type", input.EventType, "resource_type", input.ResourceType, "resource_id", input.ResourceID, "error", err)
+	}
+}
+
+// countMissedRuns counts the occurrences between the overdue run and now,
+// including the overdue run itself.
+func countMissedRuns(schedule serverpkg.StoredSchedule, dueAt, now time.Time) int {
+	expr, err := cronexpr.Parse(schedule.CronExpression)
+	if err != nil {
+		return 1
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return 1
+	}
+	count := 1
+	for next := expr.Next(dueAt.In(loc)); !next.IsZero() && !next.After(now) && count < maxCountedMissedRuns; next = expr.Next(next) {
+		count++
+	}
+	return count
+}
+
+func triggerActivityPayload(scheduleID string, missedRuns int) string {
+	payload := map[string]any{"schedule_id": scheduleID}
+	if missedRuns > 0 {
+		payload["catch_up"] = true
+		payload["missed_runs"] = missedRuns
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/orchestration/orchestrator"
+
+	_ "modernc.org/sqlite"
+)
+
+const schedulerTestServerID = "00000000-0000-7000-8000-000000000001"
+
+func TestSchedulerQueuesDueScheduleLinkedToJob(t *testing.T) {
+	db := mustOpenSchedulerDB(t)
+	scheduleStore := serverpkg.NewScheduleStore(db)
+	jobStore := orchestrator.NewStore(db)
+	activityStore := activity.NewStore(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	scheduleID := mustCreateDueSchedule(t, db, scheduleStore, serverpkg.ScheduleMissedRunSkip, now.Add(-time.Minute))
+	s := New(scheduleStore, jobStore, nil, activityStore, testSchedulerLogger(), DefaultConfig())
+	s.tick(context.Background(), now)
+
+	schedule, err := scheduleStore.Get(context.Background(), scheduleID)
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	if schedule.LastJobID == "" || schedule.LastError != "" || schedule.NextRunAt <= now.Format(time.RFC3339) {
+		t.Fatalf("schedule = %+v, want recorded run and future next_run_at", schedule)
+	}
+	job, err := jobStore.GetJob(context.Background(), schedule.LastJobID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if job.Kind != string(orchestrator.JobKindBackupSite) || job.ServerID != schedulerTestServerID || orchestrator.ScheduleIDFromPayload(job.Payload) != scheduleID {
+		t.Fatalf("job = %+v, want backup_site tagged with schedule", job)
+	}
+	payload, err := orchestrator.UnmarshalBackupSitePayload(job.Payload)
+	if err != nil || payload.SiteID == "" || payload.TargetID == "" {
+		t.Fatalf("job payload = %+v, err = %v; want original schedule payload", payload, err)
+	}
+
+	entries, _, err := activityStore.List(context.Background(), activity.ListFilter{ParentResourceType: activity.ResourceSchedule, ParentResourceID: scheduleID, Limit: 10})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EventType != activity.EventScheduleTriggered || entries[0].ResourceID != job.ID {
+		t.Fatalf("activity = %+v, want schedule.triggered for the job", entries)
+	}
+
+	s.tick(context.Background(), now)
+	if jobs := mustCountJobs(t, db); jobs != 1 {
+		t.Fatalf("jobs after second tick = %d, want 1", jobs)
+	}
+}
+
+func TestSchedulerAppliesMissedRunPolicy(t *testing.T) {
+	db := mustOpenSchedulerDB(t)
+	scheduleStore := serverpkg.NewScheduleStore(db)
+	jobStore := orchestrator.NewStore(db)
+	activityStore := activity.NewStore(db)
+	now := time.Date(2026, time.March, 14, 10, 30, 0, 0, time.UTC)
+	overdue := time.Date(2026, time.March, 14, 8, 0, 0, 0, time.UTC)
+
+	skipID := mustCreateDueSchedule(t, db, scheduleStore, serverpkg.ScheduleMissedRunSkip, overdue)
+	catchUpID := mustCreateDueSchedule(t, db, scheduleStore, serverpkg.ScheduleMissedRunCatchUp, overdue)
+	s := New(scheduleStore, jobStore, nil, activityStore, testSchedulerLogger(), DefaultConfig())
+	s.tick(context.Background(), now)
+
+	skipped, err := scheduleStore.Get(context.Background(), skipID)
+	if err != nil {
+		t.Fatalf("get skipped schedule: %v", err)
+	}
+	if skipped.LastJobID != "" || !strings.Contains(skipped.LastError, "Skipped 3 missed run(s)") {
+		t.Fatalf("skip schedule = %+v, want three skipped runs and no job", skipped)
+	}
+
+	caughtUp, err := scheduleStore.Get(context.Background(), catchUpID)
+	if err != nil {
+		t.Fatalf("get catch-up schedule: %v", err)
+	}
+	if caughtUp.LastJobID == "" {
+		t.Fatalf("catch-up schedule = %+v, want one queued job", caughtUp)
+	}
+	if jobs := mustCountJobs(t, db); jobs != 1 {
+		t.Fatalf("jobs = %d, want a single catch-up run", jobs)
+	}
+
+	entries, _, err := activityStore.List(context.Background(), activity.ListFilter{ParentResourceType: activity.ResourceSchedule, ParentResourceID: catchUpID, Limit: 10})
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0].Payload, `"missed_runs":3`) {
+		t.Fatalf("catch-up activity = %+v, want missed run count", entries)
+	}
+}
+
+func mustCreateDueSchedule(t *testing.T, db *sql.DB, store *serverpkg.ScheduleStore, policy string, dueAt time.Time) string {
+	t.Helper()
+	payload, err := orchestrator.MarshalBackupSitePayload(orchestrator.BackupSitePayload{
+		SiteID:   "00000000-0000-7000-8000-0000000000aa",
+		TargetID: "00000000-0000-7000-8000-0000000000bb",
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	id, err := store.Create(context.Background(), serverpkg.CreateScheduleInput{
+		Name:            "Hourly backup " + policy,
+		JobKind:         string(orchestrator.JobKindBackupSite),
+		ServerID:        schedulerTestServerID,
+		Payload:         payload,
+		CronExpression:  "@hourly",
+		MissedRunPolicy: policy,
+	})
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE schedules SET next_run_at = ? WHERE id = ?`, dueAt.UTC().Format(time.RFC3339), id); err != nil {
+		t.Fatalf("backdate schedule: %v", err)
+	}
+	return id
+}
+
+func mustCountJobs(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM jobs`).Scan(&count); err != nil {
+		t.Fatalf("count jobs: %v", err)
+	}
+	return count
+}
+
+func mustOpenSchedulerDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file::memory:?cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec(`
+		CREATE TABLE servers (
+			id          TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			provider_id TEXT
+		);
+		CREATE TABLE jobs (
+			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			server_id    TEXT,
+			kind         TEXT    NOT NULL,
+			status       TEXT    NOT NULL,
+			current_step TEXT    NOT NULL DEFAULT '',
+			retry_count  INTEGER NOT NULL DEFAULT 0,
+			last_error   TEXT,
+			payload      TEXT,
+			started_at   TEXT,
+			finished_at  TEXT,
+			timeout_at   TEXT,
+			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
+			created_at   TEXT    NOT NULL,
+			updated_at   TEXT    NOT NULL
+		);
+		CREATE TABLE job_events (
+			id         TEXT PRIMARY KEY,
+			job_id     TEXT    NOT NULL,
+			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
+			event_type TEXT    NOT NULL,
+			level      TEXT    NOT NULL,
+			step_key   TEXT,
+			status     TEXT,
+			message    TEXT    NOT NULL,
+			payload    TEXT,
+			created_at TEXT    NOT NULL
+		);
+		CREATE TABLE activity (
+			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			event_type           TEXT    NOT NULL,
+			category             TEXT    NOT NULL,
+			level                TEXT    NOT NULL,
+			resource_type        TEXT,
+			resource_id          TEXT,
+			parent_resource_type TEXT,
+			parent_resource_id   TEXT,
+			actor_type           TEXT    NOT NULL,
+			actor_id             TEXT,
+			title                TEXT    NOT NULL,
+			message              TEXT,
+			payload              TEXT,
+			requires_attention   INTEGER NOT NULL DEFAULT 0,
+			read_at              TEXT,
+			created_at           TEXT    NOT NULL
+		);
+		CREATE TABLE schedules (
+			id                TEXT PRIMARY KEY,
+			workspace_id      TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			name              TEXT    NOT NULL,
+			job_kind          TEXT    NOT NULL,
+			server_id         TEXT,
+			payload           TEXT,
+			cron_expression   TEXT    NOT NULL,
+			timezone          TEXT    NOT NULL DEFAULT 'UTC',
+			jitter_seconds    INTEGER NOT NULL DEFAULT 0,
+			missed_run_policy TEXT    NOT NULL DEFAULT 'skip',
+			paused            INTEGER NOT NULL DEFAULT 0,
+			next_run_at       TEXT,
+			last_run_at       TEXT,
+			last_job_id       TEXT,
+			last_error        TEXT,
+			created_at        TEXT    NOT NULL,
+			updated_at        TEXT    NOT NULL
+		);
+		INSERT INTO servers (id) VALUES ('00000000-0000-7000-8000-000000000001');
+	`); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	return db
+}
+
+func testSchedulerLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
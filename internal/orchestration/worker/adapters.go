@@ -43,6 +43,10 @@ func (a *ServerStoreAdapter) UpdateImage(ctx context.Context, id string, image s
 	return a.store.UpdateImage(ctx, id, image)
 }
 
+func (a *ServerStoreAdapter) UpdateSSHHostKey(ctx context.Context, id string, fingerprint string) error {
+	return a.store.UpdateSSHHostKey(ctx, id, fingerprint)
+}
+
 func (a *ServerStoreAdapter) GetKey(ctx context.Context, serverID string) (*server.StoredServerKey, error) {
 	return a.store.GetKey(ctx, serverID)
 }
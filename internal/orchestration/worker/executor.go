@@ -2,13 +2,15 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"pressluft/internal/agent/agentcommand"
 	"pressluft/internal/controlplane/activity"
 	serverpkg "pressluft/internal/controlplane/server"
 	"pressluft/internal/infra/provider"
@@ -26,6 +28,7 @@ type ServerStore interface {
 	UpdateProvisioning(ctx context.Context, id string, providerServerID, actionID, actionStatus string, status platform.ServerStatus, ipv4, ipv6 string) error
 	UpdateServerType(ctx context.Context, id string, serverType string) error
 	UpdateImage(ctx context.Context, id string, image string) error
+	UpdateSSHHostKey(ctx context.Context, id string, fingerprint string) error
 	GetKey(ctx context.Context, serverID string) (*serverpkg.StoredServerKey, error)
 	CreateKey(ctx context.Context, in serverpkg.CreateServerKeyInput) error
 }
@@ -46,6 +49,40 @@ type DomainStore interface {
 	UpdateRoutingStatus(ctx context.Context, domainID, routingState, routingStatusMessage string, checkedAt time.Time) error
 }
 
+// BackupStore defines the backup persistence interface needed by the executor.
+type BackupStore interface {
+	GetTarget(ctx context.Context, id string) (*serverpkg.StoredBackupTarget, error)
+	CreateBackup(ctx context.Context, in serverpkg.CreateSiteBackupInput) (string, error)
+	GetBackup(ctx context.Context, id string) (*serverpkg.StoredSiteBackup, error)
+	MarkRunning(ctx context.Context, id, jobID string) error
+	Complete(ctx context.Context, id string, sizeBytes int64, checksumSHA256 string) error
+	Fail(ctx context.Context, id, lastError string) error
+	ListExpired(ctx context.Context, siteID string, now time.Time) ([]serverpkg.StoredSiteBackup, error)
+	MarkExpired(ctx context.Context, id string) error
+}
+
+// CostStore records the list prices servers are billed at.
+type CostStore interface {
+	RecordPrice(ctx context.Context, in serverpkg.ServerPriceInput) error
+	ClosePrice(ctx context.Context, serverID string, at time.Time) error
+}
+
+// SiteHealthProber asks a server's agent for a runtime snapshot of one site.
+type SiteHealthProber interface {
+	SiteHealthSnapshot(ctx context.Context, serverID string, params agentcommand.SiteHealthSnapshotParams) (*agentcommand.SiteHealthSnapshot, error)
+}
+
+// SiteInventoryCollector asks a server's agent for the WordPress components
+// installed on one site.
+type SiteInventoryCollector interface {
+	WPInventory(ctx context.Context, serverID string, params agentcommand.WPInventoryParams) (*agentcommand.WPInventory, error)
+}
+
+// ComponentStore persists a site's WordPress inventory.
+type ComponentStore interface {
+	ReplaceForSite(ctx context.Context, siteID string, components []serverpkg.SiteComponentInput, checkedAt time.Time) error
+}
+
 // Executor runs job steps and emits events.
 type Executor struct {
 	jobStore          *orchestrator.Store
@@ -53,8 +90,14 @@ type Executor struct {
 	providerStore     ProviderStore
 	siteStore         SiteStore
 	domainStore       DomainStore
+	backupStore       BackupStore
+	costStore         CostStore
+	healthProber      SiteHealthProber
+	inventory         SiteInventoryCollector
+	componentStore    ComponentStore
 	activityStore     *activity.Store
 	runner            runner.Runner
+	simulatedRunner   runner.Runner
 	agentRunner       AgentJobRunner
 	devTokenStore     DevTokenStore
 	registrationStore RegistrationTokenStore
@@ -62,27 +105,26 @@ type Executor struct {
 	playbookBasePath  string
 	configurePath     string
 	controlPlaneURL   string
+	lifecycleLookup   func(providerType string) (provider.ServerLifecycle, bool)
+	waitForSSH        func(ctx context.Context, host string) error
 	logger            *slog.Logger
 }
 
-// Conventional playbook filenames inside ops/ansible/playbooks/<provider-type>/.
-// Each provider supplies its own set of playbooks following this naming
-// convention, making it obvious where to add playbooks for a new provider.
+// Site playbook filenames inside ops/ansible/playbooks/. Server lifecycle
+// operations go through the provider's API instead, see
+// provider.ServerLifecycle.
 const (
-	playbookProvision  = "provision.yml"
-	playbookDelete     = "delete.yml"
-	playbookRebuild    = "rebuild.yml"
-	playbookResize     = "resize.yml"
-	playbookFirewalls  = "firewalls.yml"
-	playbookVolume     = "volume.yml"
-	playbookSiteDeploy = "deploy-site.yml"
+	playbookSiteDeploy  = "deploy-site.yml"
+	playbookSiteBackup  = "backup-site.yml"
+	playbookSiteRestore = "restore-site.yml"
+	playbookSiteStaging = "create-staging.yml"
+	playbookSitePush    = "push-site.yml"
+	playbookSiteUpdate  = "update-site.yml"
 )
 
 // ExecutorConfig defines runner configuration.
 type ExecutorConfig struct {
-	// PlaybookBasePath is the root directory containing per-provider playbook
-	// subdirectories. The executor resolves provider-specific playbooks as
-	// <PlaybookBasePath>/<provider-type>/<action>.yml.
+	// PlaybookBasePath is the directory containing the site playbooks.
 	PlaybookBasePath      string
 	ConfigurePlaybookPath string
 	ControlPlaneURL       string
@@ -90,6 +132,19 @@ type ExecutorConfig struct {
 	DevTokenStore         DevTokenStore
 	RegistrationStore     RegistrationTokenStore
 	AgentRunner           AgentJobRunner
+	BackupStore           BackupStore
+	SiteHealthProber      SiteHealthProber
+	InventoryCollector    SiteInventoryCollector
+	ComponentStore        ComponentStore
+	// CostStore, when set, records the price of provisioned and resized
+	// servers for cost reports.
+	CostStore CostStore
+	// LifecycleLookup resolves the native lifecycle of a provider type and
+	// defaults to provider.GetServerLifecycle.
+	LifecycleLookup func(providerType string) (provider.ServerLifecycle, bool)
+	// SimulatedRunner runs playbooks for servers of simulated providers,
+	// which the real runner cannot reach. See provider.SimulatesServers.
+	SimulatedRunner runner.Runner
 }
 
 type DevTokenStore interface {
@@ -116,14 +171,24 @@ func NewExecutor(
 	config ExecutorConfig,
 	logger *slog.Logger,
 ) *Executor {
+	lifecycleLookup := config.LifecycleLookup
+	if lifecycleLookup == nil {
+		lifecycleLookup = provider.GetServerLifecycle
+	}
 	return &Executor{
 		jobStore:          jobStore,
 		serverStore:       serverStore,
 		providerStore:     providerStore,
 		siteStore:         siteStore,
 		domainStore:       domainStore,
+		backupStore:       config.BackupStore,
+		costStore:         config.CostStore,
+		healthProber:      config.SiteHealthProber,
+		inventory:         config.InventoryCollector,
+		componentStore:    config.ComponentStore,
 		activityStore:     activityStore,
 		runner:            runner,
+		simulatedRunner:   config.SimulatedRunner,
 		agentRunner:       config.AgentRunner,
 		devTokenStore:     config.DevTokenStore,
 		registrationStore: config.RegistrationStore,
@@ -131,20 +196,36 @@ func NewExecutor(
 		playbookBasePath:  strings.TrimSpace(config.PlaybookBasePath),
 		configurePath:     strings.TrimSpace(config.ConfigurePlaybookPath),
 		controlPlaneURL:   strings.TrimSpace(config.ControlPlaneURL),
+		lifecycleLookup:   lifecycleLookup,
+		waitForSSH:        dialSSH,
 		logger:            logger,
 	}
 }
 
-// providerPlaybook resolves a playbook path for the given provider type.
-// The convention is: <playbookBasePath>/<providerType>/<filename>.
-func (e *Executor) providerPlaybook(providerType, filename string) string {
-	return filepath.Join(e.playbookBasePath, providerType, filename)
-}
-
 func (e *Executor) siteDeployPlaybook() string {
 	return filepath.Join(e.playbookBasePath, playbookSiteDeploy)
 }
 
+func (e *Executor) siteBackupPlaybook() string {
+	return filepath.Join(e.playbookBasePath, playbookSiteBackup)
+}
+
+func (e *Executor) siteRestorePlaybook() string {
+	return filepath.Join(e.playbookBasePath, playbookSiteRestore)
+}
+
+func (e *Executor) siteStagingPlaybook() string {
+	return filepath.Join(e.playbookBasePath, playbookSiteStaging)
+}
+
+func (e *Executor) sitePushPlaybook() string {
+	return filepath.Join(e.playbookBasePath, playbookSitePush)
+}
+
+func (e *Executor) siteUpdatePlaybook() string {
+	return filepath.Join(e.playbookBasePath, playbookSiteUpdate)
+}
+
 // Execute runs all steps for a job. It handles state transitions and event emission.
 func (e *Executor) Execute(ctx context.Context, job *orchestrator.Job) error {
 	switch job.Kind {
@@ -152,6 +233,8 @@ func (e *Executor) Execute(ctx context.Context, job *orchestrator.Job) error {
 		return e.executeProvisionServer(ctx, job)
 	case string(orchestrator.JobKindConfigureServer):
 		return e.executeConfigureServer(ctx, job)
+	case string(orchestrator.JobKindConnectServer):
+		return e.executeConnectServer(ctx, job)
 	case string(orchestrator.JobKindDeleteServer):
 		return e.executeDeleteServer(ctx, job)
 	case string(orchestrator.JobKindRebuildServer):
@@ -166,6 +249,16 @@ func (e *Executor) Execute(ctx context.Context, job *orchestrator.Job) error {
 		return e.executeAgentJob(ctx, job)
 	case string(orchestrator.JobKindDeploySite):
 		return e.executeDeploySite(ctx, job)
+	case string(orchestrator.JobKindBackupSite):
+		return e.executeBackupSite(ctx, job)
+	case string(orchestrator.JobKindRestoreSite):
+		return e.executeRestoreSite(ctx, job)
+	case string(orchestrator.JobKindCreateStaging):
+		return e.executeCreateStaging(ctx, job)
+	case string(orchestrator.JobKindPushSite):
+		return e.executePushSite(ctx, job)
+	case string(orchestrator.JobKindUpdateSiteComponents):
+		return e.executeUpdateSiteComponents(ctx, job)
 	default:
 		return e.failJob(ctx, job, fmt.Sprintf("unknown job kind: %s", job.Kind))
 	}
@@ -195,28 +288,6 @@ func (e *Executor) setSetupState(ctx context.Context, serverID string, setupStat
 	e.logger.Info("server setup state updated", "server_id", serverID, "setup_state", setupState)
 }
 
-func (e *Executor) runLocalPlaybook(ctx context.Context, jobID string, playbookPath string, extraVars map[string]string) error {
-	workspace, err := os.MkdirTemp("", "pressluft-ansible-")
-	if err != nil {
-		return fmt.Errorf("failed to create ansible workspace: %v", err)
-	}
-	defer os.RemoveAll(workspace)
-
-	inventoryPath := filepath.Join(workspace, "inventory.ini")
-	if err := os.WriteFile(inventoryPath, []byte("localhost ansible_connection=local\n"), 0o600); err != nil {
-		return fmt.Errorf("failed to write ansible inventory: %v", err)
-	}
-
-	request := runner.Request{
-		JobID:         jobID,
-		InventoryPath: inventoryPath,
-		PlaybookPath:  playbookPath,
-		ExtraVars:     extraVars,
-	}
-
-	return e.runner.Run(ctx, request, &runnerEventSink{jobStore: e.jobStore, jobID: jobID, logger: e.logger})
-}
-
 func (e *Executor) completeJob(ctx context.Context, job *orchestrator.Job, step string) error {
 	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{
 		ToStatus:    orchestrator.JobStatusSucceeded,
@@ -244,6 +315,7 @@ func (e *Executor) completeJob(ctx context.Context, job *orchestrator.Job, step
 		input.ParentResourceType = activity.ResourceServer
 		input.ParentResourceID = job.ServerID
 	}
+	input.Payload = scheduledJobActivityPayload(*job)
 	e.emitActivity(ctx, input)
 
 	return nil
@@ -251,9 +323,18 @@ func (e *Executor) completeJob(ctx context.Context, job *orchestrator.Job, step
 
 func (e *Executor) failJob(ctx context.Context, job *orchestrator.Job, errMsg string) error {
 	corr := observability.Correlation{JobID: job.ID, ServerID: job.ServerID, CommandID: derefString(job.CommandID)}
+	if errors.Is(context.Cause(ctx), ErrJobCancelled) {
+		// The step failed because the job was cancelled; the cancellation
+		// already settled the job and server status.
+		e.logger.Info("job step aborted by cancellation", corr.LogArgs("error", errMsg)...)
+		return ErrJobCancelled
+	}
+	if retried, err := e.retryJob(ctx, job, errMsg); retried {
+		return err
+	}
 	e.logger.Error("job failed", corr.LogArgs("error", errMsg)...)
 
-	if job.ServerID != "" && job.Kind != string(orchestrator.JobKindDeploySite) {
+	if job.ServerID != "" && !siteScopedJobKind(job.Kind) {
 		if job.Kind == string(orchestrator.JobKindConfigureServer) {
 			e.setSetupState(ctx, job.ServerID, platform.SetupStateDegraded, errMsg)
 		} else if err := e.serverStore.UpdateStatus(ctx, job.ServerID, platform.ServerStatusFailed); err != nil {
@@ -291,11 +372,72 @@ func (e *Executor) failJob(ctx context.Context, job *orchestrator.Job, errMsg st
 		input.ParentResourceType = activity.ResourceServer
 		input.ParentResourceID = job.ServerID
 	}
+	input.Payload = scheduledJobActivityPayload(*job)
 	e.emitActivity(ctx, input)
 
 	return fmt.Errorf("job failed: %s", errMsg)
 }
 
+// retryJob puts the job back in the queue when the failure looks transient
+// and the kind has retries left. Server status is left alone: the next
+// attempt picks up where the server is. A job whose context ended (timeout or
+// shutdown) is not retried here.
+func (e *Executor) retryJob(ctx context.Context, job *orchestrator.Job, errMsg string) (bool, error) {
+	if ctx.Err() != nil || orchestrator.ClassifyMessage(errMsg) != orchestrator.ErrorClassTransient {
+		return false, nil
+	}
+	delay, ok := orchestrator.RetryDelay(job.Kind, job.RetryCount)
+	if !ok {
+		return false, nil
+	}
+	corr := observability.Correlation{JobID: job.ID, ServerID: job.ServerID, CommandID: derefString(job.CommandID)}
+	retried, changed, err := e.jobStore.RetryJob(ctx, job.ID, errMsg, delay)
+	if err != nil {
+		e.logger.Error("job retry persistence failed", corr.LogArgs("error", err)...)
+		return false, nil
+	}
+	if !changed {
+		return false, nil
+	}
+	e.logger.Warn("job failed with transient error; retry scheduled", corr.LogArgs("error", errMsg, "attempt", retried.RetryCount, "next_attempt_at", retried.NextAttemptAt)...)
+
+	input := activity.EmitInput{
+		EventType:    activity.EventJobRetrying,
+		Category:     activity.CategoryJob,
+		Level:        activity.LevelWarning,
+		ResourceType: activity.ResourceJob,
+		ResourceID:   job.ID,
+		ActorType:    activity.ActorSystem,
+		Title:        fmt.Sprintf("%s retry scheduled", orchestrator.JobKindLabel(job.Kind)),
+		Message:      fmt.Sprintf("Attempt %d failed: %s. Retrying at %s.", retried.RetryCount, errMsg, retried.NextAttemptAt),
+	}
+	if siteID := e.siteIDForJob(*job); siteID != "" {
+		input.ParentResourceType = activity.ResourceSite
+		input.ParentResourceID = siteID
+	} else if job.ServerID != "" {
+		input.ParentResourceType = activity.ResourceServer
+		input.ParentResourceID = job.ServerID
+	}
+	input.Payload = scheduledJobActivityPayload(*job)
+	e.emitActivity(ctx, input)
+
+	return true, fmt.Errorf("job attempt failed, retry scheduled: %s", errMsg)
+}
+
+// scheduledJobActivityPayload links the activity of a scheduled run back to
+// the schedule that queued it.
+func scheduledJobActivityPayload(job orchestrator.Job) string {
+	scheduleID := orchestrator.ScheduleIDFromPayload(job.Payload)
+	if scheduleID == "" {
+		return ""
+	}
+	data, err := json.Marshal(map[string]string{"schedule_id": scheduleID})
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 func (e *Executor) updateStep(ctx context.Context, jobID string, step string) {
 	if _, err := e.jobStore.TransitionJob(ctx, jobID, orchestrator.TransitionInput{
 		ToStatus:    orchestrator.JobStatusRunning,
@@ -305,12 +447,25 @@ func (e *Executor) updateStep(ctx context.Context, jobID string, step string) {
 	}
 }
 
+// siteScopedJobKind reports whether a job operates on a single site. A
+// failure of such a job must not mark the hosting server as failed.
+func siteScopedJobKind(kind string) bool {
+	switch kind {
+	case string(orchestrator.JobKindDeploySite), string(orchestrator.JobKindBackupSite), string(orchestrator.JobKindRestoreSite), string(orchestrator.JobKindCreateStaging), string(orchestrator.JobKindPushSite), string(orchestrator.JobKindUpdateSiteComponents):
+		return true
+	default:
+		return false
+	}
+}
+
 func (e *Executor) siteIDForJob(job orchestrator.Job) string {
-	if job.Kind != string(orchestrator.JobKindDeploySite) {
+	if !siteScopedJobKind(job.Kind) {
 		return ""
 	}
-	payload, err := orchestrator.UnmarshalDeploySitePayload(job.Payload)
-	if err != nil {
+	var payload struct {
+		SiteID string `json:"site_id"`
+	}
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
 		return ""
 	}
 	return strings.TrimSpace(payload.SiteID)
@@ -339,6 +494,19 @@ func (e *Executor) emitEvent(ctx context.Context, jobID string, eventType, level
 	e.logger.Debug("job event appended", "job_id", jobID, "event_seq", event.Seq, "event_type", event.EventType, "status", status)
 }
 
+// runPlaybook runs request against server, recording runner output as job
+// events. Servers of simulated providers go to the simulated runner.
+func (e *Executor) runPlaybook(ctx context.Context, server *serverpkg.StoredServer, request runner.Request) error {
+	r := e.runner
+	if server != nil && provider.SimulatesServers(server.ProviderType) {
+		if e.simulatedRunner == nil {
+			return fmt.Errorf("server %s is simulated but no simulated runner is configured", server.Name)
+		}
+		r = e.simulatedRunner
+	}
+	return r.Run(ctx, request, &runnerEventSink{jobStore: e.jobStore, jobID: request.JobID, logger: e.logger})
+}
+
 type runnerEventSink struct {
 	jobStore *orchestrator.Store
 	jobID    string
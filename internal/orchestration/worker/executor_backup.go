@@ -0,0 +1,270 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/infra/s3"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+// backupUploadURLExpiry bounds how long the presigned upload URL handed to
+// the managed server stays valid. It outlives the backup_site job timeout so
+// a slow archive step cannot strand an upload halfway.
+const backupUploadURLExpiry = 2 * time.Hour
+
+type siteBackupArtifact struct {
+	SizeBytes      int64  `json:"size_bytes"`
+	ChecksumSHA256 string `json:"checksum_sha256"`
+}
+
+func (e *Executor) executeBackupSite(ctx context.Context, job *orchestrator.Job) error {
+	if strings.TrimSpace(job.ServerID) == "" {
+		return e.failJob(ctx, job, "server_id is required for site backup job")
+	}
+	if e.siteStore == nil {
+		return e.failJob(ctx, job, "site store not configured")
+	}
+	if e.backupStore == nil {
+		return e.failJob(ctx, job, "backup store not configured")
+	}
+	if e.runner == nil {
+		return e.failJob(ctx, job, "ansible runner not configured")
+	}
+
+	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{ToStatus: orchestrator.JobStatusRunning, CurrentStep: "validate"}); err != nil {
+		return fmt.Errorf("transition to running: %w", err)
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventJobStarted,
+		Category:           activity.CategoryJob,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   e.siteIDForJob(*job),
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("%s started", orchestrator.JobKindLabel(job.Kind)),
+	})
+
+	payload, err := orchestrator.UnmarshalBackupSitePayload(job.Payload)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+
+	e.emitStepStart(ctx, job.ID, "validate", "Validating site backup inputs")
+	site, err := e.siteStore.GetByID(ctx, payload.SiteID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("site not found: %v", err))
+	}
+	if site.ServerID != job.ServerID {
+		return e.failJob(ctx, job, "site does not belong to the job server")
+	}
+	if site.DeploymentState != serverpkg.SiteDeploymentStateReady {
+		return e.failJob(ctx, job, "site must be deployed before it can be backed up")
+	}
+	server, err := e.serverStore.GetByID(ctx, job.ServerID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("server not found: %v", err))
+	}
+	if server.Status != platform.ServerStatusReady {
+		return e.failJob(ctx, job, "server must be ready before backing up a site")
+	}
+	target, err := e.backupStore.GetTarget(ctx, payload.TargetID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("backup target not found: %v", err))
+	}
+	client, err := s3.NewClient(target.S3Config(), nil)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("invalid backup target: %v", err))
+	}
+	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("failed to read SSH key: %v", err))
+	}
+	if storedKey == nil {
+		return e.failJob(ctx, job, "missing SSH key for server")
+	}
+	decryptedKey, err := security.Decrypt(storedKey.PrivateKeyEncrypted)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("failed to decrypt SSH key: %v", err))
+	}
+	backupID, err := e.backupStore.CreateBackup(ctx, serverpkg.CreateSiteBackupInput{
+		SiteID:        site.ID,
+		ServerID:      server.ID,
+		TargetID:      target.ID,
+		JobID:         job.ID,
+		RetentionDays: payload.RetentionDays,
+	})
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("record backup: %v", err))
+	}
+	backup, err := e.backupStore.GetBackup(ctx, backupID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("read backup record: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "validate", "Site backup request validated")
+
+	e.updateStep(ctx, job.ID, "backup")
+	e.emitStepStart(ctx, job.ID, "backup", fmt.Sprintf("Archiving site to %s", target.Name))
+	if err := e.backupStore.MarkRunning(ctx, backup.ID, job.ID); err != nil {
+		return e.failBackup(ctx, job, site, backup.ID, fmt.Sprintf("mark backup running: %v", err))
+	}
+	uploadURL, err := client.PresignPut(backup.ObjectKey, backupUploadURLExpiry)
+	if err != nil {
+		return e.failBackup(ctx, job, site, backup.ID, fmt.Sprintf("presign upload: %v", err))
+	}
+	artifact, err := e.runSiteBackupPlaybook(ctx, job.ID, server, site, backup.ID, uploadURL, string(decryptedKey))
+	if err != nil {
+		return e.failBackup(ctx, job, site, backup.ID, fmt.Sprintf("site backup failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "backup", "Site archive uploaded")
+
+	e.updateStep(ctx, job.ID, "verify")
+	e.emitStepStart(ctx, job.ID, "verify", "Verifying uploaded archive")
+	info, err := client.HeadObject(ctx, backup.ObjectKey)
+	if err != nil {
+		return e.failBackup(ctx, job, site, backup.ID, fmt.Sprintf("verify uploaded archive: %v", err))
+	}
+	if info.Size != artifact.SizeBytes {
+		return e.failBackup(ctx, job, site, backup.ID, fmt.Sprintf("uploaded archive is %d bytes, expected %d", info.Size, artifact.SizeBytes))
+	}
+	if err := e.backupStore.Complete(ctx, backup.ID, artifact.SizeBytes, artifact.ChecksumSHA256); err != nil {
+		return e.failBackup(ctx, job, site, backup.ID, fmt.Sprintf("record completed backup: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "verify", "Uploaded archive verified")
+
+	e.updateStep(ctx, job.ID, "finalize")
+	e.emitStepStart(ctx, job.ID, "finalize", "Applying backup retention")
+	pruned := e.pruneExpiredBackups(ctx, site.ID)
+	e.emitStepComplete(ctx, job.ID, "finalize", fmt.Sprintf("Retention applied, %d expired backup(s) removed", pruned))
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventBackupCompleted,
+		Category:           activity.CategoryBackup,
+		Level:              activity.LevelSuccess,
+		ResourceType:       activity.ResourceBackup,
+		ResourceID:         backup.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   site.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Site '%s' backed up", site.Name),
+		Message:            fmt.Sprintf("Archive of %d bytes stored in %s.", artifact.SizeBytes, target.Name),
+	})
+	return e.completeJob(ctx, job, "finalize")
+}
+
+// failBackup records the failure on the backup row before failing the job so
+// a partial upload is never listed as a usable backup.
+func (e *Executor) failBackup(ctx context.Context, job *orchestrator.Job, site *serverpkg.StoredSite, backupID, errMsg string) error {
+	if err := e.backupStore.Fail(ctx, backupID, errMsg); err != nil {
+		e.logger.Error("backup failure persistence failed", "job_id", job.ID, "backup_id", backupID, "error", err)
+	}
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventBackupFailed,
+		Category:           activity.CategoryBackup,
+		Level:              activity.LevelError,
+		ResourceType:       activity.ResourceBackup,
+		ResourceID:         backupID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   site.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Backup of site '%s' failed", site.Name),
+		Message:            errMsg,
+	})
+	return e.failJob(ctx, job, errMsg)
+}
+
+// pruneExpiredBackups deletes the site's archives past their retention window,
+// on whichever target holds them. Errors are logged rather than failing the
+// job: the new backup is already safe and the next run retries the cleanup.
+func (e *Executor) pruneExpiredBackups(ctx context.Context, siteID string) int {
+	expired, err := e.backupStore.ListExpired(ctx, siteID, time.Now().UTC())
+	if err != nil {
+		e.logger.Error("list expired backups failed", "site_id", siteID, "error", err)
+		return 0
+	}
+	clients := map[string]*s3.Client{}
+	pruned := 0
+	for _, backup := range expired {
+		client, ok := clients[backup.TargetID]
+		if !ok {
+			target, err := e.backupStore.GetTarget(ctx, backup.TargetID)
+			if err == nil {
+				client, err = s3.NewClient(target.S3Config(), nil)
+			}
+			if err != nil {
+				e.logger.Error("open backup target for pruning failed", "backup_id", backup.ID, "target_id", backup.TargetID, "error", err)
+			}
+			clients[backup.TargetID] = client
+		}
+		if client == nil {
+			continue
+		}
+		if err := client.DeleteObject(ctx, backup.ObjectKey); err != nil {
+			e.logger.Error("delete expired backup object failed", "backup_id", backup.ID, "object_key", backup.ObjectKey, "error", err)
+			continue
+		}
+		if err := e.backupStore.MarkExpired(ctx, backup.ID); err != nil {
+			e.logger.Error("mark backup expired failed", "backup_id", backup.ID, "error", err)
+			continue
+		}
+		pruned++
+	}
+	return pruned
+}
+
+func (e *Executor) runSiteBackupPlaybook(ctx context.Context, jobID string, server *serverpkg.StoredServer, site *serverpkg.StoredSite, backupID, uploadURL, privateKey string) (siteBackupArtifact, error) {
+	workspace, err := os.MkdirTemp("", "pressluft-site-backup-")
+	if err != nil {
+		return siteBackupArtifact{}, fmt.Errorf("failed to create backup workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+
+	inventoryPath, err := writeSiteInventory(workspace, server, privateKey)
+	if err != nil {
+		return siteBackupArtifact{}, err
+	}
+	artifactPath := filepath.Join(workspace, "backup-result.json")
+	request := runner.Request{
+		JobID:         jobID,
+		InventoryPath: inventoryPath,
+		PlaybookPath:  e.siteBackupPlaybook(),
+		ExtraVars: map[string]string{
+			"site_id":              site.ID,
+			"site_path":            effectiveWordPressPath(*site),
+			"backup_id":            backupID,
+			"presigned_upload_url": uploadURL,
+			"artifact_path":        artifactPath,
+		},
+	}
+	if err := e.runPlaybook(ctx, server, request); err != nil {
+		return siteBackupArtifact{}, err
+	}
+	return readSiteBackupArtifact(artifactPath)
+}
+
+func readSiteBackupArtifact(path string) (siteBackupArtifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return siteBackupArtifact{}, fmt.Errorf("read backup result: %w", err)
+	}
+	var out siteBackupArtifact
+	if err := json.Unmarshal(data, &out); err != nil {
+		return siteBackupArtifact{}, fmt.Errorf("decode backup result: %w", err)
+	}
+	if out.SizeBytes <= 0 {
+		return siteBackupArtifact{}, fmt.Errorf("backup result reported an empty archive")
+	}
+	return out, nil
+}
@@ -0,0 +1,208 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/infra/s3/s3test"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+const (
+	backupTestServerID = "00000000-0000-7000-8000-000000000001"
+	backupTestSiteID   = "00000000-0000-7000-8000-0000000000aa"
+)
+
+func TestExecutorBackupSiteUploadsAndVerifiesArchive(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	bucket := s3test.NewServer("agency-backups")
+	defer bucket.Close()
+	serverStore := mustBackupTestServerStore(t)
+	backupStore := server.NewBackupStore(executorTestDB)
+	targetID := mustCreateWorkerBackupTarget(t, backupStore, bucket.URL)
+
+	archive := []byte("site archive contents")
+	sum := sha256.Sum256(archive)
+	checksum := hex.EncodeToString(sum[:])
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		if req.ExtraVars["presigned_upload_url"] == "" || req.ExtraVars["site_id"] != backupTestSiteID {
+			return fmt.Errorf("unexpected extra vars: %v", req.ExtraVars)
+		}
+		uploadReq, err := http.NewRequest(http.MethodPut, req.ExtraVars["presigned_upload_url"], bytes.NewReader(archive))
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(uploadReq)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("upload status %d", resp.StatusCode)
+		}
+		return os.WriteFile(req.ExtraVars["artifact_path"], []byte(fmt.Sprintf(`{"size_bytes":%d,"checksum_sha256":%q}`, len(archive), checksum)), 0o600)
+	}}
+	executor := NewExecutor(jobStore, serverStore, nil, &fakeSiteStore{site: readyBackupTestSite()}, nil, nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		BackupStore:      backupStore,
+	}, testLogger())
+
+	payload, err := orchestrator.MarshalBackupSitePayload(orchestrator.BackupSitePayload{SiteID: backupTestSiteID, TargetID: targetID})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindBackupSite),
+		ServerID: backupTestServerID,
+		Payload:  payload,
+	})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute backup: %v", err)
+	}
+
+	if got := mustGetExecutorJob(t, jobStore, job.ID).Status; got != orchestrator.JobStatusSucceeded {
+		t.Fatalf("job status = %q, want succeeded", got)
+	}
+	if len(fakeRunner.requests) != 1 || fakeRunner.requests[0].PlaybookPath != filepath.Join("playbooks", playbookSiteBackup) {
+		t.Fatalf("runner requests = %+v, want backup playbook", fakeRunner.requests)
+	}
+	backups, err := backupStore.ListBySite(context.Background(), backupTestSiteID)
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("backups = %d, want 1", len(backups))
+	}
+	backup := backups[0]
+	if backup.Status != server.SiteBackupStatusCompleted || backup.SizeBytes != int64(len(archive)) || backup.ChecksumSHA256 != checksum || backup.JobID != job.ID {
+		t.Fatalf("backup = %+v, want completed record", backup)
+	}
+	if stored, ok := bucket.Object("agency-backups", backup.ObjectKey); !ok || !bytes.Equal(stored, archive) {
+		t.Fatalf("stored object for %s does not match uploaded archive", backup.ObjectKey)
+	}
+}
+
+func TestExecutorBackupSiteFailureKeepsServerReady(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	bucket := s3test.NewServer("agency-backups")
+	defer bucket.Close()
+	serverStore := mustBackupTestServerStore(t)
+	backupStore := server.NewBackupStore(executorTestDB)
+	targetID := mustCreateWorkerBackupTarget(t, backupStore, bucket.URL)
+
+	fakeRunner := &fakeRunner{failPlaybooks: map[string]error{filepath.Join("playbooks", playbookSiteBackup): errors.New("mysqldump failed")}}
+	executor := NewExecutor(jobStore, serverStore, nil, &fakeSiteStore{site: readyBackupTestSite()}, nil, nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		BackupStore:      backupStore,
+	}, testLogger())
+
+	payload, err := orchestrator.MarshalBackupSitePayload(orchestrator.BackupSitePayload{SiteID: backupTestSiteID, TargetID: targetID})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindBackupSite),
+		ServerID: backupTestServerID,
+		Payload:  payload,
+	})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected backup to fail")
+	}
+
+	if got := serverStore.servers[backupTestServerID].Status; got != platform.ServerStatusReady {
+		t.Fatalf("server status = %q, want ready after a site-scoped failure", got)
+	}
+	backups, err := backupStore.ListBySite(context.Background(), backupTestSiteID)
+	if err != nil {
+		t.Fatalf("list backups: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Status != server.SiteBackupStatusFailed || !strings.Contains(backups[0].LastError, "mysqldump failed") {
+		t.Fatalf("backups = %+v, want one failed backup", backups)
+	}
+	if len(bucket.Keys("agency-backups")) != 0 {
+		t.Fatal("expected no object in bucket after failed backup")
+	}
+}
+
+type fakeSiteStore struct {
+	site *server.StoredSite
+}
+
+func (s *fakeSiteStore) GetByID(_ context.Context, id string) (*server.StoredSite, error) {
+	if s.site == nil || s.site.ID != id {
+		return nil, errors.New("site not found")
+	}
+	copy := *s.site
+	return &copy, nil
+}
+
+func (s *fakeSiteStore) UpdateDeployment(_ context.Context, _, deploymentState, deploymentStatus, lastDeployJobID, lastDeployedAt string) error {
+	s.site.DeploymentState = deploymentState
+	s.site.DeploymentStatus = deploymentStatus
+	return nil
+}
+
+func (s *fakeSiteStore) UpdateRuntimeHealth(_ context.Context, _, runtimeHealthState, runtimeHealthStatus, lastHealthCheckAt string) error {
+	s.site.RuntimeHealthState = runtimeHealthState
+	s.site.RuntimeHealthStatus = runtimeHealthStatus
+	return nil
+}
+
+func readyBackupTestSite() *server.StoredSite {
+	return &server.StoredSite{
+		ID:              backupTestSiteID,
+		ServerID:        backupTestServerID,
+		Name:            "Agency Site",
+		DeploymentState: server.SiteDeploymentStateReady,
+	}
+}
+
+func mustBackupTestServerStore(t *testing.T) *fakeServerStore {
+	t.Helper()
+	keyPath := filepath.Join(t.TempDir(), "age.txt")
+	t.Setenv("PRESSLUFT_AGE_KEY_PATH", keyPath)
+	if _, err := security.EnsureAgeKey(keyPath, true); err != nil {
+		t.Fatalf("ensure age key: %v", err)
+	}
+	encrypted, keyID, err := security.Encrypt([]byte("dummy-private-key"))
+	if err != nil {
+		t.Fatalf("encrypt private key: %v", err)
+	}
+	return &fakeServerStore{
+		servers: map[string]*server.StoredServer{
+			backupTestServerID: {ID: backupTestServerID, Name: "agency-prod-01", ProfileKey: "nginx-stack", IPv4: "203.0.113.10", Status: platform.ServerStatusReady, SetupState: platform.SetupStateReady},
+		},
+		keys: map[string]*server.StoredServerKey{
+			backupTestServerID: {ServerID: backupTestServerID, PrivateKeyEncrypted: encrypted, EncryptionKeyID: keyID, PublicKey: "ssh-ed25519 AAAATEST"},
+		},
+	}
+}
+
+func mustCreateWorkerBackupTarget(t *testing.T, store *server.BackupStore, endpoint string) string {
+	t.Helper()
+	targetID, err := store.CreateTarget(context.Background(), server.CreateBackupTargetInput{
+		Name:            "Offsite",
+		Endpoint:        endpoint,
+		Bucket:          "agency-backups",
+		PathStyle:       true,
+		AccessKeyID:     s3test.DefaultAccessKeyID,
+		SecretAccessKey: s3test.DefaultSecretAccessKey,
+	})
+	if err != nil {
+		t.Fatalf("create backup target: %v", err)
+	}
+	return targetID
+}
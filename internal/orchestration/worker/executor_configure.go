@@ -127,7 +127,7 @@ func (e *Executor) runConfigurePlaybook(ctx context.Context, jobID string, serve
 	}
 
 	configureInventoryPath := filepath.Join(workspace, "configure.ini")
-	configureInventory := fmt.Sprintf("server ansible_host=%s ansible_user=root ansible_ssh_private_key_file=%s ansible_ssh_common_args='-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null'\n", ipv4, privateKeyPath)
+	configureInventory := sshInventoryLine(ipv4, server, privateKeyPath)
 	if err := os.WriteFile(configureInventoryPath, []byte(configureInventory), 0o600); err != nil {
 		return fmt.Errorf("failed to write configure inventory: %w", err)
 	}
@@ -166,7 +166,7 @@ func (e *Executor) runConfigurePlaybook(ctx context.Context, jobID string, serve
 		ExtraVars:     extraVars,
 	}
 
-	return e.runner.Run(ctx, configureRequest, &runnerEventSink{jobStore: e.jobStore, jobID: jobID, logger: e.logger})
+	return e.runPlaybook(ctx, server, configureRequest)
 }
 
 func validateSelectableProfile(profileKey string) (profiles.Profile, error) {
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/provider"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/sshutil"
+)
+
+// executeConnectServer checks that an adopted server is reachable with its
+// generated key and runs a supported OS, then queues the normal setup job.
+func (e *Executor) executeConnectServer(ctx context.Context, job *orchestrator.Job) error {
+	if strings.TrimSpace(job.ServerID) == "" {
+		return e.failJob(ctx, job, "server_id is required for connect_server job")
+	}
+	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{
+		ToStatus:    orchestrator.JobStatusRunning,
+		CurrentStep: "validate",
+	}); err != nil {
+		return fmt.Errorf("transition to running: %w", err)
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventJobStarted,
+		Category:           activity.CategoryJob,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceServer,
+		ParentResourceID:   job.ServerID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("%s started", orchestrator.JobKindLabel(job.Kind)),
+	})
+
+	e.emitStepStart(ctx, job.ID, "validate", "Validating server connection request")
+	server, err := e.serverStore.GetByID(ctx, job.ServerID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("server not found: %v", err))
+	}
+	if !provider.AdoptsExistingServers(server.ProviderType) {
+		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not adopt existing servers", server.ProviderType))
+	}
+	if _, err := orchestrator.UnmarshalConnectServerPayload(job.Payload); err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+	if strings.TrimSpace(server.IPv4) == "" {
+		return e.failJob(ctx, job, "server IPv4 is required for connect_server job")
+	}
+	profile, err := validateSelectableProfile(server.ProfileKey)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("failed to read SSH key: %v", err))
+	}
+	if storedKey == nil {
+		return e.failJob(ctx, job, "SSH key is required for connect_server job")
+	}
+	privateKey, err := security.Decrypt(storedKey.PrivateKeyEncrypted)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("failed to decrypt SSH key: %v", err))
+	}
+	auth, err := sshutil.PrivateKeyAuth(string(privateKey))
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("invalid SSH key: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "validate", "Server connection request validated")
+	e.setServerStatus(ctx, server.ID, platform.ServerStatusProvisioning)
+	e.setSetupState(ctx, server.ID, platform.SetupStateNotStarted, "")
+
+	e.updateStep(ctx, job.ID, "connect")
+	target := serverSSHTarget(server)
+	e.emitStepStart(ctx, job.ID, "connect", fmt.Sprintf("Connecting to %s as %s", target.Address(), target.User))
+	client, err := sshutil.Dial(ctx, target, auth)
+	if errors.Is(err, sshutil.ErrHostKeyMismatch) {
+		return e.failJob(ctx, job, fmt.Sprintf("ssh connection refused: %v; the server is not the one that was added, or its host key changed", err))
+	}
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("ssh connection failed: %v; check that the public key is in %s's authorized_keys", err, target.User))
+	}
+	defer client.Close()
+	// The first key seen is pinned for every later connection.
+	if target.HostKeyFingerprint == "" {
+		if err := e.serverStore.UpdateSSHHostKey(ctx, server.ID, client.HostKeyFingerprint); err != nil {
+			e.logger.Error("failed to record server host key", "server_id", server.ID, "error", err)
+		}
+	}
+	e.emitStepComplete(ctx, job.ID, "connect", fmt.Sprintf("Connected; host key %s", client.HostKeyFingerprint))
+
+	e.updateStep(ctx, job.ID, "verify")
+	e.emitStepStart(ctx, job.ID, "verify", "Checking operating system and privileges")
+	facts, err := sshutil.InspectHost(ctx, client)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+	if facts.Image() != profile.Image {
+		found := firstNonEmpty(facts.OSName, facts.Image(), "an unknown operating system")
+		return e.failJob(ctx, job, fmt.Sprintf("unsupported operating system: server runs %s, profile %s requires %s", found, profile.Key, profile.Image))
+	}
+	if !facts.CanBecomeRoot() {
+		return e.failJob(ctx, job, fmt.Sprintf("permission denied: %s is not root and cannot use sudo without a password", target.User))
+	}
+	e.emitStepComplete(ctx, job.ID, "verify", fmt.Sprintf("%s on %s is supported", firstNonEmpty(facts.OSName, facts.Image()), facts.Arch))
+
+	e.updateStep(ctx, job.ID, "finalize")
+	e.emitStepStart(ctx, job.ID, "finalize", "Queueing server setup")
+	e.setServerStatus(ctx, server.ID, platform.ServerStatusConfiguring)
+	e.setSetupState(ctx, server.ID, platform.SetupStateRunning, "")
+
+	payloadBytes, err := json.Marshal(orchestrator.ConfigureServerPayload{IPv4: server.IPv4})
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("marshal configure payload: %v", err))
+	}
+	configureJob, err := e.jobStore.CreateJob(ctx, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindConfigureServer),
+		ServerID: server.ID,
+		Payload:  string(payloadBytes),
+	})
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("queue configure job: %v", err))
+	}
+	e.emitEvent(ctx, configureJob.ID, orchestrator.JobEventTypeCreated, "info", "", string(configureJob.Status), "Setup job accepted and queued")
+	e.emitStepComplete(ctx, job.ID, "finalize", "Server setup queued")
+
+	if err := e.completeJob(ctx, job, "finalize"); err != nil {
+		return err
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:    activity.EventServerProvisioned,
+		Category:     activity.CategoryServer,
+		Level:        activity.LevelSuccess,
+		ResourceType: activity.ResourceServer,
+		ResourceID:   job.ServerID,
+		ActorType:    activity.ActorSystem,
+		Title:        fmt.Sprintf("Server '%s' connected", server.Name),
+	})
+	return nil
+}
+
+// serverSSHTarget is where playbooks and SSH checks reach a server, pinned
+// to its recorded host key.
+func serverSSHTarget(server *serverpkg.StoredServer) sshutil.Target {
+	return sshutil.Target{
+		Host:               server.IPv4,
+		Port:               server.SSHPort,
+		User:               firstNonEmpty(server.SSHUser, "root"),
+		HostKeyFingerprint: server.SSHHostKeyFingerprint,
+	}
+}
+
+// sshInventoryLine is the single-host inventory entry playbooks use to reach
+// a server with the key at privateKeyPath.
+func sshInventoryLine(host string, server *serverpkg.StoredServer, privateKeyPath string) string {
+	target := serverSSHTarget(server)
+	port := target.Port
+	if port <= 0 {
+		port = sshutil.DefaultPort
+	}
+	return fmt.Sprintf("server ansible_host=%s ansible_port=%d ansible_user=%s ansible_ssh_private_key_file=%s ansible_ssh_common_args='-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null'\n", host, port, target.User, privateKeyPath)
+}
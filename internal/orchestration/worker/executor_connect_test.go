@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/provider"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+	"pressluft/internal/shared/sshutil"
+	"pressluft/internal/shared/sshutil/sshtest"
+
+	_ "pressluft/internal/infra/provider/custom"
+)
+
+const connectTestServerID = "00000000-0000-7000-8000-000000000001"
+
+func TestExecutorConnectServerQueuesSetup(t *testing.T) {
+	host := sshtest.NewServer(hostFactsHandler("os_id=ubuntu\nos_version=24.04\nos_name=Ubuntu 24.04.1 LTS\narch=x86_64\nuid=1000\nsudo=yes\n"))
+	t.Cleanup(host.Close)
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustAdoptedServerStore(t, host)
+	executor := NewExecutor(jobStore, serverStore, adoptedProviderStore(), nil, nil, nil, &fakeRunner{}, ExecutorConfig{}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindConnectServer), ServerID: connectTestServerID})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute connect: %v", err)
+	}
+
+	stored := serverStore.servers[connectTestServerID]
+	if stored.Status != platform.ServerStatusConfiguring {
+		t.Fatalf("server status = %q, want %q", stored.Status, platform.ServerStatusConfiguring)
+	}
+	if stored.SSHHostKeyFingerprint != ssh.FingerprintSHA256(host.HostKey()) {
+		t.Fatalf("host key = %q, want %q", stored.SSHHostKeyFingerprint, ssh.FingerprintSHA256(host.HostKey()))
+	}
+	if got := mustGetExecutorJob(t, jobStore, job.ID).Status; got != orchestrator.JobStatusSucceeded {
+		t.Fatalf("job status = %q, want %q", got, orchestrator.JobStatusSucceeded)
+	}
+	jobs, err := jobStore.ListJobsByServer(context.Background(), connectTestServerID)
+	if err != nil {
+		t.Fatalf("list jobs: %v", err)
+	}
+	var configure *orchestrator.Job
+	for i := range jobs {
+		if jobs[i].Kind == string(orchestrator.JobKindConfigureServer) {
+			configure = &jobs[i]
+		}
+	}
+	if configure == nil || !strings.Contains(configure.Payload, host.Host()) {
+		t.Fatalf("configure job = %+v, want one targeting %s", configure, host.Host())
+	}
+}
+
+func TestExecutorConnectServerRejectsUnsupportedOS(t *testing.T) {
+	host := sshtest.NewServer(hostFactsHandler("os_id=debian\nos_version=12\nos_name=Debian GNU/Linux 12 (bookworm)\narch=x86_64\nuid=0\nsudo=yes\n"))
+	t.Cleanup(host.Close)
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustAdoptedServerStore(t, host)
+	executor := NewExecutor(jobStore, serverStore, adoptedProviderStore(), nil, nil, nil, &fakeRunner{}, ExecutorConfig{}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindConnectServer), ServerID: connectTestServerID})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected connect to fail")
+	}
+
+	stored := mustGetExecutorJob(t, jobStore, job.ID)
+	if !strings.Contains(stored.LastError, "Debian GNU/Linux 12") {
+		t.Fatalf("last error = %q, want the detected OS", stored.LastError)
+	}
+	if got := serverStore.servers[connectTestServerID].Status; got != platform.ServerStatusFailed {
+		t.Fatalf("server status = %q, want %q", got, platform.ServerStatusFailed)
+	}
+}
+
+func TestExecutorConnectServerRejectsChangedHostKey(t *testing.T) {
+	host := sshtest.NewServer(hostFactsHandler("os_id=ubuntu\nos_version=24.04\nos_name=Ubuntu 24.04.1 LTS\narch=x86_64\nuid=0\nsudo=yes\n"))
+	t.Cleanup(host.Close)
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustAdoptedServerStore(t, host)
+	pinned := "SHA256:" + strings.Repeat("A", 43)
+	serverStore.servers[connectTestServerID].SSHHostKeyFingerprint = pinned
+	executor := NewExecutor(jobStore, serverStore, adoptedProviderStore(), nil, nil, nil, &fakeRunner{}, ExecutorConfig{}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindConnectServer), ServerID: connectTestServerID})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected connect to fail")
+	}
+
+	if stored := mustGetExecutorJob(t, jobStore, job.ID); !strings.Contains(stored.LastError, "host key mismatch") {
+		t.Fatalf("last error = %q, want a host key mismatch", stored.LastError)
+	}
+	if len(host.Commands()) != 0 {
+		t.Fatalf("commands = %q, want none run on the unexpected host", host.Commands())
+	}
+	if got := serverStore.servers[connectTestServerID].SSHHostKeyFingerprint; got != pinned {
+		t.Fatalf("host key = %q, want the pinned key kept", got)
+	}
+}
+
+func TestExecutorDeleteAdoptedServerLeavesMachineRunning(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		connectTestServerID: {ID: connectTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", ProviderType: "custom", Name: "release-me", IPv4: "203.0.113.10", Status: platform.ServerStatusDeleting},
+	}}
+	runner := &fakeRunner{}
+	executor := NewExecutor(jobStore, serverStore, adoptedProviderStore(), nil, nil, nil, runner, ExecutorConfig{PlaybookBasePath: "playbooks"}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindDeleteServer), ServerID: connectTestServerID})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute delete: %v", err)
+	}
+	if len(runner.requests) != 0 {
+		t.Fatalf("runner requests = %d, want none", len(runner.requests))
+	}
+	if got := serverStore.servers[connectTestServerID].Status; got != platform.ServerStatusDeleted {
+		t.Fatalf("server status = %q, want %q", got, platform.ServerStatusDeleted)
+	}
+}
+
+func hostFactsHandler(facts string) sshtest.Handler {
+	return func(command string) (string, uint32) {
+		if strings.Contains(command, "/etc/os-release") {
+			return facts, 0
+		}
+		return "", 1
+	}
+}
+
+func adoptedProviderStore() *fakeProviderStore {
+	return &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "custom"}}
+}
+
+// mustAdoptedServerStore returns a pending adopted server whose generated key
+// host already trusts.
+func mustAdoptedServerStore(t *testing.T, host *sshtest.Server) *fakeServerStore {
+	t.Helper()
+	keyPath := filepath.Join(t.TempDir(), "age.txt")
+	os.Setenv("PRESSLUFT_AGE_KEY_PATH", keyPath)
+	t.Cleanup(func() { _ = os.Unsetenv("PRESSLUFT_AGE_KEY_PATH") })
+	if _, err := security.EnsureAgeKey(keyPath, true); err != nil {
+		t.Fatalf("ensure age key: %v", err)
+	}
+	publicKey, privateKey, err := sshutil.GenerateKeyPair("pressluft-server-test")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	if err := host.Authorize(publicKey); err != nil {
+		t.Fatalf("authorize key: %v", err)
+	}
+	encrypted, keyID, err := security.Encrypt([]byte(privateKey))
+	if err != nil {
+		t.Fatalf("encrypt private key: %v", err)
+	}
+	return &fakeServerStore{
+		servers: map[string]*server.StoredServer{
+			connectTestServerID: {ID: connectTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", ProviderType: "custom", Name: "adopt-me", ProfileKey: "nginx-stack", Image: "ubuntu-24.04", IPv4: host.Host(), SSHPort: host.Port(), SSHUser: "deploy", Status: platform.ServerStatusPending},
+		},
+		keys: map[string]*server.StoredServerKey{
+			connectTestServerID: {ServerID: connectTestServerID, PrivateKeyEncrypted: encrypted, EncryptionKeyID: keyID, PublicKey: publicKey},
+		},
+	}
+}
@@ -46,17 +46,14 @@ func (e *Executor) executeDeleteServer(ctx context.Context, job *orchestrator.Jo
 	if err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("provider not found: %v", err))
 	}
-	if !provider.SupportsServerMutationWorkflow(storedProvider.Type) {
-		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not support ansible workflows", storedProvider.Type))
-	}
-	if strings.TrimSpace(storedProvider.APIToken) == "" {
-		return e.failJob(ctx, job, "provider token is required")
-	}
-	if e.runner == nil {
-		return e.failJob(ctx, job, "ansible runner not configured")
-	}
-	if strings.TrimSpace(e.playbookBasePath) == "" {
-		return e.failJob(ctx, job, "playbook base path not configured")
+	// Adopted servers were never created through a provider API, so
+	// deleting one only stops managing it; the machine keeps running.
+	adopted := provider.AdoptsExistingServers(storedProvider.Type)
+	var lifecycle provider.ServerLifecycle
+	if !adopted {
+		if lifecycle, err = e.serverLifecycle(storedProvider); err != nil {
+			return e.failJob(ctx, job, err.Error())
+		}
 	}
 
 	if _, err := orchestrator.UnmarshalDeleteServerPayload(job.Payload); err != nil {
@@ -70,16 +67,18 @@ func (e *Executor) executeDeleteServer(ctx context.Context, job *orchestrator.Jo
 	e.emitStepComplete(ctx, job.ID, "validate", "Delete request validated")
 
 	e.updateStep(ctx, job.ID, "delete")
-	e.emitStepStart(ctx, job.ID, "delete", "Running delete playbook")
+	if adopted {
+		e.emitStepStart(ctx, job.ID, "delete", "Releasing server from management")
+		e.emitStepComplete(ctx, job.ID, "delete", "Server released; the machine itself was left running")
+	} else {
+		e.emitStepStart(ctx, job.ID, "delete", fmt.Sprintf("Deleting server at %s", storedProvider.Type))
 
-	if err := e.runLocalPlaybook(ctx, job.ID, e.providerPlaybook(storedProvider.Type, playbookDelete), map[string]string{
-		"api_token":   storedProvider.APIToken,
-		"server_name": serverName,
-	}); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("ansible delete failed: %v", err))
-	}
+		if err := lifecycle.DeleteServer(ctx, storedProvider.APIToken, providerRef(server), e.providerActionReporter(ctx, job.ID, "delete", storedProvider.Type)); err != nil {
+			return e.failJob(ctx, job, fmt.Sprintf("provider delete failed: %v", err))
+		}
 
-	e.emitStepComplete(ctx, job.ID, "delete", "Delete playbook completed")
+		e.emitStepComplete(ctx, job.ID, "delete", "Server deleted at provider")
+	}
 
 	e.updateStep(ctx, job.ID, "finalize")
 	e.emitStepStart(ctx, job.ID, "finalize", "Finalizing delete")
@@ -87,6 +86,7 @@ func (e *Executor) executeDeleteServer(ctx context.Context, job *orchestrator.Jo
 	if err := e.serverStore.UpdateStatus(ctx, server.ID, platform.ServerStatusDeleted); err != nil {
 		e.logger.Error("failed to update server status to deleted", "error", err)
 	}
+	e.closeServerPrice(ctx, server.ID)
 
 	e.emitStepComplete(ctx, job.ID, "finalize", "Server delete complete")
 	e.emitActivity(ctx, activity.EmitInput{
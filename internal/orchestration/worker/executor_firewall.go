@@ -6,7 +6,6 @@ import (
 	"strings"
 
 	"pressluft/internal/controlplane/activity"
-	"pressluft/internal/infra/provider"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 )
@@ -46,17 +45,9 @@ func (e *Executor) executeUpdateFirewalls(ctx context.Context, job *orchestrator
 	if err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("provider not found: %v", err))
 	}
-	if !provider.SupportsServerMutationWorkflow(storedProvider.Type) {
-		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not support ansible workflows", storedProvider.Type))
-	}
-	if strings.TrimSpace(storedProvider.APIToken) == "" {
-		return e.failJob(ctx, job, "provider token is required")
-	}
-	if e.runner == nil {
-		return e.failJob(ctx, job, "ansible runner not configured")
-	}
-	if strings.TrimSpace(e.playbookBasePath) == "" {
-		return e.failJob(ctx, job, "playbook base path not configured")
+	lifecycle, err := e.serverLifecycle(storedProvider)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
 	}
 
 	payload, err := orchestrator.UnmarshalUpdateFirewallsPayload(job.Payload)
@@ -73,22 +64,17 @@ func (e *Executor) executeUpdateFirewalls(ctx context.Context, job *orchestrator
 	if len(firewalls) == 0 {
 		return e.failJob(ctx, job, "firewalls payload must contain at least one firewall")
 	}
-	firewallsCSV := strings.Join(firewalls, ",")
 
 	e.emitStepComplete(ctx, job.ID, "validate", "Firewall update validated")
 
 	e.updateStep(ctx, job.ID, "update_firewalls")
-	e.emitStepStart(ctx, job.ID, "update_firewalls", "Running firewall update playbook")
+	e.emitStepStart(ctx, job.ID, "update_firewalls", fmt.Sprintf("Applying firewalls: %s", strings.Join(firewalls, ", ")))
 
-	if err := e.runLocalPlaybook(ctx, job.ID, e.providerPlaybook(storedProvider.Type, playbookFirewalls), map[string]string{
-		"api_token":     storedProvider.APIToken,
-		"server_name":   server.Name,
-		"firewalls_csv": firewallsCSV,
-	}); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("ansible firewall update failed: %v", err))
+	if err := lifecycle.ApplyFirewalls(ctx, storedProvider.APIToken, providerRef(server), firewalls, e.providerActionReporter(ctx, job.ID, "update_firewalls", storedProvider.Type)); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("provider firewall update failed: %v", err))
 	}
 
-	e.emitStepComplete(ctx, job.ID, "update_firewalls", "Firewall update playbook completed")
+	e.emitStepComplete(ctx, job.ID, "update_firewalls", "Firewalls applied")
 
 	e.updateStep(ctx, job.ID, "finalize")
 	e.emitStepStart(ctx, job.ID, "finalize", "Finalizing firewall update")
@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/provider"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+// sshWaitTimeout bounds how long a new server may take to accept SSH
+// connections after the provider reports it running.
+const sshWaitTimeout = 5 * time.Minute
+
+// serverLifecycle returns the native lifecycle of the provider, failing
+// with a job error message when the provider has none or lacks a token.
+func (e *Executor) serverLifecycle(storedProvider *provider.StoredProvider) (provider.ServerLifecycle, error) {
+	lc, ok := e.lifecycleLookup(storedProvider.Type)
+	if !ok {
+		return nil, fmt.Errorf("provider %s does not support server lifecycle operations", storedProvider.Type)
+	}
+	if strings.TrimSpace(storedProvider.APIToken) == "" {
+		return nil, fmt.Errorf("provider token is required")
+	}
+	return lc, nil
+}
+
+// providerRef identifies the server at its provider. Servers provisioned
+// before their provider ID was recorded are looked up by name.
+func providerRef(server *serverpkg.StoredServer) provider.ServerRef {
+	return provider.ServerRef{ID: strings.TrimSpace(server.ProviderServerID), Name: strings.TrimSpace(server.Name)}
+}
+
+// recordServerPrice records the list price of serverType for cost reports.
+// Prices only come from the provider catalog, so servers of providers
+// without one, or types it no longer lists, go unpriced. Failures are
+// logged: they must not fail the lifecycle job that already ran.
+func (e *Executor) recordServerPrice(ctx context.Context, storedProvider *provider.StoredProvider, server *serverpkg.StoredServer, serverType string) {
+	if e.costStore == nil {
+		return
+	}
+	serverProvider, ok := provider.GetServerProvider(storedProvider.Type)
+	if !ok {
+		return
+	}
+	catalog, err := serverProvider.ListServerCatalog(ctx, storedProvider.APIToken)
+	if err != nil {
+		e.logger.Warn("server price not recorded: catalog unavailable", "server_id", server.ID, "error", err)
+		return
+	}
+	price, ok := catalog.Price(serverType, server.Location)
+	if !ok {
+		e.logger.Warn("server price not recorded: type not in catalog", "server_id", server.ID, "server_type", serverType, "location", server.Location)
+		return
+	}
+	if err := e.costStore.RecordPrice(ctx, serverpkg.ServerPriceInput{
+		ServerID:      server.ID,
+		ServerType:    serverType,
+		Location:      server.Location,
+		HourlyGross:   price.HourlyGross,
+		MonthlyGross:  price.MonthlyGross,
+		Currency:      price.Currency,
+		EffectiveFrom: time.Now(),
+	}); err != nil {
+		e.logger.Error("failed to record server price", "server_id", server.ID, "error", err)
+	}
+}
+
+// closeServerPrice stops billing a deleted or released server.
+func (e *Executor) closeServerPrice(ctx context.Context, serverID string) {
+	if e.costStore == nil {
+		return
+	}
+	if err := e.costStore.ClosePrice(ctx, serverID, time.Now()); err != nil {
+		e.logger.Error("failed to close server price", "server_id", serverID, "error", err)
+	}
+}
+
+// providerActionReporter records every provider action update as a job
+// event on step.
+func (e *Executor) providerActionReporter(ctx context.Context, jobID, step, providerType string) provider.ActionReporter {
+	return func(action provider.Action) {
+		payload, err := json.Marshal(orchestrator.ProviderActionEvent{
+			Provider: providerType,
+			Action:   action.Command,
+			ActionID: action.ID,
+			Status:   string(action.Status),
+			Progress: action.Progress,
+			Error:    action.Error,
+		})
+		if err != nil {
+			e.logger.Error("provider action payload encoding failed", "job_id", jobID, "error", err)
+			return
+		}
+		level := "info"
+		message := fmt.Sprintf("%s %s (%d%%)", action.Command, action.Status, action.Progress)
+		if action.Status == provider.ActionFailed {
+			level = "error"
+			if action.Error != "" {
+				message = fmt.Sprintf("%s failed: %s", action.Command, action.Error)
+			}
+		}
+		if _, err := e.jobStore.AppendEvent(ctx, jobID, orchestrator.CreateEventInput{
+			EventType: orchestrator.JobEventTypeProviderAction,
+			Level:     level,
+			StepKey:   step,
+			Status:    string(action.Status),
+			Message:   message,
+			Payload:   string(payload),
+		}); err != nil {
+			e.logger.Error("provider action event append failed", "job_id", jobID, "action_id", action.ID, "error", err)
+		}
+	}
+}
+
+// dialSSH waits until host accepts TCP connections on the SSH port.
+func dialSSH(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, sshWaitTimeout)
+	defer cancel()
+	address := net.JoinHostPort(host, "22")
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("ssh on %s did not respond: %w", address, ctx.Err())
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/provider"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+const lifecycleTestServerID = "00000000-0000-7000-8000-000000000001"
+
+func TestExecutorProvisionServerCreatesThroughLifecycle(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "age.txt")
+	os.Setenv("PRESSLUFT_AGE_KEY_PATH", keyPath)
+	t.Cleanup(func() { _ = os.Unsetenv("PRESSLUFT_AGE_KEY_PATH") })
+	if _, err := security.EnsureAgeKey(keyPath, true); err != nil {
+		t.Fatalf("ensure age key: %v", err)
+	}
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		lifecycleTestServerID: {ID: lifecycleTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", Name: "web-1", Location: "fsn1", ServerType: "cx22", Image: "ubuntu-24.04", Status: platform.ServerStatusPending},
+	}}
+	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
+	lifecycle := &fakeLifecycle{actions: []provider.Action{
+		{ID: "456", Command: "create_server", Status: provider.ActionRunning},
+		{ID: "456", Command: "create_server", Status: provider.ActionSucceeded, Progress: 100},
+	}}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{LifecycleLookup: lifecycle.lookup}, testLogger())
+	var sshHost string
+	executor.waitForSSH = func(_ context.Context, host string) error {
+		sshHost = host
+		return nil
+	}
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindProvisionServer), ServerID: lifecycleTestServerID})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute provision: %v", err)
+	}
+
+	stored := serverStore.servers[lifecycleTestServerID]
+	if stored.ProviderServerID != "123" || stored.ActionID != "456" || stored.ActionStatus != string(provider.ActionSucceeded) || stored.IPv4 != "203.0.113.10" {
+		t.Fatalf("server = %+v, want the created server recorded", stored)
+	}
+	if stored.Status != platform.ServerStatusConfiguring || sshHost != "203.0.113.10" {
+		t.Fatalf("status = %q, ssh host = %q", stored.Status, sshHost)
+	}
+	if len(lifecycle.calls) != 1 || lifecycle.calls[0] != "create web-1" {
+		t.Fatalf("lifecycle calls = %q", lifecycle.calls)
+	}
+
+	events, err := jobStore.ListAllEvents(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	var actions []orchestrator.ProviderActionEvent
+	for _, event := range events {
+		if event.EventType != orchestrator.JobEventTypeProviderAction {
+			continue
+		}
+		if event.StepKey != "provision" {
+			t.Fatalf("provider action event step = %q, want provision", event.StepKey)
+		}
+		var payload orchestrator.ProviderActionEvent
+		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+			t.Fatalf("decode provider action payload: %v", err)
+		}
+		actions = append(actions, payload)
+	}
+	if len(actions) != 2 || actions[0].Provider != "hetzner" || actions[1].Status != string(provider.ActionSucceeded) || actions[1].Progress != 100 {
+		t.Fatalf("provider action events = %+v", actions)
+	}
+}
+
+func TestExecutorProvisionServerRejectsProviderWithoutLifecycle(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		lifecycleTestServerID: {ID: lifecycleTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", Name: "web-1", Status: platform.ServerStatusPending},
+	}}
+	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "unknown", APIToken: "token"}}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindProvisionServer), ServerID: lifecycleTestServerID})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected provision to fail")
+	}
+	if got := mustGetExecutorJob(t, jobStore, job.ID).LastError; !strings.Contains(got, "does not support server lifecycle") {
+		t.Fatalf("last error = %q", got)
+	}
+}
+
+func TestExecutorManageVolumeAbsentDeletesVolume(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		lifecycleTestServerID: {ID: lifecycleTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", ProviderServerID: "123", Name: "web-1", Status: platform.ServerStatusReady},
+	}}
+	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
+	lifecycle := &fakeLifecycle{}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{LifecycleLookup: lifecycle.lookup}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindManageVolume),
+		ServerID: lifecycleTestServerID,
+		Payload:  `{"volume_name":"data","state":"absent"}`,
+	})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute manage volume: %v", err)
+	}
+	// The recorded provider ID wins over the name.
+	if len(lifecycle.calls) != 1 || lifecycle.calls[0] != "delete_volume 123 data" {
+		t.Fatalf("lifecycle calls = %q", lifecycle.calls)
+	}
+}
+
+func TestExecutorProviderActionFailureIsLoggedAsError(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		lifecycleTestServerID: {ID: lifecycleTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", Name: "resize-me", ServerType: "cx22", Status: platform.ServerStatusResizing},
+	}}
+	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
+	lifecycle := &fakeLifecycle{
+		actions: []provider.Action{{ID: "7", Command: "change_server_type", Status: provider.ActionFailed, Error: "server type not available"}},
+		fail:    map[string]error{"resize": errors.New("change_server_type action 7 failed: server type not available")},
+	}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{LifecycleLookup: lifecycle.lookup}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindResizeServer),
+		ServerID: lifecycleTestServerID,
+		Payload:  `{"server_type":"cx32"}`,
+	})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected resize to fail")
+	}
+	events, err := jobStore.ListAllEvents(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	for _, event := range events {
+		if event.EventType == orchestrator.JobEventTypeProviderAction {
+			if event.Level != "error" || event.Message != "change_server_type failed: server type not available" {
+				t.Fatalf("provider action event = %+v", event)
+			}
+			return
+		}
+	}
+	t.Fatal("expected a provider action event")
+}
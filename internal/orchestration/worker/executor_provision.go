@@ -4,15 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
 
 	"pressluft/internal/controlplane/activity"
 	serverpkg "pressluft/internal/controlplane/server"
 	"pressluft/internal/infra/provider"
-	"pressluft/internal/infra/runner"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 	"pressluft/internal/shared/security"
@@ -53,17 +49,9 @@ func (e *Executor) executeProvisionServer(ctx context.Context, job *orchestrator
 		return e.failJob(ctx, job, fmt.Sprintf("provider not found: %v", err))
 	}
 
-	if !provider.SupportsProvisioningWorkflow(storedProvider.Type) {
-		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not support ansible provisioning", storedProvider.Type))
-	}
-	if strings.TrimSpace(storedProvider.APIToken) == "" {
-		return e.failJob(ctx, job, "provider token is required for provisioning")
-	}
-	if e.runner == nil {
-		return e.failJob(ctx, job, "ansible runner not configured")
-	}
-	if strings.TrimSpace(e.playbookBasePath) == "" {
-		return e.failJob(ctx, job, "playbook base path not configured")
+	lifecycle, err := e.serverLifecycle(storedProvider)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
 	}
 
 	e.emitStepComplete(ctx, job.ID, "validate", "Server configuration validated")
@@ -71,7 +59,7 @@ func (e *Executor) executeProvisionServer(ctx context.Context, job *orchestrator
 	e.setSetupState(ctx, server.ID, platform.SetupStateNotStarted, "")
 
 	e.updateStep(ctx, job.ID, "provision")
-	e.emitStepStart(ctx, job.ID, "provision", "Running provision playbook")
+	e.emitStepStart(ctx, job.ID, "provision", fmt.Sprintf("Creating server at %s", storedProvider.Type))
 
 	keyName := fmt.Sprintf("pressluft-server-%s", server.ID)
 	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
@@ -121,61 +109,47 @@ func (e *Executor) executeProvisionServer(ctx context.Context, job *orchestrator
 	if err := validateSSHPublicKey(publicKey); err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("invalid SSH public key: %v", err))
 	}
-	e.logSSHPublicKey("pre_ansible", publicKey)
-
-	workspace, err := os.MkdirTemp("", "pressluft-ansible-")
-	if err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("failed to create ansible workspace: %v", err))
-	}
-	defer os.RemoveAll(workspace)
-
-	inventoryPath := filepath.Join(workspace, "inventory.ini")
-	if err := os.WriteFile(inventoryPath, []byte("localhost ansible_connection=local\n"), 0o600); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("failed to write ansible inventory: %v", err))
-	}
-
-	artifactPath := filepath.Join(workspace, "provision-result.json")
-	request := runner.Request{
-		JobID:         job.ID,
-		InventoryPath: inventoryPath,
-		PlaybookPath:  e.providerPlaybook(storedProvider.Type, playbookProvision),
-		ExtraVars: map[string]string{
-			"api_token":       storedProvider.APIToken,
-			"server_name":     server.Name,
-			"server_location": server.Location,
-			"server_type":     server.ServerType,
-			"server_image":    server.Image,
-			"ssh_key_name":    keyName,
-			"ssh_public_key":  publicKey,
-			"artifact_path":   artifactPath,
-		},
-	}
-
-	if err := e.runner.Run(ctx, request, &runnerEventSink{jobStore: e.jobStore, jobID: job.ID, logger: e.logger}); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("ansible provision failed: %v", err))
-	}
+	e.logSSHPublicKey("pre_create", publicKey)
 
-	result, err := readProvisionArtifact(artifactPath)
+	created, err := lifecycle.CreateServer(ctx, storedProvider.APIToken, provider.ServerSpec{
+		Name:         server.Name,
+		Location:     server.Location,
+		ServerType:   server.ServerType,
+		Image:        server.Image,
+		SSHKeyName:   keyName,
+		SSHPublicKey: publicKey,
+	}, e.providerActionReporter(ctx, job.ID, "provision", storedProvider.Type))
 	if err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("failed to read provision result: %v", err))
+		return e.failJob(ctx, job, fmt.Sprintf("provider provision failed: %v", err))
 	}
-	if result.ID == 0 {
+	if strings.TrimSpace(created.ID) == "" {
 		return e.failJob(ctx, job, "provision result missing server id")
 	}
-	if strings.TrimSpace(result.IPv4) == "" {
+	if strings.TrimSpace(created.IPv4) == "" {
 		return e.failJob(ctx, job, "provision result missing IPv4")
 	}
 
-	providerServerID := strconv.FormatInt(result.ID, 10)
-	if err := e.serverStore.UpdateProvisioning(ctx, server.ID, providerServerID, "", "", platform.ServerStatusProvisioning, result.IPv4, result.IPv6); err != nil {
+	providerServerID := created.ID
+	actionStatus := ""
+	if created.ActionID != "" {
+		actionStatus = string(provider.ActionSucceeded)
+	}
+	if err := e.serverStore.UpdateProvisioning(ctx, server.ID, providerServerID, created.ActionID, actionStatus, platform.ServerStatusProvisioning, created.IPv4, created.IPv6); err != nil {
 		e.logger.Error("failed to update server provisioning state", "error", err)
 	}
+	e.recordServerPrice(ctx, storedProvider, server, server.ServerType)
+	// Simulated servers have no SSH daemon to wait for.
+	if !provider.SimulatesServers(storedProvider.Type) {
+		if err := e.waitForSSH(ctx, created.IPv4); err != nil {
+			return e.failJob(ctx, job, fmt.Sprintf("server did not come up: %v", err))
+		}
+	}
 
 	e.emitStepComplete(ctx, job.ID, "provision", fmt.Sprintf("Server created: %s", providerServerID))
 	e.setServerStatus(ctx, server.ID, platform.ServerStatusConfiguring)
 	e.setSetupState(ctx, server.ID, platform.SetupStateRunning, "")
 
-	payloadBytes, err := json.Marshal(orchestrator.ConfigureServerPayload{IPv4: result.IPv4})
+	payloadBytes, err := json.Marshal(orchestrator.ConfigureServerPayload{IPv4: created.IPv4})
 	if err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("marshal configure payload: %v", err))
 	}
@@ -205,25 +179,6 @@ func (e *Executor) executeProvisionServer(ctx context.Context, job *orchestrator
 	return nil
 }
 
-type provisionArtifact struct {
-	ID   int64  `json:"id"`
-	IPv4 string `json:"ipv4"`
-	IPv6 string `json:"ipv6"`
-}
-
-func readProvisionArtifact(path string) (provisionArtifact, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return provisionArtifact{}, err
-	}
-
-	var out provisionArtifact
-	if err := json.Unmarshal(data, &out); err != nil {
-		return provisionArtifact{}, err
-	}
-	return out, nil
-}
-
 func validateSSHPublicKey(publicKey string) error {
 	fields := strings.Fields(publicKey)
 	if len(fields) < 2 {
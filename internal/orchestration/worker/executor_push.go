@@ -0,0 +1,441 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+// Phases of push-site.yml.
+const (
+	pushPhaseDiff     = "diff"
+	pushPhaseSnapshot = "snapshot"
+	pushPhaseApply    = "apply"
+	pushPhaseRollback = "rollback"
+)
+
+// pushDiffSampleLimit caps how many changed paths the diff summary lists.
+const pushDiffSampleLimit = 50
+
+// sitePushTarget bundles what every push phase needs to reach both sites.
+type sitePushTarget struct {
+	server     *serverpkg.StoredServer
+	staging    *serverpkg.StoredSite
+	live       *serverpkg.StoredSite
+	sourceURL  string
+	targetURL  string
+	liveDomain *serverpkg.StoredDomain
+	privateKey string
+}
+
+// sitePushArtifact is the raw output of the diff phase.
+type sitePushArtifact struct {
+	FileChanges  string `json:"file_changes"`
+	SourceTables string `json:"source_tables"`
+	TargetTables string `json:"target_tables"`
+}
+
+func (e *Executor) executePushSite(ctx context.Context, job *orchestrator.Job) error {
+	if strings.TrimSpace(job.ServerID) == "" {
+		return e.failJob(ctx, job, "server_id is required for push job")
+	}
+	if e.siteStore == nil || e.domainStore == nil {
+		return e.failJob(ctx, job, "site stores not configured")
+	}
+	if e.runner == nil {
+		return e.failJob(ctx, job, "ansible runner not configured")
+	}
+
+	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{ToStatus: orchestrator.JobStatusRunning, CurrentStep: "validate"}); err != nil {
+		return fmt.Errorf("transition to running: %w", err)
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventJobStarted,
+		Category:           activity.CategoryJob,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   e.siteIDForJob(*job),
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("%s started", orchestrator.JobKindLabel(job.Kind)),
+	})
+
+	payload, err := orchestrator.UnmarshalPushSitePayload(job.Payload)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+
+	e.emitStepStart(ctx, job.ID, "validate", "Validating push inputs")
+	target, errMsg := e.resolveSitePushTarget(ctx, job, payload)
+	if errMsg != "" {
+		return e.failJob(ctx, job, errMsg)
+	}
+	e.emitStepComplete(ctx, job.ID, "validate", "Push request validated")
+
+	e.updateStep(ctx, job.ID, "diff")
+	e.emitStepStart(ctx, job.ID, "diff", fmt.Sprintf("Comparing '%s' with '%s'", target.staging.Name, target.live.Name))
+	artifact, err := e.runSitePushDiff(ctx, job.ID, target, payload)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("push diff failed: %v", err))
+	}
+	diff := buildPushSiteDiff(payload, artifact)
+	selectedTables := diff.selectedTables()
+	if !payload.IncludesFiles() && len(selectedTables) == 0 {
+		return e.failJob(ctx, job, "no staging tables match the push table filters")
+	}
+	if err := e.emitPushDiff(ctx, job.ID, diff.PushSiteDiff); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("record push diff: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "diff", "Diff summary recorded")
+
+	if payload.DryRun {
+		e.updateStep(ctx, job.ID, "finalize")
+		e.emitStepStart(ctx, job.ID, "finalize", "Dry run, the live site was not changed")
+		e.emitStepComplete(ctx, job.ID, "finalize", "Dry run complete")
+		return e.completeJob(ctx, job, "finalize")
+	}
+
+	e.updateStep(ctx, job.ID, "backup")
+	e.emitStepStart(ctx, job.ID, "backup", fmt.Sprintf("Backing up '%s' before pushing", target.live.Name))
+	if err := e.runSitePushPlaybook(ctx, job.ID, target, payload, pushPhaseSnapshot, nil); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("pre-push backup failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "backup", "Pre-push backup stored on the server")
+
+	e.updateStep(ctx, job.ID, "push")
+	e.emitStepStart(ctx, job.ID, "push", diff.message())
+	if err := e.runSitePushPlaybook(ctx, job.ID, target, payload, pushPhaseApply, map[string]string{
+		"push_tables": strings.Join(selectedTables, ","),
+	}); err != nil {
+		return e.rollbackPush(ctx, job, target, payload, fmt.Sprintf("push failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "push", "Staging changes applied to live")
+
+	e.updateStep(ctx, job.ID, "verify")
+	e.emitStepStart(ctx, job.ID, "verify", "Verifying live site")
+	if err := e.verifyPushedSite(ctx, target); err != nil {
+		return e.rollbackPush(ctx, job, target, payload, fmt.Sprintf("post-push check failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "verify", "Live site passed health checks")
+
+	e.updateStep(ctx, job.ID, "finalize")
+	e.emitStepStart(ctx, job.ID, "finalize", "Finalizing push")
+	e.emitStepComplete(ctx, job.ID, "finalize", "Push complete; the pre-push backup is kept on the server")
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventSitePushed,
+		Category:           activity.CategorySite,
+		Level:              activity.LevelSuccess,
+		ResourceType:       activity.ResourceSite,
+		ResourceID:         target.live.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   target.staging.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Staging '%s' pushed to '%s'", target.staging.Name, target.live.Name),
+		Message:            diff.message(),
+	})
+	return e.completeJob(ctx, job, "finalize")
+}
+
+// resolveSitePushTarget loads and checks both sites. It returns a failure
+// message instead of an error, matching the other site executors' messages.
+func (e *Executor) resolveSitePushTarget(ctx context.Context, job *orchestrator.Job, payload orchestrator.PushSitePayload) (sitePushTarget, string) {
+	staging, err := e.siteStore.GetByID(ctx, payload.SiteID)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("staging site not found: %v", err)
+	}
+	if staging.Environment != serverpkg.SiteEnvironmentStaging {
+		return sitePushTarget{}, "only staging sites can be pushed"
+	}
+	if staging.ParentSiteID != payload.TargetSiteID {
+		return sitePushTarget{}, "staging sites can only be pushed to their parent site"
+	}
+	live, err := e.siteStore.GetByID(ctx, payload.TargetSiteID)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("live site not found: %v", err)
+	}
+	if staging.ServerID != job.ServerID || live.ServerID != job.ServerID {
+		return sitePushTarget{}, "both sites must belong to the job server"
+	}
+	if staging.DeploymentState != serverpkg.SiteDeploymentStateReady || live.DeploymentState != serverpkg.SiteDeploymentStateReady {
+		return sitePushTarget{}, "both sites must be deployed before pushing"
+	}
+	stagingDomain, err := e.primaryDomainForSite(ctx, staging.ID)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("staging site: %v", err)
+	}
+	liveDomain, err := e.primaryDomainForSite(ctx, live.ID)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("live site: %v", err)
+	}
+	server, err := e.serverStore.GetByID(ctx, job.ServerID)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("server not found: %v", err)
+	}
+	if server.Status != platform.ServerStatusReady {
+		return sitePushTarget{}, "server must be ready before pushing a site"
+	}
+	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("failed to read SSH key: %v", err)
+	}
+	if storedKey == nil {
+		return sitePushTarget{}, "missing SSH key for server"
+	}
+	decryptedKey, err := security.Decrypt(storedKey.PrivateKeyEncrypted)
+	if err != nil {
+		return sitePushTarget{}, fmt.Sprintf("failed to decrypt SSH key: %v", err)
+	}
+	return sitePushTarget{
+		server:     server,
+		staging:    staging,
+		live:       live,
+		sourceURL:  "https://" + stagingDomain.Hostname,
+		targetURL:  "https://" + liveDomain.Hostname,
+		liveDomain: liveDomain,
+		privateKey: string(decryptedKey),
+	}, ""
+}
+
+// rollbackPush puts the pre-push backup back and fails the job. The rollback
+// outcome is part of the failure message, as with restores, and it also runs
+// after a cancellation.
+func (e *Executor) rollbackPush(ctx context.Context, job *orchestrator.Job, target sitePushTarget, payload orchestrator.PushSitePayload, errMsg string) error {
+	rollbackCtx, cancel := rollbackContext(ctx)
+	defer cancel()
+	e.emitStepStart(rollbackCtx, job.ID, "rollback", "Rolling back to pre-push backup")
+	if err := e.runSitePushPlaybook(rollbackCtx, job.ID, target, payload, pushPhaseRollback, nil); err != nil {
+		errMsg = fmt.Sprintf("%s; rollback failed: %v", errMsg, err)
+		_ = e.siteStore.UpdateRuntimeHealth(rollbackCtx, target.live.ID, serverpkg.SiteRuntimeHealthStateIssue, errMsg, time.Now().UTC().Format(time.RFC3339))
+		return e.failJob(ctx, job, errMsg)
+	}
+	e.emitStepComplete(rollbackCtx, job.ID, "rollback", "Pre-push backup reapplied")
+	e.emitActivity(rollbackCtx, activity.EmitInput{
+		EventType:          activity.EventSitePushRolledBack,
+		Category:           activity.CategorySite,
+		Level:              activity.LevelWarning,
+		ResourceType:       activity.ResourceSite,
+		ResourceID:         target.live.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   target.staging.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Push to '%s' rolled back", target.live.Name),
+		Message:            errMsg,
+	})
+	return e.failJob(ctx, job, errMsg+"; live site rolled back to its pre-push backup")
+}
+
+// verifyPushedSite prefers the agent snapshot and falls back to the public
+// checks used after a deploy when no agent prober is configured.
+func (e *Executor) verifyPushedSite(ctx context.Context, target sitePushTarget) error {
+	if e.healthProber == nil {
+		return e.verifySiteDeployment(ctx, *target.live, *target.liveDomain)
+	}
+	snapshot, err := e.healthProber.SiteHealthSnapshot(ctx, target.server.ID, agentcommand.SiteHealthSnapshotParams{
+		SiteID:   target.live.ID,
+		Hostname: target.liveDomain.Hostname,
+		SitePath: effectiveWordPressPath(*target.live),
+	})
+	if err != nil {
+		return err
+	}
+	if !snapshot.Healthy {
+		return fmt.Errorf("live site is unhealthy: %s", snapshot.Summary)
+	}
+	state, message := serverpkg.RuntimeHealthFromAgentSnapshot(snapshot)
+	_ = e.siteStore.UpdateRuntimeHealth(ctx, target.live.ID, state, message, time.Now().UTC().Format(time.RFC3339))
+	return nil
+}
+
+func (e *Executor) emitPushDiff(ctx context.Context, jobID string, diff orchestrator.PushSiteDiff) error {
+	encoded, err := json.Marshal(diff)
+	if err != nil {
+		return err
+	}
+	_, err = e.jobStore.AppendEvent(ctx, jobID, orchestrator.CreateEventInput{
+		EventType: orchestrator.JobEventTypeDiffSummary,
+		Level:     "info",
+		StepKey:   "diff",
+		Status:    string(orchestrator.JobStatusRunning),
+		Message:   pushSiteDiff{diff}.message(),
+		Payload:   string(encoded),
+	})
+	return err
+}
+
+func (e *Executor) runSitePushDiff(ctx context.Context, jobID string, target sitePushTarget, payload orchestrator.PushSitePayload) (sitePushArtifact, error) {
+	workspace, err := os.MkdirTemp("", "pressluft-site-push-diff-")
+	if err != nil {
+		return sitePushArtifact{}, fmt.Errorf("failed to create diff workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+	artifactPath := filepath.Join(workspace, "push-diff.json")
+	if err := e.runSitePushPlaybook(ctx, jobID, target, payload, pushPhaseDiff, map[string]string{"artifact_path": artifactPath}); err != nil {
+		return sitePushArtifact{}, err
+	}
+	data, err := os.ReadFile(artifactPath)
+	if err != nil {
+		return sitePushArtifact{}, fmt.Errorf("read push diff: %w", err)
+	}
+	var out sitePushArtifact
+	if err := json.Unmarshal(data, &out); err != nil {
+		return sitePushArtifact{}, fmt.Errorf("decode push diff: %w", err)
+	}
+	return out, nil
+}
+
+func (e *Executor) runSitePushPlaybook(ctx context.Context, jobID string, target sitePushTarget, payload orchestrator.PushSitePayload, phase string, vars map[string]string) error {
+	workspace, err := os.MkdirTemp("", "pressluft-site-push-")
+	if err != nil {
+		return fmt.Errorf("failed to create push workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+
+	inventoryPath, err := writeSiteInventory(workspace, target.server, target.privateKey)
+	if err != nil {
+		return err
+	}
+	extraVars := map[string]string{
+		"site_id":          target.staging.ID,
+		"site_path":        effectiveWordPressPath(*target.staging),
+		"target_site_id":   target.live.ID,
+		"target_site_path": effectiveWordPressPath(*target.live),
+		"source_url":       target.sourceURL,
+		"target_url":       target.targetURL,
+		"push_id":          jobID,
+		"push_phase":       phase,
+		"push_files":       strconv.FormatBool(payload.IncludesFiles()),
+	}
+	for key, value := range vars {
+		extraVars[key] = value
+	}
+	request := runner.Request{
+		JobID:         jobID,
+		InventoryPath: inventoryPath,
+		PlaybookPath:  e.sitePushPlaybook(),
+		ExtraVars:     extraVars,
+	}
+	return e.runPlaybook(ctx, target.server, request)
+}
+
+// pushSiteDiff adds summary helpers to the published diff type.
+type pushSiteDiff struct {
+	orchestrator.PushSiteDiff
+}
+
+func buildPushSiteDiff(payload orchestrator.PushSitePayload, artifact sitePushArtifact) pushSiteDiff {
+	diff := orchestrator.PushSiteDiff{DryRun: payload.DryRun}
+	if payload.IncludesFiles() {
+		files := parseRsyncItemizedChanges(artifact.FileChanges)
+		diff.Files = &files
+	}
+	if payload.IncludesDatabase() {
+		source := parseTableRowCounts(artifact.SourceTables)
+		target := parseTableRowCounts(artifact.TargetTables)
+		names := make([]string, 0, len(source))
+		for name := range source {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			diff.Tables = append(diff.Tables, orchestrator.PushSiteTableDiff{
+				Name:       name,
+				SourceRows: source[name],
+				TargetRows: target[name],
+				Selected:   payload.SelectsTable(name),
+			})
+		}
+	}
+	return pushSiteDiff{diff}
+}
+
+func (d pushSiteDiff) selectedTables() []string {
+	var out []string
+	for _, table := range d.Tables {
+		if table.Selected {
+			out = append(out, table.Name)
+		}
+	}
+	return out
+}
+
+func (d pushSiteDiff) message() string {
+	var parts []string
+	if d.Files != nil {
+		parts = append(parts, fmt.Sprintf("%d files added, %d changed, %d deleted", d.Files.Added, d.Files.Changed, d.Files.Deleted))
+	}
+	if d.Tables != nil {
+		parts = append(parts, fmt.Sprintf("%d of %d tables selected", len(d.selectedTables()), len(d.Tables)))
+	}
+	if len(parts) == 0 {
+		return "Nothing to push"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseRsyncItemizedChanges counts regular-file changes in the output of
+// `rsync --itemize-changes`. Directory and attribute-only lines are skipped.
+func parseRsyncItemizedChanges(output string) orchestrator.PushSiteFileDiff {
+	var diff orchestrator.PushSiteFileDiff
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var path string
+		switch {
+		case strings.HasPrefix(line, "*deleting"):
+			path = strings.TrimSpace(strings.TrimPrefix(line, "*deleting"))
+			if strings.HasSuffix(path, "/") {
+				continue
+			}
+			diff.Deleted++
+		case len(line) > 12 && (line[0] == '>' || line[0] == '<') && line[1] == 'f':
+			path = strings.TrimSpace(line[12:])
+			if strings.HasPrefix(line[2:11], "+++") {
+				diff.Added++
+			} else {
+				diff.Changed++
+			}
+		default:
+			continue
+		}
+		if len(diff.Sample) < pushDiffSampleLimit {
+			diff.Sample = append(diff.Sample, path)
+		}
+	}
+	return diff
+}
+
+// parseTableRowCounts reads the tab-separated `table<TAB>rows` lines written
+// by the diff phase.
+func parseTableRowCounts(output string) map[string]int64 {
+	counts := map[string]int64{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		name, rows, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if !ok || name == "" {
+			continue
+		}
+		count, err := strconv.ParseInt(strings.TrimSpace(rows), 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[name] = count
+	}
+	return counts
+}
@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+)
+
+const pushTestDiffArtifact = `{
+	"file_changes": ">f+++++++++ wp-content/themes/shop/new.css\n>f.st...... wp-content/themes/shop/style.css\ncd+++++++++ wp-content/uploads/2026/\n*deleting   wp-content/plugins/old/old.php\n*deleting   wp-content/plugins/old/\n",
+	"source_tables": "wp_options\t120\nwp_posts\t42\nwp_users\t3\nwp_woocommerce_order_items\t9\n",
+	"target_tables": "wp_options\t118\nwp_posts\t40\nwp_users\t5\nwp_woocommerce_order_items\t12\n"
+}`
+
+func TestExecutorPushSiteDryRunOnlyReportsDiff(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustBackupTestServerStore(t)
+	var phases []string
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		phases = append(phases, req.ExtraVars["push_phase"])
+		if path := req.ExtraVars["artifact_path"]; path != "" {
+			return os.WriteFile(path, []byte(pushTestDiffArtifact), 0o600)
+		}
+		return nil
+	}}
+	executor := NewExecutor(jobStore, serverStore, nil, pushTestSites(), pushTestDomainStore(), nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+	}, testLogger())
+
+	job := mustClaimPushJob(t, jobStore, orchestrator.PushSitePayload{
+		SiteID:        stagingTestSiteID,
+		TargetSiteID:  backupTestSiteID,
+		ExcludeTables: []string{"wp_users", "wp_woocommerce_*"},
+		DryRun:        true,
+	})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute push: %v", err)
+	}
+
+	if got := mustGetExecutorJob(t, jobStore, job.ID).Status; got != orchestrator.JobStatusSucceeded {
+		t.Fatalf("job status = %q, want succeeded", got)
+	}
+	if got := strings.Join(phases, ","); got != "diff" {
+		t.Fatalf("push phases = %q, want diff only", got)
+	}
+	vars := fakeRunner.requests[0].ExtraVars
+	if vars["site_id"] != stagingTestSiteID || vars["target_site_id"] != backupTestSiteID || vars["push_files"] != "true" {
+		t.Fatalf("diff extra vars = %v", vars)
+	}
+
+	diff := mustPushDiffEvent(t, jobStore, job.ID)
+	if !diff.DryRun || diff.Files == nil {
+		t.Fatalf("diff = %+v, want dry run with file changes", diff)
+	}
+	if diff.Files.Added != 1 || diff.Files.Changed != 1 || diff.Files.Deleted != 1 || len(diff.Files.Sample) != 3 {
+		t.Fatalf("file diff = %+v, want 1 added, 1 changed, 1 deleted", diff.Files)
+	}
+	selected := map[string]bool{}
+	for _, table := range diff.Tables {
+		selected[table.Name] = table.Selected
+	}
+	if len(diff.Tables) != 4 || !selected["wp_options"] || !selected["wp_posts"] || selected["wp_users"] || selected["wp_woocommerce_order_items"] {
+		t.Fatalf("table diff = %+v, want users and orders excluded", diff.Tables)
+	}
+	if diff.Tables[0].Name != "wp_options" || diff.Tables[0].SourceRows != 120 || diff.Tables[0].TargetRows != 118 {
+		t.Fatalf("wp_options row counts = %+v", diff.Tables[0])
+	}
+}
+
+func TestExecutorPushSiteRollsBackWhenHealthCheckFails(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustBackupTestServerStore(t)
+	var phases []string
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		phases = append(phases, req.ExtraVars["push_phase"])
+		switch req.ExtraVars["push_phase"] {
+		case pushPhaseDiff:
+			return os.WriteFile(req.ExtraVars["artifact_path"], []byte(pushTestDiffArtifact), 0o600)
+		case pushPhaseApply:
+			if req.ExtraVars["push_files"] != "false" || req.ExtraVars["push_tables"] != "wp_options,wp_posts" ||
+				req.ExtraVars["source_url"] != "https://staging-agency.203-0-113-10.sslip.io" || req.ExtraVars["target_url"] != "https://agency.example.test" {
+				t.Errorf("apply extra vars = %v", req.ExtraVars)
+			}
+		}
+		return nil
+	}}
+	sites := pushTestSites()
+	prober := &fakeSiteHealthProber{snapshot: &agentcommand.SiteHealthSnapshot{Healthy: false, Summary: "siteurl does not match hostname"}}
+	executor := NewExecutor(jobStore, serverStore, nil, sites, pushTestDomainStore(), nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		SiteHealthProber: prober,
+	}, testLogger())
+
+	job := mustClaimPushJob(t, jobStore, orchestrator.PushSitePayload{
+		SiteID:        stagingTestSiteID,
+		TargetSiteID:  backupTestSiteID,
+		Scope:         orchestrator.PushScopeDatabase,
+		IncludeTables: []string{"wp_options", "wp_posts"},
+	})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected push to fail")
+	}
+
+	stored := mustGetExecutorJob(t, jobStore, job.ID)
+	if stored.Status != orchestrator.JobStatusFailed || !strings.Contains(stored.LastError, "rolled back") {
+		t.Fatalf("job = %+v, want failed with rollback note", stored)
+	}
+	if got := strings.Join(phases, ","); got != "diff,snapshot,apply,rollback" {
+		t.Fatalf("push phases = %q, want diff,snapshot,apply,rollback", got)
+	}
+	if prober.params.SiteID != backupTestSiteID || prober.params.Hostname != "agency.example.test" {
+		t.Fatalf("health probe params = %+v, want the live site", prober.params)
+	}
+	if got := serverStore.servers[backupTestServerID].Status; got != platform.ServerStatusReady {
+		t.Fatalf("server status = %q, want ready after a site-scoped failure", got)
+	}
+}
+
+func TestParseRsyncItemizedChangesSkipsDirectories(t *testing.T) {
+	diff := parseRsyncItemizedChanges("cd+++++++++ wp-content/uploads/\n.d..t...... wp-content/\n*deleting   wp-content/cache/\n<f.st...... index.php\n")
+	if diff.Added != 0 || diff.Changed != 1 || diff.Deleted != 0 {
+		t.Fatalf("diff = %+v, want a single changed file", diff)
+	}
+	if len(diff.Sample) != 1 || diff.Sample[0] != "index.php" {
+		t.Fatalf("sample = %v, want [index.php]", diff.Sample)
+	}
+}
+
+func pushTestSites() *fakeSitesByID {
+	live := readyBackupTestSite()
+	return &fakeSitesByID{sites: map[string]*server.StoredSite{
+		backupTestSiteID: live,
+		stagingTestSiteID: {
+			ID:              stagingTestSiteID,
+			ServerID:        backupTestServerID,
+			Name:            "Agency Site (staging)",
+			Environment:     server.SiteEnvironmentStaging,
+			ParentSiteID:    backupTestSiteID,
+			DeploymentState: server.SiteDeploymentStateReady,
+		},
+	}}
+}
+
+func pushTestDomainStore() *fakeDomainStore {
+	return &fakeDomainStore{domains: []server.StoredDomain{
+		{ID: "00000000-0000-7000-8000-0000000000d1", Hostname: "agency.example.test", SiteID: backupTestSiteID, IsPrimary: true},
+		{ID: "00000000-0000-7000-8000-0000000000d2", Hostname: "staging-agency.203-0-113-10.sslip.io", SiteID: stagingTestSiteID, IsPrimary: true},
+	}}
+}
+
+func mustClaimPushJob(t *testing.T, jobStore *orchestrator.Store, payload orchestrator.PushSitePayload) orchestrator.Job {
+	t.Helper()
+	raw, err := orchestrator.MarshalPushSitePayload(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	return mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindPushSite),
+		ServerID: backupTestServerID,
+		Payload:  raw,
+	})
+}
+
+func mustPushDiffEvent(t *testing.T, jobStore *orchestrator.Store, jobID string) orchestrator.PushSiteDiff {
+	t.Helper()
+	events, err := jobStore.ListEvents(context.Background(), jobID, 0, 100)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	for _, event := range events {
+		if event.EventType != orchestrator.JobEventTypeDiffSummary {
+			continue
+		}
+		var diff orchestrator.PushSiteDiff
+		if err := json.Unmarshal([]byte(event.Payload), &diff); err != nil {
+			t.Fatalf("decode diff payload: %v", err)
+		}
+		return diff
+	}
+	t.Fatal("no diff_summary event recorded")
+	return orchestrator.PushSiteDiff{}
+}
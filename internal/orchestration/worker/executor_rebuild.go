@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"pressluft/internal/controlplane/activity"
-	"pressluft/internal/infra/provider"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 )
@@ -47,17 +46,9 @@ func (e *Executor) executeRebuildServer(ctx context.Context, job *orchestrator.J
 	if err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("provider not found: %v", err))
 	}
-	if !provider.SupportsServerMutationWorkflow(storedProvider.Type) {
-		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not support ansible workflows", storedProvider.Type))
-	}
-	if strings.TrimSpace(storedProvider.APIToken) == "" {
-		return e.failJob(ctx, job, "provider token is required")
-	}
-	if e.runner == nil {
-		return e.failJob(ctx, job, "ansible runner not configured")
-	}
-	if strings.TrimSpace(e.playbookBasePath) == "" {
-		return e.failJob(ctx, job, "playbook base path not configured")
+	lifecycle, err := e.serverLifecycle(storedProvider)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
 	}
 
 	payload, err := orchestrator.UnmarshalRebuildServerPayload(job.Payload)
@@ -82,17 +73,13 @@ func (e *Executor) executeRebuildServer(ctx context.Context, job *orchestrator.J
 	e.emitStepComplete(ctx, job.ID, "validate", "Rebuild request validated")
 
 	e.updateStep(ctx, job.ID, "rebuild")
-	e.emitStepStart(ctx, job.ID, "rebuild", "Running rebuild playbook")
+	e.emitStepStart(ctx, job.ID, "rebuild", fmt.Sprintf("Rebuilding server from %s", serverImage))
 
-	if err := e.runLocalPlaybook(ctx, job.ID, e.providerPlaybook(storedProvider.Type, playbookRebuild), map[string]string{
-		"api_token":    storedProvider.APIToken,
-		"server_name":  serverName,
-		"server_image": serverImage,
-	}); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("ansible rebuild failed: %v", err))
+	if err := lifecycle.RebuildServer(ctx, storedProvider.APIToken, providerRef(server), serverImage, e.providerActionReporter(ctx, job.ID, "rebuild", storedProvider.Type)); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("provider rebuild failed: %v", err))
 	}
 
-	e.emitStepComplete(ctx, job.ID, "rebuild", "Rebuild playbook completed")
+	e.emitStepComplete(ctx, job.ID, "rebuild", "Server rebuilt")
 	e.setServerStatus(ctx, server.ID, platform.ServerStatusConfiguring)
 	e.setSetupState(ctx, server.ID, platform.SetupStateRunning, "")
 	if err := e.serverStore.UpdateImage(ctx, server.ID, serverImage); err != nil {
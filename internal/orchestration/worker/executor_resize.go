@@ -3,11 +3,9 @@ package worker
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"pressluft/internal/controlplane/activity"
-	"pressluft/internal/infra/provider"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
 )
@@ -47,17 +45,9 @@ func (e *Executor) executeResizeServer(ctx context.Context, job *orchestrator.Jo
 	if err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("provider not found: %v", err))
 	}
-	if !provider.SupportsServerMutationWorkflow(storedProvider.Type) {
-		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not support ansible workflows", storedProvider.Type))
-	}
-	if strings.TrimSpace(storedProvider.APIToken) == "" {
-		return e.failJob(ctx, job, "provider token is required")
-	}
-	if e.runner == nil {
-		return e.failJob(ctx, job, "ansible runner not configured")
-	}
-	if strings.TrimSpace(e.playbookBasePath) == "" {
-		return e.failJob(ctx, job, "playbook base path not configured")
+	lifecycle, err := e.serverLifecycle(storedProvider)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
 	}
 
 	payload, err := orchestrator.UnmarshalResizeServerPayload(job.Payload)
@@ -72,18 +62,13 @@ func (e *Executor) executeResizeServer(ctx context.Context, job *orchestrator.Jo
 	e.emitStepComplete(ctx, job.ID, "validate", "Resize request validated")
 
 	e.updateStep(ctx, job.ID, "resize")
-	e.emitStepStart(ctx, job.ID, "resize", "Running resize playbook")
+	e.emitStepStart(ctx, job.ID, "resize", fmt.Sprintf("Resizing server to %s", serverType))
 
-	if err := e.runLocalPlaybook(ctx, job.ID, e.providerPlaybook(storedProvider.Type, playbookResize), map[string]string{
-		"api_token":    storedProvider.APIToken,
-		"server_name":  server.Name,
-		"server_type":  serverType,
-		"upgrade_disk": strconv.FormatBool(payload.UpgradeDisk),
-	}); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("ansible resize failed: %v", err))
+	if err := lifecycle.ResizeServer(ctx, storedProvider.APIToken, providerRef(server), serverType, payload.UpgradeDisk, e.providerActionReporter(ctx, job.ID, "resize", storedProvider.Type)); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("provider resize failed: %v", err))
 	}
 
-	e.emitStepComplete(ctx, job.ID, "resize", "Resize playbook completed")
+	e.emitStepComplete(ctx, job.ID, "resize", "Server resized")
 
 	e.updateStep(ctx, job.ID, "finalize")
 	e.emitStepStart(ctx, job.ID, "finalize", "Finalizing resize")
@@ -91,6 +76,7 @@ func (e *Executor) executeResizeServer(ctx context.Context, job *orchestrator.Jo
 	if err := e.serverStore.UpdateServerType(ctx, server.ID, serverType); err != nil {
 		e.logger.Error("failed to update server type", "error", err)
 	}
+	e.recordServerPrice(ctx, storedProvider, server, serverType)
 	if err := e.serverStore.UpdateStatus(ctx, server.ID, platform.ServerStatusReady); err != nil {
 		e.logger.Error("failed to update server status to ready", "error", err)
 	}
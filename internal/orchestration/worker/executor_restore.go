@@ -0,0 +1,259 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/infra/s3"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+// backupDownloadURLExpiry bounds how long the presigned download URL handed to
+// the managed server stays valid.
+const backupDownloadURLExpiry = 2 * time.Hour
+
+// rollbackTimeout bounds putting a site back on its pre-change snapshot.
+const rollbackTimeout = 30 * time.Minute
+
+// rollbackContext detaches a rollback from the job context. A job cancelled
+// or timed out while its playbook writes the live site must still put the
+// site back, so the rollback only keeps the job's values and gets its own
+// deadline.
+func rollbackContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+}
+
+// Phases of restore-site.yml. The worker runs the playbook once per phase so
+// it can verify the restored site between applying the archive and dropping
+// the pre-restore snapshot.
+const (
+	restorePhaseSnapshot = "snapshot"
+	restorePhaseRestore  = "restore"
+	restorePhaseRollback = "rollback"
+	restorePhaseCleanup  = "cleanup"
+)
+
+// siteRestoreTarget bundles what every restore phase needs to reach the site.
+type siteRestoreTarget struct {
+	server     *serverpkg.StoredServer
+	site       *serverpkg.StoredSite
+	hostname   string
+	privateKey string
+}
+
+func (e *Executor) executeRestoreSite(ctx context.Context, job *orchestrator.Job) error {
+	if strings.TrimSpace(job.ServerID) == "" {
+		return e.failJob(ctx, job, "server_id is required for site restore job")
+	}
+	if e.siteStore == nil || e.domainStore == nil {
+		return e.failJob(ctx, job, "site stores not configured")
+	}
+	if e.backupStore == nil {
+		return e.failJob(ctx, job, "backup store not configured")
+	}
+	if e.healthProber == nil {
+		return e.failJob(ctx, job, "site health prober not configured")
+	}
+	if e.runner == nil {
+		return e.failJob(ctx, job, "ansible runner not configured")
+	}
+
+	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{ToStatus: orchestrator.JobStatusRunning, CurrentStep: "validate"}); err != nil {
+		return fmt.Errorf("transition to running: %w", err)
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventJobStarted,
+		Category:           activity.CategoryJob,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   e.siteIDForJob(*job),
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("%s started", orchestrator.JobKindLabel(job.Kind)),
+	})
+
+	payload, err := orchestrator.UnmarshalRestoreSitePayload(job.Payload)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+
+	e.emitStepStart(ctx, job.ID, "validate", "Validating site restore inputs")
+	site, err := e.siteStore.GetByID(ctx, payload.SiteID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("site not found: %v", err))
+	}
+	if site.ServerID != job.ServerID {
+		return e.failJob(ctx, job, "site does not belong to the job server")
+	}
+	if site.DeploymentState != serverpkg.SiteDeploymentStateReady {
+		return e.failJob(ctx, job, "site must be deployed before a backup can be restored onto it")
+	}
+	primaryDomain, err := e.primaryDomainForSite(ctx, site.ID)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+	server, err := e.serverStore.GetByID(ctx, job.ServerID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("server not found: %v", err))
+	}
+	if server.Status != platform.ServerStatusReady {
+		return e.failJob(ctx, job, "server must be ready before restoring a site")
+	}
+	backup, err := e.backupStore.GetBackup(ctx, payload.BackupID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("backup not found: %v", err))
+	}
+	if backup.Status != serverpkg.SiteBackupStatusCompleted {
+		return e.failJob(ctx, job, fmt.Sprintf("backup is %s, only completed backups can be restored", backup.Status))
+	}
+	target, err := e.backupStore.GetTarget(ctx, backup.TargetID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("backup target not found: %v", err))
+	}
+	client, err := s3.NewClient(target.S3Config(), nil)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("invalid backup target: %v", err))
+	}
+	downloadURL, err := client.PresignGet(backup.ObjectKey, backupDownloadURLExpiry)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("presign download: %v", err))
+	}
+	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("failed to read SSH key: %v", err))
+	}
+	if storedKey == nil {
+		return e.failJob(ctx, job, "missing SSH key for server")
+	}
+	decryptedKey, err := security.Decrypt(storedKey.PrivateKeyEncrypted)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("failed to decrypt SSH key: %v", err))
+	}
+	restoreTarget := siteRestoreTarget{server: server, site: site, hostname: primaryDomain.Hostname, privateKey: string(decryptedKey)}
+	e.emitStepComplete(ctx, job.ID, "validate", "Site restore request validated")
+
+	e.updateStep(ctx, job.ID, "snapshot")
+	e.emitStepStart(ctx, job.ID, "snapshot", "Taking pre-restore snapshot")
+	if err := e.runSiteRestorePlaybook(ctx, job.ID, restoreTarget, restorePhaseSnapshot, nil); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("pre-restore snapshot failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "snapshot", "Pre-restore snapshot stored on the server")
+
+	e.updateStep(ctx, job.ID, "restore")
+	e.emitStepStart(ctx, job.ID, "restore", fmt.Sprintf("Restoring backup from %s", target.Name))
+	if err := e.runSiteRestorePlaybook(ctx, job.ID, restoreTarget, restorePhaseRestore, map[string]string{
+		"presigned_download_url": downloadURL,
+		"checksum_sha256":        backup.ChecksumSHA256,
+		"target_url":             "https://" + primaryDomain.Hostname,
+	}); err != nil {
+		return e.rollbackRestore(ctx, job, restoreTarget, backup.ID, fmt.Sprintf("restore failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "restore", "Backup applied to the site")
+
+	e.updateStep(ctx, job.ID, "verify")
+	e.emitStepStart(ctx, job.ID, "verify", "Verifying restored site")
+	snapshot, err := e.healthProber.SiteHealthSnapshot(ctx, server.ID, agentcommand.SiteHealthSnapshotParams{
+		SiteID:   site.ID,
+		Hostname: primaryDomain.Hostname,
+		SitePath: effectiveWordPressPath(*site),
+	})
+	if err != nil {
+		return e.rollbackRestore(ctx, job, restoreTarget, backup.ID, fmt.Sprintf("post-restore health check failed: %v", err))
+	}
+	if !snapshot.Healthy {
+		return e.rollbackRestore(ctx, job, restoreTarget, backup.ID, fmt.Sprintf("restored site is unhealthy: %s", snapshot.Summary))
+	}
+	healthState, healthMessage := serverpkg.RuntimeHealthFromAgentSnapshot(snapshot)
+	_ = e.siteStore.UpdateRuntimeHealth(ctx, site.ID, healthState, healthMessage, time.Now().UTC().Format(time.RFC3339))
+	e.emitStepComplete(ctx, job.ID, "verify", "Restored site passed health checks")
+
+	e.updateStep(ctx, job.ID, "finalize")
+	e.emitStepStart(ctx, job.ID, "finalize", "Removing pre-restore snapshot")
+	if err := e.runSiteRestorePlaybook(ctx, job.ID, restoreTarget, restorePhaseCleanup, nil); err != nil {
+		// The restore itself succeeded; a leftover snapshot only costs disk.
+		e.logger.Error("pre-restore snapshot cleanup failed", "job_id", job.ID, "site_id", site.ID, "error", err)
+	}
+	e.emitStepComplete(ctx, job.ID, "finalize", "Site restore finalized")
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventBackupRestored,
+		Category:           activity.CategoryBackup,
+		Level:              activity.LevelSuccess,
+		ResourceType:       activity.ResourceBackup,
+		ResourceID:         backup.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   site.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Backup restored onto site '%s'", site.Name),
+		Message:            fmt.Sprintf("Site is serving the restored content at https://%s/.", primaryDomain.Hostname),
+	})
+	return e.completeJob(ctx, job, "finalize")
+}
+
+// rollbackRestore puts the pre-restore snapshot back and fails the job. The
+// rollback outcome is part of the failure message so operators know whether
+// the site is back on its previous content. It also runs when the job was
+// cancelled mid-restore; see rollbackContext.
+func (e *Executor) rollbackRestore(ctx context.Context, job *orchestrator.Job, target siteRestoreTarget, backupID, errMsg string) error {
+	rollbackCtx, cancel := rollbackContext(ctx)
+	defer cancel()
+	e.emitStepStart(rollbackCtx, job.ID, "rollback", "Rolling back to pre-restore snapshot")
+	if err := e.runSiteRestorePlaybook(rollbackCtx, job.ID, target, restorePhaseRollback, nil); err != nil {
+		errMsg = fmt.Sprintf("%s; rollback failed: %v", errMsg, err)
+		_ = e.siteStore.UpdateRuntimeHealth(rollbackCtx, target.site.ID, serverpkg.SiteRuntimeHealthStateIssue, errMsg, time.Now().UTC().Format(time.RFC3339))
+		return e.failJob(ctx, job, errMsg)
+	}
+	e.emitStepComplete(rollbackCtx, job.ID, "rollback", "Pre-restore snapshot reapplied")
+	e.emitActivity(rollbackCtx, activity.EmitInput{
+		EventType:          activity.EventBackupRolledBack,
+		Category:           activity.CategoryBackup,
+		Level:              activity.LevelWarning,
+		ResourceType:       activity.ResourceBackup,
+		ResourceID:         backupID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   target.site.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Restore onto site '%s' rolled back", target.site.Name),
+		Message:            errMsg,
+	})
+	return e.failJob(ctx, job, errMsg+"; site rolled back to its pre-restore snapshot")
+}
+
+func (e *Executor) runSiteRestorePlaybook(ctx context.Context, jobID string, target siteRestoreTarget, phase string, vars map[string]string) error {
+	workspace, err := os.MkdirTemp("", "pressluft-site-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create restore workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+
+	inventoryPath, err := writeSiteInventory(workspace, target.server, target.privateKey)
+	if err != nil {
+		return err
+	}
+	extraVars := map[string]string{
+		"site_id":       target.site.ID,
+		"site_path":     effectiveWordPressPath(*target.site),
+		"restore_id":    jobID,
+		"restore_phase": phase,
+	}
+	for key, value := range vars {
+		extraVars[key] = value
+	}
+	request := runner.Request{
+		JobID:         jobID,
+		InventoryPath: inventoryPath,
+		PlaybookPath:  e.siteRestorePlaybook(),
+		ExtraVars:     extraVars,
+	}
+	return e.runPlaybook(ctx, target.server, request)
+}
@@ -0,0 +1,231 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/infra/s3/s3test"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+)
+
+func TestExecutorRestoreSiteAppliesBackupAndCleansUp(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	bucket := s3test.NewServer("agency-backups")
+	defer bucket.Close()
+	serverStore := mustBackupTestServerStore(t)
+	backupStore := server.NewBackupStore(executorTestDB)
+	backupID := mustCreateCompletedWorkerBackup(t, backupStore, bucket.URL)
+
+	var phases []string
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		phases = append(phases, req.ExtraVars["restore_phase"])
+		if req.ExtraVars["restore_phase"] == restorePhaseRestore {
+			if req.ExtraVars["presigned_download_url"] == "" || req.ExtraVars["target_url"] != "https://clone.example.test" {
+				t.Errorf("restore extra vars = %v", req.ExtraVars)
+			}
+		}
+		return nil
+	}}
+	prober := &fakeSiteHealthProber{snapshot: &agentcommand.SiteHealthSnapshot{Healthy: true, Summary: "WordPress runtime checks passed."}}
+	siteStore := &fakeSiteStore{site: readyBackupTestSite()}
+	executor := NewExecutor(jobStore, serverStore, nil, siteStore, restoreTestDomainStore(), nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		BackupStore:      backupStore,
+		SiteHealthProber: prober,
+	}, testLogger())
+
+	job := mustClaimRestoreJob(t, jobStore, backupID)
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute restore: %v", err)
+	}
+
+	if got := mustGetExecutorJob(t, jobStore, job.ID).Status; got != orchestrator.JobStatusSucceeded {
+		t.Fatalf("job status = %q, want succeeded", got)
+	}
+	if got := strings.Join(phases, ","); got != "snapshot,restore,cleanup" {
+		t.Fatalf("restore phases = %q, want snapshot,restore,cleanup", got)
+	}
+	for _, req := range fakeRunner.requests {
+		if req.PlaybookPath != filepath.Join("playbooks", playbookSiteRestore) {
+			t.Fatalf("playbook = %q, want restore playbook", req.PlaybookPath)
+		}
+	}
+	if prober.params.Hostname != "clone.example.test" || prober.params.SiteID != backupTestSiteID {
+		t.Fatalf("health probe params = %+v", prober.params)
+	}
+	if siteStore.site.RuntimeHealthState != server.SiteRuntimeHealthStateHealthy {
+		t.Fatalf("runtime health = %q, want healthy", siteStore.site.RuntimeHealthState)
+	}
+}
+
+func TestExecutorRestoreSiteRollsBackWhenHealthCheckFails(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	bucket := s3test.NewServer("agency-backups")
+	defer bucket.Close()
+	serverStore := mustBackupTestServerStore(t)
+	backupStore := server.NewBackupStore(executorTestDB)
+	backupID := mustCreateCompletedWorkerBackup(t, backupStore, bucket.URL)
+
+	var phases []string
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		phases = append(phases, req.ExtraVars["restore_phase"])
+		return nil
+	}}
+	prober := &fakeSiteHealthProber{snapshot: &agentcommand.SiteHealthSnapshot{Healthy: false, Summary: "siteurl does not match hostname"}}
+	executor := NewExecutor(jobStore, serverStore, nil, &fakeSiteStore{site: readyBackupTestSite()}, restoreTestDomainStore(), nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		BackupStore:      backupStore,
+		SiteHealthProber: prober,
+	}, testLogger())
+
+	job := mustClaimRestoreJob(t, jobStore, backupID)
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected restore to fail")
+	}
+
+	stored := mustGetExecutorJob(t, jobStore, job.ID)
+	if stored.Status != orchestrator.JobStatusFailed || !strings.Contains(stored.LastError, "rolled back") {
+		t.Fatalf("job = %+v, want failed with rollback note", stored)
+	}
+	if got := strings.Join(phases, ","); got != "snapshot,restore,rollback" {
+		t.Fatalf("restore phases = %q, want snapshot,restore,rollback", got)
+	}
+	if got := serverStore.servers[backupTestServerID].Status; got != platform.ServerStatusReady {
+		t.Fatalf("server status = %q, want ready after a site-scoped failure", got)
+	}
+}
+
+func TestExecutorRestoreSiteRollsBackWhenCancelledMidRestore(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	bucket := s3test.NewServer("agency-backups")
+	defer bucket.Close()
+	serverStore := mustBackupTestServerStore(t)
+	backupStore := server.NewBackupStore(executorTestDB)
+	backupID := mustCreateCompletedWorkerBackup(t, backupStore, bucket.URL)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	cancelling := &cancellingRunner{cancelPhase: restorePhaseRestore, cancel: cancel}
+	siteStore := &fakeSiteStore{site: readyBackupTestSite()}
+	executor := NewExecutor(jobStore, serverStore, nil, siteStore, restoreTestDomainStore(), nil, cancelling, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		BackupStore:      backupStore,
+		SiteHealthProber: &fakeSiteHealthProber{snapshot: &agentcommand.SiteHealthSnapshot{Healthy: true}},
+	}, testLogger())
+
+	job := mustClaimRestoreJob(t, jobStore, backupID)
+	if err := executor.Execute(ctx, &job); !errors.Is(err, ErrJobCancelled) {
+		t.Fatalf("Execute() error = %v, want %v", err, ErrJobCancelled)
+	}
+
+	// The cancelled restore left the site half-written; the rollback must
+	// still run and succeed on its own context.
+	if got := strings.Join(cancelling.phases, ","); got != "snapshot,restore,rollback" {
+		t.Fatalf("restore phases = %q, want snapshot,restore,rollback", got)
+	}
+	if siteStore.site.RuntimeHealthState == server.SiteRuntimeHealthStateIssue {
+		t.Fatalf("runtime health = %q (%s), want the rollback to succeed", siteStore.site.RuntimeHealthState, siteStore.site.RuntimeHealthStatus)
+	}
+}
+
+// cancellingRunner cancels the job while the named phase writes the site.
+// Like the real runner, it refuses to start a playbook on a done context.
+type cancellingRunner struct {
+	cancelPhase string
+	cancel      context.CancelCauseFunc
+	phases      []string
+}
+
+func (r *cancellingRunner) Name() string { return "cancelling" }
+
+func (r *cancellingRunner) Run(ctx context.Context, req runner.Request, _ runner.EventSink) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	phase := req.ExtraVars["restore_phase"]
+	r.phases = append(r.phases, phase)
+	if phase == r.cancelPhase {
+		r.cancel(ErrJobCancelled)
+		return ctx.Err()
+	}
+	return nil
+}
+
+type fakeSiteHealthProber struct {
+	snapshot *agentcommand.SiteHealthSnapshot
+	err      error
+	params   agentcommand.SiteHealthSnapshotParams
+}
+
+func (p *fakeSiteHealthProber) SiteHealthSnapshot(_ context.Context, _ string, params agentcommand.SiteHealthSnapshotParams) (*agentcommand.SiteHealthSnapshot, error) {
+	p.params = params
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.snapshot, nil
+}
+
+type fakeDomainStore struct {
+	domains []server.StoredDomain
+}
+
+func (s *fakeDomainStore) ListBySite(_ context.Context, siteID string) ([]server.StoredDomain, error) {
+	var out []server.StoredDomain
+	for _, domain := range s.domains {
+		if domain.SiteID == siteID {
+			out = append(out, domain)
+		}
+	}
+	if out == nil {
+		return nil, errors.New("no domains")
+	}
+	return out, nil
+}
+
+func (s *fakeDomainStore) UpdateRoutingStatus(_ context.Context, _, _, _ string, _ time.Time) error {
+	return nil
+}
+
+func restoreTestDomainStore() *fakeDomainStore {
+	return &fakeDomainStore{domains: []server.StoredDomain{
+		{ID: "00000000-0000-7000-8000-0000000000d1", Hostname: "clone.example.test", SiteID: backupTestSiteID, IsPrimary: true},
+	}}
+}
+
+func mustCreateCompletedWorkerBackup(t *testing.T, store *server.BackupStore, endpoint string) string {
+	t.Helper()
+	targetID := mustCreateWorkerBackupTarget(t, store, endpoint)
+	backupID, err := store.CreateBackup(context.Background(), server.CreateSiteBackupInput{
+		SiteID:   "00000000-0000-7000-8000-0000000000bb",
+		ServerID: backupTestServerID,
+		TargetID: targetID,
+	})
+	if err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	if err := store.Complete(context.Background(), backupID, 2048, strings.Repeat("a", 64)); err != nil {
+		t.Fatalf("complete backup: %v", err)
+	}
+	return backupID
+}
+
+func mustClaimRestoreJob(t *testing.T, jobStore *orchestrator.Store, backupID string) orchestrator.Job {
+	t.Helper()
+	payload, err := orchestrator.MarshalRestoreSitePayload(orchestrator.RestoreSitePayload{SiteID: backupTestSiteID, BackupID: backupID})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	return mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindRestoreSite),
+		ServerID: backupTestServerID,
+		Payload:  payload,
+	})
+}
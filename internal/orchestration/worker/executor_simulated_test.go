@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/provider"
+	"pressluft/internal/infra/provider/fake"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+// testFakeCloud registers the fake provider once for the whole package.
+var testFakeCloud = fake.Register()
+
+func TestExecutorSimulatedServerProvisionsAndConfiguresWithoutSSH(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "age.txt")
+	os.Setenv("PRESSLUFT_AGE_KEY_PATH", keyPath)
+	t.Cleanup(func() { _ = os.Unsetenv("PRESSLUFT_AGE_KEY_PATH") })
+	if _, err := security.EnsureAgeKey(keyPath, true); err != nil {
+		t.Fatalf("ensure age key: %v", err)
+	}
+	mustCreateTestAgentBinary(t)
+	testFakeCloud.PollInterval = time.Millisecond
+
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		lifecycleTestServerID: {ID: lifecycleTestServerID, ProviderID: "00000000-0000-7000-8000-000000000011", ProviderType: "fake", Name: "sim-web-1", Location: "sim-1", ServerType: "sim-small", Image: "ubuntu-24.04", ProfileKey: "nginx-stack", Status: platform.ServerStatusPending},
+	}}
+	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "fake", APIToken: "token"}}
+	ansible := &fakeRunner{failPlaybooks: map[string]error{"configure.yml": errors.New("ansible must not run for simulated servers")}}
+	simulated := &fakeRunner{}
+	costs := &fakeCostStore{}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, ansible, ExecutorConfig{
+		ConfigurePlaybookPath: "configure.yml",
+		ControlPlaneURL:       "https://control.example.test",
+		ExecutionMode:         platform.ExecutionModeProductionBootstrap,
+		RegistrationStore:     fakeRegistrationStore{},
+		SimulatedRunner:       simulated,
+		CostStore:             costs,
+	}, testLogger())
+	executor.waitForSSH = func(context.Context, string) error {
+		t.Fatal("waited for SSH on a simulated server")
+		return nil
+	}
+
+	provision := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindProvisionServer), ServerID: lifecycleTestServerID})
+	if err := executor.Execute(context.Background(), &provision); err != nil {
+		t.Fatalf("execute provision: %v", err)
+	}
+	stored := serverStore.servers[lifecycleTestServerID]
+	if !strings.HasPrefix(stored.IPv4, "198.18.") || stored.ProviderServerID == "" {
+		t.Fatalf("server = %+v, want a simulated address", stored)
+	}
+	if len(costs.prices) != 1 || costs.prices[0].ServerType != "sim-small" || costs.prices[0].HourlyGross != "0.0080" || costs.prices[0].Currency != "EUR" {
+		t.Fatalf("recorded prices = %+v, want the catalog price of sim-small", costs.prices)
+	}
+
+	// Provisioning queued the configure job.
+	configure, err := jobStore.ClaimNextJob(context.Background())
+	if err != nil || configure == nil || configure.Kind != string(orchestrator.JobKindConfigureServer) {
+		t.Fatalf("claim configure job = %+v, %v", configure, err)
+	}
+	if err := executor.Execute(context.Background(), configure); err != nil {
+		t.Fatalf("execute configure: %v", err)
+	}
+	if len(ansible.requests) != 0 || len(simulated.requests) != 1 || simulated.requests[0].ExtraVars["server_id"] != lifecycleTestServerID {
+		t.Fatalf("ansible requests = %+v, simulated requests = %+v", ansible.requests, simulated.requests)
+	}
+	if got := serverStore.servers[lifecycleTestServerID].Status; got != platform.ServerStatusReady {
+		t.Fatalf("server status = %q, want ready", got)
+	}
+}
+
+type fakeCostStore struct {
+	prices []server.ServerPriceInput
+	closed []string
+}
+
+func (s *fakeCostStore) RecordPrice(_ context.Context, in server.ServerPriceInput) error {
+	s.prices = append(s.prices, in)
+	return nil
+}
+
+func (s *fakeCostStore) ClosePrice(_ context.Context, serverID string, _ time.Time) error {
+	s.closed = append(s.closed, serverID)
+	return nil
+}
+
+func TestExecutorSimulatedServerRequiresSimulatedRunner(t *testing.T) {
+	executor := NewExecutor(mustOpenExecutorJobStore(t), &fakeServerStore{}, nil, nil, nil, nil, &fakeRunner{}, ExecutorConfig{}, testLogger())
+
+	err := executor.runPlaybook(context.Background(), &server.StoredServer{Name: "sim-web-1", ProviderType: "fake"}, runner.Request{JobID: "job-1", PlaybookPath: "deploy-site.yml"})
+	if err == nil || !strings.Contains(err.Error(), "no simulated runner is configured") {
+		t.Fatalf("runPlaybook() error = %v", err)
+	}
+}
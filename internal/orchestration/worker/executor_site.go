@@ -10,8 +10,10 @@ import (
 	"strings"
 	"time"
 
+	"pressluft/internal/agent/agentcommand"
 	"pressluft/internal/controlplane/activity"
 	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/provider"
 	"pressluft/internal/infra/runner"
 	"pressluft/internal/orchestration/orchestrator"
 	"pressluft/internal/platform"
@@ -167,14 +169,9 @@ func (e *Executor) runSiteDeployPlaybook(ctx context.Context, jobID string, serv
 	}
 	defer os.RemoveAll(workspace)
 
-	privateKeyPath := filepath.Join(workspace, "server.key")
-	if err := os.WriteFile(privateKeyPath, []byte(privateKey), 0o600); err != nil {
-		return fmt.Errorf("failed to write private key: %w", err)
-	}
-	inventoryPath := filepath.Join(workspace, "deploy.ini")
-	inventory := fmt.Sprintf("server ansible_host=%s ansible_user=root ansible_ssh_private_key_file=%s ansible_ssh_common_args='-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null'\n", server.IPv4, privateKeyPath)
-	if err := os.WriteFile(inventoryPath, []byte(inventory), 0o600); err != nil {
-		return fmt.Errorf("failed to write deploy inventory: %w", err)
+	inventoryPath, err := writeSiteInventory(workspace, server, privateKey)
+	if err != nil {
+		return err
 	}
 
 	dbSuffix := strings.ReplaceAll(site.ID[:8], "-", "")
@@ -215,7 +212,22 @@ func (e *Executor) runSiteDeployPlaybook(ctx context.Context, jobID string, serv
 			"secret_key":        secretKey,
 		},
 	}
-	return e.runner.Run(ctx, request, &runnerEventSink{jobStore: e.jobStore, jobID: jobID, logger: e.logger})
+	return e.runPlaybook(ctx, server, request)
+}
+
+// writeSiteInventory writes the SSH key and a single-host inventory for
+// playbooks that act on an existing site's server.
+func writeSiteInventory(workspace string, server *serverpkg.StoredServer, privateKey string) (string, error) {
+	privateKeyPath := filepath.Join(workspace, "server.key")
+	if err := os.WriteFile(privateKeyPath, []byte(privateKey), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write private key: %w", err)
+	}
+	inventoryPath := filepath.Join(workspace, "site.ini")
+	inventory := sshInventoryLine(server.IPv4, server, privateKeyPath)
+	if err := os.WriteFile(inventoryPath, []byte(inventory), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write site inventory: %w", err)
+	}
+	return inventoryPath, nil
 }
 
 func (e *Executor) resolveACMEContactEmail(operatorEmail, siteAdminEmail string) (string, error) {
@@ -257,12 +269,39 @@ func isUsableACMEContactEmail(email string) bool {
 }
 
 func (e *Executor) verifySiteDeployment(ctx context.Context, site serverpkg.StoredSite, primaryDomain serverpkg.StoredDomain) error {
-	if err := serverpkg.VerifyPublicSiteRouting(ctx, site.ID, primaryDomain.Hostname); err != nil {
+	if server, err := e.serverStore.GetByID(ctx, site.ServerID); err == nil && provider.SimulatesServers(server.ProviderType) {
+		return e.verifySimulatedSite(ctx, site, primaryDomain)
+	}
+	auth, err := serverpkg.SiteBasicAuthCredentials(site)
+	if err != nil {
+		return err
+	}
+	if err := serverpkg.VerifyPublicSiteRoutingWithAuth(ctx, site.ID, primaryDomain.Hostname, auth); err != nil {
+		return err
+	}
+	if err := serverpkg.VerifyPublicWordPressRuntimeWithAuth(ctx, primaryDomain.Hostname, auth); err != nil {
 		return err
 	}
-	if err := serverpkg.VerifyPublicWordPressRuntime(ctx, primaryDomain.Hostname); err != nil {
+	return nil
+}
+
+// verifySimulatedSite asks the agent of a simulated server for the site's
+// health, since its hostname does not resolve to anything.
+func (e *Executor) verifySimulatedSite(ctx context.Context, site serverpkg.StoredSite, primaryDomain serverpkg.StoredDomain) error {
+	if e.healthProber == nil {
+		return fmt.Errorf("site health prober not configured")
+	}
+	snapshot, err := e.healthProber.SiteHealthSnapshot(ctx, site.ServerID, agentcommand.SiteHealthSnapshotParams{
+		SiteID:   site.ID,
+		Hostname: primaryDomain.Hostname,
+		SitePath: effectiveWordPressPath(site),
+	})
+	if err != nil {
 		return err
 	}
+	if !snapshot.Healthy {
+		return fmt.Errorf("%s", snapshot.Summary)
+	}
 	return nil
 }
 
@@ -0,0 +1,246 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+// stagingCloneTarget bundles what create-staging.yml needs to copy one site
+// into another on the same server.
+type stagingCloneTarget struct {
+	server        *serverpkg.StoredServer
+	source        *serverpkg.StoredSite
+	sourceDomain  *serverpkg.StoredDomain
+	staging       *serverpkg.StoredSite
+	stagingDomain *serverpkg.StoredDomain
+	privateKey    string
+	basicAuth     *serverpkg.SiteBasicAuth
+}
+
+func (e *Executor) executeCreateStaging(ctx context.Context, job *orchestrator.Job) error {
+	if strings.TrimSpace(job.ServerID) == "" {
+		return e.failJob(ctx, job, "server_id is required for staging creation job")
+	}
+	if e.siteStore == nil || e.domainStore == nil {
+		return e.failJob(ctx, job, "site stores not configured")
+	}
+	if e.runner == nil {
+		return e.failJob(ctx, job, "ansible runner not configured")
+	}
+
+	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{ToStatus: orchestrator.JobStatusRunning, CurrentStep: "validate"}); err != nil {
+		return fmt.Errorf("transition to running: %w", err)
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventJobStarted,
+		Category:           activity.CategoryJob,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   e.siteIDForJob(*job),
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("%s started", orchestrator.JobKindLabel(job.Kind)),
+	})
+
+	payload, err := orchestrator.UnmarshalCreateStagingPayload(job.Payload)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+
+	e.emitStepStart(ctx, job.ID, "validate", "Validating staging inputs")
+	staging, err := e.siteStore.GetByID(ctx, payload.SiteID)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("staging site not found: %v", err))
+	}
+	target, errMsg := e.resolveStagingCloneTarget(ctx, job, payload, staging)
+	if errMsg != "" {
+		return e.failStaging(ctx, job, staging, nil, errMsg)
+	}
+	hostname := target.stagingDomain.Hostname
+	_ = e.siteStore.UpdateDeployment(ctx, staging.ID, serverpkg.SiteDeploymentStateDeploying, fmt.Sprintf("Cloning '%s' to %s.", target.source.Name, hostname), job.ID, "")
+	_ = e.siteStore.UpdateRuntimeHealth(ctx, staging.ID, serverpkg.SiteRuntimeHealthStatePending, "Staging clone is running. Runtime health will be verified before the site is marked live.", "")
+	_ = e.domainStore.UpdateRoutingStatus(ctx, target.stagingDomain.ID, serverpkg.DomainRoutingStatePending, "Applying server routing for this hostname.", time.Now().UTC())
+	e.emitStepComplete(ctx, job.ID, "validate", "Staging request validated")
+
+	e.updateStep(ctx, job.ID, "clone")
+	e.emitStepStart(ctx, job.ID, "clone", fmt.Sprintf("Cloning files, database, and uploads from '%s'", target.source.Name))
+	if err := e.runStagingPlaybook(ctx, job.ID, target, payload.TLSContactEmail); err != nil {
+		return e.failStaging(ctx, job, staging, target.stagingDomain, fmt.Sprintf("staging clone failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "clone", "Staging copy created with search indexing and mail disabled")
+
+	e.updateStep(ctx, job.ID, "verify")
+	e.emitStepStart(ctx, job.ID, "verify", "Verifying staging hostname and WordPress runtime")
+	if err := e.verifySiteDeployment(ctx, *staging, *target.stagingDomain); err != nil {
+		return e.failStaging(ctx, job, staging, target.stagingDomain, fmt.Sprintf("staging verification failed: %v", err))
+	}
+	_ = e.domainStore.UpdateRoutingStatus(ctx, target.stagingDomain.ID, serverpkg.DomainRoutingStateReady, "Hostname routing verified over HTTPS.", time.Now().UTC())
+	_ = e.siteStore.UpdateRuntimeHealth(ctx, staging.ID, serverpkg.SiteRuntimeHealthStateHealthy, fmt.Sprintf("WordPress rendered successfully at https://%s/.", hostname), time.Now().UTC().Format(time.RFC3339))
+	e.emitStepComplete(ctx, job.ID, "verify", "Staging routing and WordPress runtime verified")
+
+	e.updateStep(ctx, job.ID, "finalize")
+	e.emitStepStart(ctx, job.ID, "finalize", "Finalizing staging environment")
+	_ = e.siteStore.UpdateDeployment(ctx, staging.ID, serverpkg.SiteDeploymentStateReady, fmt.Sprintf("Staging is live at https://%s/ behind basic auth.", hostname), job.ID, time.Now().UTC().Format(time.RFC3339))
+	e.emitStepComplete(ctx, job.ID, "finalize", "Staging environment ready")
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventSiteStagingReady,
+		Category:           activity.CategorySite,
+		Level:              activity.LevelSuccess,
+		ResourceType:       activity.ResourceSite,
+		ResourceID:         staging.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   target.source.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Staging '%s' ready", staging.Name),
+		Message:            fmt.Sprintf("A copy of '%s' is live at https://%s/ behind basic auth.", target.source.Name, hostname),
+	})
+	return e.completeJob(ctx, job, "finalize")
+}
+
+// resolveStagingCloneTarget loads and checks everything the clone needs. It
+// returns a failure message instead of an error so the caller can record it
+// on the staging site.
+func (e *Executor) resolveStagingCloneTarget(ctx context.Context, job *orchestrator.Job, payload orchestrator.CreateStagingPayload, staging *serverpkg.StoredSite) (stagingCloneTarget, string) {
+	if staging.Environment != serverpkg.SiteEnvironmentStaging {
+		return stagingCloneTarget{}, "target site is not a staging environment"
+	}
+	if staging.ParentSiteID != payload.SourceSiteID {
+		return stagingCloneTarget{}, "staging site is not linked to the source site"
+	}
+	if staging.ServerID != job.ServerID {
+		return stagingCloneTarget{}, "staging site does not belong to the job server"
+	}
+	source, err := e.siteStore.GetByID(ctx, payload.SourceSiteID)
+	if err != nil {
+		return stagingCloneTarget{}, fmt.Sprintf("source site not found: %v", err)
+	}
+	if source.ServerID != job.ServerID {
+		return stagingCloneTarget{}, "source site does not belong to the job server"
+	}
+	if source.DeploymentState != serverpkg.SiteDeploymentStateReady {
+		return stagingCloneTarget{}, "source site must be deployed before it can be cloned"
+	}
+	sourceDomain, err := e.primaryDomainForSite(ctx, source.ID)
+	if err != nil {
+		return stagingCloneTarget{}, fmt.Sprintf("source site: %v", err)
+	}
+	stagingDomain, err := e.primaryDomainForSite(ctx, staging.ID)
+	if err != nil {
+		return stagingCloneTarget{}, err.Error()
+	}
+	basicAuth, err := serverpkg.SiteBasicAuthCredentials(*staging)
+	if err != nil {
+		return stagingCloneTarget{}, err.Error()
+	}
+	if basicAuth == nil {
+		return stagingCloneTarget{}, "staging site has no basic auth credentials"
+	}
+	server, err := e.serverStore.GetByID(ctx, job.ServerID)
+	if err != nil {
+		return stagingCloneTarget{}, fmt.Sprintf("server not found: %v", err)
+	}
+	if strings.TrimSpace(server.ProfileKey) != "nginx-stack" {
+		return stagingCloneTarget{}, fmt.Sprintf("server profile %q is not supported for staging environments", server.ProfileKey)
+	}
+	if server.Status != platform.ServerStatusReady {
+		return stagingCloneTarget{}, "server must be ready before creating a staging environment"
+	}
+	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
+	if err != nil {
+		return stagingCloneTarget{}, fmt.Sprintf("failed to read SSH key: %v", err)
+	}
+	if storedKey == nil {
+		return stagingCloneTarget{}, "missing SSH key for server"
+	}
+	decryptedKey, err := security.Decrypt(storedKey.PrivateKeyEncrypted)
+	if err != nil {
+		return stagingCloneTarget{}, fmt.Sprintf("failed to decrypt SSH key: %v", err)
+	}
+	return stagingCloneTarget{
+		server:        server,
+		source:        source,
+		sourceDomain:  sourceDomain,
+		staging:       staging,
+		stagingDomain: stagingDomain,
+		privateKey:    string(decryptedKey),
+		basicAuth:     basicAuth,
+	}, ""
+}
+
+// failStaging records the failure on the staging site before failing the job.
+// The source site is never touched by a failed clone.
+func (e *Executor) failStaging(ctx context.Context, job *orchestrator.Job, staging *serverpkg.StoredSite, domain *serverpkg.StoredDomain, errMsg string) error {
+	now := time.Now().UTC()
+	if domain != nil {
+		_ = e.domainStore.UpdateRoutingStatus(ctx, domain.ID, serverpkg.DomainRoutingStateIssue, errMsg, now)
+	}
+	_ = e.siteStore.UpdateDeployment(ctx, staging.ID, serverpkg.SiteDeploymentStateFailed, errMsg, job.ID, "")
+	_ = e.siteStore.UpdateRuntimeHealth(ctx, staging.ID, serverpkg.SiteRuntimeHealthStateIssue, errMsg, now.Format(time.RFC3339))
+	return e.failJob(ctx, job, errMsg)
+}
+
+func (e *Executor) runStagingPlaybook(ctx context.Context, jobID string, target stagingCloneTarget, tlsContactEmail string) error {
+	effectiveTLSContactEmail, err := e.resolveACMEContactEmail(strings.TrimSpace(tlsContactEmail), strings.TrimSpace(target.source.WordPressAdminEmail))
+	if err != nil {
+		return err
+	}
+	workspace, err := os.MkdirTemp("", "pressluft-site-staging-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+
+	inventoryPath, err := writeSiteInventory(workspace, target.server, target.privateKey)
+	if err != nil {
+		return err
+	}
+
+	// Staging sites are usually created shortly after their parent, so the
+	// database name comes from the random tail of the id rather than the
+	// timestamp prefix used for production sites.
+	compactID := strings.ReplaceAll(target.staging.ID, "-", "")
+	dbName := "pl_stg_" + compactID[len(compactID)-12:]
+	dbPassword, err := randomHex(24)
+	if err != nil {
+		return fmt.Errorf("generate database password: %w", err)
+	}
+	secretKey, err := randomHex(32)
+	if err != nil {
+		return fmt.Errorf("generate secret key: %w", err)
+	}
+	request := runner.Request{
+		JobID:         jobID,
+		InventoryPath: inventoryPath,
+		PlaybookPath:  e.siteStagingPlaybook(),
+		ExtraVars: map[string]string{
+			"profile_key":         target.server.ProfileKey,
+			"site_id":             target.staging.ID,
+			"site_path":           effectiveWordPressPath(*target.staging),
+			"source_site_id":      target.source.ID,
+			"source_site_path":    effectiveWordPressPath(*target.source),
+			"source_url":          "https://" + target.sourceDomain.Hostname,
+			"hostname":            target.stagingDomain.Hostname,
+			"php_version":         firstNonEmpty(target.staging.PHPVersion, target.source.PHPVersion, "8.3"),
+			"db_name":             dbName,
+			"db_user":             dbName,
+			"db_password":         dbPassword,
+			"secret_key":          secretKey,
+			"tls_contact_email":   effectiveTLSContactEmail,
+			"basic_auth_username": target.basicAuth.Username,
+			"basic_auth_password": target.basicAuth.Password,
+		},
+	}
+	return e.runPlaybook(ctx, target.server, request)
+}
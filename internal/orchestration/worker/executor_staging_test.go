@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+const stagingTestSiteID = "00000000-0000-7000-8000-0000000000cc"
+
+func TestExecutorCreateStagingMarksStagingFailedWhenCloneFails(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustBackupTestServerStore(t)
+	encryptedPassword, err := security.EncryptSiteBasicAuthPassword("staging-secret")
+	if err != nil {
+		t.Fatalf("encrypt basic auth password: %v", err)
+	}
+	sites := &fakeSitesByID{sites: map[string]*server.StoredSite{
+		backupTestSiteID: readyBackupTestSite(),
+		stagingTestSiteID: {
+			ID:                         stagingTestSiteID,
+			ServerID:                   backupTestServerID,
+			Name:                       "Agency Site (staging)",
+			Environment:                server.SiteEnvironmentStaging,
+			ParentSiteID:               backupTestSiteID,
+			BasicAuthUsername:          "pressluft",
+			BasicAuthPasswordEncrypted: encryptedPassword,
+			DeploymentState:            server.SiteDeploymentStatePending,
+		},
+	}}
+	domains := &fakeDomainStore{domains: []server.StoredDomain{
+		{ID: "00000000-0000-7000-8000-0000000000d1", Hostname: "agency.example.test", SiteID: backupTestSiteID, IsPrimary: true},
+		{ID: "00000000-0000-7000-8000-0000000000d2", Hostname: "staging-agency.203-0-113-10.sslip.io", SiteID: stagingTestSiteID, IsPrimary: true},
+	}}
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		return errors.New("rsync failed")
+	}}
+	executor := NewExecutor(jobStore, serverStore, nil, sites, domains, nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath: "playbooks",
+		ExecutionMode:    platform.ExecutionModeDev,
+	}, testLogger())
+
+	payload, err := orchestrator.MarshalCreateStagingPayload(orchestrator.CreateStagingPayload{
+		SiteID:          stagingTestSiteID,
+		SourceSiteID:    backupTestSiteID,
+		TLSContactEmail: "ops@agency.dev",
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindCreateStaging),
+		ServerID: backupTestServerID,
+		Payload:  payload,
+	})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected staging creation to fail")
+	}
+
+	if len(fakeRunner.requests) != 1 {
+		t.Fatalf("runner requests = %d, want 1", len(fakeRunner.requests))
+	}
+	req := fakeRunner.requests[0]
+	if req.PlaybookPath != filepath.Join("playbooks", playbookSiteStaging) {
+		t.Fatalf("playbook = %q, want staging playbook", req.PlaybookPath)
+	}
+	vars := req.ExtraVars
+	if vars["site_id"] != stagingTestSiteID || vars["source_site_id"] != backupTestSiteID || vars["source_url"] != "https://agency.example.test" ||
+		vars["hostname"] != "staging-agency.203-0-113-10.sslip.io" || vars["basic_auth_username"] != "pressluft" || vars["basic_auth_password"] != "staging-secret" {
+		t.Fatalf("staging extra vars = %v", vars)
+	}
+	if !strings.HasPrefix(vars["db_name"], "pl_stg_") || vars["db_password"] == "" {
+		t.Fatalf("staging database vars = %q/%q, want generated credentials", vars["db_name"], vars["db_password"])
+	}
+
+	stored := mustGetExecutorJob(t, jobStore, job.ID)
+	if stored.Status != orchestrator.JobStatusFailed || !strings.Contains(stored.LastError, "rsync failed") {
+		t.Fatalf("job = %+v, want failed with clone error", stored)
+	}
+	if got := sites.sites[stagingTestSiteID].DeploymentState; got != server.SiteDeploymentStateFailed {
+		t.Fatalf("staging deployment state = %q, want failed", got)
+	}
+	if got := sites.sites[backupTestSiteID].DeploymentState; got != server.SiteDeploymentStateReady {
+		t.Fatalf("source deployment state = %q, want it untouched", got)
+	}
+	if got := serverStore.servers[backupTestServerID].Status; got != platform.ServerStatusReady {
+		t.Fatalf("server status = %q, want ready after a site-scoped failure", got)
+	}
+}
+
+type fakeSitesByID struct {
+	sites map[string]*server.StoredSite
+}
+
+func (s *fakeSitesByID) GetByID(_ context.Context, id string) (*server.StoredSite, error) {
+	site, ok := s.sites[id]
+	if !ok {
+		return nil, errors.New("site not found")
+	}
+	copy := *site
+	return &copy, nil
+}
+
+func (s *fakeSitesByID) UpdateDeployment(_ context.Context, siteID, deploymentState, deploymentStatus, _, _ string) error {
+	s.sites[siteID].DeploymentState = deploymentState
+	s.sites[siteID].DeploymentStatus = deploymentStatus
+	return nil
+}
+
+func (s *fakeSitesByID) UpdateRuntimeHealth(_ context.Context, siteID, runtimeHealthState, runtimeHealthStatus, _ string) error {
+	s.sites[siteID].RuntimeHealthState = runtimeHealthState
+	s.sites[siteID].RuntimeHealthStatus = runtimeHealthStatus
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,9 +20,6 @@ import (
 	"pressluft/internal/shared/security"
 
 	_ "modernc.org/sqlite"
-
-	// Register provider implementations for tests that reference provider types.
-	_ "pressluft/internal/infra/provider/hetzner"
 )
 
 var executorTestDB *sql.DB
@@ -98,15 +96,18 @@ func TestExecutorDeleteServerSuccessMarksDeleted(t *testing.T) {
 		"00000000-0000-7000-8000-000000000001": {ID: "00000000-0000-7000-8000-000000000001", ProviderID: "00000000-0000-7000-8000-000000000011", Name: "delete-me", Status: platform.ServerStatusDeleting},
 	}}
 	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
-	runner := &fakeRunner{}
-	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, runner, ExecutorConfig{
-		PlaybookBasePath: "playbooks",
+	lifecycle := &fakeLifecycle{}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{
+		LifecycleLookup: lifecycle.lookup,
 	}, logger)
 
 	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindDeleteServer), ServerID: "00000000-0000-7000-8000-000000000001"})
 	if err := executor.Execute(context.Background(), &job); err != nil {
 		t.Fatalf("execute delete: %v", err)
 	}
+	if len(lifecycle.calls) != 1 || lifecycle.calls[0] != "delete delete-me" {
+		t.Fatalf("lifecycle calls = %q, want one delete", lifecycle.calls)
+	}
 
 	if got := serverStore.servers["00000000-0000-7000-8000-000000000001"].Status; got != platform.ServerStatusDeleted {
 		t.Fatalf("server status = %q, want %q", got, platform.ServerStatusDeleted)
@@ -124,9 +125,9 @@ func TestExecutorDeleteServerFailureLeavesRecoverableStatus(t *testing.T) {
 		"00000000-0000-7000-8000-000000000001": {ID: "00000000-0000-7000-8000-000000000001", ProviderID: "00000000-0000-7000-8000-000000000011", Name: "delete-me", Status: platform.ServerStatusDeleting},
 	}}
 	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
-	runner := &fakeRunner{failPlaybooks: map[string]error{filepath.Join("playbooks", "hetzner", "delete.yml"): errors.New("provider delete failed")}}
-	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, runner, ExecutorConfig{
-		PlaybookBasePath: "playbooks",
+	lifecycle := &fakeLifecycle{fail: map[string]error{"delete": errors.New("server is locked")}}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{
+		LifecycleLookup: lifecycle.lookup,
 	}, logger)
 
 	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindDeleteServer), ServerID: "00000000-0000-7000-8000-000000000001"})
@@ -154,10 +155,12 @@ func TestExecutorRebuildServerSuccessReconfiguresAndUpdatesImage(t *testing.T) {
 	}
 	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
 	runner := &fakeRunner{}
+	lifecycle := &fakeLifecycle{}
 	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, runner, ExecutorConfig{
 		PlaybookBasePath:      "playbooks",
 		ConfigurePlaybookPath: "configure.yml",
 		ControlPlaneURL:       "https://control.example.test",
+		LifecycleLookup:       lifecycle.lookup,
 	}, logger)
 
 	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
@@ -179,8 +182,11 @@ func TestExecutorRebuildServerSuccessReconfiguresAndUpdatesImage(t *testing.T) {
 	if server.Image != "ubuntu-24.04" {
 		t.Fatalf("server image = %q, want %q", server.Image, "ubuntu-24.04")
 	}
-	if len(runner.requests) != 1 {
-		t.Fatalf("runner request count = %d, want 1", len(runner.requests))
+	if len(lifecycle.calls) != 1 || lifecycle.calls[0] != "rebuild rebuild-me ubuntu-24.04" {
+		t.Fatalf("lifecycle calls = %q, want one rebuild", lifecycle.calls)
+	}
+	if len(runner.requests) != 0 {
+		t.Fatalf("runner request count = %d, want 0 before setup runs", len(runner.requests))
 	}
 	jobs, err := jobStore.ListAllJobs(context.Background())
 	if err != nil {
@@ -227,12 +233,14 @@ func TestExecutorRebuildServerRejectsUnavailableProfile(t *testing.T) {
 	}
 	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
 	runner := &fakeRunner{}
+	lifecycle := &fakeLifecycle{}
 	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, runner, ExecutorConfig{
 		PlaybookBasePath:      "playbooks",
 		ConfigurePlaybookPath: "configure.yml",
 		ControlPlaneURL:       "https://control.example.test",
 		ExecutionMode:         platform.ExecutionModeProductionBootstrap,
 		RegistrationStore:     fakeRegistrationStore{},
+		LifecycleLookup:       lifecycle.lookup,
 	}, logger)
 
 	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
@@ -247,8 +255,8 @@ func TestExecutorRebuildServerRejectsUnavailableProfile(t *testing.T) {
 	if got := serverStore.servers["00000000-0000-7000-8000-000000000001"].Status; got != platform.ServerStatusFailed {
 		t.Fatalf("server status = %q, want %q", got, platform.ServerStatusFailed)
 	}
-	if len(runner.requests) != 0 {
-		t.Fatalf("runner request count = %d, want 0", len(runner.requests))
+	if len(runner.requests) != 0 || len(lifecycle.calls) != 0 {
+		t.Fatalf("runner requests = %d, lifecycle calls = %q, want none", len(runner.requests), lifecycle.calls)
 	}
 }
 
@@ -259,9 +267,9 @@ func TestExecutorResizeServerFailureMarksFailed(t *testing.T) {
 		"00000000-0000-7000-8000-000000000001": {ID: "00000000-0000-7000-8000-000000000001", ProviderID: "00000000-0000-7000-8000-000000000011", Name: "resize-me", ServerType: "cx22", Status: platform.ServerStatusResizing},
 	}}
 	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
-	runner := &fakeRunner{failPlaybooks: map[string]error{filepath.Join("playbooks", "hetzner", "resize.yml"): errors.New("provider resize failed")}}
-	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, runner, ExecutorConfig{
-		PlaybookBasePath: "playbooks",
+	lifecycle := &fakeLifecycle{fail: map[string]error{"resize": errors.New("change_server_type action 7 failed: server type not available")}}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{
+		LifecycleLookup: lifecycle.lookup,
 	}, logger)
 
 	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
@@ -282,6 +290,43 @@ func TestExecutorResizeServerFailureMarksFailed(t *testing.T) {
 	}
 }
 
+func TestExecutorUpdateFirewallsTransientFailureSchedulesRetry(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := &fakeServerStore{servers: map[string]*server.StoredServer{
+		"00000000-0000-7000-8000-000000000001": {ID: "00000000-0000-7000-8000-000000000001", ProviderID: "00000000-0000-7000-8000-000000000011", Name: "fw-me", Status: platform.ServerStatusReady},
+	}}
+	providerStore := &fakeProviderStore{provider: &provider.StoredProvider{ID: "00000000-0000-7000-8000-000000000011", Type: "hetzner", APIToken: "token"}}
+	lifecycle := &fakeLifecycle{fail: map[string]error{"firewalls": errors.New("hcloud: service unavailable (service_error)")}}
+	executor := NewExecutor(jobStore, serverStore, providerStore, nil, nil, nil, &fakeRunner{}, ExecutorConfig{
+		LifecycleLookup: lifecycle.lookup,
+	}, testLogger())
+
+	job := mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindUpdateFirewalls),
+		ServerID: "00000000-0000-7000-8000-000000000001",
+		Payload:  `{"firewalls":["web"]}`,
+	})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected the attempt to fail")
+	}
+
+	updated := mustGetExecutorJob(t, jobStore, job.ID)
+	if updated.Status != orchestrator.JobStatusQueued || updated.RetryCount != 1 || updated.NextAttemptAt == "" {
+		t.Fatalf("job = %+v, want a queued retry with a next attempt time", updated)
+	}
+	if got := serverStore.servers["00000000-0000-7000-8000-000000000001"].Status; got != platform.ServerStatusReady {
+		t.Fatalf("server status = %q, want %q while a retry is pending", got, platform.ServerStatusReady)
+	}
+	events, err := jobStore.ListAllEvents(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	last := events[len(events)-1]
+	if last.EventType != orchestrator.JobEventTypeRetrying || last.Attempt != 1 {
+		t.Fatalf("last event = %+v, want a retry event on attempt 1", last)
+	}
+}
+
 func TestExecutorConfigureServerFailureMarksSetupDegraded(t *testing.T) {
 	jobStore := mustOpenExecutorJobStore(t)
 	logger := testLogger()
@@ -365,6 +410,8 @@ func (s *fakeServerStore) UpdateSetupState(_ context.Context, id string, setupSt
 func (s *fakeServerStore) UpdateProvisioning(_ context.Context, id string, providerServerID, actionID, actionStatus string, status platform.ServerStatus, ipv4, ipv6 string) error {
 	server := s.servers[id]
 	server.ProviderServerID = providerServerID
+	server.ActionID = actionID
+	server.ActionStatus = actionStatus
 	server.Status = status
 	server.IPv4 = ipv4
 	server.IPv6 = ipv6
@@ -381,6 +428,11 @@ func (s *fakeServerStore) UpdateImage(_ context.Context, id string, image string
 	return nil
 }
 
+func (s *fakeServerStore) UpdateSSHHostKey(_ context.Context, id string, fingerprint string) error {
+	s.servers[id].SSHHostKeyFingerprint = fingerprint
+	return nil
+}
+
 func (s *fakeServerStore) GetKey(_ context.Context, serverID string) (*server.StoredServerKey, error) {
 	key, ok := s.keys[serverID]
 	if !ok {
@@ -415,6 +467,9 @@ func (s *fakeProviderStore) GetByID(context.Context, string) (*provider.StoredPr
 type fakeRunner struct {
 	requests      []runner.Request
 	failPlaybooks map[string]error
+	// onRun runs after the default artifact is written so a test can
+	// replace it or perform the playbook's side effects.
+	onRun func(runner.Request) error
 }
 
 func (r *fakeRunner) Name() string { return "fake" }
@@ -426,12 +481,67 @@ func (r *fakeRunner) Run(_ context.Context, req runner.Request, _ runner.EventSi
 			return err
 		}
 	}
+	if r.onRun != nil {
+		if err := r.onRun(req); err != nil {
+			return err
+		}
+	}
 	if err, ok := r.failPlaybooks[req.PlaybookPath]; ok {
 		return err
 	}
 	return nil
 }
 
+// fakeLifecycle records lifecycle calls as "<operation> <server> [args]",
+// reports actions to every call and fails the operations listed in fail.
+type fakeLifecycle struct {
+	provider.ServerLifecycle
+	calls   []string
+	actions []provider.Action
+	fail    map[string]error
+}
+
+func (l *fakeLifecycle) lookup(string) (provider.ServerLifecycle, bool) { return l, true }
+
+func (l *fakeLifecycle) call(report provider.ActionReporter, operation string, args ...string) error {
+	l.calls = append(l.calls, strings.Join(append([]string{operation}, args...), " "))
+	for _, action := range l.actions {
+		report(action)
+	}
+	return l.fail[operation]
+}
+
+func (l *fakeLifecycle) CreateServer(_ context.Context, _ string, spec provider.ServerSpec, report provider.ActionReporter) (*provider.CreatedServer, error) {
+	if err := l.call(report, "create", spec.Name); err != nil {
+		return nil, err
+	}
+	return &provider.CreatedServer{ID: "123", IPv4: "203.0.113.10", IPv6: "2001:db8::10", ActionID: "456"}, nil
+}
+
+func (l *fakeLifecycle) DeleteServer(_ context.Context, _ string, ref provider.ServerRef, report provider.ActionReporter) error {
+	return l.call(report, "delete", ref.String())
+}
+
+func (l *fakeLifecycle) RebuildServer(_ context.Context, _ string, ref provider.ServerRef, image string, report provider.ActionReporter) error {
+	return l.call(report, "rebuild", ref.String(), image)
+}
+
+func (l *fakeLifecycle) ResizeServer(_ context.Context, _ string, ref provider.ServerRef, serverType string, _ bool, report provider.ActionReporter) error {
+	return l.call(report, "resize", ref.String(), serverType)
+}
+
+func (l *fakeLifecycle) ApplyFirewalls(_ context.Context, _ string, ref provider.ServerRef, firewalls []string, report provider.ActionReporter) error {
+	return l.call(report, "firewalls", append([]string{ref.String()}, firewalls...)...)
+}
+
+func (l *fakeLifecycle) AttachVolume(_ context.Context, _ string, ref provider.ServerRef, spec provider.VolumeSpec, report provider.ActionReporter) error {
+	return l.call(report, "attach_volume", ref.String(), spec.Name)
+}
+
+func (l *fakeLifecycle) DeleteVolume(_ context.Context, _ string, ref provider.ServerRef, name string, report provider.ActionReporter) error {
+	return l.call(report, "delete_volume", ref.String(), name)
+}
+
 type fakeRegistrationStore struct{}
 
 func (fakeRegistrationStore) Create(string, time.Duration) (string, error) {
@@ -448,10 +558,13 @@ func mustOpenExecutorJobStore(t *testing.T) *orchestrator.Store {
 	executorTestDB = db
 	if _, err := db.Exec(`
 		CREATE TABLE servers (
-			id TEXT PRIMARY KEY
+			id          TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			provider_id TEXT
 		);
 		CREATE TABLE jobs (
 			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id    TEXT,
 			kind         TEXT    NOT NULL,
 			status       TEXT    NOT NULL,
@@ -463,6 +576,8 @@ func mustOpenExecutorJobStore(t *testing.T) *orchestrator.Store {
 			finished_at  TEXT,
 			timeout_at   TEXT,
 			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
 			created_at   TEXT    NOT NULL,
 			updated_at   TEXT    NOT NULL
 		);
@@ -470,6 +585,7 @@ func mustOpenExecutorJobStore(t *testing.T) *orchestrator.Store {
 			id         TEXT PRIMARY KEY,
 			job_id     TEXT    NOT NULL,
 			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
 			event_type TEXT    NOT NULL,
 			level      TEXT    NOT NULL,
 			step_key   TEXT,
@@ -478,6 +594,45 @@ func mustOpenExecutorJobStore(t *testing.T) *orchestrator.Store {
 			payload    TEXT,
 			created_at TEXT    NOT NULL
 		);
+		CREATE TABLE backup_targets (
+			id                   TEXT PRIMARY KEY,
+			workspace_id         TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			name                 TEXT    NOT NULL,
+			endpoint             TEXT    NOT NULL,
+			region               TEXT    NOT NULL,
+			bucket               TEXT    NOT NULL,
+			path_prefix          TEXT    NOT NULL DEFAULT '',
+			path_style           INTEGER NOT NULL DEFAULT 1,
+			access_key_id        TEXT    NOT NULL,
+			secret_key_encrypted TEXT    NOT NULL,
+			secret_key_key_id    TEXT    NOT NULL,
+			secret_key_version   INTEGER NOT NULL DEFAULT 0,
+			retention_days       INTEGER NOT NULL DEFAULT 30,
+			created_at           TEXT    NOT NULL,
+			updated_at           TEXT    NOT NULL
+		);
+		CREATE TABLE sites (
+			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001'
+		);
+		CREATE TABLE site_backups (
+			id              TEXT PRIMARY KEY,
+			site_id         TEXT    NOT NULL,
+			server_id       TEXT    NOT NULL,
+			target_id       TEXT    NOT NULL,
+			job_id          TEXT,
+			status          TEXT    NOT NULL DEFAULT 'pending',
+			object_key      TEXT    NOT NULL,
+			size_bytes      INTEGER NOT NULL DEFAULT 0,
+			checksum_sha256 TEXT,
+			retention_days  INTEGER NOT NULL,
+			expires_at      TEXT,
+			last_error      TEXT,
+			started_at      TEXT,
+			completed_at    TEXT,
+			created_at      TEXT    NOT NULL,
+			updated_at      TEXT    NOT NULL
+		);
 	`); err != nil {
 		t.Fatalf("create jobs schema: %v", err)
 	}
@@ -535,3 +690,16 @@ func mustCreateTestAgentBinary(t *testing.T) {
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
+
+func TestScheduledJobActivityPayloadLinksSchedule(t *testing.T) {
+	payload, err := orchestrator.WithScheduleID(`{"site_id":"00000000-0000-7000-8000-0000000000aa"}`, "00000000-0000-7000-8000-0000000000cc")
+	if err != nil {
+		t.Fatalf("tag payload: %v", err)
+	}
+	if got := scheduledJobActivityPayload(orchestrator.Job{Payload: payload}); got != `{"schedule_id":"00000000-0000-7000-8000-0000000000cc"}` {
+		t.Fatalf("scheduledJobActivityPayload() = %q", got)
+	}
+	if got := scheduledJobActivityPayload(orchestrator.Job{Payload: `{"site_id":"x"}`}); got != "" {
+		t.Fatalf("scheduledJobActivityPayload() for manual job = %q, want empty", got)
+	}
+}
@@ -0,0 +1,310 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/activity"
+	serverpkg "pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+	"pressluft/internal/platform"
+	"pressluft/internal/shared/security"
+)
+
+// Phases of update-site.yml, run in the same order as a restore: snapshot,
+// apply, then either rollback or cleanup depending on the comparison.
+const (
+	updatePhaseSnapshot = "snapshot"
+	updatePhaseApply    = "apply"
+	updatePhaseRollback = "rollback"
+	updatePhaseCleanup  = "cleanup"
+)
+
+// siteUpdateTarget bundles what every update phase needs to reach the site.
+type siteUpdateTarget struct {
+	server     *serverpkg.StoredServer
+	site       *serverpkg.StoredSite
+	hostname   string
+	privateKey string
+}
+
+func (e *Executor) executeUpdateSiteComponents(ctx context.Context, job *orchestrator.Job) error {
+	if strings.TrimSpace(job.ServerID) == "" {
+		return e.failJob(ctx, job, "server_id is required for site update job")
+	}
+	if e.siteStore == nil || e.domainStore == nil {
+		return e.failJob(ctx, job, "site stores not configured")
+	}
+	if e.healthProber == nil {
+		return e.failJob(ctx, job, "site health prober not configured")
+	}
+	if e.runner == nil {
+		return e.failJob(ctx, job, "ansible runner not configured")
+	}
+
+	if _, err := e.jobStore.TransitionJob(ctx, job.ID, orchestrator.TransitionInput{ToStatus: orchestrator.JobStatusRunning, CurrentStep: "validate"}); err != nil {
+		return fmt.Errorf("transition to running: %w", err)
+	}
+
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventJobStarted,
+		Category:           activity.CategoryJob,
+		Level:              activity.LevelInfo,
+		ResourceType:       activity.ResourceJob,
+		ResourceID:         job.ID,
+		ParentResourceType: activity.ResourceSite,
+		ParentResourceID:   e.siteIDForJob(*job),
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("%s started", orchestrator.JobKindLabel(job.Kind)),
+	})
+
+	payload, err := orchestrator.UnmarshalUpdateSiteComponentsPayload(job.Payload)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
+	}
+
+	e.emitStepStart(ctx, job.ID, "validate", "Validating site update inputs")
+	target, errMsg := e.resolveSiteUpdateTarget(ctx, job, payload)
+	if errMsg != "" {
+		return e.failJob(ctx, job, errMsg)
+	}
+	e.emitStepComplete(ctx, job.ID, "validate", "Site update request validated")
+
+	e.updateStep(ctx, job.ID, "snapshot")
+	e.emitStepStart(ctx, job.ID, "snapshot", "Recording current site state and taking pre-update snapshot")
+	baseline, err := e.probeUpdatedSite(ctx, target, payload.CheckPaths)
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("pre-update health check failed: %v", err))
+	}
+	if !baseline.Healthy {
+		return e.failJob(ctx, job, fmt.Sprintf("site must be healthy before updating: %s", baseline.Summary))
+	}
+	if err := e.runSiteUpdatePlaybook(ctx, job.ID, target, updatePhaseSnapshot, nil); err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("pre-update snapshot failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "snapshot", "Pre-update snapshot stored on the server")
+
+	selection := describeComponentSelection(payload)
+	e.updateStep(ctx, job.ID, "update")
+	e.emitStepStart(ctx, job.ID, "update", "Updating "+selection)
+	if err := e.runSiteUpdatePlaybook(ctx, job.ID, target, updatePhaseApply, map[string]string{
+		"update_core":    fmt.Sprintf("%t", payload.Core),
+		"update_plugins": strings.Join(payload.Plugins, ","),
+		"update_themes":  strings.Join(payload.Themes, ","),
+	}); err != nil {
+		return e.rollbackUpdate(ctx, job, target, fmt.Sprintf("update failed: %v", err))
+	}
+	e.emitStepComplete(ctx, job.ID, "update", "Updates applied")
+
+	e.updateStep(ctx, job.ID, "verify")
+	e.emitStepStart(ctx, job.ID, "verify", "Comparing site before and after the update")
+	after, err := e.probeUpdatedSite(ctx, target, payload.CheckPaths)
+	if err != nil {
+		return e.rollbackUpdate(ctx, job, target, fmt.Sprintf("post-update health check failed: %v", err))
+	}
+	if !after.Healthy {
+		return e.rollbackUpdate(ctx, job, target, fmt.Sprintf("updated site is unhealthy: %s", after.Summary))
+	}
+	if regressions := pageRegressions(baseline.Pages, after.Pages); len(regressions) > 0 {
+		return e.rollbackUpdate(ctx, job, target, "updated site changed unexpectedly: "+strings.Join(regressions, "; "))
+	}
+	healthState, healthMessage := serverpkg.RuntimeHealthFromAgentSnapshot(after)
+	_ = e.siteStore.UpdateRuntimeHealth(ctx, target.site.ID, healthState, healthMessage, time.Now().UTC().Format(time.RFC3339))
+	e.emitStepComplete(ctx, job.ID, "verify", fmt.Sprintf("Health checks passed and %d page(s) match the pre-update state", len(after.Pages)))
+
+	e.updateStep(ctx, job.ID, "finalize")
+	e.emitStepStart(ctx, job.ID, "finalize", "Removing pre-update snapshot and refreshing inventory")
+	if err := e.runSiteUpdatePlaybook(ctx, job.ID, target, updatePhaseCleanup, nil); err != nil {
+		// The update itself succeeded; a leftover snapshot only costs disk.
+		e.logger.Error("pre-update snapshot cleanup failed", "job_id", job.ID, "site_id", target.site.ID, "error", err)
+	}
+	e.refreshSiteInventory(ctx, target)
+	e.emitStepComplete(ctx, job.ID, "finalize", "Site update finalized")
+	e.emitActivity(ctx, activity.EmitInput{
+		EventType:          activity.EventSiteComponentsUpdated,
+		Category:           activity.CategorySite,
+		Level:              activity.LevelSuccess,
+		ResourceType:       activity.ResourceSite,
+		ResourceID:         target.site.ID,
+		ParentResourceType: activity.ResourceServer,
+		ParentResourceID:   target.server.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Site '%s' updated", target.site.Name),
+		Message:            "Updated " + selection + ".",
+	})
+	return e.completeJob(ctx, job, "finalize")
+}
+
+func (e *Executor) resolveSiteUpdateTarget(ctx context.Context, job *orchestrator.Job, payload orchestrator.UpdateSiteComponentsPayload) (siteUpdateTarget, string) {
+	site, err := e.siteStore.GetByID(ctx, payload.SiteID)
+	if err != nil {
+		return siteUpdateTarget{}, fmt.Sprintf("site not found: %v", err)
+	}
+	if site.ServerID != job.ServerID {
+		return siteUpdateTarget{}, "site does not belong to the job server"
+	}
+	if site.DeploymentState != serverpkg.SiteDeploymentStateReady {
+		return siteUpdateTarget{}, "site must be deployed before it can be updated"
+	}
+	primaryDomain, err := e.primaryDomainForSite(ctx, site.ID)
+	if err != nil {
+		return siteUpdateTarget{}, err.Error()
+	}
+	server, err := e.serverStore.GetByID(ctx, job.ServerID)
+	if err != nil {
+		return siteUpdateTarget{}, fmt.Sprintf("server not found: %v", err)
+	}
+	if server.Status != platform.ServerStatusReady {
+		return siteUpdateTarget{}, "server must be ready before updating a site"
+	}
+	storedKey, err := e.serverStore.GetKey(ctx, server.ID)
+	if err != nil {
+		return siteUpdateTarget{}, fmt.Sprintf("failed to read SSH key: %v", err)
+	}
+	if storedKey == nil {
+		return siteUpdateTarget{}, "missing SSH key for server"
+	}
+	decryptedKey, err := security.Decrypt(storedKey.PrivateKeyEncrypted)
+	if err != nil {
+		return siteUpdateTarget{}, fmt.Sprintf("failed to decrypt SSH key: %v", err)
+	}
+	return siteUpdateTarget{server: server, site: site, hostname: primaryDomain.Hostname, privateKey: string(decryptedKey)}, ""
+}
+
+// rollbackUpdate puts the pre-update snapshot back and fails the job, also
+// after a cancellation.
+func (e *Executor) rollbackUpdate(ctx context.Context, job *orchestrator.Job, target siteUpdateTarget, errMsg string) error {
+	rollbackCtx, cancel := rollbackContext(ctx)
+	defer cancel()
+	e.emitStepStart(rollbackCtx, job.ID, "rollback", "Rolling back to pre-update snapshot")
+	if err := e.runSiteUpdatePlaybook(rollbackCtx, job.ID, target, updatePhaseRollback, nil); err != nil {
+		errMsg = fmt.Sprintf("%s; rollback failed: %v", errMsg, err)
+		_ = e.siteStore.UpdateRuntimeHealth(rollbackCtx, target.site.ID, serverpkg.SiteRuntimeHealthStateIssue, errMsg, time.Now().UTC().Format(time.RFC3339))
+		return e.failJob(ctx, job, errMsg)
+	}
+	e.emitStepComplete(rollbackCtx, job.ID, "rollback", "Pre-update snapshot reapplied")
+	e.emitActivity(rollbackCtx, activity.EmitInput{
+		EventType:          activity.EventSiteUpdateRolledBack,
+		Category:           activity.CategorySite,
+		Level:              activity.LevelWarning,
+		ResourceType:       activity.ResourceSite,
+		ResourceID:         target.site.ID,
+		ParentResourceType: activity.ResourceServer,
+		ParentResourceID:   target.server.ID,
+		ActorType:          activity.ActorSystem,
+		Title:              fmt.Sprintf("Update of site '%s' rolled back", target.site.Name),
+		Message:            errMsg,
+	})
+	return e.failJob(ctx, job, errMsg+"; site rolled back to its pre-update snapshot")
+}
+
+func (e *Executor) probeUpdatedSite(ctx context.Context, target siteUpdateTarget, paths []string) (*agentcommand.SiteHealthSnapshot, error) {
+	return e.healthProber.SiteHealthSnapshot(ctx, target.server.ID, agentcommand.SiteHealthSnapshotParams{
+		SiteID:   target.site.ID,
+		Hostname: target.hostname,
+		SitePath: effectiveWordPressPath(*target.site),
+		Paths:    paths,
+	})
+}
+
+// refreshSiteInventory stores the site's current component versions. Failures
+// are logged only; the inventory is refreshed again on the next request.
+func (e *Executor) refreshSiteInventory(ctx context.Context, target siteUpdateTarget) {
+	if e.inventory == nil || e.componentStore == nil {
+		return
+	}
+	inventory, err := e.inventory.WPInventory(ctx, target.server.ID, agentcommand.WPInventoryParams{
+		SiteID:   target.site.ID,
+		SitePath: effectiveWordPressPath(*target.site),
+	})
+	if err != nil {
+		e.logger.Warn("site inventory refresh failed", "site_id", target.site.ID, "error", err)
+		return
+	}
+	if err := e.componentStore.ReplaceForSite(ctx, target.site.ID, serverpkg.SiteComponentInputs(inventory.Components), time.Now()); err != nil {
+		e.logger.Warn("site inventory persistence failed", "site_id", target.site.ID, "error", err)
+	}
+}
+
+func (e *Executor) runSiteUpdatePlaybook(ctx context.Context, jobID string, target siteUpdateTarget, phase string, vars map[string]string) error {
+	workspace, err := os.MkdirTemp("", "pressluft-site-update-")
+	if err != nil {
+		return fmt.Errorf("failed to create update workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+
+	inventoryPath, err := writeSiteInventory(workspace, target.server, target.privateKey)
+	if err != nil {
+		return err
+	}
+	extraVars := map[string]string{
+		"site_id":      target.site.ID,
+		"site_path":    effectiveWordPressPath(*target.site),
+		"update_id":    jobID,
+		"update_phase": phase,
+	}
+	for key, value := range vars {
+		extraVars[key] = value
+	}
+	request := runner.Request{
+		JobID:         jobID,
+		InventoryPath: inventoryPath,
+		PlaybookPath:  e.siteUpdatePlaybook(),
+		ExtraVars:     extraVars,
+	}
+	return e.runPlaybook(ctx, target.server, request)
+}
+
+// pageRegressions compares page probes taken before and after an update. A
+// page regresses when it starts failing, changes status class, changes its
+// title, or loses more than half of its size, which catches most white
+// screens and broken templates without a rendering engine.
+func pageRegressions(before, after []agentcommand.SitePageProbe) []string {
+	previous := make(map[string]agentcommand.SitePageProbe, len(before))
+	for _, page := range before {
+		previous[page.Path] = page
+	}
+	var out []string
+	for _, page := range after {
+		prev, ok := previous[page.Path]
+		if !ok || prev.StatusCode == 0 {
+			continue
+		}
+		switch {
+		case page.StatusCode == 0:
+			out = append(out, fmt.Sprintf("%s did not respond", page.Path))
+		case page.StatusCode/100 != prev.StatusCode/100:
+			out = append(out, fmt.Sprintf("%s returned %d (was %d)", page.Path, page.StatusCode, prev.StatusCode))
+		case page.Title != prev.Title:
+			out = append(out, fmt.Sprintf("%s title changed from %q to %q", page.Path, prev.Title, page.Title))
+		case page.Bytes*2 < prev.Bytes:
+			out = append(out, fmt.Sprintf("%s shrank from %d to %d bytes", page.Path, prev.Bytes, page.Bytes))
+		}
+	}
+	return out
+}
+
+func describeComponentSelection(payload orchestrator.UpdateSiteComponentsPayload) string {
+	var parts []string
+	if payload.Core {
+		parts = append(parts, "WordPress core")
+	}
+	for _, group := range []struct {
+		label string
+		slugs []string
+	}{{"plugins", payload.Plugins}, {"themes", payload.Themes}} {
+		switch {
+		case len(group.slugs) == 0:
+		case group.slugs[0] == orchestrator.UpdateAllComponents:
+			parts = append(parts, "all "+group.label)
+		default:
+			parts = append(parts, group.label+" "+strings.Join(group.slugs, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
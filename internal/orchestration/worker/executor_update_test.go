@@ -0,0 +1,175 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"pressluft/internal/agent/agentcommand"
+	"pressluft/internal/controlplane/server"
+	"pressluft/internal/infra/runner"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+func TestExecutorUpdateSiteComponentsAppliesUpdatesAndRefreshesInventory(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustBackupTestServerStore(t)
+	var phases []string
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		phases = append(phases, req.ExtraVars["update_phase"])
+		if req.ExtraVars["update_phase"] == updatePhaseApply {
+			if req.ExtraVars["update_core"] != "true" || req.ExtraVars["update_plugins"] != "woocommerce,akismet" || req.ExtraVars["update_themes"] != "*" {
+				t.Errorf("apply extra vars = %v", req.ExtraVars)
+			}
+		}
+		return nil
+	}}
+	page := agentcommand.SitePageProbe{Path: "/", StatusCode: 200, Bytes: 4096, Title: "Agency Site"}
+	prober := &fakeSiteHealthSequence{snapshots: []*agentcommand.SiteHealthSnapshot{
+		{Healthy: true, Pages: []agentcommand.SitePageProbe{page}},
+		{Healthy: true, Summary: "WordPress runtime checks passed.", Pages: []agentcommand.SitePageProbe{page}},
+	}}
+	inventory := &fakeInventoryCollector{inventory: &agentcommand.WPInventory{Components: []agentcommand.WPComponent{
+		{Type: agentcommand.WPComponentCore, Name: "wordpress", Version: "6.8"},
+		{Type: agentcommand.WPComponentPlugin, Name: "woocommerce", Version: "9.1.0"},
+	}}}
+	components := &fakeComponentStore{}
+	siteStore := &fakeSiteStore{site: readyBackupTestSite()}
+	executor := NewExecutor(jobStore, serverStore, nil, siteStore, restoreTestDomainStore(), nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath:   "playbooks",
+		SiteHealthProber:   prober,
+		InventoryCollector: inventory,
+		ComponentStore:     components,
+	}, testLogger())
+
+	job := mustClaimUpdateJob(t, jobStore, orchestrator.UpdateSiteComponentsPayload{
+		SiteID:  backupTestSiteID,
+		Core:    true,
+		Plugins: []string{"woocommerce", "akismet"},
+		Themes:  []string{orchestrator.UpdateAllComponents},
+	})
+	if err := executor.Execute(context.Background(), &job); err != nil {
+		t.Fatalf("execute update: %v", err)
+	}
+
+	if got := mustGetExecutorJob(t, jobStore, job.ID).Status; got != orchestrator.JobStatusSucceeded {
+		t.Fatalf("job status = %q, want succeeded", got)
+	}
+	if got := strings.Join(phases, ","); got != "snapshot,apply,cleanup" {
+		t.Fatalf("update phases = %q, want snapshot,apply,cleanup", got)
+	}
+	if len(prober.params) != 2 || len(prober.params[0].Paths) != 1 || prober.params[0].Paths[0] != "/" {
+		t.Fatalf("health probes = %+v, want two probes of /", prober.params)
+	}
+	if components.siteID != backupTestSiteID || len(components.components) != 2 || components.components[1].Name != "woocommerce" {
+		t.Fatalf("stored inventory = %s %+v", components.siteID, components.components)
+	}
+	if siteStore.site.RuntimeHealthState != server.SiteRuntimeHealthStateHealthy {
+		t.Fatalf("runtime health = %q, want healthy", siteStore.site.RuntimeHealthState)
+	}
+}
+
+func TestExecutorUpdateSiteComponentsRollsBackWhenPageChanges(t *testing.T) {
+	jobStore := mustOpenExecutorJobStore(t)
+	serverStore := mustBackupTestServerStore(t)
+	var phases []string
+	fakeRunner := &fakeRunner{onRun: func(req runner.Request) error {
+		phases = append(phases, req.ExtraVars["update_phase"])
+		return nil
+	}}
+	prober := &fakeSiteHealthSequence{snapshots: []*agentcommand.SiteHealthSnapshot{
+		{Healthy: true, Pages: []agentcommand.SitePageProbe{{Path: "/shop", StatusCode: 200, Bytes: 9000, Title: "Shop"}}},
+		{Healthy: true, Pages: []agentcommand.SitePageProbe{{Path: "/shop", StatusCode: 500, Bytes: 120, Title: "Critical error"}}},
+	}}
+	components := &fakeComponentStore{}
+	executor := NewExecutor(jobStore, serverStore, nil, &fakeSiteStore{site: readyBackupTestSite()}, restoreTestDomainStore(), nil, fakeRunner, ExecutorConfig{
+		PlaybookBasePath:   "playbooks",
+		SiteHealthProber:   prober,
+		InventoryCollector: &fakeInventoryCollector{inventory: &agentcommand.WPInventory{}},
+		ComponentStore:     components,
+	}, testLogger())
+
+	job := mustClaimUpdateJob(t, jobStore, orchestrator.UpdateSiteComponentsPayload{
+		SiteID:     backupTestSiteID,
+		Plugins:    []string{orchestrator.UpdateAllComponents},
+		CheckPaths: []string{"/shop"},
+	})
+	if err := executor.Execute(context.Background(), &job); err == nil {
+		t.Fatal("expected update to fail")
+	}
+
+	stored := mustGetExecutorJob(t, jobStore, job.ID)
+	if stored.Status != orchestrator.JobStatusFailed || !strings.Contains(stored.LastError, "/shop returned 500 (was 200)") || !strings.Contains(stored.LastError, "rolled back") {
+		t.Fatalf("job = %+v, want failed with page regression and rollback note", stored)
+	}
+	if got := strings.Join(phases, ","); got != "snapshot,apply,rollback" {
+		t.Fatalf("update phases = %q, want snapshot,apply,rollback", got)
+	}
+	if components.siteID != "" {
+		t.Fatalf("inventory stored after rollback for %q", components.siteID)
+	}
+}
+
+func TestPageRegressionsIgnoresMinorChanges(t *testing.T) {
+	before := []agentcommand.SitePageProbe{
+		{Path: "/", StatusCode: 200, Bytes: 1000, Title: "Home"},
+		{Path: "/blog", StatusCode: 200, Bytes: 2000, Title: "Blog"},
+		{Path: "/down", StatusCode: 0},
+	}
+	after := []agentcommand.SitePageProbe{
+		{Path: "/", StatusCode: 200, Bytes: 900, Title: "Home"},
+		{Path: "/blog", StatusCode: 200, Bytes: 800, Title: "Blog"},
+		{Path: "/down", StatusCode: 502},
+	}
+	got := pageRegressions(before, after)
+	if len(got) != 1 || got[0] != "/blog shrank from 2000 to 800 bytes" {
+		t.Fatalf("regressions = %v, want only the shrunken /blog page", got)
+	}
+}
+
+type fakeSiteHealthSequence struct {
+	snapshots []*agentcommand.SiteHealthSnapshot
+	params    []agentcommand.SiteHealthSnapshotParams
+}
+
+func (p *fakeSiteHealthSequence) SiteHealthSnapshot(_ context.Context, _ string, params agentcommand.SiteHealthSnapshotParams) (*agentcommand.SiteHealthSnapshot, error) {
+	p.params = append(p.params, params)
+	snapshot := p.snapshots[0]
+	if len(p.snapshots) > 1 {
+		p.snapshots = p.snapshots[1:]
+	}
+	return snapshot, nil
+}
+
+type fakeInventoryCollector struct {
+	inventory *agentcommand.WPInventory
+}
+
+func (c *fakeInventoryCollector) WPInventory(_ context.Context, _ string, _ agentcommand.WPInventoryParams) (*agentcommand.WPInventory, error) {
+	return c.inventory, nil
+}
+
+type fakeComponentStore struct {
+	siteID     string
+	components []server.SiteComponentInput
+}
+
+func (s *fakeComponentStore) ReplaceForSite(_ context.Context, siteID string, components []server.SiteComponentInput, _ time.Time) error {
+	s.siteID = siteID
+	s.components = components
+	return nil
+}
+
+func mustClaimUpdateJob(t *testing.T, jobStore *orchestrator.Store, payload orchestrator.UpdateSiteComponentsPayload) orchestrator.Job {
+	t.Helper()
+	raw, err := orchestrator.MarshalUpdateSiteComponentsPayload(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	return mustClaimExecutorJob(t, jobStore, orchestrator.CreateJobInput{
+		Kind:     string(orchestrator.JobKindUpdateSiteComponents),
+		ServerID: backupTestServerID,
+		Payload:  raw,
+	})
+}
@@ -3,7 +3,6 @@ package worker
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"pressluft/internal/controlplane/activity"
@@ -47,17 +46,9 @@ func (e *Executor) executeManageVolume(ctx context.Context, job *orchestrator.Jo
 	if err != nil {
 		return e.failJob(ctx, job, fmt.Sprintf("provider not found: %v", err))
 	}
-	if !provider.SupportsServerMutationWorkflow(storedProvider.Type) {
-		return e.failJob(ctx, job, fmt.Sprintf("provider %s does not support ansible workflows", storedProvider.Type))
-	}
-	if strings.TrimSpace(storedProvider.APIToken) == "" {
-		return e.failJob(ctx, job, "provider token is required")
-	}
-	if e.runner == nil {
-		return e.failJob(ctx, job, "ansible runner not configured")
-	}
-	if strings.TrimSpace(e.playbookBasePath) == "" {
-		return e.failJob(ctx, job, "playbook base path not configured")
+	lifecycle, err := e.serverLifecycle(storedProvider)
+	if err != nil {
+		return e.failJob(ctx, job, err.Error())
 	}
 
 	payload, err := orchestrator.UnmarshalManageVolumePayload(job.Payload)
@@ -85,25 +76,24 @@ func (e *Executor) executeManageVolume(ctx context.Context, job *orchestrator.Jo
 	e.emitStepComplete(ctx, job.ID, "validate", "Volume request validated")
 
 	e.updateStep(ctx, job.ID, "manage_volume")
-	e.emitStepStart(ctx, job.ID, "manage_volume", "Running volume playbook")
-	automountValue := "false"
-	if payload.Automount != nil {
-		automountValue = strconv.FormatBool(*payload.Automount)
+	report := e.providerActionReporter(ctx, job.ID, "manage_volume", storedProvider.Type)
+	if state == "present" {
+		e.emitStepStart(ctx, job.ID, "manage_volume", fmt.Sprintf("Attaching volume %s", volumeName))
+		err = lifecycle.AttachVolume(ctx, storedProvider.APIToken, providerRef(server), provider.VolumeSpec{
+			Name:      volumeName,
+			SizeGB:    payload.SizeGB,
+			Location:  location,
+			Automount: *payload.Automount,
+		}, report)
+	} else {
+		e.emitStepStart(ctx, job.ID, "manage_volume", fmt.Sprintf("Deleting volume %s", volumeName))
+		err = lifecycle.DeleteVolume(ctx, storedProvider.APIToken, providerRef(server), volumeName, report)
 	}
-
-	if err := e.runLocalPlaybook(ctx, job.ID, e.providerPlaybook(storedProvider.Type, playbookVolume), map[string]string{
-		"api_token":   storedProvider.APIToken,
-		"server_name": server.Name,
-		"volume_name": volumeName,
-		"size_gb":     strconv.Itoa(payload.SizeGB),
-		"location":    location,
-		"state":       state,
-		"automount":   automountValue,
-	}); err != nil {
-		return e.failJob(ctx, job, fmt.Sprintf("ansible volume management failed: %v", err))
+	if err != nil {
+		return e.failJob(ctx, job, fmt.Sprintf("provider volume management failed: %v", err))
 	}
 
-	e.emitStepComplete(ctx, job.ID, "manage_volume", "Volume playbook completed")
+	e.emitStepComplete(ctx, job.ID, "manage_volume", fmt.Sprintf("Volume %s is %s", volumeName, state))
 
 	e.updateStep(ctx, job.ID, "finalize")
 	e.emitStepStart(ctx, job.ID, "finalize", "Finalizing volume workflow")
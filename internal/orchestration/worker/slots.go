@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"sort"
+	"sync"
+
+	"pressluft/internal/controlplane/apitypes"
+	"pressluft/internal/orchestration/orchestrator"
+)
+
+// slots tracks running jobs against the global, per-provider and per-server
+// limits. At the default per-server limit of one, two jobs never touch the
+// same machine at once; above it, destructive jobs still lock their server:
+// they start only on an idle server and nothing else starts beside them.
+type slots struct {
+	mu          sync.Mutex
+	maxJobs     int
+	perProvider int
+	perServer   int
+	running     int
+	providers   map[string]int
+	servers     map[string]int
+	// exclusive holds the servers running a destructive job.
+	exclusive map[string]bool
+}
+
+func newSlots(maxJobs, perProvider, perServer int) *slots {
+	return &slots{
+		maxJobs:     maxJobs,
+		perProvider: perProvider,
+		perServer:   perServer,
+		providers:   make(map[string]int),
+		servers:     make(map[string]int),
+		exclusive:   make(map[string]bool),
+	}
+}
+
+func (s *slots) full() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running >= s.maxJobs
+}
+
+// filter lists the servers and providers that cannot take another job, and
+// the servers that can take one but not a destructive one.
+func (s *slots) filter() orchestrator.ClaimFilter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var filter orchestrator.ClaimFilter
+	for id, n := range s.servers {
+		if n >= s.perServer || s.exclusive[id] {
+			filter.BusyServerIDs = append(filter.BusyServerIDs, id)
+		} else {
+			filter.OccupiedServerIDs = append(filter.OccupiedServerIDs, id)
+		}
+	}
+	if s.perProvider > 0 {
+		for id, n := range s.providers {
+			if n >= s.perProvider {
+				filter.BusyProviderIDs = append(filter.BusyProviderIDs, id)
+			}
+		}
+	}
+	sort.Strings(filter.BusyServerIDs)
+	sort.Strings(filter.OccupiedServerIDs)
+	sort.Strings(filter.BusyProviderIDs)
+	return filter
+}
+
+func (s *slots) acquire(job orchestrator.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running++
+	if job.ServerID != "" {
+		s.servers[job.ServerID]++
+		if spec, ok := orchestrator.JobKindPolicy(job.Kind); ok && spec.Destructive {
+			s.exclusive[job.ServerID] = true
+		}
+	}
+	if job.ProviderID != "" {
+		s.providers[job.ProviderID]++
+	}
+}
+
+func (s *slots) release(job orchestrator.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	decrement(s.servers, job.ServerID)
+	if spec, ok := orchestrator.JobKindPolicy(job.Kind); ok && spec.Destructive {
+		delete(s.exclusive, job.ServerID)
+	}
+	decrement(s.providers, job.ProviderID)
+}
+
+func (s *slots) usage() apitypes.WorkerSlotUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := apitypes.WorkerSlotUsage{
+		Running:            s.running,
+		MaxJobs:            s.maxJobs,
+		MaxJobsPerProvider: s.perProvider,
+		MaxJobsPerServer:   s.perServer,
+		Providers:          make(map[string]int, len(s.providers)),
+		Servers:            make(map[string]int, len(s.servers)),
+	}
+	for id, n := range s.providers {
+		out.Providers[id] = n
+	}
+	for id, n := range s.servers {
+		out.Servers[id] = n
+	}
+	return out
+}
+
+func decrement(counts map[string]int, key string) {
+	if key == "" {
+		return
+	}
+	if counts[key] <= 1 {
+		delete(counts, key)
+		return
+	}
+	counts[key]--
+}
@@ -2,9 +2,12 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
 	"time"
 
+	"pressluft/internal/controlplane/apitypes"
 	"pressluft/internal/orchestration/orchestrator"
 )
 
@@ -12,12 +15,23 @@ import (
 type Config struct {
 	PollInterval time.Duration
 	JobTimeouts  map[string]time.Duration
+	// MaxJobs caps the jobs running at once.
+	MaxJobs int
+	// MaxJobsPerProvider caps concurrent jobs against servers of one
+	// provider account. Zero leaves providers bounded by MaxJobs only.
+	MaxJobsPerProvider int
+	// MaxJobsPerServer caps concurrent jobs on one server. Destructive jobs
+	// always run alone on theirs.
+	MaxJobsPerServer int
 }
 
 // DefaultConfig returns sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		PollInterval: 2 * time.Second,
+		PollInterval:       2 * time.Second,
+		MaxJobs:            4,
+		MaxJobsPerProvider: 2,
+		MaxJobsPerServer:   1,
 	}
 }
 
@@ -27,28 +41,109 @@ type Worker struct {
 	executor jobExecutor
 	config   Config
 	logger   *slog.Logger
+
+	slots *slots
+	jobs  sync.WaitGroup
+
+	mu       sync.Mutex
+	running  map[string]context.CancelCauseFunc
+	observer JobObserver
 }
 
+// JobObserver is told how long each job this worker ran took, by kind and
+// by the status the job was left in.
+type JobObserver interface {
+	ObserveJob(kind, status string, duration time.Duration)
+}
+
+// ErrJobCancelled is the context cause of a job cancelled through CancelJob.
+var ErrJobCancelled = errors.New("job cancelled")
+
 type jobExecutor interface {
 	Execute(ctx context.Context, job *orchestrator.Job) error
 }
 
 // New creates a worker with the given dependencies.
 func New(jobStore *orchestrator.Store, executor jobExecutor, logger *slog.Logger, config Config) *Worker {
+	defaults := DefaultConfig()
 	if config.PollInterval <= 0 {
-		config.PollInterval = DefaultConfig().PollInterval
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.MaxJobs <= 0 {
+		config.MaxJobs = defaults.MaxJobs
+	}
+	if config.MaxJobsPerProvider < 0 {
+		config.MaxJobsPerProvider = 0
+	}
+	if config.MaxJobsPerServer <= 0 {
+		config.MaxJobsPerServer = defaults.MaxJobsPerServer
 	}
 	return &Worker{
 		jobStore: jobStore,
 		executor: executor,
 		config:   config,
 		logger:   logger,
+		slots:    newSlots(config.MaxJobs, config.MaxJobsPerProvider, config.MaxJobsPerServer),
+		running:  make(map[string]context.CancelCauseFunc),
+	}
+}
+
+// SlotUsage reports how many of the worker's concurrency slots are taken.
+func (w *Worker) SlotUsage() apitypes.WorkerSlotUsage {
+	return w.slots.usage()
+}
+
+// SetJobObserver registers who hears about finished job executions.
+func (w *Worker) SetJobObserver(observer JobObserver) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.observer = observer
+}
+
+// observe reports a job execution that started at started to the observer,
+// labelled with the status the job is stored with now.
+func (w *Worker) observe(ctx context.Context, job *orchestrator.Job, started time.Time) {
+	w.mu.Lock()
+	observer := w.observer
+	w.mu.Unlock()
+	if observer == nil {
+		return
+	}
+	duration := time.Since(started)
+	status := "unknown"
+	if current, err := w.jobStore.GetJob(context.WithoutCancel(ctx), job.ID); err == nil {
+		status = string(current.Status)
+	}
+	observer.ObserveJob(job.Kind, status, duration)
+}
+
+// CancelJob cancels the context of a job this worker is executing, which
+// stops its runner. It reports false when the job is not running here.
+func (w *Worker) CancelJob(jobID string) bool {
+	w.mu.Lock()
+	cancel, ok := w.running[jobID]
+	w.mu.Unlock()
+	if ok {
+		cancel(ErrJobCancelled)
 	}
+	return ok
+}
+
+func (w *Worker) track(jobID string, cancel context.CancelCauseFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running[jobID] = cancel
+}
+
+func (w *Worker) untrack(jobID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.running, jobID)
 }
 
 // Run starts the polling loop. It blocks until ctx is cancelled.
 func (w *Worker) Run(ctx context.Context) {
-	w.logger.Info("worker started", "poll_interval", w.config.PollInterval)
+	w.logger.Info("worker started", "poll_interval", w.config.PollInterval, "max_jobs", w.config.MaxJobs, "max_jobs_per_provider", w.config.MaxJobsPerProvider, "max_jobs_per_server", w.config.MaxJobsPerServer)
 
 	// Recover any jobs that were interrupted by a previous shutdown.
 	if recovered, err := w.jobStore.RecoverStuckJobs(ctx); err != nil {
@@ -64,6 +159,7 @@ func (w *Worker) Run(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			w.logger.Info("worker shutting down")
+			w.jobs.Wait()
 			return
 		case <-ticker.C:
 			w.poll(ctx)
@@ -71,37 +167,72 @@ func (w *Worker) Run(ctx context.Context) {
 	}
 }
 
+// poll claims queued jobs until every free slot is taken or nothing
+// claimable is left, and starts each one in its own goroutine. Jobs whose
+// server or provider is at its limit stay queued for a later poll.
 func (w *Worker) poll(ctx context.Context) {
-	job, err := w.jobStore.ClaimNextJob(ctx)
-	if err != nil {
-		w.logger.Error("job claim failed", "error", err)
-		return
-	}
-	if job == nil {
-		// No jobs available
-		return
+	for !w.slots.full() {
+		job, err := w.jobStore.ClaimNextJobWithin(ctx, w.slots.filter())
+		if err != nil {
+			w.logger.Error("job claim failed", "error", err)
+			return
+		}
+		if job == nil {
+			// No jobs available
+			return
+		}
+
+		w.logger.Info("job claimed", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID, "provider_id", job.ProviderID)
+		w.slots.acquire(*job)
+		w.jobs.Add(1)
+		go func() {
+			defer w.jobs.Done()
+			defer w.slots.release(*job)
+			w.execute(ctx, job)
+		}()
 	}
+}
 
-	w.logger.Info("job claimed", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID)
+// wait blocks until every job started by poll has returned.
+func (w *Worker) wait() {
+	w.jobs.Wait()
+}
 
+func (w *Worker) execute(ctx context.Context, job *orchestrator.Job) {
 	policy, ok := orchestrator.JobKindPolicy(job.Kind)
 	if !ok {
 		w.logger.Error("claimed job kind unsupported", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID)
 		return
 	}
 
-	jobCtx := ctx
+	defer w.observe(ctx, job, time.Now())
+
+	jobCtx, cancelJob := context.WithCancelCause(ctx)
+	defer cancelJob(nil)
+	w.track(job.ID, cancelJob)
+	defer w.untrack(job.ID)
+	if current, err := w.jobStore.GetJob(ctx, job.ID); err == nil && current.Status == orchestrator.JobStatusCancelled {
+		// Cancelled between the claim and registration above.
+		w.logger.Info("job cancelled before execution", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID)
+		return
+	}
+
 	cancel := func() {}
 	timeout := policy.Timeout
 	if override, ok := w.config.JobTimeouts[job.Kind]; ok && override > 0 {
 		timeout = override
 	}
 	if timeout > 0 {
-		jobCtx, cancel = context.WithTimeout(ctx, timeout)
+		jobCtx, cancel = context.WithTimeout(jobCtx, timeout)
 	}
 	defer cancel()
 
 	if err := w.executor.Execute(jobCtx, job); err != nil {
+		if errors.Is(context.Cause(jobCtx), ErrJobCancelled) {
+			// Whoever cancelled the job records the cancellation.
+			w.logger.Info("job execution cancelled", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID)
+			return
+		}
 		w.logger.Error("job execution failed", "job_id", job.ID, "job_kind", job.Kind, "server_id", job.ServerID, "error", err)
 		if jobCtx.Err() == context.DeadlineExceeded {
 			message := "job timed out before completion"
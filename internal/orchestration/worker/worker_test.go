@@ -66,6 +66,7 @@ func TestWorkerMarksTimedOutJobFailed(t *testing.T) {
 	defer cancel()
 
 	w.poll(ctx)
+	w.wait()
 
 	updated, err := store.GetJob(context.Background(), job.ID)
 	if err != nil {
@@ -87,15 +88,193 @@ func TestWorkerMarksTimedOutJobFailed(t *testing.T) {
 	}
 }
 
+func TestWorkerCancelJobStopsRunningExecution(t *testing.T) {
+	store := newWorkerJobStore(t)
+	job, err := store.CreateJob(context.Background(), orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindRestartService), ServerID: "00000000-0000-7000-8000-000000000003"})
+	if err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	executor := blockingExecutor{wait: 5 * time.Second, started: make(chan struct{})}
+	w := New(store, executor, testWorkerLogger(), Config{PollInterval: 5 * time.Millisecond})
+	if w.CancelJob(job.ID) {
+		t.Fatal("expected cancel before the job runs to report false")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.poll(context.Background())
+		w.wait()
+	}()
+	<-executor.started
+	if !w.CancelJob(job.ID) {
+		t.Fatal("expected cancel of the running job to report true")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected cancelled execution to return")
+	}
+
+	updated, err := store.GetJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	// The worker leaves recording the cancellation to its caller.
+	if updated.Status != orchestrator.JobStatusRunning {
+		t.Fatalf("status = %q, want the worker not to mark the cancelled job failed", updated.Status)
+	}
+	if w.CancelJob(job.ID) {
+		t.Fatal("expected finished job to be forgotten")
+	}
+}
+
+func TestWorkerPollRespectsProviderAndServerLimits(t *testing.T) {
+	store := newWorkerJobStore(t)
+	var jobIDs []string
+	for _, serverID := range []string{
+		"00000000-0000-7000-8000-000000000001",
+		"00000000-0000-7000-8000-000000000001",
+		"00000000-0000-7000-8000-000000000002",
+		"00000000-0000-7000-8000-000000000003",
+	} {
+		job, err := store.CreateJob(context.Background(), orchestrator.CreateJobInput{Kind: string(orchestrator.JobKindRestartService), ServerID: serverID})
+		if err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		jobIDs = append(jobIDs, job.ID)
+	}
+
+	executor := &gatedExecutor{started: make(chan string, len(jobIDs)), release: make(chan struct{})}
+	w := New(store, executor, testWorkerLogger(), Config{PollInterval: time.Hour, MaxJobs: 4, MaxJobsPerProvider: 1, MaxJobsPerServer: 1})
+	w.poll(context.Background())
+
+	// Provider 101 has a single slot, so only one of its three jobs starts;
+	// the job on server 3 runs alongside it on the other provider.
+	started := []string{<-executor.started, <-executor.started}
+	if started[0] != "00000000-0000-7000-8000-000000000003" && started[1] != "00000000-0000-7000-8000-000000000003" {
+		t.Fatalf("started servers = %v, want server 3 among them", started)
+	}
+	usage := w.SlotUsage()
+	if usage.Running != 2 || usage.Providers["00000000-0000-7000-8000-000000000101"] != 1 || usage.Providers["00000000-0000-7000-8000-000000000102"] != 1 {
+		t.Fatalf("slot usage = %+v, want one running job per provider", usage)
+	}
+	queued := 0
+	for _, id := range jobIDs {
+		job, err := store.GetJob(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if job.Status == orchestrator.JobStatusQueued {
+			queued++
+		}
+	}
+	if queued != 2 {
+		t.Fatalf("queued jobs = %d, want 2 waiting for a slot", queued)
+	}
+
+	// Lifting the provider limit still keeps the two jobs on server 1 apart.
+	w.slots.perProvider = 0
+	w.poll(context.Background())
+	third := <-executor.started
+	if usage := w.SlotUsage(); usage.Running != 3 || usage.Servers["00000000-0000-7000-8000-000000000001"] != 1 {
+		t.Fatalf("slot usage = %+v (started %s), want three jobs with server 1 locked to one", usage, third)
+	}
+
+	close(executor.release)
+	w.wait()
+	if usage := w.SlotUsage(); usage.Running != 0 || len(usage.Servers) != 0 || len(usage.Providers) != 0 {
+		t.Fatalf("slot usage after completion = %+v, want all slots free", usage)
+	}
+}
+
+func TestWorkerPollLocksServerForDestructiveJobs(t *testing.T) {
+	store := newWorkerJobStore(t)
+	const serverID = "00000000-0000-7000-8000-000000000001"
+	createJob := func(kind orchestrator.JobKind) orchestrator.Job {
+		t.Helper()
+		job, err := store.CreateJob(context.Background(), orchestrator.CreateJobInput{Kind: string(kind), ServerID: serverID})
+		if err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		return job
+	}
+	assertStatus := func(job orchestrator.Job, want orchestrator.JobStatus) {
+		t.Helper()
+		got, err := store.GetJob(context.Background(), job.ID)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if got.Status != want {
+			t.Fatalf("%s status = %q, want %q", job.Kind, got.Status, want)
+		}
+	}
+	config := Config{PollInterval: time.Hour, MaxJobs: 4, MaxJobsPerServer: 3}
+
+	// The server has room for three jobs, but a resize waits until the
+	// restart already running on it has finished.
+	executor := &gatedExecutor{started: make(chan string, 4), release: make(chan struct{})}
+	w := New(store, executor, testWorkerLogger(), config)
+	createJob(orchestrator.JobKindRestartService)
+	w.poll(context.Background())
+	<-executor.started
+	resize := createJob(orchestrator.JobKindResizeServer)
+	restart := createJob(orchestrator.JobKindRestartService)
+	w.poll(context.Background())
+	<-executor.started
+	if usage := w.SlotUsage(); usage.Running != 2 || usage.Servers[serverID] != 2 {
+		t.Fatalf("slot usage = %+v, want two restarts sharing the server", usage)
+	}
+	assertStatus(restart, orchestrator.JobStatusRunning)
+	assertStatus(resize, orchestrator.JobStatusQueued)
+	close(executor.release)
+	w.wait()
+
+	// Once it runs, nothing else starts on the server beside it.
+	executor = &gatedExecutor{started: make(chan string, 4), release: make(chan struct{})}
+	w = New(store, executor, testWorkerLogger(), config)
+	w.poll(context.Background())
+	<-executor.started
+	assertStatus(resize, orchestrator.JobStatusRunning)
+	blocked := createJob(orchestrator.JobKindRestartService)
+	w.poll(context.Background())
+	if usage := w.SlotUsage(); usage.Running != 1 {
+		t.Fatalf("slot usage = %+v, want the resize alone on the server", usage)
+	}
+	assertStatus(blocked, orchestrator.JobStatusQueued)
+	close(executor.release)
+	w.wait()
+}
+
+type gatedExecutor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (e *gatedExecutor) Execute(ctx context.Context, job *orchestrator.Job) error {
+	e.started <- job.ServerID
+	select {
+	case <-e.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 type noopExecutor struct{}
 
 func (noopExecutor) Execute(context.Context, *orchestrator.Job) error { return nil }
 
 type blockingExecutor struct {
-	wait time.Duration
+	wait    time.Duration
+	started chan struct{}
 }
 
 func (e blockingExecutor) Execute(ctx context.Context, _ *orchestrator.Job) error {
+	if e.started != nil {
+		close(e.started)
+	}
 	select {
 	case <-time.After(e.wait):
 		return errors.New("unexpected completion")
@@ -113,10 +292,13 @@ func newWorkerJobStore(t *testing.T) *orchestrator.Store {
 	t.Cleanup(func() { _ = db.Close() })
 	if _, err := db.Exec(`
 		CREATE TABLE servers (
-			id TEXT PRIMARY KEY
+			id          TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
+			provider_id TEXT
 		);
 		CREATE TABLE jobs (
 			id           TEXT PRIMARY KEY,
+			workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001',
 			server_id    TEXT,
 			kind         TEXT    NOT NULL,
 			status       TEXT    NOT NULL,
@@ -128,6 +310,8 @@ func newWorkerJobStore(t *testing.T) *orchestrator.Store {
 			finished_at  TEXT,
 			timeout_at   TEXT,
 			command_id   TEXT,
+			prior_server_status TEXT,
+			next_attempt_at TEXT,
 			created_at   TEXT    NOT NULL,
 			updated_at   TEXT    NOT NULL
 		);
@@ -135,6 +319,7 @@ func newWorkerJobStore(t *testing.T) *orchestrator.Store {
 			id         TEXT PRIMARY KEY,
 			job_id     TEXT    NOT NULL,
 			seq        INTEGER NOT NULL,
+			attempt    INTEGER NOT NULL DEFAULT 1,
 			event_type TEXT    NOT NULL,
 			level      TEXT    NOT NULL,
 			step_key   TEXT,
@@ -143,9 +328,10 @@ func newWorkerJobStore(t *testing.T) *orchestrator.Store {
 			payload    TEXT,
 			created_at TEXT    NOT NULL
 		);
-		INSERT INTO servers (id) VALUES
-			('00000000-0000-7000-8000-000000000001'),
-			('00000000-0000-7000-8000-000000000002');
+		INSERT INTO servers (id, provider_id) VALUES
+			('00000000-0000-7000-8000-000000000001', '00000000-0000-7000-8000-000000000101'),
+			('00000000-0000-7000-8000-000000000002', '00000000-0000-7000-8000-000000000101'),
+			('00000000-0000-7000-8000-000000000003', '00000000-0000-7000-8000-000000000102');
 	`); err != nil {
 		t.Fatalf("create schema: %v", err)
 	}
@@ -69,7 +69,7 @@ func RenderAPITypeScriptModule() (string, error) {
 	out.WriteString("  SupportLevel,\n")
 	out.WriteString("} from '~/lib/platform-contract.generated'\n\n")
 	out.WriteString(fmt.Sprintf("export type Capability = %s\n", quotedUnion(capabilitiesToStrings(auth.AllCapabilities()))))
-	out.WriteString("export type Role = 'admin'\n\n")
+	out.WriteString(fmt.Sprintf("export type Role = %s\n\n", quotedUnion(rolesToStrings(auth.AllRoles()))))
 
 	names := make([]string, 0, len(registry.types))
 	for name := range registry.types {
@@ -264,6 +264,14 @@ func capabilitiesToStrings(values []auth.Capability) []string {
 	return out
 }
 
+func rolesToStrings(values []auth.Role) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		out = append(out, string(value))
+	}
+	return out
+}
+
 func quotedUnion(values []string) string {
 	if len(values) == 0 {
 		return "never"
@@ -41,18 +41,19 @@ type NodeThresholds struct {
 }
 
 type JobKindSpec struct {
-	Kind            string         `json:"kind"`
-	Label           string         `json:"label"`
-	AllowedStatuses []string       `json:"allowed_statuses"`
-	Destructive     bool           `json:"destructive"`
-	Experimental    bool           `json:"experimental"`
-	ExecutionPath   string         `json:"execution_path"`
-	DispatchPolicy  DispatchPolicy `json:"dispatch_policy"`
-	TimeoutSeconds  int64          `json:"timeout_seconds"`
-	RetryLimit      int            `json:"retry_limit"`
-	Recovery        string         `json:"recovery"`
-	QueuedStatus    string         `json:"queued_server_status,omitempty"`
-	Steps           []Step         `json:"steps"`
+	Kind                string         `json:"kind"`
+	Label               string         `json:"label"`
+	AllowedStatuses     []string       `json:"allowed_statuses"`
+	Destructive         bool           `json:"destructive"`
+	Experimental        bool           `json:"experimental"`
+	ExecutionPath       string         `json:"execution_path"`
+	DispatchPolicy      DispatchPolicy `json:"dispatch_policy"`
+	TimeoutSeconds      int64          `json:"timeout_seconds"`
+	RetryLimit          int            `json:"retry_limit"`
+	RetryBackoffSeconds int64          `json:"retry_backoff_seconds"`
+	Recovery            string         `json:"recovery"`
+	QueuedStatus        string         `json:"queued_server_status,omitempty"`
+	Steps               []Step         `json:"steps"`
 }
 
 type DispatchPolicy struct {
@@ -92,10 +93,12 @@ func SpecData() Spec {
 			string(orchestrator.JobStatusRunning),
 			string(orchestrator.JobStatusSucceeded),
 			string(orchestrator.JobStatusFailed),
+			string(orchestrator.JobStatusCancelled),
 		},
 		JobTerminalStatuses: []string{
 			string(orchestrator.JobStatusSucceeded),
 			string(orchestrator.JobStatusFailed),
+			string(orchestrator.JobStatusCancelled),
 		},
 		JobKinds:     jobKindSpecs(),
 		ConfigScopes: configScopes,
@@ -147,18 +150,19 @@ func jobKindSpecs() []JobKindSpec {
 	out := make([]JobKindSpec, 0, len(specs))
 	for _, spec := range specs {
 		out = append(out, JobKindSpec{
-			Kind:            string(spec.Kind),
-			Label:           spec.Label,
-			AllowedStatuses: toStrings(spec.AllowedStatuses),
-			Destructive:     spec.Destructive,
-			Experimental:    spec.Experimental,
-			ExecutionPath:   spec.ExecutionPath,
-			DispatchPolicy:  DispatchPolicy{QueueServer: spec.DispatchPolicy.QueueServer},
-			TimeoutSeconds:  int64(spec.Timeout / time.Second),
-			RetryLimit:      spec.RetryLimit,
-			Recovery:        spec.Recovery,
-			QueuedStatus:    string(spec.QueuedStatus),
-			Steps:           toSteps(spec.Steps),
+			Kind:                string(spec.Kind),
+			Label:               spec.Label,
+			AllowedStatuses:     toStrings(spec.AllowedStatuses),
+			Destructive:         spec.Destructive,
+			Experimental:        spec.Experimental,
+			ExecutionPath:       spec.ExecutionPath,
+			DispatchPolicy:      DispatchPolicy{QueueServer: spec.DispatchPolicy.QueueServer},
+			TimeoutSeconds:      int64(spec.Timeout / time.Second),
+			RetryLimit:          spec.RetryLimit,
+			RetryBackoffSeconds: int64(spec.RetryBackoff / time.Second),
+			Recovery:            spec.Recovery,
+			QueuedStatus:        string(spec.QueuedStatus),
+			Steps:               toSteps(spec.Steps),
 		})
 	}
 	sort.Slice(out, func(i, j int) bool {
@@ -29,6 +29,29 @@ func TestOpenCreatesParentDirAndRunsLatestMigrations(t *testing.T) {
 	requireTable(t, db.DB, "node_certificates")
 	requireTable(t, db.DB, "sites")
 	requireTable(t, db.DB, "domains")
+	requireTable(t, db.DB, "backup_targets")
+	requireTable(t, db.DB, "site_backups")
+	requireTable(t, db.DB, "schedules")
+	requireTable(t, db.DB, "user_site_grants")
+	requireTable(t, db.DB, "api_tokens")
+	requireTable(t, db.DB, "user_totp")
+	requireTable(t, db.DB, "user_recovery_codes")
+	requireTable(t, db.DB, "login_challenges")
+	requireTable(t, db.DB, "auth_policy")
+	requireTable(t, db.DB, "user_identities")
+	requireTable(t, db.DB, "oidc_login_states")
+	requireTable(t, db.DB, "user_account_tokens")
+	requireTable(t, db.DB, "workspaces")
+	requireTable(t, db.DB, "workspace_members")
+	requireTable(t, db.DB, "webhook_subscriptions")
+	requireTable(t, db.DB, "webhook_deliveries")
+	requireTable(t, db.DB, "notification_preferences")
+	requireTable(t, db.DB, "uptime_monitors")
+	requireTable(t, db.DB, "uptime_checks")
+	requireTable(t, db.DB, "uptime_incidents")
+	requireTable(t, db.DB, "webhook_cursor")
+	requireColumn(t, db.DB, "servers", "workspace_id")
+	requireColumn(t, db.DB, "activity", "workspace_id")
 	requireColumn(t, db.DB, "domains", "source")
 	requireColumn(t, db.DB, "domains", "dns_state")
 	requireColumn(t, db.DB, "domains", "routing_state")
@@ -38,6 +61,9 @@ func TestOpenCreatesParentDirAndRunsLatestMigrations(t *testing.T) {
 	requireColumn(t, db.DB, "jobs", "finished_at")
 	requireColumn(t, db.DB, "jobs", "timeout_at")
 	requireColumn(t, db.DB, "jobs", "command_id")
+	requireColumn(t, db.DB, "jobs", "prior_server_status")
+	requireColumn(t, db.DB, "jobs", "next_attempt_at")
+	requireColumn(t, db.DB, "job_events", "attempt")
 
 	requireTableMissing(t, db.DB, "job_steps")
 	requireTableMissing(t, db.DB, "job_checkpoints")
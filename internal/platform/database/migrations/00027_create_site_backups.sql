@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS backup_targets (
+    id                   TEXT PRIMARY KEY,
+    name                 TEXT    NOT NULL,
+    endpoint             TEXT    NOT NULL,
+    region               TEXT    NOT NULL,
+    bucket               TEXT    NOT NULL,
+    path_prefix          TEXT    NOT NULL DEFAULT '',
+    path_style           INTEGER NOT NULL DEFAULT 1,
+    access_key_id        TEXT    NOT NULL,
+    secret_key_encrypted TEXT    NOT NULL,
+    secret_key_key_id    TEXT    NOT NULL,
+    secret_key_version   INTEGER NOT NULL DEFAULT 0,
+    retention_days       INTEGER NOT NULL DEFAULT 30,
+    created_at           TEXT    NOT NULL,
+    updated_at           TEXT    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS site_backups (
+    id              TEXT PRIMARY KEY,
+    site_id         TEXT    NOT NULL,
+    server_id       TEXT    NOT NULL,
+    target_id       TEXT    NOT NULL,
+    job_id          TEXT,
+    status          TEXT    NOT NULL DEFAULT 'pending',
+    object_key      TEXT    NOT NULL,
+    size_bytes      INTEGER NOT NULL DEFAULT 0,
+    checksum_sha256 TEXT,
+    retention_days  INTEGER NOT NULL,
+    expires_at      TEXT,
+    last_error      TEXT,
+    started_at      TEXT,
+    completed_at    TEXT,
+    created_at      TEXT    NOT NULL,
+    updated_at      TEXT    NOT NULL,
+    FOREIGN KEY (target_id) REFERENCES backup_targets(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_site_backups_site_id ON site_backups(site_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_site_backups_status ON site_backups(status);
+CREATE INDEX IF NOT EXISTS idx_site_backups_expires_at ON site_backups(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_site_backups_expires_at;
+DROP INDEX IF EXISTS idx_site_backups_status;
+DROP INDEX IF EXISTS idx_site_backups_site_id;
+DROP TABLE IF EXISTS site_backups;
+DROP TABLE IF EXISTS backup_targets;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS schedules (
+    id                TEXT PRIMARY KEY,
+    name              TEXT    NOT NULL,
+    job_kind          TEXT    NOT NULL,
+    server_id         TEXT,
+    payload           TEXT,
+    cron_expression   TEXT    NOT NULL,
+    timezone          TEXT    NOT NULL DEFAULT 'UTC',
+    jitter_seconds    INTEGER NOT NULL DEFAULT 0,
+    missed_run_policy TEXT    NOT NULL DEFAULT 'skip',
+    paused            INTEGER NOT NULL DEFAULT 0,
+    next_run_at       TEXT,
+    last_run_at       TEXT,
+    last_job_id       TEXT,
+    last_error        TEXT,
+    created_at        TEXT    NOT NULL,
+    updated_at        TEXT    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules(paused, next_run_at);
+CREATE INDEX IF NOT EXISTS idx_schedules_server_id ON schedules(server_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_schedules_server_id;
+DROP INDEX IF EXISTS idx_schedules_due;
+DROP TABLE IF EXISTS schedules;
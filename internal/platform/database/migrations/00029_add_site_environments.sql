@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE sites ADD COLUMN environment TEXT NOT NULL DEFAULT 'production';
+ALTER TABLE sites ADD COLUMN parent_site_id TEXT REFERENCES sites(id);
+ALTER TABLE sites ADD COLUMN basic_auth_username TEXT;
+ALTER TABLE sites ADD COLUMN basic_auth_password_encrypted TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_sites_parent_site_id ON sites(parent_site_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sites_parent_site_id;
+
+ALTER TABLE sites DROP COLUMN basic_auth_password_encrypted;
+ALTER TABLE sites DROP COLUMN basic_auth_username;
+ALTER TABLE sites DROP COLUMN parent_site_id;
+ALTER TABLE sites DROP COLUMN environment;
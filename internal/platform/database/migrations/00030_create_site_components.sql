@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS site_components (
+    site_id        TEXT NOT NULL,
+    component_type TEXT NOT NULL,
+    name           TEXT NOT NULL,
+    title          TEXT NOT NULL DEFAULT '',
+    status         TEXT NOT NULL DEFAULT '',
+    version        TEXT NOT NULL DEFAULT '',
+    update_version TEXT NOT NULL DEFAULT '',
+    checked_at     TEXT NOT NULL,
+    PRIMARY KEY (site_id, component_type, name),
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_site_components_outdated ON site_components(update_version) WHERE update_version != '';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_site_components_outdated;
+DROP TABLE IF EXISTS site_components;
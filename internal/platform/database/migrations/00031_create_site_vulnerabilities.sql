@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS site_vulnerabilities (
+    site_id           TEXT NOT NULL,
+    vulnerability_id  TEXT NOT NULL,
+    component_type    TEXT NOT NULL,
+    component_name    TEXT NOT NULL,
+    installed_version TEXT NOT NULL DEFAULT '',
+    title             TEXT NOT NULL DEFAULT '',
+    description       TEXT NOT NULL DEFAULT '',
+    severity          TEXT NOT NULL DEFAULT 'unknown',
+    score             REAL NOT NULL DEFAULT 0,
+    fixed_in          TEXT NOT NULL DEFAULT '',
+    refs              TEXT NOT NULL DEFAULT '[]',
+    first_seen_at     TEXT NOT NULL,
+    scanned_at        TEXT NOT NULL,
+    PRIMARY KEY (site_id, vulnerability_id, component_type, component_name),
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_site_vulnerabilities_severity ON site_vulnerabilities(severity);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_site_vulnerabilities_severity;
+DROP TABLE IF EXISTS site_vulnerabilities;
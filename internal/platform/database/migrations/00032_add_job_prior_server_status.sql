@@ -0,0 +1,7 @@
+-- +goose Up
+-- Server status held before a lifecycle job moved the server into its queued
+-- status, so a cancelled job can put the server back.
+ALTER TABLE jobs ADD COLUMN prior_server_status TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN prior_server_status;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Retried jobs wait in the queue until next_attempt_at; events record which
+-- attempt they belong to so the timeline can be grouped per attempt.
+ALTER TABLE jobs ADD COLUMN next_attempt_at TEXT;
+ALTER TABLE job_events ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE job_events DROP COLUMN attempt;
+ALTER TABLE jobs DROP COLUMN next_attempt_at;
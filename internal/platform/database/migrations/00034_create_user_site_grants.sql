@@ -0,0 +1,17 @@
+-- +goose Up
+-- Site grants scope a user to specific sites. Clients are always limited to
+-- their grants; developers and viewers only once they have at least one.
+CREATE TABLE IF NOT EXISTS user_site_grants (
+    user_id    TEXT NOT NULL,
+    site_id    TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    PRIMARY KEY (user_id, site_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (site_id) REFERENCES sites(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_site_grants_site_id ON user_site_grants(site_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_site_grants_site_id;
+DROP TABLE IF EXISTS user_site_grants;
@@ -0,0 +1,54 @@
+-- +goose Up
+-- TOTP second factor for operator logins. The shared secret is age-encrypted
+-- because codes are verified against it; recovery codes and login challenges
+-- only store the HMAC of their value. last_used_step stops a code from being
+-- replayed within its validity window.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id           TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_ciphertext TEXT NOT NULL,
+    enabled_at        TEXT,
+    last_used_step    INTEGER NOT NULL DEFAULT 0,
+    created_at        TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    updated_at        TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL UNIQUE,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    used_at    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+
+-- A login challenge is issued once the password checks out and is traded for
+-- a session together with a valid code.
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id             TEXT PRIMARY KEY,
+    user_id        TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    challenge_hash TEXT NOT NULL UNIQUE,
+    attempts       INTEGER NOT NULL DEFAULT 0,
+    created_at     TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    expires_at     TEXT NOT NULL,
+    consumed_at    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON login_challenges(user_id);
+
+-- auth_policy holds the single row of control-plane wide login policy.
+CREATE TABLE IF NOT EXISTS auth_policy (
+    id           INTEGER PRIMARY KEY CHECK (id = 1),
+    require_totp INTEGER NOT NULL DEFAULT 0,
+    updated_at   TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+INSERT OR IGNORE INTO auth_policy (id, require_totp) VALUES (1, 0);
+
+-- +goose Down
+DROP TABLE IF EXISTS auth_policy;
+DROP INDEX IF EXISTS idx_login_challenges_user_id;
+DROP TABLE IF EXISTS login_challenges;
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
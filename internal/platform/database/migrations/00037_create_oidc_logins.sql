@@ -0,0 +1,38 @@
+-- +goose Up
+-- OIDC single sign-on. user_identities links an identity provider subject to
+-- a panel user, so a changed email at the provider keeps the same account.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer        TEXT NOT NULL,
+    subject       TEXT NOT NULL,
+    user_id       TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email         TEXT NOT NULL,
+    created_at    TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    last_login_at TEXT,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+-- An OIDC login state lives from the redirect to the provider until the
+-- callback. Only the HMAC of the state is stored; the nonce and PKCE verifier
+-- are short-lived and never leave the control plane.
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id            TEXT PRIMARY KEY,
+    state_hash    TEXT NOT NULL UNIQUE,
+    nonce         TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    redirect_path TEXT NOT NULL DEFAULT '/',
+    created_at    TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    expires_at    TEXT NOT NULL,
+    consumed_at   TEXT
+);
+
+-- auth_method tells password sessions from single sign-on ones; the identity
+-- provider is responsible for the second factor of the latter.
+ALTER TABLE sessions ADD COLUMN auth_method TEXT NOT NULL DEFAULT 'password';
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN auth_method;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- One-time tokens that let someone set a password without knowing the old
+-- one: invitations for new users and password resets for existing ones. Only
+-- the HMAC of a token is stored, and a token is spent on first use.
+CREATE TABLE IF NOT EXISTS user_account_tokens (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose    TEXT NOT NULL CHECK (purpose IN ('invitation', 'password_reset')),
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by TEXT,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    expires_at TEXT NOT NULL,
+    used_at    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_account_tokens_user_id ON user_account_tokens(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_account_tokens_user_id;
+DROP TABLE IF EXISTS user_account_tokens;
@@ -0,0 +1,76 @@
+-- +goose Up
+-- Workspaces own providers, servers, sites, domains, backup targets,
+-- schedules, jobs and activity so one install can keep agencies or clients
+-- strictly apart. Existing data moves into the default workspace, and every
+-- existing user becomes a member of it with their current role.
+CREATE TABLE IF NOT EXISTS workspaces (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    slug       TEXT NOT NULL UNIQUE,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id TEXT NOT NULL,
+    user_id      TEXT NOT NULL,
+    role         TEXT NOT NULL,
+    created_at   TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    PRIMARY KEY (workspace_id, user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+
+INSERT OR IGNORE INTO workspaces (id, name, slug)
+VALUES ('00000000-0000-7000-8000-000000000001', 'Default', 'default');
+
+INSERT OR IGNORE INTO workspace_members (workspace_id, user_id, role, created_at)
+SELECT '00000000-0000-7000-8000-000000000001', id, role, created_at FROM users;
+
+-- SQLite cannot add a column with both a foreign key and a non-NULL
+-- default, so the owning workspace is enforced by the stores.
+ALTER TABLE providers ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE servers ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE sites ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE domains ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE backup_targets ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE schedules ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE jobs ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE activity ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+ALTER TABLE api_tokens ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '00000000-0000-7000-8000-000000000001';
+
+-- Provider names only need to be unique within a workspace.
+DROP INDEX IF EXISTS idx_providers_type_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_providers_workspace_type_name ON providers(workspace_id, type, name);
+CREATE INDEX IF NOT EXISTS idx_servers_workspace_id ON servers(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_sites_workspace_id ON sites(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_domains_workspace_id ON domains(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_backup_targets_workspace_id ON backup_targets(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_workspace_id ON schedules(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_workspace_id ON jobs(workspace_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_activity_workspace_id ON activity(workspace_id, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_activity_workspace_id;
+DROP INDEX IF EXISTS idx_jobs_workspace_id;
+DROP INDEX IF EXISTS idx_schedules_workspace_id;
+DROP INDEX IF EXISTS idx_backup_targets_workspace_id;
+DROP INDEX IF EXISTS idx_domains_workspace_id;
+DROP INDEX IF EXISTS idx_sites_workspace_id;
+DROP INDEX IF EXISTS idx_servers_workspace_id;
+DROP INDEX IF EXISTS idx_providers_workspace_type_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_providers_type_name ON providers(type, name);
+ALTER TABLE api_tokens DROP COLUMN workspace_id;
+ALTER TABLE activity DROP COLUMN workspace_id;
+ALTER TABLE jobs DROP COLUMN workspace_id;
+ALTER TABLE schedules DROP COLUMN workspace_id;
+ALTER TABLE backup_targets DROP COLUMN workspace_id;
+ALTER TABLE domains DROP COLUMN workspace_id;
+ALTER TABLE sites DROP COLUMN workspace_id;
+ALTER TABLE servers DROP COLUMN workspace_id;
+ALTER TABLE providers DROP COLUMN workspace_id;
+DROP INDEX IF EXISTS idx_workspace_members_user_id;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
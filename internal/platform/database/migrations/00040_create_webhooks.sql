@@ -0,0 +1,58 @@
+-- +goose Up
+-- Webhook subscriptions send activity events to external tools. The signing
+-- secret is age-encrypted at rest because every delivery is signed with it.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id               TEXT PRIMARY KEY,
+    workspace_id     TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name             TEXT NOT NULL,
+    url              TEXT NOT NULL,
+    secret_encrypted TEXT NOT NULL,
+    event_types      TEXT NOT NULL DEFAULT '[]',
+    categories       TEXT NOT NULL DEFAULT '[]',
+    resource_type    TEXT,
+    resource_id      TEXT,
+    enabled          INTEGER NOT NULL DEFAULT 1,
+    created_at       TEXT NOT NULL,
+    updated_at       TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_workspace_id ON webhook_subscriptions(workspace_id);
+
+-- Deliveries are the durable queue and, once settled, the delivery log. The
+-- body is fixed when the delivery is queued so retries send the same bytes.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              TEXT PRIMARY KEY,
+    subscription_id TEXT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    activity_id     TEXT,
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TEXT,
+    last_attempt_at TEXT,
+    response_status INTEGER,
+    last_error      TEXT,
+    created_at      TEXT NOT NULL,
+    delivered_at    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at);
+
+-- webhook_cursor remembers the last activity entry fanned out to
+-- subscriptions. It starts at the newest entry so history is not replayed.
+CREATE TABLE IF NOT EXISTS webhook_cursor (
+    id               INTEGER PRIMARY KEY CHECK (id = 1),
+    last_activity_id TEXT NOT NULL
+);
+
+INSERT INTO webhook_cursor (id, last_activity_id)
+SELECT 1, COALESCE(MAX(id), '') FROM activity;
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_cursor;
+DROP INDEX IF EXISTS idx_webhook_deliveries_subscription;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhook_subscriptions_workspace_id;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Email notifications are opt-in per user. last_activity_id is the newest
+-- activity entry already considered for the user, so nothing is mailed
+-- twice and history is not replayed when notifications are switched on.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id          TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email_enabled    INTEGER NOT NULL DEFAULT 0,
+    event_types      TEXT NOT NULL DEFAULT '[]',
+    delivery         TEXT NOT NULL DEFAULT 'immediate',
+    last_activity_id TEXT NOT NULL DEFAULT '',
+    last_sent_at     TEXT,
+    created_at       TEXT NOT NULL,
+    updated_at       TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_preferences;
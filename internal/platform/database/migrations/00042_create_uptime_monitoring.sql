@@ -0,0 +1,50 @@
+-- +goose Up
+-- uptime_monitors holds per-site probe settings. Sites without a row are
+-- probed with the defaults: enabled and without a keyword.
+CREATE TABLE IF NOT EXISTS uptime_monitors (
+    site_id    TEXT PRIMARY KEY REFERENCES sites(id) ON DELETE CASCADE,
+    enabled    INTEGER NOT NULL DEFAULT 1,
+    keyword    TEXT NOT NULL DEFAULT '',
+    updated_at TEXT NOT NULL
+);
+
+-- uptime_checks is the time series of external probes, one row per hostname
+-- and probe. Old rows are pruned by the prober.
+CREATE TABLE IF NOT EXISTS uptime_checks (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    site_id        TEXT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    hostname       TEXT NOT NULL,
+    checked_at     TEXT NOT NULL,
+    up             INTEGER NOT NULL,
+    status_code    INTEGER,
+    latency_ms     INTEGER NOT NULL DEFAULT 0,
+    tls_expires_at TEXT,
+    keyword_match  INTEGER,
+    error          TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_uptime_checks_site_hostname ON uptime_checks(site_id, hostname, checked_at);
+CREATE INDEX IF NOT EXISTS idx_uptime_checks_checked_at ON uptime_checks(checked_at);
+
+-- uptime_incidents spans from the failure that opened it to the first
+-- successful probe after it. At most one incident per hostname is open.
+CREATE TABLE IF NOT EXISTS uptime_incidents (
+    id          TEXT PRIMARY KEY,
+    site_id     TEXT NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    hostname    TEXT NOT NULL,
+    cause       TEXT NOT NULL,
+    started_at  TEXT NOT NULL,
+    resolved_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_uptime_incidents_site ON uptime_incidents(site_id, started_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_uptime_incidents_open ON uptime_incidents(site_id, hostname) WHERE resolved_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_uptime_incidents_open;
+DROP INDEX IF EXISTS idx_uptime_incidents_site;
+DROP TABLE IF EXISTS uptime_incidents;
+DROP INDEX IF EXISTS idx_uptime_checks_checked_at;
+DROP INDEX IF EXISTS idx_uptime_checks_site_hostname;
+DROP TABLE IF EXISTS uptime_checks;
+DROP TABLE IF EXISTS uptime_monitors;
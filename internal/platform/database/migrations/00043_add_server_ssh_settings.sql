@@ -0,0 +1,12 @@
+-- +goose Up
+-- Servers adopted over SSH keep whatever port and login user they already
+-- had; provisioned servers use the defaults. The host key fingerprint is
+-- recorded when Pressluft first connects to an adopted server.
+ALTER TABLE servers ADD COLUMN ssh_port INTEGER NOT NULL DEFAULT 22;
+ALTER TABLE servers ADD COLUMN ssh_user TEXT NOT NULL DEFAULT 'root';
+ALTER TABLE servers ADD COLUMN ssh_host_key_fingerprint TEXT;
+
+-- +goose Down
+ALTER TABLE servers DROP COLUMN ssh_host_key_fingerprint;
+ALTER TABLE servers DROP COLUMN ssh_user;
+ALTER TABLE servers DROP COLUMN ssh_port;
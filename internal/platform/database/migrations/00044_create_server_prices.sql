@@ -0,0 +1,25 @@
+-- +goose Up
+-- server_prices records the provider list price a server is billed at, one
+-- row per period between provisioning, resizes and deletion. The open period
+-- has no effective_until. Cost reports prorate these periods per month.
+CREATE TABLE IF NOT EXISTS server_prices (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id       TEXT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    server_type     TEXT NOT NULL,
+    location        TEXT NOT NULL,
+    hourly_gross    TEXT NOT NULL,
+    monthly_gross   TEXT NOT NULL,
+    currency        TEXT NOT NULL,
+    effective_from  TEXT NOT NULL,
+    effective_until TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_prices_server ON server_prices(server_id, effective_from);
+CREATE INDEX IF NOT EXISTS idx_server_prices_effective ON server_prices(effective_from, effective_until);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_server_prices_open ON server_prices(server_id) WHERE effective_until IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_server_prices_open;
+DROP INDEX IF EXISTS idx_server_prices_effective;
+DROP INDEX IF EXISTS idx_server_prices_server;
+DROP TABLE IF EXISTS server_prices;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Failed deliveries used to keep the start of the receiver's response body.
+-- Only the status is kept now; drop the bodies already stored.
+UPDATE webhook_deliveries
+SET last_error = 'unexpected status ' || response_status
+WHERE response_status IS NOT NULL
+  AND last_error LIKE 'unexpected status %: %';
+
+-- +goose Down
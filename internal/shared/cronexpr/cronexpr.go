@@ -0,0 +1,239 @@
+// Package cronexpr parses standard five-field cron expressions and computes
+// their next fire time.
+//
+// Supported syntax per field: "*", single values, ranges ("1-5"), steps
+// ("*/15", "0-30/10") and comma-separated lists. Month and weekday fields
+// also accept three-letter names (JAN, MON). Weekday 7 is Sunday. The
+// macros @hourly, @daily, @midnight, @weekly, @monthly, @yearly and
+// @annually are accepted as shorthands.
+package cronexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds Next for expressions that can never fire, such as
+// "0 0 30 2 *".
+const maxSearchYears = 5
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+type fieldSpec struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField  = fieldSpec{name: "minute", min: 0, max: 59}
+	hourField    = fieldSpec{name: "hour", min: 0, max: 23}
+	domField     = fieldSpec{name: "day of month", min: 1, max: 31}
+	monthField   = fieldSpec{name: "month", min: 1, max: 12, names: monthNames}
+	weekdayField = fieldSpec{name: "day of week", min: 0, max: 7, names: weekdayNames}
+)
+
+// Expression is a parsed cron expression.
+type Expression struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+// Parse parses a five-field cron expression or macro.
+func Parse(expr string) (Expression, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return Expression{}, fmt.Errorf("cron expression is required")
+	}
+	if strings.HasPrefix(expr, "@") {
+		expanded, ok := macros[strings.ToLower(expr)]
+		if !ok {
+			return Expression{}, fmt.Errorf("unknown cron macro %q", expr)
+		}
+		expr = expanded
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Expression{}, fmt.Errorf("cron expression must have 5 fields, got %d", len(fields))
+	}
+
+	var out Expression
+	var err error
+	if out.minute, err = parseField(fields[0], minuteField); err != nil {
+		return Expression{}, err
+	}
+	if out.hour, err = parseField(fields[1], hourField); err != nil {
+		return Expression{}, err
+	}
+	if out.dom, err = parseField(fields[2], domField); err != nil {
+		return Expression{}, err
+	}
+	if out.month, err = parseField(fields[3], monthField); err != nil {
+		return Expression{}, err
+	}
+	if out.dow, err = parseField(fields[4], weekdayField); err != nil {
+		return Expression{}, err
+	}
+	// Weekday 7 is an alias for Sunday.
+	if out.dow&(1<<7) != 0 {
+		out.dow |= 1
+		out.dow &^= 1 << 7
+	}
+	out.domStar = fields[2] == "*" || fields[2] == "?"
+	out.dowStar = fields[4] == "*" || fields[4] == "?"
+	return out, nil
+}
+
+// Next returns the first fire time strictly after t, evaluated in t's
+// location. It returns the zero time when the expression never fires.
+func (e Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	yearLimit := t.Year() + maxSearchYears
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for !has(e.month, int(t.Month())) {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !e.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for !has(e.hour, t.Hour()) {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for !has(e.minute, t.Minute()) {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// dayMatches follows the traditional cron rule: when both day fields are
+// restricted, a day matches if either one does.
+func (e Expression) dayMatches(t time.Time) bool {
+	domMatch := has(e.dom, t.Day())
+	dowMatch := has(e.dow, int(t.Weekday()))
+	if e.domStar || e.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func has(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
+
+func parseField(raw string, spec fieldSpec) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(raw, ",") {
+		partBits, err := parseFieldPart(part, spec)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+func parseFieldPart(part string, spec fieldSpec) (uint64, error) {
+	if part == "" {
+		return 0, fmt.Errorf("%s field has an empty list entry", spec.name)
+	}
+	rangePart, step := part, 1
+	if idx := strings.Index(part, "/"); idx >= 0 {
+		rangePart = part[:idx]
+		parsed, err := strconv.Atoi(part[idx+1:])
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("%s field has invalid step %q", spec.name, part[idx+1:])
+		}
+		step = parsed
+	}
+
+	var lo, hi int
+	switch {
+	case rangePart == "*" || rangePart == "?":
+		lo, hi = spec.min, spec.max
+	case strings.Contains(rangePart, "-"):
+		bounds := strings.SplitN(rangePart, "-", 2)
+		var err error
+		if lo, err = parseValue(bounds[0], spec); err != nil {
+			return 0, err
+		}
+		if hi, err = parseValue(bounds[1], spec); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("%s field has inverted range %q", spec.name, rangePart)
+		}
+	default:
+		value, err := parseValue(rangePart, spec)
+		if err != nil {
+			return 0, err
+		}
+		lo, hi = value, value
+		if step > 1 {
+			hi = spec.max
+		}
+	}
+
+	var bits uint64
+	for value := lo; value <= hi; value += step {
+		bits |= 1 << uint(value)
+	}
+	return bits, nil
+}
+
+func parseValue(raw string, spec fieldSpec) (int, error) {
+	if spec.names != nil {
+		if value, ok := spec.names[strings.ToLower(raw)]; ok {
+			return value, nil
+		}
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s field has invalid value %q", spec.name, raw)
+	}
+	if value < spec.min || value > spec.max {
+		return 0, fmt.Errorf("%s field value %d is outside %d-%d", spec.name, value, spec.min, spec.max)
+	}
+	return value, nil
+}
@@ -0,0 +1,78 @@
+package cronexpr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextComputesFireTimes(t *testing.T) {
+	from := time.Date(2026, time.March, 14, 10, 7, 30, 0, time.UTC) // Saturday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, time.March, 14, 10, 15, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, time.March, 15, 2, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 4 * * mon", time.Date(2026, time.March, 16, 4, 0, 0, 0, time.UTC)},
+		{"0 4 * * 7", time.Date(2026, time.March, 15, 4, 0, 0, 0, time.UTC)},
+		{"0 3 1 * *", time.Date(2026, time.April, 1, 3, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * 1-5", time.Date(2026, time.March, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+		}
+		if got := expr.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next() = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNextRespectsLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	expr, err := Parse("0 2 * * *")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got := expr.Next(time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC).In(berlin))
+	want := time.Date(2026, time.June, 2, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("Next() = %s, want %s", got.UTC(), want)
+	}
+}
+
+func TestNextReturnsZeroForImpossibleExpression(t *testing.T) {
+	expr, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := expr.Next(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Fatalf("Next() = %s, want zero time", got)
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every 5m",
+		"1,,2 * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+}
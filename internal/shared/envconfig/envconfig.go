@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +14,10 @@ import (
 var (
 	defaultSessionIdleTimeout     = 12 * time.Hour
 	defaultSessionAbsoluteTimeout = 7 * 24 * time.Hour
+
+	defaultWorkerMaxJobs            = 4
+	defaultWorkerMaxJobsPerProvider = 2
+	defaultWorkerMaxJobsPerServer   = 1
 )
 
 type RuntimePaths struct {
@@ -38,6 +43,7 @@ type ControlPlaneRuntime struct {
 	AgeKeyPath             string                 `json:"age_key_path"`
 	CAKeyPath              string                 `json:"ca_key_path"`
 	SessionSecretPath      string                 `json:"session_secret_path"`
+	VulnFeedPath           string                 `json:"vuln_feed_path"`
 	AnsibleDir             string                 `json:"ansible_dir"`
 	AnsibleBinary          string                 `json:"ansible_binary"`
 	TLSCertFile            string                 `json:"tls_cert_file,omitempty"`
@@ -45,6 +51,15 @@ type ControlPlaneRuntime struct {
 	SessionIdleTimeout     time.Duration          `json:"session_idle_timeout"`
 	SessionAbsoluteTimeout time.Duration          `json:"session_absolute_timeout"`
 	SessionCookieSecure    bool                   `json:"session_cookie_secure"`
+	Worker                 WorkerConcurrency      `json:"worker"`
+}
+
+// WorkerConcurrency bounds how many jobs the worker runs at once. A zero
+// per-provider limit leaves providers bounded only by the global limit.
+type WorkerConcurrency struct {
+	MaxJobs            int `json:"max_jobs"`
+	MaxJobsPerProvider int `json:"max_jobs_per_provider"`
+	MaxJobsPerServer   int `json:"max_jobs_per_server"`
 }
 
 type AgentRuntime struct {
@@ -102,6 +117,11 @@ func ResolveControlPlaneRuntime(isDevBuild bool, cwd string) (ControlPlaneRuntim
 		return ControlPlaneRuntime{}, err
 	}
 
+	workerConcurrency, err := ResolveWorkerConcurrency()
+	if err != nil {
+		return ControlPlaneRuntime{}, err
+	}
+
 	return ControlPlaneRuntime{
 		ExecutionMode:          executionMode,
 		ControlPlaneURL:        strings.TrimSpace(os.Getenv("PRESSLUFT_CONTROL_PLANE_URL")),
@@ -110,6 +130,7 @@ func ResolveControlPlaneRuntime(isDevBuild bool, cwd string) (ControlPlaneRuntim
 		AgeKeyPath:             resolveAgeKeyPath(dataDir),
 		CAKeyPath:              resolveCAKeyPath(dataDir),
 		SessionSecretPath:      resolveSessionSecretPath(dataDir),
+		VulnFeedPath:           resolveVulnFeedPath(dataDir),
 		AnsibleDir:             ansibleDir,
 		AnsibleBinary:          ansibleBinary,
 		TLSCertFile:            strings.TrimSpace(os.Getenv("PRESSLUFT_TLS_CERT_FILE")),
@@ -117,6 +138,7 @@ func ResolveControlPlaneRuntime(isDevBuild bool, cwd string) (ControlPlaneRuntim
 		SessionIdleTimeout:     idleTimeout,
 		SessionAbsoluteTimeout: absoluteTimeout,
 		SessionCookieSecure:    ResolveSecureSessionCookies(executionMode),
+		Worker:                 workerConcurrency,
 	}, nil
 }
 
@@ -151,6 +173,37 @@ func ResolveSessionTimeouts() (time.Duration, time.Duration, error) {
 	return idle, absolute, nil
 }
 
+func ResolveWorkerConcurrency() (WorkerConcurrency, error) {
+	out := WorkerConcurrency{
+		MaxJobs:            defaultWorkerMaxJobs,
+		MaxJobsPerProvider: defaultWorkerMaxJobsPerProvider,
+		MaxJobsPerServer:   defaultWorkerMaxJobsPerServer,
+	}
+	for _, setting := range []struct {
+		name string
+		min  int
+		dest *int
+	}{
+		{name: "PRESSLUFT_WORKER_MAX_JOBS", min: 1, dest: &out.MaxJobs},
+		{name: "PRESSLUFT_WORKER_MAX_JOBS_PER_PROVIDER", min: 0, dest: &out.MaxJobsPerProvider},
+		{name: "PRESSLUFT_WORKER_MAX_JOBS_PER_SERVER", min: 1, dest: &out.MaxJobsPerServer},
+	} {
+		raw := strings.TrimSpace(os.Getenv(setting.name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return WorkerConcurrency{}, fmt.Errorf("parse %s: %w", setting.name, err)
+		}
+		if parsed < setting.min {
+			return WorkerConcurrency{}, fmt.Errorf("%s must be at least %d", setting.name, setting.min)
+		}
+		*setting.dest = parsed
+	}
+	return out, nil
+}
+
 func ResolveSecureSessionCookies(mode platform.ExecutionMode) bool {
 	if raw := strings.TrimSpace(os.Getenv("PRESSLUFT_SESSION_COOKIE_SECURE")); raw != "" {
 		return raw == "1" || strings.EqualFold(raw, "true")
@@ -170,14 +223,40 @@ func ControlPlaneEnvSpec() []EnvVarSpec {
 		{Name: "PRESSLUFT_CA_KEY_PATH", Scope: "control-plane", Description: "Encrypted CA private key path."},
 		{Name: "PRESSLUFT_AGE_KEY_PATH", Scope: "shared", Description: "age identity path used for local secret encryption."},
 		{Name: "PRESSLUFT_SESSION_KEY_PATH", Scope: "control-plane", Description: "Session HMAC secret path."},
+		{Name: "PRESSLUFT_VULN_FEED_PATH", Scope: "control-plane", Description: "Vulnerability feed file in wpvulnerability.com format."},
 		{Name: "PRESSLUFT_SESSION_IDLE_TIMEOUT", Scope: "control-plane", DefaultValue: defaultSessionIdleTimeout.String(), Description: "Operator session idle timeout."},
 		{Name: "PRESSLUFT_SESSION_ABSOLUTE_TIMEOUT", Scope: "control-plane", DefaultValue: defaultSessionAbsoluteTimeout.String(), Description: "Operator session absolute timeout."},
 		{Name: "PRESSLUFT_SESSION_COOKIE_SECURE", Scope: "control-plane", Description: "Override secure-cookie behavior."},
 		{Name: "PRESSLUFT_BOOTSTRAP_ADMIN_EMAIL", Scope: "control-plane", Description: "Bootstrap admin email for non-dev mode."},
 		{Name: "PRESSLUFT_BOOTSTRAP_ADMIN_PASSWORD", Scope: "control-plane", Description: "Bootstrap admin password for non-dev mode."},
 		{Name: "PRESSLUFT_BOOTSTRAP_ADMIN_PASSWORD_FILE", Scope: "control-plane", Description: "File-based bootstrap admin password source."},
+		{Name: "PRESSLUFT_OIDC_ISSUER", Scope: "control-plane", Description: "OpenID Connect issuer URL; enables single sign-on."},
+		{Name: "PRESSLUFT_OIDC_CLIENT_ID", Scope: "control-plane", Description: "OpenID Connect client id."},
+		{Name: "PRESSLUFT_OIDC_CLIENT_SECRET", Scope: "control-plane", Description: "OpenID Connect client secret; leave empty for public clients."},
+		{Name: "PRESSLUFT_OIDC_CLIENT_SECRET_FILE", Scope: "control-plane", Description: "File-based OpenID Connect client secret source."},
+		{Name: "PRESSLUFT_OIDC_REDIRECT_URL", Scope: "control-plane", Description: "OpenID Connect callback URL; defaults to /api/auth/oidc/callback under the control plane URL."},
+		{Name: "PRESSLUFT_OIDC_SCOPES", Scope: "control-plane", DefaultValue: "openid email profile", Description: "Scopes requested from the identity provider."},
+		{Name: "PRESSLUFT_OIDC_NAME", Scope: "control-plane", DefaultValue: "single sign-on", Description: "Identity provider name shown on the login page."},
+		{Name: "PRESSLUFT_OIDC_GROUPS_CLAIM", Scope: "control-plane", DefaultValue: "groups", Description: "ID token claim holding the user's groups."},
+		{Name: "PRESSLUFT_OIDC_ROLE_MAP", Scope: "control-plane", Description: "Comma-separated group=role pairs mapping provider groups to roles in the default workspace."},
+		{Name: "PRESSLUFT_OIDC_DEFAULT_ROLE", Scope: "control-plane", Description: "Role for single sign-on users in no mapped group; empty refuses them."},
+		{Name: "PRESSLUFT_PASSWORD_LOGIN_DISABLED", Scope: "control-plane", Description: "Refuse password logins when single sign-on is configured."},
+		{Name: "PRESSLUFT_SMTP_HOST", Scope: "control-plane", Description: "SMTP relay for email notifications; unset leaves them off."},
+		{Name: "PRESSLUFT_SMTP_PORT", Scope: "control-plane", DefaultValue: "587", Description: "SMTP relay port."},
+		{Name: "PRESSLUFT_SMTP_SECURITY", Scope: "control-plane", DefaultValue: "starttls", Description: "SMTP transport security: starttls, tls or none."},
+		{Name: "PRESSLUFT_SMTP_USERNAME", Scope: "control-plane", Description: "SMTP username; leave empty for relays without authentication."},
+		{Name: "PRESSLUFT_SMTP_PASSWORD", Scope: "control-plane", Description: "SMTP password."},
+		{Name: "PRESSLUFT_SMTP_PASSWORD_FILE", Scope: "control-plane", Description: "File-based SMTP password source."},
+		{Name: "PRESSLUFT_SMTP_FROM", Scope: "control-plane", Description: "Sender address of notification emails."},
+		{Name: "PRESSLUFT_METRICS_TOKEN", Scope: "control-plane", Description: "Bearer token scrapers present to /metrics; the endpoint is disabled when unset."},
+		{Name: "PRESSLUFT_METRICS_TOKEN_FILE", Scope: "control-plane", Description: "File-based metrics token source."},
+		{Name: "PRESSLUFT_WORKER_MAX_JOBS", Scope: "control-plane", DefaultValue: strconv.Itoa(defaultWorkerMaxJobs), Description: "Jobs the worker runs at once."},
+		{Name: "PRESSLUFT_WORKER_MAX_JOBS_PER_PROVIDER", Scope: "control-plane", DefaultValue: strconv.Itoa(defaultWorkerMaxJobsPerProvider), Description: "Concurrent jobs against servers of one provider account; 0 disables the limit."},
+		{Name: "PRESSLUFT_WORKER_MAX_JOBS_PER_SERVER", Scope: "control-plane", DefaultValue: strconv.Itoa(defaultWorkerMaxJobsPerServer), Description: "Concurrent jobs on one server; destructive jobs always run alone."},
 		{Name: "PRESSLUFT_ANSIBLE_DIR", Scope: "control-plane", Description: "Working directory used to resolve ansible paths."},
 		{Name: "PRESSLUFT_ANSIBLE_BIN", Scope: "control-plane", Description: "Path to ansible-playbook."},
+		{Name: "PRESSLUFT_FAKE_CLOUD", Scope: "control-plane", Description: "Dev builds only: add the in-memory fake provider and simulate playbooks and agents for its servers."},
+		{Name: "PRESSLUFT_FAKE_CLOUD_DELAY", Scope: "control-plane", DefaultValue: "2s", Description: "How long each simulated provider action and playbook run takes."},
 	}
 }
 
@@ -208,6 +287,13 @@ func resolveCAKeyPath(dataDir string) string {
 	return filepath.Join(dataDir, "ca.key")
 }
 
+func resolveVulnFeedPath(dataDir string) string {
+	if p := strings.TrimSpace(os.Getenv("PRESSLUFT_VULN_FEED_PATH")); p != "" {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dataDir, "vulnerabilities.json")
+}
+
 func resolveSessionSecretPath(dataDir string) string {
 	if p := strings.TrimSpace(os.Getenv("PRESSLUFT_SESSION_KEY_PATH")); p != "" {
 		return filepath.Clean(p)
@@ -76,4 +76,7 @@ func TestResolveControlPlaneRuntimeUsesRepoLocalStateInDevMode(t *testing.T) {
 	if runtime.SessionSecretPath != filepath.Join(expectedDir, "session.key") {
 		t.Fatalf("SessionSecretPath = %q", runtime.SessionSecretPath)
 	}
+	if runtime.VulnFeedPath != filepath.Join(expectedDir, "vulnerabilities.json") {
+		t.Fatalf("VulnFeedPath = %q", runtime.VulnFeedPath)
+	}
 }
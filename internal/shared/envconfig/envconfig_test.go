@@ -85,6 +85,26 @@ func TestResolveControlPlaneRuntimeRejectsInvalidTimeouts(t *testing.T) {
 	}
 }
 
+func TestResolveWorkerConcurrency(t *testing.T) {
+	t.Setenv("PRESSLUFT_WORKER_MAX_JOBS", "")
+	t.Setenv("PRESSLUFT_WORKER_MAX_JOBS_PER_PROVIDER", "0")
+	t.Setenv("PRESSLUFT_WORKER_MAX_JOBS_PER_SERVER", "")
+
+	got, err := ResolveWorkerConcurrency()
+	if err != nil {
+		t.Fatalf("ResolveWorkerConcurrency() error = %v", err)
+	}
+	want := WorkerConcurrency{MaxJobs: 4, MaxJobsPerProvider: 0, MaxJobsPerServer: 1}
+	if got != want {
+		t.Fatalf("worker concurrency = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("PRESSLUFT_WORKER_MAX_JOBS", "0")
+	if _, err := ResolveWorkerConcurrency(); err == nil {
+		t.Fatal("expected a zero global limit to be rejected")
+	}
+}
+
 func TestResolveAgentRuntimeModes(t *testing.T) {
 	t.Setenv("PRESSLUFT_EXECUTION_MODE", "")
 	runtime, err := ResolveAgentRuntime(false, "/etc/pressluft/agent.yaml")
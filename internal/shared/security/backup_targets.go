@@ -0,0 +1,22 @@
+package security
+
+const BackupTargetSecretVersion = 1
+
+func EncryptBackupTargetSecret(secret string) (ciphertext string, keyID string, version int, err error) {
+	if _, err = EnsureAgeKey(ageKeyPath(), true); err != nil {
+		return "", "", 0, err
+	}
+	ciphertext, keyID, err = Encrypt([]byte(secret))
+	if err != nil {
+		return "", "", 0, err
+	}
+	return ciphertext, keyID, BackupTargetSecretVersion, nil
+}
+
+func DecryptBackupTargetSecret(ciphertext string) (string, error) {
+	plaintext, err := Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,22 @@
+package security
+
+// EncryptSiteBasicAuthPassword encrypts the HTTP basic auth password that
+// protects a non-production site so health checks can authenticate later.
+func EncryptSiteBasicAuthPassword(password string) (string, error) {
+	if _, err := EnsureAgeKey(ageKeyPath(), true); err != nil {
+		return "", err
+	}
+	ciphertext, _, err := Encrypt([]byte(password))
+	if err != nil {
+		return "", err
+	}
+	return ciphertext, nil
+}
+
+func DecryptSiteBasicAuthPassword(ciphertext string) (string, error) {
+	plaintext, err := Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,22 @@
+package security
+
+// EncryptTOTPSecret encrypts an operator's TOTP shared secret, which must be
+// readable again to verify codes and so cannot be hashed.
+func EncryptTOTPSecret(secret string) (string, error) {
+	if _, err := EnsureAgeKey(ageKeyPath(), true); err != nil {
+		return "", err
+	}
+	ciphertext, _, err := Encrypt([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return ciphertext, nil
+}
+
+func DecryptTOTPSecret(ciphertext string) (string, error) {
+	plaintext, err := Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,22 @@
+package security
+
+// EncryptWebhookSecret encrypts a webhook signing secret. Deliveries are
+// signed with it, so unlike API tokens it cannot be hashed.
+func EncryptWebhookSecret(secret string) (string, error) {
+	if _, err := EnsureAgeKey(ageKeyPath(), true); err != nil {
+		return "", err
+	}
+	ciphertext, _, err := Encrypt([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return ciphertext, nil
+}
+
+func DecryptWebhookSecret(ciphertext string) (string, error) {
+	plaintext, err := Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
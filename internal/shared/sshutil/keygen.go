@@ -1,4 +1,5 @@
-// Package sshutil provides provider-agnostic SSH key utilities.
+// Package sshutil provides provider-agnostic SSH key utilities and a small
+// client for running commands on servers before the agent is installed.
 package sshutil
 
 import (
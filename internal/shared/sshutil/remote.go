@@ -0,0 +1,268 @@
+package sshutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultPort is the SSH port used when a target does not name one.
+const DefaultPort = 22
+
+// defaultDialTimeout bounds the TCP connect and SSH handshake.
+const defaultDialTimeout = 15 * time.Second
+
+// ErrHostKeyMismatch means the host presented a different key than the one
+// pinned for it.
+var ErrHostKeyMismatch = errors.New("ssh host key mismatch")
+
+// hostKeyAlgorithms prefers ED25519 host keys, so a host offers the key
+// whose fingerprint operators read with
+// ssh-keygen -lf /etc/ssh/ssh_host_ed25519_key.pub, and the same key on
+// every connection.
+var hostKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+	ssh.KeyAlgoRSASHA256,
+}
+
+// Target is an SSH endpoint and the user to log in as.
+type Target struct {
+	Host string
+	Port int
+	User string
+	// HostKeyFingerprint pins the SHA256 fingerprint of the key the host
+	// must present. Empty accepts any key on first contact.
+	HostKeyFingerprint string
+}
+
+// Address returns host:port, defaulting the port to 22.
+func (t Target) Address() string {
+	port := t.Port
+	if port <= 0 {
+		port = DefaultPort
+	}
+	return net.JoinHostPort(t.Host, strconv.Itoa(port))
+}
+
+// Client is an open SSH connection to a target.
+type Client struct {
+	conn *ssh.Client
+	// HostKeyFingerprint is the SHA256 fingerprint of the key the host
+	// presented, in the format ssh-keygen -l prints.
+	HostKeyFingerprint string
+}
+
+// PrivateKeyAuth returns an auth method for a PEM-encoded private key.
+func PrivateKeyAuth(privateKeyPEM string) (ssh.AuthMethod, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	return ssh.PublicKeys(signer), nil
+}
+
+// PasswordAuth returns auth methods for a password. Hosts that disable plain
+// password auth often still answer keyboard-interactive prompts with it.
+func PasswordAuth(password string) []ssh.AuthMethod {
+	return []ssh.AuthMethod{
+		ssh.Password(password),
+		ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range answers {
+				answers[i] = password
+			}
+			return answers, nil
+		}),
+	}
+}
+
+// Dial connects to target. A host presenting a key other than the pinned
+// one is rejected during the handshake, before any credentials are sent.
+// Without a pin the key is accepted on first contact; either way it is
+// reported on the returned client so callers can record it.
+func Dial(ctx context.Context, target Target, auth ...ssh.AuthMethod) (*Client, error) {
+	if strings.TrimSpace(target.Host) == "" {
+		return nil, errors.New("ssh host is required")
+	}
+	if strings.TrimSpace(target.User) == "" {
+		return nil, errors.New("ssh user is required")
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("ssh auth method is required")
+	}
+
+	timeout := defaultDialTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+	client := &Client{}
+	config := &ssh.ClientConfig{
+		User: target.User,
+		Auth: auth,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			fingerprint := ssh.FingerprintSHA256(key)
+			if pinned := strings.TrimSpace(target.HostKeyFingerprint); pinned != "" && fingerprint != pinned {
+				return fmt.Errorf("%w: %s presented %s, expected %s", ErrHostKeyMismatch, target.Address(), fingerprint, pinned)
+			}
+			client.HostKeyFingerprint = fingerprint
+			return nil
+		},
+		HostKeyAlgorithms: hostKeyAlgorithms,
+		Timeout:           timeout,
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", target.Address())
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", target.Address(), err)
+	}
+	_ = netConn.SetDeadline(time.Now().Add(timeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, target.Address(), config)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s as %s: %w", target.Address(), target.User, err)
+	}
+	_ = netConn.SetDeadline(time.Time{})
+	client.conn = ssh.NewClient(sshConn, chans, reqs)
+	return client, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Run executes command and returns its stdout. A non-zero exit status is an
+// error that includes stderr.
+func (c *Client) Run(ctx context.Context, command string) (string, error) {
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("open ssh session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+	select {
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		return "", ctx.Err()
+	case err := <-done:
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return stdout.String(), fmt.Errorf("%w: %s", err, msg)
+			}
+			return stdout.String(), err
+		}
+		return stdout.String(), nil
+	}
+}
+
+// InstallAuthorizedKey appends publicKey to the login user's
+// authorized_keys unless it is already there.
+func InstallAuthorizedKey(ctx context.Context, c *Client, publicKey string) error {
+	publicKey = strings.TrimSpace(publicKey)
+	if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey)); err != nil {
+		return fmt.Errorf("parse public key: %w", err)
+	}
+	if strings.ContainsAny(publicKey, "'\n") {
+		return errors.New("public key contains unsupported characters")
+	}
+	command := fmt.Sprintf(
+		`umask 077 && mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys && (grep -qxF '%[1]s' ~/.ssh/authorized_keys || printf '%%s\n' '%[1]s' >> ~/.ssh/authorized_keys)`,
+		publicKey,
+	)
+	if _, err := c.Run(ctx, command); err != nil {
+		return fmt.Errorf("install authorized key: %w", err)
+	}
+	return nil
+}
+
+// HostFacts is what a host reports about itself before it is configured.
+type HostFacts struct {
+	// OSID and OSVersion are ID and VERSION_ID from /etc/os-release.
+	OSID      string
+	OSVersion string
+	OSName    string
+	Arch      string
+	// Root is true when the login user is uid 0.
+	Root bool
+	// PasswordlessSudo is true when the login user may sudo without a
+	// password prompt.
+	PasswordlessSudo bool
+}
+
+// Image returns the OS in the "<id>-<version>" form server profiles use for
+// their base image, e.g. "ubuntu-24.04".
+func (f HostFacts) Image() string {
+	if f.OSID == "" || f.OSVersion == "" {
+		return ""
+	}
+	return f.OSID + "-" + f.OSVersion
+}
+
+// CanBecomeRoot reports whether configuration can escalate to root.
+func (f HostFacts) CanBecomeRoot() bool {
+	return f.Root || f.PasswordlessSudo
+}
+
+const inspectHostCommand = `. /etc/os-release 2>/dev/null
+printf 'os_id=%s\n' "$ID"
+printf 'os_version=%s\n' "$VERSION_ID"
+printf 'os_name=%s\n' "$PRETTY_NAME"
+printf 'arch=%s\n' "$(uname -m)"
+printf 'uid=%s\n' "$(id -u)"
+if sudo -n true 2>/dev/null; then echo sudo=yes; else echo sudo=no; fi`
+
+// InspectHost reads the facts needed to decide whether a host can be
+// configured.
+func InspectHost(ctx context.Context, c *Client) (HostFacts, error) {
+	out, err := c.Run(ctx, inspectHostCommand)
+	if err != nil {
+		return HostFacts{}, fmt.Errorf("inspect host: %w", err)
+	}
+	return parseHostFacts(out), nil
+}
+
+func parseHostFacts(out string) HostFacts {
+	var facts HostFacts
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "os_id":
+			facts.OSID = strings.ToLower(value)
+		case "os_version":
+			facts.OSVersion = value
+		case "os_name":
+			facts.OSName = value
+		case "arch":
+			facts.Arch = value
+		case "uid":
+			facts.Root = value == "0"
+		case "sudo":
+			facts.PasswordlessSudo = value == "yes"
+		}
+	}
+	return facts
+}
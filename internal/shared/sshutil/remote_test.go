@@ -0,0 +1,157 @@
+package sshutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"pressluft/internal/shared/sshutil/sshtest"
+)
+
+func newTestServer(t *testing.T, handler sshtest.Handler) *sshtest.Server {
+	t.Helper()
+	srv := sshtest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testTarget(srv *sshtest.Server) Target {
+	return Target{Host: srv.Host(), Port: srv.Port(), User: "deploy"}
+}
+
+func TestTargetAddressDefaultsPort(t *testing.T) {
+	if got := (Target{Host: "203.0.113.10"}).Address(); got != "203.0.113.10:22" {
+		t.Fatalf("Address() = %q", got)
+	}
+	if got := (Target{Host: "2001:db8::1", Port: 2222}).Address(); got != "[2001:db8::1]:2222" {
+		t.Fatalf("Address() = %q", got)
+	}
+}
+
+func TestDialWithPasswordInstallsKey(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.SetPassword("one-time")
+
+	pub, _, err := GenerateKeyPair("pressluft-server-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := Dial(context.Background(), testTarget(srv), PasswordAuth("one-time")...)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+	if client.HostKeyFingerprint != ssh.FingerprintSHA256(srv.HostKey()) {
+		t.Fatalf("HostKeyFingerprint = %q, want %q", client.HostKeyFingerprint, ssh.FingerprintSHA256(srv.HostKey()))
+	}
+
+	if err := InstallAuthorizedKey(context.Background(), client, pub); err != nil {
+		t.Fatalf("InstallAuthorizedKey() error = %v", err)
+	}
+	commands := srv.Commands()
+	if len(commands) != 1 || !strings.Contains(commands[0], "'"+pub+"'") || !strings.Contains(commands[0], ".ssh/authorized_keys") {
+		t.Fatalf("commands = %q", commands)
+	}
+}
+
+func TestDialRejectsWrongPassword(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.SetPassword("right")
+
+	if _, err := Dial(context.Background(), testTarget(srv), PasswordAuth("wrong")...); err == nil {
+		t.Fatal("expected handshake error")
+	}
+}
+
+func TestDialRejectsUnpinnedHostKeyBeforeSendingPassword(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.SetPassword("one-time")
+	target := testTarget(srv)
+
+	target.HostKeyFingerprint = "SHA256:" + strings.Repeat("A", 43)
+	if _, err := Dial(context.Background(), target, PasswordAuth("one-time")...); !errors.Is(err, ErrHostKeyMismatch) {
+		t.Fatalf("Dial() error = %v, want %v", err, ErrHostKeyMismatch)
+	}
+	if attempts := srv.PasswordAttempts(); attempts != 0 {
+		t.Fatalf("password attempts = %d, want none sent to the wrong host", attempts)
+	}
+
+	target.HostKeyFingerprint = ssh.FingerprintSHA256(srv.HostKey())
+	client, err := Dial(context.Background(), target, PasswordAuth("one-time")...)
+	if err != nil {
+		t.Fatalf("Dial() with the pinned key error = %v", err)
+	}
+	client.Close()
+}
+
+func TestInstallAuthorizedKeyRejectsInvalidKey(t *testing.T) {
+	if err := InstallAuthorizedKey(context.Background(), nil, "not a key"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestInspectHostWithPrivateKey(t *testing.T) {
+	srv := newTestServer(t, func(command string) (string, uint32) {
+		if command != inspectHostCommand {
+			return "", 1
+		}
+		return "os_id=ubuntu\nos_version=24.04\nos_name=Ubuntu 24.04.1 LTS\narch=x86_64\nuid=1000\nsudo=yes\n", 0
+	})
+	pub, priv, err := GenerateKeyPair("pressluft-server-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Authorize(pub); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := PrivateKeyAuth(priv)
+	if err != nil {
+		t.Fatalf("PrivateKeyAuth() error = %v", err)
+	}
+	client, err := Dial(context.Background(), testTarget(srv), auth)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	facts, err := InspectHost(context.Background(), client)
+	if err != nil {
+		t.Fatalf("InspectHost() error = %v", err)
+	}
+	if facts.Image() != "ubuntu-24.04" || facts.OSName != "Ubuntu 24.04.1 LTS" || facts.Arch != "x86_64" {
+		t.Fatalf("facts = %+v", facts)
+	}
+	if facts.Root || !facts.PasswordlessSudo || !facts.CanBecomeRoot() {
+		t.Fatalf("privilege facts = %+v", facts)
+	}
+}
+
+func TestRunReportsNonZeroExit(t *testing.T) {
+	srv := newTestServer(t, func(string) (string, uint32) { return "", 3 })
+	srv.SetPassword("pw")
+	client, err := Dial(context.Background(), testTarget(srv), PasswordAuth("pw")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.Run(context.Background(), "false"); err == nil {
+		t.Fatal("expected exit status error")
+	}
+}
+
+func TestParseHostFactsWithoutSudo(t *testing.T) {
+	facts := parseHostFacts("os_id=Debian\nos_version=12\nuid=1001\nsudo=no\n")
+	if facts.Image() != "debian-12" {
+		t.Fatalf("Image() = %q", facts.Image())
+	}
+	if facts.CanBecomeRoot() {
+		t.Fatal("expected no way to become root")
+	}
+	if (HostFacts{OSID: "ubuntu"}).Image() != "" {
+		t.Fatal("expected empty image without a version")
+	}
+}
@@ -0,0 +1,198 @@
+// Package sshtest provides an in-process stand-in for an SSH server. It
+// authenticates with a password or authorized keys and answers exec requests
+// through a handler instead of running anything, and is intended for tests
+// only.
+package sshtest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Handler answers one exec request with stdout and an exit status.
+type Handler func(command string) (stdout string, status uint32)
+
+// Server listens on a loopback port until Close.
+type Server struct {
+	listener net.Listener
+	hostKey  ssh.PublicKey
+	handler  Handler
+
+	mu         sync.Mutex
+	password   string
+	attempts   int
+	authorized []ssh.PublicKey
+	commands   []string
+	wg         sync.WaitGroup
+}
+
+// NewServer starts a stand-in on a random loopback port. A nil handler
+// succeeds every command with no output.
+func NewServer(handler Handler) *Server {
+	if handler == nil {
+		handler = func(string) (string, uint32) { return "", 0 }
+	}
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("sshtest: host key: %v", err))
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		panic(fmt.Sprintf("sshtest: host key: %v", err))
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("sshtest: listen: %v", err))
+	}
+	s := &Server{listener: listener, hostKey: signer.PublicKey(), handler: handler}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.attempts++
+			if s.password != "" && string(password) == s.password {
+				return nil, nil
+			}
+			return nil, errors.New("wrong password")
+		},
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, authorized := range s.authorized {
+				if string(authorized.Marshal()) == string(key.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(signer)
+
+	s.wg.Add(1)
+	go s.serve(config)
+	return s
+}
+
+// Host is the address clients should dial.
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port is the port clients should dial.
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// HostKey is the key the stand-in presents.
+func (s *Server) HostKey() ssh.PublicKey {
+	return s.hostKey
+}
+
+// SetPassword makes the stand-in accept password logins with password.
+func (s *Server) SetPassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// PasswordAttempts counts the passwords clients have sent.
+func (s *Server) PasswordAttempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts
+}
+
+// Authorize makes the stand-in accept logins with the key in authorized_keys
+// format.
+func (s *Server) Authorize(authorizedKey string) error {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(authorizedKey))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorized = append(s.authorized, key)
+	return nil
+}
+
+// Commands returns the commands executed so far.
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Close stops listening and waits for open connections to end.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve(config *ssh.ServerConfig) {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn, config)
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		s.handleSession(channel, requests)
+	}
+}
+
+func (s *Server) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		s.mu.Lock()
+		s.commands = append(s.commands, payload.Command)
+		s.mu.Unlock()
+
+		stdout, status := s.handler(payload.Command)
+		_, _ = channel.Write([]byte(stdout))
+		exit := make([]byte, 4)
+		binary.BigEndian.PutUint32(exit, status)
+		_, _ = channel.SendRequest("exit-status", false, exit)
+		return
+	}
+}
@@ -0,0 +1,44 @@
+// Package workspace carries the workspace a request acts in. Stores read it
+// from the context to keep every query inside that workspace; contexts
+// without one belong to the control plane itself (workers, schedulers,
+// agents) and see every workspace.
+package workspace
+
+import "context"
+
+// DefaultID is the workspace that existing data and single-tenant installs
+// live in.
+const DefaultID = "00000000-0000-7000-8000-000000000001"
+
+type contextKey struct{}
+
+// WithID scopes ctx to a workspace.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFromContext returns the workspace ctx is scoped to, if any.
+func IDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Filter is the workspace queries must stay in, or "" for unscoped
+// control-plane contexts. Stores pass it twice to a
+// `(? = ” OR workspace_id = ?)` condition.
+func Filter(ctx context.Context) string {
+	id, _ := IDFromContext(ctx)
+	return id
+}
+
+// ForWrite is the workspace new top-level records are created in: the
+// request's workspace, or the default one for control-plane contexts.
+func ForWrite(ctx context.Context) string {
+	if id, ok := IDFromContext(ctx); ok {
+		return id
+	}
+	return DefaultID
+}
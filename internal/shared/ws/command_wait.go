@@ -3,6 +3,7 @@ package ws
 import (
 	"context"
 	"errors"
+	"time"
 
 	"pressluft/internal/agent/agentcommand"
 )
@@ -36,16 +37,48 @@ func (h *Hub) SendCommandAndWait(ctx context.Context, serverID string, cmd Comma
 	}
 	env := Envelope{Type: TypeCommand, Payload: payload}
 
+	started := time.Now()
 	if err := conn.Send(ctx, env); err != nil {
 		waiter.Cancel(cmd.ID)
+		h.observeCommand(cmd.Type, "send_failed", started)
 		return CommandResult{}, err
 	}
 
 	select {
 	case result := <-ch:
+		outcome := "success"
+		if !result.Success {
+			outcome = "failure"
+		}
+		h.observeCommand(cmd.Type, outcome, started)
 		return result, nil
 	case <-ctx.Done():
 		waiter.Cancel(cmd.ID)
+		h.observeCommand(cmd.Type, "timeout", started)
 		return CommandResult{}, ctx.Err()
 	}
 }
+
+func (h *Hub) observeCommand(commandType, outcome string, started time.Time) {
+	if observer := h.commandObserver(); observer != nil {
+		observer.ObserveCommand(commandType, outcome, time.Since(started))
+	}
+}
+
+// CancelCommand tells the agent on serverID to abort a running command.
+func (h *Hub) CancelCommand(ctx context.Context, serverID string, cancel CommandCancel) error {
+	if cancel.CommandID == "" {
+		return errors.New("command id is required")
+	}
+
+	conn, ok := h.Get(serverID)
+	if !ok {
+		return errors.New("agent not connected")
+	}
+
+	payload, err := marshalJSON(cancel)
+	if err != nil {
+		return err
+	}
+	return conn.Send(ctx, Envelope{Type: TypeCommandCancel, Payload: payload})
+}
@@ -8,10 +8,17 @@ import (
 	"pressluft/internal/platform"
 )
 
+// CommandObserver is told how long every command sent through
+// SendCommandAndWait took to come back, and how it ended.
+type CommandObserver interface {
+	ObserveCommand(commandType, outcome string, duration time.Duration)
+}
+
 type Hub struct {
-	conns  map[string]*Conn
-	mu     sync.RWMutex
-	waiter *ResultWaiter
+	conns    map[string]*Conn
+	mu       sync.RWMutex
+	waiter   *ResultWaiter
+	observer CommandObserver
 }
 
 func NewHub() *Hub {
@@ -26,6 +33,19 @@ func (h *Hub) SetResultWaiter(waiter *ResultWaiter) {
 	h.waiter = waiter
 }
 
+// SetCommandObserver registers who hears about command round trips.
+func (h *Hub) SetCommandObserver(observer CommandObserver) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observer = observer
+}
+
+func (h *Hub) commandObserver() CommandObserver {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.observer
+}
+
 func (h *Hub) resultWaiter() *ResultWaiter {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -11,7 +11,13 @@ import (
 
 type ServerStore interface {
 	UpdateNodeStatus(ctx context.Context, serverID string, status platform.NodeStatus, lastSeen, version string) error
-	MarkNodesOfflineBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	MarkNodesOfflineBefore(ctx context.Context, cutoff time.Time) ([]string, error)
+}
+
+// OfflineNotifier is told about every node the monitor takes offline,
+// whether its connection timed out or it was already gone.
+type OfflineNotifier interface {
+	NodeOffline(ctx context.Context, serverID string)
 }
 
 type Monitor struct {
@@ -19,6 +25,7 @@ type Monitor struct {
 	store              ServerStore
 	unhealthyThreshold time.Duration
 	offlineThreshold   time.Duration
+	notifier           OfflineNotifier
 	logger             *slog.Logger
 }
 
@@ -32,6 +39,11 @@ func NewMonitor(hub *Hub, store ServerStore, logger *slog.Logger) *Monitor {
 	}
 }
 
+// SetOfflineNotifier registers who hears about nodes going offline.
+func (m *Monitor) SetOfflineNotifier(notifier OfflineNotifier) {
+	m.notifier = notifier
+}
+
 func (m *Monitor) Start(ctx context.Context) {
 	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
@@ -61,6 +73,7 @@ func (m *Monitor) checkConnections() {
 			}
 			conn.Close()
 			m.hub.Unregister(serverID)
+			m.notifyOffline(serverID)
 		} else if elapsed > m.unhealthyThreshold {
 			m.logger.Debug("node health transitioned", observability.Correlation{ServerID: serverID}.LogArgs("node_status", platform.NodeStatusUnhealthy, "reason", "heartbeat_degraded", "elapsed", elapsed)...)
 			if m.store != nil {
@@ -74,8 +87,17 @@ func (m *Monitor) checkConnections() {
 	if m.store != nil {
 		if marked, err := m.store.MarkNodesOfflineBefore(context.Background(), now.Add(-m.offlineThreshold)); err != nil {
 			m.logger.Error("stale node offline sweep failed", "error", err)
-		} else if marked > 0 {
-			m.logger.Info("stale node offline sweep completed", "marked_offline", marked)
+		} else if len(marked) > 0 {
+			m.logger.Info("stale node offline sweep completed", "marked_offline", len(marked))
+			for _, serverID := range marked {
+				m.notifyOffline(serverID)
+			}
 		}
 	}
 }
+
+func (m *Monitor) notifyOffline(serverID string) {
+	if m.notifier != nil {
+		m.notifier.NodeOffline(context.Background(), serverID)
+	}
+}
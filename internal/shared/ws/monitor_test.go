@@ -13,6 +13,7 @@ import (
 type monitorStore struct {
 	updates      []nodeStatusUpdate
 	offlineCalls int
+	swept        []string
 }
 
 func (s *monitorStore) UpdateNodeStatus(_ context.Context, serverID string, status platform.NodeStatus, lastSeen, version string) error {
@@ -20,15 +21,25 @@ func (s *monitorStore) UpdateNodeStatus(_ context.Context, serverID string, stat
 	return nil
 }
 
-func (s *monitorStore) MarkNodesOfflineBefore(_ context.Context, cutoff time.Time) (int64, error) {
+func (s *monitorStore) MarkNodesOfflineBefore(_ context.Context, cutoff time.Time) ([]string, error) {
 	s.offlineCalls++
-	return 0, nil
+	return s.swept, nil
+}
+
+type offlineRecorder struct {
+	serverIDs []string
+}
+
+func (r *offlineRecorder) NodeOffline(_ context.Context, serverID string) {
+	r.serverIDs = append(r.serverIDs, serverID)
 }
 
 func TestMonitorMarksStaleConnectionsUnhealthyAndOffline(t *testing.T) {
 	hub := NewHub()
-	store := &monitorStore{}
+	store := &monitorStore{swept: []string{"3"}}
 	monitor := NewMonitor(hub, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	notifier := &offlineRecorder{}
+	monitor.SetOfflineNotifier(notifier)
 
 	unhealthyConn := &Conn{serverID: "1", lastSeen: time.Now().Add(-50 * time.Second), version: "v1"}
 	offlineConn := &Conn{serverID: "2", lastSeen: time.Now().Add(-3 * time.Minute), version: "v2"}
@@ -46,4 +57,9 @@ func TestMonitorMarksStaleConnectionsUnhealthyAndOffline(t *testing.T) {
 	if store.offlineCalls != 1 {
 		t.Fatalf("offlineCalls = %d, want 1", store.offlineCalls)
 	}
+	// The timed-out connection and the node swept without one are both
+	// reported; the merely unhealthy one is not.
+	if len(notifier.serverIDs) != 2 || notifier.serverIDs[0] != "2" || notifier.serverIDs[1] != "3" {
+		t.Fatalf("offline notifications = %v, want [2 3]", notifier.serverIDs)
+	}
 }
@@ -14,6 +14,7 @@ const (
 	TypeHeartbeatAck  MessageType = "heartbeat_ack"
 	TypeCommand       MessageType = "command"
 	TypeCommandResult MessageType = "command_result"
+	TypeCommandCancel MessageType = "command_cancel"
 	TypeLogEntry      MessageType = "log_entry"
 )
 
@@ -49,6 +50,13 @@ type Command struct {
 	Payload  json.RawMessage `json:"payload"`
 }
 
+// CommandCancel asks an agent to abort a command it is still running. The
+// agent still reports a result for the aborted command.
+type CommandCancel struct {
+	CommandID string `json:"command_id"`
+	JobID     string `json:"job_id,omitempty"`
+}
+
 type CommandResult struct {
 	CommandID string          `json:"command_id"`
 	JobID     string          `json:"job_id,omitempty"`
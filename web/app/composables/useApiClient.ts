@@ -1,15 +1,23 @@
+// workspaceCookieName remembers the workspace the dashboard acts in. The API
+// client sends it as the X-Pressluft-Workspace header.
+export const workspaceCookieName = 'pressluft_workspace'
+
 export function useApiClient() {
   const config = useRuntimeConfig()
+  const workspaceID = useCookie<string | null>(workspaceCookieName, { sameSite: 'lax' })
 
   const normalizePath = (path: string) => (path.startsWith('/') ? path : `/${path}`)
 
   const apiPath = (path: string) => `${config.public.apiBase}${normalizePath(path)}`
 
-  const apiFetch = async <T>(
+  const request = async <T>(
     path: string,
-    options: Parameters<typeof $fetch<T>>[1] = {},
+    options: Parameters<typeof $fetch<T>>[1],
   ) => {
-    const requestHeaders = import.meta.server ? useRequestHeaders(['cookie']) : undefined
+    const requestHeaders: Record<string, string> = import.meta.server ? { ...useRequestHeaders(['cookie']) } : {}
+    if (workspaceID.value) {
+      requestHeaders['X-Pressluft-Workspace'] = workspaceID.value
+    }
     return await $fetch<T>(normalizePath(path), {
       baseURL: config.public.apiBase,
       credentials: 'include',
@@ -18,6 +26,24 @@ export function useApiClient() {
     })
   }
 
+  const apiFetch = async <T>(
+    path: string,
+    options: Parameters<typeof $fetch<T>>[1] = {},
+  ) => {
+    try {
+      return await request<T>(path, options)
+    } catch (e: unknown) {
+      // A remembered workspace the user has since left: fall back to their
+      // default workspace instead of failing every request.
+      const response = (e as { statusCode?: number, data?: { error?: string } } | null)
+      if (workspaceID.value && response?.statusCode === 403 && response.data?.error === 'no access to this workspace') {
+        workspaceID.value = null
+        return await request<T>(path, options)
+      }
+      throw e
+    }
+  }
+
   return {
     apiFetch,
     apiPath,
@@ -1,11 +1,11 @@
 import { computed } from 'vue'
-import type { AuthActor } from '~/lib/api-contract'
+import type { AuthActor, LoginChallengeResponse, LoginMethods } from '~/lib/api-contract'
 import { parseAuthActor } from '~/lib/api-runtime'
 
 export function useAuth() {
   const user = useState<AuthActor | null>('auth-user', () => null)
   const initialized = useState<boolean>('auth-initialized', () => false)
-  const { apiFetch } = useApiClient()
+  const { apiFetch, apiPath } = useApiClient()
 
   const fetchMe = async () => {
     try {
@@ -20,16 +20,63 @@ export function useAuth() {
     }
   }
 
-  const login = async (email: string, password: string) => {
-    const actor = parseAuthActor(await apiFetch('/auth/login', {
+  // login resolves to the actor, or to the challenge for the TOTP step when
+  // the user has two-factor authentication enabled.
+  const login = async (email: string, password: string): Promise<AuthActor | LoginChallengeResponse> => {
+    const payload = await apiFetch<unknown>('/auth/login', {
       method: 'POST',
       body: { email, password },
+    })
+    if ((payload as LoginChallengeResponse | null)?.totp_required) {
+      return payload as LoginChallengeResponse
+    }
+    const actor = parseAuthActor(payload)
+    user.value = actor
+    initialized.value = true
+    return actor
+  }
+
+  const loginWithCode = async (challenge: string, code: string) => {
+    const actor = parseAuthActor(await apiFetch('/auth/login/totp', {
+      method: 'POST',
+      body: { challenge, code },
     }))
     user.value = actor
     initialized.value = true
     return actor
   }
 
+  const loginMethods = async (): Promise<LoginMethods> => {
+    try {
+      return await apiFetch<LoginMethods>('/auth/methods')
+    } catch {
+      return { password: true, oidc: false }
+    }
+  }
+
+  // Single sign-on is a full-page navigation through the identity provider,
+  // which redirects back to the control plane with a session.
+  const oidcLoginURL = (redirect: string) =>
+    `${apiPath('/auth/oidc/login')}?redirect=${encodeURIComponent(redirect)}`
+
+  // acceptInvitation sets the invited user's password and signs them in.
+  const acceptInvitation = async (token: string, password: string) => {
+    const actor = parseAuthActor(await apiFetch('/auth/invitations/accept', {
+      method: 'POST',
+      body: { token, password },
+    }))
+    user.value = actor
+    initialized.value = true
+    return actor
+  }
+
+  const resetPassword = async (token: string, password: string) => {
+    await apiFetch('/auth/password/reset', {
+      method: 'POST',
+      body: { token, password },
+    })
+  }
+
   const logout = async () => {
     await apiFetch('/auth/logout', { method: 'POST' })
     user.value = null
@@ -42,6 +89,11 @@ export function useAuth() {
     isAuthenticated: computed(() => !!user.value?.authenticated),
     fetchMe,
     login,
+    loginWithCode,
+    loginMethods,
+    oidcLoginURL,
+    acceptInvitation,
+    resetPassword,
     logout,
   }
 }
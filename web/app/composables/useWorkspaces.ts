@@ -0,0 +1,50 @@
+import { ref, readonly } from 'vue'
+import type { Workspace } from '~/lib/api-contract'
+import { errorMessage } from '~/lib/utils'
+import { workspaceCookieName } from './useApiClient'
+export type { Workspace } from '~/lib/api-contract'
+
+const workspaces = ref<Workspace[]>([])
+const error = ref('')
+
+export function useWorkspaces() {
+  const { apiFetch } = useApiClient()
+  const current = useCookie<string | null>(workspaceCookieName, { sameSite: 'lax' })
+
+  const fetchWorkspaces = async () => {
+    error.value = ''
+    try {
+      workspaces.value = await apiFetch<Workspace[]>('/workspaces')
+    } catch (e: unknown) {
+      error.value = errorMessage(e)
+    }
+  }
+
+  const createWorkspace = async (name: string) => {
+    const workspace = await apiFetch<Workspace>('/workspaces', {
+      method: 'POST',
+      body: { name },
+    })
+    workspaces.value = [...workspaces.value, workspace]
+    return workspace
+  }
+
+  // switchWorkspace makes every later API request act in the workspace.
+  // Everything loaded so far belongs to the previous one, so the dashboard
+  // reloads.
+  const switchWorkspace = (id: string) => {
+    current.value = id
+    if (import.meta.client) {
+      window.location.assign('/')
+    }
+  }
+
+  return {
+    workspaces: readonly(workspaces),
+    current: readonly(current),
+    error: readonly(error),
+    fetchWorkspaces,
+    createWorkspace,
+    switchWorkspace,
+  }
+}
@@ -9,8 +9,23 @@ import type {
   SupportLevel,
 } from '~/lib/platform-contract.generated'
 
-export type Capability = "manage_providers" | "manage_servers" | "manage_sites" | "queue_jobs" | "read_activity"
-export type Role = 'admin'
+export type Capability = "delete_servers" | "manage_backups" | "manage_providers" | "manage_servers" | "manage_sites" | "manage_users" | "manage_webhooks" | "queue_jobs" | "read_activity" | "read_jobs" | "read_servers" | "read_sites"
+export type Role = "owner" | "admin" | "developer" | "viewer" | "client"
+
+export interface APIToken {
+  id: string
+  user_id: string
+  kind: string
+  name: string
+  role: Role
+  scopes: Capability[]
+  prefix: string
+  created_at: string
+  expires_at: string
+  last_used_at?: string
+  last_used_ip?: string
+  revoked_at?: string
+}
 
 export interface Activity {
   id: string
@@ -36,6 +51,11 @@ export interface ActivityListResponse {
   next_cursor?: string
 }
 
+export interface AddWorkspaceMemberRequest {
+  email: string
+  role: Role
+}
+
 export interface AgentInfo {
   connected: boolean
   status: NodeStatus
@@ -56,6 +76,81 @@ export interface AuthActor {
   capabilities?: Capability[]
   authenticated: boolean
   auth_source?: string
+  workspace_id?: string
+  scoped?: boolean
+  site_ids?: string[]
+  token_id?: string
+  scopes?: Capability[]
+  totp_enabled?: boolean
+  totp_enrollment_required?: boolean
+}
+
+export interface AuthPolicy {
+  require_totp: boolean
+  updated_at?: string
+}
+
+export interface BackupTarget {
+  id: string
+  name: string
+  endpoint: string
+  region: string
+  bucket: string
+  path_prefix?: string
+  path_style: boolean
+  access_key_id: string
+  retention_days: number
+  created_at: string
+  updated_at: string
+}
+
+export interface ChangePasswordRequest {
+  current_password: string
+  new_password: string
+}
+
+export interface ConnectServerRequest {
+  ssh_host_key_fingerprint?: string
+  password?: string
+}
+
+export interface CostReport {
+  month: string
+  generated_at: string
+  totals: CostTotal[]
+  workspaces: WorkspaceCost[]
+  servers: ServerCost[]
+  sites: SiteCost[]
+}
+
+export interface CostTotal {
+  currency: string
+  amount: string
+}
+
+export interface CreateAPITokenRequest {
+  name: string
+  kind?: string
+  role?: Role
+  scopes?: Capability[]
+  expires_in_days?: number
+}
+
+export interface CreateAPITokenResponse {
+  token: APIToken
+  secret: string
+}
+
+export interface CreateBackupTargetRequest {
+  name: string
+  endpoint: string
+  region?: string
+  bucket: string
+  path_prefix?: string
+  path_style: boolean
+  access_key_id: string
+  secret_access_key: string
+  retention_days?: number
 }
 
 export interface CreateDomainRequest {
@@ -89,18 +184,62 @@ export interface CreateProviderResponse {
   validation: ValidationResult
 }
 
+export interface CreateScheduleRequest {
+  name: string
+  job_kind: string
+  server_id?: string
+  payload?: unknown
+  cron_expression: string
+  timezone?: string
+  jitter_seconds?: number
+  missed_run_policy?: string
+  paused: boolean
+}
+
 export interface CreateServerRequest {
   provider_id: string
   name: string
   location: string
   server_type: string
   profile_key: string
+  ipv4?: string
+  ssh_port?: number
+  ssh_user?: string
+  ssh_host_key_fingerprint?: string
+  password?: string
 }
 
 export interface CreateServerResponse {
   server_id: string
   job_id: string
   status: ServerStatus
+  ssh_public_key?: string
+}
+
+export interface CreateSiteBackupRequest {
+  target_id: string
+  retention_days?: number
+}
+
+export interface CreateSiteBackupResponse {
+  site_id: string
+  job_id: string
+  job_status: JobStatus
+}
+
+export interface CreateSitePushRequest {
+  scope?: string
+  include_tables?: string[]
+  exclude_tables?: string[]
+  dry_run?: boolean
+}
+
+export interface CreateSitePushResponse {
+  site_id: string
+  target_site_id: string
+  job_id: string
+  job_status: JobStatus
+  dry_run: boolean
 }
 
 export interface CreateSiteRequest {
@@ -115,12 +254,71 @@ export interface CreateSiteRequest {
   wordpress_version?: string
 }
 
+export interface CreateSiteRestoreRequest {
+  backup_id: string
+}
+
+export interface CreateSiteRestoreResponse {
+  site_id: string
+  backup_id: string
+  job_id: string
+  job_status: JobStatus
+}
+
+export interface CreateSiteUpdateRequest {
+  core?: boolean
+  plugins?: string[]
+  themes?: string[]
+  check_paths?: string[]
+}
+
+export interface CreateSiteUpdateResponse {
+  site_id: string
+  job_id: string
+  job_status: JobStatus
+}
+
+export interface CreateStagingRequest {
+  name?: string
+  label?: string
+}
+
+export interface CreateStagingResponse {
+  site: StoredSite
+  parent_site_id: string
+  job_id: string
+  job_status: JobStatus
+  basic_auth_username: string
+  basic_auth_password: string
+}
+
+export interface CreateWebhookSubscriptionResponse {
+  subscription: WebhookSubscription
+  secret: string
+}
+
+export interface CreateWorkspaceRequest {
+  name: string
+}
+
+export interface DeleteBackupTargetResponse {
+  target_id: string
+  deleted: boolean
+  description: string
+}
+
 export interface DeleteDomainResponse {
   domain_id: string
   deleted: boolean
   description: string
 }
 
+export interface DeleteScheduleResponse {
+  schedule_id: string
+  deleted: boolean
+  description: string
+}
+
 export interface DeleteServerResponse {
   server_id: string
   job_id: string
@@ -145,6 +343,13 @@ export interface HealthResponse {
   status: string
   callback_url_mode?: CallbackURLMode
   callback_url_warning?: string
+  worker?: WorkerHealth
+}
+
+export interface InviteUserRequest {
+  email: string
+  role: Role
+  expires_in_days?: number
 }
 
 export interface Job {
@@ -162,12 +367,14 @@ export interface Job {
   created_at: string
   updated_at: string
   command_id?: string
+  next_attempt_at?: string
 }
 
 export interface JobEvent {
   id: string
   job_id: string
   seq: number
+  attempt: number
   event_type: string
   level: string
   step_key?: string
@@ -177,11 +384,45 @@ export interface JobEvent {
   occurred_at: string
 }
 
+export interface LoginChallengeResponse {
+  totp_required: boolean
+  challenge: string
+  expires_at: string
+}
+
+export interface LoginMethods {
+  password: boolean
+  oidc: boolean
+  oidc_name?: string
+}
+
 export interface LoginRequest {
   email: string
   password: string
 }
 
+export interface LoginTOTPRequest {
+  challenge: string
+  code: string
+}
+
+export interface NotificationPreferences {
+  email_enabled: boolean
+  event_types: string[]
+  delivery: string
+  available_event_types: string[]
+  email_configured: boolean
+}
+
+export interface ProviderActionEvent {
+  provider: string
+  action: string
+  action_id: string
+  status: string
+  progress: number
+  error?: string
+}
+
 export interface ProviderType {
   type: string
   name: string
@@ -190,6 +431,12 @@ export interface ProviderType {
   description: string
 }
 
+export interface PushSiteDiff {
+  dry_run: boolean
+  files?: { added: number; changed: number; deleted: number; sample?: string[] }
+  tables?: { name: string; source_rows: number; target_rows: number; selected: boolean }[]
+}
+
 export interface RebuildOptionsResponse {
   server_id: string
   server_type: string
@@ -205,6 +452,29 @@ export interface ResizeOptionsResponse {
   server_types: ServerTypeOption[]
 }
 
+export interface RevokeSessionsResponse {
+  revoked: number
+}
+
+export interface Schedule {
+  id: string
+  name: string
+  job_kind: string
+  server_id?: string
+  payload?: string
+  cron_expression: string
+  timezone: string
+  jitter_seconds: number
+  missed_run_policy: string
+  paused: boolean
+  next_run_at?: string
+  last_run_at?: string
+  last_job_id?: string
+  last_error?: string
+  created_at: string
+  updated_at: string
+}
+
 export interface ServerCatalog {
   locations: ServerLocation[]
   server_types: ServerTypeOption[]
@@ -215,6 +485,19 @@ export interface ServerCatalogResponse {
   profiles: ServerProfile[]
 }
 
+export interface ServerCost {
+  server_id: string
+  server_name: string
+  provider_type: string
+  server_type: string
+  workspace_id: string
+  workspace_name: string
+  currency: string
+  hours: number
+  amount: string
+  sites: number
+}
+
 export interface ServerLocation {
   name: string
   description: string
@@ -234,6 +517,14 @@ export interface ServerProfile {
   support_reason?: string
 }
 
+export interface ServerSSHKeyResponse {
+  server_id: string
+  public_key: string
+  ssh_user: string
+  ssh_port: number
+  install_command: string
+}
+
 export interface ServerTypeOption {
   name: string
   description: string
@@ -265,6 +556,66 @@ export interface ServicesResponse {
   services: Service[]
 }
 
+export interface SetPasswordRequest {
+  token: string
+  password: string
+}
+
+export interface SiteBackup {
+  id: string
+  site_id: string
+  server_id: string
+  target_id: string
+  target_name: string
+  job_id?: string
+  status: string
+  object_key: string
+  size_bytes: number
+  checksum_sha256?: string
+  retention_days: number
+  expires_at?: string
+  last_error?: string
+  started_at?: string
+  completed_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface SiteComponent {
+  site_id: string
+  site_name?: string
+  server_id?: string
+  type: string
+  name: string
+  title?: string
+  status?: string
+  version: string
+  update_version?: string
+  checked_at: string
+}
+
+export interface SiteComponentsResponse {
+  site_id: string
+  checked_at?: string
+  components: SiteComponent[]
+}
+
+export interface SiteCost {
+  site_id: string
+  site_name: string
+  server_id: string
+  server_name: string
+  workspace_id: string
+  workspace_name: string
+  currency: string
+  amount: string
+}
+
+export interface SiteGrants {
+  user_id: string
+  site_ids: string[]
+}
+
 export interface SiteHealthCheck {
   name: string
   ok: boolean
@@ -289,6 +640,39 @@ export interface SiteHealthSnapshot {
   services?: Service[]
   checks?: SiteHealthCheck[]
   recent_errors?: string[]
+  pages?: { path: string; status_code: number; bytes: number; title?: string }[]
+}
+
+export interface SiteUptimeResponse {
+  site_id: string
+  enabled: boolean
+  keyword?: string
+  hostnames: UptimeHostname[]
+  open_incidents: UptimeIncident[]
+}
+
+export interface SiteVulnerabilitiesResponse {
+  site_id: string
+  scanned_at?: string
+  vulnerabilities: SiteVulnerability[]
+}
+
+export interface SiteVulnerability {
+  site_id: string
+  site_name?: string
+  server_id?: string
+  vulnerability_id: string
+  component_type: string
+  component_name: string
+  installed_version: string
+  title: string
+  description?: string
+  severity: string
+  score?: number
+  fixed_in?: string
+  references?: string[]
+  first_seen_at: string
+  scanned_at: string
 }
 
 export interface StatusResponse {
@@ -344,6 +728,9 @@ export interface StoredServer {
   node_status?: NodeStatus
   node_last_seen?: string
   node_version?: string
+  ssh_port: number
+  ssh_user: string
+  ssh_host_key_fingerprint?: string
   created_at: string
   updated_at: string
 }
@@ -366,14 +753,41 @@ export interface StoredSite {
   wordpress_path?: string
   php_version?: string
   wordpress_version?: string
+  environment: string
+  parent_site_id?: string
+  basic_auth_username?: string
   created_at: string
   updated_at: string
 }
 
+export interface TOTPCodeRequest {
+  code: string
+}
+
+export interface TOTPEnrollmentResponse {
+  secret: string
+  provisioning_uri: string
+}
+
+export interface TOTPRecoveryCodesResponse {
+  recovery_codes: string[]
+}
+
+export interface TOTPStatus {
+  enabled: boolean
+  enabled_at?: string
+  recovery_codes_remaining: number
+  required_by_policy: boolean
+}
+
 export interface UnreadCountResponse {
   count: number
 }
 
+export interface UpdateAuthPolicyRequest {
+  require_totp: boolean
+}
+
 export interface UpdateDomainRequest {
   hostname?: string
   kind?: string
@@ -388,6 +802,25 @@ export interface UpdateDomainRequest {
   is_primary?: boolean
 }
 
+export interface UpdateNotificationPreferencesRequest {
+  email_enabled: boolean
+  event_types: string[]
+  delivery?: string
+}
+
+export interface UpdateScheduleRequest {
+  name?: string
+  payload?: unknown
+  cron_expression?: string
+  timezone?: string
+  jitter_seconds?: number
+  missed_run_policy?: string
+}
+
+export interface UpdateSiteGrantsRequest {
+  site_ids: string[]
+}
+
 export interface UpdateSiteRequest {
   server_id?: string
   name?: string
@@ -399,6 +832,76 @@ export interface UpdateSiteRequest {
   wordpress_version?: string
 }
 
+export interface UpdateUptimeSettingsRequest {
+  enabled?: boolean
+  keyword: string
+}
+
+export interface UpdateUserRequest {
+  role?: Role
+  status?: string
+}
+
+export interface UptimeCheck {
+  hostname: string
+  checked_at: string
+  up: boolean
+  status_code?: number
+  latency_ms: number
+  tls_expires_at?: string
+  keyword_match?: boolean
+  error?: string
+}
+
+export interface UptimeHostname {
+  hostname: string
+  last_check?: UptimeCheck
+  windows: UptimeWindow[]
+}
+
+export interface UptimeIncident {
+  id: string
+  hostname: string
+  cause: string
+  started_at: string
+  resolved_at?: string
+}
+
+export interface UptimeWindow {
+  window: string
+  checks: number
+  uptime_percent: number
+  avg_latency_ms: number
+}
+
+export interface User {
+  id: string
+  email: string
+  role: Role
+  status: string
+  created_at: string
+  updated_at: string
+  last_login_at?: string
+}
+
+export interface UserAccountLink {
+  user: User
+  token: string
+  url: string
+  expires_at: string
+}
+
+export interface UserSession {
+  id: string
+  created_at: string
+  last_used_at?: string
+  expires_at: string
+  user_agent?: string
+  ip?: string
+  auth_method: string
+  current: boolean
+}
+
 export interface ValidateProviderRequest {
   type: string
   api_token: string
@@ -416,3 +919,90 @@ export interface VolumesResponse {
   volumes: { id: number; name: string; size_gb: number; location: string; status: string; server_id?: number }[]
 }
 
+export interface VulnerabilityReport {
+  affected_sites: number
+  counts: VulnerabilitySeverityCounts
+  vulnerabilities: SiteVulnerability[]
+}
+
+export interface VulnerabilitySeverityCounts {
+  critical: number
+  high: number
+  medium: number
+  low: number
+  unknown: number
+}
+
+export interface WebhookDelivery {
+  id: string
+  subscription_id: string
+  activity_id?: string
+  event_type: string
+  status: string
+  attempts: number
+  next_attempt_at?: string
+  last_attempt_at?: string
+  response_status?: number
+  last_error?: string
+  created_at: string
+  delivered_at?: string
+}
+
+export interface WebhookSubscription {
+  id: string
+  name: string
+  url: string
+  event_types: string[]
+  categories: string[]
+  resource_type?: string
+  resource_id?: string
+  enabled: boolean
+  created_at: string
+  updated_at: string
+}
+
+export interface WebhookSubscriptionRequest {
+  name: string
+  url: string
+  event_types?: string[]
+  categories?: string[]
+  resource_type?: string
+  resource_id?: string
+  enabled?: boolean
+}
+
+export interface WorkerHealth {
+  running: number
+  max_jobs: number
+  max_jobs_per_provider: number
+  max_jobs_per_server: number
+  busy_providers: number
+  busy_servers: number
+}
+
+export interface WorkerSlotUsage {
+  running: number
+  max_jobs: number
+  max_jobs_per_provider: number
+  max_jobs_per_server: number
+  providers: Record<string, number>
+  servers: Record<string, number>
+}
+
+export interface Workspace {
+  id: string
+  name: string
+  slug: string
+  role: Role
+  created_at: string
+}
+
+export interface WorkspaceCost {
+  workspace_id: string
+  workspace_name: string
+  currency: string
+  servers: number
+  sites: number
+  amount: string
+}
+
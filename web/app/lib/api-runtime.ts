@@ -35,10 +35,17 @@ const authActorSchema = z.object({
   id: z.string(),
   type: z.string(),
   email: z.string(),
-  role: z.literal("admin"),
+  role: z.enum(["owner", "admin", "developer", "viewer", "client"]),
   capabilities: z.array(z.string()).optional(),
   authenticated: z.boolean(),
   auth_source: z.string().optional(),
+  workspace_id: z.string().optional(),
+  scoped: z.boolean().optional(),
+  site_ids: z.array(z.string()).optional(),
+  token_id: z.string().optional(),
+  scopes: z.array(z.string()).optional(),
+  totp_enabled: z.boolean().optional(),
+  totp_enrollment_required: z.boolean().optional(),
 });
 
 const storedServerSchema = z.object({
@@ -212,12 +219,14 @@ const jobSchema = z.object({
   created_at: z.string(),
   updated_at: z.string(),
   command_id: z.string().optional(),
+  next_attempt_at: z.string().optional(),
 });
 
 const jobEventSchema = z.object({
   id: z.string(),
   job_id: z.string(),
   seq: z.number(),
+  attempt: z.number(),
   event_type: z.string(),
   level: z.string(),
   step_key: z.string().optional(),
@@ -305,6 +314,14 @@ const healthResponseSchema = z.object({
   status: z.string(),
   callback_url_mode: callbackURLModeSchema.optional(),
   callback_url_warning: z.string().optional(),
+  worker: z.object({
+    running: z.number(),
+    max_jobs: z.number(),
+    max_jobs_per_provider: z.number(),
+    max_jobs_per_server: z.number(),
+    busy_providers: z.number(),
+    busy_servers: z.number(),
+  }).optional(),
 });
 
 function decode<T>(schema: z.ZodType<T>, payload: unknown, label: string): T {
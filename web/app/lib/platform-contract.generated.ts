@@ -69,13 +69,54 @@ export const platformContract = {
     "queued",
     "running",
     "succeeded",
-    "failed"
+    "failed",
+    "cancelled"
   ],
   "job_terminal_statuses": [
     "succeeded",
-    "failed"
+    "failed",
+    "cancelled"
   ],
   "job_kinds": [
+    {
+      "kind": "backup_site",
+      "label": "Site backup",
+      "allowed_statuses": [
+        "queued",
+        "running",
+        "succeeded",
+        "failed",
+        "cancelled"
+      ],
+      "destructive": false,
+      "experimental": false,
+      "execution_path": "worker",
+      "dispatch_policy": {
+        "queue_server": false
+      },
+      "timeout_seconds": 3600,
+      "retry_limit": 2,
+      "retry_backoff_seconds": 120,
+      "recovery": "retried with backoff on transient errors; the partial archive is never recorded as a usable backup",
+      "steps": [
+        {
+          "key": "validate",
+          "label": "Validating request"
+        },
+        {
+          "key": "backup",
+          "label": "Archiving and uploading site"
+        },
+        {
+          "key": "verify",
+          "label": "Verifying uploaded archive"
+        },
+        {
+          "key": "finalize",
+          "label": "Applying retention"
+        }
+      ]
+    },
     {
       "kind": "configure_server",
       "label": "Server setup",
@@ -83,7 +124,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": false,
       "experimental": false,
@@ -92,8 +134,9 @@ export const platformContract = {
         "queue_server": false
       },
       "timeout_seconds": 1800,
-      "retry_limit": 0,
-      "recovery": "mark failed on worker interruption; retry setup manually after inspection",
+      "retry_limit": 2,
+      "retry_backoff_seconds": 60,
+      "recovery": "retried with backoff on transient errors; otherwise mark failed and retry setup manually after inspection",
       "steps": [
         {
           "key": "validate",
@@ -109,6 +152,84 @@ export const platformContract = {
         }
       ]
     },
+    {
+      "kind": "connect_server",
+      "label": "Server connection",
+      "allowed_statuses": [
+        "queued",
+        "running",
+        "succeeded",
+        "failed",
+        "cancelled"
+      ],
+      "destructive": false,
+      "experimental": false,
+      "execution_path": "worker",
+      "dispatch_policy": {
+        "queue_server": false
+      },
+      "timeout_seconds": 300,
+      "retry_limit": 2,
+      "retry_backoff_seconds": 30,
+      "recovery": "retried with backoff when the server is unreachable; otherwise mark failed, fix SSH access or the operating system and connect again",
+      "steps": [
+        {
+          "key": "validate",
+          "label": "Validating request"
+        },
+        {
+          "key": "connect",
+          "label": "Connecting over SSH"
+        },
+        {
+          "key": "verify",
+          "label": "Checking operating system and privileges"
+        },
+        {
+          "key": "finalize",
+          "label": "Queueing server setup"
+        }
+      ]
+    },
+    {
+      "kind": "create_staging",
+      "label": "Staging environment creation",
+      "allowed_statuses": [
+        "queued",
+        "running",
+        "succeeded",
+        "failed",
+        "cancelled"
+      ],
+      "destructive": false,
+      "experimental": false,
+      "execution_path": "worker",
+      "dispatch_policy": {
+        "queue_server": false
+      },
+      "timeout_seconds": 3600,
+      "retry_limit": 0,
+      "retry_backoff_seconds": 0,
+      "recovery": "mark failed on worker interruption; the production site is only read, so delete the staging site and create it again",
+      "steps": [
+        {
+          "key": "validate",
+          "label": "Validating request"
+        },
+        {
+          "key": "clone",
+          "label": "Cloning files and database"
+        },
+        {
+          "key": "verify",
+          "label": "Verifying staging routing"
+        },
+        {
+          "key": "finalize",
+          "label": "Finalizing"
+        }
+      ]
+    },
     {
       "kind": "delete_server",
       "label": "Server deletion",
@@ -116,7 +237,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": true,
       "experimental": true,
@@ -126,6 +248,7 @@ export const platformContract = {
       },
       "timeout_seconds": 1200,
       "retry_limit": 0,
+      "retry_backoff_seconds": 0,
       "recovery": "mark failed on worker interruption; verify provider-side deletion before retrying manually",
       "queued_server_status": "deleting",
       "steps": [
@@ -150,7 +273,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": false,
       "experimental": false,
@@ -159,8 +283,9 @@ export const platformContract = {
         "queue_server": false
       },
       "timeout_seconds": 1500,
-      "retry_limit": 0,
-      "recovery": "mark failed on worker interruption; inspect site files, database, and routing before retrying manually",
+      "retry_limit": 2,
+      "retry_backoff_seconds": 60,
+      "recovery": "retried with backoff on transient errors; otherwise mark failed and inspect site files, database, and routing before retrying manually",
       "steps": [
         {
           "key": "validate",
@@ -187,7 +312,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": false,
       "experimental": true,
@@ -197,6 +323,7 @@ export const platformContract = {
       },
       "timeout_seconds": 1200,
       "retry_limit": 0,
+      "retry_backoff_seconds": 0,
       "recovery": "mark failed on worker interruption; retry manually after inspection",
       "steps": [
         {
@@ -220,7 +347,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": false,
       "experimental": false,
@@ -230,6 +358,7 @@ export const platformContract = {
       },
       "timeout_seconds": 1800,
       "retry_limit": 0,
+      "retry_backoff_seconds": 0,
       "recovery": "mark failed on worker interruption; inspect provider state before retrying manually",
       "steps": [
         {
@@ -242,6 +371,53 @@ export const platformContract = {
         }
       ]
     },
+    {
+      "kind": "push_site",
+      "label": "Push to live",
+      "allowed_statuses": [
+        "queued",
+        "running",
+        "succeeded",
+        "failed",
+        "cancelled"
+      ],
+      "destructive": false,
+      "experimental": false,
+      "execution_path": "worker",
+      "dispatch_policy": {
+        "queue_server": false
+      },
+      "timeout_seconds": 5400,
+      "retry_limit": 0,
+      "retry_backoff_seconds": 0,
+      "recovery": "mark failed on worker interruption; the pre-push backup stays on the server for manual rollback",
+      "steps": [
+        {
+          "key": "validate",
+          "label": "Validating request"
+        },
+        {
+          "key": "diff",
+          "label": "Comparing staging with live"
+        },
+        {
+          "key": "backup",
+          "label": "Backing up live site"
+        },
+        {
+          "key": "push",
+          "label": "Pushing changes to live"
+        },
+        {
+          "key": "verify",
+          "label": "Verifying live site"
+        },
+        {
+          "key": "finalize",
+          "label": "Finalizing"
+        }
+      ]
+    },
     {
       "kind": "rebuild_server",
       "label": "Server rebuild",
@@ -249,7 +425,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": true,
       "experimental": true,
@@ -259,6 +436,7 @@ export const platformContract = {
       },
       "timeout_seconds": 2700,
       "retry_limit": 0,
+      "retry_backoff_seconds": 0,
       "recovery": "mark failed on worker interruption; inspect machine state before retrying manually",
       "queued_server_status": "rebuilding",
       "steps": [
@@ -283,7 +461,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": true,
       "experimental": true,
@@ -293,6 +472,7 @@ export const platformContract = {
       },
       "timeout_seconds": 1200,
       "retry_limit": 0,
+      "retry_backoff_seconds": 0,
       "recovery": "mark failed on worker interruption; inspect provider-side resize state before retrying manually",
       "queued_server_status": "resizing",
       "steps": [
@@ -317,7 +497,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": false,
       "experimental": true,
@@ -327,6 +508,7 @@ export const platformContract = {
       },
       "timeout_seconds": 120,
       "retry_limit": 0,
+      "retry_backoff_seconds": 0,
       "recovery": "mark failed on worker interruption or timeout; late agent results are ignored",
       "steps": [
         {
@@ -343,6 +525,49 @@ export const platformContract = {
         }
       ]
     },
+    {
+      "kind": "restore_site",
+      "label": "Site restore",
+      "allowed_statuses": [
+        "queued",
+        "running",
+        "succeeded",
+        "failed",
+        "cancelled"
+      ],
+      "destructive": false,
+      "experimental": false,
+      "execution_path": "worker",
+      "dispatch_policy": {
+        "queue_server": false
+      },
+      "timeout_seconds": 5400,
+      "retry_limit": 0,
+      "retry_backoff_seconds": 0,
+      "recovery": "mark failed on worker interruption; the pre-restore snapshot stays on the server for manual rollback",
+      "steps": [
+        {
+          "key": "validate",
+          "label": "Validating request"
+        },
+        {
+          "key": "snapshot",
+          "label": "Taking pre-restore snapshot"
+        },
+        {
+          "key": "restore",
+          "label": "Restoring files and database"
+        },
+        {
+          "key": "verify",
+          "label": "Verifying restored site"
+        },
+        {
+          "key": "finalize",
+          "label": "Finalizing"
+        }
+      ]
+    },
     {
       "kind": "update_firewalls",
       "label": "Firewall update",
@@ -350,7 +575,8 @@ export const platformContract = {
         "queued",
         "running",
         "succeeded",
-        "failed"
+        "failed",
+        "cancelled"
       ],
       "destructive": false,
       "experimental": true,
@@ -359,8 +585,9 @@ export const platformContract = {
         "queue_server": true
       },
       "timeout_seconds": 900,
-      "retry_limit": 0,
-      "recovery": "mark failed on worker interruption; retry manually after inspection",
+      "retry_limit": 3,
+      "retry_backoff_seconds": 30,
+      "recovery": "retried with backoff on transient provider errors; otherwise mark failed and retry manually after inspection",
       "steps": [
         {
           "key": "validate",
@@ -375,6 +602,49 @@ export const platformContract = {
           "label": "Finalizing"
         }
       ]
+    },
+    {
+      "kind": "update_site_components",
+      "label": "Site component update",
+      "allowed_statuses": [
+        "queued",
+        "running",
+        "succeeded",
+        "failed",
+        "cancelled"
+      ],
+      "destructive": false,
+      "experimental": false,
+      "execution_path": "worker",
+      "dispatch_policy": {
+        "queue_server": false
+      },
+      "timeout_seconds": 3600,
+      "retry_limit": 0,
+      "retry_backoff_seconds": 0,
+      "recovery": "mark failed on worker interruption; the pre-update snapshot stays on the server for manual rollback",
+      "steps": [
+        {
+          "key": "validate",
+          "label": "Validating request"
+        },
+        {
+          "key": "snapshot",
+          "label": "Taking pre-update snapshot"
+        },
+        {
+          "key": "update",
+          "label": "Applying updates"
+        },
+        {
+          "key": "verify",
+          "label": "Comparing site before and after"
+        },
+        {
+          "key": "finalize",
+          "label": "Finalizing"
+        }
+      ]
     }
   ],
   "config_scopes": {
@@ -439,6 +709,11 @@ export const platformContract = {
         "required": false,
         "description": "Session HMAC secret path."
       },
+      {
+        "name": "PRESSLUFT_VULN_FEED_PATH",
+        "required": false,
+        "description": "Vulnerability feed file in wpvulnerability.com format."
+      },
       {
         "name": "PRESSLUFT_SESSION_IDLE_TIMEOUT",
         "required": false,
@@ -471,6 +746,129 @@ export const platformContract = {
         "required": false,
         "description": "File-based bootstrap admin password source."
       },
+      {
+        "name": "PRESSLUFT_OIDC_ISSUER",
+        "required": false,
+        "description": "OpenID Connect issuer URL; enables single sign-on."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_CLIENT_ID",
+        "required": false,
+        "description": "OpenID Connect client id."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_CLIENT_SECRET",
+        "required": false,
+        "description": "OpenID Connect client secret; leave empty for public clients."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_CLIENT_SECRET_FILE",
+        "required": false,
+        "description": "File-based OpenID Connect client secret source."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_REDIRECT_URL",
+        "required": false,
+        "description": "OpenID Connect callback URL; defaults to /api/auth/oidc/callback under the control plane URL."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_SCOPES",
+        "required": false,
+        "default_value": "openid email profile",
+        "description": "Scopes requested from the identity provider."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_NAME",
+        "required": false,
+        "default_value": "single sign-on",
+        "description": "Identity provider name shown on the login page."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_GROUPS_CLAIM",
+        "required": false,
+        "default_value": "groups",
+        "description": "ID token claim holding the user's groups."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_ROLE_MAP",
+        "required": false,
+        "description": "Comma-separated group=role pairs mapping provider groups to roles in the default workspace."
+      },
+      {
+        "name": "PRESSLUFT_OIDC_DEFAULT_ROLE",
+        "required": false,
+        "description": "Role for single sign-on users in no mapped group; empty refuses them."
+      },
+      {
+        "name": "PRESSLUFT_PASSWORD_LOGIN_DISABLED",
+        "required": false,
+        "description": "Refuse password logins when single sign-on is configured."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_HOST",
+        "required": false,
+        "description": "SMTP relay for email notifications; unset leaves them off."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_PORT",
+        "required": false,
+        "default_value": "587",
+        "description": "SMTP relay port."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_SECURITY",
+        "required": false,
+        "default_value": "starttls",
+        "description": "SMTP transport security: starttls, tls or none."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_USERNAME",
+        "required": false,
+        "description": "SMTP username; leave empty for relays without authentication."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_PASSWORD",
+        "required": false,
+        "description": "SMTP password."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_PASSWORD_FILE",
+        "required": false,
+        "description": "File-based SMTP password source."
+      },
+      {
+        "name": "PRESSLUFT_SMTP_FROM",
+        "required": false,
+        "description": "Sender address of notification emails."
+      },
+      {
+        "name": "PRESSLUFT_METRICS_TOKEN",
+        "required": false,
+        "description": "Bearer token scrapers present to /metrics; the endpoint is disabled when unset."
+      },
+      {
+        "name": "PRESSLUFT_METRICS_TOKEN_FILE",
+        "required": false,
+        "description": "File-based metrics token source."
+      },
+      {
+        "name": "PRESSLUFT_WORKER_MAX_JOBS",
+        "required": false,
+        "default_value": "4",
+        "description": "Jobs the worker runs at once."
+      },
+      {
+        "name": "PRESSLUFT_WORKER_MAX_JOBS_PER_PROVIDER",
+        "required": false,
+        "default_value": "2",
+        "description": "Concurrent jobs against servers of one provider account; 0 disables the limit."
+      },
+      {
+        "name": "PRESSLUFT_WORKER_MAX_JOBS_PER_SERVER",
+        "required": false,
+        "default_value": "1",
+        "description": "Concurrent jobs on one server; destructive jobs always run alone."
+      },
       {
         "name": "PRESSLUFT_ANSIBLE_DIR",
         "required": false,
@@ -480,6 +878,17 @@ export const platformContract = {
         "name": "PRESSLUFT_ANSIBLE_BIN",
         "required": false,
         "description": "Path to ansible-playbook."
+      },
+      {
+        "name": "PRESSLUFT_FAKE_CLOUD",
+        "required": false,
+        "description": "Dev builds only: add the in-memory fake provider and simulate playbooks and agents for its servers."
+      },
+      {
+        "name": "PRESSLUFT_FAKE_CLOUD_DELAY",
+        "required": false,
+        "default_value": "2s",
+        "description": "How long each simulated provider action and playbook run takes."
       }
     ]
   }
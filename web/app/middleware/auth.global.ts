@@ -9,6 +9,11 @@ export default defineNuxtRouteMiddleware(async (to) => {
     await fetchMe()
   }
 
+  // Invitation and password reset links are opened before signing in.
+  if (to.path === '/invite' || to.path === '/reset-password') {
+    return
+  }
+
   if (to.path === '/login') {
     if (isAuthenticated.value) {
       return navigateTo('/')
@@ -68,6 +68,22 @@ describe('parseAuthActor', () => {
     expect(actor.capabilities).toBeUndefined()
     expect(actor.auth_source).toBeUndefined()
   })
+
+  it('accepts scoped client actor', () => {
+    const actor = parseAuthActor({
+      id: '1',
+      type: 'operator',
+      email: 'client@example.com',
+      role: 'client',
+      capabilities: ['read_sites', 'read_jobs'],
+      authenticated: true,
+      scoped: true,
+      site_ids: ['site-1'],
+    })
+
+    expect(actor.role).toBe('client')
+    expect(actor.site_ids).toEqual(['site-1'])
+  })
 })
 
 describe('parseJob', () => {
@@ -315,6 +331,7 @@ describe('parseJobEvents', () => {
         id: 'evt-1',
         job_id: 'job-1',
         seq: 1,
+        attempt: 1,
         event_type: 'step_started',
         level: 'info',
         step_key: 'validate',